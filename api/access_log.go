@@ -0,0 +1,21 @@
+package api
+
+import (
+	"github.com/ONSdigital/go-ns/server"
+)
+
+// configureAccessLog turns the request log of the server off when the access log is disabled. The server logs the
+// method, path, query, status and duration of every request with log.Handler, which is used as the access log.
+func configureAccessLog(srv *server.Server, enabled bool) {
+	if enabled {
+		return
+	}
+
+	order := make([]string, 0, len(srv.MiddlewareOrder))
+	for _, key := range srv.MiddlewareOrder {
+		if key != server.LogHandlerKey {
+			order = append(order, key)
+		}
+	}
+	srv.MiddlewareOrder = order
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ONSdigital/go-ns/server"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConfigureAccessLog(t *testing.T) {
+	t.Parallel()
+	Convey("Given a server which logs every request", t, func() {
+		srv := server.New(":0", http.NotFoundHandler())
+		So(srv.MiddlewareOrder, ShouldContain, server.LogHandlerKey)
+
+		Convey("When the access log is enabled then the request log is kept", func() {
+			configureAccessLog(srv, true)
+			So(srv.MiddlewareOrder, ShouldResemble, []string{server.RequestIDHandlerKey, server.LogHandlerKey})
+		})
+
+		Convey("When the access log is disabled then the request log is removed", func() {
+			configureAccessLog(srv, false)
+			So(srv.MiddlewareOrder, ShouldResemble, []string{server.RequestIDHandlerKey})
+		})
+	})
+}
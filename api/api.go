@@ -1,16 +1,21 @@
 package api
 
 //go:generate moq -out ../mocks/generated_auth_mocks.go -pkg mocks . AuthHandler
+//go:generate moq -out ../mocks/publish_notifier_mocks.go -pkg mocks . PublishNotifier
+//go:generate moq -out ../mocks/download_url_signer_mocks.go -pkg mocks . DownloadURLSigner
 
 import (
 	"context"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/ONSdigital/dp-authorisation/auth"
 	"github.com/ONSdigital/dp-dataset-api/config"
 	"github.com/ONSdigital/dp-dataset-api/dimension"
 	"github.com/ONSdigital/dp-dataset-api/instance"
+	"github.com/ONSdigital/dp-dataset-api/metrics"
+	"github.com/ONSdigital/dp-dataset-api/models"
 	"github.com/ONSdigital/dp-dataset-api/store"
 	"github.com/ONSdigital/dp-dataset-api/url"
 	"github.com/ONSdigital/go-ns/audit"
@@ -30,21 +35,38 @@ const (
 	downloadServiceToken = "X-Download-Service-Token"
 
 	// audit actions
-	addDatasetAction    = "addDataset"
-	deleteDatasetAction = "deleteDataset"
-	getDatasetsAction   = "getDatasets"
-	getDatasetAction    = "getDataset"
-
-	getEditionsAction = "getEditions"
-	getEditionAction  = "getEdition"
-
-	getVersionsAction      = "getVersions"
-	getVersionAction       = "getVersion"
-	updateDatasetAction    = "updateDataset"
-	updateVersionAction    = "updateVersion"
-	associateVersionAction = "associateVersionAction"
-	publishVersionAction   = "publishVersion"
-	detachVersionAction    = "detachVersion"
+	addDatasetAction     = "addDataset"
+	deleteDatasetAction  = "deleteDataset"
+	getDatasetsAction    = "getDatasets"
+	getDatasetAction     = "getDataset"
+	getDatasetTreeAction = "getDatasetTree"
+	getThemesAction      = "getThemes"
+	exportDatasetAction  = "exportDataset"
+
+	freezeDatasetLinksAction = "freezeDatasetLinks"
+	thawDatasetLinksAction   = "thawDatasetLinks"
+
+	validateCSVHeadersAction        = "validateCSVHeaders"
+	validateVersionCSVHeadersAction = "validateVersionCSVHeaders"
+
+	getEditionsAction         = "getEditions"
+	getEditionAction          = "getEdition"
+	refreshEditionLinksAction = "refreshEditionLinks"
+
+	getVersionsAction        = "getVersions"
+	getVersionAction         = "getVersion"
+	updateDatasetAction      = "updateDataset"
+	patchDatasetAction       = "patchDataset"
+	updateVersionAction      = "updateVersion"
+	associateVersionAction   = "associateVersionAction"
+	publishVersionAction     = "publishVersion"
+	detachVersionAction      = "detachVersion"
+	diffVersionAction        = "diffVersion"
+	transitionVersionsAction = "transitionVersions"
+
+	attachCollectionVersionsAction = "attachCollectionVersions"
+
+	updateMaintenanceModeAction = "updateMaintenanceMode"
 
 	getDimensionsAction       = "getDimensions"
 	getDimensionOptionsAction = "getDimensionOptionsAction"
@@ -69,7 +91,18 @@ type API interface {
 
 // DownloadsGenerator pre generates full file downloads for the specified dataset/edition/version
 type DownloadsGenerator interface {
-	Generate(datasetID, instanceID, edition, version string) error
+	Generate(datasetID, instanceID, edition, version string, formats []string) error
+}
+
+// PublishNotifier tells external systems when a version of a dataset has been published. A failure to notify is
+// handled by the notifier, never failing the publish.
+type PublishNotifier interface {
+	NotifyPublished(ctx context.Context, event models.VersionPublishedEvent)
+}
+
+// DownloadURLSigner signs the download links of unpublished versions, so a link can only be used until it expires
+type DownloadURLSigner interface {
+	Sign(href string, expires time.Time) (string, error)
 }
 
 // Auditor is an alias for the auditor service
@@ -90,10 +123,35 @@ type DatasetAPI struct {
 	Router                   *mux.Router
 	urlBuilder               *url.Builder
 	downloadGenerator        DownloadsGenerator
+	hierarchyBuildTrigger    instance.HierarchyBuildTrigger
+	publishNotifier          PublishNotifier
+	downloadURLSigner        DownloadURLSigner
+	downloadURLExpiry        time.Duration
 	serviceAuthToken         string
 	auditor                  Auditor
 	enablePrivateEndpoints   bool
 	enableDetachDataset      bool
+	enableObservations       bool
+	normaliseDimensionNames  bool
+	datasetTreeMaxVersions   int
+	datasetExportMaxVersions int
+	datasetFieldMaxLength    int
+	datasetMaxSubtopics      int
+	downloadFormats          []string
+	defaultPageSize          int
+	maxPageSize              int
+	features                 map[string]bool
+	wildcardDimensions       []string
+	publishDenylist          []string
+	maintenance              *maintenanceMode
+	redactPublicContacts     bool
+	rejectPastReleaseDates   bool
+	dimensionOptionsMaxCodes int
+	maxEditions              int
+	maxVersions              int
+	progressInterval         time.Duration
+	jsonLimits               models.JSONLimits
+	observationStreams       chan struct{}
 	datasetPermissions       AuthHandler
 	permissions              AuthHandler
 	instancePublishedChecker *instance.PublishCheck
@@ -101,21 +159,30 @@ type DatasetAPI struct {
 }
 
 // CreateDatasetAPI create a new DatasetAPI instance based on the configuration provided, apply middleware and starts the HTTP server.
-func CreateAndInitialiseDatasetAPI(cfg config.Configuration, dataStore store.DataStore, urlBuilder *url.Builder, errorChan chan error, downloadGenerator DownloadsGenerator, auditor Auditor, datasetPermissions AuthHandler, permissions AuthHandler) {
+func CreateAndInitialiseDatasetAPI(cfg config.Configuration, dataStore store.DataStore, urlBuilder *url.Builder, errorChan chan error, downloadGenerator DownloadsGenerator, hierarchyBuildTrigger instance.HierarchyBuildTrigger, publishNotifier PublishNotifier, downloadURLSigner DownloadURLSigner, auditor Auditor, datasetPermissions AuthHandler, permissions AuthHandler, metricsRegistry *metrics.Registry) {
 	router := mux.NewRouter()
-	api := NewDatasetAPI(cfg, router, dataStore, urlBuilder, downloadGenerator, auditor, datasetPermissions, permissions)
 
-	healthcheckHandler := healthcheck.NewMiddleware(healthcheck.Do)
-	middleware := alice.New(healthcheckHandler)
-
-	// Only add the identity middleware when running in publishing.
+	// the audit trail of an instance is only read back in publishing, where its actions are audited
 	if cfg.EnablePrivateEnpoints {
-		middleware = middleware.Append(identity.Handler(cfg.ZebedeeURL))
+		auditor = &instanceAuditor{Auditor: auditor, storer: dataStore.Backend}
 	}
 
-	middleware = middleware.Append(collectionID.CheckHeader)
+	api := NewDatasetAPI(cfg, router, dataStore, urlBuilder, downloadGenerator, hierarchyBuildTrigger, publishNotifier, downloadURLSigner, auditor, datasetPermissions, permissions)
+
+	middleware, err := newMiddleware(cfg, router, metricsRegistry)
+	if err != nil {
+		log.ErrorC("failed to parse internal networks", err, nil)
+		errorChan <- err
+		return
+	}
 
 	httpServer = server.New(cfg.BindAddr, middleware.Then(api.Router))
+	configureHTTPServer(&httpServer.Server, cfg)
+	configureAccessLog(httpServer, cfg.EnableAccessLog)
+
+	// streamed responses extend the write deadline of the connection, so this wraps the server's own middleware
+	httpServer.Middleware[streamingWriteDeadlineKey] = streamingWriteDeadline(cfg.HTTPWriteTimeout)
+	httpServer.MiddlewareOrder = append([]string{streamingWriteDeadlineKey}, httpServer.MiddlewareOrder...)
 
 	// Disable this here to allow main to manage graceful shutdown of the entire app.
 	httpServer.HandleOSSignals = false
@@ -129,8 +196,49 @@ func CreateAndInitialiseDatasetAPI(cfg config.Configuration, dataStore store.Dat
 	}()
 }
 
+// newMiddleware returns the middleware every request to the router is handled through
+func newMiddleware(cfg config.Configuration, router *mux.Router, metricsRegistry *metrics.Registry) (alice.Chain, error) {
+	healthcheckHandler := healthcheck.NewMiddleware(healthcheck.Do)
+	middleware := alice.New(healthcheckHandler, metrics.Middleware(metrics.NewRequestDurationHistogram(metricsRegistry), router))
+
+	networks, err := cfg.ParseInternalNetworks()
+	if err != nil {
+		return middleware, err
+	}
+
+	if len(networks) > 0 {
+		middleware = middleware.Append(internalNetworks(networks))
+	}
+
+	if len(cfg.CORSAllowedOrigins) > 0 {
+		middleware = middleware.Append(cors(cfg.CORSAllowedOrigins))
+	}
+
+	// Only add the identity middleware when running in publishing.
+	if cfg.EnablePrivateEnpoints {
+		middleware = middleware.Append(identity.Handler(cfg.ZebedeeURL))
+	}
+
+	middleware = middleware.Append(collectionID.CheckHeader)
+
+	middleware = middleware.Append(prettyJSON(cfg.PrettyJSONResponses))
+	middleware = middleware.Append(requestTimeout(cfg.RequestTimeout))
+
+	return middleware, nil
+}
+
+// configureHTTPServer applies the configured timeouts and header size limit to the server. The read and write
+// timeouts stop slow clients holding connections open, and the idle timeout bounds how long keep-alive connections
+// wait for another request. HTTP/2 is negotiated by net/http when the server is started with TLS.
+func configureHTTPServer(srv *http.Server, cfg config.Configuration) {
+	srv.ReadTimeout = cfg.HTTPReadTimeout
+	srv.WriteTimeout = cfg.HTTPWriteTimeout
+	srv.IdleTimeout = cfg.HTTPIdleTimeout
+	srv.MaxHeaderBytes = cfg.HTTPMaxHeaderBytes
+}
+
 // NewDatasetAPI create a new Dataset API instance and register the API routes based on the application configuration.
-func NewDatasetAPI(cfg config.Configuration, router *mux.Router, dataStore store.DataStore, urlBuilder *url.Builder, downloadGenerator DownloadsGenerator, auditor Auditor, datasetPermissions AuthHandler, permissions AuthHandler) *DatasetAPI {
+func NewDatasetAPI(cfg config.Configuration, router *mux.Router, dataStore store.DataStore, urlBuilder *url.Builder, downloadGenerator DownloadsGenerator, hierarchyBuildTrigger instance.HierarchyBuildTrigger, publishNotifier PublishNotifier, downloadURLSigner DownloadURLSigner, auditor Auditor, datasetPermissions AuthHandler, permissions AuthHandler) *DatasetAPI {
 	api := &DatasetAPI{
 		dataStore:                dataStore,
 		host:                     cfg.DatasetAPIURL,
@@ -141,21 +249,49 @@ func NewDatasetAPI(cfg config.Configuration, router *mux.Router, dataStore store
 		Router:                   router,
 		urlBuilder:               urlBuilder,
 		downloadGenerator:        downloadGenerator,
+		hierarchyBuildTrigger:    hierarchyBuildTrigger,
+		publishNotifier:          publishNotifier,
+		downloadURLSigner:        downloadURLSigner,
+		downloadURLExpiry:        cfg.DownloadURLExpiry,
 		auditor:                  auditor,
 		enablePrivateEndpoints:   cfg.EnablePrivateEnpoints,
 		enableDetachDataset:      cfg.EnableDetachDataset,
+		enableObservations:       cfg.EnableObservationsEndpoint,
+		normaliseDimensionNames:  cfg.NormaliseDimensionNames,
+		datasetTreeMaxVersions:   cfg.DatasetTreeMaxVersions,
+		datasetExportMaxVersions: cfg.DatasetExportMaxVersions,
+		datasetFieldMaxLength:    cfg.DatasetFieldMaxLength,
+		datasetMaxSubtopics:      cfg.DatasetMaxSubtopics,
+		downloadFormats:          cfg.DownloadFormats,
+		defaultPageSize:          cfg.DefaultPageSize,
+		maxPageSize:              cfg.MaxPageSize,
+		features:                 cfg.FeatureFlags(),
+		wildcardDimensions:       cfg.WildcardDimensions,
+		publishDenylist:          cfg.PublishDenylist,
+		maintenance:              newMaintenanceMode(cfg.MaintenanceMode),
+		redactPublicContacts:     cfg.RedactPublicContacts,
+		rejectPastReleaseDates:   cfg.RejectPastReleaseDates,
+		dimensionOptionsMaxCodes: cfg.DimensionOptionsMaxCodes,
+		maxEditions:              cfg.MaxEditionsPerDataset,
+		maxVersions:              cfg.MaxVersionsPerEdition,
+		progressInterval:         cfg.InstanceProgressInterval,
+		jsonLimits:               models.JSONLimits{MaxDepth: cfg.JSONMaxDepth, MaxSize: cfg.JSONMaxBodySize},
+		observationStreams:       make(chan struct{}, cfg.MaxObservationStreams),
 		datasetPermissions:       datasetPermissions,
 		permissions:              permissions,
 		versionPublishedChecker:  nil,
 		instancePublishedChecker: nil,
 	}
 
+	api.get("/version", api.getBuildInfo)
+
 	if api.enablePrivateEndpoints {
 		log.Info("enabling private endpoints for dataset api", nil)
 
 		api.versionPublishedChecker = &PublishCheck{
-			Auditor:   auditor,
-			Datastore: api.dataStore.Backend,
+			Auditor:    auditor,
+			Datastore:  api.dataStore.Backend,
+			JSONLimits: api.jsonLimits,
 		}
 
 		api.instancePublishedChecker = &instance.PublishCheck{
@@ -164,15 +300,29 @@ func NewDatasetAPI(cfg config.Configuration, router *mux.Router, dataStore store
 		}
 
 		instanceAPI := &instance.Store{
-			Host:                api.host,
-			Storer:              api.dataStore.Backend,
-			Auditor:             api.auditor,
-			EnableDetachDataset: api.enablePrivateEndpoints,
+			Host:                    api.host,
+			Storer:                  api.dataStore.Backend,
+			Auditor:                 api.auditor,
+			EnableDetachDataset:     api.enablePrivateEndpoints,
+			NormaliseDimensionNames: api.normaliseDimensionNames,
+			HierarchyBuildTrigger:   api.hierarchyBuildTrigger,
+			MaxEditions:             api.maxEditions,
+			MaxVersions:             api.maxVersions,
+			ProgressInterval:        api.progressInterval,
+			DownloadServiceToken:    api.downloadServiceToken,
+			DownloadURLSigner:       api.downloadURLSigner,
+			DownloadURLExpiry:       api.downloadURLExpiry,
+			JSONLimits:              api.jsonLimits,
+			PublishDenylist:         api.publishDenylist,
+			RejectPastReleaseDates:  api.rejectPastReleaseDates,
 		}
 
 		dimensionAPI := &dimension.Store{
-			Auditor: api.auditor,
-			Storer:  api.dataStore.Backend,
+			Auditor:        api.auditor,
+			Storer:         api.dataStore.Backend,
+			NormaliseNames: api.normaliseDimensionNames,
+			MaxOptionCodes: api.dimensionOptionsMaxCodes,
+			JSONLimits:     api.jsonLimits,
 		}
 
 		api.enablePrivateDatasetEndpoints()
@@ -187,14 +337,26 @@ func NewDatasetAPI(cfg config.Configuration, router *mux.Router, dataStore store
 
 // enablePublicEndpoints register only the public GET endpoints.
 func (api *DatasetAPI) enablePublicEndpoints() {
+	getObservations, getObservationCount, getObservationDimensions := api.getObservations, api.getObservationCount, api.getObservationDimensions
+	getObservationsMetadata := api.getObservationsMetadata
+	if !api.enableObservations {
+		log.Info("observations endpoints disabled, requests will be refused as unavailable", nil)
+		getObservations, getObservationCount, getObservationDimensions = observationsUnavailable, observationsUnavailable, observationsUnavailable
+		getObservationsMetadata = observationsUnavailable
+	}
+
 	api.get("/datasets", api.getDatasets)
+	api.get("/datasets/themes", api.getThemes)
 	api.get("/datasets/{dataset_id}", api.getDataset)
 	api.get("/datasets/{dataset_id}/editions", api.getEditions)
 	api.get("/datasets/{dataset_id}/editions/{edition}", api.getEdition)
 	api.get("/datasets/{dataset_id}/editions/{edition}/versions", api.getVersions)
 	api.get("/datasets/{dataset_id}/editions/{edition}/versions/{version}", api.getVersion)
 	api.get("/datasets/{dataset_id}/editions/{edition}/versions/{version}/metadata", api.getMetadata)
-	api.get("/datasets/{dataset_id}/editions/{edition}/versions/{version}/observations", api.getObservations)
+	api.get("/datasets/{dataset_id}/editions/{edition}/versions/{version}/observations", getObservations)
+	api.get("/datasets/{dataset_id}/editions/{edition}/versions/{version}/observations/count", getObservationCount)
+	api.get("/datasets/{dataset_id}/editions/{edition}/versions/{version}/observations/dimensions", getObservationDimensions)
+	api.get("/datasets/{dataset_id}/editions/{edition}/versions/{version}/observations/metadata", getObservationsMetadata)
 	api.get("/datasets/{dataset_id}/editions/{edition}/versions/{version}/dimensions", api.getDimensions)
 	api.get("/datasets/{dataset_id}/editions/{edition}/versions/{version}/dimensions/{dimension}/options", api.getDimensionOptions)
 }
@@ -207,12 +369,32 @@ func (api *DatasetAPI) enablePrivateDatasetEndpoints() {
 		api.isAuthorised(readPermission, api.getDatasets),
 	)
 
+	// registered ahead of /datasets/{dataset_id} so themes is not taken to be a dataset id
+	api.get(
+		"/datasets/themes",
+		api.isAuthorised(readPermission, api.getThemes),
+	)
+
 	api.get(
 		"/datasets/{dataset_id}",
 		api.isAuthorisedForDatasets(readPermission,
 			api.getDataset),
 	)
 
+	api.get(
+		"/datasets/{dataset_id}/tree",
+		api.isAuthenticated(getDatasetTreeAction,
+			api.isAuthorisedForDatasets(readPermission,
+				api.getDatasetTree)),
+	)
+
+	api.get(
+		"/datasets/{dataset_id}/export",
+		api.isAuthenticated(exportDatasetAction,
+			api.isAuthorisedForDatasets(readPermission,
+				api.exportDataset)),
+	)
+
 	api.get(
 		"/datasets/{dataset_id}/editions",
 		api.isAuthorisedForDatasets(readPermission, api.getEditions),
@@ -248,6 +430,24 @@ func (api *DatasetAPI) enablePrivateDatasetEndpoints() {
 			api.getObservations),
 	)
 
+	api.get(
+		"/datasets/{dataset_id}/editions/{edition}/versions/{version}/observations/count",
+		api.isAuthorisedForDatasets(readPermission,
+			api.getObservationCount),
+	)
+
+	api.get(
+		"/datasets/{dataset_id}/editions/{edition}/versions/{version}/observations/dimensions",
+		api.isAuthorisedForDatasets(readPermission,
+			api.getObservationDimensions),
+	)
+
+	api.get(
+		"/datasets/{dataset_id}/editions/{edition}/versions/{version}/observations/metadata",
+		api.isAuthorisedForDatasets(readPermission,
+			api.getObservationsMetadata),
+	)
+
 	api.get(
 		"/datasets/{dataset_id}/editions/{edition}/versions/{version}/dimensions",
 		api.isAuthorisedForDatasets(readPermission,
@@ -274,6 +474,13 @@ func (api *DatasetAPI) enablePrivateDatasetEndpoints() {
 				api.putDataset)),
 	)
 
+	api.patch(
+		"/datasets/{dataset_id}",
+		api.isAuthenticated(patchDatasetAction,
+			api.isAuthorisedForDatasets(updatePermission,
+				api.patchDataset)),
+	)
+
 	api.delete(
 		"/datasets/{dataset_id}",
 		api.isAuthenticated(deleteDatasetAction,
@@ -281,6 +488,20 @@ func (api *DatasetAPI) enablePrivateDatasetEndpoints() {
 				api.deleteDataset)),
 	)
 
+	api.get(
+		"/datasets/{dataset_id}/editions/{edition}/versions/{version}/diff",
+		api.isAuthenticated(diffVersionAction,
+			api.isAuthorisedForDatasets(readPermission,
+				api.diffVersion)),
+	)
+
+	api.post(
+		"/datasets/{dataset_id}/editions/{edition}/versions/{version}/validate-csv-header",
+		api.isAuthenticated(validateVersionCSVHeadersAction,
+			api.isAuthorisedForDatasets(readPermission,
+				api.validateVersionCSVHeaders)),
+	)
+
 	api.put(
 		"/datasets/{dataset_id}/editions/{edition}/versions/{version}",
 		api.isAuthenticated(updateVersionAction,
@@ -289,6 +510,49 @@ func (api *DatasetAPI) enablePrivateDatasetEndpoints() {
 					api.putVersion))),
 	)
 
+	api.post(
+		"/datasets/{dataset_id}/editions/{edition}/versions/transition",
+		api.isAuthenticated(transitionVersionsAction,
+			api.isAuthorisedForDatasets(updatePermission,
+				api.transitionVersions)),
+	)
+
+	api.post(
+		"/datasets/{dataset_id}/links/freeze",
+		api.isAuthenticated(freezeDatasetLinksAction,
+			api.isAuthorisedForDatasets(updatePermission,
+				api.freezeDatasetLinks)),
+	)
+
+	api.post(
+		"/datasets/{dataset_id}/links/thaw",
+		api.isAuthenticated(thawDatasetLinksAction,
+			api.isAuthorisedForDatasets(updatePermission,
+				api.thawDatasetLinks)),
+	)
+
+	api.post(
+		"/datasets/{dataset_id}/editions/{edition}/links/refresh",
+		api.isAuthenticated(refreshEditionLinksAction,
+			api.isAuthorisedForDatasets(updatePermission,
+				api.refreshEditionLinks)),
+	)
+
+	api.post(
+		"/collections/{collection_id}/versions",
+		api.isAuthenticated(attachCollectionVersionsAction,
+			api.isAuthorised(updatePermission,
+				api.attachCollectionVersions)),
+	)
+
+	// maintenance mode is changed with a write request, so the route is registered without the maintenance check
+	api.Router.HandleFunc(
+		"/maintenance",
+		requireJSON(api.isAuthenticated(updateMaintenanceModeAction,
+			api.isAuthorised(updatePermission,
+				api.updateMaintenanceMode))),
+	).Methods("PUT")
+
 	if api.enableDetachDataset {
 		api.delete(
 			"/datasets/{dataset_id}/editions/{edition}/versions/{version}",
@@ -316,6 +580,21 @@ func (api *DatasetAPI) enablePrivateInstancesEndpoints(instanceAPI *instance.Sto
 				instanceAPI.Add)),
 	)
 
+	api.delete(
+		"/instances",
+		api.isAuthenticated(instance.PurgeInstancesAction,
+			api.isAuthorised(deletePermission,
+				instanceAPI.Purge)),
+	)
+
+	// registered before /instances/{instance_id} so that summary is not taken as an instance id
+	api.get(
+		"/instances/summary",
+		api.isAuthenticated(instance.GetInstancesSummaryAction,
+			api.isAuthorised(readPermission,
+				instanceAPI.Summary)),
+	)
+
 	api.get(
 		"/instances/{instance_id}",
 		api.isAuthenticated(instance.GetInstanceAction,
@@ -323,6 +602,20 @@ func (api *DatasetAPI) enablePrivateInstancesEndpoints(instanceAPI *instance.Sto
 				instanceAPI.Get)),
 	)
 
+	api.get(
+		"/instances/{instance_id}/audit",
+		api.isAuthenticated(instance.GetInstanceAuditAction,
+			api.isAuthorised(readPermission,
+				instanceAPI.AuditTrail)),
+	)
+
+	api.get(
+		"/instances/{instance_id}/progress",
+		api.isAuthenticated(instance.GetProgressAction,
+			api.isAuthorised(readPermission,
+				instanceAPI.Progress)),
+	)
+
 	api.put(
 		"/instances/{instance_id}",
 		api.isAuthenticated(instance.UpdateInstanceAction,
@@ -331,6 +624,14 @@ func (api *DatasetAPI) enablePrivateInstancesEndpoints(instanceAPI *instance.Sto
 					instanceAPI.Update))),
 	)
 
+	api.post(
+		"/instances/{instance_id}/reset",
+		api.isAuthenticated(instance.ResetInstanceAction,
+			api.isAuthorised(updatePermission,
+				api.isInstancePublished(instance.ResetInstanceAction,
+					instanceAPI.Reset))),
+	)
+
 	api.put(
 		"/instances/{instance_id}/dimensions/{dimension}",
 		api.isAuthenticated(instance.UpdateDimensionAction,
@@ -354,6 +655,14 @@ func (api *DatasetAPI) enablePrivateInstancesEndpoints(instanceAPI *instance.Sto
 					instanceAPI.UpdateObservations))),
 	)
 
+	api.post(
+		"/instances/{instance_id}/inserted_observations/increment/{inserted_observations}",
+		api.isAuthenticated(instance.IncrementInsertedObservationsAction,
+			api.isAuthorised(updatePermission,
+				api.isInstancePublished(instance.IncrementInsertedObservationsAction,
+					instanceAPI.IncrementObservations))),
+	)
+
 	api.put(
 		"/instances/{instance_id}/import_tasks",
 		api.isAuthenticated(instance.UpdateImportTasksAction,
@@ -361,6 +670,21 @@ func (api *DatasetAPI) enablePrivateInstancesEndpoints(instanceAPI *instance.Sto
 				api.isInstancePublished(instance.UpdateImportTasksAction,
 					instanceAPI.UpdateImportTask))),
 	)
+
+	api.post(
+		"/instances/{instance_id}/dimensions/{dimension}/rebuild-hierarchy",
+		api.isAuthenticated(instance.RebuildHierarchyAction,
+			api.isAuthorised(updatePermission,
+				api.isInstancePublished(instance.RebuildHierarchyAction,
+					instanceAPI.RebuildHierarchy))),
+	)
+
+	api.post(
+		"/csv-headers/validate",
+		api.isAuthenticated(validateCSVHeadersAction,
+			api.isAuthorised(readPermission,
+				api.validateCSVHeaders)),
+	)
 }
 
 // enablePrivateDatasetEndpoints register the dimenions endpoints with the appropriate authentication and authorisation
@@ -388,6 +712,20 @@ func (api *DatasetAPI) enablePrivateDimensionsEndpoints(dimensionAPI *dimension.
 				dimensionAPI.GetUniqueDimensionAndOptionsHandler)),
 	)
 
+	api.get(
+		"/instances/{instance_id}/dimensions/{dimension}/options.csv",
+		api.isAuthenticated(dimension.GetDimensionOptionsCSVAction,
+			api.isAuthorised(readPermission,
+				dimensionAPI.GetOptionsCSVHandler)),
+	)
+
+	api.post(
+		"/instances/{instance_id}/dimensions/{dimension}/options/byCodes",
+		api.isAuthenticated(dimension.GetDimensionOptionsByCodesAction,
+			api.isAuthorised(readPermission,
+				dimensionAPI.GetOptionsByCodesHandler)),
+	)
+
 	api.put(
 		"/instances/{instance_id}/dimensions/{dimension}/options/{option}/node_id/{node_id}",
 		api.isAuthenticated(dimension.UpdateNodeIDAction,
@@ -395,6 +733,22 @@ func (api *DatasetAPI) enablePrivateDimensionsEndpoints(dimensionAPI *dimension.
 				api.isInstancePublished(dimension.UpdateNodeIDAction,
 					dimensionAPI.AddNodeIDHandler))),
 	)
+
+	api.patch(
+		"/instances/{instance_id}/dimensions/{dimension}/options/{option}",
+		api.isAuthenticated(dimension.UpdateOptionAction,
+			api.isAuthorised(updatePermission,
+				api.isInstancePublished(dimension.UpdateOptionAction,
+					dimensionAPI.UpdateOptionHandler))),
+	)
+
+	api.put(
+		"/instances/{instance_id}/dimensions/{dimension}/name",
+		api.isAuthenticated(dimension.RenameDimensionAction,
+			api.isAuthorised(updatePermission,
+				api.isInstancePublished(dimension.RenameDimensionAction,
+					dimensionAPI.RenameHandler))),
+	)
 }
 
 // isAuthenticated wraps a http handler func in another http handler func that checks the caller is authenticated to
@@ -436,19 +790,24 @@ func (api *DatasetAPI) get(path string, handler http.HandlerFunc) {
 	api.Router.HandleFunc(path, handler).Methods("GET")
 }
 
-// get register a PUT http.HandlerFunc.
+// get register a PUT http.HandlerFunc, which is refused while in maintenance mode.
 func (api *DatasetAPI) put(path string, handler http.HandlerFunc) {
-	api.Router.HandleFunc(path, handler).Methods("PUT")
+	api.Router.HandleFunc(path, api.rejectDuringMaintenance(requireJSON(handler))).Methods("PUT")
+}
+
+// get register a PATCH http.HandlerFunc, which is refused while in maintenance mode.
+func (api *DatasetAPI) patch(path string, handler http.HandlerFunc) {
+	api.Router.HandleFunc(path, api.rejectDuringMaintenance(requireJSON(handler))).Methods("PATCH")
 }
 
-// get register a POST http.HandlerFunc.
+// get register a POST http.HandlerFunc, which is refused while in maintenance mode.
 func (api *DatasetAPI) post(path string, handler http.HandlerFunc) {
-	api.Router.HandleFunc(path, handler).Methods("POST")
+	api.Router.HandleFunc(path, api.rejectDuringMaintenance(requireJSON(handler))).Methods("POST")
 }
 
-// get register a DELETE http.HandlerFunc.
+// get register a DELETE http.HandlerFunc, which is refused while in maintenance mode.
 func (api *DatasetAPI) delete(path string, handler http.HandlerFunc) {
-	api.Router.HandleFunc(path, handler).Methods("DELETE")
+	api.Router.HandleFunc(path, api.rejectDuringMaintenance(handler)).Methods("DELETE")
 }
 
 func (api *DatasetAPI) authenticate(r *http.Request, logData map[string]interface{}) (bool, map[string]interface{}) {
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/pkg/errors"
+)
+
+// Build details of the running binary, set at build time using ldflags, e.g.
+// -X github.com/ONSdigital/dp-dataset-api/api.Version=1.2.0
+var (
+	Version   = "unknown"
+	GitCommit = "unknown"
+	BuildTime = "unknown"
+)
+
+// BuildInfo describes the build of the running service and the features enabled in its configuration
+type BuildInfo struct {
+	Version   string          `json:"version"`
+	GitCommit string          `json:"git_commit"`
+	BuildTime string          `json:"build_time"`
+	Features  map[string]bool `json:"features"`
+}
+
+// getBuildInfo returns the build details of the running service so the deployed build can be confirmed
+func (api *DatasetAPI) getBuildInfo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	b, err := json.Marshal(BuildInfo{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildTime: BuildTime,
+		Features:  api.features,
+	})
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "getBuildInfo endpoint: failed to marshal build info into bytes"), nil)
+		errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	setJSONContentType(w)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "getBuildInfo endpoint: error writing bytes to response"), nil)
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	storetest "github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// not run in parallel as the build details are package variables
+func TestGetBuildInfo(t *testing.T) {
+	Convey("Given build details injected at build time", t, func() {
+		Version, GitCommit, BuildTime = "1.2.0", "a1b2c3d", "2018-06-01T09:00:00Z"
+		defer func() {
+			Version, GitCommit, BuildTime = "unknown", "unknown", "unknown"
+		}()
+
+		Convey("When the version endpoint is called", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/version", nil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(&storetest.StorerMock{}, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the injected build details and feature flags are returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var info BuildInfo
+				So(json.Unmarshal(w.Body.Bytes(), &info), ShouldBeNil)
+				So(info.Version, ShouldEqual, "1.2.0")
+				So(info.GitCommit, ShouldEqual, "a1b2c3d")
+				So(info.BuildTime, ShouldEqual, "2018-06-01T09:00:00Z")
+				So(info.Features["ENABLE_PRIVATE_ENDPOINTS"], ShouldBeTrue)
+				So(info.Features, ShouldContainKey, "ENABLE_DETACH_DATASET")
+			})
+		})
+	})
+}
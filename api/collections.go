@@ -0,0 +1,151 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/ONSdigital/go-ns/request"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// reasonVersionPublished is reported against published versions, which are skipped when attaching a collection
+const reasonVersionPublished = "version has already been published"
+
+// attachCollectionVersions associates each of the requested versions with the collection, in the same way as a PUT of
+// the version with the collection_id and the associated state. Only edition-confirmed and associated versions can be
+// attached. The outcome of each version is reported individually, published versions are skipped and a version which
+// fails is reported rather than failing the whole batch.
+func (api *DatasetAPI) attachCollectionVersions(w http.ResponseWriter, r *http.Request) {
+
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	collectionID := vars["collection_id"]
+	auditParams := common.Params{"collection_id": collectionID}
+	logData := audit.ToLogData(auditParams)
+
+	b, err := func() ([]byte, error) {
+		versions, err := models.CreateCollectionVersions(r.Body, api.jsonLimits)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "attachCollectionVersions endpoint: failed to model collection versions based on request"), logData)
+			return nil, err
+		}
+
+		results := models.CollectionVersionResults{
+			CollectionID: collectionID,
+			Items:        make([]models.CollectionVersionResult, len(versions)),
+		}
+
+		// indexes of the results for each version which is to be attached, keyed by version id
+		pending := make(map[string][]int)
+		var versionIDs []string
+
+		for i, v := range versions {
+			results.Items[i] = models.CollectionVersionResult{DatasetID: v.DatasetID, Edition: v.Edition, Version: v.Version}
+			result := &results.Items[i]
+
+			if err := v.Validate(); err != nil {
+				result.Status, result.Reason = models.CollectionVersionFailed, err.Error()
+				continue
+			}
+
+			version, err := api.dataStore.Backend.GetVersion(v.DatasetID, v.Edition, strconv.Itoa(v.Version), "")
+			if err != nil {
+				if err != errs.ErrVersionNotFound {
+					log.ErrorCtx(ctx, errors.WithMessage(err, "attachCollectionVersions endpoint: datastore.GetVersion returned an error"), logData)
+					return nil, err
+				}
+				result.Status, result.Reason = models.CollectionVersionFailed, err.Error()
+				continue
+			}
+
+			if version.State == models.PublishedState {
+				result.Status, result.Reason = models.CollectionVersionSkipped, reasonVersionPublished
+				continue
+			}
+
+			if version.State != models.EditionConfirmedState && version.State != models.AssociatedState {
+				result.Status, result.Reason = models.CollectionVersionFailed, errs.ErrExpectedResourceStateOfEditionConfirmed.Error()
+				continue
+			}
+
+			if _, ok := pending[version.ID]; !ok {
+				versionIDs = append(versionIDs, version.ID)
+			}
+			pending[version.ID] = append(pending[version.ID], i)
+		}
+
+		for _, id := range versionIDs {
+			err := api.attachCollectionVersion(ctx, collectionID, versions[pending[id][0]])
+			if err != nil {
+				logData["version_id"] = id
+				log.ErrorCtx(ctx, errors.WithMessage(err, "attachCollectionVersions endpoint: failed to associate version with collection"), logData)
+				delete(logData, "version_id")
+			} else {
+				results.Count++
+			}
+
+			for _, i := range pending[id] {
+				if err != nil {
+					results.Items[i].Status, results.Items[i].Reason = models.CollectionVersionFailed, err.Error()
+					continue
+				}
+				results.Items[i].Status = models.CollectionVersionAttached
+			}
+		}
+
+		logData["count"] = results.Count
+		auditParams["count"] = strconv.Itoa(results.Count)
+
+		b, err := json.Marshal(results)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "attachCollectionVersions endpoint: failed to marshal results into bytes"), logData)
+			return nil, err
+		}
+		return b, nil
+	}()
+
+	if err != nil {
+		if auditErr := api.auditor.Record(ctx, attachCollectionVersionsAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleVersionAPIErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := api.auditor.Record(ctx, attachCollectionVersionsAction, audit.Successful, auditParams); auditErr != nil {
+		handleVersionAPIErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	setJSONContentType(w)
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "attachCollectionVersions endpoint: error writing bytes to response"), logData)
+		errs.WriteError(w, err, http.StatusInternalServerError)
+	}
+	log.InfoCtx(ctx, "attachCollectionVersions endpoint: request successful", logData)
+}
+
+// attachCollectionVersion associates a version with the collection through the same update, association and download
+// generation as a PUT of the version
+func (api *DatasetAPI) attachCollectionVersion(ctx context.Context, collectionID string, v models.CollectionVersion) error {
+	body, err := json.Marshal(models.Version{CollectionID: collectionID, State: models.AssociatedState})
+	if err != nil {
+		return err
+	}
+
+	versionDetails := VersionDetails{datasetID: v.DatasetID, edition: v.Edition, version: strconv.Itoa(v.Version)}
+	return api.applyVersionUpdate(ctx, ioutil.NopCloser(bytes.NewReader(body)), versionDetails, false)
+}
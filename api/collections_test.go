@@ -0,0 +1,187 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	storetest "github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const collectionVersionsPayload = `[
+	{"dataset_id":"123","edition":"2017","version":1},
+	{"dataset_id":"123","edition":"2017","version":2},
+	{"dataset_id":"456","edition":"2018","version":1},
+	{"dataset_id":"789","edition":"2019","version":1},
+	{"dataset_id":"123","edition":"2017","version":3},
+	{"dataset_id":"123","edition":"2017","version":4},
+	{"dataset_id":"","edition":"2017","version":1}
+]`
+
+// collectionVersionsStore returns a store holding versions in a mix of states, whose update of version "d" fails
+func collectionVersionsStore() *storetest.StorerMock {
+	return &storetest.StorerMock{
+		GetVersionFunc: func(datasetID, edition, version, state string) (*models.Version, error) {
+			v := &models.Version{ReleaseDate: "2017-12-12"}
+			switch datasetID + "/" + version {
+			case "123/1":
+				v.ID, v.State = "a", models.EditionConfirmedState
+			case "123/2":
+				v.ID, v.State = "b", models.PublishedState
+			case "456/1":
+				v.ID, v.State, v.CollectionID = "c", models.AssociatedState, "collection-0"
+			case "123/3":
+				v.ID, v.State = "d", models.EditionConfirmedState
+			case "123/4":
+				v.ID, v.State = "e", models.CompletedState
+			default:
+				return nil, errs.ErrVersionNotFound
+			}
+			return v, nil
+		},
+		GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+			return &models.DatasetUpdate{ID: "123", Next: &models.Dataset{}}, nil
+		},
+		CheckEditionExistsFunc: func(string, string, string) error {
+			return nil
+		},
+		UpdateVersionFunc: func(id string, version *models.Version) error {
+			if id == "d" {
+				return errors.New("write conflict")
+			}
+			return nil
+		},
+		UpdateDatasetWithAssociationFunc: func(string, string, *models.Version) error {
+			return nil
+		},
+	}
+}
+
+func TestAttachCollectionVersionsReturnsOK(t *testing.T) {
+	t.Parallel()
+	Convey("Given a batch of versions in a mix of states", t, func() {
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/collections/collection-1/versions", bytes.NewBufferString(collectionVersionsPayload))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := collectionVersionsStore()
+		generatorMock := &mocks.DownloadsGeneratorMock{
+			GenerateFunc: func(string, string, string, string, []string) error {
+				return nil
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, generatorMock, auditMock, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		Convey("Then the outcome of each version is reported", func() {
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(datasetPermissions.Required.Calls, ShouldEqual, 0)
+			So(permissions.Required.Calls, ShouldEqual, 1)
+
+			var results models.CollectionVersionResults
+			So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+			So(results.CollectionID, ShouldEqual, "collection-1")
+			So(results.Count, ShouldEqual, 2)
+			So(results.Items, ShouldResemble, []models.CollectionVersionResult{
+				{DatasetID: "123", Edition: "2017", Version: 1, Status: models.CollectionVersionAttached},
+				{DatasetID: "123", Edition: "2017", Version: 2, Status: models.CollectionVersionSkipped, Reason: reasonVersionPublished},
+				{DatasetID: "456", Edition: "2018", Version: 1, Status: models.CollectionVersionAttached},
+				{DatasetID: "789", Edition: "2019", Version: 1, Status: models.CollectionVersionFailed, Reason: errs.ErrVersionNotFound.Error()},
+				{DatasetID: "123", Edition: "2017", Version: 3, Status: models.CollectionVersionFailed, Reason: "write conflict"},
+				{DatasetID: "123", Edition: "2017", Version: 4, Status: models.CollectionVersionFailed, Reason: errs.ErrExpectedResourceStateOfEditionConfirmed.Error()},
+				{DatasetID: "", Edition: "2017", Version: 1, Status: models.CollectionVersionFailed, Reason: errs.ErrCollectionVersionInvalid.Error()},
+			})
+
+			Convey("And the edition-confirmed and associated versions are updated with the collection and associated state", func() {
+				So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 3)
+				for i, id := range []string{"a", "c", "d"} {
+					So(mockedDataStore.UpdateVersionCalls()[i].ID, ShouldEqual, id)
+					So(mockedDataStore.UpdateVersionCalls()[i].Version.CollectionID, ShouldEqual, "collection-1")
+					So(mockedDataStore.UpdateVersionCalls()[i].Version.State, ShouldEqual, models.AssociatedState)
+				}
+			})
+
+			Convey("And only the newly associated version is associated with its dataset and has its downloads generated", func() {
+				So(len(mockedDataStore.UpdateDatasetWithAssociationCalls()), ShouldEqual, 1)
+				So(mockedDataStore.UpdateDatasetWithAssociationCalls()[0].ID, ShouldEqual, "123")
+				So(len(generatorMock.GenerateCalls()), ShouldEqual, 1)
+				So(generatorMock.GenerateCalls()[0].InstanceID, ShouldEqual, "a")
+			})
+
+			Convey("And each version update and association is audited, along with the collection id and count", func() {
+				version1 := common.Params{"dataset_id": "123", "edition": "2017", "version": "1"}
+				auditMock.AssertRecordCalls(
+					auditortest.Expected{Action: attachCollectionVersionsAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "collection_id": "collection-1"}},
+					auditortest.Expected{Action: updateVersionAction, Result: audit.Successful, Params: version1},
+					auditortest.Expected{Action: associateVersionAction, Result: audit.Attempted, Params: version1},
+					auditortest.Expected{Action: associateVersionAction, Result: audit.Successful, Params: version1},
+					auditortest.Expected{Action: updateVersionAction, Result: audit.Successful, Params: common.Params{"dataset_id": "456", "edition": "2018", "version": "1"}},
+					auditortest.Expected{Action: updateVersionAction, Result: audit.Unsuccessful, Params: common.Params{"dataset_id": "123", "edition": "2017", "version": "3"}},
+					auditortest.Expected{Action: attachCollectionVersionsAction, Result: audit.Successful, Params: common.Params{"collection_id": "collection-1", "count": "2"}},
+				)
+			})
+		})
+	})
+}
+
+func TestAttachCollectionVersionsReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("When the request body contains no versions then a bad request is returned", t, func() {
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/collections/collection-1/versions", bytes.NewBufferString(`[]`))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{}
+
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrNoCollectionVersions.Error())
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 0)
+
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: attachCollectionVersionsAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "collection_id": "collection-1"}},
+			auditortest.Expected{Action: attachCollectionVersionsAction, Result: audit.Unsuccessful, Params: common.Params{"collection_id": "collection-1"}},
+		)
+	})
+
+	Convey("When a version cannot be read then an internal server error is returned", t, func() {
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/collections/collection-1/versions", bytes.NewBufferString(`[{"dataset_id":"123","edition":"2017","version":1}]`))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetVersionFunc: func(datasetID, edition, version, state string) (*models.Version, error) {
+				return nil, errs.ErrInternalServer
+			},
+		}
+
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 0)
+
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: attachCollectionVersionsAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "collection_id": "collection-1"}},
+			auditortest.Expected{Action: attachCollectionVersionsAction, Result: audit.Unsuccessful, Params: common.Params{"collection_id": "collection-1"}},
+		)
+	})
+}
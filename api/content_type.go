@@ -0,0 +1,35 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/pkg/errors"
+)
+
+const jsonMediaType = "application/json"
+
+// requireJSON wraps a http.HandlerFunc for a write endpoint, rejecting requests which declare a content type other
+// than application/json with a 415 before the body is read. Parameters such as a charset are allowed, requests
+// which do not declare a content type are passed through as existing clients do not always set one.
+func requireJSON(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.Header.Get("Content-Type")
+		if contentType == "" {
+			handler(w, r)
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != jsonMediaType {
+			logData := log.Data{"content_type": contentType, "method": r.Method, "path": r.URL.Path}
+			log.ErrorCtx(r.Context(), errors.WithMessage(errs.ErrUnsupportedContentType, "request rejected"), logData)
+			errs.WriteError(w, errs.ErrUnsupportedContentType, http.StatusUnsupportedMediaType)
+			return
+		}
+
+		handler(w, r)
+	}
+}
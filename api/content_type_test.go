@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	storetest "github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRequireJSON(t *testing.T) {
+	t.Parallel()
+	Convey("Given a handler which requires a JSON request body", t, func() {
+		called := false
+		handler := requireJSON(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		})
+
+		for _, contentType := range []string{"", "application/json", "application/json; charset=utf-8", "Application/JSON"} {
+			Convey("When the content type is '"+contentType+"' then the request is handled", func() {
+				r := httptest.NewRequest("POST", "http://localhost:22000/instances", strings.NewReader(`{}`))
+				r.Header.Set("Content-Type", contentType)
+				w := httptest.NewRecorder()
+				handler(w, r)
+
+				So(called, ShouldBeTrue)
+				So(w.Code, ShouldEqual, http.StatusOK)
+			})
+		}
+
+		for _, contentType := range []string{"text/plain", "application/x-www-form-urlencoded", "application/json;;"} {
+			Convey("When the content type is '"+contentType+"' then the request is rejected", func() {
+				r := httptest.NewRequest("POST", "http://localhost:22000/instances", strings.NewReader(`{}`))
+				r.Header.Set("Content-Type", contentType)
+				w := httptest.NewRecorder()
+				handler(w, r)
+
+				So(called, ShouldBeFalse)
+				So(w.Code, ShouldEqual, http.StatusUnsupportedMediaType)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrUnsupportedContentType.Error())
+			})
+		}
+	})
+}
+
+func TestWriteEndpointsRejectFormEncodedBody(t *testing.T) {
+	t.Parallel()
+	Convey("When a form encoded body is posted to create an instance then unsupported media type is returned", t, func() {
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/instances", strings.NewReader("links.job.id=123"))
+		So(err, ShouldBeNil)
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{}
+		permissions := getAuthorisationHandlerMock()
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), permissions)
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusUnsupportedMediaType)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrUnsupportedContentType.Error())
+		So(permissions.Required.Calls, ShouldEqual, 0)
+		So(len(mockedDataStore.AddInstanceCalls()), ShouldEqual, 0)
+		auditMock.AssertRecordCalls()
+	})
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/justinas/alice"
+)
+
+// corsAllowedMethods are the methods browsers are told they may use in cross-origin requests
+const corsAllowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+
+// cors returns middleware allowing browsers on the given origins, or on any origin when "*" is given, to call the
+// API. Preflight requests are answered directly with 204 No Content. Requests from the internal networks are
+// service-to-service calls rather than browsers, so they are passed straight through without any CORS handling.
+// Every response varies by origin so that a cache never serves one origin the headers computed for another.
+func cors(allowedOrigins []string) alice.Constructor {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if origin == "" || isInternalRequest(r.Context()) || !isAllowedOrigin(origin, allowedOrigins) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+				if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+					w.Header().Set("Access-Control-Allow-Headers", headers)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isAllowedOrigin(origin string, allowedOrigins []string) bool {
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
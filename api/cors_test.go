@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/justinas/alice"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+	Convey("Given CORS middleware behind the internal networks middleware", t, func() {
+		_, network, err := net.ParseCIDR("10.0.0.0/8")
+		So(err, ShouldBeNil)
+
+		var handled bool
+		handler := alice.New(internalNetworks([]*net.IPNet{network}), cors([]string{"https://www.ons.gov.uk"})).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handled = true
+		}))
+
+		preflight := func(remoteAddr, origin string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest("OPTIONS", "http://localhost:22000/datasets", nil)
+			r.RemoteAddr = remoteAddr
+			r.Header.Set("Origin", origin)
+			r.Header.Set("Access-Control-Request-Method", "GET")
+			r.Header.Set("Access-Control-Request-Headers", "Content-Type")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			return w
+		}
+
+		Convey("When a browser on an allowed origin makes a preflight request", func() {
+			w := preflight("192.168.1.10:51234", "https://www.ons.gov.uk")
+
+			Convey("Then the preflight is answered without reaching the handler", func() {
+				So(w.Code, ShouldEqual, http.StatusNoContent)
+				So(w.Header().Get("Access-Control-Allow-Origin"), ShouldEqual, "https://www.ons.gov.uk")
+				So(w.Header().Get("Access-Control-Allow-Methods"), ShouldEqual, corsAllowedMethods)
+				So(w.Header().Get("Access-Control-Allow-Headers"), ShouldEqual, "Content-Type")
+				So(w.Header()["Vary"], ShouldResemble, []string{"Origin"})
+				So(handled, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a browser on an allowed origin makes a request", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets", nil)
+			r.RemoteAddr = "192.168.1.10:51234"
+			r.Header.Set("Origin", "https://www.ons.gov.uk")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			Convey("Then the request is handled and the origin allowed", func() {
+				So(handled, ShouldBeTrue)
+				So(w.Header().Get("Access-Control-Allow-Origin"), ShouldEqual, "https://www.ons.gov.uk")
+				So(w.Header()["Vary"], ShouldResemble, []string{"Origin"})
+			})
+		})
+
+		Convey("When a request is made from an internal network", func() {
+			w := preflight("10.1.2.3:51234", "https://www.ons.gov.uk")
+
+			Convey("Then CORS handling is skipped and the request is passed to the handler", func() {
+				So(handled, ShouldBeTrue)
+				So(w.Header().Get("Access-Control-Allow-Origin"), ShouldBeEmpty)
+				So(w.Header()["Vary"], ShouldResemble, []string{"Origin"})
+			})
+		})
+
+		Convey("When a request is made from an origin which is not allowed", func() {
+			w := preflight("192.168.1.10:51234", "https://example.com")
+
+			Convey("Then no CORS headers are added", func() {
+				So(handled, ShouldBeTrue)
+				So(w.Header().Get("Access-Control-Allow-Origin"), ShouldBeEmpty)
+				So(w.Header()["Vary"], ShouldResemble, []string{"Origin"})
+			})
+		})
+
+		Convey("When a request is made without an origin", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets", nil)
+			r.RemoteAddr = "192.168.1.10:51234"
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			Convey("Then the response still varies by origin", func() {
+				So(handled, ShouldBeTrue)
+				So(w.Header().Get("Access-Control-Allow-Origin"), ShouldBeEmpty)
+				So(w.Header()["Vary"], ShouldResemble, []string{"Origin"})
+			})
+		})
+	})
+}
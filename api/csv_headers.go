@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/ONSdigital/go-ns/request"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// errors that should return a 400 status when validating csv headers
+var csvHeadersBadRequest = map[error]bool{
+	errs.ErrHeadersDimensionColumnsInvalid: true,
+	errs.ErrHeadersEmpty:                   true,
+	errs.ErrHeadersFirstCellInvalid:        true,
+	errs.ErrJSONTooDeep:                    true,
+	errs.ErrRequestBodyTooLarge:            true,
+	errs.ErrUnableToParseJSON:              true,
+}
+
+// errors that should return a 404 status when validating csv headers against a version
+var csvHeadersNotFound = map[error]bool{
+	errs.ErrDatasetNotFound: true,
+	errs.ErrEditionNotFound: true,
+	errs.ErrVersionNotFound: true,
+}
+
+// validateCSVHeaders parses the header row of a V4 file in the same way as an import, responding with the dimension
+// offset and dimension names so the headers can be checked before a full import is run
+func (api *DatasetAPI) validateCSVHeaders(w http.ResponseWriter, r *http.Request) {
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	logData := log.Data{}
+
+	b, err := func() ([]byte, error) {
+		var headers models.CSVHeaders
+		if err := models.DecodeJSON(r.Body, &headers, api.jsonLimits); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "validateCSVHeaders endpoint: failed to parse request body"), logData)
+			return nil, err
+		}
+		logData["headers"] = headers.Headers
+
+		preview, err := models.PreviewHeaders(headers.Headers)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "validateCSVHeaders endpoint: invalid headers"), logData)
+			return nil, err
+		}
+
+		return json.Marshal(preview)
+	}()
+
+	if err != nil {
+		if auditErr := api.auditor.Record(ctx, validateCSVHeadersAction, audit.Unsuccessful, nil); auditErr != nil {
+			err = auditErr
+		}
+		handleCSVHeadersErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := api.auditor.Record(ctx, validateCSVHeadersAction, audit.Successful, nil); auditErr != nil {
+		handleCSVHeadersErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	setJSONContentType(w)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "validateCSVHeaders endpoint: error writing bytes to response"), logData)
+	}
+	log.InfoCtx(ctx, "validateCSVHeaders endpoint: request successful", logData)
+}
+
+// validateVersionCSVHeaders parses the header row of a V4 file in the same way as an import and checks its
+// dimensions against those declared on the version, responding with the dimensions missing from or unexpected in
+// the header row so mistakes are caught before the file is imported
+func (api *DatasetAPI) validateVersionCSVHeaders(w http.ResponseWriter, r *http.Request) {
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	auditParams := common.Params{"dataset_id": vars["dataset_id"], "edition": vars["edition"], "version": vars["version"]}
+	logData := audit.ToLogData(auditParams)
+
+	b, err := func() ([]byte, error) {
+		var headers models.CSVHeaders
+		if err := models.DecodeJSON(r.Body, &headers, api.jsonLimits); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "validateVersionCSVHeaders endpoint: failed to parse request body"), logData)
+			return nil, err
+		}
+		logData["headers"] = headers.Headers
+
+		preview, err := models.PreviewHeaders(headers.Headers)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "validateVersionCSVHeaders endpoint: invalid headers"), logData)
+			return nil, err
+		}
+
+		versionDoc, err := api.dataStore.Backend.GetVersion(vars["dataset_id"], vars["edition"], vars["version"], "")
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "validateVersionCSVHeaders endpoint: failed to find version for dataset edition"), logData)
+			return nil, err
+		}
+
+		validation := compareHeaderDimensions(preview.Dimensions, getListOfValidDimensionNames(versionDoc.Dimensions))
+		logData["validation"] = validation
+
+		return json.Marshal(validation)
+	}()
+
+	if err != nil {
+		if auditErr := api.auditor.Record(ctx, validateVersionCSVHeadersAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleCSVHeadersErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := api.auditor.Record(ctx, validateVersionCSVHeadersAction, audit.Successful, auditParams); auditErr != nil {
+		handleCSVHeadersErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	setJSONContentType(w)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "validateVersionCSVHeaders endpoint: error writing bytes to response"), logData)
+	}
+	log.InfoCtx(ctx, "validateVersionCSVHeaders endpoint: request successful", logData)
+}
+
+// compareHeaderDimensions returns the dimensions of a version missing from a header row and the dimensions of the
+// header row which are not on the version, header dimensions are lower case so version dimensions are compared
+// ignoring case
+func compareHeaderDimensions(headerDimensions, versionDimensions []string) *models.CSVHeadersValidation {
+	validation := &models.CSVHeadersValidation{
+		MissingDimensions:    []string{},
+		UnexpectedDimensions: []string{},
+	}
+
+	inHeader := make(map[string]bool, len(headerDimensions))
+	for _, name := range headerDimensions {
+		inHeader[name] = true
+	}
+
+	onVersion := make(map[string]bool, len(versionDimensions))
+	for _, name := range versionDimensions {
+		name = strings.ToLower(name)
+		onVersion[name] = true
+		if !inHeader[name] {
+			validation.MissingDimensions = append(validation.MissingDimensions, name)
+		}
+	}
+
+	for _, name := range headerDimensions {
+		if !onVersion[name] {
+			validation.UnexpectedDimensions = append(validation.UnexpectedDimensions, name)
+		}
+	}
+
+	validation.Valid = len(validation.MissingDimensions) == 0 && len(validation.UnexpectedDimensions) == 0
+	return validation
+}
+
+func handleCSVHeadersErr(ctx context.Context, err error, w http.ResponseWriter, data log.Data) {
+	var status int
+	switch {
+	case csvHeadersBadRequest[err]:
+		status = http.StatusBadRequest
+	case csvHeadersNotFound[err]:
+		status = http.StatusNotFound
+	default:
+		err = errs.ErrInternalServer
+		status = http.StatusInternalServerError
+	}
+
+	data["responseStatus"] = status
+	log.ErrorCtx(ctx, errors.WithMessage(err, "request unsuccessful"), data)
+	errs.WriteError(w, err, status)
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	storetest "github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidateCSVHeaders(t *testing.T) {
+	t.Parallel()
+	Convey("When valid headers are validated then the dimension offset and dimension names are returned", t, func() {
+		b := `{"headers":["V4_1","data_marking","time_codelist","time","geography_codelist","geography"]}`
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/csv-headers/validate", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		permissions := getAuthorisationHandlerMock()
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(&storetest.StorerMock{}, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), permissions)
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldEqual, `{"dimension_offset":1,"dimensions":["time","geography"]}`)
+		So(permissions.Required.Calls, ShouldEqual, 1)
+
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: validateCSVHeadersAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity}},
+			auditortest.Expected{Action: validateCSVHeadersAction, Result: audit.Successful, Params: nil},
+		)
+	})
+
+	Convey("When headers with a malformed first header are validated then a bad request status is returned", t, func() {
+		b := `{"headers":["observation","time_codelist","time"]}`
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/csv-headers/validate", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(&storetest.StorerMock{}, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrHeadersFirstCellInvalid.Error())
+
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: validateCSVHeadersAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity}},
+			auditortest.Expected{Action: validateCSVHeadersAction, Result: audit.Unsuccessful, Params: nil},
+		)
+	})
+
+	Convey("When headers missing a dimension label column are validated then a bad request status is returned", t, func() {
+		b := `{"headers":["v4_0","time_codelist","time","geography_codelist"]}`
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/csv-headers/validate", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		api := GetAPIWithMocks(&storetest.StorerMock{}, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrHeadersDimensionColumnsInvalid.Error())
+	})
+
+	Convey("When the request body is not a json document then a bad request status is returned", t, func() {
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/csv-headers/validate", bytes.NewBufferString("V4_0,time_codelist,time"))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		api := GetAPIWithMocks(&storetest.StorerMock{}, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrUnableToParseJSON.Error())
+	})
+}
+
+func TestValidateVersionCSVHeaders(t *testing.T) {
+	t.Parallel()
+	Convey("Given a version declaring time, geography and aggregate dimensions", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetVersionFunc: func(datasetID, edition, version, state string) (*models.Version, error) {
+				if version != "1" {
+					return nil, errs.ErrVersionNotFound
+				}
+				return &models.Version{Dimensions: []models.Dimension{{Name: "time"}, {Name: "geography"}, {Name: "aggregate"}}}, nil
+			},
+		}
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When a header row missing the aggregate dimension is validated against the version", func() {
+			b := `{"headers":["V4_1","data_marking","time_codelist","time","geography_codelist","geography"]}`
+			r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123/editions/2017/versions/1/validate-csv-header", bytes.NewBufferString(b))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the missing dimension is reported and the header row is not valid", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var validation models.CSVHeadersValidation
+				So(json.Unmarshal(w.Body.Bytes(), &validation), ShouldBeNil)
+				So(validation, ShouldResemble, models.CSVHeadersValidation{
+					Valid:                false,
+					MissingDimensions:    []string{"aggregate"},
+					UnexpectedDimensions: []string{},
+				})
+
+				So(mockedDataStore.GetVersionCalls(), ShouldHaveLength, 1)
+				So(mockedDataStore.GetVersionCalls()[0].State, ShouldEqual, "")
+
+				params := common.Params{"dataset_id": "123", "edition": "2017", "version": "1"}
+				auditMock.AssertRecordCalls(
+					auditortest.Expected{Action: validateVersionCSVHeadersAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "dataset_id": "123", "edition": "2017", "version": "1"}},
+					auditortest.Expected{Action: validateVersionCSVHeadersAction, Result: audit.Successful, Params: params},
+				)
+			})
+		})
+
+		Convey("When a header row with an undeclared dimension is validated against the version then it is reported", func() {
+			b := `{"headers":["V4_0","time_codelist","Time","geography_codelist","geography","aggregate_codelist","aggregate","sex_codelist","sex"]}`
+			r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123/editions/2017/versions/1/validate-csv-header", bytes.NewBufferString(b))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldEqual, `{"valid":false,"missing_dimensions":[],"unexpected_dimensions":["sex"]}`)
+		})
+
+		Convey("When the version does not exist then a not found status is returned", func() {
+			b := `{"headers":["V4_0","time_codelist","time"]}`
+			r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123/editions/2017/versions/2/validate-csv-header", bytes.NewBufferString(b))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrVersionNotFound.Error())
+		})
+
+		Convey("When malformed headers are validated then a bad request status is returned without finding the version", func() {
+			b := `{"headers":["observation","time_codelist","time"]}`
+			r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123/editions/2017/versions/1/validate-csv-header", bytes.NewBufferString(b))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrHeadersFirstCellInvalid.Error())
+			So(mockedDataStore.GetVersionCalls(), ShouldBeEmpty)
+		})
+	})
+}
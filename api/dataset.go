@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
@@ -17,6 +19,12 @@ import (
 	"github.com/pkg/errors"
 )
 
+// linksRepresentation is the representation parameter value for a dataset holding only its navigation links
+const linksRepresentation = "links"
+
+// latestVersionInclude embeds the latest published version of a dataset in the dataset returned
+const latestVersionInclude = "latest_version"
+
 var (
 	// errors that should return a 403 status
 	datasetsForbidden = map[error]bool{
@@ -31,7 +39,21 @@ var (
 
 	// errors that should return a 400 status
 	datasetsBadRequest = map[error]bool{
-		errs.ErrAddUpdateDatasetBadRequest: true,
+		errs.ErrAddUpdateDatasetBadRequest:  true,
+		errs.ErrDatasetContactInvalid:       true,
+		errs.ErrDatasetPatchFieldInvalid:    true,
+		errs.ErrDatasetPatchPublishMixed:    true,
+		errs.ErrDatasetPublisherTypeInvalid: true,
+		errs.ErrDatasetSubtopicsInvalid:     true,
+		errs.ErrDatasetSunsetDateInvalid:    true,
+		errs.ErrDatasetSurveyInvalid:        true,
+		errs.ErrDatasetTranslationInvalid:   true,
+		errs.ErrDatasetTypeInvalid:          true,
+		errs.ErrDatasetURIInvalid:           true,
+		errs.ErrInvalidModifiedSince:        true,
+		errs.ErrInvalidPaginationParameter:  true,
+		errs.ErrInvalidInclude:              true,
+		errs.ErrInvalidRepresentation:       true,
 	}
 
 	// errors that should return a 404 status
@@ -44,12 +66,24 @@ var (
 func (api *DatasetAPI) getDatasets(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	if err := api.auditor.Record(ctx, getDatasetsAction, audit.Attempted, nil); err != nil {
-		http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
+		errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
 		return
 	}
 
 	b, err := func() ([]byte, error) {
-		datasets, err := api.dataStore.Backend.GetDatasets()
+		datasetType := r.URL.Query().Get("type")
+		if datasetType != "" {
+			if err := models.ValidateDatasetType(datasetType); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "api endpoint getDatasets invalid type filter"), log.Data{"type": datasetType})
+				return nil, err
+			}
+		}
+
+		if modifiedSince := r.URL.Query().Get("modified_since"); modifiedSince != "" {
+			return api.getDatasetsModifiedSince(r, modifiedSince, datasetType)
+		}
+
+		datasets, err := api.dataStore.Backend.GetDatasets(datasetType)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "api endpoint getDatasets datastore.GetDatasets returned an error"), nil)
 			return nil, err
@@ -64,7 +98,7 @@ func (api *DatasetAPI) getDatasets(w http.ResponseWriter, r *http.Request) {
 			datasetsResponse = &models.DatasetUpdateResults{Items: datasets}
 		} else {
 			// User is not authenticated and hence has only access to current sub document
-			datasetsResponse = &models.DatasetResults{Items: mapResults(datasets)}
+			datasetsResponse = &models.DatasetResults{Items: api.mapResults(datasets)}
 		}
 
 		b, err = json.Marshal(datasetsResponse)
@@ -99,6 +133,104 @@ func (api *DatasetAPI) getDatasets(w http.ResponseWriter, r *http.Request) {
 	log.InfoCtx(ctx, "api endpoint getDatasets request successful", nil)
 }
 
+// getThemes returns the themes given to published datasets in alphabetical order, each theme listed once
+func (api *DatasetAPI) getThemes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := api.auditor.Record(ctx, getThemesAction, audit.Attempted, nil); err != nil {
+		handleDatasetAPIErr(ctx, err, w, nil)
+		return
+	}
+
+	b, err := func() ([]byte, error) {
+		themes, err := api.dataStore.Backend.GetDistinctThemes()
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "api endpoint getThemes datastore.GetDistinctThemes returned an error"), nil)
+			return nil, err
+		}
+
+		sort.Strings(themes)
+
+		b, err := json.Marshal(&models.ThemeResults{Items: themes})
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "api endpoint getThemes failed to marshal themes into bytes"), nil)
+			return nil, err
+		}
+		return b, nil
+	}()
+
+	if err != nil {
+		if auditErr := api.auditor.Record(ctx, getThemesAction, audit.Unsuccessful, nil); auditErr != nil {
+			err = auditErr
+		}
+		handleDatasetAPIErr(ctx, err, w, nil)
+		return
+	}
+
+	if auditErr := api.auditor.Record(ctx, getThemesAction, audit.Successful, nil); auditErr != nil {
+		handleDatasetAPIErr(ctx, auditErr, w, nil)
+		return
+	}
+
+	setJSONContentType(w)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "api endpoint getThemes error writing response body"), nil)
+	}
+	log.InfoCtx(ctx, "api endpoint getThemes request successful", nil)
+}
+
+// getDatasetsModifiedSince returns a page of the datasets of the type given, if any, updated at or after the
+// modified_since time, allowing consumers such as search indexing to only pull the datasets which have changed since
+// they last synchronised
+func (api *DatasetAPI) getDatasetsModifiedSince(r *http.Request, modifiedSince, datasetType string) ([]byte, error) {
+	ctx := r.Context()
+	logData := log.Data{"modified_since": modifiedSince, "type": datasetType}
+
+	since, err := time.Parse(time.RFC3339, modifiedSince)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "api endpoint getDatasets invalid modified_since parameter"), logData)
+		return nil, errs.ErrInvalidModifiedSince
+	}
+
+	offset, limit, err := api.getPaginationParameters(r)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "api endpoint getDatasets invalid pagination parameters"), logData)
+		return nil, err
+	}
+	logData["offset"] = offset
+	logData["limit"] = limit
+
+	authorised, logData := api.authenticate(r, logData)
+
+	// public callers are only shown published datasets, so only those are counted towards the total
+	page, err := api.dataStore.Backend.GetDatasetsModifiedSince(since, datasetType, !authorised, offset, limit)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "api endpoint getDatasets datastore.GetDatasetsModifiedSince returned an error"), logData)
+		return nil, err
+	}
+
+	var datasetsResponse interface{}
+	if authorised {
+		datasetsResponse = page
+	} else {
+		items := api.mapResults(page.Items)
+		datasetsResponse = &models.DatasetPage{
+			Items:      items,
+			Count:      len(items),
+			Offset:     page.Offset,
+			Limit:      page.Limit,
+			TotalCount: page.TotalCount,
+		}
+	}
+
+	b, err := json.Marshal(datasetsResponse)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "api endpoint getDatasets failed to marshal dataset resource into bytes"), logData)
+		return nil, err
+	}
+
+	return b, nil
+}
+
 func (api *DatasetAPI) getDataset(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	vars := mux.Vars(r)
@@ -111,13 +243,43 @@ func (api *DatasetAPI) getDataset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// shown is the sub document the deprecation headers are taken from, the next sub document for authorised callers
+	var shown *models.Dataset
+
+	// language is the language of the translation returned, empty when the dataset is in the default language. Only
+	// the published dataset returned to public callers is localised, authorised callers get the stored document so a
+	// translation cannot be written back over the default text by a later update.
+	var language string
+	languages := models.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+
 	b, err := func() ([]byte, error) {
+		representation := r.URL.Query().Get("representation")
+		if representation != "" && representation != linksRepresentation {
+			logData["representation"] = representation
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrInvalidRepresentation, "getDataset endpoint: invalid representation"), logData)
+			return nil, errs.ErrInvalidRepresentation
+		}
+
+		include := r.URL.Query().Get("include")
+		if include != "" && include != latestVersionInclude {
+			logData["include"] = include
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrInvalidInclude, "getDataset endpoint: invalid include"), logData)
+			return nil, errs.ErrInvalidInclude
+		}
+
 		dataset, err := api.dataStore.Backend.GetDataset(datasetID)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "getDataset endpoint: dataStore.Backend.GetDataset returned an error"), logData)
 			return nil, err
 		}
 
+		if include == latestVersionInclude {
+			if err = api.embedLatestVersion(datasetID, dataset.Current); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "getDataset endpoint: failed to get the latest published version"), logData)
+				return nil, err
+			}
+		}
+
 		authorised, logData := api.authenticate(r, logData)
 
 		var b []byte
@@ -133,7 +295,10 @@ func (api *DatasetAPI) getDataset(w http.ResponseWriter, r *http.Request) {
 			log.InfoCtx(ctx, "getDataset endpoint: caller authorised returning dataset current sub document", logData)
 
 			dataset.Current.ID = dataset.ID
+			api.sanitiseDataset(dataset.Current)
+			language = dataset.Current.Localise(languages)
 			datasetResponse = dataset.Current
+			shown = dataset.Current
 		} else {
 			// User has valid authentication to get raw dataset document
 			if dataset == nil {
@@ -142,6 +307,11 @@ func (api *DatasetAPI) getDataset(w http.ResponseWriter, r *http.Request) {
 			}
 			log.InfoCtx(ctx, "getDataset endpoint: caller not authorised returning dataset", logData)
 			datasetResponse = dataset
+			shown = dataset.Next
+		}
+
+		if representation == linksRepresentation {
+			datasetResponse = api.buildDatasetLinks(datasetID, shown)
 		}
 
 		b, err = json.Marshal(datasetResponse)
@@ -167,6 +337,11 @@ func (api *DatasetAPI) getDataset(w http.ResponseWriter, r *http.Request) {
 	}
 
 	setJSONContentType(w)
+	setDeprecationHeaders(w, shown)
+	w.Header().Set("Vary", "Accept-Language")
+	if language != "" {
+		w.Header().Set("Content-Language", language)
+	}
 	if _, err = w.Write(b); err != nil {
 		log.ErrorCtx(ctx, errors.WithMessage(err, "getDataset endpoint: error writing bytes to response"), logData)
 		handleDatasetAPIErr(ctx, err, w, logData)
@@ -174,6 +349,185 @@ func (api *DatasetAPI) getDataset(w http.ResponseWriter, r *http.Request) {
 	log.InfoCtx(ctx, "getDataset endpoint: request successful", logData)
 }
 
+// embedLatestVersion embeds the latest published version of the edition the published dataset links to, so the
+// dataset and its latest version can be read in one request. Nothing is embedded when no version is published.
+func (api *DatasetAPI) embedLatestVersion(datasetID string, dataset *models.Dataset) error {
+	if dataset == nil || dataset.Links == nil || dataset.Links.LatestVersion == nil {
+		return nil
+	}
+
+	edition := editionFromVersionHRef(dataset.Links.LatestVersion.HRef)
+	if edition == "" {
+		return nil
+	}
+
+	version, err := api.dataStore.Backend.GetLatestVersion(datasetID, edition, models.PublishedState)
+	if err != nil {
+		if err == errs.ErrVersionNotFound {
+			return nil
+		}
+		return err
+	}
+
+	version.Sanitise()
+	dataset.LatestVersion = version
+	return nil
+}
+
+// editionFromVersionHRef returns the edition of a version from its url, or an empty string if the url is not that of
+// a version
+func editionFromVersionHRef(href string) string {
+	const editions = "/editions/"
+
+	i := strings.LastIndex(href, editions)
+	if i < 0 {
+		return ""
+	}
+
+	parts := strings.SplitN(href[i+len(editions):], "/", 2)
+	if len(parts) != 2 || !strings.HasPrefix(parts[1], "versions/") {
+		return ""
+	}
+	return parts[0]
+}
+
+// buildDatasetLinks returns the navigation links of a dataset, the editions and self links being built from the
+// dataset id and the latest version link taken from the stored dataset as it identifies the edition
+func (api *DatasetAPI) buildDatasetLinks(datasetID string, dataset *models.Dataset) *models.DatasetLinks {
+	links := &models.DatasetLinks{
+		Editions: &models.LinkObject{HRef: api.urlBuilder.BuildDatasetEditionsURL(datasetID)},
+		Self:     &models.LinkObject{HRef: api.urlBuilder.BuildDatasetURL(datasetID)},
+	}
+
+	if dataset != nil && dataset.Links != nil {
+		links.LatestVersion = dataset.Links.LatestVersion
+	}
+
+	return links
+}
+
+// getDatasetTree returns the dataset with each of its editions and their versions, holding only the fields needed to
+// identify them. The number of versions returned is capped by the configured maximum, once reached the remaining
+// versions are omitted and the tree is marked as truncated.
+func (api *DatasetAPI) getDatasetTree(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	datasetID := vars["dataset_id"]
+	auditParams := common.Params{"dataset_id": datasetID}
+	logData := audit.ToLogData(auditParams)
+
+	b, err := func() ([]byte, error) {
+		datasetDoc, err := api.dataStore.Backend.GetDataset(datasetID)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "getDatasetTree endpoint: dataStore.Backend.GetDataset returned an error"), logData)
+			return nil, err
+		}
+
+		dataset := datasetDoc.Next
+		if dataset == nil {
+			dataset = datasetDoc.Current
+		}
+		if dataset == nil {
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrDatasetNotFound, "getDatasetTree endpoint: dataset has no current or next sub document"), logData)
+			return nil, errs.ErrDatasetNotFound
+		}
+
+		tree := models.DatasetTree{
+			ID:       datasetID,
+			State:    dataset.State,
+			Title:    dataset.Title,
+			Editions: []models.EditionTree{},
+		}
+
+		editions, err := api.dataStore.Backend.GetEditions(datasetID, "")
+		if err != nil && err != errs.ErrEditionNotFound {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "getDatasetTree endpoint: dataStore.Backend.GetEditions returned an error"), logData)
+			return nil, err
+		}
+
+		if editions != nil {
+			remaining := api.datasetTreeMaxVersions
+
+			for _, edition := range editions.Items {
+				if edition.Next == nil {
+					continue
+				}
+
+				editionTree := models.EditionTree{
+					Edition:  edition.Next.Edition,
+					State:    edition.Next.State,
+					Versions: []models.VersionSummary{},
+				}
+
+				if remaining <= 0 {
+					tree.Truncated = true
+					tree.Editions = append(tree.Editions, editionTree)
+					continue
+				}
+
+				versions, err := api.dataStore.Backend.GetVersions(datasetID, edition.Next.Edition, "", nil)
+				if err != nil && err != errs.ErrVersionNotFound {
+					logData["edition"] = edition.Next.Edition
+					log.ErrorCtx(ctx, errors.WithMessage(err, "getDatasetTree endpoint: dataStore.Backend.GetVersions returned an error"), logData)
+					return nil, err
+				}
+
+				if versions != nil {
+					items := versions.Items
+					sort.Slice(items, func(i, j int) bool { return items[i].Version < items[j].Version })
+
+					if len(items) > remaining {
+						items = items[:remaining]
+						tree.Truncated = true
+					}
+					remaining -= len(items)
+
+					for _, version := range items {
+						editionTree.Versions = append(editionTree.Versions, models.VersionSummary{
+							ID:          version.ID,
+							Version:     version.Version,
+							State:       version.State,
+							ReleaseDate: version.ReleaseDate,
+						})
+					}
+				}
+
+				tree.Editions = append(tree.Editions, editionTree)
+			}
+		}
+
+		sort.Slice(tree.Editions, func(i, j int) bool { return tree.Editions[i].Edition < tree.Editions[j].Edition })
+		logData["truncated"] = tree.Truncated
+
+		b, err := json.Marshal(tree)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "getDatasetTree endpoint: failed to marshal dataset tree into bytes"), logData)
+			return nil, err
+		}
+		return b, nil
+	}()
+
+	if err != nil {
+		if auditErr := api.auditor.Record(ctx, getDatasetTreeAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleDatasetAPIErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := api.auditor.Record(ctx, getDatasetTreeAction, audit.Successful, auditParams); auditErr != nil {
+		handleDatasetAPIErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	setJSONContentType(w)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "getDatasetTree endpoint: error writing bytes to response"), logData)
+		handleDatasetAPIErr(ctx, err, w, logData)
+	}
+	log.InfoCtx(ctx, "getDatasetTree endpoint: request successful", logData)
+}
+
 func (api *DatasetAPI) addDataset(w http.ResponseWriter, r *http.Request) {
 
 	defer request.DrainBody(r)
@@ -198,12 +552,52 @@ func (api *DatasetAPI) addDataset(w http.ResponseWriter, r *http.Request) {
 			return nil, errs.ErrAddDatasetAlreadyExists
 		}
 
-		dataset, err := models.CreateDataset(r.Body)
+		dataset, err := models.CreateDataset(r.Body, api.jsonLimits)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "addDataset endpoint: failed to model dataset resource based on request"), logData)
 			return nil, errs.ErrAddUpdateDatasetBadRequest
 		}
 
+		if dataset.Type == "" {
+			dataset.Type = models.FilterableDatasetType
+		}
+
+		if err = models.ValidateDatasetType(dataset.Type); err != nil {
+			logData["type"] = dataset.Type
+			log.ErrorCtx(ctx, errors.WithMessage(err, "addDataset endpoint: invalid dataset type"), logData)
+			return nil, err
+		}
+
+		if err = models.ValidateDatasetClassification(dataset, api.datasetFieldMaxLength, api.datasetMaxSubtopics); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "addDataset endpoint: invalid survey or subtopics"), logData)
+			return nil, err
+		}
+
+		if err = models.ValidateDatasetDeprecation(dataset, nil, nil, time.Now()); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "addDataset endpoint: invalid deprecation"), logData)
+			return nil, err
+		}
+
+		if err = models.ValidateDatasetContacts(dataset.Contacts); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "addDataset endpoint: invalid contacts"), logData)
+			return nil, err
+		}
+
+		if err = models.ValidateDatasetURI(dataset.URI); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "addDataset endpoint: invalid uri"), logData)
+			return nil, err
+		}
+
+		if err = models.ValidateDatasetPublisher(dataset.Publisher); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "addDataset endpoint: invalid publisher"), logData)
+			return nil, err
+		}
+
+		if err = models.ValidateDatasetTranslations(dataset.Translations); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "addDataset endpoint: invalid translations"), logData)
+			return nil, err
+		}
+
 		dataset.State = models.CreatedState
 		dataset.ID = datasetID
 
@@ -255,7 +649,7 @@ func (api *DatasetAPI) addDataset(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 	if _, err = w.Write(b); err != nil {
 		log.ErrorCtx(ctx, errors.WithMessage(err, "addDataset endpoint: error writing bytes to response"), logData)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		errs.WriteError(w, err, http.StatusInternalServerError)
 	}
 	log.InfoCtx(ctx, "addDataset endpoint: request completed successfully", logData)
 }
@@ -272,18 +666,48 @@ func (api *DatasetAPI) putDataset(w http.ResponseWriter, r *http.Request) {
 
 	err := func() error {
 
-		dataset, err := models.CreateDataset(r.Body)
+		dataset, err := models.CreateDataset(r.Body, api.jsonLimits)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "putDataset endpoint: failed to model dataset resource based on request"), data)
 			return errs.ErrAddUpdateDatasetBadRequest
 		}
 
+		if err = models.ValidateDatasetClassification(dataset, api.datasetFieldMaxLength, api.datasetMaxSubtopics); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "putDataset endpoint: invalid survey or subtopics"), data)
+			return err
+		}
+
+		if err = models.ValidateDatasetContacts(dataset.Contacts); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "putDataset endpoint: invalid contacts"), data)
+			return err
+		}
+
+		if err = models.ValidateDatasetURI(dataset.URI); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "putDataset endpoint: invalid uri"), data)
+			return err
+		}
+
+		if err = models.ValidateDatasetPublisher(dataset.Publisher); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "putDataset endpoint: invalid publisher"), data)
+			return err
+		}
+
+		if err = models.ValidateDatasetTranslations(dataset.Translations); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "putDataset endpoint: invalid translations"), data)
+			return err
+		}
+
 		currentDataset, err := api.dataStore.Backend.GetDataset(datasetID)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "putDataset endpoint: datastore.getDataset returned an error"), data)
 			return err
 		}
 
+		if err = models.ValidateDatasetDeprecation(dataset, currentDataset.Next, nil, time.Now()); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "putDataset endpoint: invalid deprecation"), data)
+			return err
+		}
+
 		if dataset.State == models.PublishedState {
 			if err := api.publishDataset(ctx, currentDataset, nil); err != nil {
 				log.ErrorCtx(ctx, errors.WithMessage(err, "putDataset endpoint: failed to update dataset document to published"), data)
@@ -311,13 +735,107 @@ func (api *DatasetAPI) putDataset(w http.ResponseWriter, r *http.Request) {
 	log.InfoCtx(ctx, "putDataset endpoint: request successful", data)
 }
 
+func (api *DatasetAPI) patchDataset(w http.ResponseWriter, r *http.Request) {
+
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	datasetID := vars["dataset_id"]
+	data := log.Data{"dataset_id": datasetID}
+	auditParams := common.Params{"dataset_id": datasetID}
+
+	err := func() error {
+
+		patch, err := models.CreateDatasetPatch(r.Body, api.jsonLimits)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "patchDataset endpoint: failed to model dataset patch based on request"), data)
+			if err == errs.ErrDatasetPatchFieldInvalid {
+				return err
+			}
+			return errs.ErrAddUpdateDatasetBadRequest
+		}
+
+		if err = models.ValidateDatasetClassification(patch.Dataset, api.datasetFieldMaxLength, api.datasetMaxSubtopics); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "patchDataset endpoint: invalid survey or subtopics"), data)
+			return err
+		}
+
+		if err = models.ValidateDatasetContacts(patch.Dataset.Contacts); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "patchDataset endpoint: invalid contacts"), data)
+			return err
+		}
+
+		if err = models.ValidateDatasetURI(patch.Dataset.URI); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "patchDataset endpoint: invalid uri"), data)
+			return err
+		}
+
+		if err = models.ValidateDatasetPublisher(patch.Dataset.Publisher); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "patchDataset endpoint: invalid publisher"), data)
+			return err
+		}
+
+		if err = models.ValidateDatasetTranslations(patch.Dataset.Translations); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "patchDataset endpoint: invalid translations"), data)
+			return err
+		}
+
+		currentDataset, err := api.dataStore.Backend.GetDataset(datasetID)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "patchDataset endpoint: datastore.getDataset returned an error"), data)
+			return err
+		}
+
+		if err = models.ValidateDatasetDeprecation(patch.Dataset, currentDataset.Next, patch.Clear, time.Now()); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "patchDataset endpoint: invalid deprecation"), data)
+			return err
+		}
+
+		if patch.Dataset.State == models.PublishedState {
+			// publishing copies the stored dataset, so any other change in the patch would be lost
+			if !patch.ChangesOnlyState() {
+				log.ErrorCtx(ctx, errors.WithMessage(errs.ErrDatasetPatchPublishMixed, "patchDataset endpoint: patch publishes the dataset and changes other fields"), data)
+				return errs.ErrDatasetPatchPublishMixed
+			}
+
+			if err := api.publishDataset(ctx, currentDataset, nil); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "patchDataset endpoint: failed to update dataset document to published"), data)
+				return err
+			}
+		} else {
+			data["clear"] = patch.Clear
+			if err := api.dataStore.Backend.PatchDataset(datasetID, patch, currentDataset.Next.State); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "patchDataset endpoint: failed to patch dataset resource"), data)
+				return err
+			}
+		}
+		return nil
+	}()
+
+	if err != nil {
+		api.auditor.Record(ctx, patchDatasetAction, audit.Unsuccessful, auditParams)
+		handleDatasetAPIErr(ctx, err, w, data)
+		return
+	}
+
+	api.auditor.Record(ctx, patchDatasetAction, audit.Successful, auditParams)
+
+	setJSONContentType(w)
+	w.WriteHeader(http.StatusOK)
+	log.InfoCtx(ctx, "patchDataset endpoint: request successful", data)
+}
+
 func (api *DatasetAPI) publishDataset(ctx context.Context, currentDataset *models.DatasetUpdate, version *models.Version) error {
 	if version != nil {
 		currentDataset.Next.CollectionID = ""
 
-		currentDataset.Next.Links.LatestVersion = &models.LinkObject{
-			ID:   version.Links.Version.ID,
-			HRef: version.Links.Version.HRef,
+		// the dataset keeps linking to its latest version while its links are frozen, until they are thawed
+		if !currentDataset.LinksFrozen || currentDataset.Next.Links.LatestVersion == nil {
+			currentDataset.Next.Links.LatestVersion = &models.LinkObject{
+				ID:   version.Links.Version.ID,
+				HRef: version.Links.Version.HRef,
+			}
 		}
 	}
 
@@ -402,19 +920,42 @@ func (api *DatasetAPI) deleteDataset(w http.ResponseWriter, r *http.Request) {
 	log.DebugCtx(ctx, "delete dataset", logData)
 }
 
-func mapResults(results []models.DatasetUpdate) []*models.Dataset {
+func (api *DatasetAPI) mapResults(results []models.DatasetUpdate) []*models.Dataset {
 	items := []*models.Dataset{}
 	for _, item := range results {
 		if item.Current == nil {
 			continue
 		}
 		item.Current.ID = item.ID
+		api.sanitiseDataset(item.Current)
 
 		items = append(items, item.Current)
 	}
 	return items
 }
 
+// sanitiseDataset removes the internal-only fields of a published dataset before it is returned to a public caller,
+// along with the email and telephone of its contacts when contact details are configured not to be public
+func (api *DatasetAPI) sanitiseDataset(dataset *models.Dataset) {
+	dataset.Sanitise()
+	if api.redactPublicContacts {
+		dataset.RedactContacts()
+	}
+}
+
+// setDeprecationHeaders tells clients a dataset is deprecated with the Deprecation header, and when it will be
+// removed with the Sunset header (RFC 8594)
+func setDeprecationHeaders(w http.ResponseWriter, dataset *models.Dataset) {
+	if dataset == nil || !dataset.IsDeprecated() {
+		return
+	}
+
+	w.Header().Set("Deprecation", "true")
+	if sunset, err := models.ParseSunsetDate(dataset.SunsetDate); err == nil {
+		w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+}
+
 func handleDatasetAPIErr(ctx context.Context, err error, w http.ResponseWriter, data log.Data) {
 	if data == nil {
 		data = log.Data{}
@@ -430,6 +971,8 @@ func handleDatasetAPIErr(ctx context.Context, err error, w http.ResponseWriter,
 		status = http.StatusBadRequest
 	case resourcesNotFound[err]:
 		status = http.StatusNotFound
+	case err == errs.ErrRequestTimeout:
+		status = http.StatusServiceUnavailable
 	default:
 		err = errs.ErrInternalServer
 		status = http.StatusInternalServerError
@@ -437,5 +980,5 @@ func handleDatasetAPIErr(ctx context.Context, err error, w http.ResponseWriter,
 
 	data["responseStatus"] = status
 	log.ErrorCtx(ctx, errors.WithMessage(err, "request unsuccessful"), data)
-	http.Error(w, err.Error(), status)
+	errs.WriteError(w, err, status)
 }
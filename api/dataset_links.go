@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/dp-dataset-api/store"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/ONSdigital/go-ns/request"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// freezeDatasetLinks stops the latest version links of the editions of a dataset being updated as its versions are
+// published, so a bulk load of versions does not recompute the links after every version
+func (api *DatasetAPI) freezeDatasetLinks(w http.ResponseWriter, r *http.Request) {
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	datasetID := mux.Vars(r)["dataset_id"]
+	auditParams := common.Params{"dataset_id": datasetID}
+	logData := audit.ToLogData(auditParams)
+
+	if err := api.dataStore.Backend.SetDatasetLinksFrozen(datasetID, true); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "freezeDatasetLinks endpoint: failed to freeze dataset links"), logData)
+		if auditErr := api.auditor.Record(ctx, freezeDatasetLinksAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleDatasetAPIErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := api.auditor.Record(ctx, freezeDatasetLinksAction, audit.Successful, auditParams); auditErr != nil {
+		handleDatasetAPIErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	log.InfoCtx(ctx, "freezeDatasetLinks endpoint: request successful", logData)
+}
+
+// thawDatasetLinks resumes updating the latest version links of the editions of a dataset, refreshing the links of
+// each edition, and the latest version link of the dataset, once to take in the versions published while they were
+// frozen. The version ids linked to before and after the refresh are returned for each edition.
+func (api *DatasetAPI) thawDatasetLinks(w http.ResponseWriter, r *http.Request) {
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	datasetID := mux.Vars(r)["dataset_id"]
+	auditParams := common.Params{"dataset_id": datasetID}
+	logData := audit.ToLogData(auditParams)
+
+	refreshes := map[string]*models.EditionLinksRefresh{}
+	var latestPublished *models.Version
+
+	// the links are refreshed and the dataset thawed together, so a failure leaves the dataset frozen to be retried
+	err := api.dataStore.Backend.WithTransaction(func(tx store.Storer) error {
+		txAPI := api.withBackend(tx)
+
+		editions, err := tx.GetEditions(datasetID, "")
+		if err != nil && err != errs.ErrEditionNotFound {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "thawDatasetLinks endpoint: unable to find editions"), logData)
+			return err
+		}
+
+		if editions != nil {
+			for _, editionDoc := range editions.Items {
+				if editionDoc.Next == nil {
+					continue
+				}
+				if err = checkRequestTimeout(ctx); err != nil {
+					return err
+				}
+
+				edition := editionDoc.Next.Edition
+				refresh, published, err := txAPI.refreshLinks(ctx, datasetID, edition, editionDoc)
+				if err == errs.ErrVersionNotFound {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				refreshes[edition] = refresh
+
+				if published != nil && (latestPublished == nil || published.LastUpdated.After(latestPublished.LastUpdated)) {
+					latestPublished = published
+				}
+			}
+		}
+
+		if latestPublished != nil {
+			if err = txAPI.refreshDatasetLatestVersion(ctx, datasetID, latestPublished); err != nil {
+				return err
+			}
+		}
+
+		if err = tx.SetDatasetLinksFrozen(datasetID, false); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "thawDatasetLinks endpoint: failed to thaw dataset links"), logData)
+			return err
+		}
+		return nil
+	})
+
+	var b []byte
+	if err == nil {
+		b, err = json.Marshal(refreshes)
+	}
+
+	if err != nil {
+		if auditErr := api.auditor.Record(ctx, thawDatasetLinksAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleDatasetAPIErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := api.auditor.Record(ctx, thawDatasetLinksAction, audit.Successful, auditParams); auditErr != nil {
+		handleDatasetAPIErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	setJSONContentType(w)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "thawDatasetLinks endpoint: failed to write bytes to response"), logData)
+		errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+	log.InfoCtx(ctx, "thawDatasetLinks endpoint: request successful", logData)
+}
+
+// refreshDatasetLatestVersion points the latest version link of a dataset at the version published most recently
+func (api *DatasetAPI) refreshDatasetLatestVersion(ctx context.Context, datasetID string, version *models.Version) error {
+	logData := log.Data{"dataset_id": datasetID, "instance_id": version.ID}
+
+	if version.Links == nil || version.Links.Version == nil {
+		return nil
+	}
+
+	dataset, err := api.dataStore.Backend.GetDataset(datasetID)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "refresh dataset latest version: unable to find dataset"), logData)
+		return err
+	}
+
+	latestVersion := &models.LinkObject{ID: version.Links.Version.ID, HRef: version.Links.Version.HRef}
+	for _, doc := range []*models.Dataset{dataset.Current, dataset.Next} {
+		if doc != nil && doc.Links != nil {
+			doc.Links.LatestVersion = latestVersion
+		}
+	}
+
+	update := &models.DatasetUpdate{ID: dataset.ID, Current: dataset.Current, Next: dataset.Next}
+	if err = api.dataStore.Backend.UpsertDataset(datasetID, update); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "refresh dataset latest version: failed to update dataset"), logData)
+		return err
+	}
+
+	return nil
+}
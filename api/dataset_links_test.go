@@ -0,0 +1,277 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFreezeDatasetLinks(t *testing.T) {
+	t.Parallel()
+	Convey("Given a dataset", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			SetDatasetLinksFrozenFunc: func(id string, frozen bool) error {
+				return nil
+			},
+		}
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When its links are frozen", func() {
+			r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123/links/freeze", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the dataset is marked as having frozen links", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.SetDatasetLinksFrozenCalls()), ShouldEqual, 1)
+				So(mockedDataStore.SetDatasetLinksFrozenCalls()[0].ID, ShouldEqual, "123")
+				So(mockedDataStore.SetDatasetLinksFrozenCalls()[0].Frozen, ShouldBeTrue)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{Action: freezeDatasetLinksAction, Result: audit.Attempted, Params: common.Params{"caller_identity": "someone@ons.gov.uk", "dataset_id": "123"}},
+					auditortest.Expected{Action: freezeDatasetLinksAction, Result: audit.Successful, Params: common.Params{"dataset_id": "123"}},
+				)
+			})
+		})
+	})
+
+	Convey("When the dataset to freeze the links of does not exist then a not found response is returned", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			SetDatasetLinksFrozenFunc: func(id string, frozen bool) error {
+				return errs.ErrDatasetNotFound
+			},
+		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123/links/freeze", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+	})
+}
+
+func TestPublishVersionWithFrozenLinks(t *testing.T) {
+	t.Parallel()
+	publishVersion := func(linksFrozen bool) *storetest.StorerMock {
+		mockedDataStore := &storetest.StorerMock{
+			CheckEditionExistsFunc: func(string, string, string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					ID: "789",
+					Links: &models.VersionLinks{
+						Dataset: &models.LinkObject{ID: "123", HRef: "http://localhost:22000/datasets/123"},
+						Edition: &models.LinkObject{ID: "2017", HRef: "http://localhost:22000/datasets/123/editions/2017"},
+						Self:    &models.LinkObject{HRef: "http://localhost:22000/instances/789"},
+						Version: &models.LinkObject{ID: "2", HRef: "http://localhost:22000/datasets/123/editions/2017/versions/2"},
+					},
+					ReleaseDate: "2017-12-12",
+					State:       models.EditionConfirmedState,
+				}, nil
+			},
+			UpdateVersionFunc: func(string, *models.Version) error {
+				return nil
+			},
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				latestVersion := func() *models.DatasetLinks {
+					return &models.DatasetLinks{LatestVersion: &models.LinkObject{ID: "1", HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1"}}
+				}
+				return &models.DatasetUpdate{
+					ID:          "123",
+					Next:        &models.Dataset{Links: latestVersion()},
+					Current:     &models.Dataset{Links: latestVersion()},
+					LinksFrozen: linksFrozen,
+				}, nil
+			},
+			UpsertDatasetFunc: func(string, *models.DatasetUpdate) error {
+				return nil
+			},
+			GetEditionFunc: func(string, string, string) (*models.EditionUpdate, error) {
+				return &models.EditionUpdate{
+					ID: "123",
+					Current: &models.Edition{
+						Edition: "2017",
+						State:   models.PublishedState,
+						Links:   &models.EditionUpdateLinks{LatestVersion: &models.LinkObject{ID: "1"}},
+					},
+					Next: &models.Edition{
+						Edition: "2017",
+						State:   models.EditionConfirmedState,
+						Links:   &models.EditionUpdateLinks{LatestVersion: &models.LinkObject{ID: "3"}},
+					},
+				}, nil
+			},
+			UpsertEditionFunc: func(string, string, *models.EditionUpdate) error {
+				return nil
+			},
+			SetInstanceIsPublishedFunc: func(ctx context.Context, instanceID string) error {
+				return nil
+			},
+		}
+
+		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/2", bytes.NewBufferString(versionPublishedPayload))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+		So(w.Code, ShouldEqual, http.StatusOK)
+
+		return mockedDataStore
+	}
+
+	Convey("Given a dataset whose links are frozen", t, func() {
+		Convey("When a version is published", func() {
+			mockedDataStore := publishVersion(true)
+
+			Convey("Then the latest version links of the edition are not updated", func() {
+				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 1)
+				editionDoc := mockedDataStore.UpsertEditionCalls()[0].EditionDoc
+				So(editionDoc.Current.State, ShouldEqual, models.PublishedState)
+				So(editionDoc.Current.Links.LatestVersion.ID, ShouldEqual, "1")
+				So(editionDoc.Next.Links.LatestVersion.ID, ShouldEqual, "3")
+			})
+
+			Convey("Then the dataset is published still linking to its previous latest version", func() {
+				So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 1)
+				datasetDoc := mockedDataStore.UpsertDatasetCalls()[0].DatasetDoc
+				So(datasetDoc.Current.State, ShouldEqual, models.PublishedState)
+				So(datasetDoc.Current.Links.LatestVersion.ID, ShouldEqual, "1")
+			})
+		})
+	})
+
+	Convey("Given a dataset whose links are not frozen", t, func() {
+		Convey("When a version is published", func() {
+			mockedDataStore := publishVersion(false)
+
+			Convey("Then the edition links point at the published version", func() {
+				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 1)
+				editionDoc := mockedDataStore.UpsertEditionCalls()[0].EditionDoc
+				So(editionDoc.Current.Links.LatestVersion.ID, ShouldEqual, "2")
+			})
+
+			Convey("Then the dataset links to the published version", func() {
+				So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 1)
+				datasetDoc := mockedDataStore.UpsertDatasetCalls()[0].DatasetDoc
+				So(datasetDoc.Current.Links.LatestVersion.ID, ShouldEqual, "2")
+			})
+		})
+	})
+}
+
+func TestThawDatasetLinks(t *testing.T) {
+	t.Parallel()
+	Convey("Given a dataset with frozen links and three editions, one without any versions", t, func() {
+		editionDoc := func(edition string) *models.EditionUpdate {
+			return &models.EditionUpdate{
+				Current: &models.Edition{
+					Edition: edition,
+					Links: &models.EditionUpdateLinks{
+						Dataset:       &models.LinkObject{ID: "123"},
+						LatestVersion: &models.LinkObject{ID: "1"},
+					},
+				},
+				Next: &models.Edition{
+					Edition: edition,
+					Links: &models.EditionUpdateLinks{
+						Dataset:       &models.LinkObject{ID: "123"},
+						LatestVersion: &models.LinkObject{ID: "4"},
+					},
+				},
+			}
+		}
+
+		mockedDataStore := &storetest.StorerMock{
+			GetEditionsFunc: func(id string, state string) (*models.EditionUpdateResults, error) {
+				return &models.EditionUpdateResults{Items: []*models.EditionUpdate{editionDoc("2017"), editionDoc("2018"), editionDoc("2019")}}, nil
+			},
+			GetLatestVersionFunc: func(datasetID, editionID, state string) (*models.Version, error) {
+				if editionID == "2018" {
+					return nil, errs.ErrVersionNotFound
+				}
+				if state == models.PublishedState {
+					// the version of 2017 was published after the version of 2019
+					published := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+					if editionID == "2017" {
+						published = published.AddDate(1, 0, 0)
+					}
+					return &models.Version{
+						ID:          "published-" + editionID,
+						Version:     3,
+						LastUpdated: published,
+						Links: &models.VersionLinks{
+							Version: &models.LinkObject{ID: "3", HRef: "http://localhost:22000/datasets/123/editions/" + editionID + "/versions/3"},
+						},
+					}, nil
+				}
+				return &models.Version{Version: 4}, nil
+			},
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{
+					ID:          "123",
+					Current:     &models.Dataset{Links: &models.DatasetLinks{LatestVersion: &models.LinkObject{ID: "1"}}},
+					Next:        &models.Dataset{Links: &models.DatasetLinks{LatestVersion: &models.LinkObject{ID: "1"}}},
+					LinksFrozen: true,
+				}, nil
+			},
+			UpsertDatasetFunc: func(string, *models.DatasetUpdate) error {
+				return nil
+			},
+			UpsertEditionFunc: func(datasetID, edition string, editionDoc *models.EditionUpdate) error {
+				return nil
+			},
+			SetDatasetLinksFrozenFunc: func(id string, frozen bool) error {
+				return nil
+			},
+		}
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When its links are thawed", func() {
+			r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123/links/thaw", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the links of each edition with versions are refreshed once and the dataset is thawed", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, `{"2017":{"current":{"before":"1","after":"3"},"next":{"before":"4","after":"4"}},"2019":{"current":{"before":"1","after":"3"},"next":{"before":"4","after":"4"}}}`)
+
+				So(len(mockedDataStore.WithTransactionCalls()), ShouldEqual, 1)
+				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 2)
+				So(mockedDataStore.UpsertEditionCalls()[0].Edition, ShouldEqual, "2017")
+				So(mockedDataStore.UpsertEditionCalls()[1].Edition, ShouldEqual, "2019")
+
+				So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 1)
+				datasetDoc := mockedDataStore.UpsertDatasetCalls()[0].DatasetDoc
+				So(datasetDoc.Current.Links.LatestVersion.HRef, ShouldEqual, "http://localhost:22000/datasets/123/editions/2017/versions/3")
+				So(datasetDoc.Next.Links.LatestVersion.HRef, ShouldEqual, "http://localhost:22000/datasets/123/editions/2017/versions/3")
+				So(len(mockedDataStore.SetDatasetLinksFrozenCalls()), ShouldEqual, 1)
+				So(mockedDataStore.SetDatasetLinksFrozenCalls()[0].Frozen, ShouldBeFalse)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{Action: thawDatasetLinksAction, Result: audit.Attempted, Params: common.Params{"caller_identity": "someone@ons.gov.uk", "dataset_id": "123"}},
+					auditortest.Expected{Action: thawDatasetLinksAction, Result: audit.Successful, Params: common.Params{"dataset_id": "123"}},
+				)
+			})
+		})
+	})
+}
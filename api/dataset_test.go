@@ -38,7 +38,7 @@ const (
 var (
 	datasetPayload = `{"contacts":[{"email":"testing@hotmail.com","name":"John Cox","telephone":"01623 456789"}],"description":"census","links":{"access_rights":{"href":"http://ons.gov.uk/accessrights"}},"title":"CensusEthnicity","theme":"population","periodicity":"yearly","state":"completed","next_release":"2016-04-04","publisher":{"name":"The office of national statistics","type":"government department","url":"https://www.ons.gov.uk/"}}`
 
-	urlBuilder         = url.NewBuilder("localhost:20000")
+	urlBuilder         = url.NewBuilder("localhost:20000", "http://localhost:22000")
 	genericAuditParams = common.Params{"caller_identity": callerIdentity, "dataset_id": "123-456"}
 	mu                 sync.Mutex
 )
@@ -49,6 +49,13 @@ func getAuthorisationHandlerMock() *mocks.AuthHandlerMock {
 	}
 }
 
+// publishNotifierMock returns a notifier which accepts every notification, so publishing tests need not stub it
+func publishNotifierMock() *mocks.PublishNotifierMock {
+	return &mocks.PublishNotifierMock{
+		NotifyPublishedFunc: func(context.Context, models.VersionPublishedEvent) {},
+	}
+}
+
 // GetAPIWithMocks also used in other tests, so exported
 func GetAPIWithMocks(mockedDataStore store.Storer, mockedGeneratedDownloads DownloadsGenerator, auditMock Auditor, datasetPermissions AuthHandler, permissions AuthHandler) *DatasetAPI {
 	mu.Lock()
@@ -59,7 +66,14 @@ func GetAPIWithMocks(mockedDataStore store.Storer, mockedGeneratedDownloads Down
 	cfg.DatasetAPIURL = host
 	cfg.EnablePrivateEnpoints = true
 
-	return NewDatasetAPI(*cfg, mux.NewRouter(), store.DataStore{Backend: mockedDataStore}, urlBuilder, mockedGeneratedDownloads, auditMock, datasetPermissions, permissions)
+	// run transactions against the mock itself unless a test has set its own behaviour
+	if mock, ok := mockedDataStore.(*storetest.StorerMock); ok && mock.WithTransactionFunc == nil {
+		mock.WithTransactionFunc = func(fn func(store.Storer) error) error {
+			return fn(mock)
+		}
+	}
+
+	return NewDatasetAPI(*cfg, mux.NewRouter(), store.DataStore{Backend: mockedDataStore}, urlBuilder, mockedGeneratedDownloads, &mocks.HierarchyBuildTriggerMock{}, publishNotifierMock(), nil, auditMock, datasetPermissions, permissions)
 }
 
 func createRequestWithAuth(method, URL string, body io.Reader) (*http.Request, error) {
@@ -76,7 +90,7 @@ func TestGetDatasetsReturnsOK(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			GetDatasetsFunc: func() ([]models.DatasetUpdate, error) {
+			GetDatasetsFunc: func(string) ([]models.DatasetUpdate, error) {
 				return []models.DatasetUpdate{}, nil
 			},
 		}
@@ -100,13 +114,232 @@ func TestGetDatasetsReturnsOK(t *testing.T) {
 	})
 }
 
+func TestGetDatasetsTypeFilter(t *testing.T) {
+	t.Parallel()
+	Convey("Given filterable and static datasets", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetsFunc: func(datasetType string) ([]models.DatasetUpdate, error) {
+				datasets := []models.DatasetUpdate{
+					{ID: "cpih01", Current: &models.Dataset{Type: models.FilterableDatasetType}},
+					{ID: "census-tables", Current: &models.Dataset{Type: models.StaticDatasetType}},
+				}
+
+				results := []models.DatasetUpdate{}
+				for _, dataset := range datasets {
+					if datasetType == "" || dataset.Current.Type == datasetType {
+						results = append(results, dataset)
+					}
+				}
+				return results, nil
+			},
+		}
+
+		Convey("When the datasets are filtered by type then only datasets of that type are returned", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets?type=static", nil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(mockedDataStore.GetDatasetsCalls()[0].DatasetType, ShouldEqual, models.StaticDatasetType)
+
+			var results models.DatasetResults
+			So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+			So(results.Items, ShouldHaveLength, 1)
+			So(results.Items[0].ID, ShouldEqual, "census-tables")
+		})
+
+		Convey("When no type is given then datasets of every type are returned", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets", nil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(mockedDataStore.GetDatasetsCalls()[0].DatasetType, ShouldBeEmpty)
+
+			var results models.DatasetResults
+			So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+			So(results.Items, ShouldHaveLength, 2)
+		})
+
+		Convey("When the type is not recognised then a bad request status is returned", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets?type=interactive", nil)
+			w := httptest.NewRecorder()
+
+			auditMock := auditortest.New()
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetTypeInvalid.Error())
+			So(len(mockedDataStore.GetDatasetsCalls()), ShouldEqual, 0)
+
+			auditMock.AssertRecordCalls(
+				auditortest.Expected{Action: getDatasetsAction, Result: audit.Attempted, Params: nil},
+				auditortest.Expected{Action: getDatasetsAction, Result: audit.Unsuccessful, Params: nil},
+			)
+		})
+	})
+}
+
+func TestGetDatasetsModifiedSince(t *testing.T) {
+	t.Parallel()
+	since := time.Date(2018, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+	Convey("Given datasets updated before and after a point in time", t, func() {
+		datasets := []models.DatasetUpdate{
+			{ID: "old", Current: &models.Dataset{LastUpdated: since.Add(-time.Hour)}},
+			{ID: "recent", Current: &models.Dataset{LastUpdated: since.Add(time.Hour)}},
+		}
+
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetsModifiedSinceFunc: func(t time.Time, datasetType string, publishedOnly bool, offset, limit int) (*models.DatasetUpdatePage, error) {
+				page := &models.DatasetUpdatePage{Items: []models.DatasetUpdate{}, Offset: offset, Limit: limit}
+				for _, dataset := range datasets {
+					if !dataset.Current.LastUpdated.Before(t) {
+						page.Items = append(page.Items, dataset)
+					}
+				}
+				page.Count = len(page.Items)
+				page.TotalCount = len(page.Items)
+				return page, nil
+			},
+		}
+
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When the datasets modified since that time are requested", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets?modified_since=2018-06-01T09:00:00Z&offset=0&limit=10", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then only the recently updated datasets are returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.GetDatasetsCalls()), ShouldEqual, 0)
+				So(mockedDataStore.GetDatasetsModifiedSinceCalls(), ShouldHaveLength, 1)
+				So(mockedDataStore.GetDatasetsModifiedSinceCalls()[0].T.Equal(since), ShouldBeTrue)
+				So(mockedDataStore.GetDatasetsModifiedSinceCalls()[0].PublishedOnly, ShouldBeTrue)
+				So(mockedDataStore.GetDatasetsModifiedSinceCalls()[0].Limit, ShouldEqual, 10)
+
+				var page models.DatasetPage
+				So(json.Unmarshal(w.Body.Bytes(), &page), ShouldBeNil)
+				So(page.Count, ShouldEqual, 1)
+				So(page.TotalCount, ShouldEqual, 1)
+				So(page.Items[0].ID, ShouldEqual, "recent")
+
+				auditMock.AssertRecordCalls(
+					auditortest.Expected{Action: getDatasetsAction, Result: audit.Attempted, Params: nil},
+					auditortest.Expected{Action: getDatasetsAction, Result: audit.Successful, Params: nil},
+				)
+			})
+		})
+
+		Convey("When the datasets of a type modified since that time are requested", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets?modified_since=2018-06-01T09:00:00Z&type=static", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the type is passed to the datastore along with the time", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockedDataStore.GetDatasetsModifiedSinceCalls(), ShouldHaveLength, 1)
+				So(mockedDataStore.GetDatasetsModifiedSinceCalls()[0].DatasetType, ShouldEqual, models.StaticDatasetType)
+				So(mockedDataStore.GetDatasetsModifiedSinceCalls()[0].T.Equal(since), ShouldBeTrue)
+			})
+		})
+
+		Convey("When an invalid type is given along with the time", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets?modified_since=2018-06-01T09:00:00Z&type=interactive", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then a bad request is returned without querying the datastore", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetTypeInvalid.Error())
+				So(mockedDataStore.GetDatasetsModifiedSinceCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When an authorised caller requests the datasets modified since that time", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets?modified_since=2018-06-01T09:00:00Z", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then unpublished datasets are included in the page and its total count", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockedDataStore.GetDatasetsModifiedSinceCalls(), ShouldHaveLength, 1)
+				So(mockedDataStore.GetDatasetsModifiedSinceCalls()[0].PublishedOnly, ShouldBeFalse)
+			})
+		})
+
+		Convey("When no limit is given then the default page size is used", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets?modified_since=2018-06-01T09:00:00Z", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(mockedDataStore.GetDatasetsModifiedSinceCalls()[0].Offset, ShouldEqual, 0)
+			So(mockedDataStore.GetDatasetsModifiedSinceCalls()[0].Limit, ShouldEqual, api.defaultPageSize)
+		})
+
+		Convey("When the limit is above the maximum page size then it is reduced to the maximum", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets?modified_since=2018-06-01T09:00:00Z&limit=100000", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(mockedDataStore.GetDatasetsModifiedSinceCalls()[0].Limit, ShouldEqual, api.maxPageSize)
+		})
+	})
+
+	Convey("Given a request for datasets modified since an invalid timestamp", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets?modified_since=yesterday", nil)
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{}
+		auditMock := auditortest.New()
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		Convey("Then a bad request is returned without querying the datastore", func() {
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrInvalidModifiedSince.Error())
+			So(mockedDataStore.GetDatasetsModifiedSinceCalls(), ShouldHaveLength, 0)
+
+			auditMock.AssertRecordCalls(
+				auditortest.Expected{Action: getDatasetsAction, Result: audit.Attempted, Params: nil},
+				auditortest.Expected{Action: getDatasetsAction, Result: audit.Unsuccessful, Params: nil},
+			)
+		})
+	})
+
+	Convey("Given a request for datasets modified since a time with an invalid offset", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets?modified_since=2018-06-01T09:00:00Z&offset=-1", nil)
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{}
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		Convey("Then a bad request is returned without querying the datastore", func() {
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrInvalidPaginationParameter.Error())
+			So(mockedDataStore.GetDatasetsModifiedSinceCalls(), ShouldHaveLength, 0)
+		})
+	})
+}
+
 func TestGetDatasetsReturnsErrorIfAuditAttemptFails(t *testing.T) {
 	t.Parallel()
 	Convey("When auditing get datasets attempt returns an error an internal server error is returned", t, func() {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			GetDatasetsFunc: func() ([]models.DatasetUpdate, error) {
+			GetDatasetsFunc: func(string) ([]models.DatasetUpdate, error) {
 				return nil, errs.ErrInternalServer
 			},
 		}
@@ -139,7 +372,7 @@ func TestGetDatasetsReturnsErrorIfAuditAttemptFails(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			GetDatasetsFunc: func() ([]models.DatasetUpdate, error) {
+			GetDatasetsFunc: func(string) ([]models.DatasetUpdate, error) {
 				return nil, errs.ErrInternalServer
 			},
 		}
@@ -175,7 +408,7 @@ func TestGetDatasetsReturnsError(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			GetDatasetsFunc: func() ([]models.DatasetUpdate, error) {
+			GetDatasetsFunc: func(string) ([]models.DatasetUpdate, error) {
 				return nil, errs.ErrInternalServer
 			},
 		}
@@ -204,7 +437,7 @@ func TestGetDatasetsAuditSuccessfulError(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			GetDatasetsFunc: func() ([]models.DatasetUpdate, error) {
+			GetDatasetsFunc: func(string) ([]models.DatasetUpdate, error) {
 				return []models.DatasetUpdate{}, nil
 			},
 		}
@@ -227,6 +460,72 @@ func TestGetDatasetsAuditSuccessfulError(t *testing.T) {
 	})
 }
 
+func TestGetThemes(t *testing.T) {
+	t.Parallel()
+	Convey("When the themes of published datasets are requested they are returned in alphabetical order", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/themes", nil)
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDistinctThemesFunc: func() ([]string, error) {
+				return []string{"population", "economy", "health"}, nil
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldEqual, `{"items":["economy","health","population"]}`)
+		So(len(mockedDataStore.GetDistinctThemesCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
+		So(permissions.Required.Calls, ShouldEqual, 1)
+
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: getThemesAction, Result: audit.Attempted, Params: nil},
+			auditortest.Expected{Action: getThemesAction, Result: audit.Successful, Params: nil},
+		)
+	})
+
+	Convey("When no dataset has a theme an empty list is returned", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/themes", nil)
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDistinctThemesFunc: func() ([]string, error) {
+				return []string{}, nil
+			},
+		}
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldEqual, `{"items":[]}`)
+	})
+
+	Convey("When the themes cannot be read an internal server error is returned", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/themes", nil)
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDistinctThemesFunc: func() ([]string, error) {
+				return nil, errs.ErrInternalServer
+			},
+		}
+
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		assertInternalServerErr(w)
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: getThemesAction, Result: audit.Attempted, Params: nil},
+			auditortest.Expected{Action: getThemesAction, Result: audit.Unsuccessful, Params: nil},
+		)
+	})
+}
+
 func TestGetDatasetReturnsOK(t *testing.T) {
 	auditParams := common.Params{"dataset_id": "123-456"}
 
@@ -286,6 +585,416 @@ func TestGetDatasetReturnsOK(t *testing.T) {
 	})
 }
 
+func TestGetDatasetSanitisesPublicResponse(t *testing.T) {
+	t.Parallel()
+	Convey("Given a dataset whose current and next sub documents belong to a collection", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(id string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{
+					ID:      "123",
+					Current: &models.Dataset{ID: "123", CollectionID: "collection-1"},
+					Next:    &models.Dataset{ID: "123", CollectionID: "collection-2"},
+				}, nil
+			},
+		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When a public caller gets the dataset", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the collection id is omitted", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldNotContainSubstring, "collection_id")
+			})
+		})
+
+		Convey("When an authorised caller gets the dataset", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123-456", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the collection id is included", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var dataset models.DatasetUpdate
+				So(json.Unmarshal(w.Body.Bytes(), &dataset), ShouldBeNil)
+				So(dataset.Next.CollectionID, ShouldEqual, "collection-2")
+			})
+		})
+	})
+}
+
+func TestGetDatasetRedactsPublicContacts(t *testing.T) {
+	t.Parallel()
+	Convey("Given contact details are configured not to be public", t, func() {
+		contacts := []models.ContactDetails{{Name: "Jane Doe", Email: "jane@ons.gov.uk", Telephone: "01234 567890"}}
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(id string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{
+					ID:      "123",
+					Current: &models.Dataset{ID: "123", Contacts: contacts},
+					Next:    &models.Dataset{ID: "123", Contacts: contacts},
+				}, nil
+			},
+			GetDatasetsFunc: func(string) ([]models.DatasetUpdate, error) {
+				return []models.DatasetUpdate{{ID: "123", Current: &models.Dataset{ID: "123", Contacts: contacts}}}, nil
+			},
+		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.redactPublicContacts = true
+
+		Convey("When a public caller gets the dataset then only the names of its contacts are returned", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			var dataset models.Dataset
+			So(json.Unmarshal(w.Body.Bytes(), &dataset), ShouldBeNil)
+			So(dataset.Contacts, ShouldResemble, []models.ContactDetails{{Name: "Jane Doe"}})
+		})
+
+		Convey("When a public caller lists the datasets then only the names of their contacts are returned", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			var results models.DatasetResults
+			So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+			So(len(results.Items), ShouldEqual, 1)
+			So(results.Items[0].Contacts, ShouldResemble, []models.ContactDetails{{Name: "Jane Doe"}})
+		})
+
+		Convey("When an authorised caller gets the dataset then the full contact details are returned", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			var dataset models.DatasetUpdate
+			So(json.Unmarshal(w.Body.Bytes(), &dataset), ShouldBeNil)
+			So(dataset.Current.Contacts, ShouldResemble, contacts)
+			So(dataset.Next.Contacts, ShouldResemble, contacts)
+		})
+	})
+}
+
+func TestGetDeprecatedDataset(t *testing.T) {
+	t.Parallel()
+	Convey("Given a published dataset which has been deprecated", t, func() {
+		deprecated := true
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(id string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{ID: "123", Current: &models.Dataset{
+					ID:                "123",
+					Deprecated:        &deprecated,
+					DeprecationNotice: "replaced by cpih02",
+					SunsetDate:        "2030-01-31",
+				}}, nil
+			},
+		}
+
+		Convey("When the dataset is requested then the deprecation and sunset headers are set", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123", nil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Deprecation"), ShouldEqual, "true")
+			So(w.Header().Get("Sunset"), ShouldEqual, "Thu, 31 Jan 2030 00:00:00 GMT")
+			So(w.Body.String(), ShouldContainSubstring, `"deprecation_notice":"replaced by cpih02"`)
+		})
+	})
+
+	Convey("When a dataset which is not deprecated is requested then no deprecation headers are set", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123", nil)
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(id string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{ID: "123", Current: &models.Dataset{ID: "123"}}, nil
+			},
+		}
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Deprecation"), ShouldBeEmpty)
+		So(w.Header().Get("Sunset"), ShouldBeEmpty)
+	})
+}
+
+func TestGetDatasetLinksRepresentation(t *testing.T) {
+	t.Parallel()
+	Convey("Given a published dataset with metadata and links", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(id string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{ID: "123", Current: &models.Dataset{
+					ID:          "123",
+					Title:       "CPI",
+					Description: "consumer prices index",
+					Links: &models.DatasetLinks{
+						LatestVersion: &models.LinkObject{ID: "1", HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1"},
+					},
+				}}, nil
+			},
+		}
+
+		Convey("When the links representation is requested then only the dataset links are returned", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123?representation=links", nil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldNotContainSubstring, `"title"`)
+			So(w.Body.String(), ShouldNotContainSubstring, `"description"`)
+
+			var links models.DatasetLinks
+			So(json.Unmarshal(w.Body.Bytes(), &links), ShouldBeNil)
+			So(links.Self.HRef, ShouldEqual, "http://localhost:22000/datasets/123")
+			So(links.Editions.HRef, ShouldEqual, "http://localhost:22000/datasets/123/editions")
+			So(links.LatestVersion.HRef, ShouldEqual, "http://localhost:22000/datasets/123/editions/2017/versions/1")
+		})
+
+		Convey("When an unknown representation is requested then a bad request is returned", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123?representation=summary", nil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrInvalidRepresentation.Error())
+			So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestGetDatasetWithLatestVersion(t *testing.T) {
+	t.Parallel()
+	Convey("Given a dataset with a published version", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(id string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{ID: "123", Current: &models.Dataset{
+					ID:    "123",
+					Links: &models.DatasetLinks{LatestVersion: &models.LinkObject{ID: "2", HRef: "http://localhost:22000/datasets/123/editions/2017/versions/2"}},
+				}}, nil
+			},
+			GetLatestVersionFunc: func(datasetID, editionID, state string) (*models.Version, error) {
+				return &models.Version{ID: "789", Edition: "2017", Version: 2, State: models.PublishedState, ReleaseDate: "2017-12-12"}, nil
+			},
+		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When the dataset is requested with its latest version", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123?include=latest_version", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the latest published version of the linked edition is embedded in the dataset", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.GetLatestVersionCalls()), ShouldEqual, 1)
+				So(mockedDataStore.GetLatestVersionCalls()[0].DatasetID, ShouldEqual, "123")
+				So(mockedDataStore.GetLatestVersionCalls()[0].EditionID, ShouldEqual, "2017")
+				So(mockedDataStore.GetLatestVersionCalls()[0].State, ShouldEqual, models.PublishedState)
+
+				var dataset models.Dataset
+				So(json.Unmarshal(w.Body.Bytes(), &dataset), ShouldBeNil)
+				So(dataset.ID, ShouldEqual, "123")
+				So(dataset.LatestVersion, ShouldNotBeNil)
+				So(dataset.LatestVersion.Version, ShouldEqual, 2)
+				So(dataset.LatestVersion.ReleaseDate, ShouldEqual, "2017-12-12")
+			})
+		})
+
+		Convey("When the dataset is requested without including its latest version", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the latest version is not read or embedded", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.GetLatestVersionCalls()), ShouldEqual, 0)
+				So(w.Body.String(), ShouldNotContainSubstring, `"latest_version":{"id"`)
+			})
+		})
+	})
+
+	Convey("Given a dataset without a published version", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(id string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{ID: "123", Next: &models.Dataset{ID: "123", State: models.CreatedState}}, nil
+			},
+		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When an authorised caller requests the dataset with its latest version", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123?include=latest_version", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the dataset is returned without a latest version", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.GetLatestVersionCalls()), ShouldEqual, 0)
+
+				var dataset models.DatasetUpdate
+				So(json.Unmarshal(w.Body.Bytes(), &dataset), ShouldBeNil)
+				So(dataset.Current, ShouldBeNil)
+				So(dataset.Next.LatestVersion, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("When the edition linked to has no published version then the latest version is omitted", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(id string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{ID: "123", Current: &models.Dataset{
+					ID:    "123",
+					Links: &models.DatasetLinks{LatestVersion: &models.LinkObject{ID: "1", HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1"}},
+				}}, nil
+			},
+			GetLatestVersionFunc: func(datasetID, editionID, state string) (*models.Version, error) {
+				return nil, errs.ErrVersionNotFound
+			},
+		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123?include=latest_version", nil)
+		w := httptest.NewRecorder()
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(len(mockedDataStore.GetLatestVersionCalls()), ShouldEqual, 1)
+
+		var dataset models.Dataset
+		So(json.Unmarshal(w.Body.Bytes(), &dataset), ShouldBeNil)
+		So(dataset.LatestVersion, ShouldBeNil)
+	})
+
+	Convey("When an unknown include is requested then a bad request is returned", t, func() {
+		mockedDataStore := &storetest.StorerMock{}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123?include=editions", nil)
+		w := httptest.NewRecorder()
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrInvalidInclude.Error())
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
+	})
+}
+
+func TestGetDatasetTranslations(t *testing.T) {
+	t.Parallel()
+	Convey("Given a published dataset with a Welsh translation", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(id string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{ID: "123", Current: &models.Dataset{
+					ID:          "123",
+					Title:       "Consumer Prices Index",
+					Description: "A measure of inflation",
+					Translations: models.Translations{
+						"cy": {Title: "Mynegai Prisiau Defnyddwyr", Description: "Mesur o chwyddiant"},
+					},
+				}}, nil
+			},
+		}
+
+		Convey("When the dataset is requested in Welsh then the Welsh text is returned", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123", nil)
+			r.Header.Set("Accept-Language", "cy-GB, en;q=0.8")
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Content-Language"), ShouldEqual, "cy")
+			So(w.Header().Get("Vary"), ShouldEqual, "Accept-Language")
+
+			var dataset models.Dataset
+			So(json.Unmarshal(w.Body.Bytes(), &dataset), ShouldBeNil)
+			So(dataset.Title, ShouldEqual, "Mynegai Prisiau Defnyddwyr")
+			So(dataset.Description, ShouldEqual, "Mesur o chwyddiant")
+		})
+
+		Convey("When the dataset is requested in a language without a translation then the default text is returned", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123", nil)
+			r.Header.Set("Accept-Language", "fr")
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Content-Language"), ShouldBeEmpty)
+
+			var dataset models.Dataset
+			So(json.Unmarshal(w.Body.Bytes(), &dataset), ShouldBeNil)
+			So(dataset.Title, ShouldEqual, "Consumer Prices Index")
+			So(dataset.Description, ShouldEqual, "A measure of inflation")
+		})
+
+		Convey("When an authorised caller requests the dataset in Welsh then the stored document is returned untranslated", func() {
+			mockedDataStore.GetDatasetFunc = func(id string) (*models.DatasetUpdate, error) {
+				translations := models.Translations{"cy": {Title: "Mynegai Prisiau Defnyddwyr"}}
+				return &models.DatasetUpdate{
+					ID:      "123",
+					Current: &models.Dataset{ID: "123", Title: "Consumer Prices Index", Translations: translations},
+					Next:    &models.Dataset{ID: "123", Title: "Consumer Prices Index", Translations: translations},
+				}, nil
+			}
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123", nil)
+			So(err, ShouldBeNil)
+			r.Header.Set("Accept-Language", "cy")
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Content-Language"), ShouldBeEmpty)
+
+			var dataset models.DatasetUpdate
+			So(json.Unmarshal(w.Body.Bytes(), &dataset), ShouldBeNil)
+			So(dataset.Current.Title, ShouldEqual, "Consumer Prices Index")
+			So(dataset.Next.Title, ShouldEqual, "Consumer Prices Index")
+		})
+	})
+}
+
+func TestPutDatasetTranslations(t *testing.T) {
+	t.Parallel()
+	Convey("When a dataset is updated with a translation not keyed by a language tag then a bad request is returned", t, func() {
+		b := `{"title":"CPI","translations":{"Welsh":{"title":"Mynegai"}}}`
+		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetTranslationInvalid.Error())
+		So(len(mockedDataStore.UpdateDatasetCalls()), ShouldEqual, 0)
+	})
+}
+
 func TestGetDatasetReturnsError(t *testing.T) {
 	auditParams := common.Params{"dataset_id": "123-456"}
 
@@ -486,6 +1195,122 @@ func TestGetDatasetAuditingErrors(t *testing.T) {
 	})
 }
 
+func TestGetDatasetTreeReturnsOK(t *testing.T) {
+	t.Parallel()
+	Convey("Given a dataset with two editions", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{ID: "123", Next: &models.Dataset{State: models.AssociatedState, Title: "CPIH"}}, nil
+			},
+			GetEditionsFunc: func(ID string, state string) (*models.EditionUpdateResults, error) {
+				return &models.EditionUpdateResults{Items: []*models.EditionUpdate{
+					{Next: &models.Edition{Edition: "time-series", State: models.EditionConfirmedState}},
+					{Next: &models.Edition{Edition: "2017", State: models.PublishedState}},
+				}}, nil
+			},
+			GetVersionsFunc: func(datasetID, editionID, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
+				if editionID == "2017" {
+					return &models.VersionResults{Items: []models.Version{
+						{ID: "b", Version: 2, State: models.AssociatedState, ReleaseDate: "2018-01-01"},
+						{ID: "a", Version: 1, State: models.PublishedState, ReleaseDate: "2017-01-01"},
+					}}, nil
+				}
+				return &models.VersionResults{Items: []models.Version{
+					{ID: "c", Version: 1, State: models.EditionConfirmedState},
+				}}, nil
+			},
+		}
+
+		Convey("When the dataset tree is requested", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123/tree", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			datasetPermissions := getAuthorisationHandlerMock()
+			permissions := getAuthorisationHandlerMock()
+			auditMock := auditortest.New()
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, datasetPermissions, permissions)
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the editions are returned, each with its versions", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+				So(permissions.Required.Calls, ShouldEqual, 0)
+				So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 2)
+
+				var tree models.DatasetTree
+				So(json.Unmarshal(w.Body.Bytes(), &tree), ShouldBeNil)
+				So(tree, ShouldResemble, models.DatasetTree{
+					ID:    "123",
+					State: models.AssociatedState,
+					Title: "CPIH",
+					Editions: []models.EditionTree{
+						{Edition: "2017", State: models.PublishedState, Versions: []models.VersionSummary{
+							{ID: "a", Version: 1, State: models.PublishedState, ReleaseDate: "2017-01-01"},
+							{ID: "b", Version: 2, State: models.AssociatedState, ReleaseDate: "2018-01-01"},
+						}},
+						{Edition: "time-series", State: models.EditionConfirmedState, Versions: []models.VersionSummary{
+							{ID: "c", Version: 1, State: models.EditionConfirmedState},
+						}},
+					},
+				})
+
+				auditMock.AssertRecordCalls(
+					auditortest.Expected{Action: getDatasetTreeAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "dataset_id": "123"}},
+					auditortest.Expected{Action: getDatasetTreeAction, Result: audit.Successful, Params: common.Params{"dataset_id": "123"}},
+				)
+			})
+		})
+
+		Convey("When the dataset tree is requested with a cap smaller than the number of versions", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123/tree", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.datasetTreeMaxVersions = 2
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the versions beyond the cap are omitted and the tree is marked as truncated", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var tree models.DatasetTree
+				So(json.Unmarshal(w.Body.Bytes(), &tree), ShouldBeNil)
+				So(tree.Truncated, ShouldBeTrue)
+				So(len(tree.Editions), ShouldEqual, 2)
+				So(len(tree.Editions[0].Versions)+len(tree.Editions[1].Versions), ShouldEqual, 2)
+			})
+		})
+	})
+}
+
+func TestGetDatasetTreeReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("When the dataset does not exist then a not found response is returned", t, func() {
+		r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123/tree", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return nil, errs.ErrDatasetNotFound
+			},
+		}
+
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(len(mockedDataStore.GetEditionsCalls()), ShouldEqual, 0)
+
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: getDatasetTreeAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "dataset_id": "123"}},
+			auditortest.Expected{Action: getDatasetTreeAction, Result: audit.Unsuccessful, Params: common.Params{"dataset_id": "123"}},
+		)
+	})
+}
+
 func TestPostDatasetsReturnsCreated(t *testing.T) {
 	t.Parallel()
 	Convey("A successful request to post dataset returns 200 OK response", t, func() {
@@ -516,6 +1341,7 @@ func TestPostDatasetsReturnsCreated(t *testing.T) {
 		So(permissions.Required.Calls, ShouldEqual, 0)
 		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 2)
+		So(mockedDataStore.UpsertDatasetCalls()[1].DatasetDoc.Next.Type, ShouldEqual, models.FilterableDatasetType)
 
 		auditMock.AssertRecordCalls(
 			auditortest.Expected{Action: addDatasetAction, Result: audit.Attempted, Params: common.Params{"caller_identity": "someone@ons.gov.uk", "dataset_id": "123"}},
@@ -531,6 +1357,27 @@ func TestPostDatasetsReturnsCreated(t *testing.T) {
 
 func TestPostDatasetReturnsError(t *testing.T) {
 	t.Parallel()
+	Convey("When the request is nested deeper than the configured maximum a bad request status is returned", t, func() {
+		b := `{"title":"CPI","contacts":[{"name":"someone"}]}`
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return nil, errs.ErrDatasetNotFound
+			},
+		}
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.jsonLimits = models.JSONLimits{MaxDepth: 2}
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrAddUpdateDatasetBadRequest.Error())
+		So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 0)
+	})
+
 	Convey("When the request contain malformed json a bad request status is returned", t, func() {
 		var b string
 		b = "{"
@@ -571,6 +1418,35 @@ func TestPostDatasetReturnsError(t *testing.T) {
 		})
 	})
 
+	Convey("When the request contains an invalid dataset type a bad request status is returned", t, func() {
+		b := `{"title":"CPI","type":"interactive"}`
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return nil, errs.ErrDatasetNotFound
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetTypeInvalid.Error())
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 0)
+
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: addDatasetAction, Result: audit.Attempted, Params: common.Params{"caller_identity": "someone@ons.gov.uk", "dataset_id": "123"}},
+			auditortest.Expected{Action: addDatasetAction, Result: audit.Unsuccessful, Params: common.Params{"dataset_id": "123"}},
+		)
+	})
+
 	Convey("When the api cannot connect to datastore return an internal server error", t, func() {
 		var b string
 		b = datasetPayload
@@ -677,7 +1553,7 @@ func TestPostDatasetReturnsError(t *testing.T) {
 		So(w.Code, ShouldEqual, http.StatusForbidden)
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(w.Body.String(), ShouldResemble, "forbidden - dataset already exists\n")
+		So(w.Body.String(), ShouldResemble, `{"code":"dataset_already_exists","message":"forbidden - dataset already exists"}`+"\n")
 		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 0)
 
@@ -935,6 +1811,220 @@ func TestPutDatasetReturnsSuccessfully(t *testing.T) {
 	})
 }
 
+func TestPutDatasetContacts(t *testing.T) {
+	t.Parallel()
+	Convey("Given a dataset", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Next: &models.Dataset{State: models.CreatedState}}, nil
+			},
+			UpdateDatasetFunc: func(string, *models.Dataset, string) error {
+				return nil
+			},
+		}
+
+		Convey("When the dataset is updated with two contacts then both contacts are stored", func() {
+			b := `{"contacts":[{"name":"natalie jarrod","email":"njarrod@test.com"},{"email":"cpi@test.com","telephone":"01658 234567"}]}`
+			r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(len(mockedDataStore.UpdateDatasetCalls()), ShouldEqual, 1)
+			So(mockedDataStore.UpdateDatasetCalls()[0].Dataset.Contacts, ShouldResemble, []models.ContactDetails{
+				{Name: "natalie jarrod", Email: "njarrod@test.com"},
+				{Email: "cpi@test.com", Telephone: "01658 234567"},
+			})
+		})
+
+		Convey("When a contact has neither a name nor an email then a bad request status is returned", func() {
+			b := `{"contacts":[{"name":"natalie jarrod"},{"telephone":"01658 234567"}]}`
+			r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetContactInvalid.Error())
+			So(len(mockedDataStore.UpdateDatasetCalls()), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestPutDatasetPublisherAndNationalStatistic(t *testing.T) {
+	t.Parallel()
+	Convey("Given a dataset", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Next: &models.Dataset{State: models.CreatedState}}, nil
+			},
+			UpdateDatasetFunc: func(string, *models.Dataset, string) error {
+				return nil
+			},
+		}
+
+		putDataset := func(b string) *httptest.ResponseRecorder {
+			r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+			return w
+		}
+
+		Convey("When the dataset is updated with a known publisher type and not a national statistic then both are stored", func() {
+			w := putDataset(`{"publisher":{"name":"ONS","type":"government"},"national_statistic":false}`)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(len(mockedDataStore.UpdateDatasetCalls()), ShouldEqual, 1)
+			dataset := mockedDataStore.UpdateDatasetCalls()[0].Dataset
+			So(dataset.Publisher, ShouldResemble, &models.Publisher{Name: "ONS", Type: models.PublisherTypeGovernment})
+			So(dataset.NationalStatistic, ShouldNotBeNil)
+			So(*dataset.NationalStatistic, ShouldBeFalse)
+		})
+
+		Convey("When the publisher type is unknown then a bad request status is returned", func() {
+			w := putDataset(`{"publisher":{"name":"ONS","type":"charity"}}`)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetPublisherTypeInvalid.Error())
+			So(len(mockedDataStore.UpdateDatasetCalls()), ShouldEqual, 0)
+		})
+
+		Convey("When the uri is malformed then a bad request status is returned", func() {
+			w := putDataset(`{"uri":"localhost:22000/datasets/123/breadcrumbs"}`)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetURIInvalid.Error())
+			So(len(mockedDataStore.UpdateDatasetCalls()), ShouldEqual, 0)
+		})
+
+		Convey("When national_statistic is not a boolean then a bad request status is returned", func() {
+			w := putDataset(`{"national_statistic":"yes"}`)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(len(mockedDataStore.UpdateDatasetCalls()), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestPutDatasetSurveyAndSubtopics(t *testing.T) {
+	t.Parallel()
+	Convey("Given a dataset with a survey and subtopics", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Next: &models.Dataset{State: models.CreatedState}}, nil
+			},
+			UpdateDatasetFunc: func(string, *models.Dataset, string) error {
+				return nil
+			},
+		}
+
+		Convey("When the dataset is updated then the survey and subtopics are stored", func() {
+			b := `{"survey":"census","subtopics":["ethnicity","national identity"]}`
+			r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(len(mockedDataStore.UpdateDatasetCalls()), ShouldEqual, 1)
+			So(mockedDataStore.UpdateDatasetCalls()[0].Dataset.Survey, ShouldEqual, "census")
+			So(mockedDataStore.UpdateDatasetCalls()[0].Dataset.Subtopics, ShouldResemble, []string{"ethnicity", "national identity"})
+		})
+
+		Convey("When the survey is longer than the configured maximum then a bad request status is returned", func() {
+			b := `{"survey":"census"}`
+			r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			auditMock := auditortest.New()
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.datasetFieldMaxLength = 5
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetSurveyInvalid.Error())
+			So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
+			So(len(mockedDataStore.UpdateDatasetCalls()), ShouldEqual, 0)
+
+			auditMock.AssertRecordCalls(
+				auditortest.Expected{Action: updateDatasetAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "dataset_id": "123"}},
+				auditortest.Expected{Action: updateDatasetAction, Result: audit.Unsuccessful, Params: common.Params{"dataset_id": "123"}},
+			)
+		})
+
+		Convey("When the dataset is deprecated with a sunset date in the past then a bad request status is returned", func() {
+			b := `{"deprecated":true,"sunset_date":"2018-01-01"}`
+			r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetSunsetDateInvalid.Error())
+			So(len(mockedDataStore.UpdateDatasetCalls()), ShouldEqual, 0)
+		})
+
+		Convey("When a deprecated dataset whose sunset date has passed", func() {
+			deprecated := true
+			mockedDataStore.GetDatasetFunc = func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Next: &models.Dataset{State: models.CreatedState, Deprecated: &deprecated, SunsetDate: "2018-01-01"}}, nil
+			}
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+			Convey("is no longer deprecated then the update is stored", func() {
+				r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123", bytes.NewBufferString(`{"deprecated":false}`))
+				So(err, ShouldBeNil)
+				w := httptest.NewRecorder()
+				api.Router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.UpdateDatasetCalls()), ShouldEqual, 1)
+				So(mockedDataStore.UpdateDatasetCalls()[0].Dataset.Deprecated, ShouldNotBeNil)
+				So(*mockedDataStore.UpdateDatasetCalls()[0].Dataset.Deprecated, ShouldBeFalse)
+			})
+
+			Convey("is given a sunset date which has also passed then a bad request status is returned", func() {
+				r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123", bytes.NewBufferString(`{"sunset_date":"2018-02-01"}`))
+				So(err, ShouldBeNil)
+				w := httptest.NewRecorder()
+				api.Router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetSunsetDateInvalid.Error())
+				So(len(mockedDataStore.UpdateDatasetCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When there are more subtopics than the configured maximum then a bad request status is returned", func() {
+			b := `{"subtopics":["ethnicity","national identity"]}`
+			r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.datasetMaxSubtopics = 1
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetSubtopicsInvalid.Error())
+			So(len(mockedDataStore.UpdateDatasetCalls()), ShouldEqual, 0)
+		})
+	})
+}
+
 func TestPutDatasetReturnsError(t *testing.T) {
 
 	t.Parallel()
@@ -1365,6 +2455,143 @@ func TestPutDatasetAuditErrors(t *testing.T) {
 	})
 }
 
+func TestPatchDatasetReturnsSuccessfully(t *testing.T) {
+	t.Parallel()
+	Convey("A successful request to patch a dataset clearing the description returns 200 OK response", t, func() {
+		b := `{"description": null, "title": "CPI"}`
+		r, err := createRequestWithAuth("PATCH", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Next: &models.Dataset{State: models.CreatedState, Description: "census"}}, nil
+			},
+			PatchDatasetFunc: func(string, *models.DatasetPatch, string) error {
+				return nil
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+		So(permissions.Required.Calls, ShouldEqual, 0)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.PatchDatasetCalls()), ShouldEqual, 1)
+
+		patchCall := mockedDataStore.PatchDatasetCalls()[0]
+		So(patchCall.ID, ShouldEqual, "123")
+		So(patchCall.Patch.Clear, ShouldResemble, []string{"description"})
+		So(patchCall.Patch.Dataset.Title, ShouldEqual, "CPI")
+		So(patchCall.CurrentState, ShouldEqual, models.CreatedState)
+
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: patchDatasetAction, Result: audit.Attempted, Params: common.Params{"caller_identity": "someone@ons.gov.uk", "dataset_id": "123"}},
+			auditortest.Expected{Action: patchDatasetAction, Result: audit.Successful, Params: common.Params{"dataset_id": "123"}},
+		)
+
+		Convey("then the request body has been drained", func() {
+			_, err = r.Body.Read(make([]byte, 1))
+			So(err, ShouldEqual, io.EOF)
+		})
+	})
+}
+
+func TestPatchDatasetReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("When the patch attempts to clear a field which cannot be removed a bad request status is returned", t, func() {
+		b := `{"state": null}`
+		r, err := createRequestWithAuth("PATCH", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetPatchFieldInvalid.Error())
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.PatchDatasetCalls()), ShouldEqual, 0)
+
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: patchDatasetAction, Result: audit.Attempted, Params: common.Params{"caller_identity": "someone@ons.gov.uk", "dataset_id": "123"}},
+			auditortest.Expected{Action: patchDatasetAction, Result: audit.Unsuccessful, Params: common.Params{"dataset_id": "123"}},
+		)
+	})
+
+	Convey("When the dataset does not exist a not found status is returned", t, func() {
+		b := `{"description": null}`
+		r, err := createRequestWithAuth("PATCH", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return nil, errs.ErrDatasetNotFound
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.PatchDatasetCalls()), ShouldEqual, 0)
+
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: patchDatasetAction, Result: audit.Attempted, Params: common.Params{"caller_identity": "someone@ons.gov.uk", "dataset_id": "123"}},
+			auditortest.Expected{Action: patchDatasetAction, Result: audit.Unsuccessful, Params: common.Params{"dataset_id": "123"}},
+		)
+	})
+
+	Convey("When the patch publishes the dataset and changes another field a bad request status is returned", t, func() {
+		b := `{"state": "published", "title": "CPI"}`
+		r, err := createRequestWithAuth("PATCH", "http://localhost:22000/datasets/123", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Next: &models.Dataset{State: models.AssociatedState}}, nil
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetPatchPublishMixed.Error())
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.PatchDatasetCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 0)
+
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: patchDatasetAction, Result: audit.Attempted, Params: common.Params{"caller_identity": "someone@ons.gov.uk", "dataset_id": "123"}},
+			auditortest.Expected{Action: patchDatasetAction, Result: audit.Unsuccessful, Params: common.Params{"dataset_id": "123"}},
+		)
+	})
+}
+
 func TestDeleteDatasetReturnsSuccessfully(t *testing.T) {
 	t.Parallel()
 	Convey("A successful request to delete dataset returns 200 OK response", t, func() {
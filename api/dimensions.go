@@ -89,7 +89,7 @@ func (api *DatasetAPI) getDimensions(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(b)
 	if err != nil {
 		log.ErrorCtx(ctx, errors.WithMessage(err, "error writing bytes to response"), logData)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		errs.WriteError(w, err, http.StatusInternalServerError)
 	}
 
 	log.InfoCtx(ctx, "getDimensions endpoint: request successful", logData)
@@ -215,7 +215,7 @@ func (api *DatasetAPI) getDimensionOptions(w http.ResponseWriter, r *http.Reques
 	_, err = w.Write(b)
 	if err != nil {
 		log.ErrorCtx(ctx, errors.WithMessage(err, "error writing bytes to response"), logData)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		errs.WriteError(w, err, http.StatusInternalServerError)
 	}
 
 	log.DebugCtx(ctx, "get dimension options", logData)
@@ -238,5 +238,5 @@ func handleDimensionsErr(ctx context.Context, w http.ResponseWriter, err error,
 
 	data["response_status"] = status
 	log.ErrorCtx(ctx, errors.WithMessage(err, "request unsuccessful"), data)
-	http.Error(w, response.Error(), status)
+	errs.WriteError(w, response, status)
 }
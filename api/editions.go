@@ -1,18 +1,30 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"sort"
+	"strings"
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
 	"github.com/ONSdigital/dp-dataset-api/models"
 	"github.com/ONSdigital/go-ns/audit"
 	"github.com/ONSdigital/go-ns/common"
 	"github.com/ONSdigital/go-ns/log"
+	"github.com/ONSdigital/go-ns/request"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 )
 
+// defaultEditionsSort lists the newest editions first
+const defaultEditionsSort = "-release_date"
+
+var editionSortKeys = map[string]bool{
+	"edition":      true,
+	"release_date": true,
+}
+
 func (api *DatasetAPI) getEditions(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	vars := mux.Vars(r)
@@ -21,7 +33,7 @@ func (api *DatasetAPI) getEditions(w http.ResponseWriter, r *http.Request) {
 	auditParams := common.Params{"dataset_id": datasetID}
 
 	if auditErr := api.auditor.Record(r.Context(), getEditionsAction, audit.Attempted, auditParams); auditErr != nil {
-		http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
+		errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
 		return
 	}
 
@@ -35,6 +47,17 @@ func (api *DatasetAPI) getEditions(w http.ResponseWriter, r *http.Request) {
 
 		logData["state"] = state
 
+		sortBy := r.URL.Query().Get("sort")
+		if sortBy == "" {
+			sortBy = defaultEditionsSort
+		}
+		logData["sort"] = sortBy
+
+		if !editionSortKeys[strings.TrimPrefix(sortBy, "-")] {
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrEditionsSortInvalid, "getEditions endpoint: invalid sort parameter"), logData)
+			return nil, errs.ErrEditionsSortInvalid
+		}
+
 		if err := api.dataStore.Backend.CheckDatasetExists(datasetID, state); err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "getEditions endpoint: unable to find dataset"), logData)
 			return nil, err
@@ -46,6 +69,20 @@ func (api *DatasetAPI) getEditions(w http.ResponseWriter, r *http.Request) {
 			return nil, err
 		}
 
+		summaries, err := api.dataStore.Backend.SummariseEditionVersions(datasetID, state)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "getEditions endpoint: unable to summarise the versions of editions"), logData)
+			return nil, err
+		}
+
+		versions := make(map[string]models.EditionVersions, len(summaries))
+		for _, summary := range summaries {
+			versions[summary.Edition] = summary
+		}
+
+		sortEditions(state, sortBy, results.Items, versions)
+		countEditionVersions(authorised, results.Items, versions)
+
 		var editionBytes []byte
 
 		if authorised {
@@ -81,15 +118,17 @@ func (api *DatasetAPI) getEditions(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if err == errs.ErrDatasetNotFound || err == errs.ErrEditionNotFound {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			errs.WriteError(w, err, http.StatusNotFound)
+		} else if err == errs.ErrEditionsSortInvalid {
+			errs.WriteError(w, err, http.StatusBadRequest)
 		} else {
-			http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
+			errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
 		}
 		return
 	}
 
 	if auditErr := api.auditor.Record(r.Context(), getEditionsAction, audit.Successful, auditParams); auditErr != nil {
-		http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
+		errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
 		return
 	}
 
@@ -97,7 +136,7 @@ func (api *DatasetAPI) getEditions(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(b)
 	if err != nil {
 		log.ErrorCtx(ctx, errors.WithMessage(err, "getEditions endpoint: failed writing bytes to response"), logData)
-		http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
+		errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
 	}
 	log.InfoCtx(ctx, "getEditions endpoint: request successful", logData)
 }
@@ -111,7 +150,7 @@ func (api *DatasetAPI) getEdition(w http.ResponseWriter, r *http.Request) {
 	logData := audit.ToLogData(auditParams)
 
 	if auditErr := api.auditor.Record(r.Context(), getEditionAction, audit.Attempted, auditParams); auditErr != nil {
-		http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
+		errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
 		return
 	}
 
@@ -163,15 +202,15 @@ func (api *DatasetAPI) getEdition(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if err == errs.ErrDatasetNotFound || err == errs.ErrEditionNotFound {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			errs.WriteError(w, err, http.StatusNotFound)
 		} else {
-			http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
+			errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
 		}
 		return
 	}
 
 	if auditErr := api.auditor.Record(ctx, getEditionAction, audit.Successful, auditParams); auditErr != nil {
-		http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
+		errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
 		return
 	}
 
@@ -179,8 +218,144 @@ func (api *DatasetAPI) getEdition(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(b)
 	if err != nil {
 		log.ErrorCtx(ctx, errors.WithMessage(err, "getEdition endpoint: failed to write byte to response"), logData)
-		http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
+		errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
 		return
 	}
 	log.InfoCtx(ctx, "getEdition endpoint: request successful", logData)
 }
+
+// refreshEditionLinks recomputes the latest version links of an edition from its versions, correcting links
+// left pointing at the wrong version, and responds with the version ids linked to before and after the refresh
+func (api *DatasetAPI) refreshEditionLinks(w http.ResponseWriter, r *http.Request) {
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	datasetID := vars["dataset_id"]
+	edition := vars["edition"]
+	auditParams := common.Params{"dataset_id": datasetID, "edition": edition}
+	logData := audit.ToLogData(auditParams)
+
+	b, err := func() ([]byte, error) {
+		editionDoc, err := api.dataStore.Backend.GetEdition(datasetID, edition, "")
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "refreshEditionLinks endpoint: unable to find edition"), logData)
+			return nil, err
+		}
+
+		refresh, _, err := api.refreshLinks(ctx, datasetID, edition, editionDoc)
+		if err != nil {
+			return nil, err
+		}
+
+		logData["refresh"] = refresh
+		return json.Marshal(refresh)
+	}()
+
+	if err != nil {
+		if auditErr := api.auditor.Record(ctx, refreshEditionLinksAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleVersionAPIErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := api.auditor.Record(ctx, refreshEditionLinksAction, audit.Successful, auditParams); auditErr != nil {
+		handleVersionAPIErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	setJSONContentType(w)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "refreshEditionLinks endpoint: failed to write bytes to response"), logData)
+		errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+	log.InfoCtx(ctx, "refreshEditionLinks endpoint: request successful", logData)
+}
+
+// refreshLinks points the latest version links of an edition at its latest versions and stores the edition, the
+// latest published version of the edition is returned along with the refresh when it has one
+func (api *DatasetAPI) refreshLinks(ctx context.Context, datasetID, edition string, editionDoc *models.EditionUpdate) (*models.EditionLinksRefresh, *models.Version, error) {
+	logData := log.Data{"dataset_id": datasetID, "edition": edition}
+
+	latest, err := api.dataStore.Backend.GetLatestVersion(datasetID, edition, "")
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "refresh edition links: unable to find latest version"), logData)
+		return nil, nil, err
+	}
+
+	latestPublished, err := api.dataStore.Backend.GetLatestVersion(datasetID, edition, models.PublishedState)
+	if err != nil {
+		if err != errs.ErrVersionNotFound {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "refresh edition links: unable to find latest published version"), logData)
+			return nil, nil, err
+		}
+		latestPublished = nil
+	}
+
+	refresh, err := editionDoc.RefreshLinks(api.host, latest, latestPublished)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "refresh edition links: unable to refresh edition links"), logData)
+		return nil, nil, err
+	}
+
+	if err = api.dataStore.Backend.UpsertEdition(datasetID, edition, editionDoc); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "refresh edition links: failed to update edition"), logData)
+		return nil, nil, err
+	}
+
+	return refresh, latestPublished, nil
+}
+
+// sortEditions orders editions by the edition name or by the release date of their latest
+// version, a sort key prefixed with '-' orders the editions in descending order. Editions
+// are compared using the sub document visible to the caller, so unauthenticated callers
+// are ordered by the latest published version, the versions having been summarised in the
+// state visible to the caller.
+func sortEditions(state, sortBy string, editions []*models.EditionUpdate, versions map[string]models.EditionVersions) {
+	descending := strings.HasPrefix(sortBy, "-")
+	sortKey := strings.TrimPrefix(sortBy, "-")
+
+	keys := make(map[*models.EditionUpdate]string, len(editions))
+	for _, edition := range editions {
+		doc := edition.Current
+		if state == "" && edition.Next != nil {
+			doc = edition.Next
+		}
+
+		if doc == nil {
+			continue
+		}
+
+		if sortKey == "edition" {
+			keys[edition] = doc.Edition
+			continue
+		}
+
+		keys[edition] = versions[strings.ToLower(doc.Edition)].LatestReleaseDate
+	}
+
+	sort.SliceStable(editions, func(i, j int) bool {
+		if descending {
+			return keys[editions[i]] > keys[editions[j]]
+		}
+		return keys[editions[i]] < keys[editions[j]]
+	})
+}
+
+// countEditionVersions sets the number of versions of each edition listed. The published edition is given the count
+// of its published versions and, for authorised callers, the next edition the count of all of its versions.
+func countEditionVersions(authorised bool, editions []*models.EditionUpdate, versions map[string]models.EditionVersions) {
+	for _, edition := range editions {
+		if edition.Current != nil {
+			count := versions[strings.ToLower(edition.Current.Edition)].PublishedCount
+			edition.Current.VersionCount = &count
+		}
+
+		if authorised && edition.Next != nil {
+			count := versions[strings.ToLower(edition.Next.Edition)].Count
+			edition.Next.VersionCount = &count
+		}
+	}
+}
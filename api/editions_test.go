@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -34,6 +35,9 @@ func TestGetEditionsReturnsOK(t *testing.T) {
 			GetEditionsFunc: func(id string, state string) (*models.EditionUpdateResults, error) {
 				return &models.EditionUpdateResults{}, nil
 			},
+			SummariseEditionVersionsFunc: func(datasetID, state string) ([]models.EditionVersions, error) {
+				return []models.EditionVersions{}, nil
+			},
 		}
 
 		auditor := auditortest.New()
@@ -56,6 +60,181 @@ func TestGetEditionsReturnsOK(t *testing.T) {
 	})
 }
 
+func TestGetEditionsSorted(t *testing.T) {
+	t.Parallel()
+
+	releaseDates := map[string]string{
+		"2016": "2018-01-01T00:00:00.000Z",
+		"2017": "2017-06-01T00:00:00.000Z",
+		"2018": "2019-03-01T00:00:00.000Z",
+	}
+
+	getEditionsInOrder := func(url string) []string {
+		r := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			CheckDatasetExistsFunc: func(datasetID, state string) error {
+				return nil
+			},
+			GetEditionsFunc: func(id string, state string) (*models.EditionUpdateResults, error) {
+				var items []*models.EditionUpdate
+				for _, edition := range []string{"2017", "2018", "2016"} {
+					items = append(items, &models.EditionUpdate{
+						Current: &models.Edition{
+							Edition: edition,
+							Links:   &models.EditionUpdateLinks{LatestVersion: &models.LinkObject{ID: "1"}},
+						},
+					})
+				}
+				return &models.EditionUpdateResults{Items: items}, nil
+			},
+			SummariseEditionVersionsFunc: func(datasetID, state string) ([]models.EditionVersions, error) {
+				var summaries []models.EditionVersions
+				for edition, releaseDate := range releaseDates {
+					summaries = append(summaries, models.EditionVersions{Edition: edition, Count: 1, PublishedCount: 1, LatestReleaseDate: releaseDate})
+				}
+				return summaries, nil
+			},
+		}
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(len(mockedDataStore.SummariseEditionVersionsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 0)
+
+		var results models.EditionResults
+		So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+
+		var editions []string
+		for _, item := range results.Items {
+			editions = append(editions, item.Edition)
+		}
+		return editions
+	}
+
+	Convey("When no sort is given editions are returned newest release first", t, func() {
+		So(getEditionsInOrder("http://localhost:22000/datasets/123-456/editions"), ShouldResemble, []string{"2018", "2016", "2017"})
+	})
+
+	Convey("When sorted by ascending release date editions are returned oldest release first", t, func() {
+		So(getEditionsInOrder("http://localhost:22000/datasets/123-456/editions?sort=release_date"), ShouldResemble, []string{"2017", "2016", "2018"})
+	})
+
+	Convey("When sorted by descending edition name editions are returned in reverse name order", t, func() {
+		So(getEditionsInOrder("http://localhost:22000/datasets/123-456/editions?sort=-edition"), ShouldResemble, []string{"2018", "2017", "2016"})
+	})
+
+	Convey("When sorted by ascending edition name editions are returned in name order", t, func() {
+		So(getEditionsInOrder("http://localhost:22000/datasets/123-456/editions?sort=edition"), ShouldResemble, []string{"2016", "2017", "2018"})
+	})
+
+	Convey("When the sort parameter is invalid a bad request is returned", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions?sort=title", nil)
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{}
+
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrEditionsSortInvalid.Error())
+		So(len(mockedDataStore.GetEditionsCalls()), ShouldEqual, 0)
+
+		auditParams := common.Params{"dataset_id": "123-456"}
+		auditor.AssertRecordCalls(
+			auditortest.Expected{Action: getEditionsAction, Result: audit.Attempted, Params: auditParams},
+			auditortest.Expected{Action: getEditionsAction, Result: audit.Unsuccessful, Params: auditParams},
+		)
+	})
+}
+
+func TestGetEditionsVersionCounts(t *testing.T) {
+	t.Parallel()
+	Convey("Given a dataset with an edition of two published versions and five versions in total", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			CheckDatasetExistsFunc: func(datasetID, state string) error {
+				return nil
+			},
+			GetEditionsFunc: func(id string, state string) (*models.EditionUpdateResults, error) {
+				edition := func() *models.Edition {
+					return &models.Edition{Edition: "2017", Links: &models.EditionUpdateLinks{LatestVersion: &models.LinkObject{ID: "1"}}}
+				}
+				return &models.EditionUpdateResults{Items: []*models.EditionUpdate{{Current: edition(), Next: edition()}}}, nil
+			},
+			SummariseEditionVersionsFunc: func(datasetID, state string) ([]models.EditionVersions, error) {
+				if state == models.PublishedState {
+					return []models.EditionVersions{{Edition: "2017", Count: 2, PublishedCount: 2, LatestReleaseDate: "2017-12-12"}}, nil
+				}
+				return []models.EditionVersions{{Edition: "2017", Count: 5, PublishedCount: 2, LatestReleaseDate: "2017-12-12"}}, nil
+			},
+		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When the editions are requested by a public caller", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the edition has the count of its published versions", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var results models.EditionResults
+				So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+				So(results.Items, ShouldHaveLength, 1)
+				So(*results.Items[0].VersionCount, ShouldEqual, 2)
+
+				So(len(mockedDataStore.SummariseEditionVersionsCalls()), ShouldEqual, 1)
+				So(mockedDataStore.SummariseEditionVersionsCalls()[0].State, ShouldEqual, models.PublishedState)
+				So(len(mockedDataStore.CountVersionsCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the editions are requested by an authorised caller", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123-456/editions", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the next edition has the count of all its versions", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var results models.EditionUpdateResults
+				So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+				So(results.Items, ShouldHaveLength, 1)
+				So(*results.Items[0].Current.VersionCount, ShouldEqual, 2)
+				So(*results.Items[0].Next.VersionCount, ShouldEqual, 5)
+				So(len(mockedDataStore.CountVersionsCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("When an edition has no published versions a count of zero is returned", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions", nil)
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			CheckDatasetExistsFunc: func(datasetID, state string) error {
+				return nil
+			},
+			GetEditionsFunc: func(id string, state string) (*models.EditionUpdateResults, error) {
+				return &models.EditionUpdateResults{Items: []*models.EditionUpdate{{Current: &models.Edition{Edition: "2017"}}}}, nil
+			},
+			SummariseEditionVersionsFunc: func(datasetID, state string) ([]models.EditionVersions, error) {
+				return []models.EditionVersions{}, nil
+			},
+		}
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"version_count":0`)
+	})
+}
+
 func TestGetEditionsAuditingError(t *testing.T) {
 	auditParams := common.Params{"dataset_id": "123-456"}
 
@@ -70,6 +249,9 @@ func TestGetEditionsAuditingError(t *testing.T) {
 			GetEditionsFunc: func(id string, state string) (*models.EditionUpdateResults, error) {
 				return &models.EditionUpdateResults{}, nil
 			},
+			SummariseEditionVersionsFunc: func(datasetID, state string) ([]models.EditionVersions, error) {
+				return []models.EditionVersions{}, nil
+			},
 		}
 
 		datasetPermissions := getAuthorisationHandlerMock()
@@ -106,6 +288,9 @@ func TestGetEditionsAuditingError(t *testing.T) {
 			GetEditionsFunc: func(id string, state string) (*models.EditionUpdateResults, error) {
 				return &models.EditionUpdateResults{}, nil
 			},
+			SummariseEditionVersionsFunc: func(datasetID, state string) ([]models.EditionVersions, error) {
+				return []models.EditionVersions{}, nil
+			},
 		}
 
 		auditor := auditortest.NewErroring(getEditionsAction, audit.Successful)
@@ -586,3 +771,136 @@ func TestGetEditionAuditErrors(t *testing.T) {
 		)
 	})
 }
+
+func TestRefreshEditionLinksReturnsOK(t *testing.T) {
+	t.Parallel()
+	Convey("Given an edition whose latest version links are stale", t, func() {
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123-456/editions/2017/links/refresh", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetEditionFunc: func(id, editionID, state string) (*models.EditionUpdate, error) {
+				return &models.EditionUpdate{
+					ID: "789",
+					Current: &models.Edition{
+						Edition: "2017",
+						Links: &models.EditionUpdateLinks{
+							Dataset:       &models.LinkObject{ID: "123-456"},
+							LatestVersion: &models.LinkObject{ID: "1"},
+						},
+					},
+					Next: &models.Edition{
+						Edition: "2017",
+						Links: &models.EditionUpdateLinks{
+							Dataset:       &models.LinkObject{ID: "123-456"},
+							LatestVersion: &models.LinkObject{ID: "5"},
+						},
+					},
+				}, nil
+			},
+			GetLatestVersionFunc: func(datasetID, editionID, state string) (*models.Version, error) {
+				if state == models.PublishedState {
+					return &models.Version{Version: 2}, nil
+				}
+				return &models.Version{Version: 3}, nil
+			},
+			UpsertEditionFunc: func(datasetID, edition string, editionDoc *models.EditionUpdate) error {
+				return nil
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+
+		Convey("When the edition links are refreshed", func() {
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the links are corrected and the before and after versions are returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, `{"current":{"before":"1","after":"2"},"next":{"before":"5","after":"3"}}`)
+				So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+				So(permissions.Required.Calls, ShouldEqual, 0)
+
+				So(len(mockedDataStore.GetLatestVersionCalls()), ShouldEqual, 2)
+				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 1)
+				editionDoc := mockedDataStore.UpsertEditionCalls()[0].EditionDoc
+				So(editionDoc.Current.Links.LatestVersion, ShouldResemble, &models.LinkObject{ID: "2", HRef: "http://localhost:22000/datasets/123-456/editions/2017/versions/2"})
+				So(editionDoc.Next.Links.LatestVersion, ShouldResemble, &models.LinkObject{ID: "3", HRef: "http://localhost:22000/datasets/123-456/editions/2017/versions/3"})
+
+				auditParams := common.Params{"dataset_id": "123-456", "edition": "2017"}
+				auditor.AssertRecordCalls(
+					auditortest.Expected{Action: refreshEditionLinksAction, Result: audit.Attempted, Params: common.Params{"caller_identity": "someone@ons.gov.uk", "dataset_id": "123-456", "edition": "2017"}},
+					auditortest.Expected{Action: refreshEditionLinksAction, Result: audit.Successful, Params: auditParams},
+				)
+			})
+		})
+	})
+}
+
+func TestRefreshEditionLinksReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("When the edition to refresh the links of does not exist then a not found response is returned", t, func() {
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123-456/editions/2017/links/refresh", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetEditionFunc: func(id, editionID, state string) (*models.EditionUpdate, error) {
+				return nil, errs.ErrEditionNotFound
+			},
+		}
+
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrEditionNotFound.Error())
+		So(len(mockedDataStore.GetLatestVersionCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 0)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{Action: refreshEditionLinksAction, Result: audit.Attempted, Params: common.Params{"caller_identity": "someone@ons.gov.uk", "dataset_id": "123-456", "edition": "2017"}},
+			auditortest.Expected{Action: refreshEditionLinksAction, Result: audit.Unsuccessful, Params: common.Params{"dataset_id": "123-456", "edition": "2017"}},
+		)
+	})
+
+	Convey("When no version of the edition has been published then only the unpublished link is refreshed", t, func() {
+		r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123-456/editions/2017/links/refresh", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetEditionFunc: func(id, editionID, state string) (*models.EditionUpdate, error) {
+				return &models.EditionUpdate{
+					Next: &models.Edition{
+						Edition: "2017",
+						Links: &models.EditionUpdateLinks{
+							Dataset:       &models.LinkObject{ID: "123-456"},
+							LatestVersion: &models.LinkObject{ID: "2"},
+						},
+					},
+				}, nil
+			},
+			GetLatestVersionFunc: func(datasetID, editionID, state string) (*models.Version, error) {
+				if state == models.PublishedState {
+					return nil, errs.ErrVersionNotFound
+				}
+				return &models.Version{Version: 1}, nil
+			},
+			UpsertEditionFunc: func(datasetID, edition string, editionDoc *models.EditionUpdate) error {
+				return nil
+			},
+		}
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldEqual, `{"next":{"before":"2","after":"1"}}`)
+		So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 1)
+	})
+}
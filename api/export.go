@@ -0,0 +1,182 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// exportDataset streams a dataset with all of its editions and versions, along with the metadata of each version,
+// as a single models.DatasetExport document. Each edition is written as soon as its versions have been read so the
+// export is never held in memory, at most datasetExportMaxVersions versions are included before the export is
+// marked as truncated and the editions not yet written are left out. The dataset and its editions are read before the response is started, an error after that
+// point ends the response early leaving an incomplete document.
+func (api *DatasetAPI) exportDataset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	datasetID := vars["dataset_id"]
+	auditParams := common.Params{"dataset_id": datasetID}
+	logData := audit.ToLogData(auditParams)
+
+	datasetDoc, editions, err := api.getDatasetForExport(datasetID, logData)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "exportDataset endpoint: failed to read dataset for export"), logData)
+		if auditErr := api.auditor.Record(ctx, exportDatasetAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleDatasetAPIErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := api.auditor.Record(ctx, exportDatasetAction, audit.Successful, auditParams); auditErr != nil {
+		handleDatasetAPIErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	setJSONContentType(w)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+datasetID+`.json"`)
+
+	if err = api.writeDatasetExport(w, datasetDoc, editions, logData); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "exportDataset endpoint: export ended early"), logData)
+		return
+	}
+	log.InfoCtx(ctx, "exportDataset endpoint: request successful", logData)
+}
+
+// getDatasetForExport reads the dataset to export along with its editions, ordered by edition
+func (api *DatasetAPI) getDatasetForExport(datasetID string, logData log.Data) (*models.DatasetUpdate, []*models.EditionUpdate, error) {
+	datasetDoc, err := api.dataStore.Backend.GetDataset(datasetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if datasetDoc.Next == nil && datasetDoc.Current == nil {
+		return nil, nil, errs.ErrDatasetNotFound
+	}
+
+	editions, err := api.dataStore.Backend.GetEditions(datasetID, "")
+	if err != nil {
+		if err != errs.ErrEditionNotFound {
+			return nil, nil, err
+		}
+		editions = &models.EditionUpdateResults{}
+	}
+
+	items := []*models.EditionUpdate{}
+	for _, edition := range editions.Items {
+		if edition.Next != nil {
+			items = append(items, edition)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Next.Edition < items[j].Next.Edition })
+	logData["editions"] = len(items)
+
+	return datasetDoc, items, nil
+}
+
+// writeDatasetExport writes the export document, reading the versions of each edition as it is written
+func (api *DatasetAPI) writeDatasetExport(w io.Writer, datasetDoc *models.DatasetUpdate, editions []*models.EditionUpdate, logData log.Data) error {
+	dataset := datasetDoc.Next
+	if dataset == nil {
+		dataset = datasetDoc.Current
+	}
+	dataset.ID = datasetDoc.ID
+
+	e := &exportWriter{w: w, encoder: json.NewEncoder(w)}
+	flusher, _ := w.(http.Flusher)
+
+	e.raw(`{"dataset":`)
+	e.value(datasetDoc)
+	e.raw(`,"editions":[`)
+
+	remaining := api.datasetExportMaxVersions
+	truncated := false
+
+	for i, edition := range editions {
+		if remaining <= 0 {
+			// the cap has been reached, so the versions of the remaining editions are not read
+			truncated = true
+			break
+		}
+
+		versions, err := api.dataStore.Backend.GetVersions(dataset.ID, edition.Next.Edition, "", nil)
+		if err != nil && err != errs.ErrVersionNotFound {
+			logData["edition"] = edition.Next.Edition
+			return errors.WithMessage(err, "dataStore.Backend.GetVersions returned an error")
+		}
+
+		var items []models.Version
+		if versions != nil {
+			items = versions.Items
+			sort.Slice(items, func(i, j int) bool { return items[i].Version < items[j].Version })
+		}
+
+		if len(items) > remaining {
+			items = items[:remaining]
+			truncated = true
+		}
+		remaining -= len(items)
+
+		if i > 0 {
+			e.raw(",")
+		}
+		e.raw(`{"edition":`)
+		e.value(edition)
+		e.raw(`,"versions":[`)
+
+		for j := range items {
+			if j > 0 {
+				e.raw(",")
+			}
+			e.value(models.VersionExport{
+				Version:  &items[j],
+				Metadata: models.CreateMetaDataDoc(dataset, &items[j], api.urlBuilder, false),
+			})
+		}
+		e.raw("]}")
+
+		if e.err != nil {
+			return e.err
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	e.raw(`],"truncated":`)
+	e.value(truncated)
+	e.raw("}")
+
+	logData["truncated"] = truncated
+	return e.err
+}
+
+// exportWriter writes the parts of a streamed JSON document, once a write has failed the remaining writes are
+// skipped and the error is kept to be returned once the document is finished
+type exportWriter struct {
+	w       io.Writer
+	encoder *json.Encoder
+	err     error
+}
+
+func (e *exportWriter) raw(s string) {
+	if e.err == nil {
+		_, e.err = io.WriteString(e.w, s)
+	}
+}
+
+func (e *exportWriter) value(v interface{}) {
+	if e.err == nil {
+		e.err = e.encoder.Encode(v)
+	}
+}
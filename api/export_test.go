@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	storetest "github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func exportVersion(id string, version int, edition string) models.Version {
+	return models.Version{
+		ID:          id,
+		Version:     version,
+		Edition:     edition,
+		State:       models.PublishedState,
+		ReleaseDate: "2017-01-01",
+		Links: &models.VersionLinks{
+			Edition: &models.LinkObject{ID: edition},
+			Version: &models.LinkObject{HRef: host + "/datasets/123/editions/" + edition + "/versions/" + id, ID: id},
+		},
+	}
+}
+
+func TestExportDataset(t *testing.T) {
+	t.Parallel()
+	Convey("Given a dataset with two editions", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{ID: "123", Next: &models.Dataset{State: models.PublishedState, Title: "CPIH", Description: "consumer prices"}}, nil
+			},
+			GetEditionsFunc: func(ID string, state string) (*models.EditionUpdateResults, error) {
+				return &models.EditionUpdateResults{Items: []*models.EditionUpdate{
+					{ID: "e2", Next: &models.Edition{Edition: "time-series", State: models.EditionConfirmedState}},
+					{ID: "e1", Next: &models.Edition{Edition: "2017", State: models.PublishedState}},
+				}}, nil
+			},
+			GetVersionsFunc: func(datasetID, editionID, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
+				if editionID == "2017" {
+					return &models.VersionResults{Items: []models.Version{
+						exportVersion("b", 2, "2017"),
+						exportVersion("a", 1, "2017"),
+					}}, nil
+				}
+				return nil, errs.ErrVersionNotFound
+			},
+		}
+
+		Convey("When the dataset is exported", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123/export", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			datasetPermissions := getAuthorisationHandlerMock()
+			permissions := getAuthorisationHandlerMock()
+			auditMock := auditortest.New()
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, datasetPermissions, permissions)
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the export contains the dataset with each edition and the metadata of each version", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get("Content-Disposition"), ShouldEqual, `attachment; filename="123.json"`)
+				So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+				So(permissions.Required.Calls, ShouldEqual, 0)
+
+				var export models.DatasetExport
+				So(json.Unmarshal(w.Body.Bytes(), &export), ShouldBeNil)
+				So(export.Truncated, ShouldBeFalse)
+				So(export.Dataset.ID, ShouldEqual, "123")
+				So(export.Dataset.Next.Title, ShouldEqual, "CPIH")
+
+				So(export.Editions, ShouldHaveLength, 2)
+				So(export.Editions[0].Edition.Next.Edition, ShouldEqual, "2017")
+				So(export.Editions[0].Versions, ShouldHaveLength, 2)
+				So(export.Editions[0].Versions[0].Version.ID, ShouldEqual, "a")
+				So(export.Editions[0].Versions[0].Metadata.Description, ShouldEqual, "consumer prices")
+				So(export.Editions[0].Versions[0].Metadata.Links.Self.HRef, ShouldEqual, host+"/datasets/123/editions/2017/versions/a/metadata")
+				So(export.Editions[0].Versions[1].Version.ID, ShouldEqual, "b")
+				So(export.Editions[1].Edition.Next.Edition, ShouldEqual, "time-series")
+				So(export.Editions[1].Versions, ShouldBeEmpty)
+
+				auditMock.AssertRecordCalls(
+					auditortest.Expected{Action: exportDatasetAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "dataset_id": "123"}},
+					auditortest.Expected{Action: exportDatasetAction, Result: audit.Successful, Params: common.Params{"dataset_id": "123"}},
+				)
+			})
+		})
+
+		Convey("When the dataset is exported with a cap smaller than the number of versions", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123/export", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+			api.datasetExportMaxVersions = 1
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the versions beyond the cap are omitted and the export is marked as truncated", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var export models.DatasetExport
+				So(json.Unmarshal(w.Body.Bytes(), &export), ShouldBeNil)
+				So(export.Truncated, ShouldBeTrue)
+				So(export.Editions, ShouldHaveLength, 1)
+				So(export.Editions[0].Versions, ShouldHaveLength, 1)
+				So(export.Editions[0].Versions[0].Version.ID, ShouldEqual, "a")
+			})
+
+			Convey("Then the versions of the editions after the cap was reached are not read", func() {
+				So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 1)
+				So(mockedDataStore.GetVersionsCalls()[0].EditionID, ShouldEqual, "2017")
+			})
+		})
+	})
+
+	Convey("When a dataset which does not exist is exported then a not found response is returned", t, func() {
+		r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123/export", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return nil, errs.ErrDatasetNotFound
+			},
+		}
+
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(len(mockedDataStore.GetEditionsCalls()), ShouldEqual, 0)
+
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: exportDatasetAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "dataset_id": "123"}},
+			auditortest.Expected{Action: exportDatasetAction, Result: audit.Unsuccessful, Params: common.Params{"dataset_id": "123"}},
+		)
+	})
+}
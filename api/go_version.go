@@ -0,0 +1,9 @@
+//go:build !go1.20
+// +build !go1.20
+
+package api
+
+// The HTTP server configured by the api extends the write deadline of streamed responses through
+// http.NewResponseController, which was added in Go 1.20. Building with an earlier toolchain fails here, naming the
+// version needed, rather than on the missing function.
+var _ = go1_20OrLaterIsRequired
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/ONSdigital/dp-dataset-api/dimension"
+	"github.com/ONSdigital/dp-dataset-api/instance"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/dp-dataset-api/store"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/pkg/errors"
+)
+
+// trailActions are the actions changing an instance which are kept in its audit trail. Reads, including of the trail
+// itself, and the per option and per batch updates made while importing are left out, as an import makes them
+// millions of times.
+var trailActions = map[string]bool{
+	instance.AddInstanceAction:       true,
+	instance.AddInstanceEventAction:  true,
+	instance.CreateEditionAction:     true,
+	instance.RebuildHierarchyAction:  true,
+	instance.ResetInstanceAction:     true,
+	instance.UpdateDimensionAction:   true,
+	instance.UpdateEditionAction:     true,
+	instance.UpdateImportTasksAction: true,
+	instance.UpdateInstanceAction:    true,
+	dimension.RenameDimensionAction:  true,
+}
+
+// instanceAuditor records audit events with the wrapped auditor and then stores the results of those changing an
+// instance, so the audit trail of an instance can be read back. The audit events topic remains the complete record,
+// so an event which cannot be stored is logged rather than failing the request.
+type instanceAuditor struct {
+	Auditor
+	storer store.Storer
+}
+
+// Record records the event with the wrapped auditor, storing it when it is the result of an action changing an
+// instance
+func (a *instanceAuditor) Record(ctx context.Context, action string, result string, params common.Params) error {
+	if err := a.Auditor.Record(ctx, action, result, params); err != nil {
+		return err
+	}
+
+	instanceID := params["instance_id"]
+	if instanceID == "" || !trailActions[action] || result == audit.Attempted {
+		return nil
+	}
+
+	event := &models.AuditEvent{
+		InstanceID: instanceID,
+		Action:     action,
+		Result:     result,
+		User:       common.User(ctx),
+		Caller:     common.Caller(ctx),
+		RequestID:  common.GetRequestId(ctx),
+		Params:     params,
+		Created:    time.Now().UTC(),
+	}
+
+	if err := a.storer.AddAuditEvent(event); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "failed to store audit event"), audit.ToLogData(params))
+	}
+
+	return nil
+}
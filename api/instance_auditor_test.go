@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-dataset-api/dimension"
+	"github.com/ONSdigital/dp-dataset-api/instance"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInstanceAuditorRecord(t *testing.T) {
+	t.Parallel()
+	Convey("Given an instance auditor", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			AddAuditEventFunc: func(event *models.AuditEvent) error {
+				return nil
+			},
+		}
+		ctx := common.SetUser(common.SetCaller(context.Background(), "someone@ons.gov.uk"), "someone@ons.gov.uk")
+
+		Convey("When an action against an instance is recorded", func() {
+			wrapped := auditortest.New()
+			auditor := &instanceAuditor{Auditor: wrapped, storer: mockedDataStore}
+			params := common.Params{"instance_id": "123", "dimension": "geography"}
+			err := auditor.Record(ctx, "updateDimension", audit.Successful, params)
+
+			Convey("Then the event is recorded by the wrapped auditor and stored against the instance", func() {
+				So(err, ShouldBeNil)
+				wrapped.AssertRecordCalls(auditortest.Expected{"updateDimension", audit.Successful, params})
+
+				So(len(mockedDataStore.AddAuditEventCalls()), ShouldEqual, 1)
+				event := mockedDataStore.AddAuditEventCalls()[0].Event
+				So(event.InstanceID, ShouldEqual, "123")
+				So(event.Action, ShouldEqual, "updateDimension")
+				So(event.Result, ShouldEqual, audit.Successful)
+				So(event.User, ShouldEqual, "someone@ons.gov.uk")
+				So(event.Params, ShouldResemble, map[string]string{"instance_id": "123", "dimension": "geography"})
+				So(event.Created.IsZero(), ShouldBeFalse)
+			})
+		})
+
+		Convey("When an action which is not against an instance is recorded", func() {
+			auditor := &instanceAuditor{Auditor: auditortest.New(), storer: mockedDataStore}
+			err := auditor.Record(ctx, "getDatasets", audit.Successful, nil)
+
+			Convey("Then the event is not stored", func() {
+				So(err, ShouldBeNil)
+				So(len(mockedDataStore.AddAuditEventCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When an attempt at an action changing an instance is recorded", func() {
+			wrapped := auditortest.New()
+			auditor := &instanceAuditor{Auditor: wrapped, storer: mockedDataStore}
+			params := common.Params{"instance_id": "123"}
+			err := auditor.Record(ctx, instance.UpdateInstanceAction, audit.Attempted, params)
+
+			Convey("Then the event is recorded by the wrapped auditor but only its result is stored", func() {
+				So(err, ShouldBeNil)
+				wrapped.AssertRecordCalls(auditortest.Expected{instance.UpdateInstanceAction, audit.Attempted, params})
+				So(len(mockedDataStore.AddAuditEventCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When reads and per option updates of an instance are recorded", func() {
+			wrapped := auditortest.New()
+			auditor := &instanceAuditor{Auditor: wrapped, storer: mockedDataStore}
+			params := common.Params{"instance_id": "123"}
+			actions := []string{instance.GetInstanceAction, instance.GetInstanceAuditAction, dimension.AddDimensionAction, dimension.UpdateNodeIDAction, instance.UpdateInsertedObservationsAction}
+			for _, action := range actions {
+				So(auditor.Record(ctx, action, audit.Successful, params), ShouldBeNil)
+			}
+
+			Convey("Then the events are recorded by the wrapped auditor but are not stored", func() {
+				So(len(wrapped.RecordCalls()), ShouldEqual, len(actions))
+				So(len(mockedDataStore.AddAuditEventCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the wrapped auditor fails to record the event", func() {
+			auditor := &instanceAuditor{Auditor: auditortest.NewErroring("updateDimension", audit.Successful), storer: mockedDataStore}
+			err := auditor.Record(ctx, "updateDimension", audit.Successful, common.Params{"instance_id": "123"})
+
+			Convey("Then the error is returned and the event is not stored", func() {
+				So(err, ShouldNotBeNil)
+				So(len(mockedDataStore.AddAuditEventCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the event cannot be stored", func() {
+			mockedDataStore.AddAuditEventFunc = func(event *models.AuditEvent) error {
+				return errors.New("mongo is down")
+			}
+			auditor := &instanceAuditor{Auditor: auditortest.New(), storer: mockedDataStore}
+			err := auditor.Record(ctx, "updateDimension", audit.Successful, common.Params{"instance_id": "123"})
+
+			Convey("Then the action is still recorded successfully", func() {
+				So(err, ShouldBeNil)
+				So(len(mockedDataStore.AddAuditEventCalls()), ShouldEqual, 1)
+			})
+		})
+	})
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/justinas/alice"
+)
+
+type contextKey string
+
+const internalRequestKey = contextKey("internal-request")
+
+// internalNetworks returns middleware marking requests from any of the given networks as internal service-to-service
+// calls. The source address of the connection is used, forwarded headers are ignored as they can be set by a client.
+func internalNetworks(networks []*net.IPNet) alice.Constructor {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isFromNetworks(r.RemoteAddr, networks) {
+				r = r.WithContext(context.WithValue(r.Context(), internalRequestKey, true))
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isInternalRequest reports whether the request was made from one of the configured internal networks, CORS handling
+// uses this to skip work which only applies to browser clients
+func isInternalRequest(ctx context.Context) bool {
+	internal, _ := ctx.Value(internalRequestKey).(bool)
+	return internal
+}
+
+func isFromNetworks(remoteAddr string, networks []*net.IPNet) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
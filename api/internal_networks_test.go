@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInternalNetworks(t *testing.T) {
+	t.Parallel()
+	Convey("Given middleware configured with an internal network range", t, func() {
+		_, network, err := net.ParseCIDR("10.0.0.0/8")
+		So(err, ShouldBeNil)
+
+		var internal bool
+		handler := internalNetworks([]*net.IPNet{network})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			internal = isInternalRequest(r.Context())
+		}))
+
+		Convey("When a request is made from an address in the range then it is marked as internal", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets", nil)
+			r.RemoteAddr = "10.1.2.3:51234"
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			So(internal, ShouldBeTrue)
+		})
+
+		Convey("When a request is made from an address outside the range then it is not marked as internal", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets", nil)
+			r.RemoteAddr = "192.168.1.10:51234"
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			So(internal, ShouldBeFalse)
+		})
+
+		Convey("When an external request claims to be forwarded from an address in the range then it is not marked as internal", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets", nil)
+			r.RemoteAddr = "192.168.1.10:51234"
+			r.Header.Set("X-Forwarded-For", "10.1.2.3")
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			So(internal, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/ONSdigital/go-ns/request"
+	"github.com/pkg/errors"
+)
+
+// errors that should return a 400 status when changing maintenance mode
+var maintenanceBadRequest = map[error]bool{
+	errs.ErrJSONTooDeep:         true,
+	errs.ErrMissingParameters:   true,
+	errs.ErrRequestBodyTooLarge: true,
+	errs.ErrUnableToParseJSON:   true,
+}
+
+// maintenanceMode records whether write requests are being rejected, so a migration can run while reads continue.
+// It is held by pointer as copies of the API are made for transactions, and is changed while requests are served.
+type maintenanceMode struct {
+	enabled int32
+}
+
+func newMaintenanceMode(enabled bool) *maintenanceMode {
+	m := &maintenanceMode{}
+	m.set(enabled)
+	return m
+}
+
+func (m *maintenanceMode) isEnabled() bool {
+	return atomic.LoadInt32(&m.enabled) == 1
+}
+
+func (m *maintenanceMode) set(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&m.enabled, value)
+}
+
+// rejectDuringMaintenance wraps the handler of a write request, responding with 503 Service Unavailable rather than
+// calling the handler while the API is in maintenance mode
+func (api *DatasetAPI) rejectDuringMaintenance(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if api.maintenance.isEnabled() {
+			log.InfoCtx(r.Context(), "maintenance mode enabled, rejecting write request", log.Data{"method": r.Method, "path": r.URL.Path})
+			errs.WriteError(w, errs.ErrMaintenanceMode, http.StatusServiceUnavailable)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// updateMaintenanceMode enables or disables maintenance mode for this instance of the API, responding with the mode
+// now in place. The mode is not shared between instances, so each one behind a load balancer must be changed.
+func (api *DatasetAPI) updateMaintenanceMode(w http.ResponseWriter, r *http.Request) {
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	logData := log.Data{}
+
+	mode, err := models.CreateMaintenanceMode(r.Body, api.jsonLimits)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "updateMaintenanceMode endpoint: failed to model maintenance mode based on request"), logData)
+		if auditErr := api.auditor.Record(ctx, updateMaintenanceModeAction, audit.Unsuccessful, nil); auditErr != nil {
+			err = auditErr
+		}
+		handleMaintenanceErr(ctx, err, w, logData)
+		return
+	}
+
+	auditParams := common.Params{"enabled": strconv.FormatBool(*mode.Enabled)}
+	logData["enabled"] = *mode.Enabled
+
+	api.maintenance.set(*mode.Enabled)
+
+	if auditErr := api.auditor.Record(ctx, updateMaintenanceModeAction, audit.Successful, auditParams); auditErr != nil {
+		handleMaintenanceErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	b, err := json.Marshal(mode)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "updateMaintenanceMode endpoint: failed to marshal maintenance mode into bytes"), logData)
+		handleMaintenanceErr(ctx, err, w, logData)
+		return
+	}
+
+	setJSONContentType(w)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "updateMaintenanceMode endpoint: error writing bytes to response"), logData)
+	}
+	log.InfoCtx(ctx, "updateMaintenanceMode endpoint: request successful", logData)
+}
+
+func handleMaintenanceErr(ctx context.Context, err error, w http.ResponseWriter, data log.Data) {
+	status := http.StatusBadRequest
+	if !maintenanceBadRequest[err] {
+		err = errs.ErrInternalServer
+		status = http.StatusInternalServerError
+	}
+
+	data["responseStatus"] = status
+	log.ErrorCtx(ctx, errors.WithMessage(err, "request unsuccessful"), data)
+	errs.WriteError(w, err, status)
+}
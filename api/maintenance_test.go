@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	t.Parallel()
+	Convey("Given the API is in maintenance mode", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetsFunc: func(datasetType string) ([]models.DatasetUpdate, error) {
+				return []models.DatasetUpdate{}, nil
+			},
+			SetDatasetLinksFrozenFunc: func(id string, frozen bool) error {
+				return nil
+			},
+		}
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.maintenance.set(true)
+
+		Convey("When a write request is made", func() {
+			r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123/links/freeze", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then it is rejected as unavailable without being handled", func() {
+				So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrMaintenanceMode.Error())
+				So(len(mockedDataStore.SetDatasetLinksFrozenCalls()), ShouldEqual, 0)
+				auditor.AssertRecordCalls()
+			})
+		})
+
+		Convey("When a read request is made", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then it is handled as usual", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.GetDatasetsCalls()), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When maintenance mode is disabled", func() {
+			r, err := createRequestWithAuth("PUT", "http://localhost:22000/maintenance", bytes.NewBufferString(`{"enabled":false}`))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the mode now in place is returned and write requests are handled again", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, `{"enabled":false}`)
+				So(api.maintenance.isEnabled(), ShouldBeFalse)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{Action: updateMaintenanceModeAction, Result: audit.Attempted, Params: common.Params{"caller_identity": "someone@ons.gov.uk"}},
+					auditortest.Expected{Action: updateMaintenanceModeAction, Result: audit.Successful, Params: common.Params{"enabled": "false"}},
+				)
+
+				r, err := createRequestWithAuth("POST", "http://localhost:22000/datasets/123/links/freeze", nil)
+				So(err, ShouldBeNil)
+				w := httptest.NewRecorder()
+				api.Router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.SetDatasetLinksFrozenCalls()), ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("When maintenance mode is enabled write requests are rejected", t, func() {
+		api := GetAPIWithMocks(&storetest.StorerMock{}, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		r, err := createRequestWithAuth("PUT", "http://localhost:22000/maintenance", bytes.NewBufferString(`{"enabled":true}`))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(api.maintenance.isEnabled(), ShouldBeTrue)
+
+		r, err = createRequestWithAuth("DELETE", "http://localhost:22000/datasets/123", nil)
+		So(err, ShouldBeNil)
+		w = httptest.NewRecorder()
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+	})
+
+	Convey("When the maintenance mode is missing from the request a bad request is returned", t, func() {
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(&storetest.StorerMock{}, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		r, err := createRequestWithAuth("PUT", "http://localhost:22000/maintenance", bytes.NewBufferString(`{}`))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrMissingParameters.Error())
+		So(api.maintenance.isEnabled(), ShouldBeFalse)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{Action: updateMaintenanceModeAction, Result: audit.Attempted, Params: common.Params{"caller_identity": "someone@ons.gov.uk"}},
+			auditortest.Expected{Action: updateMaintenanceModeAction, Result: audit.Unsuccessful, Params: nil},
+		)
+	})
+}
@@ -80,9 +80,9 @@ func (api *DatasetAPI) getMetadata(w http.ResponseWriter, r *http.Request) {
 		var metaDataDoc *models.Metadata
 		// combine version and dataset metadata
 		if state != models.PublishedState {
-			metaDataDoc = models.CreateMetaDataDoc(datasetDoc.Next, versionDoc, api.urlBuilder)
+			metaDataDoc = models.CreateMetaDataDoc(datasetDoc.Next, versionDoc, api.urlBuilder, false)
 		} else {
-			metaDataDoc = models.CreateMetaDataDoc(datasetDoc.Current, versionDoc, api.urlBuilder)
+			metaDataDoc = models.CreateMetaDataDoc(datasetDoc.Current, versionDoc, api.urlBuilder, !authorised && api.redactPublicContacts)
 		}
 
 		b, err := json.Marshal(metaDataDoc)
@@ -110,7 +110,7 @@ func (api *DatasetAPI) getMetadata(w http.ResponseWriter, r *http.Request) {
 	setJSONContentType(w)
 	if _, err = w.Write(b); err != nil {
 		log.ErrorCtx(ctx, errors.WithMessage(err, "getMetadata endpoint: failed to write bytes to response"), logData)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		errs.WriteError(w, err, http.StatusInternalServerError)
 	}
 	log.InfoCtx(ctx, "getMetadata endpoint: get metadata request successful", logData)
 }
@@ -132,5 +132,5 @@ func handleMetadataErr(w http.ResponseWriter, err error) {
 		responseStatus = http.StatusInternalServerError
 	}
 
-	http.Error(w, err.Error(), responseStatus)
+	errs.WriteError(w, err, responseStatus)
 }
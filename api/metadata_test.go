@@ -651,6 +651,55 @@ func TestGetMetadataAuditingErrors(t *testing.T) {
 
 // createDatasetDoc returns a datasetUpdate doc containing minimal fields but
 // there is a clear difference between the current and next sub documents
+func TestGetMetadataRedactsPublicContacts(t *testing.T) {
+	t.Parallel()
+	Convey("Given contact details are configured not to be public", t, func() {
+		contacts := []models.ContactDetails{{Name: "Jane Doe", Email: "jane@ons.gov.uk", Telephone: "01234 567890"}}
+		datasetDoc := createDatasetDoc()
+		datasetDoc.Current.Contacts = contacts
+		versionDoc := createPublishedVersionDoc()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return datasetDoc, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, edition, state string) error {
+				return nil
+			},
+			GetVersionFunc: func(datasetID, edition, version, state string) (*models.Version, error) {
+				return versionDoc, nil
+			},
+		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.redactPublicContacts = true
+
+		Convey("When a public caller gets the metadata then only the names of the contacts are returned", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123/editions/2017/versions/1/metadata", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			var metaData models.Metadata
+			So(json.Unmarshal(w.Body.Bytes(), &metaData), ShouldBeNil)
+			So(metaData.Contacts, ShouldResemble, []models.ContactDetails{{Name: "Jane Doe"}})
+		})
+
+		Convey("When an authorised caller gets the metadata then the full contact details are returned", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123/editions/2017/versions/1/metadata", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			var metaData models.Metadata
+			So(json.Unmarshal(w.Body.Bytes(), &metaData), ShouldBeNil)
+			So(metaData.Contacts, ShouldResemble, contacts)
+		})
+	})
+}
+
 func createDatasetDoc() *models.DatasetUpdate {
 	generalDataset := &models.Dataset{
 		CollectionID:     "4321",
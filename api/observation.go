@@ -27,7 +27,18 @@ const (
 	defaultObservationLimit = 10000
 	defaultOffset           = 0
 
-	getObservationsAction = "getObservations"
+	getObservationsAction          = "getObservations"
+	getObservationCountAction      = "getObservationCount"
+	getObservationDimensionsAction = "getObservationDimensions"
+	getObservationsMetadataAction  = "getObservationsMetadata"
+
+	// observationStreamsRetryAfter is the number of seconds a client is asked to wait before retrying an
+	// observations query rejected as too many are in progress
+	observationStreamsRetryAfter = "1"
+
+	// observationRowParam is the query parameter authorised callers give to fetch a single observation row of a
+	// version by its index, rather than querying by dimension options
+	observationRowParam = "row"
 )
 
 var (
@@ -36,10 +47,16 @@ var (
 		errs.ErrEditionNotFound:      true,
 		errs.ErrVersionNotFound:      true,
 		errs.ErrObservationsNotFound: true,
+
+		errs.ErrObservationRowNotFound: true,
 	}
 
 	observationBadRequest = map[error]bool{
-		errs.ErrTooManyWildcards: true,
+		errs.ErrObservationRowInvalid:   true,
+		errs.ErrTooManyWildcards:        true,
+		errs.ErrMalformedVersionHeaders: true,
+		models.ErrTimeRangeInvalid:      true,
+		models.ErrOptionRangeInvalid:    true,
 	}
 )
 
@@ -63,12 +80,30 @@ func errorMissingQueryParameters(params []string) error {
 	}
 }
 
+func errorWildcardNotAllowed(dimension string) error {
+	return observationQueryError{
+		message: fmt.Sprintf("a wildcard is not allowed for the dimension: %v", dimension),
+	}
+}
+
+func errorRangeNotAllowed(dimension string) error {
+	return observationQueryError{
+		message: fmt.Sprintf("a range is not allowed for the dimension, as it is not ordinal: %v", dimension),
+	}
+}
+
 func errorMultivaluedQueryParameters(params []string) error {
 	return observationQueryError{
 		message: fmt.Sprintf("multi-valued query parameters for the following dimensions: %v", params),
 	}
 }
 
+func errorRowWithQueryParameters() error {
+	return observationQueryError{
+		message: "the row query parameter cannot be combined with dimension query parameters",
+	}
+}
+
 func (api *DatasetAPI) getObservations(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	vars := mux.Vars(r)
@@ -85,49 +120,10 @@ func (api *DatasetAPI) getObservations(w http.ResponseWriter, r *http.Request) {
 	}
 
 	observationsDoc, err := func() (*models.ObservationsDoc, error) {
-		// get dataset document
-		datasetDoc, err := api.dataStore.Backend.GetDataset(datasetID)
-		if err != nil {
-			log.ErrorCtx(ctx, errors.WithMessage(err, "get observations: datastore.GetDataset returned an error"), logData)
-			return nil, err
-		}
-
 		authorised, logData := api.authenticate(r, logData)
 
-		var (
-			state   string
-			dataset *models.Dataset
-		)
-
-		// if request is not authenticated then only access resources of state published
-		if !authorised {
-			// Check for current sub document
-			if datasetDoc.Current == nil || datasetDoc.Current.State != models.PublishedState {
-				logData["dataset_doc"] = datasetDoc.Current
-				log.ErrorCtx(ctx, errors.WithMessage(errs.ErrDatasetNotFound, "get observations: found no published dataset"), logData)
-				return nil, errs.ErrDatasetNotFound
-			}
-
-			dataset = datasetDoc.Current
-			state = dataset.State
-		} else {
-			dataset = datasetDoc.Next
-		}
-
-		if err = api.dataStore.Backend.CheckEditionExists(datasetID, edition, state); err != nil {
-			log.ErrorCtx(ctx, errors.WithMessage(err, "get observations: failed to find edition for dataset"), logData)
-			return nil, err
-		}
-
-		versionDoc, err := api.dataStore.Backend.GetVersion(datasetID, edition, version, state)
+		dataset, versionDoc, err := api.getObservableVersion(ctx, datasetID, edition, version, authorised, logData)
 		if err != nil {
-			log.ErrorCtx(ctx, errors.WithMessage(err, "get observations: failed to find version for dataset edition"), logData)
-			return nil, err
-		}
-
-		if err = models.CheckState("version", versionDoc.State); err != nil {
-			logData["state"] = versionDoc.State
-			log.ErrorCtx(ctx, errors.WithMessage(err, "get observations: unpublished version has an invalid state"), logData)
 			return nil, err
 		}
 
@@ -141,12 +137,30 @@ func (api *DatasetAPI) getObservations(w http.ResponseWriter, r *http.Request) {
 		validDimensionNames := getListOfValidDimensionNames(versionDoc.Dimensions)
 		logData["version_dimensions"] = validDimensionNames
 
-		dimensionOffset, err := getDimensionOffsetInHeaderRow(versionDoc.Headers)
+		dimensionOffset, err := models.DimensionOffset(versionDoc.Headers)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "get observations: unable to distinguish headers from version document"), logData)
 			return nil, err
 		}
 
+		// a single row is only fetched for authorised callers, to others row is an unknown dimension
+		if _, ok := r.URL.Query()[observationRowParam]; ok && authorised {
+			row, err := parseObservationRow(r.URL.Query())
+			if err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "get observations: invalid row query parameter"), logData)
+				return nil, err
+			}
+			logData["row"] = row
+
+			observation, err := api.getObservationRow(ctx, versionDoc, row, dimensionOffset, logData)
+			if err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "get observations: unable to retrieve observation row"), logData)
+				return nil, err
+			}
+
+			return models.CreateObservationsDoc(r.URL.RawQuery, versionDoc, dataset, []models.Observation{*observation}, map[string]string{}, row, 1), nil
+		}
+
 		// check query parameters match the version headers
 		queryParameters, err := extractQueryParameters(r.URL.Query(), validDimensionNames)
 		if err != nil {
@@ -180,6 +194,10 @@ func (api *DatasetAPI) getObservations(w http.ResponseWriter, r *http.Request) {
 
 	setJSONContentType(w)
 
+	// observations are streamed from the graph database as they are read so there is no way to seek to a byte
+	// offset, any Range header is ignored and the whole document returned
+	w.Header().Set("Accept-Ranges", "none")
+
 	// The ampersand "&" is escaped to "\u0026" to keep some browsers from
 	// misinterpreting JSON output as HTML. This escaping can be disabled using
 	// an Encoder that had SetEscapeHTML(false) called on it.
@@ -194,19 +212,360 @@ func (api *DatasetAPI) getObservations(w http.ResponseWriter, r *http.Request) {
 	log.InfoCtx(ctx, "get observations endpoint: successfully retrieved observations relative to a selected set of dimension options for a version", logData)
 }
 
-func getDimensionOffsetInHeaderRow(headerRow []string) (int, error) {
-	metaData := strings.Split(headerRow[0], "_")
+func (api *DatasetAPI) getObservationCount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	datasetID := vars["dataset_id"]
+	edition := vars["edition"]
+	version := vars["version"]
+
+	auditParams := common.Params{"dataset_id": datasetID, "edition": edition, "version": version}
+	logData := audit.ToLogData(auditParams)
+
+	if auditErr := api.auditor.Record(ctx, getObservationCountAction, audit.Attempted, auditParams); auditErr != nil {
+		handleObservationsErrorType(ctx, w, auditErr, logData)
+		return
+	}
+
+	b, err := func() ([]byte, error) {
+		authorised, logData := api.authenticate(r, logData)
+
+		// counting reads every observation of the version, so only authorised callers may ask for a fresh count
+		fresh := authorised && r.URL.Query().Get("fresh") == "true"
+		logData["fresh"] = fresh
+
+		_, versionDoc, err := api.getObservableVersion(ctx, datasetID, edition, version, authorised, logData)
+		if err != nil {
+			return nil, err
+		}
+
+		var count int
+
+		// prefer the count stored against the version, unless an authorised caller
+		// has requested a fresh count or the count has never been stored
+		if !fresh && versionDoc.TotalObservations != nil {
+			count = *versionDoc.TotalObservations
+		} else {
+			count, err = api.countObservations(ctx, versionDoc)
+			if err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "get observation count: unable to count observations"), logData)
+				return nil, err
+			}
+		}
+
+		b, err := json.Marshal(models.ObservationCount{Count: count})
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "get observation count: failed to marshal count into bytes"), logData)
+			return nil, err
+		}
+
+		return b, nil
+	}()
+
+	if err != nil {
+		if auditErr := api.auditor.Record(ctx, getObservationCountAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleObservationsErrorType(ctx, w, err, logData)
+		return
+	}
+
+	if auditErr := api.auditor.Record(ctx, getObservationCountAction, audit.Successful, auditParams); auditErr != nil {
+		handleObservationsErrorType(ctx, w, auditErr, logData)
+		return
+	}
+
+	setJSONContentType(w)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "get observation count: error writing bytes to response"), logData)
+		handleObservationsErrorType(ctx, w, err, logData)
+		return
+	}
+
+	log.InfoCtx(ctx, "get observation count endpoint: successfully retrieved count of observations for a version", logData)
+}
+
+func (api *DatasetAPI) getObservationDimensions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	datasetID := vars["dataset_id"]
+	edition := vars["edition"]
+	version := vars["version"]
+
+	auditParams := common.Params{"dataset_id": datasetID, "edition": edition, "version": version}
+	logData := audit.ToLogData(auditParams)
+
+	if auditErr := api.auditor.Record(ctx, getObservationDimensionsAction, audit.Attempted, auditParams); auditErr != nil {
+		handleObservationsErrorType(ctx, w, auditErr, logData)
+		return
+	}
+
+	b, err := func() ([]byte, error) {
+		authorised, logData := api.authenticate(r, logData)
+
+		_, versionDoc, err := api.getObservableVersion(ctx, datasetID, edition, version, authorised, logData)
+		if err != nil {
+			return nil, err
+		}
+
+		if versionDoc.Headers == nil || versionDoc.Dimensions == nil {
+			logData["version_doc"] = versionDoc
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrMissingVersionHeadersOrDimensions, "get observation dimensions"), logData)
+			return nil, errs.ErrMissingVersionHeadersOrDimensions
+		}
+
+		dimensions, err := api.getObservationDimensionList(versionDoc.Headers, versionDoc.Dimensions)
+		if err != nil {
+			logData["headers"] = versionDoc.Headers
+			log.ErrorCtx(ctx, errors.WithMessage(err, "get observation dimensions: unable to distinguish headers from version document"), logData)
+			return nil, err
+		}
+
+		b, err := json.Marshal(models.ObservationDimensions{Items: dimensions})
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "get observation dimensions: failed to marshal dimensions into bytes"), logData)
+			return nil, err
+		}
+
+		return b, nil
+	}()
+
+	if err != nil {
+		if auditErr := api.auditor.Record(ctx, getObservationDimensionsAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleObservationsErrorType(ctx, w, err, logData)
+		return
+	}
+
+	if auditErr := api.auditor.Record(ctx, getObservationDimensionsAction, audit.Successful, auditParams); auditErr != nil {
+		handleObservationsErrorType(ctx, w, auditErr, logData)
+		return
+	}
+
+	setJSONContentType(w)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "get observation dimensions: error writing bytes to response"), logData)
+		handleObservationsErrorType(ctx, w, err, logData)
+		return
+	}
+
+	log.InfoCtx(ctx, "get observation dimensions endpoint: successfully retrieved dimensions for a version", logData)
+}
+
+// getObservationsMetadata returns what a consumer needs to interpret the observations of a version, without
+// querying the observations themselves
+func (api *DatasetAPI) getObservationsMetadata(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	datasetID := vars["dataset_id"]
+	edition := vars["edition"]
+	version := vars["version"]
+
+	auditParams := common.Params{"dataset_id": datasetID, "edition": edition, "version": version}
+	logData := audit.ToLogData(auditParams)
+
+	if auditErr := api.auditor.Record(ctx, getObservationsMetadataAction, audit.Attempted, auditParams); auditErr != nil {
+		handleObservationsErrorType(ctx, w, auditErr, logData)
+		return
+	}
+
+	b, err := func() ([]byte, error) {
+		authorised, logData := api.authenticate(r, logData)
+
+		dataset, versionDoc, err := api.getObservableVersion(ctx, datasetID, edition, version, authorised, logData)
+		if err != nil {
+			return nil, err
+		}
+
+		if versionDoc.Headers == nil {
+			logData["version_doc"] = versionDoc
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrMissingVersionHeadersOrDimensions, "get observations metadata"), logData)
+			return nil, errs.ErrMissingVersionHeadersOrDimensions
+		}
+
+		metadata, err := models.CreateObservationsMetadata(versionDoc, dataset)
+		if err != nil {
+			logData["headers"] = versionDoc.Headers
+			log.ErrorCtx(ctx, errors.WithMessage(err, "get observations metadata: unable to distinguish headers from version document"), logData)
+			return nil, errs.ErrMalformedVersionHeaders
+		}
+
+		b, err := json.Marshal(metadata)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "get observations metadata: failed to marshal metadata into bytes"), logData)
+			return nil, err
+		}
+
+		return b, nil
+	}()
+
+	if err != nil {
+		if auditErr := api.auditor.Record(ctx, getObservationsMetadataAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleObservationsErrorType(ctx, w, err, logData)
+		return
+	}
+
+	if auditErr := api.auditor.Record(ctx, getObservationsMetadataAction, audit.Successful, auditParams); auditErr != nil {
+		handleObservationsErrorType(ctx, w, auditErr, logData)
+		return
+	}
+
+	setJSONContentType(w)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "get observations metadata: error writing bytes to response"), logData)
+		handleObservationsErrorType(ctx, w, err, logData)
+		return
+	}
+
+	log.InfoCtx(ctx, "get observations metadata endpoint: successfully retrieved observations metadata for a version", logData)
+}
+
+// getObservableVersion returns the dataset and the version whose observations are requested. Callers who are not
+// authorised only see published datasets and versions, authorised callers are given the next dataset and a version
+// in any state observations can be read in.
+func (api *DatasetAPI) getObservableVersion(ctx context.Context, datasetID, edition, version string, authorised bool, logData log.Data) (*models.Dataset, *models.Version, error) {
+	datasetDoc, err := api.dataStore.Backend.GetDataset(datasetID)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "get observable version: datastore.GetDataset returned an error"), logData)
+		return nil, nil, err
+	}
+
+	var state string
+	dataset := datasetDoc.Next
+
+	// if request is not authenticated then only access resources of state published
+	if !authorised {
+		if datasetDoc.Current == nil || datasetDoc.Current.State != models.PublishedState {
+			logData["dataset_doc"] = datasetDoc.Current
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrDatasetNotFound, "get observable version: found no published dataset"), logData)
+			return nil, nil, errs.ErrDatasetNotFound
+		}
+
+		dataset = datasetDoc.Current
+		state = dataset.State
+	}
+
+	if err = api.dataStore.Backend.CheckEditionExists(datasetID, edition, state); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "get observable version: failed to find edition for dataset"), logData)
+		return nil, nil, err
+	}
+
+	versionDoc, err := api.dataStore.Backend.GetVersion(datasetID, edition, version, state)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "get observable version: failed to find version for dataset edition"), logData)
+		return nil, nil, err
+	}
+
+	if err = models.CheckState("version", versionDoc.State); err != nil {
+		logData["state"] = versionDoc.State
+		log.ErrorCtx(ctx, errors.WithMessage(err, "get observable version: unpublished version has an invalid state"), logData)
+		return nil, nil, err
+	}
+
+	return dataset, versionDoc, nil
+}
+
+// getObservationDimensionList returns the dimensions which must be given to query
+// observations. A dimension accepts a wildcard when it has a label column in the
+// version headers, which is where the wildcarded option labels are read from, and
+// it is allowed to be wildcarded by the configuration.
+func (api *DatasetAPI) getObservationDimensionList(headers []string, versionDimensions []models.Dimension) ([]models.ObservationDimension, error) {
+	if len(headers) == 0 {
+		return nil, errs.ErrMalformedVersionHeaders
+	}
+
+	dimensionOffset, err := models.DimensionOffset(headers)
+	if err != nil {
+		return nil, errs.ErrMalformedVersionHeaders
+	}
+
+	labelColumns := make(map[string]bool)
+	for i := dimensionOffset + 2; i < len(headers); i += 2 {
+		labelColumns[strings.ToLower(headers[i])] = true
+	}
+
+	dimensions := []models.ObservationDimension{}
+	for _, name := range getListOfValidDimensionNames(versionDimensions) {
+		dimensions = append(dimensions, models.ObservationDimension{
+			Name:     name,
+			Wildcard: labelColumns[name] && api.wildcardAllowed(name),
+		})
+	}
+
+	return dimensions, nil
+}
+
+// countObservations streams every observation for the version from the
+// observation store, returning the number of rows excluding the header row
+func (api *DatasetAPI) countObservations(ctx context.Context, versionDoc *models.Version) (int, error) {
+	queryObject := observation.Filter{
+		InstanceID: versionDoc.ID,
+	}
 
-	if len(metaData) < 2 {
-		return 0, errs.ErrIndexOutOfRange
+	release, err := api.acquireObservationStream()
+	if err != nil {
+		return 0, err
 	}
+	defer release()
 
-	dimensionOffset, err := strconv.Atoi(metaData[1])
+	csvRowReader, err := api.dataStore.Backend.StreamCSVRows(ctx, &queryObject, nil)
 	if err != nil {
 		return 0, err
 	}
+	defer csvRowReader.Close(context.Background())
+
+	// discard the header row
+	if _, err = csvRowReader.Read(); err != nil {
+		return 0, err
+	}
 
-	return dimensionOffset, nil
+	count := 0
+	for _, err = csvRowReader.Read(); err != io.EOF; _, err = csvRowReader.Read() {
+		if err != nil {
+			if strings.Contains(err.Error(), "the filter options created no results") {
+				return 0, nil
+			}
+			return 0, err
+		}
+		if err = checkRequestTimeout(ctx); err != nil {
+			return 0, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// acquireObservationStream takes one of the configured number of concurrent observation streams, returning a func
+// to give it back once the stream is closed. Queries are rejected rather than queued when all the streams are in use,
+// so a burst of slow queries cannot tie up every request handler waiting on the graph database.
+func (api *DatasetAPI) acquireObservationStream() (func(), error) {
+	select {
+	case api.observationStreams <- struct{}{}:
+		return func() { <-api.observationStreams }, nil
+	default:
+		return nil, errs.ErrTooManyObservationStreams
+	}
+}
+
+// wildcardAllowed reports whether the dimension may be wildcarded in an observations query. Wildcards on
+// dimensions with many options are expensive, so they can be limited to the configured dimensions, when
+// none are configured any dimension may be wildcarded.
+func (api *DatasetAPI) wildcardAllowed(dimension string) bool {
+	if len(api.wildcardDimensions) == 0 {
+		return true
+	}
+
+	for _, allowed := range api.wildcardDimensions {
+		if strings.EqualFold(allowed, dimension) {
+			return true
+		}
+	}
+
+	return false
 }
 
 func getListOfValidDimensionNames(dimensions []models.Dimension) []string {
@@ -263,6 +622,12 @@ func extractQueryParameters(urlQuery url.Values, validDimensions []string) (map[
 		return nil, errorMissingQueryParameters(missingQueryParameters)
 	}
 
+	if option := queryParameters[models.TimeDimension]; models.IsTimeRange(models.TimeDimension, option) {
+		if _, err := models.ParseTimeRange(option); err != nil {
+			return nil, err
+		}
+	}
+
 	return queryParameters, nil
 }
 
@@ -281,10 +646,34 @@ func (api *DatasetAPI) getObservationList(ctx context.Context, versionDoc *model
 				return nil, errs.ErrTooManyWildcards
 			}
 
+			if !api.wildcardAllowed(dimension) {
+				return nil, errorWildcardNotAllowed(dimension)
+			}
+
 			wildcardParameter = dimension
 			continue
 		}
 
+		if models.IsTimeRange(dimension, option) {
+			dimensionFilter, err := api.getTimeRangeFilter(versionDoc, dimension, option)
+			if err != nil {
+				return nil, err
+			}
+
+			dimensionFilters = append(dimensionFilters, dimensionFilter)
+			continue
+		}
+
+		if models.IsOptionRange(dimension, option) {
+			dimensionFilter, err := api.getOptionRangeFilter(versionDoc, dimension, option)
+			if err != nil {
+				return nil, err
+			}
+
+			dimensionFilters = append(dimensionFilters, dimensionFilter)
+			continue
+		}
+
 		dimensionFilter := &observation.DimensionFilter{
 			Name:    dimension,
 			Options: []string{option},
@@ -301,17 +690,24 @@ func (api *DatasetAPI) getObservationList(ctx context.Context, versionDoc *model
 
 	log.InfoCtx(ctx, "query object built to retrieve observations from db", logData)
 
-	csvRowReader, err := api.dataStore.Backend.StreamCSVRows(context.Background(), &queryObject, &limit)
+	release, err := api.acquireObservationStream()
 	if err != nil {
 		return nil, err
 	}
+	defer release()
 
-	headerRow, err := csvRowReader.Read()
+	// the stream is tied to the request so it is abandoned if the client disconnects
+	csvRowReader, err := api.dataStore.Backend.StreamCSVRows(ctx, &queryObject, &limit)
 	if err != nil {
 		return nil, err
 	}
 	defer csvRowReader.Close(context.Background())
 
+	headerRow, err := csvRowReader.Read()
+	if err != nil {
+		return nil, err
+	}
+
 	headerRowReader := csv.NewReader(strings.NewReader(headerRow))
 	headerRowArray, err := headerRowReader.Read()
 	if err != nil {
@@ -328,63 +724,207 @@ func (api *DatasetAPI) getObservationList(ctx context.Context, versionDoc *model
 			}
 			return nil, err
 		}
+		if err = checkRequestTimeout(ctx); err != nil {
+			return nil, err
+		}
 
-		observationRowReader := csv.NewReader(strings.NewReader(observationRow))
-		observationRowArray, err := observationRowReader.Read()
+		observation, err := createObservation(headerRowArray, observationRow, dimensionOffset, versionDoc)
 		if err != nil {
 			return nil, err
 		}
 
-		// TODO for the below maybe put this in a seperate function?
-		observation := models.Observation{
-			Observation: observationRowArray[0],
-		}
+		observations = append(observations, *observation)
+	}
+
+	// neo4j will always return the same list of observations in the same
+	// order as it is deterministic for static data, but this does not
+	// necessarily mean we won't want to return observations in a particular
+	// order (which may be costly on the services performance)
+
+	return observations, nil
+}
+
+// parseObservationRow returns the index of the observation row requested, which cannot be combined with a query on
+// dimension options
+func parseObservationRow(urlQuery url.Values) (int, error) {
+	if len(urlQuery) > 1 {
+		return 0, errorRowWithQueryParameters()
+	}
+
+	values := urlQuery[observationRowParam]
+	if len(values) != 1 {
+		return 0, errorMultivaluedQueryParameters([]string{observationRowParam})
+	}
+
+	row, err := strconv.Atoi(values[0])
+	if err != nil || row < 0 {
+		return 0, errs.ErrObservationRowInvalid
+	}
+
+	return row, nil
+}
+
+// getObservationRow returns the observation at the index of the rows streamed for the version, counting from 0 after
+// the header row. The stream cannot seek, so the rows before the index are read and discarded.
+func (api *DatasetAPI) getObservationRow(ctx context.Context, versionDoc *models.Version, row, dimensionOffset int, logData log.Data) (*models.Observation, error) {
+	queryObject := observation.Filter{
+		InstanceID: versionDoc.ID,
+	}
+	logData["query_object"] = queryObject
+
+	release, err := api.acquireObservationStream()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// no row after the one requested is needed
+	limit := row + 1
+	csvRowReader, err := api.dataStore.Backend.StreamCSVRows(ctx, &queryObject, &limit)
+	if err != nil {
+		return nil, err
+	}
+	defer csvRowReader.Close(context.Background())
+
+	headerRow, err := csvRowReader.Read()
+	if err != nil {
+		return nil, err
+	}
 
-		// add observation metadata
-		if dimensionOffset != 0 {
-			observationMetaData := make(map[string]string)
+	headerRowArray, err := csv.NewReader(strings.NewReader(headerRow)).Read()
+	if err != nil {
+		return nil, err
+	}
 
-			for i := 1; i < dimensionOffset+1; i++ {
-				observationMetaData[headerRowArray[i]] = observationRowArray[i]
+	for i := 0; ; i++ {
+		observationRow, err := csvRowReader.Read()
+		if err == io.EOF {
+			return nil, errs.ErrObservationRowNotFound
+		}
+		if err != nil {
+			if strings.Contains(err.Error(), "the filter options created no results") {
+				return nil, errs.ErrObservationRowNotFound
 			}
+			return nil, err
+		}
+		if err = checkRequestTimeout(ctx); err != nil {
+			return nil, err
+		}
 
-			observation.Metadata = observationMetaData
+		if i == row {
+			return createObservation(headerRowArray, observationRow, dimensionOffset, versionDoc)
 		}
+	}
+}
 
-		if wildcardParameter != "" {
-			dimensions := make(map[string]*models.DimensionObject)
+// createObservation parses an observation row streamed for the version, taking the names of its metadata columns
+// and dimensions from the header row
+func createObservation(headerRowArray []string, observationRow string, dimensionOffset int, versionDoc *models.Version) (*models.Observation, error) {
+	observationRowArray, err := csv.NewReader(strings.NewReader(observationRow)).Read()
+	if err != nil {
+		return nil, err
+	}
 
-			for i := dimensionOffset + 2; i < len(observationRowArray); i += 2 {
+	observation := &models.Observation{
+		Observation: observationRowArray[0],
+	}
 
-				if strings.ToLower(headerRowArray[i]) == wildcardParameter {
-					for _, versionDimension := range versionDoc.Dimensions {
-						if versionDimension.Name == wildcardParameter {
+	// add observation metadata
+	if dimensionOffset != 0 {
+		observationMetaData := make(map[string]string)
 
-							dimensions[headerRowArray[i]] = &models.DimensionObject{
-								ID:    observationRowArray[i-1],
-								HRef:  versionDimension.HRef + "/codes/" + observationRowArray[i-1],
-								Label: observationRowArray[i],
-							}
+		for i := 1; i < dimensionOffset+1; i++ {
+			observationMetaData[headerRowArray[i]] = observationRowArray[i]
+		}
 
-							break
-						}
-					}
+		observation.Metadata = observationMetaData
+	}
 
-					break
-				}
-			}
-			observation.Dimensions = dimensions
+	observation.Dimensions = models.ObservationRowDimensions(headerRowArray, observationRowArray, dimensionOffset, versionDoc.Dimensions)
+
+	return observation, nil
+}
+
+// getTimeRangeFilter creates a filter on every option of the time dimension within the range, as the observation
+// store can only filter on a list of options
+func (api *DatasetAPI) getTimeRangeFilter(versionDoc *models.Version, dimension, value string) (*observation.DimensionFilter, error) {
+	timeRange, err := models.ParseTimeRange(value)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := api.dataStore.Backend.GetDimensionOptions(versionDoc, dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	dimensionFilter := &observation.DimensionFilter{Name: dimension}
+	for _, option := range options.Items {
+		if timeRange.Contains(option.Option) {
+			dimensionFilter.Options = append(dimensionFilter.Options, option.Option)
 		}
+	}
 
-		observations = append(observations, observation)
+	if len(dimensionFilter.Options) == 0 {
+		return nil, errs.ErrObservationsNotFound
 	}
 
-	// neo4j will always return the same list of observations in the same
-	// order as it is deterministic for static data, but this does not
-	// necessarily mean we won't want to return observations in a particular
-	// order (which may be costly on the services performance)
+	return dimensionFilter, nil
+}
 
-	return observations, nil
+// getOptionRangeFilter creates a filter on every option of an ordinal dimension within the range, in the order given
+// to the options. Dimensions which are not ordinal have no meaningful order, so a range on them is rejected. A value
+// which is itself an option of the dimension is not a range, so it is filtered on as it is.
+func (api *DatasetAPI) getOptionRangeFilter(versionDoc *models.Version, dimension, value string) (*observation.DimensionFilter, error) {
+	ordinal := false
+	for _, versionDimension := range versionDoc.Dimensions {
+		if versionDimension.Name == dimension {
+			ordinal = versionDimension.Ordinal
+			break
+		}
+	}
+
+	if !ordinal {
+		options, err := api.dataStore.Backend.GetDimensionOptionsByCodes(versionDoc.ID, dimension, []string{value})
+		if err != nil {
+			return nil, err
+		}
+		if len(options.Items) == 0 {
+			return nil, errorRangeNotAllowed(dimension)
+		}
+		return &observation.DimensionFilter{Name: dimension, Options: []string{value}}, nil
+	}
+
+	options, err := api.dataStore.Backend.GetDimensionOptions(versionDoc, dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, len(options.Items))
+	for _, option := range options.Items {
+		if option.Option == value {
+			return &observation.DimensionFilter{Name: dimension, Options: []string{value}}, nil
+		}
+		codes = append(codes, option.Option)
+	}
+
+	optionRange, err := models.ParseOptionRange(value)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := optionRange.Select(codes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &observation.DimensionFilter{Name: dimension, Options: selected}, nil
+}
+
+// observationsUnavailable responds to requests for observations while the public observations endpoints are disabled
+func observationsUnavailable(w http.ResponseWriter, r *http.Request) {
+	log.InfoCtx(r.Context(), "observations endpoints disabled, returning service unavailable", nil)
+	errs.WriteError(w, errs.ErrObservationsUnavailable, http.StatusServiceUnavailable)
 }
 
 func handleObservationsErrorType(ctx context.Context, w http.ResponseWriter, err error, data log.Data) {
@@ -398,6 +938,11 @@ func handleObservationsErrorType(ctx context.Context, w http.ResponseWriter, err
 		status = http.StatusNotFound
 	case observationBadRequest[err]:
 		status = http.StatusBadRequest
+	case err == errs.ErrRequestTimeout:
+		status = http.StatusServiceUnavailable
+	case err == errs.ErrTooManyObservationStreams:
+		w.Header().Set("Retry-After", observationStreamsRetryAfter)
+		status = http.StatusServiceUnavailable
 	default:
 		err = errs.ErrInternalServer
 		status = http.StatusInternalServerError
@@ -409,5 +954,5 @@ func handleObservationsErrorType(ctx context.Context, w http.ResponseWriter, err
 
 	data["responseStatus"] = status
 	log.ErrorCtx(ctx, errors.WithMessage(err, "get observation endpoint: request unsuccessful"), data)
-	http.Error(w, err.Error(), status)
+	errs.WriteError(w, err, status)
 }
@@ -141,6 +141,18 @@ func TestGetObservationsReturnsOK(t *testing.T) {
 				auditortest.Expected{Action: getObservationsAction, Result: audit.Successful, Params: auditParams},
 			)
 		})
+
+		Convey("When the request contains a byte range then the range is ignored and the whole document returned", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations?time=16-Aug&aggregate=cpi1dim1S40403&geography=K02000001", nil)
+			r.Header.Set("Range", "bytes=0-99")
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Header().Get("Accept-Ranges"), ShouldEqual, "none")
+			So(w.Header().Get("Content-Range"), ShouldBeEmpty)
+			So(w.Body.String(), ShouldContainSubstring, getTestData("expectedDocWithSingleObservation"))
+		})
 	})
 
 	Convey("A successful request to get multiple observations via a wildcard for a version of a dataset returns 200 OK response", t, func() {
@@ -250,7 +262,7 @@ func TestGetObservationsReturnsError(t *testing.T) {
 		api.Router.ServeHTTP(w, r)
 
 		So(w.Code, ShouldEqual, http.StatusInternalServerError)
-		So(w.Body.String(), ShouldResemble, "internal error\n")
+		So(w.Body.String(), ShouldResemble, `{"code":"internal_error","message":"internal error"}`+"\n")
 
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
@@ -655,7 +667,7 @@ func TestGetObservationsReturnsError(t *testing.T) {
 		api.Router.ServeHTTP(w, r)
 
 		So(w.Code, ShouldEqual, http.StatusBadRequest)
-		So(w.Body.String(), ShouldResemble, "only one wildcard (*) is allowed as a value in selected query parameters\n")
+		So(w.Body.String(), ShouldResemble, `{"code":"too_many_wildcards","message":"only one wildcard (*) is allowed as a value in selected query parameters"}`+"\n")
 
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
@@ -783,124 +795,194 @@ func TestGetObservationsReturnsError(t *testing.T) {
 	})
 }
 
-func TestGetListOfValidDimensionNames(t *testing.T) {
+func TestGetObservationsWildcardAllowlist(t *testing.T) {
 	t.Parallel()
-	Convey("Given a list of valid dimension codelist objects", t, func() {
-		Convey("When getListOfValidDimensionNames is called", func() {
-			dimension1 := models.Dimension{
-				Name: "time",
-			}
+	Convey("Given only the aggregate dimension may be wildcarded", t, func() {
+		count := 0
+		mockRowReader := &observationtest.CSVRowReaderMock{
+			ReadFunc: func() (string, error) {
+				count++
+				if count == 1 {
+					return "v4_0,time_code,time,geography_code,geography,aggregate_code,aggregate", nil
+				} else if count == 2 {
+					return "146.3,Month,Aug-16,K02000001,,cpi1dim1G10100,01.1 Food", nil
+				}
+				return "", io.EOF
+			},
+			CloseFunc: func(context.Context) error {
+				return nil
+			},
+		}
 
-			dimension2 := models.Dimension{
-				Name: "aggregate",
-			}
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionFunc: func(datasetID, editionID, version, state string) (*models.Version, error) {
+				return &models.Version{
+					Dimensions: []models.Dimension{dimension1, dimension2, dimension3},
+					Headers:    []string{"v4_0", "time_code", "time", "aggregate_code", "aggregate", "geography_code", "geography"},
+					Links: &models.VersionLinks{
+						Version: &models.LinkObject{HRef: "http://localhost:8080/datasets/cpih012/editions/2017/versions/1", ID: "1"},
+					},
+					State: models.PublishedState,
+				}, nil
+			},
+			StreamCSVRowsFunc: func(context.Context, *observation.Filter, *int) (observation.StreamRowReader, error) {
+				return mockRowReader, nil
+			},
+		}
 
-			dimension3 := models.Dimension{
-				Name: "geography",
-			}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.wildcardDimensions = []string{"aggregate"}
 
-			version := &models.Version{
-				Dimensions: []models.Dimension{dimension1, dimension2, dimension3},
-			}
+		Convey("When the geography dimension is wildcarded then the request is rejected", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/cpih012/editions/2017/versions/1/observations?time=Aug-16&aggregate=cpi1dim1G10100&geography=*", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
 
-			Convey("Then func returns the correct number of dimensions", func() {
-				validDimensions := getListOfValidDimensionNames(version.Dimensions)
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldEqual, "a wildcard is not allowed for the dimension: geography\n")
+			So(len(mockedDataStore.StreamCSVRowsCalls()), ShouldEqual, 0)
+		})
 
-				So(len(validDimensions), ShouldEqual, 3)
-				So(validDimensions[0], ShouldEqual, "time")
-				So(validDimensions[1], ShouldEqual, "aggregate")
-				So(validDimensions[2], ShouldEqual, "geography")
+		Convey("When the aggregate dimension is wildcarded then the observations are returned", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/cpih012/editions/2017/versions/1/observations?time=Aug-16&aggregate=*&geography=K02000001", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(len(mockedDataStore.StreamCSVRowsCalls()), ShouldEqual, 1)
+		})
+
+		Convey("When the observation dimensions are requested then only aggregate accepts a wildcard", func() {
+			list, err := api.getObservationDimensionList([]string{"v4_0", "time_code", "time", "aggregate_code", "aggregate"}, []models.Dimension{{Name: "time"}, {Name: "aggregate"}})
+			So(err, ShouldBeNil)
+			So(list, ShouldResemble, []models.ObservationDimension{
+				{Name: "time", Wildcard: false},
+				{Name: "aggregate", Wildcard: true},
 			})
 		})
 	})
 }
 
-func TestGetDimensionOffsetInHeaderRow(t *testing.T) {
+func TestGetObservationsConcurrentStreamLimit(t *testing.T) {
 	t.Parallel()
-	Convey("Given the version headers are valid", t, func() {
-		Convey("When the version has no metadata headers", func() {
-			version := &models.Version{
-				Headers: []string{
-					"v4_0",
-					"time_codelist",
-					"time",
-					"aggregate_codelist",
-					"Aggregate",
-					"geography_codelist",
-					"geography",
-				},
-			}
+	Convey("Given only one observation stream may be open at once", t, func() {
+		streaming := make(chan struct{})
+		finish := make(chan struct{})
+		readErr := error(nil)
+
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionFunc: func(datasetID, editionID, version, state string) (*models.Version, error) {
+				return &models.Version{
+					Dimensions: []models.Dimension{dimension1, dimension2, dimension3},
+					Headers:    []string{"v4_0", "time_code", "time", "aggregate_code", "aggregate", "geography_code", "geography"},
+					Links: &models.VersionLinks{
+						Version: &models.LinkObject{HRef: "http://localhost:8080/datasets/cpih012/editions/2017/versions/1", ID: "1"},
+					},
+					State: models.PublishedState,
+				}, nil
+			},
+			StreamCSVRowsFunc: func(context.Context, *observation.Filter, *int) (observation.StreamRowReader, error) {
+				count := 0
+				return &observationtest.CSVRowReaderMock{
+					ReadFunc: func() (string, error) {
+						count++
+						if count == 1 {
+							if readErr != nil {
+								return "", readErr
+							}
+							streaming <- struct{}{}
+							<-finish
+							return "v4_0,time_code,time,geography_code,geography,aggregate_code,aggregate", nil
+						} else if count == 2 {
+							return "146.3,Month,Aug-16,K02000001,,cpi1dim1G10100,01.1 Food", nil
+						}
+						return "", io.EOF
+					},
+					CloseFunc: func(context.Context) error {
+						return nil
+					},
+				}, nil
+			},
+		}
 
-			Convey("Then getListOfValidDimensionNames func returns the correct number of headers", func() {
-				dimensionOffset, err := getDimensionOffsetInHeaderRow(version.Headers)
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.observationStreams = make(chan struct{}, 1)
 
-				So(err, ShouldBeNil)
-				So(dimensionOffset, ShouldEqual, 0)
-			})
-		})
+		getObservations := func() *httptest.ResponseRecorder {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/cpih012/editions/2017/versions/1/observations?time=Aug-16&aggregate=cpi1dim1G10100&geography=K02000001", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+			return w
+		}
 
-		Convey("When the version has metadata headers", func() {
-			version := &models.Version{
-				Headers: []string{
-					"V4_2",
-					"data_marking",
-					"confidence_interval",
-					"time_codelist",
-					"time",
-				},
-			}
+		Convey("When a second query is made while the first is still streaming", func() {
+			first := make(chan *httptest.ResponseRecorder, 1)
+			go func() {
+				first <- getObservations()
+			}()
+			<-streaming
 
-			Convey("Then getListOfValidDimensionNames func returns the correct number of headers", func() {
-				dimensionOffset, err := getDimensionOffsetInHeaderRow(version.Headers)
+			w := getObservations()
+			close(finish)
 
-				So(err, ShouldBeNil)
-				So(dimensionOffset, ShouldEqual, 2)
+			Convey("Then the second query is rejected and asked to retry", func() {
+				So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(w.Header().Get("Retry-After"), ShouldEqual, "1")
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrTooManyObservationStreams.Error())
+				So(len(mockedDataStore.StreamCSVRowsCalls()), ShouldEqual, 1)
 			})
-		})
-	})
 
-	Convey("Given the first value in the header does not have an underscore `_` in value", t, func() {
-		Convey("When the getListOfValidDimensionNames func is called", func() {
-			version := &models.Version{
-				Headers: []string{
-					"v4",
-					"time_codelist",
-					"time",
-					"aggregate_codelist",
-					"aggregate",
-					"geography_codelist",
-					"geography",
-				},
-			}
-			Convey("Then function returns error, `index out of range`", func() {
-				dimensionOffset, err := getDimensionOffsetInHeaderRow(version.Headers)
+			Convey("Then the first query completes and releases its stream for the next query", func() {
+				So((<-first).Code, ShouldEqual, http.StatusOK)
 
-				So(err, ShouldNotBeNil)
-				So(err.Error(), ShouldResemble, "index out of range")
-				So(dimensionOffset, ShouldEqual, 0)
+				readErr = errs.ErrObservationsNotFound
+				So(getObservations().Code, ShouldEqual, http.StatusNotFound)
+				So(getObservations().Code, ShouldEqual, http.StatusNotFound)
+				So(len(mockedDataStore.StreamCSVRowsCalls()), ShouldEqual, 3)
 			})
 		})
 	})
+}
+
+func TestGetListOfValidDimensionNames(t *testing.T) {
+	t.Parallel()
+	Convey("Given a list of valid dimension codelist objects", t, func() {
+		Convey("When getListOfValidDimensionNames is called", func() {
+			dimension1 := models.Dimension{
+				Name: "time",
+			}
+
+			dimension2 := models.Dimension{
+				Name: "aggregate",
+			}
+
+			dimension3 := models.Dimension{
+				Name: "geography",
+			}
 
-	Convey("Given the first value in the header does not follow the format `v4_1`", t, func() {
-		Convey("When the getListOfValidDimensionNames func is called", func() {
 			version := &models.Version{
-				Headers: []string{
-					"v4_one",
-					"time_codelist",
-					"time",
-					"aggregate_codelist",
-					"aggregate",
-					"geography_codelist",
-					"geography",
-				},
+				Dimensions: []models.Dimension{dimension1, dimension2, dimension3},
 			}
-			Convey("Then function returns error, `index out of range`", func() {
-				dimensionOffset, err := getDimensionOffsetInHeaderRow(version.Headers)
 
-				So(err, ShouldNotBeNil)
-				So(err.Error(), ShouldResemble, "strconv.Atoi: parsing \"one\": invalid syntax")
-				So(dimensionOffset, ShouldEqual, 0)
+			Convey("Then func returns the correct number of dimensions", func() {
+				validDimensions := getListOfValidDimensionNames(version.Dimensions)
+
+				So(len(validDimensions), ShouldEqual, 3)
+				So(validDimensions[0], ShouldEqual, "time")
+				So(validDimensions[1], ShouldEqual, "aggregate")
+				So(validDimensions[2], ShouldEqual, "geography")
 			})
 		})
 	})
@@ -976,85 +1058,431 @@ func TestExtractQueryParameters(t *testing.T) {
 				So(queryParameters, ShouldBeNil)
 			})
 		})
-	})
-}
-
-func TestGetObservationAuditAttemptedError(t *testing.T) {
-	Convey("given audit action attempted returns an error", t, func() {
-		auditor := auditortest.NewErroring(getObservationsAction, audit.Attempted)
-
-		mockedDataStore := &storetest.StorerMock{}
-		datasetPermissions := getAuthorisationHandlerMock()
-		permissions := getAuthorisationHandlerMock()
-		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
 
-		Convey("when get observation is called", func() {
-			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations?time=16-Aug&aggregate=cpi1dim1S40403&geography=K02000001", nil)
-			w := httptest.NewRecorder()
+		Convey("When a request is made containing a range of time points", func() {
+			r, err := http.NewRequest("GET",
+				"http://localhost:22000/datasets/123/editions/2017/versions/1/observations?time=2015..2017&aggregate=Food&geography=wales",
+				nil,
+			)
+			So(err, ShouldBeNil)
 
-			api.Router.ServeHTTP(w, r)
+			Convey("Then extractQueryParameters func returns the range as the value of the time dimension", func() {
+				queryParameters, err := extractQueryParameters(r.URL.Query(), headers)
+				So(err, ShouldBeNil)
+				So(queryParameters["time"], ShouldEqual, "2015..2017")
+			})
+		})
 
-			Convey("then a 500 response status is returned", func() {
-				assertInternalServerErr(w)
-				So(datasetPermissions.Required.Calls, ShouldEqual, 1)
-				So(permissions.Required.Calls, ShouldEqual, 0)
-				So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
-				So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 0)
-				So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 0)
+		Convey("When a request is made containing a malformed range of time points", func() {
+			r, err := http.NewRequest("GET",
+				"http://localhost:22000/datasets/123/editions/2017/versions/1/observations?time=2017..2015&aggregate=Food&geography=wales",
+				nil,
+			)
+			So(err, ShouldBeNil)
 
-				auditor.AssertRecordCalls(
-					auditortest.Expected{
-						Action: getObservationsAction,
-						Result: audit.Attempted,
-						Params: common.Params{"dataset_id": "cpih012", "edition": "2017", "version": "1"}},
-				)
+			Convey("Then extractQueryParameters func returns an error", func() {
+				queryParameters, err := extractQueryParameters(r.URL.Query(), headers)
+				So(err, ShouldEqual, models.ErrTimeRangeInvalid)
+				So(queryParameters, ShouldBeNil)
 			})
 		})
 	})
 }
 
-func TestGetObservationAuditUnsuccessfulError(t *testing.T) {
-	auditParams := common.Params{"dataset_id": "cpih012", "edition": "2017", "version": "1"}
+func TestGetObservationsTimeRange(t *testing.T) {
+	t.Parallel()
+	Convey("Given a version with a time dimension", t, func() {
+		dimensions := []models.Dimension{
+			{Name: "aggregate", HRef: "http://localhost:8081/code-lists/cpih1dim1aggid"},
+			{Name: "time", HRef: "http://localhost:8081/code-lists/time"},
+		}
 
-	Convey("given audit action unsuccessful returns an error", t, func() {
+		mockRowReader := &observationtest.CSVRowReaderMock{
+			ReadFunc: func() (string, error) {
+				return "", io.EOF
+			},
+			CloseFunc: func(context.Context) error {
+				return nil
+			},
+		}
 
-		Convey("when datastore.getDataset returns an error", func() {
-			auditor := auditortest.NewErroring(getObservationsAction, audit.Unsuccessful)
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					Dimensions: dimensions,
+					Headers:    []string{"v4_0", "aggregate_code", "aggregate", "time", "time"},
+					Links: &models.VersionLinks{
+						Version: &models.LinkObject{HRef: "http://localhost:8080/datasets/cpih012/editions/2017/versions/1", ID: "1"},
+					},
+					State: models.PublishedState,
+				}, nil
+			},
+			GetDimensionOptionsFunc: func(version *models.Version, dimension string) (*models.DimensionOptionResults, error) {
+				return &models.DimensionOptionResults{Items: []models.PublicDimensionOption{
+					{Option: "2014"}, {Option: "2015"}, {Option: "2016"}, {Option: "2017"}, {Option: "2018"},
+				}}, nil
+			},
+			StreamCSVRowsFunc: func(context.Context, *observation.Filter, *int) (observation.StreamRowReader, error) {
+				return mockRowReader, nil
+			},
+		}
 
-			mockedDataStore := &storetest.StorerMock{
-				GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
-					return nil, errs.ErrDatasetNotFound
-				},
-			}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
 
-			datasetPermissions := getAuthorisationHandlerMock()
-			permissions := getAuthorisationHandlerMock()
-			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
-			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations?time=16-Aug&aggregate=cpi1dim1S40403&geography=K02000001", nil)
+		Convey("When observations are requested for a range of time points", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations?time=2015..2017&aggregate=cpi1dim1S40403", nil)
 			w := httptest.NewRecorder()
-
 			api.Router.ServeHTTP(w, r)
 
-			Convey("then a 500 response status is returned", func() {
-				assertInternalServerErr(w)
-				So(datasetPermissions.Required.Calls, ShouldEqual, 1)
-				So(permissions.Required.Calls, ShouldEqual, 0)
-				So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
-				So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 0)
-				So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 0)
-				So(len(mockedDataStore.StreamCSVRowsCalls()), ShouldEqual, 0)
+			Convey("Then the observations are filtered on every time option within the range", func() {
+				So(mockedDataStore.GetDimensionOptionsCalls(), ShouldHaveLength, 1)
+				So(mockedDataStore.GetDimensionOptionsCalls()[0].Dimension, ShouldEqual, "time")
+				So(mockedDataStore.StreamCSVRowsCalls(), ShouldHaveLength, 1)
+
+				filters := mockedDataStore.StreamCSVRowsCalls()[0].Filter.DimensionFilters
+				So(filters, ShouldHaveLength, 2)
+				for _, filter := range filters {
+					if filter.Name == "time" {
+						So(filter.Options, ShouldResemble, []string{"2015", "2016", "2017"})
+					} else {
+						So(filter.Options, ShouldResemble, []string{"cpi1dim1S40403"})
+					}
+				}
+			})
+		})
 
-				auditor.AssertRecordCalls(
-					auditortest.Expected{Action: getObservationsAction, Result: audit.Attempted, Params: auditParams},
-					auditortest.Expected{Action: getObservationsAction, Result: audit.Unsuccessful, Params: auditParams},
-				)
+		Convey("When observations are requested for a malformed range of time points", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations?time=2015..&aggregate=cpi1dim1S40403", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then a bad request is returned without querying the observations", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, models.ErrTimeRangeInvalid.Error())
+				So(mockedDataStore.GetDimensionOptionsCalls(), ShouldHaveLength, 0)
+				So(mockedDataStore.StreamCSVRowsCalls(), ShouldHaveLength, 0)
 			})
 		})
+	})
+}
 
-		Convey("when datastore.getEdition returns an error", func() {
-			auditor := auditortest.NewErroring(getObservationsAction, audit.Unsuccessful)
+func TestGetObservationsOptionRange(t *testing.T) {
+	t.Parallel()
+	Convey("Given a version with an ordinal age dimension and a nominal sex dimension", t, func() {
+		dimensions := []models.Dimension{
+			{Name: "age", HRef: "http://localhost:8081/code-lists/age", Ordinal: true},
+			{Name: "sex", HRef: "http://localhost:8081/code-lists/sex"},
+		}
 
-			mockedDataStore := &storetest.StorerMock{
+		mockRowReader := &observationtest.CSVRowReaderMock{
+			ReadFunc: func() (string, error) {
+				return "", io.EOF
+			},
+			CloseFunc: func(context.Context) error {
+				return nil
+			},
+		}
+
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					Dimensions: dimensions,
+					Headers:    []string{"v4_0", "age_code", "age", "sex_code", "sex"},
+					Links: &models.VersionLinks{
+						Version: &models.LinkObject{HRef: "http://localhost:8080/datasets/123/editions/2017/versions/1", ID: "1"},
+					},
+					State: models.PublishedState,
+				}, nil
+			},
+			GetDimensionOptionsFunc: func(version *models.Version, dimension string) (*models.DimensionOptionResults, error) {
+				return &models.DimensionOptionResults{Items: []models.PublicDimensionOption{
+					{Option: "15-19"}, {Option: "20-24"}, {Option: "25-29"}, {Option: "30-34"}, {Option: "35-39"}, {Option: "40-44"},
+				}}, nil
+			},
+			GetDimensionOptionsByCodesFunc: func(instanceID, dimension string, codes []string) (*models.DimensionOptionResults, error) {
+				if codes[0] == "not..stated" {
+					return &models.DimensionOptionResults{Items: []models.PublicDimensionOption{{Option: "not..stated"}}}, nil
+				}
+				return &models.DimensionOptionResults{}, nil
+			},
+			StreamCSVRowsFunc: func(context.Context, *observation.Filter, *int) (observation.StreamRowReader, error) {
+				return mockRowReader, nil
+			},
+		}
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When observations are requested for a range of ages", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/123/editions/2017/versions/1/observations?age=20-24..35-39&sex=female", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the observations are filtered on every age option within the range, in order", func() {
+				So(mockedDataStore.GetDimensionOptionsCalls(), ShouldHaveLength, 1)
+				So(mockedDataStore.GetDimensionOptionsCalls()[0].Dimension, ShouldEqual, "age")
+				So(mockedDataStore.StreamCSVRowsCalls(), ShouldHaveLength, 1)
+
+				filters := mockedDataStore.StreamCSVRowsCalls()[0].Filter.DimensionFilters
+				So(filters, ShouldHaveLength, 2)
+				for _, filter := range filters {
+					if filter.Name == "age" {
+						So(filter.Options, ShouldResemble, []string{"20-24", "25-29", "30-34", "35-39"})
+					} else {
+						So(filter.Options, ShouldResemble, []string{"female"})
+					}
+				}
+			})
+		})
+
+		Convey("When observations are requested for a range of ages which are not options", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/123/editions/2017/versions/1/observations?age=20-24..90-94&sex=female", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then a bad request is returned without querying the observations", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, models.ErrOptionRangeInvalid.Error())
+				So(mockedDataStore.StreamCSVRowsCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When observations are requested for a range on the nominal dimension", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/123/editions/2017/versions/1/observations?age=20-24&sex=female..male", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then a bad request is returned without querying the options or observations", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errorRangeNotAllowed("sex").Error())
+				So(mockedDataStore.GetDimensionOptionsCalls(), ShouldHaveLength, 0)
+				So(mockedDataStore.StreamCSVRowsCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When observations are requested for an option of the nominal dimension which looks like a range", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/123/editions/2017/versions/1/observations?age=20-24&sex=not..stated", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the observations are filtered on the option itself", func() {
+				So(mockedDataStore.GetDimensionOptionsByCodesCalls(), ShouldHaveLength, 1)
+				So(mockedDataStore.StreamCSVRowsCalls(), ShouldHaveLength, 1)
+
+				for _, filter := range mockedDataStore.StreamCSVRowsCalls()[0].Filter.DimensionFilters {
+					if filter.Name == "sex" {
+						So(filter.Options, ShouldResemble, []string{"not..stated"})
+					}
+				}
+			})
+		})
+	})
+}
+
+func TestGetObservationRow(t *testing.T) {
+	t.Parallel()
+	Convey("Given a version with four observation rows", t, func() {
+		rows := []string{
+			"v4_0,time,time,aggregate_code,aggregate",
+			"101.1,Month,Aug-16,cpi1dim1G10100,01.1 Food",
+			"102.2,Month,Aug-16,cpi1dim1G10200,01.2 Non-alcoholic beverages",
+			"103.3,Month,Aug-16,cpi1dim1S10201,01.2.1 Coffee",
+			"104.4,Month,Aug-16,cpi1dim1S10202,01.2.2 Tea",
+		}
+		mockRowReader := &observationtest.CSVRowReaderMock{
+			ReadFunc: func() (string, error) {
+				if len(rows) == 0 {
+					return "", io.EOF
+				}
+				row := rows[0]
+				rows = rows[1:]
+				return row, nil
+			},
+			CloseFunc: func(context.Context) error {
+				return nil
+			},
+		}
+
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Next: &models.Dataset{State: models.AssociatedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					ID: "789",
+					Dimensions: []models.Dimension{
+						{Name: "aggregate", HRef: "http://localhost:8081/code-lists/cpih1dim1aggid"},
+						{Name: "time", HRef: "http://localhost:8081/code-lists/time"},
+					},
+					Headers: []string{"v4_0", "time", "time", "aggregate_code", "aggregate"},
+					Links: &models.VersionLinks{
+						Version: &models.LinkObject{HRef: "http://localhost:8080/datasets/cpih012/editions/2017/versions/1", ID: "1"},
+					},
+					State: models.AssociatedState,
+				}, nil
+			},
+			StreamCSVRowsFunc: func(context.Context, *observation.Filter, *int) (observation.StreamRowReader, error) {
+				return mockRowReader, nil
+			},
+		}
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When row 2 is requested by an authorised caller", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations?row=2", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the third observation of the stream is returned, without reading the rows after it", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var doc models.ObservationsDoc
+				So(json.Unmarshal(w.Body.Bytes(), &doc), ShouldBeNil)
+				So(doc.Offset, ShouldEqual, 2)
+				So(doc.Limit, ShouldEqual, 1)
+				So(doc.Observations, ShouldHaveLength, 1)
+				So(doc.Observations[0].Observation, ShouldEqual, "103.3")
+				So(doc.Observations[0].Dimensions["aggregate"].ID, ShouldEqual, "cpi1dim1S10201")
+
+				So(mockedDataStore.StreamCSVRowsCalls(), ShouldHaveLength, 1)
+				So(mockedDataStore.StreamCSVRowsCalls()[0].Filter.InstanceID, ShouldEqual, "789")
+				So(mockedDataStore.StreamCSVRowsCalls()[0].Filter.DimensionFilters, ShouldBeEmpty)
+				So(*mockedDataStore.StreamCSVRowsCalls()[0].Limit, ShouldEqual, 3)
+				So(mockRowReader.ReadCalls(), ShouldHaveLength, 4)
+				So(mockRowReader.CloseCalls(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When a row beyond the end of the stream is requested", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations?row=4", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then not found is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrObservationRowNotFound.Error())
+			})
+		})
+
+		Convey("When a negative row is requested", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations?row=-1", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then a bad request is returned without querying the observations", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrObservationRowInvalid.Error())
+				So(mockedDataStore.StreamCSVRowsCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When a row is requested along with dimension options", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations?row=2&time=Aug-16", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then a bad request is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errorRowWithQueryParameters().Error())
+			})
+		})
+	})
+}
+
+func TestGetObservationAuditAttemptedError(t *testing.T) {
+	Convey("given audit action attempted returns an error", t, func() {
+		auditor := auditortest.NewErroring(getObservationsAction, audit.Attempted)
+
+		mockedDataStore := &storetest.StorerMock{}
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+
+		Convey("when get observation is called", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations?time=16-Aug&aggregate=cpi1dim1S40403&geography=K02000001", nil)
+			w := httptest.NewRecorder()
+
+			api.Router.ServeHTTP(w, r)
+
+			Convey("then a 500 response status is returned", func() {
+				assertInternalServerErr(w)
+				So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+				So(permissions.Required.Calls, ShouldEqual, 0)
+				So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 0)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{
+						Action: getObservationsAction,
+						Result: audit.Attempted,
+						Params: common.Params{"dataset_id": "cpih012", "edition": "2017", "version": "1"}},
+				)
+			})
+		})
+	})
+}
+
+func TestGetObservationAuditUnsuccessfulError(t *testing.T) {
+	auditParams := common.Params{"dataset_id": "cpih012", "edition": "2017", "version": "1"}
+
+	Convey("given audit action unsuccessful returns an error", t, func() {
+
+		Convey("when datastore.getDataset returns an error", func() {
+			auditor := auditortest.NewErroring(getObservationsAction, audit.Unsuccessful)
+
+			mockedDataStore := &storetest.StorerMock{
+				GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+					return nil, errs.ErrDatasetNotFound
+				},
+			}
+
+			datasetPermissions := getAuthorisationHandlerMock()
+			permissions := getAuthorisationHandlerMock()
+			api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations?time=16-Aug&aggregate=cpi1dim1S40403&geography=K02000001", nil)
+			w := httptest.NewRecorder()
+
+			api.Router.ServeHTTP(w, r)
+
+			Convey("then a 500 response status is returned", func() {
+				assertInternalServerErr(w)
+				So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+				So(permissions.Required.Calls, ShouldEqual, 0)
+				So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
+				So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.StreamCSVRowsCalls()), ShouldEqual, 0)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{Action: getObservationsAction, Result: audit.Attempted, Params: auditParams},
+					auditortest.Expected{Action: getObservationsAction, Result: audit.Unsuccessful, Params: auditParams},
+				)
+			})
+		})
+
+		Convey("when datastore.getEdition returns an error", func() {
+			auditor := auditortest.NewErroring(getObservationsAction, audit.Unsuccessful)
+
+			mockedDataStore := &storetest.StorerMock{
 				GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
 					return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 				},
@@ -1258,6 +1686,147 @@ func TestGetObservationAuditSuccessfulError(t *testing.T) {
 	})
 }
 
+func TestGetObservationCountReturnsOK(t *testing.T) {
+	t.Parallel()
+	Convey("Given a published version of a dataset with a stored count of observations", t, func() {
+		totalObservations := 1500
+
+		count := 0
+		mockRowReader := &observationtest.CSVRowReaderMock{
+			ReadFunc: func() (string, error) {
+				count++
+				if count == 1 {
+					return "v4_0,time,time,geography_code,geography,aggregate_code,aggregate", nil
+				} else if count <= 3 {
+					return "146.3,Month,Aug-16,K02000001,,cpi1dim1G10100,01.1 Food", nil
+				}
+				return "", io.EOF
+			},
+			CloseFunc: func(context.Context) error {
+				return nil
+			},
+		}
+
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					ID:                "789",
+					State:             models.PublishedState,
+					TotalObservations: &totalObservations,
+				}, nil
+			},
+			StreamCSVRowsFunc: func(context.Context, *observation.Filter, *int) (observation.StreamRowReader, error) {
+				return mockRowReader, nil
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+		auditParams := common.Params{"dataset_id": "cpih012", "edition": "2017", "version": "1"}
+
+		Convey("When a request is made for the count of observations", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations/count", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the stored count is returned without querying the observation store", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, `{"count":1500}`)
+
+				So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+				So(permissions.Required.Calls, ShouldEqual, 0)
+				So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 1)
+				So(len(mockedDataStore.StreamCSVRowsCalls()), ShouldEqual, 0)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{Action: getObservationCountAction, Result: audit.Attempted, Params: auditParams},
+					auditortest.Expected{Action: getObservationCountAction, Result: audit.Successful, Params: auditParams},
+				)
+			})
+		})
+
+		Convey("When an unauthorised caller requests a fresh count of observations", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations/count?fresh=true", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the stored count is returned without querying the observation store", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, `{"count":1500}`)
+				So(len(mockedDataStore.StreamCSVRowsCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When an authorised caller requests a fresh count of observations", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations/count?fresh=true", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the count is computed from the observation store", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, `{"count":2}`)
+
+				So(len(mockedDataStore.StreamCSVRowsCalls()), ShouldEqual, 1)
+				So(mockedDataStore.StreamCSVRowsCalls()[0].Filter.InstanceID, ShouldEqual, "789")
+				So(mockedDataStore.StreamCSVRowsCalls()[0].Limit, ShouldBeNil)
+				So(len(mockRowReader.ReadCalls()), ShouldEqual, 4)
+				So(len(mockRowReader.CloseCalls()), ShouldEqual, 1)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{Action: getObservationCountAction, Result: audit.Attempted, Params: auditParams},
+					auditortest.Expected{Action: getObservationCountAction, Result: audit.Successful, Params: auditParams},
+				)
+			})
+		})
+	})
+}
+
+func TestGetObservationCountReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("Given the version does not exist", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return nil, errs.ErrVersionNotFound
+			},
+		}
+
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When a request is made for the count of observations", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations/count", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then a 404 status response is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrVersionNotFound.Error())
+
+				auditParams := common.Params{"dataset_id": "cpih012", "edition": "2017", "version": "1"}
+				auditor.AssertRecordCalls(
+					auditortest.Expected{Action: getObservationCountAction, Result: audit.Attempted, Params: auditParams},
+					auditortest.Expected{Action: getObservationCountAction, Result: audit.Unsuccessful, Params: auditParams},
+				)
+			})
+		})
+	})
+}
+
 func getTestData(filename string) string {
 	jsonBytes, err := ioutil.ReadFile("./observation_test_data/" + filename + ".json")
 	if err != nil {
@@ -1272,3 +1841,170 @@ func getTestData(filename string) string {
 
 	return buffer.String()
 }
+
+func TestGetObservationDimensionsReturnsOK(t *testing.T) {
+	t.Parallel()
+	Convey("Given a published version of a dataset with three dimensions", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					ID: "789",
+					Dimensions: []models.Dimension{
+						{Name: "aggregate"},
+						{Name: "geography"},
+						{Name: "time"},
+					},
+					Headers: []string{"v4_0", "time", "time", "geography_code", "geography", "aggregate_code", "aggregate"},
+					State:   models.PublishedState,
+				}, nil
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+
+		Convey("When a request is made for the queryable dimensions", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations/dimensions", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then each dimension is returned as accepting a wildcard", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, `{"items":[{"name":"aggregate","wildcard":true},{"name":"geography","wildcard":true},{"name":"time","wildcard":true}]}`)
+
+				So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+				So(permissions.Required.Calls, ShouldEqual, 0)
+				So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 1)
+
+				auditParams := common.Params{"dataset_id": "cpih012", "edition": "2017", "version": "1"}
+				auditor.AssertRecordCalls(
+					auditortest.Expected{Action: getObservationDimensionsAction, Result: audit.Attempted, Params: auditParams},
+					auditortest.Expected{Action: getObservationDimensionsAction, Result: audit.Successful, Params: auditParams},
+				)
+			})
+		})
+	})
+}
+
+func TestGetObservationDimensionsReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("Given a version with malformed headers", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					ID:         "789",
+					Dimensions: []models.Dimension{{Name: "time"}},
+					Headers:    []string{"v4", "time", "time"},
+					State:      models.PublishedState,
+				}, nil
+			},
+		}
+
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When a request is made for the queryable dimensions", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations/dimensions", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then a bad request is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrMalformedVersionHeaders.Error())
+
+				auditParams := common.Params{"dataset_id": "cpih012", "edition": "2017", "version": "1"}
+				auditor.AssertRecordCalls(
+					auditortest.Expected{Action: getObservationDimensionsAction, Result: audit.Attempted, Params: auditParams},
+					auditortest.Expected{Action: getObservationDimensionsAction, Result: audit.Unsuccessful, Params: auditParams},
+				)
+			})
+		})
+	})
+}
+
+func TestGetObservationsMetadataReturnsOK(t *testing.T) {
+	t.Parallel()
+	Convey("Given a published version with usage notes and data markings", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState, UnitOfMeasure: "Pounds Sterling"}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					ID:         "789",
+					Dimensions: []models.Dimension{{Name: "time"}},
+					Headers:    []string{"v4_1", "data_marking", "time_codelist", "time"},
+					Links: &models.VersionLinks{
+						Version: &models.LinkObject{ID: "1", HRef: "http://localhost:8080/datasets/cpih012/editions/2017/versions/1"},
+					},
+					State:      models.PublishedState,
+					UsageNotes: &[]models.UsageNote{{Title: "Data markings", Note: "p indicates a provisional estimate"}},
+				}, nil
+			},
+		}
+
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When a request is made for the observations metadata", func() {
+			r := httptest.NewRequest("GET", "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations/metadata", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the metadata block combines the usage notes, unit of measure and metadata columns", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var metadata models.ObservationsMetadata
+				So(json.Unmarshal(w.Body.Bytes(), &metadata), ShouldBeNil)
+				So(metadata.UsageNotes, ShouldResemble, &[]models.UsageNote{{Title: "Data markings", Note: "p indicates a provisional estimate"}})
+				So(metadata.UnitOfMeasure, ShouldEqual, "Pounds Sterling")
+				So(metadata.DataMarkings, ShouldBeTrue)
+				So(metadata.ConfidenceIntervals, ShouldBeFalse)
+				So(metadata.Links.Self.HRef, ShouldEqual, "http://localhost:8080/datasets/cpih012/editions/2017/versions/1/observations/metadata")
+				So(len(mockedDataStore.StreamCSVRowsCalls()), ShouldEqual, 0)
+
+				auditParams := common.Params{"dataset_id": "cpih012", "edition": "2017", "version": "1"}
+				auditor.AssertRecordCalls(
+					auditortest.Expected{Action: getObservationsMetadataAction, Result: audit.Attempted, Params: auditParams},
+					auditortest.Expected{Action: getObservationsMetadataAction, Result: audit.Successful, Params: auditParams},
+				)
+			})
+		})
+	})
+}
+
+func TestGetObservationDimensionList(t *testing.T) {
+	t.Parallel()
+	Convey("Given version headers with observation metadata and a dimension without a label column", t, func() {
+		headers := []string{"v4_1", "data_marking", "time_codelist", "time", "geography_codelist", "geography"}
+		dimensions := []models.Dimension{{Name: "time"}, {Name: "geography"}, {Name: "aggregate"}}
+
+		Convey("Then only the dimensions with a label column accept a wildcard", func() {
+			api := &DatasetAPI{}
+			list, err := api.getObservationDimensionList(headers, dimensions)
+			So(err, ShouldBeNil)
+			So(list, ShouldResemble, []models.ObservationDimension{
+				{Name: "time", Wildcard: true},
+				{Name: "geography", Wildcard: true},
+				{Name: "aggregate", Wildcard: false},
+			})
+		})
+	})
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+)
+
+// getPaginationParameters reads the offset and limit query parameters of a request for a paginated endpoint. When
+// no limit is given the configured default page size is used, and a limit above the configured maximum page size
+// is reduced to the maximum.
+func (api *DatasetAPI) getPaginationParameters(r *http.Request) (offset, limit int, err error) {
+	query := r.URL.Query()
+
+	limit = api.defaultPageSize
+
+	if value := query.Get("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			return 0, 0, errs.ErrInvalidPaginationParameter
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			return 0, 0, errs.ErrInvalidPaginationParameter
+		}
+	}
+
+	if limit > api.maxPageSize {
+		limit = api.maxPageSize
+	}
+
+	return offset, limit, nil
+}
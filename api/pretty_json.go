@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/justinas/alice"
+)
+
+// prettyParam is the query parameter callers give to have JSON responses indented, e.g. when debugging with curl
+const prettyParam = "pretty"
+
+// prettyJSON returns middleware indenting JSON responses when the request has ?pretty=true, or for every request
+// when enabled by the configuration, which ?pretty=false overrides. The parameter is removed from the request so
+// handlers validating their query parameters do not reject it. Responses are written compact by the handlers, so
+// an indented response is held until the handler has finished, other responses are passed straight through.
+func prettyJSON(enabled bool) alice.Constructor {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pretty := enabled
+
+			query := r.URL.Query()
+			if values, ok := query[prettyParam]; ok {
+				if value, err := strconv.ParseBool(values[0]); err == nil {
+					pretty = value
+				}
+				query.Del(prettyParam)
+				r.URL.RawQuery = query.Encode()
+			}
+
+			if !pretty {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			rw := &prettyJSONResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			h.ServeHTTP(rw, r)
+			rw.writeIndented()
+		})
+	}
+}
+
+// prettyJSONResponseWriter holds back a JSON response so it can be indented, any other response is written as is
+type prettyJSONResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	isJSON      bool
+	body        bytes.Buffer
+}
+
+func (rw *prettyJSONResponseWriter) WriteHeader(status int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+	rw.status = status
+
+	mediaType, _, err := mime.ParseMediaType(rw.Header().Get("Content-Type"))
+	rw.isJSON = err == nil && mediaType == jsonMediaType
+	if !rw.isJSON {
+		rw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (rw *prettyJSONResponseWriter) Write(b []byte) (int, error) {
+	rw.WriteHeader(http.StatusOK)
+	if !rw.isJSON {
+		return rw.ResponseWriter.Write(b)
+	}
+	return rw.body.Write(b)
+}
+
+// Flush passes a flush straight through for responses which are not held back, so they can be streamed
+func (rw *prettyJSONResponseWriter) Flush() {
+	if rw.wroteHeader && rw.isJSON {
+		return
+	}
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// writeIndented writes a held back JSON response, indented unless it is not valid JSON
+func (rw *prettyJSONResponseWriter) writeIndented() {
+	if !rw.isJSON {
+		return
+	}
+
+	body := rw.body.Bytes()
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err == nil {
+		body = indented.Bytes()
+	}
+
+	rw.Header().Del("Content-Length")
+	rw.ResponseWriter.WriteHeader(rw.status)
+	rw.ResponseWriter.Write(body)
+}
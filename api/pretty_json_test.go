@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPrettyJSON(t *testing.T) {
+	t.Parallel()
+	Convey("Given pretty JSON middleware wrapping handlers", t, func() {
+		var query string
+		jsonHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+			setJSONContentType(w)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"123","state":"created"}`))
+		})
+		csvHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Write([]byte("id,state\n123,created\n"))
+		})
+
+		Convey("When a JSON response is requested with pretty=true then it is indented", func() {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123?pretty=true&state=created", nil)
+			prettyJSON(false)(jsonHandler).ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusCreated)
+			So(w.Body.String(), ShouldEqual, "{\n  \"id\": \"123\",\n  \"state\": \"created\"\n}")
+			So(query, ShouldEqual, "state=created")
+		})
+
+		Convey("When a JSON response is requested without pretty then it is compact", func() {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123", nil)
+			prettyJSON(false)(jsonHandler).ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusCreated)
+			So(w.Body.String(), ShouldEqual, `{"id":"123","state":"created"}`)
+		})
+
+		Convey("When pretty JSON is enabled by the configuration then pretty=false gives a compact response", func() {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123", nil)
+			prettyJSON(true)(jsonHandler).ServeHTTP(w, r)
+			So(w.Body.String(), ShouldEqual, "{\n  \"id\": \"123\",\n  \"state\": \"created\"\n}")
+
+			w = httptest.NewRecorder()
+			r = httptest.NewRequest("GET", "http://localhost:22000/datasets/123?pretty=false", nil)
+			prettyJSON(true)(jsonHandler).ServeHTTP(w, r)
+			So(w.Body.String(), ShouldEqual, `{"id":"123","state":"created"}`)
+		})
+
+		Convey("When a response which is not JSON is requested with pretty=true then it is unchanged", func() {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123/export?pretty=true", nil)
+			prettyJSON(false)(csvHandler).ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldEqual, "id,state\n123,created\n")
+		})
+	})
+}
@@ -19,8 +19,9 @@ import (
 
 // PublishCheck Checks if an version has been published
 type PublishCheck struct {
-	Datastore store.Storer
-	Auditor   audit.AuditorService
+	Datastore  store.Storer
+	Auditor    audit.AuditorService
+	JSONLimits models.JSONLimits
 }
 
 // Check wraps a HTTP handle. Checks that the state is not published
@@ -45,7 +46,7 @@ func (d *PublishCheck) Check(handle func(http.ResponseWriter, *http.Request), ac
 				}
 
 				request.DrainBody(r)
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				errs.WriteError(w, err, http.StatusInternalServerError)
 				return
 			}
 			// If document cannot be found do not handle error
@@ -57,31 +58,36 @@ func (d *PublishCheck) Check(handle func(http.ResponseWriter, *http.Request), ac
 			if currentVersion.State == models.PublishedState {
 
 				// We can allow public download links to be modified by the exporter
-				// services when a version is published. Note that a new version will be
-				// created which contain only the download information to prevent any
-				// forbidden fields from being set on the published version
+				// services when a version is published, and its labels as they are
+				// metadata. Note that a new version will be created which contain only
+				// the download information and labels to prevent any forbidden fields
+				// from being set on the published version
 
 				// TODO Logic here might require it's own endpoint,
 				// possibly /datasets/.../versions/<version>/downloads
 				if action == updateVersionAction {
-					versionDoc, err := models.CreateVersion(r.Body)
+					versionDoc, err := models.CreateVersion(r.Body, d.JSONLimits)
 					if err != nil {
 						log.ErrorCtx(ctx, errors.WithMessage(err, "failed to model version resource based on request"), data)
 
 						if auditErr := d.Auditor.Record(ctx, action, audit.Unsuccessful, auditParams); auditErr != nil {
 							request.DrainBody(r)
-							http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
+							errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
 							return
 						}
 
 						request.DrainBody(r)
-						http.Error(w, err.Error(), http.StatusBadRequest)
+						errs.WriteError(w, err, http.StatusBadRequest)
 						return
 					}
 
-					if versionDoc.Downloads != nil {
-						newVersion := &models.Version{Downloads: &models.DownloadList{}}
-						if versionDoc.Downloads.CSV != nil && versionDoc.Downloads.CSV.Public != "" {
+					if versionDoc.Downloads != nil || versionDoc.Labels != nil {
+						newVersion := &models.Version{Labels: versionDoc.Labels}
+						if versionDoc.Downloads != nil {
+							newVersion.Downloads = &models.DownloadList{}
+						}
+
+						if versionDoc.Downloads != nil && versionDoc.Downloads.CSV != nil && versionDoc.Downloads.CSV.Public != "" {
 							newVersion.Downloads.CSV = &models.DownloadObject{
 								Public: versionDoc.Downloads.CSV.Public,
 								Size:   versionDoc.Downloads.CSV.Size,
@@ -89,7 +95,7 @@ func (d *PublishCheck) Check(handle func(http.ResponseWriter, *http.Request), ac
 							}
 						}
 
-						if versionDoc.Downloads.CSVW != nil && versionDoc.Downloads.CSVW.Public != "" {
+						if versionDoc.Downloads != nil && versionDoc.Downloads.CSVW != nil && versionDoc.Downloads.CSVW.Public != "" {
 							newVersion.Downloads.CSVW = &models.DownloadObject{
 								Public: versionDoc.Downloads.CSVW.Public,
 								Size:   versionDoc.Downloads.CSVW.Size,
@@ -97,7 +103,7 @@ func (d *PublishCheck) Check(handle func(http.ResponseWriter, *http.Request), ac
 							}
 						}
 
-						if versionDoc.Downloads.XLS != nil && versionDoc.Downloads.XLS.Public != "" {
+						if versionDoc.Downloads != nil && versionDoc.Downloads.XLS != nil && versionDoc.Downloads.XLS.Public != "" {
 							newVersion.Downloads.XLS = &models.DownloadObject{
 								Public: versionDoc.Downloads.XLS.Public,
 								Size:   versionDoc.Downloads.XLS.Size,
@@ -113,12 +119,12 @@ func (d *PublishCheck) Check(handle func(http.ResponseWriter, *http.Request), ac
 
 								if auditErr := d.Auditor.Record(ctx, action, audit.Unsuccessful, auditParams); auditErr != nil {
 									request.DrainBody(r)
-									http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
+									errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
 									return
 								}
 
 								request.DrainBody(r)
-								http.Error(w, err.Error(), http.StatusForbidden)
+								errs.WriteError(w, err, http.StatusForbidden)
 								return
 							}
 
@@ -127,7 +133,8 @@ func (d *PublishCheck) Check(handle func(http.ResponseWriter, *http.Request), ac
 							}
 
 							// Set variable `has_downloads` to true to prevent request
-							// triggering version from being republished
+							// triggering version from being republished, whether it
+							// changes the downloads or only the labels
 							vars[hasDownloads] = trueStringified
 							r.Body = ioutil.NopCloser(bytes.NewBuffer(b))
 							handle(w, r)
@@ -141,12 +148,12 @@ func (d *PublishCheck) Check(handle func(http.ResponseWriter, *http.Request), ac
 				log.ErrorCtx(ctx, err, data)
 				if auditErr := d.Auditor.Record(ctx, action, audit.Unsuccessful, auditParams); auditErr != nil {
 					request.DrainBody(r)
-					http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
+					errs.WriteError(w, errs.ErrInternalServer, http.StatusInternalServerError)
 					return
 				}
 
 				request.DrainBody(r)
-				http.Error(w, err.Error(), http.StatusForbidden)
+				errs.WriteError(w, err, http.StatusForbidden)
 				return
 			}
 		}
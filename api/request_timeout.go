@@ -0,0 +1,217 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/justinas/alice"
+)
+
+// requestTimedOutKey is the context key of the response writer recording whether the request has timed out
+const requestTimedOutKey = contextKey("request-timed-out")
+
+// streamingWriteDeadlineKey is the name the streaming write deadline middleware is registered with on the server
+const streamingWriteDeadlineKey = "StreamingWriteDeadline"
+
+// writeDeadlineKey is the context key of the function extending the write deadline of the connection
+const writeDeadlineKey = contextKey("write-deadline")
+
+// requestTimeout returns middleware running each request under a context which is cancelled after the timeout. A
+// request which has not been handled by then is answered with 503 Service Unavailable, so a wedged call to mongo or
+// the graph database cannot hold a connection open indefinitely. Anything the handler writes after the timeout is
+// discarded.
+//
+// The response is held until the handler has finished, unless the handler flushes it. A flushed response is a stream
+// which has already started, so the timeout no longer applies to it and the rest of the response is passed straight
+// through, with the write deadline of the connection extended on every flush.
+func requestTimeout(timeout time.Duration) alice.Constructor {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, header: make(http.Header), ctx: r.Context()}
+			ctx = context.WithValue(ctx, requestTimedOutKey, tw)
+
+			done := make(chan struct{})
+			panics := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panics <- p
+					}
+				}()
+				h.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+
+			select {
+			case p := <-panics:
+				panic(p)
+			case <-done:
+				tw.finish()
+			case <-timer.C:
+				if !tw.timeout() {
+					// the handler is streaming, so it is left to finish the response
+					select {
+					case p := <-panics:
+						panic(p)
+					case <-done:
+					}
+					return
+				}
+				cancel()
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(errs.ErrRequestTimeout.Error()))
+			}
+		})
+	}
+}
+
+// timeoutWriter holds the response of a handler until it has finished within the timeout, or until the handler
+// flushes it and so starts streaming
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	ctx    context.Context
+	header http.Header
+	body   bytes.Buffer
+	status int
+
+	mu        sync.Mutex
+	timedOut  bool
+	streaming bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.streaming || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.streaming {
+		return tw.w.Write(b)
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(b)
+}
+
+// Flush writes the held response and passes every later write straight through to the client
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return
+	}
+	if !tw.streaming {
+		tw.streaming = true
+		tw.writeHeld()
+	}
+
+	extendWriteDeadline(tw.ctx)
+	if flusher, ok := tw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish writes the held response once the handler has returned within the timeout
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if !tw.streaming {
+		tw.writeHeld()
+	}
+}
+
+// timeout marks the request as timed out, unless the handler has already started streaming the response
+func (tw *timeoutWriter) timeout() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.streaming {
+		return false
+	}
+	tw.timedOut = true
+	return true
+}
+
+func (tw *timeoutWriter) hasTimedOut() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	return tw.timedOut
+}
+
+func (tw *timeoutWriter) writeHeld() {
+	dst := tw.w.Header()
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	tw.w.WriteHeader(tw.status)
+	tw.w.Write(tw.body.Bytes())
+	tw.body.Reset()
+}
+
+// checkRequestTimeout returns an error once the request has timed out, handlers working through many items check it
+// so they stop rather than carry on with work whose response will be discarded
+func checkRequestTimeout(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return errs.ErrRequestTimeout
+	}
+	if tw, ok := ctx.Value(requestTimedOutKey).(*timeoutWriter); ok && ctx.Err() != nil && tw.hasTimedOut() {
+		return errs.ErrRequestTimeout
+	}
+	return nil
+}
+
+// streamingWriteDeadline returns middleware allowing streamed responses to outlast the write timeout of the server.
+// It must wrap the connection's own response writer, so it is added ahead of the server's middleware. Each time a
+// stream is flushed its write deadline is pushed back by the write timeout, so a client which stops reading is still
+// disconnected while a client keeping up can receive a stream of any length.
+func streamingWriteDeadline(writeTimeout time.Duration) alice.Constructor {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rc := http.NewResponseController(w)
+			extend := func() {
+				rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+			}
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), writeDeadlineKey, extend)))
+		})
+	}
+}
+
+// extendWriteDeadline pushes back the write deadline of the connection the request was received on, when the server
+// allows it
+func extendWriteDeadline(ctx context.Context) {
+	if extend, ok := ctx.Value(writeDeadlineKey).(func()); ok {
+		extend()
+	}
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/config"
+	"github.com/ONSdigital/dp-dataset-api/metrics"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/handlers/requestID"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/gorilla/mux"
+	"github.com/justinas/alice"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	t.Parallel()
+	Convey("Given a store which takes longer than the request timeout to find a dataset", t, func() {
+		release := make(chan struct{})
+		defer close(release)
+
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				<-release
+				return &models.DatasetUpdate{Current: &models.Dataset{ID: "123"}}, nil
+			},
+		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		handler := requestTimeout(50 * time.Millisecond)(api.Router)
+
+		Convey("When the dataset is requested", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123", nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			Convey("Then a service unavailable response is returned once the timeout has passed", func() {
+				So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(w.Body.String(), ShouldEqual, errs.ErrRequestTimeout.Error())
+			})
+		})
+	})
+}
+
+func TestRequestTimeoutStreaming(t *testing.T) {
+	t.Parallel()
+	Convey("Given a server with a request and write timeout shorter than the time taken to stream a response", t, func() {
+		cfg := streamingTestConfig()
+		chunks := []string{"first\n", "second\n", "third\n"}
+
+		router := mux.NewRouter()
+		router.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			for _, chunk := range chunks {
+				w.Write([]byte(chunk))
+				w.(http.Flusher).Flush()
+				time.Sleep(cfg.HTTPWriteTimeout * 3 / 4)
+			}
+		})
+		router.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(cfg.RequestTimeout * 2)
+			w.Write([]byte("too late"))
+		})
+
+		srv := newStreamingTestServer(cfg, router)
+		defer srv.Close()
+
+		Convey("When a response which is flushed as it is written is requested", func() {
+			start := time.Now()
+			resp, err := http.Get(srv.URL + "/stream")
+			So(err, ShouldBeNil)
+			defer resp.Body.Close()
+
+			Convey("Then the response starts before it has finished", func() {
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(resp.Header.Get("Content-Type"), ShouldEqual, "text/plain")
+				So(time.Since(start), ShouldBeLessThan, cfg.RequestTimeout)
+
+				Convey("And all of it is received although it outlasts both timeouts", func() {
+					So(readLines(resp), ShouldResemble, []string{"first", "second", "third"})
+					So(time.Since(start), ShouldBeGreaterThan, cfg.HTTPWriteTimeout)
+				})
+			})
+		})
+
+		Convey("When a response which is not flushed takes longer than the request timeout", func() {
+			resp, err := http.Get(srv.URL + "/slow")
+			So(err, ShouldBeNil)
+			defer resp.Body.Close()
+
+			Convey("Then a service unavailable response is returned", func() {
+				So(resp.StatusCode, ShouldEqual, http.StatusServiceUnavailable)
+				So(readLines(resp), ShouldResemble, []string{errs.ErrRequestTimeout.Error()})
+			})
+		})
+	})
+}
+
+func TestStreamInstancesThroughMiddleware(t *testing.T) {
+	t.Parallel()
+	Convey("Given a server with a request and write timeout shorter than the time taken to stream the instances", t, func() {
+		cfg := streamingTestConfig()
+
+		mockedDataStore := &storetest.StorerMock{
+			StreamInstancesFunc: func(states, datasets, isBasedOn []string, fn func(*models.Instance) error) error {
+				for _, id := range []string{"1", "2", "3"} {
+					if err := fn(&models.Instance{InstanceID: id, State: models.CompletedState}); err != nil {
+						return err
+					}
+					time.Sleep(cfg.HTTPWriteTimeout * 3 / 4)
+				}
+				return nil
+			},
+		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		srv := newStreamingTestServer(cfg, api.Router)
+		defer srv.Close()
+
+		Convey("When the instances are requested as newline delimited json", func() {
+			r, err := http.NewRequest("GET", srv.URL+"/instances", nil)
+			So(err, ShouldBeNil)
+			r.Header.Set("Accept", "application/x-ndjson")
+
+			start := time.Now()
+			resp, err := http.DefaultClient.Do(r)
+			So(err, ShouldBeNil)
+			defer resp.Body.Close()
+
+			Convey("Then the first instance is received before the stream has finished", func() {
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(resp.Header.Get("Content-Type"), ShouldEqual, "application/x-ndjson")
+				So(time.Since(start), ShouldBeLessThan, cfg.RequestTimeout)
+
+				Convey("And every instance is streamed although the stream outlasts both timeouts", func() {
+					lines := readLines(resp)
+					So(lines, ShouldHaveLength, 3)
+					for i, line := range lines {
+						var instance models.Instance
+						So(json.Unmarshal([]byte(line), &instance), ShouldBeNil)
+						So(instance.InstanceID, ShouldEqual, strconv.Itoa(i+1))
+					}
+					So(time.Since(start), ShouldBeGreaterThan, cfg.HTTPWriteTimeout)
+				})
+			})
+		})
+	})
+}
+
+// streamingTestConfig returns the configuration for a server whose request and write timeouts are short enough to be
+// outlasted in a test. The configuration is shared by every test, so a copy is returned.
+func streamingTestConfig() config.Configuration {
+	c, err := config.Get()
+	So(err, ShouldBeNil)
+
+	cfg := *c
+	cfg.EnablePrivateEnpoints = false
+	cfg.RequestTimeout = 50 * time.Millisecond
+	cfg.HTTPWriteTimeout = 100 * time.Millisecond
+	return cfg
+}
+
+// newStreamingTestServer starts a server handling requests to the router through the same middleware as the api,
+// with the caller set as the identity middleware would in publishing
+func newStreamingTestServer(cfg config.Configuration, router *mux.Router) *httptest.Server {
+	middleware, err := newMiddleware(cfg, router, metrics.NewRegistry())
+	So(err, ShouldBeNil)
+
+	setCaller := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(w, r.WithContext(common.SetCaller(r.Context(), "someone@ons.gov.uk")))
+		})
+	}
+	handler := alice.New(streamingWriteDeadline(cfg.HTTPWriteTimeout), requestID.Handler(16), log.Handler, setCaller).Then(middleware.Then(router))
+
+	srv := httptest.NewUnstartedServer(handler)
+	configureHTTPServer(srv.Config, cfg)
+	srv.Start()
+	return srv
+}
+
+func readLines(resp *http.Response) []string {
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	So(scanner.Err(), ShouldBeNil)
+	return lines
+}
+
+func TestCheckRequestTimeout(t *testing.T) {
+	t.Parallel()
+	Convey("Given a request context which has not timed out then no error is returned", t, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		So(checkRequestTimeout(ctx), ShouldBeNil)
+	})
+
+	Convey("Given a request context which has been cancelled by the client then no error is returned", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		So(checkRequestTimeout(ctx), ShouldBeNil)
+	})
+
+	Convey("Given a request context which has timed out then a request timeout error is returned", t, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+		<-ctx.Done()
+
+		So(checkRequestTimeout(ctx), ShouldEqual, errs.ErrRequestTimeout)
+	})
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-dataset-api/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConfigureHTTPServer(t *testing.T) {
+	t.Parallel()
+	Convey("Given configured server timeouts and header limit", t, func() {
+		cfg := config.Configuration{
+			HTTPReadTimeout:    3 * time.Second,
+			HTTPWriteTimeout:   7 * time.Second,
+			HTTPIdleTimeout:    90 * time.Second,
+			HTTPMaxHeaderBytes: 8192,
+		}
+
+		Convey("When the http server is configured", func() {
+			srv := &http.Server{ReadTimeout: 5 * time.Second, WriteTimeout: 10 * time.Second}
+			configureHTTPServer(srv, cfg)
+
+			Convey("Then the configured values are applied to the server", func() {
+				So(srv.ReadTimeout, ShouldEqual, 3*time.Second)
+				So(srv.WriteTimeout, ShouldEqual, 7*time.Second)
+				So(srv.IdleTimeout, ShouldEqual, 90*time.Second)
+				So(srv.MaxHeaderBytes, ShouldEqual, 8192)
+			})
+		})
+	})
+}
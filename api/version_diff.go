@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// diffVersion reports the dimensions added to, removed from and changed in a version compared with another version
+// of the same edition, given by the against query parameter, so editors can review what a new version changes
+func (api *DatasetAPI) diffVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	datasetID := vars["dataset_id"]
+	edition := vars["edition"]
+	version := vars["version"]
+	against := r.URL.Query().Get("against")
+	auditParams := common.Params{"dataset_id": datasetID, "edition": edition, "version": version, "against": against}
+	logData := audit.ToLogData(auditParams)
+
+	if auditErr := api.auditor.Record(ctx, diffVersionAction, audit.Attempted, auditParams); auditErr != nil {
+		handleVersionAPIErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	b, err := func() ([]byte, error) {
+		if number, err := strconv.Atoi(against); err != nil || number < 1 {
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrVersionDiffAgainstInvalid, "diffVersion endpoint: invalid against parameter"), logData)
+			return nil, errs.ErrVersionDiffAgainstInvalid
+		}
+
+		versionDoc, err := api.dataStore.Backend.GetVersion(datasetID, edition, version, "")
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "diffVersion endpoint: failed to find version for dataset edition"), logData)
+			return nil, err
+		}
+
+		againstDoc, err := api.dataStore.Backend.GetVersion(datasetID, edition, against, "")
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "diffVersion endpoint: failed to find version to compare against"), logData)
+			return nil, err
+		}
+
+		b, err := json.Marshal(models.DiffVersionDimensions(versionDoc, againstDoc))
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "diffVersion endpoint: failed to marshal version diff into bytes"), logData)
+			return nil, err
+		}
+		return b, nil
+	}()
+
+	if err != nil {
+		if auditErr := api.auditor.Record(ctx, diffVersionAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleVersionAPIErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := api.auditor.Record(ctx, diffVersionAction, audit.Successful, auditParams); auditErr != nil {
+		handleVersionAPIErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	setJSONContentType(w)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "diffVersion endpoint: error writing bytes to response"), logData)
+	}
+	log.InfoCtx(ctx, "diffVersion endpoint: request successful", logData)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiffVersion(t *testing.T) {
+	t.Parallel()
+	Convey("Given two versions of an edition differing by one dimension", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetVersionFunc: func(datasetID, edition, version, state string) (*models.Version, error) {
+				switch version {
+				case "1":
+					return &models.Version{Version: 1, Dimensions: []models.Dimension{{Name: "time", ID: "time"}}}, nil
+				case "2":
+					return &models.Version{Version: 2, Dimensions: []models.Dimension{{Name: "time", ID: "time"}, {Name: "geography", ID: "uk-only"}}}, nil
+				}
+				return nil, errs.ErrVersionNotFound
+			},
+		}
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When the later version is compared against the earlier one", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123/editions/2017/versions/2/diff?against=1", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the added dimension is reported", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var diff models.VersionDiff
+				So(json.Unmarshal(w.Body.Bytes(), &diff), ShouldBeNil)
+				So(diff, ShouldResemble, models.VersionDiff{
+					Version:           2,
+					Against:           1,
+					DimensionsAdded:   []string{"geography"},
+					DimensionsRemoved: []string{},
+					DimensionsChanged: []string{},
+				})
+
+				So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 2)
+				So(mockedDataStore.GetVersionCalls()[0].Version, ShouldEqual, "2")
+				So(mockedDataStore.GetVersionCalls()[1].Version, ShouldEqual, "1")
+
+				params := common.Params{"dataset_id": "123", "edition": "2017", "version": "2", "against": "1"}
+				auditor.AssertRecordCalls(
+					auditortest.Expected{Action: diffVersionAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "dataset_id": "123", "edition": "2017", "version": "2"}},
+					auditortest.Expected{Action: diffVersionAction, Result: audit.Attempted, Params: params},
+					auditortest.Expected{Action: diffVersionAction, Result: audit.Successful, Params: params},
+				)
+			})
+		})
+
+		Convey("When the version to compare against does not exist then a not found response is returned", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123/editions/2017/versions/2/diff?against=3", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrVersionNotFound.Error())
+		})
+
+		Convey("When the version to compare against is not given then a bad request is returned", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123/editions/2017/versions/2/diff", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrVersionDiffAgainstInvalid.Error())
+			So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 0)
+		})
+	})
+
+	Convey("When auditing the attempt to compare versions fails then an internal server error is returned", t, func() {
+		r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123/editions/2017/versions/2/diff?against=1", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{}
+		params := common.Params{"dataset_id": "123", "edition": "2017", "version": "2", "against": "1"}
+		auditor := auditortest.New()
+		auditor.RecordFunc = func(ctx context.Context, action string, result string, params common.Params) error {
+			// the attempt is recorded by the handler with the against parameter, after the authentication middleware
+			if _, ok := params["against"]; ok && action == diffVersionAction && result == audit.Attempted {
+				return errors.New("error")
+			}
+			return nil
+		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		assertInternalServerErr(w)
+		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 0)
+		auditor.AssertRecordCalls(
+			auditortest.Expected{Action: diffVersionAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "dataset_id": "123", "edition": "2017", "version": "2"}},
+			auditortest.Expected{Action: diffVersionAction, Result: audit.Attempted, Params: params},
+		)
+	})
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/dp-dataset-api/store"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/ONSdigital/go-ns/request"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// reasonVersionTransitionAborted is reported against the versions which could have been moved to the requested
+// state, but were left unchanged as another version of the edition could not be
+const reasonVersionTransitionAborted = "another version of the edition cannot be moved to the requested state"
+
+// transitionVersions moves every unpublished version of an edition to the requested state, publishing or
+// associating each version as a PUT of the version would. The versions are moved in a single transaction, in order
+// of version number, so either all of them are moved or, if any one of them cannot be, none are and the reason for
+// each failure is reported with a 409 Conflict response. Published versions are skipped.
+func (api *DatasetAPI) transitionVersions(w http.ResponseWriter, r *http.Request) {
+
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	datasetID := vars["dataset_id"]
+	edition := vars["edition"]
+	auditParams := common.Params{"dataset_id": datasetID, "edition": edition}
+	logData := audit.ToLogData(auditParams)
+
+	var results models.VersionTransitionResults
+
+	// the versions published by the request, notified once the transaction has been committed
+	var published []*models.Version
+
+	err := func() error {
+		transition, err := models.CreateVersionsTransition(r.Body, api.jsonLimits)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "transitionVersions endpoint: failed to model versions transition based on request"), logData)
+			return err
+		}
+
+		auditParams["state"] = transition.State
+		logData["state"] = transition.State
+		results = models.VersionTransitionResults{State: transition.State, Items: []models.VersionTransitionResult{}}
+
+		if transition.State == models.PublishedState {
+			if err := api.checkPublishAllowed(datasetID); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "transitionVersions endpoint: versions cannot be published"), logData)
+				return err
+			}
+		}
+
+		return api.dataStore.Backend.WithTransaction(func(tx store.Storer) error {
+			txAPI := api.withBackend(tx)
+			results.Count, results.Items = 0, []models.VersionTransitionResult{}
+			published = nil
+
+			if err := tx.CheckEditionExists(datasetID, edition, ""); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "transitionVersions endpoint: failed to find edition of dataset"), logData)
+				return err
+			}
+
+			versions, err := tx.GetVersions(datasetID, edition, "", nil)
+			if err == errs.ErrVersionNotFound {
+				return nil
+			}
+			if err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "transitionVersions endpoint: datastore.GetVersions returned an error"), logData)
+				return err
+			}
+
+			sort.Slice(versions.Items, func(i, j int) bool {
+				return versions.Items[i].Version < versions.Items[j].Version
+			})
+
+			// every transition is validated before any is applied, so a batch with an invalid transition is
+			// reported in full rather than stopping at the first failure
+			var pending []int
+			failed := false
+			for i := range versions.Items {
+				current := &versions.Items[i]
+				results.Items = append(results.Items, models.VersionTransitionResult{Version: current.Version, From: current.State})
+				result := &results.Items[len(results.Items)-1]
+
+				if current.State == models.PublishedState {
+					result.Status, result.Reason = models.VersionTransitionSkipped, reasonVersionPublished
+					continue
+				}
+
+				if err := api.validateVersionTransition(current, transition); err != nil {
+					result.Status, result.Reason = models.VersionTransitionFailed, err.Error()
+					failed = true
+					continue
+				}
+				pending = append(pending, i)
+			}
+
+			if failed {
+				for _, i := range pending {
+					results.Items[i].Status, results.Items[i].Reason = models.VersionTransitionAborted, reasonVersionTransitionAborted
+				}
+				return errs.ErrVersionTransitionsInvalid
+			}
+
+			for _, i := range pending {
+				if err := txAPI.transitionVersion(ctx, datasetID, edition, &versions.Items[i], transition); err != nil {
+					return err
+				}
+				results.Items[i].Status = models.VersionTransitioned
+				results.Count++
+				if transition.State == models.PublishedState {
+					published = append(published, transitionedVersion(&versions.Items[i], transition))
+				}
+			}
+			return nil
+		})
+	}()
+
+	if err != nil && err != errs.ErrVersionTransitionsInvalid {
+		if auditErr := api.auditor.Record(ctx, transitionVersionsAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleVersionAPIErr(ctx, err, w, logData)
+		return
+	}
+
+	status := http.StatusOK
+	auditResult := audit.Successful
+	if err == errs.ErrVersionTransitionsInvalid {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "transitionVersions endpoint: no versions were moved"), logData)
+		status = http.StatusConflict
+		auditResult = audit.Unsuccessful
+	}
+
+	for _, version := range published {
+		api.notifyPublished(ctx, datasetID, edition, version.Version, version)
+	}
+
+	logData["count"] = results.Count
+	auditParams["count"] = strconv.Itoa(results.Count)
+
+	if auditErr := api.auditor.Record(ctx, transitionVersionsAction, auditResult, auditParams); auditErr != nil {
+		handleVersionAPIErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	b, err := json.Marshal(results)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "transitionVersions endpoint: failed to marshal results into bytes"), logData)
+		handleVersionAPIErr(ctx, err, w, logData)
+		return
+	}
+
+	setJSONContentType(w)
+	w.WriteHeader(status)
+	if _, err = w.Write(b); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "transitionVersions endpoint: error writing bytes to response"), logData)
+	}
+	log.InfoCtx(ctx, "transitionVersions endpoint: request completed", logData)
+}
+
+// validateVersionTransition checks a version can be moved to the state of the transition, and that the version
+// would be valid once it has been
+func (api *DatasetAPI) validateVersionTransition(current *models.Version, transition *models.VersionsTransition) error {
+	if err := models.ValidateStateTransition(current.State, transition.State); err != nil {
+		return err
+	}
+
+	version := transitionedVersion(current, transition)
+	if err := models.ValidateVersion(version); err != nil {
+		return err
+	}
+
+	if version.State == models.PublishedState {
+		return api.checkPublishReleaseDate(version)
+	}
+	return nil
+}
+
+// transitionVersion moves a single version to the state of the transition, then publishes or associates it
+func (api *DatasetAPI) transitionVersion(ctx context.Context, datasetID, edition string, current *models.Version, transition *models.VersionsTransition) error {
+	versionDetails := VersionDetails{datasetID: datasetID, edition: edition, version: strconv.Itoa(current.Version)}
+	data := audit.ToLogData(versionDetails.baseAuditParams())
+
+	versionDoc := transitionedVersion(current, transition)
+	if err := api.dataStore.Backend.UpdateVersion(versionDoc.ID, versionDoc); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "transitionVersions endpoint: failed to update version document"), data)
+		return err
+	}
+
+	switch {
+	case versionDoc.State == models.PublishedState:
+		// the dataset is read for each version, as publishing the previous version will have updated it
+		currentDataset, err := api.dataStore.Backend.GetDataset(datasetID)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "transitionVersions endpoint: datastore.getDataset returned an error"), data)
+			return err
+		}
+		return api.publishVersion(ctx, currentDataset, current, versionDoc, versionDetails)
+	case versionDoc.State == models.AssociatedState && current.State != models.AssociatedState:
+		return api.associateVersion(ctx, current, versionDoc, versionDetails)
+	}
+	return nil
+}
+
+// transitionedVersion returns a copy of the version moved to the state of the transition
+func transitionedVersion(current *models.Version, transition *models.VersionsTransition) *models.Version {
+	version := *current
+	version.State = transition.State
+
+	if transition.CollectionID != "" {
+		version.CollectionID = transition.CollectionID
+	}
+
+	// published versions are no longer part of a collection
+	if version.State == models.PublishedState {
+		version.CollectionID = ""
+	}
+	return &version
+}
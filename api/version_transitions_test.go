@@ -0,0 +1,212 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const transitionVersionsURL = "http://localhost:22000/datasets/123/editions/2017/versions/transition"
+
+func editionVersions() *models.VersionResults {
+	version := func(id string, number int, state string) models.Version {
+		return models.Version{
+			ID:          id,
+			Version:     number,
+			State:       state,
+			ReleaseDate: "2017-12-12",
+			Links: &models.VersionLinks{
+				Version: &models.LinkObject{ID: "1", HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1"},
+			},
+		}
+	}
+
+	// versions are not returned in order of version number
+	return &models.VersionResults{Items: []models.Version{
+		version("c", 3, models.EditionConfirmedState),
+		version("a", 1, models.PublishedState),
+		version("b", 2, models.EditionConfirmedState),
+	}}
+}
+
+func TestTransitionVersionsReturnsOK(t *testing.T) {
+	t.Parallel()
+	Convey("Given an edition with a published version and two edition-confirmed versions", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			CheckEditionExistsFunc: func(string, string, string) error {
+				return nil
+			},
+			GetVersionsFunc: func(string, string, string, *models.ReleaseDateRange) (*models.VersionResults, error) {
+				return editionVersions(), nil
+			},
+			UpdateVersionFunc: func(string, *models.Version) error {
+				return nil
+			},
+			UpdateDatasetWithAssociationFunc: func(string, string, *models.Version) error {
+				return nil
+			},
+		}
+		generator := &mocks.DownloadsGeneratorMock{
+			GenerateFunc: func(string, string, string, string, []string) error {
+				return nil
+			},
+		}
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, generator, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When the versions are moved to the associated state", func() {
+			r, err := createRequestWithAuth("POST", transitionVersionsURL, bytes.NewBufferString(`{"state":"associated","collection_id":"collection-1"}`))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the unpublished versions are associated in order of version number", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var results models.VersionTransitionResults
+				So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+				So(results.State, ShouldEqual, models.AssociatedState)
+				So(results.Count, ShouldEqual, 2)
+				So(results.Items, ShouldResemble, []models.VersionTransitionResult{
+					{Version: 1, From: models.PublishedState, Status: models.VersionTransitionSkipped, Reason: reasonVersionPublished},
+					{Version: 2, From: models.EditionConfirmedState, Status: models.VersionTransitioned},
+					{Version: 3, From: models.EditionConfirmedState, Status: models.VersionTransitioned},
+				})
+
+				So(len(mockedDataStore.WithTransactionCalls()), ShouldEqual, 1)
+				So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 2)
+				So(mockedDataStore.UpdateVersionCalls()[0].ID, ShouldEqual, "b")
+				So(mockedDataStore.UpdateVersionCalls()[0].Version.State, ShouldEqual, models.AssociatedState)
+				So(mockedDataStore.UpdateVersionCalls()[0].Version.CollectionID, ShouldEqual, "collection-1")
+				So(mockedDataStore.UpdateVersionCalls()[1].ID, ShouldEqual, "c")
+				So(len(mockedDataStore.UpdateDatasetWithAssociationCalls()), ShouldEqual, 2)
+				So(len(generator.GenerateCalls()), ShouldEqual, 2)
+
+				auditMock.AssertRecordCalls(
+					auditortest.Expected{Action: transitionVersionsAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "dataset_id": "123", "edition": "2017"}},
+					auditortest.Expected{Action: associateVersionAction, Result: audit.Attempted, Params: common.Params{"dataset_id": "123", "edition": "2017", "version": "2"}},
+					auditortest.Expected{Action: associateVersionAction, Result: audit.Successful, Params: common.Params{"dataset_id": "123", "edition": "2017", "version": "2"}},
+					auditortest.Expected{Action: associateVersionAction, Result: audit.Attempted, Params: common.Params{"dataset_id": "123", "edition": "2017", "version": "3"}},
+					auditortest.Expected{Action: associateVersionAction, Result: audit.Successful, Params: common.Params{"dataset_id": "123", "edition": "2017", "version": "3"}},
+					auditortest.Expected{Action: transitionVersionsAction, Result: audit.Successful, Params: common.Params{"dataset_id": "123", "edition": "2017", "state": models.AssociatedState, "count": "2"}},
+				)
+			})
+		})
+	})
+}
+
+func TestTransitionVersionsReturnsConflict(t *testing.T) {
+	t.Parallel()
+	Convey("Given an edition with a version which is not associated", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			CheckEditionExistsFunc: func(string, string, string) error {
+				return nil
+			},
+			GetVersionsFunc: func(string, string, string, *models.ReleaseDateRange) (*models.VersionResults, error) {
+				versions := editionVersions()
+				versions.Items[2].State = models.AssociatedState
+				versions.Items[2].CollectionID = "collection-1"
+				return versions, nil
+			},
+		}
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When the versions are published", func() {
+			r, err := createRequestWithAuth("POST", transitionVersionsURL, bytes.NewBufferString(`{"state":"published"}`))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then a conflict is returned with the reason the version cannot be published", func() {
+				So(w.Code, ShouldEqual, http.StatusConflict)
+
+				var results models.VersionTransitionResults
+				So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+				So(results.Count, ShouldEqual, 0)
+				So(results.Items, ShouldResemble, []models.VersionTransitionResult{
+					{Version: 1, From: models.PublishedState, Status: models.VersionTransitionSkipped, Reason: reasonVersionPublished},
+					{Version: 2, From: models.AssociatedState, Status: models.VersionTransitionAborted, Reason: reasonVersionTransitionAborted},
+					{Version: 3, From: models.EditionConfirmedState, Status: models.VersionTransitionFailed, Reason: errs.ErrExpectedResourceStateOfAssociated.Error()},
+				})
+
+				Convey("And none of the versions are updated", func() {
+					So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 0)
+					So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 0)
+					So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 0)
+
+					auditMock.AssertRecordCalls(
+						auditortest.Expected{Action: transitionVersionsAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "dataset_id": "123", "edition": "2017"}},
+						auditortest.Expected{Action: transitionVersionsAction, Result: audit.Unsuccessful, Params: common.Params{"dataset_id": "123", "edition": "2017", "state": models.PublishedState, "count": "0"}},
+					)
+				})
+			})
+		})
+	})
+}
+
+func TestTransitionVersionsReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("When the requested state is not a version state then a bad request is returned", t, func() {
+		mockedDataStore := &storetest.StorerMock{}
+		auditMock := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditMock, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		r, err := createRequestWithAuth("POST", transitionVersionsURL, bytes.NewBufferString(`{"state":"submitted"}`))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, models.ErrVersionStateInvalid.Error())
+		So(len(mockedDataStore.WithTransactionCalls()), ShouldEqual, 0)
+
+		auditMock.AssertRecordCalls(
+			auditortest.Expected{Action: transitionVersionsAction, Result: audit.Attempted, Params: common.Params{"caller_identity": callerIdentity, "dataset_id": "123", "edition": "2017"}},
+			auditortest.Expected{Action: transitionVersionsAction, Result: audit.Unsuccessful, Params: common.Params{"dataset_id": "123", "edition": "2017"}},
+		)
+	})
+
+	Convey("When the versions of a dataset on the publish denylist are published then a forbidden response is returned", t, func() {
+		mockedDataStore := &storetest.StorerMock{}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.publishDenylist = []string{"123"}
+
+		r, err := createRequestWithAuth("POST", transitionVersionsURL, bytes.NewBufferString(`{"state":"published"}`))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetPublishDenied.Error())
+		So(len(mockedDataStore.WithTransactionCalls()), ShouldEqual, 0)
+	})
+
+	Convey("When the edition does not exist then a not found response is returned", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			CheckEditionExistsFunc: func(string, string, string) error {
+				return errs.ErrEditionNotFound
+			},
+		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		r, err := createRequestWithAuth("POST", transitionVersionsURL, bytes.NewBufferString(`{"state":"published"}`))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 0)
+	})
+}
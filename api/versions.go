@@ -6,9 +6,11 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
 	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/dp-dataset-api/store"
 	"github.com/ONSdigital/go-ns/audit"
 	"github.com/ONSdigital/go-ns/common"
 	"github.com/ONSdigital/go-ns/log"
@@ -27,10 +29,26 @@ var (
 
 	// errors that map to a HTTP 400 response
 	badRequest = map[error]bool{
+		errs.ErrJSONTooDeep:                            true,
+		errs.ErrNoCollectionVersions:                   true,
+		errs.ErrRequestBodyTooLarge:                    true,
 		errs.ErrUnableToParseJSON:                      true,
+		errs.ErrUnableToReadMessage:                    true,
+		errs.ErrVersionDiffAgainstInvalid:              true,
+		errs.ErrVersionMissingState:                    true,
 		models.ErrPublishedVersionCollectionIDInvalid:  true,
 		models.ErrAssociatedVersionCollectionIDInvalid: true,
 		models.ErrVersionStateInvalid:                  true,
+		models.ErrReleaseDateRangeInvalid:              true,
+		models.ErrReleaseDateRangeOrder:                true,
+		errs.ErrVersionReleaseDateInPast:               true,
+		errs.ErrVersionReleaseDateInvalid:              true,
+		errs.ErrVersionLabelsInvalid:                   true,
+	}
+
+	// errors that map to a HTTP 403 response
+	versionsForbidden = map[error]bool{
+		errs.ErrDatasetPublishDenied: true,
 	}
 
 	// HTTP 500 responses with a specific message
@@ -64,6 +82,15 @@ func (api *DatasetAPI) getVersions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	b, err := func() ([]byte, error) {
+		stateFilter := r.URL.Query().Get("state")
+		if stateFilter != "" {
+			logData["state_query"] = stateFilter
+			if err := models.ValidateVersionStateFilter(stateFilter); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "filter state invalid for list of versions"), logData)
+				return nil, err
+			}
+		}
+
 		authorised, logData := api.authenticate(r, logData)
 
 		var state string
@@ -71,7 +98,20 @@ func (api *DatasetAPI) getVersions(w http.ResponseWriter, r *http.Request) {
 			state = models.PublishedState
 		}
 
-		if err := api.dataStore.Backend.CheckDatasetExists(datasetID, state); err != nil {
+		// the state filter only applies to authorised users, everyone else can only see published versions
+		versionState := state
+		if authorised {
+			versionState = stateFilter
+		}
+
+		releaseDates, err := models.ParseReleaseDateRange(r.URL.Query().Get("released_after"), r.URL.Query().Get("released_before"))
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "failed to parse release date range for list of versions"), logData)
+			return nil, err
+		}
+
+		unitOfMeasure, err := api.getUnitOfMeasure(datasetID, authorised)
+		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "failed to find dataset for list of versions"), logData)
 			return nil, err
 		}
@@ -81,19 +121,25 @@ func (api *DatasetAPI) getVersions(w http.ResponseWriter, r *http.Request) {
 			return nil, err
 		}
 
-		results, err := api.dataStore.Backend.GetVersions(datasetID, edition, state)
+		results, err := api.dataStore.Backend.GetVersions(datasetID, edition, versionState, releaseDates)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "failed to find any versions for dataset edition"), logData)
 			return nil, err
 		}
 
 		var hasInvalidState bool
-		for _, item := range results.Items {
+		for i, item := range results.Items {
 			if err = models.CheckState("version", item.State); err != nil {
 				hasInvalidState = true
 				log.ErrorCtx(ctx, errors.WithMessage(err, "unpublished version has an invalid state"), log.Data{"state": item.State})
 			}
 
+			results.Items[i].UnitOfMeasure = unitOfMeasure
+
+			if !authorised {
+				results.Items[i].Sanitise()
+			}
+
 			// Only the download service should have access to the
 			// public/private download fields
 			if r.Header.Get(downloadServiceToken) != api.downloadServiceToken {
@@ -112,6 +158,11 @@ func (api *DatasetAPI) getVersions(w http.ResponseWriter, r *http.Request) {
 					}
 				}
 			}
+
+			if err := api.signDownloads(&results.Items[i]); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "failed to sign download links of version"), log.Data{"version": item.Version})
+				return nil, err
+			}
 		}
 
 		if hasInvalidState {
@@ -170,7 +221,8 @@ func (api *DatasetAPI) getVersion(w http.ResponseWriter, r *http.Request) {
 			state = models.PublishedState
 		}
 
-		if err := api.dataStore.Backend.CheckDatasetExists(datasetID, state); err != nil {
+		unitOfMeasure, err := api.getUnitOfMeasure(datasetID, authorised)
+		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "failed to find dataset"), logData)
 			return nil, err
 		}
@@ -194,6 +246,12 @@ func (api *DatasetAPI) getVersion(w http.ResponseWriter, r *http.Request) {
 			return nil, errs.ErrResourceState
 		}
 
+		results.UnitOfMeasure = unitOfMeasure
+
+		if !authorised {
+			results.Sanitise()
+		}
+
 		// Only the download service should not have access to the public/private download
 		// fields
 		if r.Header.Get(downloadServiceToken) != api.downloadServiceToken {
@@ -213,6 +271,11 @@ func (api *DatasetAPI) getVersion(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		if err := api.signDownloads(results); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "failed to sign download links of version"), logData)
+			return nil, err
+		}
+
 		b, err := json.Marshal(results)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "failed to marshal version resource into bytes"), logData)
@@ -260,32 +323,59 @@ func (api *DatasetAPI) putVersion(w http.ResponseWriter, r *http.Request) {
 		"version":   vars["version"],
 	}
 
-	currentDataset, currentVersion, versionDoc, err := api.updateVersion(ctx, r.Body, versionDetails)
-	if err != nil {
+	// If update was to add downloads do not try to publish/associate version
+	if err := api.applyVersionUpdate(ctx, r.Body, versionDetails, vars[hasDownloads] == trueStringified); err != nil {
 		handleVersionAPIErr(ctx, err, w, data)
 		return
 	}
 
-	// If update was to add downloads do not try to publish/associate version
-	if vars[hasDownloads] != trueStringified {
+	setJSONContentType(w)
+	w.WriteHeader(http.StatusOK)
+	log.InfoCtx(ctx, "putVersion endpoint: request successful", data)
+}
+
+// applyVersionUpdate updates a version and then publishes or associates it when the update moves it to the published or
+// associated state, unless the update only adds downloads. The update and the publish or association that follows it
+// run as one transaction, so a failure part way through does not leave the version published while its edition and
+// dataset are not. A published version is notified once the transaction has been committed.
+func (api *DatasetAPI) applyVersionUpdate(ctx context.Context, body io.ReadCloser, versionDetails VersionDetails, downloadsOnly bool) error {
+	var published *models.Version
+	var publishedNumber int
+
+	err := api.dataStore.Backend.WithTransaction(func(tx store.Storer) error {
+		txAPI := api.withBackend(tx)
+
+		currentDataset, currentVersion, versionDoc, err := txAPI.updateVersion(ctx, body, versionDetails)
+		if err != nil {
+			return err
+		}
+
+		if downloadsOnly {
+			return nil
+		}
+
 		if versionDoc.State == models.PublishedState {
-			if err := api.publishVersion(ctx, currentDataset, currentVersion, versionDoc, versionDetails); err != nil {
-				handleVersionAPIErr(ctx, err, w, data)
-				return
+			if err := txAPI.publishVersion(ctx, currentDataset, currentVersion, versionDoc, versionDetails); err != nil {
+				return err
 			}
+			published, publishedNumber = versionDoc, currentVersion.Version
 		}
 
 		if versionDoc.State == models.AssociatedState && currentVersion.State != models.AssociatedState {
-			if err := api.associateVersion(ctx, currentVersion, versionDoc, versionDetails); err != nil {
-				handleVersionAPIErr(ctx, err, w, data)
-				return
+			if err := txAPI.associateVersion(ctx, currentVersion, versionDoc, versionDetails); err != nil {
+				return err
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	setJSONContentType(w)
-	w.WriteHeader(http.StatusOK)
-	log.InfoCtx(ctx, "putVersion endpoint: request successful", data)
+	if published != nil {
+		api.notifyPublished(ctx, versionDetails.datasetID, versionDetails.edition, publishedNumber, published)
+	}
+	return nil
 }
 
 func (api *DatasetAPI) detachVersion(w http.ResponseWriter, r *http.Request) {
@@ -393,13 +483,40 @@ func (api *DatasetAPI) detachVersion(w http.ResponseWriter, r *http.Request) {
 	log.InfoCtx(ctx, "detachVersion endpoint: request successful", logData)
 }
 
+// checkPublishAllowed returns an error if the dataset is on the publish denylist, as some datasets are permanently
+// pre-release and their versions must never be published
+func (api *DatasetAPI) checkPublishAllowed(datasetID string) error {
+	for _, denied := range api.publishDenylist {
+		if denied == datasetID {
+			return errs.ErrDatasetPublishDenied
+		}
+	}
+	return nil
+}
+
+// checkPublishReleaseDate returns an error if the version is being published with a release date before today, when
+// past release dates are rejected, so published statistics cannot be back-dated
+func (api *DatasetAPI) checkPublishReleaseDate(version *models.Version) error {
+	if !api.rejectPastReleaseDates {
+		return nil
+	}
+	return models.ValidateReleaseDateNotPast(version.ReleaseDate, time.Now())
+}
+
+// withBackend returns a copy of the api using the given storer, for steps run within a transaction
+func (api *DatasetAPI) withBackend(backend store.Storer) *DatasetAPI {
+	txAPI := *api
+	txAPI.dataStore = store.DataStore{Backend: backend}
+	return &txAPI
+}
+
 func (api *DatasetAPI) updateVersion(ctx context.Context, body io.ReadCloser, versionDetails VersionDetails) (*models.DatasetUpdate, *models.Version, *models.Version, error) {
 	ap := versionDetails.baseAuditParams()
 	data := audit.ToLogData(ap)
 
 	// attempt to update the version
 	currentDataset, currentVersion, versionUpdate, err := func() (*models.DatasetUpdate, *models.Version, *models.Version, error) {
-		versionUpdate, err := models.CreateVersion(body)
+		versionUpdate, err := models.CreateVersion(body, api.jsonLimits)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "putVersion endpoint: failed to model version resource based on request"), data)
 			return nil, nil, nil, errs.ErrUnableToParseJSON
@@ -422,6 +539,13 @@ func (api *DatasetAPI) updateVersion(ctx context.Context, body io.ReadCloser, ve
 			return nil, nil, nil, err
 		}
 
+		// The links of the current version replace those of the update when they are combined, so the links given in
+		// the update are checked first
+		if err = models.ValidateVersionLinks(versionUpdate, versionDetails.datasetID, versionDetails.edition, versionDetails.version, api.urlBuilder); err != nil {
+			log.ErrorCtx(ctx, errors.Wrap(err, "putVersion endpoint: version links are inconsistent with the version being updated"), data)
+			return nil, nil, nil, err
+		}
+
 		// Combine update version document to existing version document
 		populateNewVersionDoc(currentVersion, versionUpdate)
 		data["updated_version"] = versionUpdate
@@ -432,6 +556,18 @@ func (api *DatasetAPI) updateVersion(ctx context.Context, body io.ReadCloser, ve
 			return nil, nil, nil, err
 		}
 
+		if versionUpdate.State == models.PublishedState && currentVersion.State != models.PublishedState {
+			if err = api.checkPublishAllowed(versionDetails.datasetID); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "putVersion endpoint: version cannot be published"), data)
+				return nil, nil, nil, err
+			}
+
+			if err = api.checkPublishReleaseDate(versionUpdate); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "putVersion endpoint: version cannot be published"), data)
+				return nil, nil, nil, err
+			}
+		}
+
 		if err := api.dataStore.Backend.UpdateVersion(versionUpdate.ID, versionUpdate); err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "putVersion endpoint: failed to update version document"), data)
 			return nil, nil, nil, err
@@ -474,20 +610,23 @@ func (api *DatasetAPI) publishVersion(ctx context.Context, currentDataset *model
 		}
 
 		editionDoc.Next.State = models.PublishedState
-		if err := editionDoc.PublishLinks(api.host, versionDoc.Links.Version); err != nil {
-			log.ErrorCtx(ctx, errors.WithMessage(err, "putVersion endpoint: failed to update the edition links for the version we're trying to publish"), data)
-			return err
-		}
 
-		editionDoc.Current = editionDoc.Next
+		if currentDataset.LinksFrozen && editionDoc.Current != nil {
+			// the published edition keeps its links until the dataset links are thawed and refreshed
+			published := *editionDoc.Next
+			published.Links = editionDoc.Current.Links
+			editionDoc.Current = &published
+		} else {
+			if err := editionDoc.PublishLinks(api.host, versionDoc.Links.Version); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "putVersion endpoint: failed to update the edition links for the version we're trying to publish"), data)
+				return err
+			}
 
-		if err := api.dataStore.Backend.UpsertEdition(versionDetails.datasetID, versionDetails.edition, editionDoc); err != nil {
-			log.ErrorCtx(ctx, errors.WithMessage(err, "putVersion endpoint: failed to update edition during publishing"), data)
-			return err
+			editionDoc.Current = editionDoc.Next
 		}
 
-		if err := api.dataStore.Backend.SetInstanceIsPublished(ctx, versionDoc.ID); err != nil {
-			audit.LogError(ctx, errors.WithMessage(err, "putVersion endpoint: failed to set instance node is_published"), data)
+		if err := api.dataStore.Backend.UpsertEdition(versionDetails.datasetID, versionDetails.edition, editionDoc); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "putVersion endpoint: failed to update edition during publishing"), data)
 			return err
 		}
 
@@ -497,9 +636,16 @@ func (api *DatasetAPI) publishVersion(ctx context.Context, currentDataset *model
 			return err
 		}
 
-		// Only want to generate downloads again if there is no public link available
-		if currentVersion.Downloads != nil && currentVersion.Downloads.CSV != nil && currentVersion.Downloads.CSV.Public == "" {
-			if err := api.downloadGenerator.Generate(versionDetails.datasetID, versionDoc.ID, versionDetails.edition, versionDetails.version); err != nil {
+		// the graph database and the downloads are outside the store transaction, so they are only changed once the
+		// mongo writes have been made and cannot fail after them
+		if err := api.dataStore.Backend.SetInstanceIsPublished(ctx, versionDoc.ID); err != nil {
+			audit.LogError(ctx, errors.WithMessage(err, "putVersion endpoint: failed to set instance node is_published"), data)
+			return err
+		}
+
+		// Only want to generate downloads again in the requested formats which have no public link available
+		if formats := currentVersion.Downloads.UnpublishedFormats(api.downloadFormatsFor(versionDoc)); len(formats) > 0 {
+			if err := api.downloadGenerator.Generate(versionDetails.datasetID, versionDoc.ID, versionDetails.edition, versionDetails.version, formats); err != nil {
 				data["instance_id"] = versionDoc.ID
 				data["state"] = versionDoc.State
 				log.ErrorCtx(ctx, errors.WithMessage(err, "putVersion endpoint: error while attempting to generate full dataset version downloads on version publish"), data)
@@ -543,7 +689,7 @@ func (api *DatasetAPI) associateVersion(ctx context.Context, currentVersion *mod
 
 		log.InfoCtx(ctx, "putVersion endpoint: generating full dataset version downloads", data)
 
-		if err := api.downloadGenerator.Generate(versionDetails.datasetID, versionDoc.ID, versionDetails.edition, versionDetails.version); err != nil {
+		if err := api.downloadGenerator.Generate(versionDetails.datasetID, versionDoc.ID, versionDetails.edition, versionDetails.version, api.downloadFormatsFor(versionDoc)); err != nil {
 			data["instance_id"] = versionDoc.ID
 			data["state"] = versionDoc.State
 			err = errors.WithMessage(err, "putVersion endpoint: error while attempting to generate full dataset version downloads on version association")
@@ -568,6 +714,68 @@ func (api *DatasetAPI) associateVersion(ctx context.Context, currentVersion *mod
 	return associateVersionErr
 }
 
+// notifyPublished tells external systems a version has been published. It is only called once the publish has been
+// committed, so a publish which is rolled back is never notified.
+func (api *DatasetAPI) notifyPublished(ctx context.Context, datasetID, edition string, number int, published *models.Version) {
+	event := models.VersionPublishedEvent{
+		DatasetID:   datasetID,
+		Edition:     edition,
+		Version:     number,
+		InstanceID:  published.ID,
+		ReleaseDate: published.ReleaseDate,
+	}
+	if published.Links != nil && published.Links.Version != nil {
+		event.HRef = published.Links.Version.HRef
+	}
+	api.publishNotifier.NotifyPublished(ctx, event)
+}
+
+// getUnitOfMeasure returns the unit of measure of a dataset, also checking the dataset exists so the versions of a
+// dataset are returned with a single read of it. Callers who are not authorised are given the unit of the published
+// dataset and do not find a dataset which has not been published, a dataset without a unit has an empty unit.
+func (api *DatasetAPI) getUnitOfMeasure(datasetID string, authorised bool) (string, error) {
+	datasetDoc, err := api.dataStore.Backend.GetDataset(datasetID)
+	if err != nil {
+		return "", err
+	}
+
+	dataset := datasetDoc.Current
+	if !authorised && (dataset == nil || dataset.State != models.PublishedState) {
+		return "", errs.ErrDatasetNotFound
+	}
+	if authorised && datasetDoc.Next != nil {
+		dataset = datasetDoc.Next
+	}
+
+	if dataset == nil {
+		return "", nil
+	}
+	return dataset.UnitOfMeasure, nil
+}
+
+// signDownloads replaces the download links of an unpublished version with signed links which expire, so a link to a
+// pre-publication download cannot be used once it has expired. Published versions keep their public links, as do all
+// versions when no signer is configured.
+func (api *DatasetAPI) signDownloads(version *models.Version) error {
+	if api.downloadURLSigner == nil || version.Downloads == nil || version.State == models.PublishedState {
+		return nil
+	}
+
+	expires := time.Now().Add(api.downloadURLExpiry)
+	return version.Downloads.Sign(func(href string) (string, error) {
+		return api.downloadURLSigner.Sign(href, expires)
+	})
+}
+
+// downloadFormatsFor returns the formats downloads should be generated in for a version, those requested in the
+// version or otherwise the configured formats
+func (api *DatasetAPI) downloadFormatsFor(version *models.Version) []string {
+	if len(version.DownloadFormats) > 0 {
+		return version.DownloadFormats
+	}
+	return api.downloadFormats
+}
+
 func populateNewVersionDoc(currentVersion *models.Version, version *models.Version) *models.Version {
 
 	var alerts []models.Alert
@@ -622,10 +830,18 @@ func populateNewVersionDoc(currentVersion *models.Version, version *models.Versi
 		version.CollectionID = ""
 	}
 
+	if version.Labels == nil {
+		version.Labels = currentVersion.Labels
+	}
+
 	if version.Temporal == nil {
 		version.Temporal = currentVersion.Temporal
 	}
 
+	if version.DownloadFormats == nil {
+		version.DownloadFormats = currentVersion.DownloadFormats
+	}
+
 	var spatial string
 
 	// Get spatial link before overwriting the version links object below
@@ -695,12 +911,16 @@ func handleVersionAPIErr(ctx context.Context, err error, w http.ResponseWriter,
 		status = http.StatusNotFound
 	case badRequest[err]:
 		status = http.StatusBadRequest
+	case versionsForbidden[err]:
+		status = http.StatusForbidden
 	case internalServerErrWithMessage[err]:
 		status = http.StatusInternalServerError
 	case strings.HasPrefix(err.Error(), "missing mandatory fields:"):
 		status = http.StatusBadRequest
 	case strings.HasPrefix(err.Error(), "invalid fields:"):
 		status = http.StatusBadRequest
+	case strings.HasPrefix(err.Error(), "bad request - invalid filter state values:"):
+		status = http.StatusBadRequest
 	default:
 		err = errs.ErrInternalServer
 		status = http.StatusInternalServerError
@@ -711,5 +931,5 @@ func handleVersionAPIErr(ctx context.Context, err error, w http.ResponseWriter,
 	}
 
 	log.ErrorCtx(ctx, errors.WithMessage(err, "request unsuccessful"), data)
-	http.Error(w, err.Error(), status)
+	errs.WriteError(w, err, status)
 }
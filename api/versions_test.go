@@ -17,6 +17,7 @@ import (
 	"github.com/ONSdigital/dp-dataset-api/config"
 	"github.com/ONSdigital/dp-dataset-api/mocks"
 	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/dp-dataset-api/store"
 	"github.com/ONSdigital/dp-dataset-api/store/datastoretest"
 	"github.com/ONSdigital/go-ns/audit"
 	"github.com/ONSdigital/go-ns/audit/auditortest"
@@ -38,13 +39,13 @@ func TestGetVersionsReturnsOK(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return nil
 			},
-			GetVersionsFunc: func(datasetID, editionID, state string) (*models.VersionResults, error) {
+			GetVersionsFunc: func(datasetID, editionID, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
 				return &models.VersionResults{}, nil
 			},
 		}
@@ -58,7 +59,7 @@ func TestGetVersionsReturnsOK(t *testing.T) {
 		So(w.Code, ShouldEqual, http.StatusOK)
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 1)
 
@@ -70,6 +71,103 @@ func TestGetVersionsReturnsOK(t *testing.T) {
 	})
 }
 
+func TestGetVersionsReleaseDateRange(t *testing.T) {
+	t.Parallel()
+	Convey("A request to get versions within a release date range passes the range to the datastore", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions?released_after=2017-01-01&released_before=2018-01-01T12:00:00Z", nil)
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionsFunc: func(datasetID, editionID, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
+				return &models.VersionResults{}, nil
+			},
+		}
+
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 1)
+
+		releaseDates := mockedDataStore.GetVersionsCalls()[0].ReleaseDates
+		So(releaseDates.After.Equal(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)), ShouldBeTrue)
+		So(releaseDates.Before.Equal(time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)), ShouldBeTrue)
+	})
+}
+
+func TestGetVersionsStateFilter(t *testing.T) {
+	t.Parallel()
+	auditParams := common.Params{"dataset_id": "123-456", "edition": "678"}
+
+	Convey("Given a datastore of versions", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionsFunc: func(datasetID, editionID, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
+				return &models.VersionResults{}, nil
+			},
+		}
+
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		Convey("When an authorised request filters the versions by a valid state", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123-456/editions/678/versions?state=associated", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the state is passed to the datastore", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockedDataStore.GetVersionsCalls(), ShouldHaveLength, 1)
+				So(mockedDataStore.GetVersionsCalls()[0].State, ShouldEqual, models.AssociatedState)
+			})
+		})
+
+		Convey("When an unauthorised request filters the versions by a valid state", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions?state=associated", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then only published versions are requested from the datastore", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockedDataStore.GetVersionsCalls(), ShouldHaveLength, 1)
+				So(mockedDataStore.GetVersionsCalls()[0].State, ShouldEqual, models.PublishedState)
+			})
+		})
+
+		Convey("When the versions are filtered by an invalid state", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123-456/editions/678/versions?state=foo", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then a bad request is returned before the datastore is called", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, "bad request - invalid filter state values: [foo]")
+				So(mockedDataStore.GetDatasetCalls(), ShouldHaveLength, 0)
+				So(mockedDataStore.CheckEditionExistsCalls(), ShouldHaveLength, 0)
+				So(mockedDataStore.GetVersionsCalls(), ShouldHaveLength, 0)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{Action: getVersionsAction, Result: audit.Attempted, Params: auditParams},
+					auditortest.Expected{Action: getVersionsAction, Result: audit.Unsuccessful, Params: auditParams},
+				)
+			})
+		})
+	})
+}
+
 func TestGetVersionsReturnsError(t *testing.T) {
 	t.Parallel()
 	auditParams := common.Params{"dataset_id": "123-456", "edition": "678"}
@@ -78,8 +176,8 @@ func TestGetVersionsReturnsError(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return errs.ErrInternalServer
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return nil, errs.ErrInternalServer
 			},
 		}
 
@@ -92,7 +190,7 @@ func TestGetVersionsReturnsError(t *testing.T) {
 		assertInternalServerErr(w)
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 0)
 		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 0)
 
@@ -102,12 +200,56 @@ func TestGetVersionsReturnsError(t *testing.T) {
 		)
 	})
 
+	Convey("When the released_after date is invalid return status bad request", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions?released_after=04-04-2017", nil)
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, models.ErrReleaseDateRangeInvalid.Error())
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 0)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{Action: getVersionsAction, Result: audit.Attempted, Params: auditParams},
+			auditortest.Expected{Action: getVersionsAction, Result: audit.Unsuccessful, Params: auditParams},
+		)
+	})
+
+	Convey("When the released_after date is not earlier than the released_before date return status bad request", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions?released_after=2018-01-01&released_before=2017-01-01", nil)
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, models.ErrReleaseDateRangeOrder.Error())
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 0)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{Action: getVersionsAction, Result: audit.Attempted, Params: auditParams},
+			auditortest.Expected{Action: getVersionsAction, Result: audit.Unsuccessful, Params: auditParams},
+		)
+	})
+
 	Convey("When the dataset does not exist return status not found", t, func() {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return errs.ErrDatasetNotFound
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return nil, errs.ErrDatasetNotFound
 			},
 		}
 
@@ -122,7 +264,7 @@ func TestGetVersionsReturnsError(t *testing.T) {
 
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 0)
 		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 0)
 
@@ -136,8 +278,8 @@ func TestGetVersionsReturnsError(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return errs.ErrEditionNotFound
@@ -155,7 +297,7 @@ func TestGetVersionsReturnsError(t *testing.T) {
 
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 0)
 
@@ -170,13 +312,13 @@ func TestGetVersionsReturnsError(t *testing.T) {
 		r.Header.Add("internal_token", "coffee")
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return nil
 			},
-			GetVersionsFunc: func(datasetID, editionID, state string) (*models.VersionResults, error) {
+			GetVersionsFunc: func(datasetID, editionID, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
 				return nil, errs.ErrVersionNotFound
 			},
 		}
@@ -192,7 +334,7 @@ func TestGetVersionsReturnsError(t *testing.T) {
 
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 1)
 
@@ -206,13 +348,13 @@ func TestGetVersionsReturnsError(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return nil
 			},
-			GetVersionsFunc: func(datasetID, editionID, state string) (*models.VersionResults, error) {
+			GetVersionsFunc: func(datasetID, editionID, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
 				return nil, errs.ErrVersionNotFound
 			},
 		}
@@ -228,7 +370,7 @@ func TestGetVersionsReturnsError(t *testing.T) {
 
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 1)
 
@@ -245,13 +387,13 @@ func TestGetVersionsReturnsError(t *testing.T) {
 		version := models.Version{State: "gobbly-gook"}
 		items := []models.Version{version}
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return nil
 			},
-			GetVersionsFunc: func(datasetID, editionID, state string) (*models.VersionResults, error) {
+			GetVersionsFunc: func(datasetID, editionID, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
 				return &models.VersionResults{Items: items}, nil
 			},
 		}
@@ -267,7 +409,7 @@ func TestGetVersionsReturnsError(t *testing.T) {
 
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 1)
 
@@ -301,7 +443,7 @@ func TestGetVersionsAuditError(t *testing.T) {
 		assertInternalServerErr(w)
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 0)
 		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 0)
 
@@ -314,8 +456,8 @@ func TestGetVersionsAuditError(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(ID string, state string) error {
-				return err
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return nil, err
 			},
 		}
 
@@ -335,7 +477,7 @@ func TestGetVersionsAuditError(t *testing.T) {
 		assertInternalServerErr(w)
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 0)
 		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 0)
 
@@ -349,8 +491,8 @@ func TestGetVersionsAuditError(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(ID string, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(ID string, editionID string, state string) error {
 				return err
@@ -373,7 +515,7 @@ func TestGetVersionsAuditError(t *testing.T) {
 		assertInternalServerErr(w)
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 0)
 
@@ -387,13 +529,13 @@ func TestGetVersionsAuditError(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(ID string, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(ID string, editionID string, state string) error {
 				return nil
 			},
-			GetVersionsFunc: func(datasetID string, editionID string, state string) (*models.VersionResults, error) {
+			GetVersionsFunc: func(datasetID string, editionID string, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
 				return nil, err
 			},
 		}
@@ -407,7 +549,7 @@ func TestGetVersionsAuditError(t *testing.T) {
 		assertInternalServerErr(w)
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 1)
 
@@ -421,13 +563,13 @@ func TestGetVersionsAuditError(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(ID string, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(ID string, editionID string, state string) error {
 				return nil
 			},
-			GetVersionsFunc: func(datasetID string, editionID string, state string) (*models.VersionResults, error) {
+			GetVersionsFunc: func(datasetID string, editionID string, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
 				return &models.VersionResults{
 					Items: []models.Version{{State: "not valid"}},
 				}, nil
@@ -450,7 +592,7 @@ func TestGetVersionsAuditError(t *testing.T) {
 		assertInternalServerErr(w)
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 1)
 
@@ -464,13 +606,13 @@ func TestGetVersionsAuditError(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return nil
 			},
-			GetVersionsFunc: func(datasetID, editionID, state string) (*models.VersionResults, error) {
+			GetVersionsFunc: func(datasetID, editionID, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
 				return &models.VersionResults{}, nil
 			},
 		}
@@ -491,7 +633,7 @@ func TestGetVersionsAuditError(t *testing.T) {
 		So(w.Code, ShouldEqual, http.StatusInternalServerError)
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.GetVersionsCalls()), ShouldEqual, 1)
 
@@ -510,8 +652,8 @@ func TestGetVersionReturnsOK(t *testing.T) {
 
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return nil
@@ -538,7 +680,7 @@ func TestGetVersionReturnsOK(t *testing.T) {
 		So(w.Code, ShouldEqual, http.StatusOK)
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 1)
 
@@ -550,128 +692,365 @@ func TestGetVersionReturnsOK(t *testing.T) {
 	})
 }
 
-func TestGetVersionReturnsError(t *testing.T) {
-	auditParams := common.Params{"dataset_id": "123-456", "edition": "678", "version": "1"}
+func TestGetVersionSanitisesPublicResponse(t *testing.T) {
 	t.Parallel()
-	Convey("When the api cannot connect to datastore return an internal server error", t, func() {
-		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
-		w := httptest.NewRecorder()
-		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return errs.ErrInternalServer
-			},
-		}
-
-		datasetPermissions := getAuthorisationHandlerMock()
-		permissions := getAuthorisationHandlerMock()
-		auditor := auditortest.New()
-		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
-		api.Router.ServeHTTP(w, r)
-
-		assertInternalServerErr(w)
-		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
-		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
-
-		auditor.AssertRecordCalls(
-			auditortest.Expected{Action: getVersionAction, Result: audit.Attempted, Params: auditParams},
-			auditortest.Expected{Action: getVersionAction, Result: audit.Unsuccessful, Params: auditParams},
-		)
-	})
-
-	Convey("When the dataset does not exist for return status not found", t, func() {
-		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
-		r.Header.Add("internal_token", "coffee")
-		w := httptest.NewRecorder()
+	Convey("Given a version belonging to a collection", t, func() {
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return errs.ErrDatasetNotFound
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
-		}
-
-		datasetPermissions := getAuthorisationHandlerMock()
-		permissions := getAuthorisationHandlerMock()
-		auditor := auditortest.New()
-		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
-		api.Router.ServeHTTP(w, r)
-
-		So(w.Code, ShouldEqual, http.StatusNotFound)
-		So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetNotFound.Error())
-
-		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
-		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
-		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 0)
-		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 0)
-
-		auditor.AssertRecordCalls(
-			auditortest.Expected{Action: getVersionAction, Result: audit.Attempted, Params: auditParams},
-			auditortest.Expected{Action: getVersionAction, Result: audit.Unsuccessful, Params: auditParams},
-		)
-	})
-
-	Convey("When the edition of a dataset does not exist return status not found", t, func() {
-		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
-		r.Header.Add("internal_token", "coffee")
-		w := httptest.NewRecorder()
-		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return nil
 			},
-			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
-				return errs.ErrEditionNotFound
+			GetVersionFunc: func(datasetID, editionID, version, state string) (*models.Version, error) {
+				return &models.Version{
+					CollectionID: "collection-1",
+					State:        models.PublishedState,
+					Links: &models.VersionLinks{
+						Self:    &models.LinkObject{},
+						Version: &models.LinkObject{HRef: "href"},
+					},
+				}, nil
 			},
 		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
 
-		datasetPermissions := getAuthorisationHandlerMock()
-		permissions := getAuthorisationHandlerMock()
-		auditor := auditortest.New()
-		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
-		api.Router.ServeHTTP(w, r)
+		Convey("When a public caller gets the version", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
 
-		So(w.Code, ShouldEqual, http.StatusNotFound)
-		So(w.Body.String(), ShouldContainSubstring, errs.ErrEditionNotFound.Error())
+			Convey("Then the collection id is omitted", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldNotContainSubstring, "collection_id")
+			})
+		})
 
-		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
-		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
-		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
-		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 0)
+		Convey("When an authorised caller gets the version", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
 
-		auditor.AssertRecordCalls(
-			auditortest.Expected{Action: getVersionAction, Result: audit.Attempted, Params: auditParams},
-			auditortest.Expected{Action: getVersionAction, Result: audit.Unsuccessful, Params: auditParams},
-		)
+			Convey("Then the collection id is included", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var version models.Version
+				So(json.Unmarshal(w.Body.Bytes(), &version), ShouldBeNil)
+				So(version.CollectionID, ShouldEqual, "collection-1")
+			})
+		})
 	})
+}
 
-	Convey("When version does not exist for an edition of a dataset return status not found", t, func() {
-		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
-		r.Header.Add("internal_token", "coffee")
-		w := httptest.NewRecorder()
+func TestGetVersionsIncludeUnitOfMeasure(t *testing.T) {
+	t.Parallel()
+	Convey("Given a version of a dataset whose next unit of measure differs from the published one", t, func() {
+		datasetDoc := &models.DatasetUpdate{
+			Current: &models.Dataset{State: models.PublishedState, UnitOfMeasure: "Pounds Sterling"},
+			Next:    &models.Dataset{State: models.CreatedState, UnitOfMeasure: "Percent"},
+		}
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return datasetDoc, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return nil
 			},
 			GetVersionFunc: func(datasetID, editionID, version, state string) (*models.Version, error) {
-				return nil, errs.ErrVersionNotFound
+				return &models.Version{
+					State: models.PublishedState,
+					Links: &models.VersionLinks{
+						Self:    &models.LinkObject{},
+						Version: &models.LinkObject{HRef: "href"},
+					},
+				}, nil
+			},
+			GetVersionsFunc: func(datasetID, editionID, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
+				return &models.VersionResults{Items: []models.Version{{State: models.PublishedState}, {State: models.PublishedState}}}, nil
 			},
 		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
 
-		datasetPermissions := getAuthorisationHandlerMock()
-		permissions := getAuthorisationHandlerMock()
-		auditor := auditortest.New()
-		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+		Convey("When a public caller gets the version", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then the unit of measure of the published dataset is included, the dataset being loaded once", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var version models.Version
+				So(json.Unmarshal(w.Body.Bytes(), &version), ShouldBeNil)
+				So(version.UnitOfMeasure, ShouldEqual, "Pounds Sterling")
+				So(mockedDataStore.GetDatasetCalls(), ShouldHaveLength, 1)
+				So(mockedDataStore.GetDatasetCalls()[0].ID, ShouldEqual, "123-456")
+			})
+		})
+
+		Convey("When an authorised caller gets the version then the unit of measure of the next dataset is included", func() {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			var version models.Version
+			So(json.Unmarshal(w.Body.Bytes(), &version), ShouldBeNil)
+			So(version.UnitOfMeasure, ShouldEqual, "Percent")
+		})
+
+		Convey("When the dataset has no unit of measure then it is omitted", func() {
+			datasetDoc.Current.UnitOfMeasure = ""
+
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldNotContainSubstring, "unit_of_measure")
+		})
+
+		Convey("When a public caller gets the list of versions", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			Convey("Then each version includes the unit of measure of the published dataset, the dataset being loaded once", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var results models.VersionResults
+				So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+				So(results.Items, ShouldHaveLength, 2)
+				So(results.Items[0].UnitOfMeasure, ShouldEqual, "Pounds Sterling")
+				So(results.Items[1].UnitOfMeasure, ShouldEqual, "Pounds Sterling")
+				So(mockedDataStore.GetDatasetCalls(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When the dataset has not been published then a public caller does not find the version", func() {
+			datasetDoc.Current = nil
+
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetNotFound.Error())
+			So(mockedDataStore.GetVersionCalls(), ShouldHaveLength, 0)
+		})
+	})
+}
+
+func TestGetVersionSignsPrePublicationDownloads(t *testing.T) {
+	t.Parallel()
+	Convey("Given versions with downloads and a download url signer", t, func() {
+		state := models.AssociatedState
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionFunc: func(datasetID, editionID, version, st string) (*models.Version, error) {
+				return &models.Version{
+					CollectionID: "collection-1",
+					State:        state,
+					Links: &models.VersionLinks{
+						Self:    &models.LinkObject{},
+						Version: &models.LinkObject{HRef: "href"},
+					},
+					Downloads: &models.DownloadList{
+						CSV: &models.DownloadObject{HRef: "http://localhost:23600/downloads/datasets/123-456/editions/678/versions/1.csv", Size: "10"},
+					},
+				}, nil
+			},
+		}
+		signer := &mocks.DownloadURLSignerMock{
+			SignFunc: func(href string, expires time.Time) (string, error) {
+				return href + "?expires=" + strconv.FormatInt(expires.Unix(), 10) + "&signature=abc", nil
+			},
+		}
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.downloadURLSigner = signer
+		api.downloadURLExpiry = 15 * time.Minute
+
+		getVersion := func() *models.Version {
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			var version models.Version
+			So(json.Unmarshal(w.Body.Bytes(), &version), ShouldBeNil)
+			return &version
+		}
+
+		Convey("When a pre-publication version is requested then its download url is signed with an expiry", func() {
+			before := time.Now()
+			version := getVersion()
+
+			So(len(signer.SignCalls()), ShouldEqual, 1)
+			So(signer.SignCalls()[0].Href, ShouldEqual, "http://localhost:23600/downloads/datasets/123-456/editions/678/versions/1.csv")
+			expires := signer.SignCalls()[0].Expires
+			So(expires, ShouldHappenOnOrBetween, before.Add(15*time.Minute), time.Now().Add(15*time.Minute))
+
+			So(version.Downloads.CSV.HRef, ShouldEqual, "http://localhost:23600/downloads/datasets/123-456/editions/678/versions/1.csv?expires="+strconv.FormatInt(expires.Unix(), 10)+"&signature=abc")
+			So(version.Downloads.CSV.Size, ShouldEqual, "10")
+		})
+
+		Convey("When a published version is requested then its public download url is kept", func() {
+			state = models.PublishedState
+			version := getVersion()
+
+			So(len(signer.SignCalls()), ShouldEqual, 0)
+			So(version.Downloads.CSV.HRef, ShouldEqual, "http://localhost:23600/downloads/datasets/123-456/editions/678/versions/1.csv")
+		})
+
+		Convey("When the download url cannot be signed then an internal server error is returned", func() {
+			signer.SignFunc = func(href string, expires time.Time) (string, error) {
+				return "", errors.New("signing failed")
+			}
+			r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			So(w.Body.String(), ShouldNotContainSubstring, "signing failed")
+		})
+	})
+}
+
+func TestGetVersionReturnsError(t *testing.T) {
+	auditParams := common.Params{"dataset_id": "123-456", "edition": "678", "version": "1"}
+	t.Parallel()
+	Convey("When the api cannot connect to datastore return an internal server error", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return nil, errs.ErrInternalServer
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		assertInternalServerErr(w)
+		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+		So(permissions.Required.Calls, ShouldEqual, 0)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{Action: getVersionAction, Result: audit.Attempted, Params: auditParams},
+			auditortest.Expected{Action: getVersionAction, Result: audit.Unsuccessful, Params: auditParams},
+		)
+	})
+
+	Convey("When the dataset does not exist for return status not found", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
+		r.Header.Add("internal_token", "coffee")
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return nil, errs.ErrDatasetNotFound
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
 		api.Router.ServeHTTP(w, r)
 
 		So(w.Code, ShouldEqual, http.StatusNotFound)
-		So(w.Body.String(), ShouldContainSubstring, errs.ErrVersionNotFound.Error())
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetNotFound.Error())
 
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 0)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{Action: getVersionAction, Result: audit.Attempted, Params: auditParams},
+			auditortest.Expected{Action: getVersionAction, Result: audit.Unsuccessful, Params: auditParams},
+		)
+	})
+
+	Convey("When the edition of a dataset does not exist return status not found", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
+		r.Header.Add("internal_token", "coffee")
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return errs.ErrEditionNotFound
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrEditionNotFound.Error())
+
+		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+		So(permissions.Required.Calls, ShouldEqual, 0)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 0)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{Action: getVersionAction, Result: audit.Attempted, Params: auditParams},
+			auditortest.Expected{Action: getVersionAction, Result: audit.Unsuccessful, Params: auditParams},
+		)
+	})
+
+	Convey("When version does not exist for an edition of a dataset return status not found", t, func() {
+		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
+		r.Header.Add("internal_token", "coffee")
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
+			},
+			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
+				return nil
+			},
+			GetVersionFunc: func(datasetID, editionID, version, state string) (*models.Version, error) {
+				return nil, errs.ErrVersionNotFound
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "application/json")
+
+		var response errs.ErrorResponse
+		So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+		So(response, ShouldResemble, errs.ErrorResponse{Code: "version_not_found", Message: errs.ErrVersionNotFound.Error()})
+
+		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+		So(permissions.Required.Calls, ShouldEqual, 0)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 1)
 
@@ -685,8 +1064,8 @@ func TestGetVersionReturnsError(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return nil
@@ -707,7 +1086,7 @@ func TestGetVersionReturnsError(t *testing.T) {
 
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 1)
 
@@ -723,8 +1102,8 @@ func TestGetVersionReturnsError(t *testing.T) {
 		w := httptest.NewRecorder()
 
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return nil
@@ -753,7 +1132,7 @@ func TestGetVersionReturnsError(t *testing.T) {
 
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 1)
 
@@ -771,8 +1150,8 @@ func TestGetVersionAuditErrors(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123-456/editions/678/versions/1", nil)
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return errs.ErrInternalServer
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return nil, errs.ErrInternalServer
 			},
 		}
 
@@ -792,7 +1171,7 @@ func TestGetVersionAuditErrors(t *testing.T) {
 		assertInternalServerErr(w)
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
 
 		auditor.AssertRecordCalls(
 			auditortest.Expected{Action: getVersionAction, Result: audit.Attempted, Params: auditParams},
@@ -804,8 +1183,8 @@ func TestGetVersionAuditErrors(t *testing.T) {
 		r.Header.Add("internal_token", "coffee")
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return errs.ErrDatasetNotFound
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return nil, errs.ErrDatasetNotFound
 			},
 		}
 
@@ -818,7 +1197,7 @@ func TestGetVersionAuditErrors(t *testing.T) {
 		assertInternalServerErr(w)
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 0)
 		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 0)
 
@@ -833,8 +1212,8 @@ func TestGetVersionAuditErrors(t *testing.T) {
 		r.Header.Add("internal_token", "coffee")
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return errs.ErrEditionNotFound
@@ -851,7 +1230,7 @@ func TestGetVersionAuditErrors(t *testing.T) {
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
 		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 0)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 
 		auditor.AssertRecordCalls(
@@ -865,8 +1244,8 @@ func TestGetVersionAuditErrors(t *testing.T) {
 		r.Header.Add("internal_token", "coffee")
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return nil
@@ -886,7 +1265,7 @@ func TestGetVersionAuditErrors(t *testing.T) {
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
 		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 1)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 
 		auditor.AssertRecordCalls(
@@ -900,8 +1279,8 @@ func TestGetVersionAuditErrors(t *testing.T) {
 		r.Header.Add("internal_token", "coffee")
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return nil
@@ -927,7 +1306,7 @@ func TestGetVersionAuditErrors(t *testing.T) {
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
 		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 1)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 
 		auditor.AssertRecordCalls(
@@ -941,8 +1320,8 @@ func TestGetVersionAuditErrors(t *testing.T) {
 
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(datasetID, state string) error {
-				return nil
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(datasetID, editionID, state string) error {
 				return nil
@@ -970,7 +1349,7 @@ func TestGetVersionAuditErrors(t *testing.T) {
 		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
 		So(permissions.Required.Calls, ShouldEqual, 0)
 		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 1)
-		So(len(mockedDataStore.CheckDatasetExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
 
 		auditor.AssertRecordCalls(
@@ -987,7 +1366,7 @@ func TestPutVersionReturnsSuccessfully(t *testing.T) {
 	t.Parallel()
 	Convey("When state is unchanged", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -1019,7 +1398,7 @@ func TestPutVersionReturnsSuccessfully(t *testing.T) {
 						},
 						Edition: &models.LinkObject{
 							HRef: "http://localhost:22000/datasets/123/editions/2017",
-							ID:   "456",
+							ID:   "2017",
 						},
 						Self: &models.LinkObject{
 							HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1",
@@ -1066,7 +1445,7 @@ func TestPutVersionReturnsSuccessfully(t *testing.T) {
 
 	Convey("When state is set to associated", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -1132,7 +1511,7 @@ func TestPutVersionReturnsSuccessfully(t *testing.T) {
 		downloadsGenerated := make(chan bool, 1)
 
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				downloadsGenerated <- true
 				return nil
 			},
@@ -1192,6 +1571,7 @@ func TestPutVersionReturnsSuccessfully(t *testing.T) {
 		So(len(mockedDataStore.SetInstanceIsPublishedCalls()), ShouldEqual, 0)
 		So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 0)
 		So(len(generatorMock.GenerateCalls()), ShouldEqual, 1)
+		So(generatorMock.GenerateCalls()[0].Formats, ShouldResemble, []string{"csv", "csvw", "xls"})
 
 		auditor.AssertRecordCalls(
 			auditortest.Expected{Action: updateVersionAction, Result: audit.Attempted, Params: auditParamsWithCallerIdentity},
@@ -1206,49 +1586,105 @@ func TestPutVersionReturnsSuccessfully(t *testing.T) {
 		})
 	})
 
-	Convey("When state is set to published", t, func() {
+	Convey("When state is set to edition-confirmed and only csv downloads are requested", t, func() {
+		downloadsGenerated := make(chan bool, 1)
+
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
+				downloadsGenerated <- true
 				return nil
 			},
 		}
 
-		var b string
-		b = versionPublishedPayload
+		b := `{"instance_id":"a1b2c3","edition":"2017","license":"ONS","release_date":"2017-04-04","state":"associated","collection_id":"12345","download_formats":["csv"]}`
 		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(b))
 		So(err, ShouldBeNil)
 
 		w := httptest.NewRecorder()
 
 		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{}, nil
+			},
 			CheckEditionExistsFunc: func(string, string, string) error {
 				return nil
 			},
 			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
 				return &models.Version{
-					ID: "789",
-					Links: &models.VersionLinks{
-						Dataset: &models.LinkObject{
-							HRef: "http://localhost:22000/datasets/123",
-							ID:   "123",
-						},
-						Dimensions: &models.LinkObject{
-							HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1/dimensions",
-						},
-						Edition: &models.LinkObject{
-							HRef: "http://localhost:22000/datasets/123/editions/2017",
-							ID:   "2017",
-						},
-						Self: &models.LinkObject{
-							HRef: "http://localhost:22000/instances/765",
-						},
-						Version: &models.LinkObject{
-							HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1",
-							ID:   "1",
-						},
-					},
-					ReleaseDate: "2017-12-12",
-					Downloads: &models.DownloadList{
+					State: models.EditionConfirmedState,
+				}, nil
+			},
+			UpdateVersionFunc: func(string, *models.Version) error {
+				return nil
+			},
+			UpdateDatasetWithAssociationFunc: func(string, string, *models.Version) error {
+				return nil
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, generatorMock, auditor, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		select {
+		case <-downloadsGenerated:
+			log.Info("download generated as expected", nil)
+		case <-time.After(time.Second * 10):
+			err := errors.New("failing test due to timeout")
+			log.Error(err, nil)
+			t.Fail()
+		}
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(len(generatorMock.GenerateCalls()), ShouldEqual, 1)
+		So(generatorMock.GenerateCalls()[0].Formats, ShouldResemble, []string{"csv"})
+	})
+
+	Convey("When state is set to published", t, func() {
+		generatorMock := &mocks.DownloadsGeneratorMock{
+			GenerateFunc: func(string, string, string, string, []string) error {
+				return nil
+			},
+		}
+
+		var b string
+		b = versionPublishedPayload
+		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			CheckEditionExistsFunc: func(string, string, string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					ID: "789",
+					Links: &models.VersionLinks{
+						Dataset: &models.LinkObject{
+							HRef: "http://localhost:22000/datasets/123",
+							ID:   "123",
+						},
+						Dimensions: &models.LinkObject{
+							HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1/dimensions",
+						},
+						Edition: &models.LinkObject{
+							HRef: "http://localhost:22000/datasets/123/editions/2017",
+							ID:   "2017",
+						},
+						Self: &models.LinkObject{
+							HRef: "http://localhost:22000/instances/765",
+						},
+						Version: &models.LinkObject{
+							HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1",
+							ID:   "1",
+						},
+					},
+					ReleaseDate: "2017-12-12",
+					Downloads: &models.DownloadList{
 						CSV: &models.DownloadObject{
 							Private: "s3://csv-exported/myfile.csv",
 							HRef:    "http://localhost:23600/datasets/123/editions/2017/versions/1.csv",
@@ -1315,6 +1751,7 @@ func TestPutVersionReturnsSuccessfully(t *testing.T) {
 		So(len(mockedDataStore.SetInstanceIsPublishedCalls()), ShouldEqual, 1)
 		So(len(mockedDataStore.UpdateDatasetWithAssociationCalls()), ShouldEqual, 0)
 		So(len(generatorMock.GenerateCalls()), ShouldEqual, 1)
+		So(generatorMock.GenerateCalls()[0].Formats, ShouldResemble, []string{"csv"})
 
 		auditor.AssertRecordCalls(
 			auditortest.Expected{Action: updateVersionAction, Result: audit.Attempted, Params: auditParamsWithCallerIdentity},
@@ -1364,7 +1801,7 @@ func updateVersionDownloadTest(r *http.Request, firstAuditParams, secondAuditPar
 	w := httptest.NewRecorder()
 
 	generatorMock := &mocks.DownloadsGeneratorMock{
-		GenerateFunc: func(string, string, string, string) error {
+		GenerateFunc: func(string, string, string, string, []string) error {
 			return nil
 		},
 	}
@@ -1481,7 +1918,7 @@ func TestPutVersionGenerateDownloadsError(t *testing.T) {
 		}
 
 		mockDownloadGenerator := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return mockedErr
 			},
 		}
@@ -1728,7 +2165,7 @@ func TestUpdateVersionAuditErrors(t *testing.T) {
 			},
 			Edition: &models.LinkObject{
 				HRef: "http://localhost:22000/datasets/123/editions/2017",
-				ID:   "456",
+				ID:   "2017",
 			},
 			Self: &models.LinkObject{
 				HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1",
@@ -1950,7 +2387,7 @@ func TestPublishVersionAuditErrors(t *testing.T) {
 					},
 					Edition: &models.LinkObject{
 						HRef: "http://localhost:22000/datasets/123/editions/2017",
-						ID:   "456",
+						ID:   "2017",
 					},
 					Self: &models.LinkObject{
 						HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1",
@@ -2004,7 +2441,7 @@ func TestAssociateVersionAuditErrors(t *testing.T) {
 			},
 			Edition: &models.LinkObject{
 				HRef: "http://localhost:22000/datasets/123/editions/2017",
-				ID:   "456",
+				ID:   "2017",
 			},
 			Self: &models.LinkObject{
 				HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1",
@@ -2092,7 +2529,7 @@ func TestAssociateVersionAuditErrors(t *testing.T) {
 				},
 			}
 			gen := &mocks.DownloadsGeneratorMock{
-				GenerateFunc: func(datasetID string, instanceID string, edition string, version string) error {
+				GenerateFunc: func(datasetID string, instanceID string, edition string, version string, formats []string) error {
 					return expectedErr
 				},
 			}
@@ -2128,7 +2565,7 @@ func TestAssociateVersionAuditErrors(t *testing.T) {
 				},
 			}
 			gen := &mocks.DownloadsGeneratorMock{
-				GenerateFunc: func(datasetID string, instanceID string, edition string, version string) error {
+				GenerateFunc: func(datasetID string, instanceID string, edition string, version string, formats []string) error {
 					return nil
 				},
 			}
@@ -2154,13 +2591,76 @@ func TestAssociateVersionAuditErrors(t *testing.T) {
 	})
 }
 
+func TestPutVersionDoesNotGenerateDownloadsBeforeAssociation(t *testing.T) {
+	t.Parallel()
+	putVersion := func(body string) (*httptest.ResponseRecorder, *mocks.DownloadsGeneratorMock) {
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{}, nil
+			},
+			CheckEditionExistsFunc: func(string, string, string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					ID: "789",
+					Links: &models.VersionLinks{
+						Dataset: &models.LinkObject{ID: "123", HRef: "http://localhost:22000/datasets/123"},
+						Edition: &models.LinkObject{ID: "2017", HRef: "http://localhost:22000/datasets/123/editions/2017"},
+						Self:    &models.LinkObject{HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1"},
+					},
+					ReleaseDate: "2017-12-12",
+					State:       models.EditionConfirmedState,
+				}, nil
+			},
+			UpdateVersionFunc: func(string, *models.Version) error {
+				return nil
+			},
+		}
+		generator := &mocks.DownloadsGeneratorMock{
+			GenerateFunc: func(string, string, string, string, []string) error {
+				return nil
+			},
+		}
+
+		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(body))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		api := GetAPIWithMocks(mockedDataStore, generator, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		return w, generator
+	}
+
+	Convey("Given an edition-confirmed version", t, func() {
+		Convey("When the version is updated and left edition-confirmed", func() {
+			w, generator := putVersion(`{"release_date":"2017-12-12","state":"edition-confirmed"}`)
+
+			Convey("Then the version is updated without generating downloads", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(generator.GenerateCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the version is moved back to created", func() {
+			w, generator := putVersion(`{"release_date":"2017-12-12","state":"created"}`)
+
+			Convey("Then the update is rejected without generating downloads", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(len(generator.GenerateCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
 func TestPutVersionReturnsError(t *testing.T) {
 	auditParams := common.Params{"dataset_id": "123", "edition": "2017", "version": "1"}
 	auditParamsWithCallerIdentity := common.Params{"caller_identity": "someone@ons.gov.uk", "dataset_id": "123", "edition": "2017", "version": "1"}
 	t.Parallel()
 	Convey("When the request contain malformed json a bad request status is returned", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -2206,9 +2706,49 @@ func TestPutVersionReturnsError(t *testing.T) {
 		})
 	})
 
+	Convey("When the version links given are for a different dataset a bad request status is returned", t, func() {
+		b := `{"links":{"dataset":{"id":"456","href":"http://localhost:22000/datasets/456"},"self":{"href":"http://localhost:22000/datasets/456/editions/2017/versions/1"}}}`
+		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{}, nil
+			},
+			CheckEditionExistsFunc: func(string, string, string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					ID: "789",
+					Links: &models.VersionLinks{
+						Dataset: &models.LinkObject{HRef: "http://localhost:22000/datasets/123", ID: "123"},
+						Self:    &models.LinkObject{HRef: "http://localhost:22000/instances/789"},
+					},
+					State: models.EditionConfirmedState,
+				}, nil
+			},
+		}
+
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+
+		api.Router.ServeHTTP(w, r)
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, `links.dataset.id "456" does not match dataset "123"`)
+		So(w.Body.String(), ShouldContainSubstring, `links.self.href "http://localhost:22000/datasets/456/editions/2017/versions/1" does not match`)
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 0)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{Action: updateVersionAction, Result: audit.Attempted, Params: auditParamsWithCallerIdentity},
+			auditortest.Expected{Action: updateVersionAction, Result: audit.Unsuccessful, Params: auditParams},
+		)
+	})
+
 	Convey("When the api cannot connect to datastore return an internal server error", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -2256,7 +2796,7 @@ func TestPutVersionReturnsError(t *testing.T) {
 
 	Convey("When the dataset document cannot be found for version return status not found", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(datasetID string, edition string, versionID string, version string) error {
+			GenerateFunc: func(datasetID string, edition string, versionID string, version string, formats []string) error {
 				return nil
 			},
 		}
@@ -2308,7 +2848,7 @@ func TestPutVersionReturnsError(t *testing.T) {
 
 	Convey("When the edition document cannot be found for version return status not found", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -2360,7 +2900,7 @@ func TestPutVersionReturnsError(t *testing.T) {
 
 	Convey("When the version document cannot be found return status not found", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -2416,7 +2956,7 @@ func TestPutVersionReturnsError(t *testing.T) {
 
 	Convey("When the request is not authorised to update version then response returns status not found", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -2460,7 +3000,7 @@ func TestPutVersionReturnsError(t *testing.T) {
 
 	Convey("When the version document has already been published return status forbidden", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -2470,149 +3010,588 @@ func TestPutVersionReturnsError(t *testing.T) {
 		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(b))
 		So(err, ShouldBeNil)
 
-		w := httptest.NewRecorder()
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					State: models.PublishedState,
+				}, nil
+			},
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{}, nil
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, generatorMock, auditor, datasetPermissions, permissions)
+
+		api.Router.ServeHTTP(w, r)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(w.Body.String(), ShouldEqual, "unable to update version as it has been published\n")
+
+		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
+		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+		So(permissions.Required.Calls, ShouldEqual, 0)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{Action: updateVersionAction, Result: audit.Attempted, Params: auditParamsWithCallerIdentity},
+			auditortest.Expected{Action: updateVersionAction, Result: audit.Unsuccessful, Params: auditParams},
+		)
+
+		Convey("then the request body has been drained", func() {
+			_, err = r.Body.Read(make([]byte, 1))
+			So(err, ShouldEqual, io.EOF)
+		})
+	})
+
+	Convey("When the request body is invalid return status bad request", t, func() {
+		generatorMock := &mocks.DownloadsGeneratorMock{
+			GenerateFunc: func(string, string, string, string, []string) error {
+				return nil
+			},
+		}
+
+		var b string
+		b = `{"instance_id":"a1b2c3","edition":"2017","license":"ONS","release_date":"2017-04-04","state":"associated"}`
+		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{State: "associated"}, nil
+			},
+			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{}, nil
+			},
+			CheckEditionExistsFunc: func(string, string, string) error {
+				return nil
+			},
+			UpdateVersionFunc: func(string, *models.Version) error {
+				return nil
+			},
+		}
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, generatorMock, auditor, datasetPermissions, permissions)
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldEqual, "missing collection_id for association between version and a collection\n")
+
+		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+		So(permissions.Required.Calls, ShouldEqual, 0)
+		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 2)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 0)
+		So(len(generatorMock.GenerateCalls()), ShouldEqual, 0)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{Action: updateVersionAction, Result: audit.Attempted, Params: auditParamsWithCallerIdentity},
+			auditortest.Expected{Action: updateVersionAction, Result: audit.Unsuccessful, Params: auditParams},
+		)
+
+		Convey("then the request body has been drained", func() {
+			_, err = r.Body.Read(make([]byte, 1))
+			So(err, ShouldEqual, io.EOF)
+		})
+	})
+
+	Convey("When setting the instance node to published fails", t, func() {
+		generatorMock := &mocks.DownloadsGeneratorMock{
+			GenerateFunc: func(string, string, string, string, []string) error {
+				return nil
+			},
+		}
+
+		var b string
+		b = versionPublishedPayload
+		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(b))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			CheckEditionExistsFunc: func(string, string, string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					ID: "789",
+					Links: &models.VersionLinks{
+						Dataset: &models.LinkObject{
+							HRef: "http://localhost:22000/datasets/123",
+							ID:   "123",
+						},
+						Dimensions: &models.LinkObject{
+							HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1/dimensions",
+						},
+						Edition: &models.LinkObject{
+							HRef: "http://localhost:22000/datasets/123/editions/2017",
+							ID:   "2017",
+						},
+						Self: &models.LinkObject{
+							HRef: "http://localhost:22000/instances/765",
+						},
+						Version: &models.LinkObject{
+							HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1",
+							ID:   "1",
+						},
+					},
+					ReleaseDate: "2017-12-12",
+					Downloads: &models.DownloadList{
+						CSV: &models.DownloadObject{
+							Private: "s3://csv-exported/myfile.csv",
+							HRef:    "http://localhost:23600/datasets/123/editions/2017/versions/1.csv",
+							Size:    "1234",
+						},
+					},
+					State: models.EditionConfirmedState,
+				}, nil
+			},
+			UpdateVersionFunc: func(string, *models.Version) error {
+				return nil
+			},
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{
+					ID:      "123",
+					Next:    &models.Dataset{Links: &models.DatasetLinks{}},
+					Current: &models.Dataset{Links: &models.DatasetLinks{}},
+				}, nil
+			},
+			UpsertDatasetFunc: func(string, *models.DatasetUpdate) error {
+				return nil
+			},
+			GetEditionFunc: func(string, string, string) (*models.EditionUpdate, error) {
+				return &models.EditionUpdate{
+					ID: "123",
+					Next: &models.Edition{
+						State: models.PublishedState,
+						Links: &models.EditionUpdateLinks{
+							Self: &models.LinkObject{
+								HRef: "http://localhost:22000/datasets/123/editions/2017",
+								ID:   "2017",
+							},
+							LatestVersion: &models.LinkObject{
+								HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1",
+								ID:   "1",
+							},
+						},
+					},
+					Current: &models.Edition{},
+				}, nil
+			},
+			UpsertEditionFunc: func(string, string, *models.EditionUpdate) error {
+				return nil
+			},
+			SetInstanceIsPublishedFunc: func(ctx context.Context, instanceID string) error {
+				return errors.New("failed to set is_published on the instance node")
+			},
+		}
+
+		mockedDataStore.GetVersion("789", "2017", "1", "")
+		mockedDataStore.GetEdition("123", "2017", "")
+		mockedDataStore.UpdateVersion("a1b2c3", &models.Version{})
+		mockedDataStore.GetDataset("123")
+		mockedDataStore.UpsertDataset("123", &models.DatasetUpdate{Next: &models.Dataset{}})
+
+		datasetPermissions := getAuthorisationHandlerMock()
+		permissions := getAuthorisationHandlerMock()
+		auditor := auditortest.New()
+		api := GetAPIWithMocks(mockedDataStore, generatorMock, auditor, datasetPermissions, permissions)
+
+		api.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
+		So(permissions.Required.Calls, ShouldEqual, 0)
+		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 3)
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 2)
+		So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 2)
+		So(len(mockedDataStore.SetInstanceIsPublishedCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 2)
+		So(len(mockedDataStore.UpdateDatasetWithAssociationCalls()), ShouldEqual, 0)
+		So(len(generatorMock.GenerateCalls()), ShouldEqual, 0)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{Action: updateVersionAction, Result: audit.Attempted, Params: auditParamsWithCallerIdentity},
+			auditortest.Expected{Action: updateVersionAction, Result: audit.Successful, Params: auditParams},
+			auditortest.Expected{Action: publishVersionAction, Result: audit.Attempted, Params: auditParams},
+			auditortest.Expected{Action: publishVersionAction, Result: audit.Unsuccessful, Params: auditParams},
+		)
+
+		Convey("then the request body has been drained", func() {
+			_, err = r.Body.Read(make([]byte, 1))
+			So(err, ShouldEqual, io.EOF)
+		})
+	})
+}
+
+func TestPutVersionPublishRollsBackOnFailure(t *testing.T) {
+	t.Parallel()
+	Convey("When publishing a version fails after the version has been updated", t, func() {
+		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(versionPublishedPayload))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+
+		storedState := models.EditionConfirmedState
+		var transactionErr error
+
+		mockedDataStore := &storetest.StorerMock{
+			CheckEditionExistsFunc: func(string, string, string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					ID: "789",
+					Links: &models.VersionLinks{
+						Dataset: &models.LinkObject{HRef: "http://localhost:22000/datasets/123", ID: "123"},
+						Edition: &models.LinkObject{HRef: "http://localhost:22000/datasets/123/editions/2017", ID: "2017"},
+						Version: &models.LinkObject{HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1", ID: "1"},
+					},
+					ReleaseDate: "2017-12-12",
+					State:       storedState,
+				}, nil
+			},
+			UpdateVersionFunc: func(id string, version *models.Version) error {
+				storedState = version.State
+				return nil
+			},
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{
+					ID:   "123",
+					Next: &models.Dataset{Links: &models.DatasetLinks{}},
+				}, nil
+			},
+			GetEditionFunc: func(string, string, string) (*models.EditionUpdate, error) {
+				return &models.EditionUpdate{
+					ID: "123",
+					Next: &models.Edition{
+						Links: &models.EditionUpdateLinks{
+							Self:          &models.LinkObject{HRef: "http://localhost:22000/datasets/123/editions/2017"},
+							LatestVersion: &models.LinkObject{HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1", ID: "1"},
+						},
+					},
+				}, nil
+			},
+			UpsertEditionFunc: func(string, string, *models.EditionUpdate) error {
+				return nil
+			},
+			SetInstanceIsPublishedFunc: func(context.Context, string) error {
+				return nil
+			},
+			UpsertDatasetFunc: func(string, *models.DatasetUpdate) error {
+				return errs.ErrInternalServer
+			},
+		}
+		// restore the version state when the unit of work fails, as the real store does
+		mockedDataStore.WithTransactionFunc = func(fn func(store.Storer) error) error {
+			before := storedState
+			if transactionErr = fn(mockedDataStore); transactionErr != nil {
+				storedState = before
+			}
+			return transactionErr
+		}
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.Router.ServeHTTP(w, r)
+
+		Convey("Then the publish runs in one transaction which fails, leaving the version unpublished", func() {
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			So(len(mockedDataStore.WithTransactionCalls()), ShouldEqual, 1)
+			So(transactionErr, ShouldEqual, errs.ErrInternalServer)
+			So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 1)
+			So(mockedDataStore.UpdateVersionCalls()[0].Version.State, ShouldEqual, models.PublishedState)
+			So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 1)
+			So(storedState, ShouldEqual, models.EditionConfirmedState)
+		})
+
+		Convey("Then the instance node is not published, as the graph database is outside the transaction", func() {
+			So(len(mockedDataStore.SetInstanceIsPublishedCalls()), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestPutVersionPublishDenylist(t *testing.T) {
+	t.Parallel()
+	publishVersion := func(publishDenylist []string) (*httptest.ResponseRecorder, *storetest.StorerMock) {
+		mockedDataStore := &storetest.StorerMock{
+			CheckEditionExistsFunc: func(string, string, string) error {
+				return nil
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					ID: "789",
+					Links: &models.VersionLinks{
+						Dataset: &models.LinkObject{ID: "123", HRef: "http://localhost:22000/datasets/123"},
+						Edition: &models.LinkObject{ID: "2017", HRef: "http://localhost:22000/datasets/123/editions/2017"},
+						Self:    &models.LinkObject{HRef: "http://localhost:22000/instances/789"},
+						Version: &models.LinkObject{ID: "1", HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1"},
+					},
+					ReleaseDate: "2017-12-12",
+					State:       models.EditionConfirmedState,
+				}, nil
+			},
+			UpdateVersionFunc: func(string, *models.Version) error {
+				return nil
+			},
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{
+					ID:      "123",
+					Next:    &models.Dataset{Links: &models.DatasetLinks{}},
+					Current: &models.Dataset{Links: &models.DatasetLinks{}},
+				}, nil
+			},
+			UpsertDatasetFunc: func(string, *models.DatasetUpdate) error {
+				return nil
+			},
+			GetEditionFunc: func(string, string, string) (*models.EditionUpdate, error) {
+				return &models.EditionUpdate{
+					ID: "123",
+					Next: &models.Edition{
+						Edition: "2017",
+						State:   models.EditionConfirmedState,
+						Links:   &models.EditionUpdateLinks{LatestVersion: &models.LinkObject{ID: "1"}},
+					},
+				}, nil
+			},
+			UpsertEditionFunc: func(string, string, *models.EditionUpdate) error {
+				return nil
+			},
+			SetInstanceIsPublishedFunc: func(ctx context.Context, instanceID string) error {
+				return nil
+			},
+		}
+
+		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(versionPublishedPayload))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.publishDenylist = publishDenylist
+		api.Router.ServeHTTP(w, r)
+
+		return w, mockedDataStore
+	}
+
+	Convey("When a version of a dataset on the publish denylist is published then a forbidden response is returned", t, func() {
+		w, mockedDataStore := publishVersion([]string{"456", "123"})
+
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetPublishDenied.Error())
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 0)
+	})
+
+	Convey("When a version of a dataset not on the publish denylist is published then it is published", t, func() {
+		w, mockedDataStore := publishVersion([]string{"456"})
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 1)
+	})
+}
+
+func TestPutVersionPublishPastReleaseDate(t *testing.T) {
+	t.Parallel()
+	publishVersion := func(rejectPastReleaseDates bool) (*httptest.ResponseRecorder, *storetest.StorerMock) {
 		mockedDataStore := &storetest.StorerMock{
+			CheckEditionExistsFunc: func(string, string, string) error {
+				return nil
+			},
 			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
 				return &models.Version{
-					State: models.PublishedState,
+					ID: "789",
+					Links: &models.VersionLinks{
+						Dataset: &models.LinkObject{ID: "123", HRef: "http://localhost:22000/datasets/123"},
+						Edition: &models.LinkObject{ID: "2017", HRef: "http://localhost:22000/datasets/123/editions/2017"},
+						Self:    &models.LinkObject{HRef: "http://localhost:22000/instances/789"},
+						Version: &models.LinkObject{ID: "1", HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1"},
+					},
+					ReleaseDate: "2017-12-12",
+					State:       models.EditionConfirmedState,
 				}, nil
 			},
-			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
-				return &models.DatasetUpdate{}, nil
+			UpdateVersionFunc: func(string, *models.Version) error {
+				return nil
+			},
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{
+					ID:      "123",
+					Next:    &models.Dataset{Links: &models.DatasetLinks{}},
+					Current: &models.Dataset{Links: &models.DatasetLinks{}},
+				}, nil
+			},
+			UpsertDatasetFunc: func(string, *models.DatasetUpdate) error {
+				return nil
+			},
+			GetEditionFunc: func(string, string, string) (*models.EditionUpdate, error) {
+				return &models.EditionUpdate{
+					ID: "123",
+					Next: &models.Edition{
+						Edition: "2017",
+						State:   models.EditionConfirmedState,
+						Links:   &models.EditionUpdateLinks{LatestVersion: &models.LinkObject{ID: "1"}},
+					},
+				}, nil
+			},
+			UpsertEditionFunc: func(string, string, *models.EditionUpdate) error {
+				return nil
+			},
+			SetInstanceIsPublishedFunc: func(ctx context.Context, instanceID string) error {
+				return nil
 			},
 		}
 
-		datasetPermissions := getAuthorisationHandlerMock()
-		permissions := getAuthorisationHandlerMock()
-		auditor := auditortest.New()
-		api := GetAPIWithMocks(mockedDataStore, generatorMock, auditor, datasetPermissions, permissions)
+		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(versionPublishedPayload))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
 
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.rejectPastReleaseDates = rejectPastReleaseDates
 		api.Router.ServeHTTP(w, r)
-		So(w.Code, ShouldEqual, http.StatusForbidden)
-		So(w.Body.String(), ShouldEqual, "unable to update version as it has been published\n")
 
-		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 1)
-		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
-		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
-		So(permissions.Required.Calls, ShouldEqual, 0)
+		return w, mockedDataStore
+	}
 
-		auditor.AssertRecordCalls(
-			auditortest.Expected{Action: updateVersionAction, Result: audit.Attempted, Params: auditParamsWithCallerIdentity},
-			auditortest.Expected{Action: updateVersionAction, Result: audit.Unsuccessful, Params: auditParams},
-		)
+	Convey("When past release dates are rejected and a version released in the past is published then a bad request response is returned", t, func() {
+		w, mockedDataStore := publishVersion(true)
 
-		Convey("then the request body has been drained", func() {
-			_, err = r.Body.Read(make([]byte, 1))
-			So(err, ShouldEqual, io.EOF)
-		})
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrVersionReleaseDateInPast.Error())
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 0)
 	})
 
-	Convey("When the request body is invalid return status bad request", t, func() {
-		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
-				return nil
-			},
-		}
+	Convey("When past release dates are allowed and a version released in the past is published then it is published", t, func() {
+		w, mockedDataStore := publishVersion(false)
 
-		var b string
-		b = `{"instance_id":"a1b2c3","edition":"2017","license":"ONS","release_date":"2017-04-04","state":"associated"}`
-		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(b))
-		So(err, ShouldBeNil)
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 1)
+	})
+}
 
-		w := httptest.NewRecorder()
+func TestPutVersionLabels(t *testing.T) {
+	t.Parallel()
+	putLabels := func(state, body string) (*httptest.ResponseRecorder, *storetest.StorerMock) {
 		mockedDataStore := &storetest.StorerMock{
-			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
-				return &models.Version{State: "associated"}, nil
-			},
-			GetDatasetFunc: func(datasetID string) (*models.DatasetUpdate, error) {
-				return &models.DatasetUpdate{}, nil
-			},
 			CheckEditionExistsFunc: func(string, string, string) error {
 				return nil
 			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return &models.Version{
+					ID: "789",
+					Links: &models.VersionLinks{
+						Dataset: &models.LinkObject{ID: "123", HRef: "http://localhost:22000/datasets/123"},
+						Edition: &models.LinkObject{ID: "2017", HRef: "http://localhost:22000/datasets/123/editions/2017"},
+						Self:    &models.LinkObject{HRef: "http://localhost:22000/instances/789"},
+						Version: &models.LinkObject{ID: "1", HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1"},
+					},
+					Labels:      map[string]string{"qa_status": "pending"},
+					ReleaseDate: "2017-12-12",
+					State:       state,
+				}, nil
+			},
 			UpdateVersionFunc: func(string, *models.Version) error {
 				return nil
 			},
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{
+					ID:      "123",
+					Next:    &models.Dataset{Links: &models.DatasetLinks{}},
+					Current: &models.Dataset{Links: &models.DatasetLinks{}},
+				}, nil
+			},
 		}
 
-		datasetPermissions := getAuthorisationHandlerMock()
-		permissions := getAuthorisationHandlerMock()
-		auditor := auditortest.New()
-		api := GetAPIWithMocks(mockedDataStore, generatorMock, auditor, datasetPermissions, permissions)
+		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(body))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
 		api.Router.ServeHTTP(w, r)
 
-		So(w.Code, ShouldEqual, http.StatusBadRequest)
-		So(w.Body.String(), ShouldEqual, "missing collection_id for association between version and a collection\n")
+		return w, mockedDataStore
+	}
 
-		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
-		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 2)
-		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
-		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
-		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 0)
-		So(len(generatorMock.GenerateCalls()), ShouldEqual, 0)
+	Convey("When the labels of an unpublished version are updated then they replace its labels", t, func() {
+		w, mockedDataStore := putLabels(models.EditionConfirmedState, `{"labels":{"qa_status":"passed","reviewed_by":"someone"}}`)
 
-		auditor.AssertRecordCalls(
-			auditortest.Expected{Action: updateVersionAction, Result: audit.Attempted, Params: auditParamsWithCallerIdentity},
-			auditortest.Expected{Action: updateVersionAction, Result: audit.Unsuccessful, Params: auditParams},
-		)
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 1)
+		So(mockedDataStore.UpdateVersionCalls()[0].Version.Labels, ShouldResemble, map[string]string{"qa_status": "passed", "reviewed_by": "someone"})
+	})
 
-		Convey("then the request body has been drained", func() {
-			_, err = r.Body.Read(make([]byte, 1))
-			So(err, ShouldEqual, io.EOF)
-		})
+	Convey("When an unpublished version is updated without labels then its labels are kept", t, func() {
+		w, mockedDataStore := putLabels(models.EditionConfirmedState, `{"release_date":"2018-01-01"}`)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 1)
+		So(mockedDataStore.UpdateVersionCalls()[0].Version.Labels, ShouldResemble, map[string]string{"qa_status": "pending"})
 	})
 
-	Convey("When setting the instance node to published fails", t, func() {
-		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
-				return nil
-			},
-		}
+	Convey("When the labels of a published version are updated then only its labels are changed and it is not republished", t, func() {
+		w, mockedDataStore := putLabels(models.PublishedState, `{"labels":{"qa_status":"passed"},"release_date":"2018-01-01"}`)
 
-		var b string
-		b = versionPublishedPayload
-		r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(b))
-		So(err, ShouldBeNil)
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 1)
+		updated := mockedDataStore.UpdateVersionCalls()[0].Version
+		So(updated.Labels, ShouldResemble, map[string]string{"qa_status": "passed"})
+		So(updated.ReleaseDate, ShouldEqual, "2017-12-12")
+		So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 0)
+	})
 
-		w := httptest.NewRecorder()
+	Convey("When a label has a key which is too long then a bad request response is returned", t, func() {
+		w, mockedDataStore := putLabels(models.EditionConfirmedState, `{"labels":{"`+strings.Repeat("k", models.MaxVersionLabelKeyLength+1)+`":"passed"}}`)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrVersionLabelsInvalid.Error())
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 0)
+	})
+
+	Convey("When a label has a key which cannot be stored as a field name then a bad request response is returned", t, func() {
+		w, mockedDataStore := putLabels(models.PublishedState, `{"labels":{"qa.status":"passed"}}`)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrVersionLabelsInvalid.Error())
+		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 0)
+	})
+}
 
+func TestPutVersionNotifiesPublication(t *testing.T) {
+	t.Parallel()
+	Convey("Given an edition-confirmed version", t, func() {
 		mockedDataStore := &storetest.StorerMock{
 			CheckEditionExistsFunc: func(string, string, string) error {
 				return nil
 			},
 			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
 				return &models.Version{
-					ID: "789",
+					ID:      "789",
+					Version: 1,
 					Links: &models.VersionLinks{
-						Dataset: &models.LinkObject{
-							HRef: "http://localhost:22000/datasets/123",
-							ID:   "123",
-						},
-						Dimensions: &models.LinkObject{
-							HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1/dimensions",
-						},
-						Edition: &models.LinkObject{
-							HRef: "http://localhost:22000/datasets/123/editions/2017",
-							ID:   "2017",
-						},
-						Self: &models.LinkObject{
-							HRef: "http://localhost:22000/instances/765",
-						},
-						Version: &models.LinkObject{
-							HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1",
-							ID:   "1",
-						},
+						Dataset: &models.LinkObject{ID: "123", HRef: "http://localhost:22000/datasets/123"},
+						Edition: &models.LinkObject{ID: "2017", HRef: "http://localhost:22000/datasets/123/editions/2017"},
+						Self:    &models.LinkObject{HRef: "http://localhost:22000/instances/789"},
+						Version: &models.LinkObject{ID: "1", HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1"},
 					},
 					ReleaseDate: "2017-12-12",
-					Downloads: &models.DownloadList{
-						CSV: &models.DownloadObject{
-							Private: "s3://csv-exported/myfile.csv",
-							HRef:    "http://localhost:23600/datasets/123/editions/2017/versions/1.csv",
-							Size:    "1234",
-						},
-					},
-					State: models.EditionConfirmedState,
+					State:       models.EditionConfirmedState,
 				}, nil
 			},
 			UpdateVersionFunc: func(string, *models.Version) error {
@@ -2632,65 +3611,63 @@ func TestPutVersionReturnsError(t *testing.T) {
 				return &models.EditionUpdate{
 					ID: "123",
 					Next: &models.Edition{
-						State: models.PublishedState,
-						Links: &models.EditionUpdateLinks{
-							Self: &models.LinkObject{
-								HRef: "http://localhost:22000/datasets/123/editions/2017",
-								ID:   "2017",
-							},
-							LatestVersion: &models.LinkObject{
-								HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1",
-								ID:   "1",
-							},
-						},
+						Edition: "2017",
+						State:   models.EditionConfirmedState,
+						Links:   &models.EditionUpdateLinks{LatestVersion: &models.LinkObject{ID: "1"}},
 					},
-					Current: &models.Edition{},
 				}, nil
 			},
 			UpsertEditionFunc: func(string, string, *models.EditionUpdate) error {
 				return nil
 			},
 			SetInstanceIsPublishedFunc: func(ctx context.Context, instanceID string) error {
-				return errors.New("failed to set is_published on the instance node")
+				return nil
 			},
 		}
+		notifier := publishNotifierMock()
+		api := GetAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+		api.publishNotifier = notifier
 
-		mockedDataStore.GetVersion("789", "2017", "1", "")
-		mockedDataStore.GetEdition("123", "2017", "")
-		mockedDataStore.UpdateVersion("a1b2c3", &models.Version{})
-		mockedDataStore.GetDataset("123")
-		mockedDataStore.UpsertDataset("123", &models.DatasetUpdate{Next: &models.Dataset{}})
+		Convey("When the version is published then the webhooks are notified once", func() {
+			r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(versionPublishedPayload))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
 
-		datasetPermissions := getAuthorisationHandlerMock()
-		permissions := getAuthorisationHandlerMock()
-		auditor := auditortest.New()
-		api := GetAPIWithMocks(mockedDataStore, generatorMock, auditor, datasetPermissions, permissions)
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(len(notifier.NotifyPublishedCalls()), ShouldEqual, 1)
+			So(notifier.NotifyPublishedCalls()[0].Event, ShouldResemble, models.VersionPublishedEvent{
+				DatasetID:   "123",
+				Edition:     "2017",
+				Version:     1,
+				InstanceID:  "789",
+				ReleaseDate: "2017-04-04",
+				HRef:        "http://localhost:22000/datasets/123/editions/2017/versions/1",
+			})
+		})
 
-		api.Router.ServeHTTP(w, r)
+		Convey("When publishing the version fails then the webhooks are not notified", func() {
+			mockedDataStore.UpsertDatasetFunc = func(string, *models.DatasetUpdate) error {
+				return errs.ErrInternalServer
+			}
 
-		So(w.Code, ShouldEqual, http.StatusInternalServerError)
-		So(datasetPermissions.Required.Calls, ShouldEqual, 1)
-		So(permissions.Required.Calls, ShouldEqual, 0)
-		So(len(mockedDataStore.CheckEditionExistsCalls()), ShouldEqual, 1)
-		So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 3)
-		So(len(mockedDataStore.UpdateVersionCalls()), ShouldEqual, 2)
-		So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 1)
-		So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 2)
-		So(len(mockedDataStore.SetInstanceIsPublishedCalls()), ShouldEqual, 1)
-		So(len(mockedDataStore.UpsertDatasetCalls()), ShouldEqual, 1)
-		So(len(mockedDataStore.UpdateDatasetWithAssociationCalls()), ShouldEqual, 0)
-		So(len(generatorMock.GenerateCalls()), ShouldEqual, 0)
+			r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(versionPublishedPayload))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
 
-		auditor.AssertRecordCalls(
-			auditortest.Expected{Action: updateVersionAction, Result: audit.Attempted, Params: auditParamsWithCallerIdentity},
-			auditortest.Expected{Action: updateVersionAction, Result: audit.Successful, Params: auditParams},
-			auditortest.Expected{Action: publishVersionAction, Result: audit.Attempted, Params: auditParams},
-			auditortest.Expected{Action: publishVersionAction, Result: audit.Unsuccessful, Params: auditParams},
-		)
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			So(len(notifier.NotifyPublishedCalls()), ShouldEqual, 0)
+		})
 
-		Convey("then the request body has been drained", func() {
-			_, err = r.Body.Read(make([]byte, 1))
-			So(err, ShouldEqual, io.EOF)
+		Convey("When the version is only updated then the webhooks are not notified", func() {
+			r, err := createRequestWithAuth("PUT", "http://localhost:22000/datasets/123/editions/2017/versions/1", bytes.NewBufferString(`{"release_date":"2017-04-04"}`))
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(len(notifier.NotifyPublishedCalls()), ShouldEqual, 0)
 		})
 	})
 }
@@ -2826,7 +3803,7 @@ func TestDetachVersionReturnOK(t *testing.T) {
 
 	Convey("A successful detach request against a version of a published dataset returns 200 OK response.", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -2892,7 +3869,7 @@ func TestDetachVersionReturnOK(t *testing.T) {
 
 	Convey("A successful detach request against a version of a unpublished dataset returns 200 OK response.", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -2972,7 +3949,7 @@ func TestDetachVersionReturnsError(t *testing.T) {
 
 	Convey("When the api cannot connect to datastore return an internal server error.", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -3012,7 +3989,7 @@ func TestDetachVersionReturnsError(t *testing.T) {
 
 	Convey("When the provided edition cannot be found, return a 404 not found error.", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -3052,7 +4029,7 @@ func TestDetachVersionReturnsError(t *testing.T) {
 
 	Convey("When detached is called against a version other than latest, return an internal server error", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -3095,7 +4072,7 @@ func TestDetachVersionReturnsError(t *testing.T) {
 
 	Convey("When state is neither edition-confirmed or associated, return an internal server error", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -3141,7 +4118,7 @@ func TestDetachVersionReturnsError(t *testing.T) {
 
 	Convey("When the requested version cannot be found, return a not found error", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -3187,7 +4164,7 @@ func TestDetachVersionReturnsError(t *testing.T) {
 
 	Convey("When updating the version fails, return an internal server error", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -3242,7 +4219,7 @@ func TestDetachVersionReturnsError(t *testing.T) {
 
 	Convey("When edition update fails whilst rolling back the edition, return an internal server error", t, func() {
 		generatorMock := &mocks.DownloadsGeneratorMock{
-			GenerateFunc: func(string, string, string, string) error {
+			GenerateFunc: func(string, string, string, string, []string) error {
 				return nil
 			},
 		}
@@ -3303,5 +4280,14 @@ func TestDetachVersionReturnsError(t *testing.T) {
 
 func assertInternalServerErr(w *httptest.ResponseRecorder) {
 	So(w.Code, ShouldEqual, http.StatusInternalServerError)
-	So(strings.TrimSpace(w.Body.String()), ShouldEqual, errs.ErrInternalServer.Error())
+
+	// errors written by the vendored identity middleware do not have a code
+	if w.Header().Get("Content-Type") != "application/json" {
+		So(strings.TrimSpace(w.Body.String()), ShouldEqual, errs.ErrInternalServer.Error())
+		return
+	}
+
+	var response errs.ErrorResponse
+	So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+	So(response, ShouldResemble, errs.ErrorResponse{Code: "internal_error", Message: errs.ErrInternalServer.Error()})
 }
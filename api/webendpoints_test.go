@@ -10,6 +10,7 @@ import (
 	"github.com/ONSdigital/go-ns/audit/auditortest"
 	"github.com/globalsign/mgo/bson"
 
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
 	"github.com/ONSdigital/dp-dataset-api/config"
 	"github.com/ONSdigital/dp-dataset-api/mocks"
 	"github.com/ONSdigital/dp-dataset-api/models"
@@ -32,7 +33,7 @@ func TestWebSubnetDatasetsEndpoint(t *testing.T) {
 
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			GetDatasetsFunc: func() ([]models.DatasetUpdate, error) {
+			GetDatasetsFunc: func(string) ([]models.DatasetUpdate, error) {
 				return []models.DatasetUpdate{{
 					Current: current,
 					Next:    next,
@@ -108,6 +109,9 @@ func TestWebSubnetEditionsEndpoint(t *testing.T) {
 					Items: []*models.EditionUpdate{edition},
 				}, nil
 			},
+			SummariseEditionVersionsFunc: func(datasetID, state string) ([]models.EditionVersions, error) {
+				return []models.EditionVersions{{Edition: "2017", Count: 1, PublishedCount: 1}}, nil
+			},
 		}
 		Convey("Calling the editions endpoint should allow only published items", func() {
 
@@ -157,18 +161,17 @@ func TestWebSubnetVersionsEndpoint(t *testing.T) {
 		r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/1234/editions/1234/versions", nil)
 		So(err, ShouldBeNil)
 
-		var versionSearchState, editionSearchState, datasetSearchState string
+		var versionSearchState, editionSearchState string
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(ID, state string) error {
-				datasetSearchState = state
-				return nil
+			GetDatasetFunc: func(ID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(ID, editionID, state string) error {
 				editionSearchState = state
 				return nil
 			},
-			GetVersionsFunc: func(id string, editionID string, state string) (*models.VersionResults, error) {
+			GetVersionsFunc: func(id string, editionID string, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
 				versionSearchState = state
 				return &models.VersionResults{
 					Items: []models.Version{{ID: "124", State: models.PublishedState}},
@@ -181,7 +184,6 @@ func TestWebSubnetVersionsEndpoint(t *testing.T) {
 
 			api.Router.ServeHTTP(w, r)
 			So(w.Code, ShouldEqual, http.StatusOK)
-			So(datasetSearchState, ShouldEqual, models.PublishedState)
 			So(editionSearchState, ShouldEqual, models.PublishedState)
 			So(versionSearchState, ShouldEqual, models.PublishedState)
 		})
@@ -193,12 +195,11 @@ func TestWebSubnetVersionEndpoint(t *testing.T) {
 		r, err := createRequestWithAuth("GET", "http://localhost:22000/datasets/1234/editions/1234/versions/1234", nil)
 		So(err, ShouldBeNil)
 
-		var versionSearchState, editionSearchState, datasetSearchState string
+		var versionSearchState, editionSearchState string
 		w := httptest.NewRecorder()
 		mockedDataStore := &storetest.StorerMock{
-			CheckDatasetExistsFunc: func(ID, state string) error {
-				datasetSearchState = state
-				return nil
+			GetDatasetFunc: func(ID string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{Current: &models.Dataset{State: models.PublishedState}}, nil
 			},
 			CheckEditionExistsFunc: func(ID, editionID, state string) error {
 				editionSearchState = state
@@ -219,7 +220,6 @@ func TestWebSubnetVersionEndpoint(t *testing.T) {
 			api.Router.ServeHTTP(w, r)
 
 			So(w.Code, ShouldEqual, http.StatusOK)
-			So(datasetSearchState, ShouldEqual, models.PublishedState)
 			So(editionSearchState, ShouldEqual, models.PublishedState)
 			So(versionSearchState, ShouldEqual, models.PublishedState)
 		})
@@ -308,7 +308,10 @@ func TestPublishedSubnetEndpointsAreDisabled(t *testing.T) {
 		{Method: "PUT", URL: "http://localhost:22000/instances/123/dimensions/test"},
 		{Method: "POST", URL: "http://localhost:22000/instances/1/events"},
 		{Method: "PUT", URL: "http://localhost:22000/instances/1/inserted_observations/11"},
+		{Method: "POST", URL: "http://localhost:22000/instances/1/inserted_observations/increment/11"},
 		{Method: "PUT", URL: "http://localhost:22000/instances/1/import_tasks"},
+		{Method: "POST", URL: "http://localhost:22000/instances/1/dimensions/geography/rebuild-hierarchy"},
+		{Method: "POST", URL: "http://localhost:22000/csv-headers/validate"},
 
 		// Dimension endpoints
 		{Method: "GET", URL: "http://localhost:22000/instances/1/dimensions"},
@@ -336,6 +339,76 @@ func TestPublishedSubnetEndpointsAreDisabled(t *testing.T) {
 	})
 }
 
+func TestWebSubnetObservationsEndpoints(t *testing.T) {
+	observationEndpoints := []string{
+		"http://localhost:22000/datasets/1234/editions/2017/versions/1/observations?time=Aug-16",
+		"http://localhost:22000/datasets/1234/editions/2017/versions/1/observations/count",
+		"http://localhost:22000/datasets/1234/editions/2017/versions/1/observations/dimensions",
+		"http://localhost:22000/datasets/1234/editions/2017/versions/1/observations/metadata",
+	}
+
+	getAPI := func(mockedDataStore store.Storer, enablePrivateEndpoints, enableObservations bool) *DatasetAPI {
+		cfg, err := config.Get()
+		So(err, ShouldBeNil)
+		cfg.DatasetAPIURL = host
+		cfg.EnablePrivateEnpoints = enablePrivateEndpoints
+		cfg.EnableObservationsEndpoint = enableObservations
+
+		return NewDatasetAPI(*cfg, mux.NewRouter(), store.DataStore{Backend: mockedDataStore}, urlBuilder, &mocks.DownloadsGeneratorMock{}, &mocks.HierarchyBuildTriggerMock{}, publishNotifierMock(), nil, auditortest.New(), getAuthorisationHandlerMock(), getAuthorisationHandlerMock())
+	}
+
+	datasetNotFound := func() *storetest.StorerMock {
+		return &storetest.StorerMock{
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return nil, errs.ErrDatasetNotFound
+			},
+			GetVersionFunc: func(string, string, string, string) (*models.Version, error) {
+				return nil, errs.ErrVersionNotFound
+			},
+		}
+	}
+
+	Convey("When the API is started with the observations endpoints disabled", t, func() {
+		for _, endpoint := range observationEndpoints {
+			Convey("Then "+endpoint+" returns service unavailable without reading from the store", func() {
+				r := httptest.NewRequest("GET", endpoint, nil)
+				w := httptest.NewRecorder()
+				mockedDataStore := datasetNotFound()
+
+				getAPI(mockedDataStore, false, false).Router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrObservationsUnavailable.Error())
+				So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.GetVersionCalls()), ShouldEqual, 0)
+			})
+		}
+
+		Convey("Then the private observations endpoint is unaffected", func() {
+			r, err := createRequestWithAuth("GET", observationEndpoints[0], nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			getAPI(datasetNotFound(), true, false).Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		})
+	})
+
+	Convey("When the API is started with the observations endpoints enabled", t, func() {
+		Convey("Then requests for observations are handled", func() {
+			r := httptest.NewRequest("GET", observationEndpoints[0], nil)
+			w := httptest.NewRecorder()
+			mockedDataStore := datasetNotFound()
+
+			getAPI(mockedDataStore, false, true).Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+			So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 1)
+		})
+	})
+}
+
 func GetWebAPIWithMocks(mockedDataStore store.Storer, mockedGeneratedDownloads DownloadsGenerator, auditor Auditor, datasetPermissions AuthHandler, permissions AuthHandler) *DatasetAPI {
 	cfg, err := config.Get()
 	So(err, ShouldBeNil)
@@ -343,5 +416,5 @@ func GetWebAPIWithMocks(mockedDataStore store.Storer, mockedGeneratedDownloads D
 	cfg.DatasetAPIURL = host
 	cfg.EnablePrivateEnpoints = false
 
-	return NewDatasetAPI(*cfg, mux.NewRouter(), store.DataStore{Backend: mockedDataStore}, urlBuilder, mockedGeneratedDownloads, auditor, datasetPermissions, permissions)
+	return NewDatasetAPI(*cfg, mux.NewRouter(), store.DataStore{Backend: mockedDataStore}, urlBuilder, mockedGeneratedDownloads, &mocks.HierarchyBuildTriggerMock{}, publishNotifierMock(), nil, auditor, datasetPermissions, permissions)
 }
@@ -0,0 +1,142 @@
+package apierrors
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// codes pairs each error with a stable, machine readable code returned alongside its message, so clients can tell
+// errors apart without matching on the wording of the message. Errors sharing a meaning share a code.
+var codes = map[error]string{
+	ErrAddDatasetAlreadyExists:           "dataset_already_exists",
+	ErrAddUpdateDatasetBadRequest:        "invalid_json",
+	ErrAuditActionAttemptedFailure:       "internal_error",
+	ErrCollectionVersionInvalid:          "collection_version_invalid",
+	ErrConflictUpdatingInstance:          "instance_update_conflict",
+	ErrDatasetContactInvalid:             "dataset_contact_invalid",
+	ErrDatasetNotFound:                   "dataset_not_found",
+	ErrDatasetPublisherTypeInvalid:       "dataset_publisher_type_invalid",
+	ErrDatasetPatchFieldInvalid:          "dataset_patch_field_invalid",
+	ErrDatasetPatchPublishMixed:          "dataset_patch_publish_mixed",
+	ErrDatasetSubtopicsInvalid:           "dataset_subtopics_invalid",
+	ErrDatasetSunsetDateInvalid:          "dataset_sunset_date_invalid",
+	ErrDatasetSurveyInvalid:              "dataset_survey_invalid",
+	ErrDatasetTranslationInvalid:         "dataset_translation_invalid",
+	ErrDatasetTypeInvalid:                "dataset_type_invalid",
+	ErrDatasetURIInvalid:                 "dataset_uri_invalid",
+	ErrDeleteDatasetNotFound:             "dataset_not_found",
+	ErrDeletePublishedDatasetForbidden:   "dataset_published",
+	ErrDatasetPublishDenied:              "dataset_publish_denied",
+	ErrDimensionAlreadyExists:            "dimension_already_exists",
+	ErrDimensionNameExists:               "dimension_name_exists",
+	ErrDimensionNameInvalid:              "dimension_name_invalid",
+	ErrDimensionNodeNotFound:             "dimension_node_not_found",
+	ErrDimensionNotFound:                 "dimension_not_found",
+	ErrDimensionOptionNotFound:           "dimension_option_not_found",
+	ErrDimensionsNotFound:                "dimensions_not_found",
+	ErrEditionNotFound:                   "edition_not_found",
+	ErrEditionsNotFound:                  "editions_not_found",
+	ErrEditionsSortInvalid:               "editions_sort_invalid",
+	ErrHeadersDimensionColumnsInvalid:    "headers_dimension_columns_invalid",
+	ErrHeadersEmpty:                      "headers_empty",
+	ErrHeadersFirstCellInvalid:           "headers_first_cell_invalid",
+	ErrHierarchyTaskNotFound:             "hierarchy_task_not_found",
+	ErrIncorrectStateToDetach:            "version_state_invalid_to_detach",
+	ErrIndexOutOfRange:                   "index_out_of_range",
+	ErrInstanceFailed:                    "instance_failed",
+	ErrInstanceFailureReasonInvalid:      "instance_failure_reason_invalid",
+	ErrInstanceFailureReasonMissing:      "instance_failure_reason_missing",
+	ErrInstanceModified:                  "instance_modified",
+	ErrInstanceNotFound:                  "instance_not_found",
+	ErrInstanceReleaseDateInvalid:        "instance_release_date_invalid",
+	ErrInstanceReleaseDateMissing:        "instance_release_date_missing",
+	ErrInstanceStateInvalid:              "instance_state_invalid",
+	ErrInternalServer:                    "internal_error",
+	ErrInvalidModifiedSince:              "modified_since_invalid",
+	ErrInvalidPaginationParameter:        "pagination_parameter_invalid",
+	ErrInvalidRepresentation:             "representation_invalid",
+	ErrInvalidInclude:                    "include_invalid",
+	ErrInvalidReturn:                     "return_invalid",
+	ErrInvalidRetentionPeriod:            "retention_period_invalid",
+	ErrInsertedObservationsInvalidSyntax: "inserted_observations_invalid",
+	ErrIsBasedOnLinkInvalid:              "is_based_on_link_invalid",
+	ErrJSONTooDeep:                       "json_too_deep",
+	ErrMetadataVersionNotFound:           "version_not_found",
+	ErrMaintenanceMode:                   "maintenance_mode",
+	ErrMalformedVersionHeaders:           "version_headers_malformed",
+	ErrMissingJobProperties:              "job_properties_missing",
+	ErrMissingParameters:                 "parameters_missing",
+	ErrMissingVersionHeadersOrDimensions: "version_headers_or_dimensions_missing",
+	ErrNoAuthHeader:                      "auth_header_missing",
+	ErrNoCollectionVersions:              "collection_versions_missing",
+	ErrObservationRowInvalid:             "observation_row_invalid",
+	ErrObservationRowNotFound:            "observation_row_not_found",
+	ErrObservationsNotFound:              "observations_not_found",
+	ErrObservationsUnavailable:           "observations_unavailable",
+	ErrPurgeInstancesInUse:               "instances_in_use",
+	ErrRequestBodyTooLarge:               "request_body_too_large",
+	ErrRequestTimeout:                    "request_timeout",
+	ErrResourcePublished:                 "resource_published",
+	ErrResourceState:                     "resource_state_invalid",
+	ErrTooManyDimensionOptionCodes:       "too_many_dimension_option_codes",
+	ErrTooManyEditions:                   "too_many_editions",
+	ErrTooManyObservationStreams:         "too_many_observation_streams",
+	ErrTooManyVersions:                   "too_many_versions",
+	ErrTooManyWildcards:                  "too_many_wildcards",
+	ErrUnableToParseJSON:                 "invalid_json",
+	ErrUnableToReadMessage:               "unreadable_body",
+	ErrUnauthorised:                      "unauthorised",
+	ErrUnsupportedContentType:            "unsupported_content_type",
+	ErrVersionMissingState:               "version_state_missing",
+	ErrVersionLabelsInvalid:              "version_labels_invalid",
+	ErrVersionNotFound:                   "version_not_found",
+	ErrVersionReleaseDateInPast:          "version_release_date_in_past",
+	ErrVersionReleaseDateInvalid:         "version_release_date_invalid",
+	ErrVersionOutOfSequence:              "version_out_of_sequence",
+	ErrVersionTransitionsInvalid:         "version_transitions_invalid",
+	ErrVersionAlreadyExists:              "version_already_exists",
+	ErrVersionDiffAgainstInvalid:         "version_diff_against_invalid",
+	ErrNotFound:                          "not_found",
+
+	ErrExpectedResourceStateOfCreated:          "resource_state_not_created",
+	ErrExpectedResourceStateOfSubmitted:        "resource_state_not_submitted",
+	ErrExpectedResourceStateOfCompleted:        "resource_state_not_completed",
+	ErrExpectedResourceStateOfEditionConfirmed: "resource_state_not_edition_confirmed",
+	ErrExpectedResourceStateOfAssociated:       "resource_state_not_associated",
+}
+
+// ErrorResponse is the body of the response to a request which failed with an error that has a code
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Code returns the machine readable code of an error, or an empty string if the error does not have one
+func Code(err error) string {
+	return codes[err]
+}
+
+// WriteError writes an error to the response with the given status. An error with a code is written as a json
+// document holding its code and message, any other error is written as plain text in the same way as http.Error.
+func WriteError(w http.ResponseWriter, err error, status int) {
+	code := Code(err)
+	if code == "" {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// messages are written as they are, without escaping characters such as < or &
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if encodeErr := encoder.Encode(ErrorResponse{Code: code, Message: err.Error()}); encodeErr != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(b.Bytes())
+}
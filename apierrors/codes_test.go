@@ -0,0 +1,37 @@
+package apierrors
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWriteError(t *testing.T) {
+	Convey("When an error with a code is written then its code and message are returned as json", t, func() {
+		w := httptest.NewRecorder()
+		WriteError(w, ErrDatasetNotFound, http.StatusNotFound)
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(w.Header().Get("Content-Type"), ShouldEqual, "application/json")
+		So(w.Body.String(), ShouldEqual, `{"code":"dataset_not_found","message":"dataset not found"}`+"\n")
+	})
+
+	Convey("When an error without a code is written then its message is returned as plain text", t, func() {
+		w := httptest.NewRecorder()
+		WriteError(w, errors.New("something went wrong"), http.StatusBadRequest)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Header().Get("Content-Type"), ShouldStartWith, "text/plain")
+		So(w.Body.String(), ShouldEqual, "something went wrong\n")
+	})
+
+	Convey("Every error paired with a code is returned by Code", t, func() {
+		for err, code := range codes {
+			So(code, ShouldNotBeEmpty)
+			So(code, ShouldEqual, Code(err))
+		}
+	})
+}
@@ -9,36 +9,91 @@ var (
 	ErrAddDatasetAlreadyExists           = errors.New("forbidden - dataset already exists")
 	ErrAddUpdateDatasetBadRequest        = errors.New("failed to parse json body")
 	ErrAuditActionAttemptedFailure       = errors.New("internal server error")
+	ErrCollectionVersionInvalid          = errors.New("dataset_id, edition and version must be provided for each version")
 	ErrConflictUpdatingInstance          = errors.New("conflict updating instance resource")
+	ErrDatasetContactInvalid             = errors.New("each contact must have a name or an email")
 	ErrDatasetNotFound                   = errors.New("dataset not found")
+	ErrDatasetPublisherTypeInvalid       = errors.New("invalid publisher type, can be one of the following: government, government department, devolved administration, public body")
+	ErrDatasetPatchFieldInvalid          = errors.New("patch document attempts to clear a field which cannot be removed")
+	ErrDatasetPatchPublishMixed          = errors.New("patch document publishing the dataset cannot change any other field")
+	ErrDatasetSubtopicsInvalid           = errors.New("too many subtopics, or a subtopic is longer than the maximum length allowed")
+	ErrDatasetSunsetDateInvalid          = errors.New("sunset_date must be a date or RFC3339 timestamp, and a deprecated dataset must have a sunset_date in the future")
+	ErrDatasetSurveyInvalid              = errors.New("survey is longer than the maximum length allowed")
+	ErrDatasetTranslationInvalid         = errors.New("translations must be keyed by a language tag, e.g. cy, and each must have a title or a description")
+	ErrDatasetTypeInvalid                = errors.New("invalid dataset type, can be one of the following: filterable, static")
+	ErrDatasetURIInvalid                 = errors.New("uri must be an absolute url with a scheme of http or https")
 	ErrDeleteDatasetNotFound             = errors.New("dataset not found")
 	ErrDeletePublishedDatasetForbidden   = errors.New("a published dataset cannot be deleted")
+	ErrDatasetPublishDenied              = errors.New("the dataset is on the publish denylist, its versions cannot be published")
+	ErrDimensionAlreadyExists            = errors.New("a dimension of that name with a different code list already exists on the instance")
+	ErrDimensionNameExists               = errors.New("a dimension of that name already exists on the instance")
+	ErrDimensionNameInvalid              = errors.New("invalid dimension name, names must be lowercase and contain no whitespace")
 	ErrDimensionNodeNotFound             = errors.New("dimension node not found")
 	ErrDimensionNotFound                 = errors.New("dimension not found")
 	ErrDimensionOptionNotFound           = errors.New("dimension option not found")
 	ErrDimensionsNotFound                = errors.New("dimensions not found")
 	ErrEditionNotFound                   = errors.New("edition not found")
 	ErrEditionsNotFound                  = errors.New("no editions were found")
+	ErrEditionsSortInvalid               = errors.New("invalid sort parameter, can be one of the following: release_date, -release_date, edition, -edition")
+	ErrHeadersDimensionColumnsInvalid    = errors.New("invalid headers, each dimension must have a code list column followed by a label column")
+	ErrHeadersEmpty                      = errors.New("invalid headers, at least one header must be provided")
+	ErrHeadersFirstCellInvalid           = errors.New("invalid headers, the first header must be in the format V4_N where N is the number of metadata columns following the observation column")
+	ErrHierarchyTaskNotFound             = errors.New("build hierarchy task not found for the dimension")
 	ErrIncorrectStateToDetach            = errors.New("only versions with a state of edition-confirmed or associated can be detached")
 	ErrIndexOutOfRange                   = errors.New("index out of range")
+	ErrInstanceFailed                    = errors.New("unable to update resource as it has failed, a failed instance can only be reset")
+	ErrInstanceFailureReasonInvalid      = errors.New("a failure_reason can only be given when moving an instance to failed")
+	ErrInstanceFailureReasonMissing      = errors.New("a failure_reason must be given when moving an instance to failed")
+	ErrInstanceModified                  = errors.New("instance has been modified since the time given in If-Unmodified-Since")
 	ErrInstanceNotFound                  = errors.New("instance not found")
+	ErrInstanceReleaseDateInvalid        = errors.New("invalid release_date, expected a date in the format YYYY-MM-DD or RFC3339")
+	ErrInstanceReleaseDateMissing        = errors.New("a release_date must be given when moving an instance to edition-confirmed")
+	ErrInstanceStateInvalid              = errors.New("instance resource has an invalid state")
 	ErrInternalServer                    = errors.New("internal error")
+	ErrInvalidModifiedSince              = errors.New("modified_since must be an RFC3339 timestamp, e.g. 2018-06-01T09:00:00Z")
+	ErrInvalidPaginationParameter        = errors.New("offset must be a non-negative integer and limit a positive integer")
+	ErrInvalidRepresentation             = errors.New("invalid representation, can be one of the following: links")
+	ErrInvalidInclude                    = errors.New("invalid include, can be one of the following: latest_version")
+	ErrInvalidReturn                     = errors.New("invalid return, can be one of the following: changes")
+	ErrInvalidRetentionPeriod            = errors.New("older_than must be a positive duration, e.g. 720h")
 	ErrInsertedObservationsInvalidSyntax = errors.New("inserted observation request parameter not an integer")
+	ErrIsBasedOnLinkInvalid              = errors.New("the is_based_on link must have the id of the parent dataset")
+	ErrJSONTooDeep                       = errors.New("json body is nested too deeply")
 	ErrMetadataVersionNotFound           = errors.New("version not found")
+	ErrMaintenanceMode                   = errors.New("the dataset API is in maintenance mode, only read requests are accepted")
+	ErrMalformedVersionHeaders           = errors.New("version headers are malformed")
 	ErrMissingJobProperties              = errors.New("missing job properties")
 	ErrMissingParameters                 = errors.New("missing properties in JSON")
 	ErrMissingVersionHeadersOrDimensions = errors.New("missing headers or dimensions or both from version doc")
 	ErrNoAuthHeader                      = errors.New("no authentication header provided")
+	ErrNoCollectionVersions              = errors.New("no versions were provided to attach to the collection")
+	ErrObservationRowInvalid             = errors.New("row must be a non-negative integer")
+	ErrObservationRowNotFound            = errors.New("no observation was found at the requested row")
 	ErrObservationsNotFound              = errors.New("no observations found")
+	ErrObservationsUnavailable           = errors.New("observations are temporarily unavailable for maintenance")
+	ErrPurgeInstancesInUse               = errors.New("edition-confirmed, associated and published instances are in use and cannot be purged")
+	ErrRequestBodyTooLarge               = errors.New("request body is too large")
+	ErrRequestTimeout                    = errors.New("request timed out")
 	ErrResourcePublished                 = errors.New("unable to update resource as it has been published")
 	ErrResourceState                     = errors.New("incorrect resource state")
+	ErrTooManyDimensionOptionCodes       = errors.New("too many dimension option codes requested")
+	ErrTooManyEditions                   = errors.New("the dataset has reached the maximum number of editions, no more can be created")
+	ErrTooManyObservationStreams         = errors.New("too many observations queries are in progress, try again later")
+	ErrTooManyVersions                   = errors.New("the edition has reached the maximum number of versions, no more can be created")
 	ErrTooManyWildcards                  = errors.New("only one wildcard (*) is allowed as a value in selected query parameters")
 	ErrUnableToParseJSON                 = errors.New("failed to parse json body")
 	ErrUnableToReadMessage               = errors.New("failed to read message body")
 	ErrUnauthorised                      = errors.New("unauthorised access to API")
+	ErrUnsupportedContentType            = errors.New("unsupported content type, request bodies must be application/json")
 	ErrVersionMissingState               = errors.New("missing state from version")
+	ErrVersionLabelsInvalid              = errors.New("too many labels, or a label has an empty key, a key containing . or starting with $, or a key or value longer than the maximum length allowed")
 	ErrVersionNotFound                   = errors.New("version not found")
+	ErrVersionReleaseDateInPast          = errors.New("a version cannot be published with a release_date before today")
+	ErrVersionReleaseDateInvalid         = errors.New("invalid release_date, expected a date in the format YYYY-MM-DD or RFC3339")
+	ErrVersionOutOfSequence              = errors.New("version number is already held by another version of the edition or is already published")
+	ErrVersionTransitionsInvalid         = errors.New("not every version of the edition can be moved to the requested state")
 	ErrVersionAlreadyExists              = errors.New("an unpublished version of this dataset already exists")
+	ErrVersionDiffAgainstInvalid         = errors.New("against must be the number of the version to compare with")
 	ErrNotFound                          = errors.New("not found")
 
 	ErrExpectedResourceStateOfCreated          = errors.New("unable to update resource, expected resource to have a state of created")
@@ -54,20 +109,42 @@ var (
 		ErrDimensionNodeNotFound:   true,
 		ErrDimensionOptionNotFound: true,
 		ErrEditionNotFound:         true,
+		ErrHierarchyTaskNotFound:   true,
 		ErrInstanceNotFound:        true,
 		ErrVersionNotFound:         true,
 	}
 
 	BadRequestMap = map[error]bool{
+		ErrDimensionNameInvalid:              true,
+		ErrHeadersEmpty:                      true,
+		ErrHeadersFirstCellInvalid:           true,
 		ErrInsertedObservationsInvalidSyntax: true,
+		ErrInstanceFailureReasonInvalid:      true,
+		ErrInstanceFailureReasonMissing:      true,
+		ErrInstanceReleaseDateInvalid:        true,
+		ErrInstanceReleaseDateMissing:        true,
+		ErrInvalidRetentionPeriod:            true,
+		ErrInvalidReturn:                     true,
+		ErrIsBasedOnLinkInvalid:              true,
+		ErrJSONTooDeep:                       true,
 		ErrMissingJobProperties:              true,
 		ErrMissingParameters:                 true,
+		ErrPurgeInstancesInUse:               true,
+		ErrRequestBodyTooLarge:               true,
+		ErrTooManyDimensionOptionCodes:       true,
+		ErrTooManyEditions:                   true,
+		ErrTooManyVersions:                   true,
 		ErrUnableToParseJSON:                 true,
 		ErrUnableToReadMessage:               true,
+		ErrVersionReleaseDateInPast:          true,
+		ErrVersionReleaseDateInvalid:         true,
 	}
 
 	ConflictRequestMap = map[error]bool{
 		ErrConflictUpdatingInstance: true,
+		ErrDimensionAlreadyExists:   true,
+		ErrDimensionNameExists:      true,
+		ErrVersionOutOfSequence:     true,
 	}
 
 	ForbiddenMap = map[error]bool{
@@ -77,6 +154,8 @@ var (
 		ErrExpectedResourceStateOfEditionConfirmed: true,
 		ErrExpectedResourceStateOfAssociated:       true,
 
-		ErrResourcePublished: true,
+		ErrDatasetPublishDenied: true,
+		ErrInstanceFailed:       true,
+		ErrResourcePublished:    true,
 	}
 )
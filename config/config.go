@@ -2,17 +2,23 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
 	"time"
 
+	"github.com/ONSdigital/dp-dataset-api/models"
 	"github.com/kelseyhightower/envconfig"
 )
 
 // Configuration structure which hold information for configuring the import API
 type Configuration struct {
 	BindAddr                    string        `envconfig:"BIND_ADDR"`
+	MetricsBindAddr             string        `envconfig:"METRICS_BIND_ADDR"`
 	KafkaAddr                   []string      `envconfig:"KAFKA_ADDR"                       json:"-"`
 	AuditEventsTopic            string        `envconfig:"AUDIT_EVENTS_TOPIC"`
 	GenerateDownloadsTopic      string        `envconfig:"GENERATE_DOWNLOADS_TOPIC"`
+	DataImportCompleteTopic     string        `envconfig:"DATA_IMPORT_COMPLETE_TOPIC"`
 	CodeListAPIURL              string        `envconfig:"CODE_LIST_API_URL"`
 	DatasetAPIURL               string        `envconfig:"DATASET_API_URL"`
 	WebsiteURL                  string        `envconfig:"WEBSITE_URL"`
@@ -22,17 +28,55 @@ type Configuration struct {
 	GracefulShutdownTimeout     time.Duration `envconfig:"GRACEFUL_SHUTDOWN_TIMEOUT"`
 	HealthCheckInterval         time.Duration `envconfig:"HEALTHCHECK_INTERVAL"`
 	HealthCheckRecoveryInterval time.Duration `envconfig:"HEALTHCHECK_RECOVERY_INTERVAL"`
+	HTTPReadTimeout             time.Duration `envconfig:"HTTP_READ_TIMEOUT"`
+	HTTPWriteTimeout            time.Duration `envconfig:"HTTP_WRITE_TIMEOUT"`
+	HTTPIdleTimeout             time.Duration `envconfig:"HTTP_IDLE_TIMEOUT"`
+	HTTPMaxHeaderBytes          int           `envconfig:"HTTP_MAX_HEADER_BYTES"`
+	RequestTimeout              time.Duration `envconfig:"REQUEST_TIMEOUT"`
 	EnablePrivateEnpoints       bool          `envconfig:"ENABLE_PRIVATE_ENDPOINTS"`
 	EnableDetachDataset         bool          `envconfig:"ENABLE_DETACH_DATASET"`
 	EnablePermissionsAuth       bool          `envconfig:"ENABLE_PERMISSIONS_AUTH"`
+	EnableObservationsEndpoint  bool          `envconfig:"ENABLE_OBSERVATIONS_ENDPOINT"`
+	EnableAccessLog             bool          `envconfig:"ENABLE_ACCESS_LOG"`
+	PrettyJSONResponses         bool          `envconfig:"PRETTY_JSON_RESPONSES"`
+	DefaultPageSize             int           `envconfig:"DEFAULT_PAGE_SIZE"`
+	MaxPageSize                 int           `envconfig:"MAX_PAGE_SIZE"`
+	NormaliseDimensionNames     bool          `envconfig:"NORMALISE_DIMENSION_NAMES"`
+	DatasetTreeMaxVersions      int           `envconfig:"DATASET_TREE_MAX_VERSIONS"`
+	MaxEditionsPerDataset       int           `envconfig:"MAX_EDITIONS_PER_DATASET"`
+	MaxVersionsPerEdition       int           `envconfig:"MAX_VERSIONS_PER_EDITION"`
+	DatasetExportMaxVersions    int           `envconfig:"DATASET_EXPORT_MAX_VERSIONS"`
+	DatasetFieldMaxLength       int           `envconfig:"DATASET_FIELD_MAX_LENGTH"`
+	DatasetMaxSubtopics         int           `envconfig:"DATASET_MAX_SUBTOPICS"`
+	InternalNetworks            []string      `envconfig:"INTERNAL_NETWORKS"`
+	CORSAllowedOrigins          []string      `envconfig:"CORS_ALLOWED_ORIGINS"`
+	DownloadFormats             []string      `envconfig:"DOWNLOAD_FORMATS"`
+	JSONMaxDepth                int           `envconfig:"JSON_MAX_DEPTH"`
+	JSONMaxBodySize             int64         `envconfig:"JSON_MAX_BODY_SIZE"`
+	WildcardDimensions          []string      `envconfig:"WILDCARD_DIMENSIONS"`
+	DimensionOptionsMaxCodes    int           `envconfig:"DIMENSION_OPTIONS_MAX_CODES"`
+	MaxObservationStreams       int           `envconfig:"MAX_OBSERVATION_STREAMS"`
+	PublishDenylist             []string      `envconfig:"PUBLISH_DENYLIST"`
+	MaintenanceMode             bool          `envconfig:"MAINTENANCE_MODE"`
+	RedactPublicContacts        bool          `envconfig:"REDACT_PUBLIC_CONTACTS"`
+	RejectPastReleaseDates      bool          `envconfig:"REJECT_PAST_RELEASE_DATES"`
+	PublishWebhookURLs          []string      `envconfig:"PUBLISH_WEBHOOK_URLS"             json:"-"`
+	PublishWebhookTimeout       time.Duration `envconfig:"PUBLISH_WEBHOOK_TIMEOUT"`
+	PublishWebhookMaxRetries    int           `envconfig:"PUBLISH_WEBHOOK_MAX_RETRIES"`
+	DownloadURLSigningKey       string        `envconfig:"DOWNLOAD_URL_SIGNING_KEY"         json:"-"`
+	DownloadURLExpiry           time.Duration `envconfig:"DOWNLOAD_URL_EXPIRY"`
+	InstanceProgressInterval    time.Duration `envconfig:"INSTANCE_PROGRESS_INTERVAL"`
 	MongoConfig                 MongoConfig
 }
 
 // MongoConfig contains the config required to connect to MongoDB.
 type MongoConfig struct {
-	BindAddr   string `envconfig:"MONGODB_BIND_ADDR"   json:"-"`
-	Collection string `envconfig:"MONGODB_COLLECTION"`
-	Database   string `envconfig:"MONGODB_DATABASE"`
+	BindAddr          string        `envconfig:"MONGODB_BIND_ADDR"   json:"-"`
+	Collection        string        `envconfig:"MONGODB_COLLECTION"`
+	Database          string        `envconfig:"MONGODB_DATABASE"`
+	SecondaryReads    bool          `envconfig:"MONGODB_SECONDARY_READS"`
+	WriteMaxAttempts  int           `envconfig:"MONGODB_WRITE_MAX_ATTEMPTS"`
+	WriteRetryBackoff time.Duration `envconfig:"MONGODB_WRITE_RETRY_BACKOFF"`
 }
 
 var cfg *Configuration
@@ -45,9 +89,11 @@ func Get() (*Configuration, error) {
 
 	cfg = &Configuration{
 		BindAddr:                    ":22000",
+		MetricsBindAddr:             ":22001",
 		KafkaAddr:                   []string{"localhost:9092"},
 		AuditEventsTopic:            "audit-events",
 		GenerateDownloadsTopic:      "filter-job-submitted",
+		DataImportCompleteTopic:     "data-import-complete",
 		CodeListAPIURL:              "http://localhost:22400",
 		DatasetAPIURL:               "http://localhost:22000",
 		WebsiteURL:                  "http://localhost:20000",
@@ -57,17 +103,204 @@ func Get() (*Configuration, error) {
 		GracefulShutdownTimeout:     5 * time.Second,
 		HealthCheckInterval:         30 * time.Second,
 		HealthCheckRecoveryInterval: 10 * time.Second,
+		HTTPReadTimeout:             5 * time.Second,
+		HTTPWriteTimeout:            10 * time.Second,
+		HTTPIdleTimeout:             120 * time.Second,
+		HTTPMaxHeaderBytes:          1 << 20,
+		RequestTimeout:              8 * time.Second,
 		EnablePrivateEnpoints:       false,
 		EnableDetachDataset:         false,
 		EnablePermissionsAuth:       false,
+		EnableObservationsEndpoint:  true,
+		EnableAccessLog:             true,
+		PrettyJSONResponses:         false,
+		DefaultPageSize:             20,
+		MaxPageSize:                 1000,
+		NormaliseDimensionNames:     true,
+		DatasetTreeMaxVersions:      1000,
+		MaxEditionsPerDataset:       1000,
+		MaxVersionsPerEdition:       10000,
+		DatasetExportMaxVersions:    1000,
+		DatasetFieldMaxLength:       256,
+		DatasetMaxSubtopics:         20,
+		InternalNetworks:            []string{},
+		CORSAllowedOrigins:          []string{},
+		DownloadFormats:             []string{models.DownloadFormatCSV, models.DownloadFormatCSVW, models.DownloadFormatXLS},
+		JSONMaxDepth:                models.DefaultJSONLimits.MaxDepth,
+		JSONMaxBodySize:             models.DefaultJSONLimits.MaxSize,
+		WildcardDimensions:          []string{},
+		DimensionOptionsMaxCodes:    1000,
+		MaxObservationStreams:       20,
+		PublishDenylist:             []string{},
+		MaintenanceMode:             false,
+		RedactPublicContacts:        false,
+		RejectPastReleaseDates:      false,
+		PublishWebhookURLs:          []string{},
+		PublishWebhookTimeout:       5 * time.Second,
+		PublishWebhookMaxRetries:    3,
+		DownloadURLSigningKey:       "",
+		DownloadURLExpiry:           15 * time.Minute,
+		InstanceProgressInterval:    time.Second,
 		MongoConfig: MongoConfig{
-			BindAddr:   "localhost:27017",
-			Collection: "datasets",
-			Database:   "datasets",
+			BindAddr:          "localhost:27017",
+			Collection:        "datasets",
+			Database:          "datasets",
+			SecondaryReads:    false,
+			WriteMaxAttempts:  3,
+			WriteRetryBackoff: 100 * time.Millisecond,
 		},
 	}
 
-	return cfg, envconfig.Process("", cfg)
+	if err := envconfig.Process("", cfg); err != nil {
+		return cfg, err
+	}
+
+	return cfg, cfg.validate()
+}
+
+// validate checks the configured values are consistent with each other
+func (config Configuration) validate() error {
+	if config.DefaultPageSize < 1 {
+		return fmt.Errorf("DEFAULT_PAGE_SIZE must be at least 1, got %d", config.DefaultPageSize)
+	}
+
+	if config.MaxPageSize < config.DefaultPageSize {
+		return fmt.Errorf("MAX_PAGE_SIZE (%d) must not be less than DEFAULT_PAGE_SIZE (%d)", config.MaxPageSize, config.DefaultPageSize)
+	}
+
+	if config.HTTPReadTimeout <= 0 {
+		return fmt.Errorf("HTTP_READ_TIMEOUT must be greater than 0, got %s", config.HTTPReadTimeout)
+	}
+
+	if config.HTTPWriteTimeout <= 0 {
+		return fmt.Errorf("HTTP_WRITE_TIMEOUT must be greater than 0, got %s", config.HTTPWriteTimeout)
+	}
+
+	if config.HTTPIdleTimeout <= 0 {
+		return fmt.Errorf("HTTP_IDLE_TIMEOUT must be greater than 0, got %s", config.HTTPIdleTimeout)
+	}
+
+	if config.RequestTimeout <= 0 || config.RequestTimeout >= config.HTTPWriteTimeout {
+		return fmt.Errorf("REQUEST_TIMEOUT (%s) must be greater than 0 and less than HTTP_WRITE_TIMEOUT (%s)", config.RequestTimeout, config.HTTPWriteTimeout)
+	}
+
+	if config.HTTPMaxHeaderBytes < 1 {
+		return fmt.Errorf("HTTP_MAX_HEADER_BYTES must be at least 1, got %d", config.HTTPMaxHeaderBytes)
+	}
+
+	if config.DatasetTreeMaxVersions < 1 {
+		return fmt.Errorf("DATASET_TREE_MAX_VERSIONS must be at least 1, got %d", config.DatasetTreeMaxVersions)
+	}
+
+	if config.MaxEditionsPerDataset < 1 {
+		return fmt.Errorf("MAX_EDITIONS_PER_DATASET must be at least 1, got %d", config.MaxEditionsPerDataset)
+	}
+
+	if config.MaxVersionsPerEdition < 1 {
+		return fmt.Errorf("MAX_VERSIONS_PER_EDITION must be at least 1, got %d", config.MaxVersionsPerEdition)
+	}
+
+	if config.DatasetExportMaxVersions < 1 {
+		return fmt.Errorf("DATASET_EXPORT_MAX_VERSIONS must be at least 1, got %d", config.DatasetExportMaxVersions)
+	}
+
+	if config.DatasetFieldMaxLength < 1 {
+		return fmt.Errorf("DATASET_FIELD_MAX_LENGTH must be at least 1, got %d", config.DatasetFieldMaxLength)
+	}
+
+	if config.DatasetMaxSubtopics < 1 {
+		return fmt.Errorf("DATASET_MAX_SUBTOPICS must be at least 1, got %d", config.DatasetMaxSubtopics)
+	}
+
+	if len(config.DownloadFormats) == 0 {
+		return fmt.Errorf("DOWNLOAD_FORMATS must contain at least one format")
+	}
+
+	if invalid := models.InvalidDownloadFormats(config.DownloadFormats); invalid != nil {
+		return fmt.Errorf("DOWNLOAD_FORMATS contains unknown formats %v", invalid)
+	}
+
+	if config.JSONMaxDepth < 1 {
+		return fmt.Errorf("JSON_MAX_DEPTH must be at least 1, got %d", config.JSONMaxDepth)
+	}
+
+	if config.JSONMaxBodySize < 1 {
+		return fmt.Errorf("JSON_MAX_BODY_SIZE must be at least 1, got %d", config.JSONMaxBodySize)
+	}
+
+	if config.DimensionOptionsMaxCodes < 1 {
+		return fmt.Errorf("DIMENSION_OPTIONS_MAX_CODES must be at least 1, got %d", config.DimensionOptionsMaxCodes)
+	}
+
+	if config.MaxObservationStreams < 1 {
+		return fmt.Errorf("MAX_OBSERVATION_STREAMS must be at least 1, got %d", config.MaxObservationStreams)
+	}
+
+	if config.PublishWebhookTimeout <= 0 {
+		return fmt.Errorf("PUBLISH_WEBHOOK_TIMEOUT must be greater than 0, got %s", config.PublishWebhookTimeout)
+	}
+
+	if config.PublishWebhookMaxRetries < 1 {
+		return fmt.Errorf("PUBLISH_WEBHOOK_MAX_RETRIES must be at least 1, got %d", config.PublishWebhookMaxRetries)
+	}
+
+	if config.DownloadURLExpiry <= 0 {
+		return fmt.Errorf("DOWNLOAD_URL_EXPIRY must be greater than 0, got %s", config.DownloadURLExpiry)
+	}
+
+	if config.InstanceProgressInterval <= 0 {
+		return fmt.Errorf("INSTANCE_PROGRESS_INTERVAL must be greater than 0, got %s", config.InstanceProgressInterval)
+	}
+
+	if config.MongoConfig.WriteMaxAttempts < 1 {
+		return fmt.Errorf("MONGODB_WRITE_MAX_ATTEMPTS must be at least 1, got %d", config.MongoConfig.WriteMaxAttempts)
+	}
+
+	if _, err := config.ParseInternalNetworks(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ParseInternalNetworks returns the CIDR ranges requests from which are treated as internal service-to-service calls
+func (config Configuration) ParseInternalNetworks() ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(config.InternalNetworks))
+	for _, cidr := range config.InternalNetworks {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("INTERNAL_NETWORKS contains an invalid CIDR range %q", cidr)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// FeatureFlags returns the state of each boolean setting, keyed by the environment variable it is read from.
+// Settings omitted from the JSON form of the config are sensitive and so are left out.
+func (config Configuration) FeatureFlags() map[string]bool {
+	flags := make(map[string]bool)
+	addFeatureFlags(reflect.ValueOf(config), flags)
+	return flags
+}
+
+func addFeatureFlags(v reflect.Value, flags map[string]bool) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		switch v.Field(i).Kind() {
+		case reflect.Bool:
+			if name := field.Tag.Get("envconfig"); name != "" {
+				flags[name] = v.Field(i).Bool()
+			}
+		case reflect.Struct:
+			addFeatureFlags(v.Field(i), flags)
+		}
+	}
 }
 
 // String is implemented to prevent sensitive fields being logged.
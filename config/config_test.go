@@ -1,6 +1,7 @@
 package config
 
 import (
+	"os"
 	"testing"
 	"time"
 
@@ -19,6 +20,7 @@ func TestSpec(t *testing.T) {
 
 			Convey("The values should be set to the expected defaults", func() {
 				So(cfg.BindAddr, ShouldEqual, ":22000")
+				So(cfg.MetricsBindAddr, ShouldEqual, ":22001")
 				So(cfg.KafkaAddr, ShouldResemble, []string{"localhost:9092"})
 				So(cfg.AuditEventsTopic, ShouldEqual, "audit-events")
 				So(cfg.GenerateDownloadsTopic, ShouldEqual, "filter-job-submitted")
@@ -33,9 +35,290 @@ func TestSpec(t *testing.T) {
 				So(cfg.MongoConfig.BindAddr, ShouldEqual, "localhost:27017")
 				So(cfg.MongoConfig.Collection, ShouldEqual, "datasets")
 				So(cfg.MongoConfig.Database, ShouldEqual, "datasets")
+				So(cfg.MongoConfig.SecondaryReads, ShouldBeFalse)
+				So(cfg.MongoConfig.WriteMaxAttempts, ShouldEqual, 3)
+				So(cfg.MongoConfig.WriteRetryBackoff, ShouldEqual, 100*time.Millisecond)
 				So(cfg.EnablePermissionsAuth, ShouldBeFalse)
+				So(cfg.EnableObservationsEndpoint, ShouldBeTrue)
+				So(cfg.EnableAccessLog, ShouldBeTrue)
+				So(cfg.PrettyJSONResponses, ShouldBeFalse)
 				So(cfg.HealthCheckRecoveryInterval, ShouldEqual, time.Second*10)
 				So(cfg.HealthCheckInterval, ShouldEqual, time.Second*30)
+				So(cfg.HTTPReadTimeout, ShouldEqual, 5*time.Second)
+				So(cfg.HTTPWriteTimeout, ShouldEqual, 10*time.Second)
+				So(cfg.HTTPIdleTimeout, ShouldEqual, 120*time.Second)
+				So(cfg.HTTPMaxHeaderBytes, ShouldEqual, 1048576)
+				So(cfg.RequestTimeout, ShouldEqual, 8*time.Second)
+				So(cfg.DefaultPageSize, ShouldEqual, 20)
+				So(cfg.MaxPageSize, ShouldEqual, 1000)
+				So(cfg.NormaliseDimensionNames, ShouldBeTrue)
+				So(cfg.DatasetTreeMaxVersions, ShouldEqual, 1000)
+				So(cfg.MaxEditionsPerDataset, ShouldEqual, 1000)
+				So(cfg.MaxVersionsPerEdition, ShouldEqual, 10000)
+				So(cfg.DatasetExportMaxVersions, ShouldEqual, 1000)
+				So(cfg.DatasetFieldMaxLength, ShouldEqual, 256)
+				So(cfg.DatasetMaxSubtopics, ShouldEqual, 20)
+				So(cfg.InternalNetworks, ShouldBeEmpty)
+				So(cfg.CORSAllowedOrigins, ShouldBeEmpty)
+				So(cfg.DownloadFormats, ShouldResemble, []string{"csv", "csvw", "xls"})
+				So(cfg.WildcardDimensions, ShouldBeEmpty)
+				So(cfg.DimensionOptionsMaxCodes, ShouldEqual, 1000)
+				So(cfg.MaxObservationStreams, ShouldEqual, 20)
+				So(cfg.PublishDenylist, ShouldBeEmpty)
+				So(cfg.MaintenanceMode, ShouldBeFalse)
+				So(cfg.RedactPublicContacts, ShouldBeFalse)
+				So(cfg.RejectPastReleaseDates, ShouldBeFalse)
+				So(cfg.PublishWebhookURLs, ShouldBeEmpty)
+				So(cfg.PublishWebhookTimeout, ShouldEqual, 5*time.Second)
+				So(cfg.PublishWebhookMaxRetries, ShouldEqual, 3)
+				So(cfg.DownloadURLSigningKey, ShouldBeEmpty)
+				So(cfg.DownloadURLExpiry, ShouldEqual, 15*time.Minute)
+				So(cfg.InstanceProgressInterval, ShouldEqual, time.Second)
+				So(cfg.DataImportCompleteTopic, ShouldEqual, "data-import-complete")
+				So(cfg.JSONMaxDepth, ShouldEqual, 32)
+				So(cfg.JSONMaxBodySize, ShouldEqual, 10485760)
+			})
+		})
+	})
+}
+
+func TestGetInvalidPageSize(t *testing.T) {
+	Convey("Given an environment where the maximum page size is less than the default page size", t, func() {
+		os.Setenv("DEFAULT_PAGE_SIZE", "50")
+		os.Setenv("MAX_PAGE_SIZE", "10")
+		cfg = nil
+
+		defer func() {
+			os.Unsetenv("DEFAULT_PAGE_SIZE")
+			os.Unsetenv("MAX_PAGE_SIZE")
+			cfg = nil
+		}()
+
+		Convey("When the config values are retrieved", func() {
+			_, err := Get()
+
+			Convey("Then an error should be returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "MAX_PAGE_SIZE (10) must not be less than DEFAULT_PAGE_SIZE (50)")
+			})
+		})
+	})
+}
+
+func TestGetInvalidDatasetTreeMaxVersions(t *testing.T) {
+	Convey("Given an environment where the dataset tree version cap is zero", t, func() {
+		os.Setenv("DATASET_TREE_MAX_VERSIONS", "0")
+		cfg = nil
+
+		defer func() {
+			os.Unsetenv("DATASET_TREE_MAX_VERSIONS")
+			cfg = nil
+		}()
+
+		Convey("When the config values are retrieved", func() {
+			_, err := Get()
+
+			Convey("Then an error should be returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "DATASET_TREE_MAX_VERSIONS must be at least 1, got 0")
+			})
+		})
+	})
+}
+
+func TestGetInvalidHTTPWriteTimeout(t *testing.T) {
+	Convey("Given an environment where the server write timeout is disabled", t, func() {
+		os.Setenv("HTTP_WRITE_TIMEOUT", "0s")
+		cfg = nil
+
+		defer func() {
+			os.Unsetenv("HTTP_WRITE_TIMEOUT")
+			cfg = nil
+		}()
+
+		Convey("When the config values are retrieved", func() {
+			_, err := Get()
+
+			Convey("Then an error should be returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "HTTP_WRITE_TIMEOUT must be greater than 0, got 0s")
+			})
+		})
+	})
+}
+
+func TestGetInvalidRequestTimeout(t *testing.T) {
+	Convey("Given an environment where requests time out after the server write timeout", t, func() {
+		os.Setenv("REQUEST_TIMEOUT", "20s")
+		cfg = nil
+
+		defer func() {
+			os.Unsetenv("REQUEST_TIMEOUT")
+			cfg = nil
+		}()
+
+		Convey("When the config values are retrieved", func() {
+			_, err := Get()
+
+			Convey("Then an error should be returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "REQUEST_TIMEOUT (20s) must be greater than 0 and less than HTTP_WRITE_TIMEOUT (10s)")
+			})
+		})
+	})
+}
+
+func TestGetInvalidMongoWriteMaxAttempts(t *testing.T) {
+	Convey("Given an environment where mongo writes are given no attempts", t, func() {
+		os.Setenv("MONGODB_WRITE_MAX_ATTEMPTS", "0")
+		cfg = nil
+
+		defer func() {
+			os.Unsetenv("MONGODB_WRITE_MAX_ATTEMPTS")
+			cfg = nil
+		}()
+
+		Convey("When the config values are retrieved", func() {
+			_, err := Get()
+
+			Convey("Then an error should be returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "MONGODB_WRITE_MAX_ATTEMPTS must be at least 1, got 0")
+			})
+		})
+	})
+}
+
+func TestGetInternalNetworks(t *testing.T) {
+	Convey("Given an environment with internal network ranges", t, func() {
+		os.Setenv("INTERNAL_NETWORKS", "10.0.0.0/8,192.168.1.0/24")
+		cfg = nil
+
+		defer func() {
+			os.Unsetenv("INTERNAL_NETWORKS")
+			cfg = nil
+		}()
+
+		Convey("When the config values are retrieved", func() {
+			cfg, err := Get()
+			So(err, ShouldBeNil)
+
+			Convey("Then each range is parsed", func() {
+				networks, err := cfg.ParseInternalNetworks()
+				So(err, ShouldBeNil)
+				So(networks, ShouldHaveLength, 2)
+				So(networks[0].String(), ShouldEqual, "10.0.0.0/8")
+				So(networks[1].String(), ShouldEqual, "192.168.1.0/24")
+			})
+		})
+	})
+
+	Convey("Given an environment with an invalid internal network range", t, func() {
+		os.Setenv("INTERNAL_NETWORKS", "10.0.0.0/8,10.0.0.300/32")
+		cfg = nil
+
+		defer func() {
+			os.Unsetenv("INTERNAL_NETWORKS")
+			cfg = nil
+		}()
+
+		Convey("When the config values are retrieved", func() {
+			_, err := Get()
+
+			Convey("Then an error should be returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, `INTERNAL_NETWORKS contains an invalid CIDR range "10.0.0.300/32"`)
+			})
+		})
+	})
+}
+
+func TestFeatureFlags(t *testing.T) {
+	Convey("Given a configuration with boolean settings", t, func() {
+		config := Configuration{
+			EnablePrivateEnpoints:  true,
+			MaintenanceMode:        true,
+			RejectPastReleaseDates: false,
+			MongoConfig:            MongoConfig{SecondaryReads: true},
+		}
+
+		Convey("When the feature flags are read", func() {
+			flags := config.FeatureFlags()
+
+			Convey("Then each boolean setting is keyed by its environment variable", func() {
+				So(flags["ENABLE_PRIVATE_ENDPOINTS"], ShouldBeTrue)
+				So(flags["MAINTENANCE_MODE"], ShouldBeTrue)
+				So(flags["MONGODB_SECONDARY_READS"], ShouldBeTrue)
+				So(flags, ShouldContainKey, "REJECT_PAST_RELEASE_DATES")
+				So(flags["REJECT_PAST_RELEASE_DATES"], ShouldBeFalse)
+			})
+
+			Convey("Then settings which are not boolean are left out", func() {
+				So(flags, ShouldNotContainKey, "BIND_ADDR")
+				So(flags, ShouldNotContainKey, "SERVICE_AUTH_TOKEN")
+			})
+		})
+	})
+}
+
+func TestGetInvalidDownloadFormats(t *testing.T) {
+	Convey("Given an environment with an unknown download format", t, func() {
+		os.Setenv("DOWNLOAD_FORMATS", "csv,xlsx")
+		cfg = nil
+
+		defer func() {
+			os.Unsetenv("DOWNLOAD_FORMATS")
+			cfg = nil
+		}()
+
+		Convey("When the config values are retrieved", func() {
+			_, err := Get()
+
+			Convey("Then an error should be returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "DOWNLOAD_FORMATS contains unknown formats [xlsx]")
+			})
+		})
+	})
+}
+
+func TestGetInvalidPublishWebhookMaxRetries(t *testing.T) {
+	Convey("Given an environment where publish webhooks are never retried", t, func() {
+		os.Setenv("PUBLISH_WEBHOOK_MAX_RETRIES", "0")
+		cfg = nil
+
+		defer func() {
+			os.Unsetenv("PUBLISH_WEBHOOK_MAX_RETRIES")
+			cfg = nil
+		}()
+
+		Convey("When the config values are retrieved", func() {
+			_, err := Get()
+
+			Convey("Then an error should be returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "PUBLISH_WEBHOOK_MAX_RETRIES must be at least 1, got 0")
+			})
+		})
+	})
+}
+
+func TestGetInvalidInstanceProgressInterval(t *testing.T) {
+	Convey("Given an environment where instance progress is never re-read", t, func() {
+		os.Setenv("INSTANCE_PROGRESS_INTERVAL", "0s")
+		cfg = nil
+
+		defer func() {
+			os.Unsetenv("INSTANCE_PROGRESS_INTERVAL")
+			cfg = nil
+		}()
+
+		Convey("When the config values are retrieved", func() {
+			_, err := Get()
+
+			Convey("Then an error should be returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "INSTANCE_PROGRESS_INTERVAL must be greater than 0, got 0s")
 			})
 		})
 	})
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
 	"github.com/ONSdigital/dp-dataset-api/models"
 	"github.com/ONSdigital/dp-dataset-api/store"
 	"github.com/ONSdigital/go-ns/audit"
@@ -18,16 +19,34 @@ import (
 
 // Store provides a backend for dimensions
 type Store struct {
-	Auditor audit.AuditorService
+	Auditor        audit.AuditorService
+	NormaliseNames bool
+	MaxOptionCodes int
+	JSONLimits     models.JSONLimits
 	store.Storer
 }
 
+// nopAuditor stands in for an auditor which has not been set, so actions go unaudited rather than the handler
+// panicking
+var nopAuditor audit.AuditorService = &audit.NopAuditor{}
+
+// auditor returns the auditor of the store, or one which records nothing when none has been set
+func (s *Store) auditor() audit.AuditorService {
+	if s.Auditor == nil {
+		return nopAuditor
+	}
+	return s.Auditor
+}
+
 // List of audit actions for dimensions
 const (
 	GetDimensions                      = "getInstanceDimensions"
 	GetUniqueDimensionAndOptionsAction = "getInstanceUniqueDimensionAndOptions"
+	GetDimensionOptionsByCodesAction   = "getInstanceDimensionOptionsByCodes"
 	AddDimensionAction                 = "addDimension"
 	UpdateNodeIDAction                 = "updateDimensionOptionWithNodeID"
+	UpdateOptionAction                 = "updateDimensionOption"
+	RenameDimensionAction              = "renameDimension"
 )
 
 func dimensionError(err error, message, action string) error {
@@ -42,9 +61,11 @@ func (s *Store) GetDimensionsHandler(w http.ResponseWriter, r *http.Request) {
 	auditParams := common.Params{"instance_id": instanceID}
 	logData := audit.ToLogData(auditParams)
 
-	b, err := s.getDimensions(ctx, instanceID, logData)
+	withCounts := r.URL.Query().Get("with_counts") == "true"
+
+	b, err := s.getDimensions(ctx, instanceID, withCounts, logData)
 	if err != nil {
-		if auditErr := s.Auditor.Record(ctx, GetDimensions, audit.Unsuccessful, auditParams); auditErr != nil {
+		if auditErr := s.auditor().Record(ctx, GetDimensions, audit.Unsuccessful, auditParams); auditErr != nil {
 			err = auditErr
 		}
 
@@ -52,7 +73,7 @@ func (s *Store) GetDimensionsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if auditErr := s.Auditor.Record(ctx, GetDimensions, audit.Successful, auditParams); auditErr != nil {
+	if auditErr := s.auditor().Record(ctx, GetDimensions, audit.Successful, auditParams); auditErr != nil {
 		handleDimensionErr(ctx, w, auditErr, logData)
 		return
 	}
@@ -61,7 +82,7 @@ func (s *Store) GetDimensionsHandler(w http.ResponseWriter, r *http.Request) {
 	log.InfoCtx(ctx, fmt.Sprintf("%v endpoint: successfully get dimensions for an instance resource", GetDimensions), logData)
 }
 
-func (s *Store) getDimensions(ctx context.Context, instanceID string, logData log.Data) ([]byte, error) {
+func (s *Store) getDimensions(ctx context.Context, instanceID string, withCounts bool, logData log.Data) ([]byte, error) {
 	instance, err := s.GetInstance(instanceID)
 	if err != nil {
 		log.ErrorCtx(ctx, dimensionError(err, "failed to get instance", GetDimensions), logData)
@@ -81,6 +102,16 @@ func (s *Store) getDimensions(ctx context.Context, instanceID string, logData lo
 		return nil, err
 	}
 
+	if withCounts {
+		if results.OptionCounts, err = s.GetDimensionOptionCounts(instanceID); err != nil {
+			log.ErrorCtx(ctx, dimensionError(err, "failed to count dimension options for instance", GetDimensions), logData)
+			return nil, err
+		}
+	}
+
+	// the dimensions are returned in the order of the columns of the headers, so observations can be aligned to them
+	results.SortByDimensionOrder(instance.DimensionOrder)
+
 	b, err := json.Marshal(results)
 	if err != nil {
 		log.ErrorCtx(ctx, dimensionError(err, "failed to marshal dimension nodes to json", GetDimensions), logData)
@@ -101,7 +132,7 @@ func (s *Store) GetUniqueDimensionAndOptionsHandler(w http.ResponseWriter, r *ht
 
 	b, err := s.getUniqueDimensionAndOptions(ctx, instanceID, dimension, logData)
 	if err != nil {
-		if auditErr := s.Auditor.Record(ctx, GetUniqueDimensionAndOptionsAction, audit.Unsuccessful, auditParams); auditErr != nil {
+		if auditErr := s.auditor().Record(ctx, GetUniqueDimensionAndOptionsAction, audit.Unsuccessful, auditParams); auditErr != nil {
 			err = auditErr
 		}
 
@@ -109,7 +140,7 @@ func (s *Store) GetUniqueDimensionAndOptionsHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
-	if auditErr := s.Auditor.Record(ctx, GetUniqueDimensionAndOptionsAction, audit.Successful, auditParams); auditErr != nil {
+	if auditErr := s.auditor().Record(ctx, GetUniqueDimensionAndOptionsAction, audit.Successful, auditParams); auditErr != nil {
 		handleDimensionErr(ctx, w, auditErr, logData)
 		return
 	}
@@ -147,6 +178,75 @@ func (s *Store) getUniqueDimensionAndOptions(ctx context.Context, instanceID, di
 	return b, nil
 }
 
+// GetOptionsByCodesHandler returns the options of a dimension of an instance with the codes given in the request body
+func (s *Store) GetOptionsByCodesHandler(w http.ResponseWriter, r *http.Request) {
+
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	instanceID := vars["instance_id"]
+	dimension := vars["dimension"]
+	auditParams := common.Params{"instance_id": instanceID, "dimension": dimension}
+	logData := audit.ToLogData(auditParams)
+
+	b, err := func() ([]byte, error) {
+		codes, err := unmarshalDimensionOptionCodes(r.Body, s.MaxOptionCodes, s.JSONLimits)
+		if err != nil {
+			log.ErrorCtx(ctx, dimensionError(err, "failed to unmarshal dimension option codes", GetDimensionOptionsByCodesAction), logData)
+			return nil, err
+		}
+		logData["codes"] = len(codes)
+
+		return s.getOptionsByCodes(ctx, instanceID, dimension, codes, logData)
+	}()
+	if err != nil {
+		if auditErr := s.auditor().Record(ctx, GetDimensionOptionsByCodesAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+
+		handleDimensionErr(ctx, w, err, logData)
+		return
+	}
+
+	if auditErr := s.auditor().Record(ctx, GetDimensionOptionsByCodesAction, audit.Successful, auditParams); auditErr != nil {
+		handleDimensionErr(ctx, w, auditErr, logData)
+		return
+	}
+
+	writeBody(ctx, w, b, GetDimensionOptionsByCodesAction, logData)
+	log.InfoCtx(ctx, fmt.Sprintf("%v endpoint: successfully get dimension options by code for an instance resource", GetDimensionOptionsByCodesAction), logData)
+}
+
+func (s *Store) getOptionsByCodes(ctx context.Context, instanceID, dimension string, codes []string, logData log.Data) ([]byte, error) {
+	instance, err := s.GetInstance(instanceID)
+	if err != nil {
+		log.ErrorCtx(ctx, dimensionError(err, "failed to get instance", GetDimensionOptionsByCodesAction), logData)
+		return nil, err
+	}
+
+	// Early return if instance state is invalid
+	if err = models.CheckState("instance", instance.State); err != nil {
+		logData["state"] = instance.State
+		log.ErrorCtx(ctx, dimensionError(err, "current instance has an invalid state", GetDimensionOptionsByCodesAction), logData)
+		return nil, err
+	}
+
+	options, err := s.GetDimensionOptionsByCodes(instanceID, dimension, codes)
+	if err != nil {
+		log.ErrorCtx(ctx, dimensionError(err, "failed to get dimension options by code for instance", GetDimensionOptionsByCodesAction), logData)
+		return nil, err
+	}
+
+	b, err := json.Marshal(options)
+	if err != nil {
+		log.ErrorCtx(ctx, dimensionError(err, "failed to marshal dimension options to json", GetDimensionOptionsByCodesAction), logData)
+		return nil, err
+	}
+
+	return b, nil
+}
+
 // AddHandler represents adding a dimension to a specific instance
 func (s *Store) AddHandler(w http.ResponseWriter, r *http.Request) {
 
@@ -159,11 +259,11 @@ func (s *Store) AddHandler(w http.ResponseWriter, r *http.Request) {
 	auditParams := common.Params{"instance_id": instanceID}
 	logData := audit.ToLogData(auditParams)
 
-	option, err := unmarshalDimensionCache(r.Body)
+	option, err := unmarshalDimensionCache(r.Body, s.JSONLimits)
 	if err != nil {
 		log.ErrorCtx(ctx, dimensionError(err, "failed to unmarshal dimension cache", AddDimensionAction), logData)
 
-		if auditErr := s.Auditor.Record(ctx, AddDimensionAction, audit.Unsuccessful, auditParams); auditErr != nil {
+		if auditErr := s.auditor().Record(ctx, AddDimensionAction, audit.Unsuccessful, auditParams); auditErr != nil {
 			err = auditErr
 		}
 
@@ -171,8 +271,10 @@ func (s *Store) AddHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.add(ctx, instanceID, option, logData); err != nil {
-		if auditErr := s.Auditor.Record(ctx, AddDimensionAction, audit.Unsuccessful, auditParams); auditErr != nil {
+	overwrite := r.URL.Query().Get("overwrite") == "true"
+
+	if err := s.add(ctx, instanceID, option, overwrite, logData); err != nil {
+		if auditErr := s.auditor().Record(ctx, AddDimensionAction, audit.Unsuccessful, auditParams); auditErr != nil {
 			err = auditErr
 		}
 
@@ -180,12 +282,12 @@ func (s *Store) AddHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.Auditor.Record(ctx, AddDimensionAction, audit.Successful, auditParams)
+	s.auditor().Record(ctx, AddDimensionAction, audit.Successful, auditParams)
 
 	log.InfoCtx(ctx, "added dimension to instance resource", logData)
 }
 
-func (s *Store) add(ctx context.Context, instanceID string, option *models.CachedDimensionOption, logData log.Data) error {
+func (s *Store) add(ctx context.Context, instanceID string, option *models.CachedDimensionOption, overwrite bool, logData log.Data) error {
 	// Get instance
 	instance, err := s.GetInstance(instanceID)
 	if err != nil {
@@ -200,6 +302,41 @@ func (s *Store) add(ctx context.Context, instanceID string, option *models.Cache
 		return err
 	}
 
+	if s.NormaliseNames {
+		option.Name = models.NormaliseDimensionName(option.Name)
+	}
+
+	if err = models.ValidateDimensionName(option.Name); err != nil {
+		logData["dimension"] = option.Name
+		log.ErrorCtx(ctx, dimensionError(err, "dimension name is invalid", AddDimensionAction), logData)
+		return err
+	}
+
+	// options of one dimension share its name, so a dimension of the same name already exists on the instance if
+	// its options come from a different code list
+	codeList, err := s.GetDimensionCodeList(instanceID, option.Name)
+	if err != nil && err != errs.ErrDimensionNotFound {
+		log.ErrorCtx(ctx, dimensionError(err, "failed to get code list of existing dimension", AddDimensionAction), logData)
+		return err
+	}
+
+	if err == nil && codeList != option.CodeList {
+		logData["dimension"] = option.Name
+		logData["existing_code_list"] = codeList
+		logData["code_list"] = option.CodeList
+
+		if !overwrite {
+			log.ErrorCtx(ctx, dimensionError(errs.ErrDimensionAlreadyExists, "dimension already exists on instance", AddDimensionAction), logData)
+			return errs.ErrDimensionAlreadyExists
+		}
+
+		log.InfoCtx(ctx, "addDimension endpoint: overwriting existing dimension on instance", logData)
+		if err = s.DeleteDimensionOptions(instanceID, option.Name); err != nil {
+			log.ErrorCtx(ctx, dimensionError(err, "failed to delete options of existing dimension", AddDimensionAction), logData)
+			return err
+		}
+	}
+
 	option.InstanceID = instanceID
 	if err := s.AddDimensionToInstance(option); err != nil {
 		log.ErrorCtx(ctx, dimensionError(err, "failed to upsert dimension for an instance", AddDimensionAction), logData)
@@ -218,18 +355,24 @@ func (s *Store) AddNodeIDHandler(w http.ResponseWriter, r *http.Request) {
 	dimensionName := vars["dimension"]
 	option := vars["option"]
 	nodeID := vars["node_id"]
+
+	// dimension names are stored in their canonical form, so look up the option the same way
+	if s.NormaliseNames {
+		dimensionName = models.NormaliseDimensionName(dimensionName)
+	}
+
 	auditParams := common.Params{"instance_id": instanceID, "dimension": dimensionName, "option": option, "node_id": nodeID}
 	logData := audit.ToLogData(auditParams)
 
 	dim := models.DimensionOption{Name: dimensionName, Option: option, NodeID: nodeID, InstanceID: instanceID}
 
 	if err := s.addNodeID(ctx, dim, logData); err != nil {
-		s.Auditor.Record(ctx, UpdateNodeIDAction, audit.Unsuccessful, auditParams)
+		s.auditor().Record(ctx, UpdateNodeIDAction, audit.Unsuccessful, auditParams)
 		handleDimensionErr(ctx, w, err, logData)
 		return
 	}
 
-	s.Auditor.Record(ctx, UpdateNodeIDAction, audit.Successful, auditParams)
+	s.auditor().Record(ctx, UpdateNodeIDAction, audit.Successful, auditParams)
 
 	log.InfoCtx(ctx, "added node id to dimension of an instance resource", logData)
 }
@@ -257,10 +400,170 @@ func (s *Store) addNodeID(ctx context.Context, dim models.DimensionOption, logDa
 	return nil
 }
 
+// UpdateOptionHandler merges the label and links given in the request into an existing option of a dimension,
+// leaving the node_id and any fields not given unchanged
+func (s *Store) UpdateOptionHandler(w http.ResponseWriter, r *http.Request) {
+
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	instanceID := vars["instance_id"]
+	dimensionName := vars["dimension"]
+	option := vars["option"]
+
+	// dimension names are stored in their canonical form, so look up the option the same way
+	if s.NormaliseNames {
+		dimensionName = models.NormaliseDimensionName(dimensionName)
+	}
+
+	auditParams := common.Params{"instance_id": instanceID, "dimension": dimensionName, "option": option}
+	logData := audit.ToLogData(auditParams)
+
+	update, err := unmarshalDimensionOptionUpdate(r.Body, s.JSONLimits)
+	if err != nil {
+		log.ErrorCtx(ctx, dimensionError(err, "failed to unmarshal dimension option update", UpdateOptionAction), logData)
+
+		if auditErr := s.auditor().Record(ctx, UpdateOptionAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+
+		handleDimensionErr(ctx, w, err, logData)
+		return
+	}
+
+	if err := s.updateOption(ctx, instanceID, dimensionName, option, update, logData); err != nil {
+		if auditErr := s.auditor().Record(ctx, UpdateOptionAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+
+		handleDimensionErr(ctx, w, err, logData)
+		return
+	}
+
+	s.auditor().Record(ctx, UpdateOptionAction, audit.Successful, auditParams)
+
+	log.InfoCtx(ctx, "updated option of a dimension of an instance resource", logData)
+}
+
+func (s *Store) updateOption(ctx context.Context, instanceID, dimensionName, option string, update *models.DimensionOptionUpdate, logData log.Data) error {
+	// Get instance
+	instance, err := s.GetInstance(instanceID)
+	if err != nil {
+		log.ErrorCtx(ctx, dimensionError(err, "failed to get instance", UpdateOptionAction), logData)
+		return err
+	}
+
+	// Early return if instance state is invalid
+	if err = models.CheckState("instance", instance.State); err != nil {
+		logData["state"] = instance.State
+		log.ErrorCtx(ctx, dimensionError(err, "current instance has an invalid state", UpdateOptionAction), logData)
+		return err
+	}
+
+	if err = s.UpdateDimensionOption(instanceID, dimensionName, option, update); err != nil {
+		log.ErrorCtx(ctx, dimensionError(err, "failed to update option of a dimension of that instance", UpdateOptionAction), logData)
+		return err
+	}
+
+	return nil
+}
+
+// RenameHandler renames a dimension of a specific instance along with each of its options
+func (s *Store) RenameHandler(w http.ResponseWriter, r *http.Request) {
+
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	instanceID := vars["instance_id"]
+	oldName := vars["dimension"]
+	auditParams := common.Params{"instance_id": instanceID, "old_name": oldName}
+	logData := audit.ToLogData(auditParams)
+
+	newName, err := unmarshalDimensionName(r.Body, s.JSONLimits)
+	if err != nil {
+		log.ErrorCtx(ctx, dimensionError(err, "failed to unmarshal dimension name", RenameDimensionAction), logData)
+
+		if auditErr := s.auditor().Record(ctx, RenameDimensionAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+
+		handleDimensionErr(ctx, w, err, logData)
+		return
+	}
+
+	if s.NormaliseNames {
+		newName = models.NormaliseDimensionName(newName)
+	}
+	auditParams["new_name"] = newName
+	logData["new_name"] = newName
+
+	if err := s.rename(ctx, instanceID, oldName, newName, logData); err != nil {
+		if auditErr := s.auditor().Record(ctx, RenameDimensionAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+
+		handleDimensionErr(ctx, w, err, logData)
+		return
+	}
+
+	s.auditor().Record(ctx, RenameDimensionAction, audit.Successful, auditParams)
+
+	log.InfoCtx(ctx, "renamed dimension of an instance resource", logData)
+}
+
+func (s *Store) rename(ctx context.Context, instanceID, oldName, newName string, logData log.Data) error {
+	// Get instance
+	instance, err := s.GetInstance(instanceID)
+	if err != nil {
+		log.ErrorCtx(ctx, dimensionError(err, "failed to get instance", RenameDimensionAction), logData)
+		return err
+	}
+
+	// Early return if instance state is invalid
+	if err = models.CheckState("instance", instance.State); err != nil {
+		logData["state"] = instance.State
+		log.ErrorCtx(ctx, dimensionError(err, "current instance has an invalid state", RenameDimensionAction), logData)
+		return err
+	}
+
+	if !hasDimension(instance, oldName) {
+		log.ErrorCtx(ctx, dimensionError(errs.ErrDimensionNotFound, "dimension not found on instance", RenameDimensionAction), logData)
+		return errs.ErrDimensionNotFound
+	}
+
+	if err = models.ValidateDimensionName(newName); err != nil {
+		log.ErrorCtx(ctx, dimensionError(err, "dimension name is invalid", RenameDimensionAction), logData)
+		return err
+	}
+
+	if hasDimension(instance, newName) {
+		log.ErrorCtx(ctx, dimensionError(errs.ErrDimensionNameExists, "dimension name already used on instance", RenameDimensionAction), logData)
+		return errs.ErrDimensionNameExists
+	}
+
+	if err = s.RenameDimension(instanceID, oldName, newName); err != nil {
+		log.ErrorCtx(ctx, dimensionError(err, "failed to rename dimension of an instance", RenameDimensionAction), logData)
+		return err
+	}
+
+	return nil
+}
+
+func hasDimension(instance *models.Instance, name string) bool {
+	for _, dimension := range instance.Dimensions {
+		if dimension.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func writeBody(ctx context.Context, w http.ResponseWriter, b []byte, action string, data log.Data) {
 	w.Header().Set("Content-Type", "application/json")
 	if _, err := w.Write(b); err != nil {
 		log.ErrorCtx(ctx, dimensionError(err, "failed to write response body", action), data)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		errs.WriteError(w, err, http.StatusInternalServerError)
 	}
 }
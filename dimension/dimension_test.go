@@ -2,6 +2,7 @@ package dimension_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -27,7 +28,7 @@ import (
 )
 
 var (
-	urlBuilder = url.NewBuilder("localhost:20000")
+	urlBuilder = url.NewBuilder("localhost:20000", "http://localhost:22000")
 	mu         sync.Mutex
 )
 
@@ -404,6 +405,9 @@ func TestAddDimensionToInstanceReturnsOk(t *testing.T) {
 			GetInstanceFunc: func(ID string) (*models.Instance, error) {
 				return &models.Instance{State: models.CreatedState}, nil
 			},
+			GetDimensionCodeListFunc: func(instanceID, dimension string) (string, error) {
+				return "", errs.ErrDimensionNotFound
+			},
 			AddDimensionToInstanceFunc: func(event *models.CachedDimensionOption) error {
 				return nil
 			},
@@ -434,6 +438,167 @@ func TestAddDimensionToInstanceReturnsOk(t *testing.T) {
 	})
 }
 
+func TestAddDuplicateDimensionToInstance(t *testing.T) {
+	t.Parallel()
+	Convey("Given an instance with a dimension of the same name taken from a different code list", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CreatedState}, nil
+			},
+			GetDimensionCodeListFunc: func(instanceID, dimension string) (string, error) {
+				return "789-012", nil
+			},
+			DeleteDimensionOptionsFunc: func(instanceID, dimension string) error {
+				return nil
+			},
+			AddDimensionToInstanceFunc: func(event *models.CachedDimensionOption) error {
+				return nil
+			},
+		}
+
+		Convey("When the dimension is added then a conflict is returned", func() {
+			json := strings.NewReader(`{"value":"24", "code_list":"123-456", "dimension": "test"}`)
+			r, err := createRequestWithToken("POST", "http://localhost:22000/instances/123/dimensions", json)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusConflict)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrDimensionAlreadyExists.Error())
+			So(len(mockedDataStore.DeleteDimensionOptionsCalls()), ShouldEqual, 0)
+			So(len(mockedDataStore.AddDimensionToInstanceCalls()), ShouldEqual, 0)
+		})
+
+		Convey("When the dimension is added with overwrite set then the existing options are replaced", func() {
+			json := strings.NewReader(`{"value":"24", "code_list":"123-456", "dimension": "test"}`)
+			r, err := createRequestWithToken("POST", "http://localhost:22000/instances/123/dimensions?overwrite=true", json)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(len(mockedDataStore.DeleteDimensionOptionsCalls()), ShouldEqual, 1)
+			So(mockedDataStore.DeleteDimensionOptionsCalls()[0].Dimension, ShouldEqual, "test")
+			So(len(mockedDataStore.AddDimensionToInstanceCalls()), ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given an instance already holding options of the dimension from the same code list", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CreatedState}, nil
+			},
+			GetDimensionCodeListFunc: func(instanceID, dimension string) (string, error) {
+				return "123-456", nil
+			},
+			AddDimensionToInstanceFunc: func(event *models.CachedDimensionOption) error {
+				return nil
+			},
+		}
+
+		Convey("When another option is added then it is accepted", func() {
+			json := strings.NewReader(`{"value":"25", "code_list":"123-456", "dimension": "test"}`)
+			r, err := createRequestWithToken("POST", "http://localhost:22000/instances/123/dimensions", json)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(len(mockedDataStore.AddDimensionToInstanceCalls()), ShouldEqual, 1)
+		})
+	})
+}
+
+func TestAddDimensionToInstanceNormalisesName(t *testing.T) {
+	t.Parallel()
+	Convey("Given a dimension name with uppercase characters and surrounding whitespace", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CreatedState}, nil
+			},
+			GetDimensionCodeListFunc: func(instanceID, dimension string) (string, error) {
+				return "", errs.ErrDimensionNotFound
+			},
+			AddDimensionToInstanceFunc: func(event *models.CachedDimensionOption) error {
+				return nil
+			},
+		}
+
+		Convey("When the dimension is added with normalisation enabled", func() {
+			json := strings.NewReader(`{"option":"24", "code_list":"123-456", "dimension": " Geography "}`)
+			r, err := createRequestWithToken("POST", "http://localhost:22000/instances/123/dimensions", json)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			auditor := auditortest.New()
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor)
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then the dimension is stored with its lowercase name", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.AddDimensionToInstanceCalls()), ShouldEqual, 1)
+				So(mockedDataStore.AddDimensionToInstanceCalls()[0].Dimension.Name, ShouldEqual, "geography")
+			})
+		})
+
+		Convey("When the dimension is added with normalisation disabled", func() {
+			json := strings.NewReader(`{"option":"24", "code_list":"123-456", "dimension": "Geography"}`)
+			r, err := createRequestWithToken("POST", "http://localhost:22000/instances/123/dimensions", json)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			auditor := auditortest.New()
+			dimensionAPI := &dimension.Store{Auditor: auditor, Storer: mockedDataStore, NormaliseNames: false}
+			router := mux.NewRouter()
+			router.HandleFunc("/instances/{instance_id}/dimensions", dimensionAPI.AddHandler)
+			router.ServeHTTP(w, r)
+
+			Convey("Then the name is rejected and the dimension is not stored", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrDimensionNameInvalid.Error())
+				So(len(mockedDataStore.AddDimensionToInstanceCalls()), ShouldEqual, 0)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{
+						Action: dimension.AddDimensionAction,
+						Result: audit.Unsuccessful,
+						Params: common.Params{"instance_id": "123"},
+					},
+				)
+			})
+		})
+	})
+
+	Convey("Given a dimension name containing whitespace", t, func() {
+		json := strings.NewReader(`{"option":"24", "code_list":"123-456", "dimension": "age group"}`)
+		r, err := createRequestWithToken("POST", "http://localhost:22000/instances/123/dimensions", json)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CreatedState}, nil
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor)
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then the name is rejected even with normalisation enabled", func() {
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrDimensionNameInvalid.Error())
+			So(len(mockedDataStore.AddDimensionToInstanceCalls()), ShouldEqual, 0)
+		})
+	})
+}
+
 func TestAddDimensionToInstanceReturnsNotFound(t *testing.T) {
 	t.Parallel()
 	Convey("Add a dimension to an instance returns not found", t, func() {
@@ -447,6 +612,9 @@ func TestAddDimensionToInstanceReturnsNotFound(t *testing.T) {
 			GetInstanceFunc: func(ID string) (*models.Instance, error) {
 				return &models.Instance{State: models.CreatedState}, nil
 			},
+			GetDimensionCodeListFunc: func(instanceID, dimension string) (string, error) {
+				return "", errs.ErrDimensionNotFound
+			},
 			AddDimensionToInstanceFunc: func(event *models.CachedDimensionOption) error {
 				return errs.ErrDimensionNodeNotFound
 			},
@@ -491,6 +659,9 @@ func TestAddDimensionToInstanceReturnsForbidden(t *testing.T) {
 			GetInstanceFunc: func(ID string) (*models.Instance, error) {
 				return &models.Instance{State: models.PublishedState}, nil
 			},
+			GetDimensionCodeListFunc: func(instanceID, dimension string) (string, error) {
+				return "", errs.ErrDimensionNotFound
+			},
 			AddDimensionToInstanceFunc: func(event *models.CachedDimensionOption) error {
 				return nil
 			},
@@ -571,6 +742,9 @@ func TestAddDimensionToInstanceReturnsInternalError(t *testing.T) {
 			GetInstanceFunc: func(ID string) (*models.Instance, error) {
 				return nil, errs.ErrInternalServer
 			},
+			GetDimensionCodeListFunc: func(instanceID, dimension string) (string, error) {
+				return "", errs.ErrDimensionNotFound
+			},
 			AddDimensionToInstanceFunc: func(event *models.CachedDimensionOption) error {
 				return nil
 			},
@@ -611,6 +785,9 @@ func TestAddDimensionToInstanceReturnsInternalError(t *testing.T) {
 			GetInstanceFunc: func(ID string) (*models.Instance, error) {
 				return &models.Instance{State: "gobbledygook"}, nil
 			},
+			GetDimensionCodeListFunc: func(instanceID, dimension string) (string, error) {
+				return "", errs.ErrDimensionNotFound
+			},
 			AddDimensionToInstanceFunc: func(event *models.CachedDimensionOption) error {
 				return nil
 			},
@@ -728,6 +905,9 @@ func TestAddDimensionAuditFailure(t *testing.T) {
 			GetInstanceFunc: func(ID string) (*models.Instance, error) {
 				return &models.Instance{State: models.CreatedState}, nil
 			},
+			GetDimensionCodeListFunc: func(instanceID, dimension string) (string, error) {
+				return "", errs.ErrDimensionNotFound
+			},
 			AddDimensionToInstanceFunc: func(event *models.CachedDimensionOption) error {
 				return nil
 			},
@@ -807,6 +987,117 @@ func TestGetDimensionsReturnsOk(t *testing.T) {
 	})
 }
 
+func TestGetDimensionsWithCountsReturnsOk(t *testing.T) {
+	t.Parallel()
+	Convey("Given an instance with two dimensions", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CreatedState}, nil
+			},
+			GetDimensionsFromInstanceFunc: func(id string) (*models.DimensionNodeResults, error) {
+				return &models.DimensionNodeResults{}, nil
+			},
+			GetDimensionOptionCountsFunc: func(instanceID string) ([]models.DimensionOptionCount, error) {
+				return []models.DimensionOptionCount{
+					{Name: "age", OptionCount: 3},
+					{Name: "geography", OptionCount: 2},
+				}, nil
+			},
+		}
+
+		Convey("When the dimensions are requested with counts", func() {
+			r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123/dimensions?with_counts=true", nil)
+			So(err, ShouldBeNil)
+
+			w := httptest.NewRecorder()
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then the option count of each dimension is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.GetDimensionOptionCountsCalls()), ShouldEqual, 1)
+				So(mockedDataStore.GetDimensionOptionCountsCalls()[0].InstanceID, ShouldEqual, "123")
+
+				var results models.DimensionNodeResults
+				So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+				So(results.OptionCounts, ShouldResemble, []models.DimensionOptionCount{
+					{Name: "age", OptionCount: 3},
+					{Name: "geography", OptionCount: 2},
+				})
+			})
+		})
+
+		Convey("When the dimensions are requested without counts then the options are not counted", func() {
+			r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123/dimensions", nil)
+			So(err, ShouldBeNil)
+
+			w := httptest.NewRecorder()
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(len(mockedDataStore.GetDimensionOptionCountsCalls()), ShouldEqual, 0)
+			So(w.Body.String(), ShouldNotContainSubstring, "option_counts")
+		})
+	})
+}
+
+func TestGetDimensionsSortedByDimensionOrder(t *testing.T) {
+	t.Parallel()
+	Convey("Given an instance with a canonical dimension order", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CreatedState, DimensionOrder: []string{"time", "geography"}}, nil
+			},
+			GetDimensionsFromInstanceFunc: func(id string) (*models.DimensionNodeResults, error) {
+				return &models.DimensionNodeResults{Items: []models.DimensionOption{
+					{Name: "geography", Option: "K02000001"},
+					{Name: "sex", Option: "male"},
+					{Name: "age", Option: "30"},
+					{Name: "time", Option: "Aug-16"},
+					{Name: "geography", Option: "K04000001"},
+				}}, nil
+			},
+			GetDimensionOptionCountsFunc: func(instanceID string) ([]models.DimensionOptionCount, error) {
+				return []models.DimensionOptionCount{
+					{Name: "age", OptionCount: 1},
+					{Name: "geography", OptionCount: 2},
+					{Name: "sex", OptionCount: 1},
+					{Name: "time", OptionCount: 1},
+				}, nil
+			},
+		}
+
+		Convey("When the dimensions are requested", func() {
+			r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123/dimensions?with_counts=true", nil)
+			So(err, ShouldBeNil)
+
+			w := httptest.NewRecorder()
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then they are returned in the configured order followed by the other dimensions by name", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var results models.DimensionNodeResults
+				So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+
+				var options []string
+				for _, item := range results.Items {
+					options = append(options, item.Name+":"+item.Option)
+				}
+				So(options, ShouldResemble, []string{"time:Aug-16", "geography:K02000001", "geography:K04000001", "age:30", "sex:male"})
+
+				var counts []string
+				for _, count := range results.OptionCounts {
+					counts = append(counts, count.Name)
+				}
+				So(counts, ShouldResemble, []string{"time", "geography", "age", "sex"})
+			})
+		})
+	})
+}
+
 func TestGetDimensionsReturnsNotFound(t *testing.T) {
 	t.Parallel()
 	Convey("Get dimensions returns not found", t, func() {
@@ -1084,6 +1375,101 @@ func TestGetUniqueDimensionAndOptionsReturnsOk(t *testing.T) {
 	})
 }
 
+func TestGetDimensionOptionsByCodesReturnsOk(t *testing.T) {
+	t.Parallel()
+	Convey("Given a dimension with options for several ages", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CreatedState}, nil
+			},
+			GetDimensionOptionsByCodesFunc: func(instanceID, dimension string, codes []string) (*models.DimensionOptionResults, error) {
+				return &models.DimensionOptionResults{Items: []models.PublicDimensionOption{
+					{Name: "age", Option: "30", Label: "30 years"},
+					{Name: "age", Option: "40", Label: "40 years"},
+				}}, nil
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor)
+
+		Convey("When a subset of the options, including a code which is not an option, is requested", func() {
+			body := strings.NewReader(`{"codes": ["30", "40", "999"]}`)
+			r, err := createRequestWithToken("POST", "http://localhost:21800/instances/123/dimensions/age/options/byCodes", body)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then the options found for the codes are returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				So(len(mockedDataStore.GetDimensionOptionsByCodesCalls()), ShouldEqual, 1)
+				call := mockedDataStore.GetDimensionOptionsByCodesCalls()[0]
+				So(call.InstanceID, ShouldEqual, "123")
+				So(call.Dimension, ShouldEqual, "age")
+				So(call.Codes, ShouldResemble, []string{"30", "40", "999"})
+
+				var options models.DimensionOptionResults
+				So(json.Unmarshal(w.Body.Bytes(), &options), ShouldBeNil)
+				So(len(options.Items), ShouldEqual, 2)
+				So(options.Items[0].Option, ShouldEqual, "30")
+				So(options.Items[1].Option, ShouldEqual, "40")
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{
+						Action: dimension.GetDimensionOptionsByCodesAction,
+						Result: audit.Attempted,
+						Params: common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123", "dimension": "age"},
+					},
+					auditortest.Expected{
+						Action: dimension.GetDimensionOptionsByCodesAction,
+						Result: audit.Successful,
+						Params: common.Params{"instance_id": "123", "dimension": "age"},
+					},
+				)
+			})
+		})
+	})
+}
+
+func TestGetDimensionOptionsByCodesReturnsBadRequest(t *testing.T) {
+	t.Parallel()
+	Convey("Given no option codes are requested then a bad request response is returned", t, func() {
+		r, err := createRequestWithToken("POST", "http://localhost:21800/instances/123/dimensions/age/options/byCodes", strings.NewReader(`{"codes": []}`))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{}
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrMissingParameters.Error())
+		So(len(mockedDataStore.GetDimensionOptionsByCodesCalls()), ShouldEqual, 0)
+	})
+
+	Convey("Given more option codes are requested than the configured maximum then a bad request response is returned", t, func() {
+		codes := make([]string, 1001)
+		for i := range codes {
+			codes[i] = "code"
+		}
+		b, err := json.Marshal(models.DimensionOptionCodes{Codes: codes})
+		So(err, ShouldBeNil)
+
+		r, err := createRequestWithToken("POST", "http://localhost:21800/instances/123/dimensions/age/options/byCodes", strings.NewReader(string(b)))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{}
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrTooManyDimensionOptionCodes.Error())
+		So(len(mockedDataStore.GetDimensionOptionsByCodesCalls()), ShouldEqual, 0)
+	})
+}
+
 func TestGetUniqueDimensionAndOptionsReturnsNotFound(t *testing.T) {
 	t.Parallel()
 	Convey("Get all unique dimensions returns not found", t, func() {
@@ -1318,6 +1704,277 @@ func TestGetUniqueDimensionAndOptionsAuditFailure(t *testing.T) {
 	})
 }
 
+func TestRenameDimensionReturnsOk(t *testing.T) {
+	t.Parallel()
+	Convey("Given an instance with a dimension imported under the wrong name", t, func() {
+		r, err := createRequestWithToken("PUT", "http://localhost:22000/instances/123/dimensions/geography/name", strings.NewReader(`{"name":"geography-2018"}`))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CompletedState, Dimensions: []models.Dimension{{Name: "age"}, {Name: "geography"}}}, nil
+			},
+			RenameDimensionFunc: func(instanceID, oldName, newName string) error {
+				return nil
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor)
+
+		Convey("When the dimension is renamed", func() {
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then the instance and its options are renamed in a single store call", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				// Gets called twice as there is a check wrapper around this route which
+				// checks the instance is not published before entering handler
+				So(len(mockedDataStore.GetInstanceCalls()), ShouldEqual, 2)
+				So(len(mockedDataStore.RenameDimensionCalls()), ShouldEqual, 1)
+				So(mockedDataStore.RenameDimensionCalls()[0].InstanceID, ShouldEqual, "123")
+				So(mockedDataStore.RenameDimensionCalls()[0].OldName, ShouldEqual, "geography")
+				So(mockedDataStore.RenameDimensionCalls()[0].NewName, ShouldEqual, "geography-2018")
+			})
+
+			Convey("Then the old and new names are audited", func() {
+				auditor.AssertRecordCalls(
+					auditortest.Expected{
+						Action: dimension.RenameDimensionAction,
+						Result: audit.Attempted,
+						Params: common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123", "dimension": "geography"},
+					},
+					auditortest.Expected{
+						Action: dimension.RenameDimensionAction,
+						Result: audit.Successful,
+						Params: common.Params{"instance_id": "123", "old_name": "geography", "new_name": "geography-2018"},
+					},
+				)
+			})
+		})
+	})
+}
+
+func TestRenameDimensionReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("Rename a dimension which is not on the instance returns not found", t, func() {
+		r, err := createRequestWithToken("PUT", "http://localhost:22000/instances/123/dimensions/sex/name", strings.NewReader(`{"name":"gender"}`))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CompletedState, Dimensions: []models.Dimension{{Name: "geography"}}}, nil
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor)
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrDimensionNotFound.Error())
+		So(len(mockedDataStore.RenameDimensionCalls()), ShouldEqual, 0)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{
+				Action: dimension.RenameDimensionAction,
+				Result: audit.Attempted,
+				Params: common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123", "dimension": "sex"},
+			},
+			auditortest.Expected{
+				Action: dimension.RenameDimensionAction,
+				Result: audit.Unsuccessful,
+				Params: common.Params{"instance_id": "123", "old_name": "sex", "new_name": "gender"},
+			},
+		)
+	})
+
+	Convey("Rename a dimension to an invalid name returns bad request", t, func() {
+		r, err := createRequestWithToken("PUT", "http://localhost:22000/instances/123/dimensions/geography/name", strings.NewReader(`{"name":"Geography 2018"}`))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CompletedState, Dimensions: []models.Dimension{{Name: "geography"}}}, nil
+			},
+		}
+
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrDimensionNameInvalid.Error())
+		So(len(mockedDataStore.RenameDimensionCalls()), ShouldEqual, 0)
+	})
+
+	Convey("Rename a dimension to the name of another dimension of the instance returns conflict", t, func() {
+		r, err := createRequestWithToken("PUT", "http://localhost:22000/instances/123/dimensions/geography/name", strings.NewReader(`{"name":"age"}`))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CompletedState, Dimensions: []models.Dimension{{Name: "age"}, {Name: "geography"}}}, nil
+			},
+		}
+
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusConflict)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrDimensionNameExists.Error())
+		So(len(mockedDataStore.RenameDimensionCalls()), ShouldEqual, 0)
+	})
+
+	Convey("Rename a dimension of a published instance returns forbidden", t, func() {
+		r, err := createRequestWithToken("PUT", "http://localhost:22000/instances/123/dimensions/geography/name", strings.NewReader(`{"name":"geography-2018"}`))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.PublishedState, Dimensions: []models.Dimension{{Name: "geography"}}}, nil
+			},
+		}
+
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrResourcePublished.Error())
+		So(len(mockedDataStore.GetInstanceCalls()), ShouldEqual, 1)
+		So(len(mockedDataStore.RenameDimensionCalls()), ShouldEqual, 0)
+	})
+}
+
+func TestUpdateDimensionOptionReturnsOk(t *testing.T) {
+	t.Parallel()
+	Convey("Given an option of a dimension which has been assigned a node_id", t, func() {
+		r, err := createRequestWithToken("PATCH", "http://localhost:22000/instances/123/dimensions/age/options/55", strings.NewReader(`{"label":"55 years"}`))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CompletedState}, nil
+			},
+			UpdateDimensionOptionFunc: func(instanceID, dimension, option string, update *models.DimensionOptionUpdate) error {
+				return nil
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor)
+
+		Convey("When only the label of the option is updated", func() {
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then only the label is passed to the store, leaving the node_id unchanged", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.UpdateDimensionOptionCalls()), ShouldEqual, 1)
+
+				call := mockedDataStore.UpdateDimensionOptionCalls()[0]
+				So(call.InstanceID, ShouldEqual, "123")
+				So(call.Dimension, ShouldEqual, "age")
+				So(call.Option, ShouldEqual, "55")
+				So(call.Update, ShouldResemble, &models.DimensionOptionUpdate{Label: "55 years"})
+				So(len(mockedDataStore.UpdateDimensionNodeIDCalls()), ShouldEqual, 0)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{
+						Action: dimension.UpdateOptionAction,
+						Result: audit.Attempted,
+						Params: common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123", "dimension": "age", "option": "55"},
+					},
+					auditortest.Expected{
+						Action: dimension.UpdateOptionAction,
+						Result: audit.Successful,
+						Params: common.Params{"instance_id": "123", "dimension": "age", "option": "55"},
+					},
+				)
+			})
+		})
+	})
+}
+
+func TestUpdateDimensionOptionReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("Update an option which does not exist returns not found", t, func() {
+		r, err := createRequestWithToken("PATCH", "http://localhost:22000/instances/123/dimensions/age/options/55", strings.NewReader(`{"label":"55 years"}`))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CompletedState}, nil
+			},
+			UpdateDimensionOptionFunc: func(instanceID, dimension, option string, update *models.DimensionOptionUpdate) error {
+				return errs.ErrDimensionOptionNotFound
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor)
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrDimensionOptionNotFound.Error())
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{
+				Action: dimension.UpdateOptionAction,
+				Result: audit.Attempted,
+				Params: common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123", "dimension": "age", "option": "55"},
+			},
+			auditortest.Expected{
+				Action: dimension.UpdateOptionAction,
+				Result: audit.Unsuccessful,
+				Params: common.Params{"instance_id": "123", "dimension": "age", "option": "55"},
+			},
+		)
+	})
+
+	Convey("Update an option without a label or links returns bad request", t, func() {
+		r, err := createRequestWithToken("PATCH", "http://localhost:22000/instances/123/dimensions/age/options/55", strings.NewReader(`{"node_id":"11"}`))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CompletedState}, nil
+			},
+		}
+
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrMissingParameters.Error())
+		So(len(mockedDataStore.UpdateDimensionOptionCalls()), ShouldEqual, 0)
+	})
+
+	Convey("Update an option of a published instance returns forbidden", t, func() {
+		r, err := createRequestWithToken("PATCH", "http://localhost:22000/instances/123/dimensions/age/options/55", strings.NewReader(`{"label":"55 years"}`))
+		So(err, ShouldBeNil)
+
+		w := httptest.NewRecorder()
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.PublishedState}, nil
+			},
+		}
+
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrResourcePublished.Error())
+		So(len(mockedDataStore.UpdateDimensionOptionCalls()), ShouldEqual, 0)
+	})
+}
+
 func getAPIWithMocks(mockedDataStore store.Storer, mockedGeneratedDownloads api.DownloadsGenerator, mockAuditor api.Auditor) *api.DatasetAPI {
 	mu.Lock()
 	defer mu.Unlock()
@@ -1331,7 +1988,7 @@ func getAPIWithMocks(mockedDataStore store.Storer, mockedGeneratedDownloads api.
 	datasetPermissions := getAuthorisationHandlerMock()
 	permissions := getAuthorisationHandlerMock()
 
-	return api.NewDatasetAPI(*cfg, mux.NewRouter(), store.DataStore{Backend: mockedDataStore}, urlBuilder, mockedGeneratedDownloads, mockAuditor, datasetPermissions, permissions)
+	return api.NewDatasetAPI(*cfg, mux.NewRouter(), store.DataStore{Backend: mockedDataStore}, urlBuilder, mockedGeneratedDownloads, &mocks.HierarchyBuildTriggerMock{}, &mocks.PublishNotifierMock{}, nil, mockAuditor, datasetPermissions, permissions)
 }
 
 func getAuthorisationHandlerMock() *mocks.AuthHandlerMock {
@@ -2,9 +2,7 @@ package dimension
 
 import (
 	"context"
-	"encoding/json"
 	"io"
-	"io/ioutil"
 	"net/http"
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
@@ -14,24 +12,62 @@ import (
 	"github.com/pkg/errors"
 )
 
-func unmarshalDimensionCache(reader io.Reader) (*models.CachedDimensionOption, error) {
-	b, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return nil, errs.ErrUnableToReadMessage
+func unmarshalDimensionCache(reader io.Reader, limits models.JSONLimits) (*models.CachedDimensionOption, error) {
+	var option models.CachedDimensionOption
+	if err := models.DecodeJSON(reader, &option, limits); err != nil {
+		return nil, err
 	}
 
-	var option models.CachedDimensionOption
+	if option.Name == "" || (option.Option == "" && option.CodeList == "") {
+		return nil, errs.ErrMissingParameters
+	}
 
-	err = json.Unmarshal(b, &option)
-	if err != nil {
-		return nil, errs.ErrUnableToParseJSON
+	return &option, nil
+}
 
+func unmarshalDimensionName(reader io.Reader, limits models.JSONLimits) (string, error) {
+	var dimension models.Dimension
+	if err := models.DecodeJSON(reader, &dimension, limits); err != nil {
+		return "", err
 	}
-	if option.Name == "" || (option.Option == "" && option.CodeList == "") {
+	if dimension.Name == "" {
+		return "", errs.ErrMissingParameters
+	}
+
+	return dimension.Name, nil
+}
+
+// unmarshalDimensionOptionUpdate reads a partial update of a dimension option, which must change its label or links
+func unmarshalDimensionOptionUpdate(reader io.Reader, limits models.JSONLimits) (*models.DimensionOptionUpdate, error) {
+	var update models.DimensionOptionUpdate
+	if err := models.DecodeJSON(reader, &update, limits); err != nil {
+		return nil, err
+	}
+
+	if update.Label == "" && (update.Links == nil || *update.Links == models.DimensionOptionLinks{}) {
 		return nil, errs.ErrMissingParameters
 	}
 
-	return &option, nil
+	return &update, nil
+}
+
+// unmarshalDimensionOptionCodes reads the option codes to retrieve, of which there must be at least one and no more
+// than maxCodes
+func unmarshalDimensionOptionCodes(reader io.Reader, maxCodes int, limits models.JSONLimits) ([]string, error) {
+	var codes models.DimensionOptionCodes
+	if err := models.DecodeJSON(reader, &codes, limits); err != nil {
+		return nil, err
+	}
+
+	if len(codes.Codes) == 0 {
+		return nil, errs.ErrMissingParameters
+	}
+
+	if len(codes.Codes) > maxCodes {
+		return nil, errs.ErrTooManyDimensionOptionCodes
+	}
+
+	return codes.Codes, nil
 }
 
 func handleDimensionErr(ctx context.Context, w http.ResponseWriter, err error, data log.Data) {
@@ -46,6 +82,8 @@ func handleDimensionErr(ctx context.Context, w http.ResponseWriter, err error, d
 		status = http.StatusNotFound
 	case errs.BadRequestMap[err]:
 		status = http.StatusBadRequest
+	case errs.ConflictRequestMap[err]:
+		status = http.StatusConflict
 	default:
 		status = http.StatusInternalServerError
 		resource = errs.ErrInternalServer
@@ -53,5 +91,5 @@ func handleDimensionErr(ctx context.Context, w http.ResponseWriter, err error, d
 
 	data["response_status"] = status
 	audit.LogError(ctx, errors.WithMessage(err, "request unsuccessful"), data)
-	http.Error(w, resource.Error(), status)
+	errs.WriteError(w, resource, status)
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
 	"github.com/ONSdigital/go-ns/log"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -17,7 +18,7 @@ func TestUnmarshalDimensionCache(t *testing.T) {
 	Convey("Successfully unmarshal dimension cache", t, func() {
 		json := strings.NewReader(`{"option":"24", "code_list":"123-456", "dimension": "test"}`)
 
-		option, err := unmarshalDimensionCache(json)
+		option, err := unmarshalDimensionCache(json, models.DefaultJSONLimits)
 		So(err, ShouldBeNil)
 		So(option.CodeList, ShouldEqual, "123-456")
 		So(option.Name, ShouldEqual, "test")
@@ -28,7 +29,7 @@ func TestUnmarshalDimensionCache(t *testing.T) {
 		Convey("When unable to marshal json", func() {
 			json := strings.NewReader("{")
 
-			option, err := unmarshalDimensionCache(json)
+			option, err := unmarshalDimensionCache(json, models.DefaultJSONLimits)
 			So(err, ShouldNotBeNil)
 			So(err, ShouldResemble, errs.ErrUnableToParseJSON)
 			So(option, ShouldBeNil)
@@ -37,7 +38,7 @@ func TestUnmarshalDimensionCache(t *testing.T) {
 		Convey("When options are missing mandatory fields", func() {
 			json := strings.NewReader("{}")
 
-			option, err := unmarshalDimensionCache(json)
+			option, err := unmarshalDimensionCache(json, models.DefaultJSONLimits)
 			So(err, ShouldNotBeNil)
 			So(err, ShouldResemble, errs.ErrMissingParameters)
 			So(option, ShouldBeNil)
@@ -0,0 +1,153 @@
+package dimension
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/gorilla/mux"
+)
+
+// GetDimensionOptionsCSVAction represents the audit action to export the options of a dimension of an instance as csv
+const GetDimensionOptionsCSVAction = "getInstanceDimensionOptionsCSV"
+
+// optionsCSVFlushRows is how many options are written between each flush of the csv to the client
+const optionsCSVFlushRows = 500
+
+// optionsCSVHeader is the header row of the csv of the options of a dimension
+var optionsCSVHeader = []string{"code", "label", "node_id"}
+
+// GetOptionsCSVHandler writes the options of a dimension of an instance as csv, one row per option ordered by code,
+// streamed from the store as they are read rather than loaded all at once, so the options of the largest dimensions
+// can be exported. Once rows have been sent an error cannot change the status of the response, so the csv is left
+// incomplete.
+func (s *Store) GetOptionsCSVHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	instanceID := vars["instance_id"]
+	dimension := vars["dimension"]
+	auditParams := common.Params{"instance_id": instanceID, "dimension": dimension}
+	logData := audit.ToLogData(auditParams)
+
+	if err := s.checkInstanceReadable(ctx, instanceID, GetDimensionOptionsCSVAction, logData); err != nil {
+		if auditErr := s.auditor().Record(ctx, GetDimensionOptionsCSVAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+
+		handleDimensionErr(ctx, w, err, logData)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.csv", instanceID, dimension)
+	csvWriter := newOptionsCSVWriter(w, filename)
+
+	err := s.StreamDimensionOptions(instanceID, dimension, csvWriter.write)
+	if err == nil {
+		err = csvWriter.flush()
+	}
+	logData["options"] = csvWriter.written
+
+	if err != nil {
+		log.ErrorCtx(ctx, dimensionError(err, "failed to write dimension options as csv", GetDimensionOptionsCSVAction), logData)
+		if auditErr := s.auditor().Record(ctx, GetDimensionOptionsCSVAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		if !csvWriter.sent {
+			handleDimensionErr(ctx, w, err, logData)
+		}
+		return
+	}
+
+	if auditErr := s.auditor().Record(ctx, GetDimensionOptionsCSVAction, audit.Successful, auditParams); auditErr != nil {
+		log.ErrorCtx(ctx, dimensionError(auditErr, "failed to audit successful csv export", GetDimensionOptionsCSVAction), logData)
+		return
+	}
+
+	log.InfoCtx(ctx, fmt.Sprintf("%v endpoint: successfully exported dimension options of an instance resource as csv", GetDimensionOptionsCSVAction), logData)
+}
+
+// checkInstanceReadable returns an error if the instance does not exist or is not in a state its dimensions can be
+// read in
+func (s *Store) checkInstanceReadable(ctx context.Context, instanceID, action string, logData log.Data) error {
+	instance, err := s.GetInstance(instanceID)
+	if err != nil {
+		log.ErrorCtx(ctx, dimensionError(err, "failed to get instance", action), logData)
+		return err
+	}
+
+	if err = models.CheckState("instance", instance.State); err != nil {
+		logData["state"] = instance.State
+		log.ErrorCtx(ctx, dimensionError(err, "current instance has an invalid state", action), logData)
+		return err
+	}
+	return nil
+}
+
+// optionsCSVWriter writes options as rows of csv after a header row, buffering the rows until they are flushed so
+// that an error before the first flush can still be returned with an error status
+type optionsCSVWriter struct {
+	w        http.ResponseWriter
+	csv      *csv.Writer
+	filename string
+	started  bool
+	sent     bool
+	written  int
+}
+
+func newOptionsCSVWriter(w http.ResponseWriter, filename string) *optionsCSVWriter {
+	return &optionsCSVWriter{w: w, csv: csv.NewWriter(w), filename: filename}
+}
+
+// write adds a row for the option, flushing the rows to the client every optionsCSVFlushRows options
+func (c *optionsCSVWriter) write(option *models.DimensionOption) error {
+	if err := c.start(); err != nil {
+		return err
+	}
+
+	if err := c.csv.Write([]string{option.Option, option.Label, option.NodeID}); err != nil {
+		return err
+	}
+
+	c.written++
+	if c.written%optionsCSVFlushRows == 0 {
+		return c.flush()
+	}
+	return nil
+}
+
+// flush sends the rows written so far to the client, setting the headers of the response the first time
+func (c *optionsCSVWriter) flush() error {
+	if err := c.start(); err != nil {
+		return err
+	}
+
+	if !c.sent {
+		c.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		c.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, c.filename))
+		c.sent = true
+	}
+
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		return err
+	}
+
+	if flusher, ok := c.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// start writes the header row, once
+func (c *optionsCSVWriter) start() error {
+	if c.started {
+		return nil
+	}
+	c.started = true
+	return c.csv.Write(optionsCSVHeader)
+}
@@ -0,0 +1,173 @@
+package dimension_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/dimension"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	storetest "github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetDimensionOptionsCSVReturnsOk(t *testing.T) {
+	t.Parallel()
+	Convey("Given a dimension of an instance with several options", t, func() {
+		options := []models.DimensionOption{
+			{Option: "E06000001", Label: "Hartlepool", NodeID: "_1"},
+			{Option: "E06000002", Label: "Middlesbrough, Redcar", NodeID: "_2"},
+			{Option: "E06000003", Label: "Stockton-on-Tees"},
+		}
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CompletedState}, nil
+			},
+			StreamDimensionOptionsFunc: func(instanceID, dimension string, fn func(*models.DimensionOption) error) error {
+				for i := range options {
+					if err := fn(&options[i]); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}
+
+		Convey("When the options are exported as csv", func() {
+			r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123/dimensions/geography/options.csv", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			auditor := auditortest.New()
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor)
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then a row with the code, label and node id of each option is written after the header row", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get("Content-Type"), ShouldEqual, "text/csv; charset=utf-8")
+				So(w.Header().Get("Content-Disposition"), ShouldEqual, `attachment; filename="123-geography.csv"`)
+				So(w.Body.String(), ShouldEqual, "code,label,node_id\n"+
+					"E06000001,Hartlepool,_1\n"+
+					"E06000002,\"Middlesbrough, Redcar\",_2\n"+
+					"E06000003,Stockton-on-Tees,\n")
+
+				So(len(mockedDataStore.StreamDimensionOptionsCalls()), ShouldEqual, 1)
+				So(mockedDataStore.StreamDimensionOptionsCalls()[0].InstanceID, ShouldEqual, "123")
+				So(mockedDataStore.StreamDimensionOptionsCalls()[0].Dimension, ShouldEqual, "geography")
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{
+						Action: dimension.GetDimensionOptionsCSVAction,
+						Result: audit.Attempted,
+						Params: common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123", "dimension": "geography"},
+					},
+					auditortest.Expected{
+						Action: dimension.GetDimensionOptionsCSVAction,
+						Result: audit.Successful,
+						Params: common.Params{"instance_id": "123", "dimension": "geography"},
+					},
+				)
+			})
+		})
+	})
+}
+
+func TestGetDimensionOptionsCSVReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("When the instance does not exist then a not found response is returned without reading the options", t, func() {
+		r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123/dimensions/geography/options.csv", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return nil, errs.ErrInstanceNotFound
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor)
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrInstanceNotFound.Error())
+		So(len(mockedDataStore.StreamDimensionOptionsCalls()), ShouldEqual, 0)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{
+				Action: dimension.GetDimensionOptionsCSVAction,
+				Result: audit.Attempted,
+				Params: common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123", "dimension": "geography"},
+			},
+			auditortest.Expected{
+				Action: dimension.GetDimensionOptionsCSVAction,
+				Result: audit.Unsuccessful,
+				Params: common.Params{"instance_id": "123", "dimension": "geography"},
+			},
+		)
+	})
+
+	Convey("When the instance has an invalid state then an internal error is returned without reading the options", t, func() {
+		r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123/dimensions/geography/options.csv", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: "gobbledygook"}, nil
+			},
+		}
+
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+		So(len(mockedDataStore.StreamDimensionOptionsCalls()), ShouldEqual, 0)
+	})
+
+	Convey("When reading the options fails before any rows are sent then an internal error is returned", t, func() {
+		r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123/dimensions/geography/options.csv", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{State: models.CompletedState}, nil
+			},
+			StreamDimensionOptionsFunc: func(instanceID, dimension string, fn func(*models.DimensionOption) error) error {
+				if err := fn(&models.DimensionOption{Option: "E06000001", Label: "Hartlepool"}); err != nil {
+					return err
+				}
+				return errors.New("iterator failed")
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor)
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrInternalServer.Error())
+		So(w.Header().Get("Content-Disposition"), ShouldBeEmpty)
+
+		auditor.AssertRecordCalls(
+			auditortest.Expected{
+				Action: dimension.GetDimensionOptionsCSVAction,
+				Result: audit.Attempted,
+				Params: common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123", "dimension": "geography"},
+			},
+			auditortest.Expected{
+				Action: dimension.GetDimensionOptionsCSVAction,
+				Result: audit.Unsuccessful,
+				Params: common.Params{"instance_id": "123", "dimension": "geography"},
+			},
+		)
+	})
+}
@@ -16,6 +16,7 @@ var (
 	instanceIDEmptyErr = newGeneratorError(nil, "failed to generate full dataset download as instance ID was empty")
 	editionEmptyErr    = newGeneratorError(nil, "failed to generate full dataset download as edition was empty")
 	versionEmptyErr    = newGeneratorError(nil, "failed to generate full dataset download as version was empty")
+	formatsEmptyErr    = newGeneratorError(nil, "failed to generate full dataset download as no formats were requested")
 )
 
 // KafkaProducer sends an outbound kafka message
@@ -29,11 +30,12 @@ type GenerateDownloadsEvent interface {
 }
 
 type generateDownloads struct {
-	FilterID   string `avro:"filter_output_id"`
-	InstanceID string `avro:"instance_id"`
-	DatasetID  string `avro:"dataset_id"`
-	Edition    string `avro:"edition"`
-	Version    string `avro:"version"`
+	FilterID   string   `avro:"filter_output_id"`
+	InstanceID string   `avro:"instance_id"`
+	DatasetID  string   `avro:"dataset_id"`
+	Edition    string   `avro:"edition"`
+	Version    string   `avro:"version"`
+	Formats    []string `avro:"formats"`
 }
 
 // Generator kicks off a full dataset version download task
@@ -42,8 +44,8 @@ type Generator struct {
 	Marshaller GenerateDownloadsEvent
 }
 
-// Generate the full file download files for the specified dataset/edition/version
-func (gen *Generator) Generate(datasetID string, instanceID string, edition string, version string) error {
+// Generate the full file download files in each of the formats for the specified dataset/edition/version
+func (gen *Generator) Generate(datasetID string, instanceID string, edition string, version string, formats []string) error {
 	if datasetID == "" {
 		return datasetIDEmptyErr
 	}
@@ -56,6 +58,9 @@ func (gen *Generator) Generate(datasetID string, instanceID string, edition stri
 	if version == "" {
 		return versionEmptyErr
 	}
+	if len(formats) == 0 {
+		return formatsEmptyErr
+	}
 
 	// FilterID is set to an empty string as the avro schema expects there to be
 	// a filter ID otherwise struct wont be marshalled into an acceptable message
@@ -65,6 +70,7 @@ func (gen *Generator) Generate(datasetID string, instanceID string, edition stri
 		InstanceID: instanceID,
 		Edition:    edition,
 		Version:    version,
+		Formats:    formats,
 	}
 
 	log.Info("send generate downloads event", log.Data{
@@ -72,6 +78,7 @@ func (gen *Generator) Generate(datasetID string, instanceID string, edition stri
 		"instanceID": instanceID,
 		"edition":    edition,
 		"version":    version,
+		"formats":    formats,
 	})
 
 	avroBytes, err := gen.Marshaller.Marshal(downloads)
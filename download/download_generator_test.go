@@ -29,7 +29,7 @@ func TestGenerator_GenerateFullDatasetDownloadsValidationErrors(t *testing.T) {
 	Convey("Given an invalid datasetID", t, func() {
 
 		Convey("When the generator is called", func() {
-			err := gen.Generate("", "", "", "")
+			err := gen.Generate("", "", "", "", nil)
 
 			Convey("Then the expected error is returned", func() {
 				So(err, ShouldResemble, datasetIDEmptyErr)
@@ -47,7 +47,7 @@ func TestGenerator_GenerateFullDatasetDownloadsValidationErrors(t *testing.T) {
 
 	Convey("Given an empty instanceID", t, func() {
 		Convey("When the generator is called", func() {
-			err := gen.Generate("1234567890", "", "", "")
+			err := gen.Generate("1234567890", "", "", "", nil)
 
 			Convey("Then the expected error is returned", func() {
 				So(err, ShouldResemble, instanceIDEmptyErr)
@@ -65,7 +65,7 @@ func TestGenerator_GenerateFullDatasetDownloadsValidationErrors(t *testing.T) {
 
 	Convey("Given an empty edition", t, func() {
 		Convey("When the generator is called", func() {
-			err := gen.Generate("1234567890", "1234567890", "", "")
+			err := gen.Generate("1234567890", "1234567890", "", "", nil)
 
 			Convey("Then the expected error is returned", func() {
 				So(err, ShouldResemble, editionEmptyErr)
@@ -83,7 +83,7 @@ func TestGenerator_GenerateFullDatasetDownloadsValidationErrors(t *testing.T) {
 
 	Convey("Given an empty version", t, func() {
 		Convey("When the generator is called", func() {
-			err := gen.Generate("1234567890", "1234567890", "time-series", "")
+			err := gen.Generate("1234567890", "1234567890", "time-series", "", nil)
 
 			Convey("Then the expected error is returned", func() {
 				So(err, ShouldResemble, versionEmptyErr)
@@ -98,6 +98,24 @@ func TestGenerator_GenerateFullDatasetDownloadsValidationErrors(t *testing.T) {
 			})
 		})
 	})
+
+	Convey("Given no formats", t, func() {
+		Convey("When the generator is called", func() {
+			err := gen.Generate("1234567890", "1234567890", "time-series", "1", nil)
+
+			Convey("Then the expected error is returned", func() {
+				So(err, ShouldResemble, formatsEmptyErr)
+			})
+
+			Convey("And marshaller is never called", func() {
+				So(len(marhsallerMock.MarshalCalls()), ShouldEqual, 0)
+			})
+
+			Convey("And producer is never called", func() {
+				So(len(producerMock.OutputCalls()), ShouldEqual, 0)
+			})
+		})
+	})
 }
 
 func TestGenerator_GenerateMarshalError(t *testing.T) {
@@ -125,7 +143,7 @@ func TestGenerator_GenerateMarshalError(t *testing.T) {
 			Marshaller: marhsallerMock,
 		}
 
-		err := gen.Generate(datasetID, instanceID, edition, version)
+		err := gen.Generate(datasetID, instanceID, edition, version, []string{"csv"})
 
 		Convey("then then expected error is returned", func() {
 			So(err, ShouldResemble, newGeneratorError(mockErr, avroMarshalErr))
@@ -147,6 +165,7 @@ func TestGenerator_Generate(t *testing.T) {
 		instanceID := "222"
 		edition := "333"
 		version := "4"
+		formats := []string{"csv", "xls"}
 
 		downloads := generateDownloads{
 			FilterID:   "",
@@ -154,6 +173,7 @@ func TestGenerator_Generate(t *testing.T) {
 			InstanceID: instanceID,
 			Edition:    edition,
 			Version:    version,
+			Formats:    formats,
 		}
 
 		output := make(chan []byte, 1)
@@ -177,7 +197,7 @@ func TestGenerator_Generate(t *testing.T) {
 		}
 
 		Convey("when generate is called no error is returned", func() {
-			err := gen.Generate(datasetID, instanceID, edition, version)
+			err := gen.Generate(datasetID, instanceID, edition, version, formats)
 			So(err, ShouldBeNil)
 
 			Convey("then marshal is called with the expected parameters", func() {
@@ -0,0 +1,56 @@
+package download
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestURLSigner_Sign(t *testing.T) {
+	Convey("Given a signer and a download url", t, func() {
+		signer := &URLSigner{Key: []byte("secret")}
+		href := "http://localhost:23600/downloads/datasets/123/editions/2017/versions/1.csv"
+		expires := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		Convey("When the url is signed", func() {
+			signed, err := signer.Sign(href, expires)
+			So(err, ShouldBeNil)
+
+			Convey("Then the signed url holds its expiry and signature", func() {
+				parsed, err := url.Parse(signed)
+				So(err, ShouldBeNil)
+				So(parsed.Path, ShouldEqual, "/downloads/datasets/123/editions/2017/versions/1.csv")
+				So(parsed.Query().Get("expires"), ShouldEqual, "1514808000")
+				So(parsed.Query().Get("signature"), ShouldNotBeEmpty)
+			})
+
+			Convey("Then the signed url is valid until it expires", func() {
+				So(signer.Verify(signed, expires.Add(-time.Minute)), ShouldBeTrue)
+				So(signer.Verify(signed, expires.Add(time.Second)), ShouldBeFalse)
+			})
+
+			Convey("Then the signed url is not valid once changed or checked with a different key", func() {
+				parsed, _ := url.Parse(signed)
+				query := parsed.Query()
+				query.Set("expires", "1514811600")
+				parsed.RawQuery = query.Encode()
+
+				So(signer.Verify(parsed.String(), expires), ShouldBeFalse)
+				So((&URLSigner{Key: []byte("other")}).Verify(signed, expires), ShouldBeFalse)
+			})
+
+			Convey("Then signing the signed url again replaces its signature", func() {
+				resigned, err := signer.Sign(signed, expires)
+				So(err, ShouldBeNil)
+				So(resigned, ShouldEqual, signed)
+			})
+		})
+	})
+
+	Convey("When a url which cannot be parsed is signed then an error is returned", t, func() {
+		_, err := (&URLSigner{Key: []byte("secret")}).Sign("http://%zz", time.Now())
+		So(err, ShouldNotBeNil)
+	})
+}
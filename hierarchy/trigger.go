@@ -0,0 +1,70 @@
+package hierarchy
+
+import (
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/pkg/errors"
+)
+
+// KafkaProducer sends an outbound kafka message
+type KafkaProducer interface {
+	Output() chan []byte
+}
+
+// Marshaller marshals the event into avro format
+type Marshaller interface {
+	Marshal(s interface{}) ([]byte, error)
+}
+
+var (
+	errInstanceIDEmpty    = errors.New("failed to trigger hierarchy build as instance ID was empty")
+	errDimensionNameEmpty = errors.New("failed to trigger hierarchy build as dimension name was empty")
+	errCodeListIDEmpty    = errors.New("failed to trigger hierarchy build as code list ID was empty")
+)
+
+type dataImportComplete struct {
+	InstanceID    string `avro:"instance_id"`
+	DimensionName string `avro:"dimension_name"`
+	CodeListID    string `avro:"code_list_id"`
+}
+
+// Trigger asks the hierarchy builder to build the hierarchy of a dimension, by sending the event the import
+// raises once the observations of an instance have been imported
+type Trigger struct {
+	Producer   KafkaProducer
+	Marshaller Marshaller
+}
+
+// BuildHierarchy sends the event which triggers the hierarchy of the dimension of the instance to be built from the
+// code list
+func (t *Trigger) BuildHierarchy(instanceID, dimensionName, codeListID string) error {
+	if instanceID == "" {
+		return errInstanceIDEmpty
+	}
+	if dimensionName == "" {
+		return errDimensionNameEmpty
+	}
+	if codeListID == "" {
+		return errCodeListIDEmpty
+	}
+
+	event := dataImportComplete{
+		InstanceID:    instanceID,
+		DimensionName: dimensionName,
+		CodeListID:    codeListID,
+	}
+
+	log.Info("send build hierarchy event", log.Data{
+		"instance_id":    instanceID,
+		"dimension_name": dimensionName,
+		"code_list_id":   codeListID,
+	})
+
+	avroBytes, err := t.Marshaller.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "error while attempting to marshal dataImportComplete event to avro bytes")
+	}
+
+	t.Producer.Output() <- avroBytes
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package hierarchy
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/schema"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTrigger_BuildHierarchy(t *testing.T) {
+	Convey("Given a trigger", t, func() {
+		output := make(chan []byte, 1)
+		producerMock := &mocks.KafkaProducerMock{
+			OutputFunc: func() chan []byte {
+				return output
+			},
+		}
+
+		trigger := Trigger{
+			Producer:   producerMock,
+			Marshaller: schema.DataImportCompleteEvent,
+		}
+
+		Convey("When a hierarchy build is triggered", func() {
+			err := trigger.BuildHierarchy("123", "geography", "456")
+			So(err, ShouldBeNil)
+
+			Convey("Then the event for the dimension is sent", func() {
+				So(len(producerMock.OutputCalls()), ShouldEqual, 1)
+
+				var event dataImportComplete
+				So(schema.DataImportCompleteEvent.Unmarshal(<-output, &event), ShouldBeNil)
+				So(event, ShouldResemble, dataImportComplete{InstanceID: "123", DimensionName: "geography", CodeListID: "456"})
+			})
+		})
+
+		Convey("When a hierarchy build is triggered without a code list", func() {
+			err := trigger.BuildHierarchy("123", "geography", "")
+
+			Convey("Then an error is returned and no event is sent", func() {
+				So(err, ShouldEqual, errCodeListIDEmpty)
+				So(len(producerMock.OutputCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given the event cannot be marshalled", t, func() {
+		marshallerMock := &mocks.GenerateDownloadsEventMock{
+			MarshalFunc: func(s interface{}) ([]byte, error) {
+				return nil, errors.New("marshal failed")
+			},
+		}
+		producerMock := &mocks.KafkaProducerMock{}
+
+		trigger := Trigger{
+			Producer:   producerMock,
+			Marshaller: marshallerMock,
+		}
+
+		Convey("When a hierarchy build is triggered", func() {
+			err := trigger.BuildHierarchy("123", "geography", "456")
+
+			Convey("Then an error is returned and no event is sent", func() {
+				So(err, ShouldNotBeNil)
+				So(len(producerMock.OutputCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+}
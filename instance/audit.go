@@ -0,0 +1,63 @@
+package instance
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// GetInstanceAuditAction represents the audit action to read the audit trail of an instance
+const GetInstanceAuditAction = "getInstanceAudit"
+
+// AuditTrail returns the audit events stored against an instance, oldest first. Reading the trail is audited but is
+// not itself kept in the trail.
+func (s *Store) AuditTrail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	instanceID := vars["instance_id"]
+	auditParams := common.Params{"instance_id": instanceID}
+	logData := audit.ToLogData(auditParams)
+
+	b, err := func() ([]byte, error) {
+		if _, err := s.GetInstance(instanceID); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "instance audit trail: store.GetInstance returned an error"), logData)
+			return nil, err
+		}
+
+		events, err := s.GetAuditEvents(instanceID)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "instance audit trail: store.GetAuditEvents returned an error"), logData)
+			return nil, err
+		}
+		logData["event_count"] = len(events)
+
+		b, err := json.Marshal(models.AuditEventResults{Items: events})
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "instance audit trail: failed to marshal audit events to json"), logData)
+			return nil, err
+		}
+		return b, nil
+	}()
+
+	if err != nil {
+		if auditErr := s.auditor().Record(ctx, GetInstanceAuditAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleInstanceErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := s.auditor().Record(ctx, GetInstanceAuditAction, audit.Successful, auditParams); auditErr != nil {
+		handleInstanceErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	writeBody(ctx, w, b)
+	log.InfoCtx(ctx, "instance audit trail: request successful", logData)
+}
@@ -0,0 +1,96 @@
+package instance_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/instance"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	storetest "github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_InstanceAuditTrailReturnsOk(t *testing.T) {
+	t.Parallel()
+	Convey("Given an instance with recorded audit events", t, func() {
+		created := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+		events := []models.AuditEvent{
+			{InstanceID: "123", Action: instance.AddInstanceAction, Result: audit.Successful, Caller: "dp-import-api", Created: created},
+			{InstanceID: "123", Action: instance.UpdateInstanceAction, Result: audit.Successful, User: "someone@ons.gov.uk", Created: created.Add(time.Minute)},
+		}
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{InstanceID: ID, State: models.CreatedState}, nil
+			},
+			GetAuditEventsFunc: func(instanceID string) ([]models.AuditEvent, error) {
+				return events, nil
+			},
+		}
+
+		Convey("When the audit trail of the instance is requested", func() {
+			r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123/audit", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			auditor := auditortest.New()
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then the recorded actions are returned in the order they were made", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.GetAuditEventsCalls()), ShouldEqual, 1)
+				So(mockedDataStore.GetAuditEventsCalls()[0].InstanceID, ShouldEqual, "123")
+
+				var results models.AuditEventResults
+				So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+				So(results.Items, ShouldResemble, events)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{instance.GetInstanceAuditAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123"}},
+					auditortest.Expected{instance.GetInstanceAuditAction, audit.Successful, common.Params{"instance_id": "123"}},
+				)
+			})
+		})
+	})
+}
+
+func Test_InstanceAuditTrailReturnsNotFound(t *testing.T) {
+	t.Parallel()
+	Convey("Given the instance does not exist", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return nil, errs.ErrInstanceNotFound
+			},
+		}
+
+		Convey("When the audit trail of the instance is requested", func() {
+			r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123/audit", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			auditor := auditortest.New()
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then a not found response is returned without reading the audit events", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrInstanceNotFound.Error())
+				So(len(mockedDataStore.GetAuditEventsCalls()), ShouldEqual, 0)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{instance.GetInstanceAuditAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123"}},
+					auditortest.Expected{instance.GetInstanceAuditAction, audit.Unsuccessful, common.Params{"instance_id": "123"}},
+				)
+			})
+		})
+	})
+}
@@ -1,8 +1,6 @@
 package instance
 
 import (
-	"encoding/json"
-	"io/ioutil"
 	"net/http"
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
@@ -46,18 +44,10 @@ func (s *Store) UpdateDimension(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Read and unmarshal request body
-		b, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			log.ErrorCtx(ctx, errors.WithMessage(err, "update instance dimension: error reading request.body"), logData)
-			return errs.ErrUnableToReadMessage
-		}
-
 		var dim *models.Dimension
-
-		err = json.Unmarshal(b, &dim)
-		if err != nil {
+		if err = models.DecodeJSON(r.Body, &dim, s.JSONLimits); err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "update instance dimension: failing to model models.Codelist resource based on request"), logData)
-			return errs.ErrUnableToParseJSON
+			return err
 		}
 
 		// Update instance-dimension
@@ -98,14 +88,14 @@ func (s *Store) UpdateDimension(w http.ResponseWriter, r *http.Request) {
 
 		return nil
 	}(); err != nil {
-		if auditErr := s.Auditor.Record(ctx, UpdateDimensionAction, audit.Unsuccessful, auditParams); auditErr != nil {
+		if auditErr := s.auditor().Record(ctx, UpdateDimensionAction, audit.Unsuccessful, auditParams); auditErr != nil {
 			err = auditErr
 		}
 		handleInstanceErr(ctx, err, w, logData)
 		return
 	}
 
-	s.Auditor.Record(ctx, UpdateDimensionAction, audit.Successful, auditParams)
+	s.auditor().Record(ctx, UpdateDimensionAction, audit.Successful, auditParams)
 
 	log.InfoCtx(ctx, "updated instance dimension: request successful", logData)
 }
@@ -2,6 +2,7 @@ package instance
 
 import (
 	"context"
+	"strconv"
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
 	"github.com/ONSdigital/dp-dataset-api/models"
@@ -11,33 +12,42 @@ import (
 	"github.com/pkg/errors"
 )
 
-func (s *Store) confirmEdition(ctx context.Context, datasetID, edition, instanceID string) (*models.EditionUpdate, error) {
+// confirmEdition creates or updates the edition an instance is confirmed to, claiming the next version number of the
+// edition for the instance. The edition and the link to the claimed version are returned. The number is released
+// again if the edition cannot be stored.
+func (s *Store) confirmEdition(ctx context.Context, datasetID, edition, instanceID string) (*models.EditionUpdate, *models.LinkObject, error) {
 	auditParams := common.Params{"dataset_id": datasetID, "instance_id": instanceID, "edition": edition}
 	logData := audit.ToLogData(auditParams)
 
 	var editionDoc *models.EditionUpdate
+	var versionLink *models.LinkObject
 	var action string
 	var err error
 
-	if editionDoc, action, err = func() (*models.EditionUpdate, string, error) {
+	if editionDoc, versionLink, action, err = func() (*models.EditionUpdate, *models.LinkObject, string, error) {
+		linksFrozen := false
 
 		log.DebugCtx(ctx, "getting edition", logData)
 		editionDoc, err := s.GetEdition(datasetID, edition, "")
 		if err != nil {
 			if err != errs.ErrEditionNotFound {
 				log.ErrorCtx(ctx, err, logData)
-				return nil, action, err
+				return nil, nil, action, err
 			}
 
 			log.Debug("edition not found, creating", logData)
 			action = CreateEditionAction
-			if auditErr := s.Auditor.Record(ctx, action, audit.Attempted, auditParams); auditErr != nil {
-				return nil, action, auditErr
+			if auditErr := s.auditor().Record(ctx, action, audit.Attempted, auditParams); auditErr != nil {
+				return nil, nil, action, auditErr
+			}
+
+			if err = s.checkEditionsLimit(ctx, datasetID, logData); err != nil {
+				return nil, nil, action, err
 			}
 
 			editionDoc, err = models.CreateEdition(s.Host, datasetID, edition)
 			if err != nil {
-				return nil, action, err
+				return nil, nil, action, err
 			}
 
 			log.Debug("created new edition", logData)
@@ -50,37 +60,168 @@ func (s *Store) confirmEdition(ctx context.Context, datasetID, edition, instance
 				// Abort if a new/next version is already in flight
 				if editionDoc.Current == nil || editionDoc.Current.Links.LatestVersion.ID != editionDoc.Next.Links.LatestVersion.ID {
 					log.InfoCtx(ctx, "there was an attempted skip of versioning sequence. Aborting edition update", logData)
-					return nil, action, errs.ErrVersionAlreadyExists
+					return nil, nil, action, errs.ErrVersionAlreadyExists
 				}
 			}
 
 			log.DebugCtx(ctx, "edition found, updating", logData)
-			if auditErr := s.Auditor.Record(ctx, action, audit.Attempted, auditParams); auditErr != nil {
-				return nil, action, auditErr
+			if auditErr := s.auditor().Record(ctx, action, audit.Attempted, auditParams); auditErr != nil {
+				return nil, nil, action, auditErr
+			}
+
+			if err = s.checkVersionsLimit(ctx, datasetID, edition, logData); err != nil {
+				return nil, nil, action, err
 			}
 
-			if err = editionDoc.UpdateLinks(s.Host); err != nil {
-				log.ErrorCtx(ctx, errors.WithMessage(err, "unable to update edition links"), logData)
-				return nil, action, err
+			// the latest version link of an edition is left alone while the links of its dataset are frozen
+			dataset, err := s.GetDataset(datasetID)
+			if err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "confirm edition: store.GetDataset returned an error"), logData)
+				return nil, nil, action, err
 			}
+			linksFrozen = dataset.LinksFrozen
+		}
+
+		// the version number is claimed rather than read from the edition, so concurrent confirmations of the
+		// edition cannot be given the same number
+		version, err := s.ClaimNextVersion(datasetID, edition)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "confirm edition: store.ClaimNextVersion returned an error"), logData)
+			return nil, nil, action, err
+		}
+		logData["version"] = version
+
+		claimedLink, err := editionDoc.ClaimLinks(s.Host, version, linksFrozen)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "unable to update edition links"), logData)
+			s.releaseVersion(ctx, datasetID, edition, version, logData)
+			return nil, nil, action, err
 		}
 
 		editionDoc.Next.State = models.EditionConfirmedState
 
 		if err = s.UpsertEdition(datasetID, edition, editionDoc); err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "confirm edition: store.UpsertEdition returned an error"), logData)
-			return nil, action, err
+			s.releaseVersion(ctx, datasetID, edition, version, logData)
+			return nil, nil, action, err
 		}
 
-		return editionDoc, action, nil
+		return editionDoc, claimedLink, action, nil
 	}(); err != nil {
-		if auditErr := s.Auditor.Record(ctx, action, audit.Unsuccessful, auditParams); auditErr != nil {
-			return nil, auditErr
+		if auditErr := s.auditor().Record(ctx, action, audit.Unsuccessful, auditParams); auditErr != nil {
+			return nil, nil, auditErr
 		}
-		return nil, err
+		return nil, nil, err
 	}
 
-	s.Auditor.Record(ctx, action, audit.Successful, auditParams)
+	s.auditor().Record(ctx, action, audit.Successful, auditParams)
 	log.InfoCtx(ctx, "instance update: created/updated edition", logData)
-	return editionDoc, nil
+	return editionDoc, versionLink, nil
+}
+
+// releaseVersion releases a version number claimed for an edition which was not then stored, so the number is not
+// lost. An error releasing it is only logged, as the error which stopped the edition being stored is the one returned.
+func (s *Store) releaseVersion(ctx context.Context, datasetID, edition string, version int, logData log.Data) {
+	if err := s.ReleaseVersion(datasetID, edition, version); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "confirm edition: store.ReleaseVersion returned an error"), logData)
+	}
+}
+
+// abandonEdition undoes the confirmation of an edition for an instance which could not then be updated, so the
+// version number claimed for it is not lost. An error undoing it is only logged, as the error which stopped the
+// instance being updated is the one returned.
+func (s *Store) abandonEdition(ctx context.Context, datasetID, edition string, version int, logData log.Data) {
+	if err := s.unconfirmEdition(ctx, datasetID, edition, version, logData); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "confirm edition: unable to undo the edition confirmation"), logData)
+	}
+}
+
+// unconfirmEdition undoes the changes confirming an edition made for a version of it which will not be created. The
+// latest version link of the edition is moved off the version, the edition is removed when no other version of it
+// remains, and the version number is released.
+func (s *Store) unconfirmEdition(ctx context.Context, datasetID, edition string, version int, logData log.Data) error {
+	editionDoc, err := s.GetEdition(datasetID, edition, "")
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "unconfirm edition: store.GetEdition returned an error"), logData)
+		return err
+	}
+
+	// the link is only moved when it points at the version being removed, so links frozen on an earlier version stay
+	if editionDoc.Next != nil && editionDoc.Next.Links != nil && editionDoc.Next.Links.LatestVersion != nil &&
+		editionDoc.Next.Links.LatestVersion.ID == strconv.Itoa(version) {
+		latest, err := s.GetLatestVersion(datasetID, edition, "")
+		switch {
+		case err == errs.ErrVersionNotFound && editionDoc.Current == nil:
+			if err = s.DeleteEdition(editionDoc.ID); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "unconfirm edition: store.DeleteEdition returned an error"), logData)
+				return err
+			}
+		case err != nil:
+			log.ErrorCtx(ctx, errors.WithMessage(err, "unconfirm edition: store.GetLatestVersion returned an error"), logData)
+			return err
+		default:
+			if _, err = editionDoc.RefreshLinks(s.Host, latest, nil); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "unconfirm edition: unable to refresh edition links"), logData)
+				return err
+			}
+
+			if err = s.UpsertEdition(datasetID, edition, editionDoc); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "unconfirm edition: store.UpsertEdition returned an error"), logData)
+				return err
+			}
+		}
+	}
+
+	if err = s.ReleaseVersion(datasetID, edition, version); err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "unconfirm edition: store.ReleaseVersion returned an error"), logData)
+		return err
+	}
+
+	return nil
+}
+
+// checkEditionsLimit returns an error when the dataset already has the maximum number of editions, so no more can be
+// created
+func (s *Store) checkEditionsLimit(ctx context.Context, datasetID string, logData log.Data) error {
+	if s.MaxEditions < 1 {
+		return nil
+	}
+
+	count, err := s.CountEditions(datasetID)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "confirm edition: store.CountEditions returned an error"), logData)
+		return err
+	}
+
+	if count >= s.MaxEditions {
+		logData["edition_count"] = count
+		logData["max_editions"] = s.MaxEditions
+		log.ErrorCtx(ctx, errors.WithMessage(errs.ErrTooManyEditions, "confirm edition"), logData)
+		return errs.ErrTooManyEditions
+	}
+
+	return nil
+}
+
+// checkVersionsLimit returns an error when the edition already has the maximum number of versions, so no more can be
+// created. It is checked before a version number is claimed so a rejected instance does not use up a number.
+func (s *Store) checkVersionsLimit(ctx context.Context, datasetID, edition string, logData log.Data) error {
+	if s.MaxVersions < 1 {
+		return nil
+	}
+
+	count, err := s.CountVersions(datasetID, edition, "")
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "confirm edition: store.CountVersions returned an error"), logData)
+		return err
+	}
+
+	if count >= s.MaxVersions {
+		logData["version_count"] = count
+		logData["max_versions"] = s.MaxVersions
+		log.ErrorCtx(ctx, errors.WithMessage(errs.ErrTooManyVersions, "confirm edition"), logData)
+		return errs.ErrTooManyVersions
+	}
+
+	return nil
 }
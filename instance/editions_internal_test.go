@@ -21,6 +21,9 @@ func Test_ConfirmEditionReturnsOK(t *testing.T) {
 			GetEditionFunc: func(dataset, edition, state string) (*models.EditionUpdate, error) {
 				return nil, errs.ErrEditionNotFound
 			},
+			ClaimNextVersionFunc: func(dataset, edition string) (int, error) {
+				return 1, nil
+			},
 			UpsertEditionFunc: func(dataset, edition string, doc *models.EditionUpdate) error {
 				return nil
 			},
@@ -38,7 +41,7 @@ func Test_ConfirmEditionReturnsOK(t *testing.T) {
 			editionName := "not-exist"
 			instanceID := "new-instance-1234"
 
-			edition, err := s.confirmEdition(ctx, datasetID, editionName, instanceID)
+			edition, _, err := s.confirmEdition(ctx, datasetID, editionName, instanceID)
 
 			Convey("then an edition is created and the version ID is 1", func() {
 				So(edition, ShouldNotBeNil)
@@ -108,7 +111,7 @@ func Test_ConfirmEditionReturnsOK(t *testing.T) {
 				editionName := "unpublished-only"
 				instanceID := "new-instance-1234"
 
-				_, err := s.confirmEdition(context.Background(), datasetID, editionName, instanceID)
+				_, _, err := s.confirmEdition(context.Background(), datasetID, editionName, instanceID)
 
 				Convey("then an internal server error is returned.", func() {
 					So(err, ShouldEqual, errs.ErrVersionAlreadyExists)
@@ -158,6 +161,12 @@ func Test_ConfirmEditionReturnsOK(t *testing.T) {
 					},
 				}, nil
 			},
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{}, nil
+			},
+			ClaimNextVersionFunc: func(dataset, edition string) (int, error) {
+				return 11, nil
+			},
 			UpsertEditionFunc: func(dataset, edition string, doc *models.EditionUpdate) error {
 				return nil
 			},
@@ -174,7 +183,7 @@ func Test_ConfirmEditionReturnsOK(t *testing.T) {
 			editionName := "published-data"
 			instanceID := "new-instance-1234"
 
-			edition, err := s.confirmEdition(ctx, datasetID, editionName, instanceID)
+			edition, _, err := s.confirmEdition(ctx, datasetID, editionName, instanceID)
 
 			Convey("then the edition is updated and the latest version ID is 11", func() {
 				So(err, ShouldBeNil)
@@ -189,6 +198,37 @@ func Test_ConfirmEditionReturnsOK(t *testing.T) {
 				So(edition.Next.Links, ShouldNotBeNil)
 				So(edition.Next.Links.LatestVersion, ShouldNotBeNil)
 				So(edition.Next.Links.LatestVersion.ID, ShouldEqual, "11")
+				So(edition.Next.Links.LatestVersion.HRef, ShouldEqual, "example.com/datasets/10/editions/published-data/versions/11")
+				So(len(mockedDataStore.ClaimNextVersionCalls()), ShouldEqual, 1)
+			})
+		})
+
+		Convey("when confirmEdition is called after another confirmation of the edition has claimed version 11", func() {
+			mockedDataStore.ClaimNextVersionFunc = func(dataset, edition string) (int, error) {
+				return 12, nil
+			}
+
+			edition, _, err := s.confirmEdition(ctx, "1234", "published-data", "new-instance-5678")
+
+			Convey("then the edition is updated with the next unclaimed version ID of 12", func() {
+				So(err, ShouldBeNil)
+				So(edition.Next.Links.LatestVersion.ID, ShouldEqual, "12")
+			})
+		})
+
+		Convey("when confirmEdition is called while the links of the dataset are frozen", func() {
+			mockedDataStore.GetDatasetFunc = func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{LinksFrozen: true}, nil
+			}
+
+			edition, versionLink, err := s.confirmEdition(ctx, "1234", "published-data", "new-instance-1234")
+
+			Convey("then version 11 is claimed but the latest version links of the edition are unchanged", func() {
+				So(err, ShouldBeNil)
+				So(versionLink, ShouldResemble, &models.LinkObject{ID: "11", HRef: "example.com/datasets/10/editions/published-data/versions/11"})
+				So(edition.Next.Links.LatestVersion.ID, ShouldEqual, "10")
+				So(edition.Current.Links.LatestVersion.ID, ShouldEqual, "10")
+				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 1)
 			})
 		})
 	})
@@ -213,7 +253,7 @@ func Test_ConfirmEditionReturnsError(t *testing.T) {
 			editionName := "failure"
 			instanceID := "new-instance-1234"
 
-			_, err := s.confirmEdition(ctx, datasetID, editionName, instanceID)
+			_, _, err := s.confirmEdition(ctx, datasetID, editionName, instanceID)
 
 			Convey("then an error is returned", func() {
 				So(err, ShouldNotBeNil)
@@ -241,6 +281,15 @@ func Test_ConfirmEditionReturnsError(t *testing.T) {
 					},
 				}, nil
 			},
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{}, nil
+			},
+			ClaimNextVersionFunc: func(dataset, edition string) (int, error) {
+				return 1, nil
+			},
+			ReleaseVersionFunc: func(dataset, edition string, version int) error {
+				return nil
+			},
 		}
 
 		host := "example.com"
@@ -255,11 +304,13 @@ func Test_ConfirmEditionReturnsError(t *testing.T) {
 			editionName := "failure"
 			instanceID := "new-instance-1234"
 
-			_, err := s.confirmEdition(ctx, datasetID, editionName, instanceID)
+			_, _, err := s.confirmEdition(ctx, datasetID, editionName, instanceID)
 
-			Convey("then updating links fails and an error is returned", func() {
+			Convey("then updating links fails, an error is returned and the claimed version number is released", func() {
 				So(err, ShouldNotBeNil)
 				So(err, ShouldResemble, models.ErrEditionLinksInvalid)
+				So(len(mockedDataStore.ReleaseVersionCalls()), ShouldEqual, 1)
+				So(mockedDataStore.ReleaseVersionCalls()[0].Version, ShouldEqual, 1)
 			})
 		})
 	})
@@ -305,9 +356,18 @@ func Test_ConfirmEditionReturnsError(t *testing.T) {
 					},
 				}, nil
 			},
+			GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+				return &models.DatasetUpdate{}, nil
+			},
+			ClaimNextVersionFunc: func(dataset, edition string) (int, error) {
+				return 2, nil
+			},
 			UpsertEditionFunc: func(dataset, edition string, doc *models.EditionUpdate) error {
 				return errs.ErrInternalServer
 			},
+			ReleaseVersionFunc: func(dataset, edition string, version int) error {
+				return nil
+			},
 		}
 
 		host := "example.com"
@@ -322,11 +382,102 @@ func Test_ConfirmEditionReturnsError(t *testing.T) {
 			editionName := "failure"
 			instanceID := "new-instance-1234"
 
-			_, err := s.confirmEdition(ctx, datasetID, editionName, instanceID)
+			_, _, err := s.confirmEdition(ctx, datasetID, editionName, instanceID)
 
-			Convey("then an error is returned", func() {
+			Convey("then an error is returned and the claimed version number is released", func() {
 				So(err, ShouldNotBeNil)
 				So(err, ShouldResemble, errs.ErrInternalServer)
+				So(len(mockedDataStore.ReleaseVersionCalls()), ShouldEqual, 1)
+				So(mockedDataStore.ReleaseVersionCalls()[0].DatasetID, ShouldEqual, datasetID)
+				So(mockedDataStore.ReleaseVersionCalls()[0].EditionID, ShouldEqual, editionName)
+				So(mockedDataStore.ReleaseVersionCalls()[0].Version, ShouldEqual, 2)
+			})
+		})
+	})
+
+	Convey("given a version number cannot be claimed for the edition", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetEditionFunc: func(dataset, edition, state string) (*models.EditionUpdate, error) {
+				return nil, errs.ErrEditionNotFound
+			},
+			ClaimNextVersionFunc: func(dataset, edition string) (int, error) {
+				return 0, errs.ErrInternalServer
+			},
+		}
+
+		s := Store{
+			Storer:  mockedDataStore,
+			Host:    "example.com",
+			Auditor: auditortest.New(),
+		}
+
+		Convey("when confirmEdition is called", func() {
+			_, _, err := s.confirmEdition(ctx, "1234", "failure", "new-instance-1234")
+
+			Convey("then an error is returned and the edition is not written", func() {
+				So(err, ShouldEqual, errs.ErrInternalServer)
+				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+	Convey("given the dataset already has the maximum number of editions", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetEditionFunc: func(dataset, edition, state string) (*models.EditionUpdate, error) {
+				return nil, errs.ErrEditionNotFound
+			},
+			CountEditionsFunc: func(dataset string) (int, error) {
+				return 3, nil
+			},
+		}
+
+		auditor := auditortest.New()
+		s := Store{
+			Storer:      mockedDataStore,
+			Host:        "example.com",
+			Auditor:     auditor,
+			MaxEditions: 3,
+		}
+
+		Convey("when confirmEdition is called for a new edition", func() {
+			_, _, err := s.confirmEdition(ctx, "1234", "one-too-many", "new-instance-1234")
+
+			Convey("then the edition is rejected before a version number is claimed or the edition written", func() {
+				So(err, ShouldEqual, errs.ErrTooManyEditions)
+				So(errs.BadRequestMap[err], ShouldBeTrue)
+				So(len(mockedDataStore.CountEditionsCalls()), ShouldEqual, 1)
+				So(mockedDataStore.CountEditionsCalls()[0].DatasetID, ShouldEqual, "1234")
+				So(len(mockedDataStore.ClaimNextVersionCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("given the edition already has the maximum number of versions", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetEditionFunc: func(dataset, edition, state string) (*models.EditionUpdate, error) {
+				return &models.EditionUpdate{Next: &models.Edition{Edition: edition}}, nil
+			},
+			CountVersionsFunc: func(dataset, edition, state string) (int, error) {
+				return 5, nil
+			},
+		}
+
+		s := Store{
+			Storer:      mockedDataStore,
+			Host:        "example.com",
+			Auditor:     auditortest.New(),
+			MaxVersions: 5,
+		}
+
+		Convey("when confirmEdition is called", func() {
+			_, _, err := s.confirmEdition(ctx, "1234", "2017", "new-instance-1234")
+
+			Convey("then the version is rejected before a version number is claimed", func() {
+				So(err, ShouldEqual, errs.ErrTooManyVersions)
+				So(len(mockedDataStore.CountVersionsCalls()), ShouldEqual, 1)
+				So(mockedDataStore.CountVersionsCalls()[0].State, ShouldEqual, "")
+				So(len(mockedDataStore.ClaimNextVersionCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 0)
 			})
 		})
 	})
@@ -1,12 +1,9 @@
 package instance
 
 import (
-	"encoding/json"
 	"io"
-	"io/ioutil"
 	"net/http"
 
-	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
 	"github.com/ONSdigital/dp-dataset-api/models"
 	"github.com/ONSdigital/go-ns/audit"
 	"github.com/ONSdigital/go-ns/common"
@@ -18,15 +15,10 @@ import (
 // AddInstanceEventAction represents the audit action to add event
 const AddInstanceEventAction = "addInstanceEvent"
 
-func unmarshalEvent(reader io.Reader) (*models.Event, error) {
-	b, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return nil, errs.ErrUnableToReadMessage
-	}
+func unmarshalEvent(reader io.Reader, limits models.JSONLimits) (*models.Event, error) {
 	var event models.Event
-	err = json.Unmarshal(b, &event)
-	if err != nil {
-		return nil, errs.ErrUnableToParseJSON
+	if err := models.DecodeJSON(reader, &event, limits); err != nil {
+		return nil, err
 	}
 	return &event, nil
 }
@@ -42,7 +34,7 @@ func (s *Store) AddEvent(w http.ResponseWriter, r *http.Request) {
 	ap := common.Params{"instance_id": instanceID}
 
 	if err := func() error {
-		event, err := unmarshalEvent(r.Body)
+		event, err := unmarshalEvent(r.Body, s.JSONLimits)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "add instance event: failed to unmarshal request body"), data)
 			return err
@@ -60,14 +52,14 @@ func (s *Store) AddEvent(w http.ResponseWriter, r *http.Request) {
 
 		return nil
 	}(); err != nil {
-		if auditErr := s.Auditor.Record(ctx, AddInstanceEventAction, audit.Unsuccessful, ap); auditErr != nil {
+		if auditErr := s.auditor().Record(ctx, AddInstanceEventAction, audit.Unsuccessful, ap); auditErr != nil {
 			err = auditErr
 		}
 		handleInstanceErr(ctx, err, w, data)
 		return
 	}
 
-	if auditErr := s.Auditor.Record(ctx, AddInstanceEventAction, audit.Successful, ap); auditErr != nil {
+	if auditErr := s.auditor().Record(ctx, AddInstanceEventAction, audit.Successful, ap); auditErr != nil {
 		handleInstanceErr(ctx, auditErr, w, data)
 		return
 	}
@@ -4,19 +4,20 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ONSdigital/dp-dataset-api/models"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 func TestCreateEventWithBadReader(t *testing.T) {
 	Convey("Create an event with an invalid reader", t, func() {
-		_, err := unmarshalEvent(Reader{})
+		_, err := unmarshalEvent(Reader{}, models.DefaultJSONLimits)
 		So(err, ShouldNotBeNil)
 	})
 }
 
 func TestCreateEventWithEmptyJson(t *testing.T) {
 	Convey("Create an event with empty json", t, func() {
-		event, err := unmarshalEvent(strings.NewReader("{ }"))
+		event, err := unmarshalEvent(strings.NewReader("{ }"), models.DefaultJSONLimits)
 		So(err, ShouldBeNil)
 		So(event.Validate(), ShouldNotBeNil)
 	})
@@ -24,7 +25,7 @@ func TestCreateEventWithEmptyJson(t *testing.T) {
 
 func TestEventInstance(t *testing.T) {
 	Convey("Create an event with the required fields", t, func() {
-		event, err := unmarshalEvent(strings.NewReader(`{"message": "321", "type": "error", "message_offset":"00", "time":"2017-08-25T15:09:11.829+01:00" }`))
+		event, err := unmarshalEvent(strings.NewReader(`{"message": "321", "type": "error", "message_offset":"00", "time":"2017-08-25T15:09:11.829+01:00" }`), models.DefaultJSONLimits)
 		So(err, ShouldBeNil)
 		So(event.Validate(), ShouldBeNil)
 	})
@@ -0,0 +1,116 @@
+package instance
+
+//go:generate moq -out ../mocks/hierarchy_trigger_mocks.go -pkg mocks . HierarchyBuildTrigger
+
+import (
+	"encoding/json"
+	"net/http"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/ONSdigital/go-ns/request"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// RebuildHierarchyAction represents the audit action to rebuild the hierarchy of a dimension of an instance
+const RebuildHierarchyAction = "rebuildHierarchy"
+
+// HierarchyBuildTrigger triggers the hierarchy of a dimension of an instance to be built from its code list
+type HierarchyBuildTrigger interface {
+	BuildHierarchy(instanceID, dimensionName, codeListID string) error
+}
+
+// RebuildHierarchy triggers the hierarchy builder to build the hierarchy of a dimension again and resets its build
+// hierarchy task back to created, so a hierarchy can be rebuilt after a fix without redoing the import. The hierarchies
+// of published instances can never be rebuilt.
+func (s *Store) RebuildHierarchy(w http.ResponseWriter, r *http.Request) {
+
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	instanceID := vars["instance_id"]
+	dimension := vars["dimension"]
+	auditParams := common.Params{"instance_id": instanceID, "dimension": dimension}
+	logData := audit.ToLogData(auditParams)
+
+	b, err := func() ([]byte, error) {
+		instance, err := s.GetInstance(instanceID)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "rebuild hierarchy: store.GetInstance returned an error"), logData)
+			return nil, err
+		}
+
+		if instance.State == models.PublishedState {
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrResourcePublished, "rebuild hierarchy: unable to rebuild a hierarchy of a published instance"), logData)
+			return nil, errs.ErrResourcePublished
+		}
+
+		task := findBuildHierarchyTask(instance, dimension)
+		if task == nil {
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrHierarchyTaskNotFound, "rebuild hierarchy: instance has no build hierarchy task for the dimension"), logData)
+			return nil, errs.ErrHierarchyTaskNotFound
+		}
+		logData["code_list_id"] = task.DimensionID
+
+		// the task is only reset once the build has been triggered, so a failed trigger leaves the task as it was
+		if err = s.HierarchyBuildTrigger.BuildHierarchy(instanceID, dimension, task.DimensionID); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "rebuild hierarchy: failed to trigger hierarchy build"), logData)
+			return nil, err
+		}
+
+		if err = s.UpdateBuildHierarchyTaskState(instanceID, dimension, models.CreatedState, ""); err != nil {
+			if err.Error() == errs.ErrNotFound.Error() {
+				err = errs.ErrHierarchyTaskNotFound
+			}
+			log.ErrorCtx(ctx, errors.WithMessage(err, "rebuild hierarchy: failed to reset build hierarchy task state"), logData)
+			return nil, err
+		}
+
+		task.State = models.CreatedState
+		task.Reason = ""
+
+		b, err := json.Marshal(task)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "rebuild hierarchy: failed to marshal build hierarchy task to json"), logData)
+			return nil, err
+		}
+
+		return b, nil
+	}()
+
+	if err != nil {
+		if auditErr := s.auditor().Record(ctx, RebuildHierarchyAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+
+		handleInstanceErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := s.auditor().Record(ctx, RebuildHierarchyAction, audit.Successful, auditParams); auditErr != nil {
+		handleInstanceErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	writeBody(ctx, w, b)
+	log.InfoCtx(ctx, "rebuild hierarchy: request successful", logData)
+}
+
+// findBuildHierarchyTask returns the build hierarchy task of the dimension of the instance, or nil if it has none
+func findBuildHierarchyTask(instance *models.Instance, dimension string) *models.BuildHierarchyTask {
+	if instance.ImportTasks == nil {
+		return nil
+	}
+
+	for _, task := range instance.ImportTasks.BuildHierarchyTasks {
+		if task != nil && task.DimensionName == dimension {
+			return task
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package instance_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/instance"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	storetest "github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_RebuildHierarchy(t *testing.T) {
+	t.Parallel()
+	Convey("Given an instance whose geography hierarchy failed to build", t, func() {
+		state := models.CompletedState
+		var triggerErr error
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{
+					InstanceID: "123",
+					State:      state,
+					ImportTasks: &models.InstanceImportTasks{
+						BuildHierarchyTasks: []*models.BuildHierarchyTask{
+							{DimensionID: "456", GenericTaskDetails: models.GenericTaskDetails{DimensionName: "geography", State: models.FailedState, Reason: "code list unavailable"}},
+						},
+					},
+				}, nil
+			},
+			UpdateBuildHierarchyTaskStateFunc: func(id, dimension, state, reason string) error {
+				return nil
+			},
+		}
+		trigger := &mocks.HierarchyBuildTriggerMock{
+			BuildHierarchyFunc: func(instanceID, dimensionName, codeListID string) error {
+				return triggerErr
+			},
+		}
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithHierarchyTrigger(mockedDataStore, &mocks.DownloadsGeneratorMock{}, trigger, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+
+		rebuild := func(dimension string) *httptest.ResponseRecorder {
+			r, err := createRequestWithToken("POST", "http://localhost:21800/instances/123/dimensions/"+dimension+"/rebuild-hierarchy", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			datasetAPI.Router.ServeHTTP(w, r)
+			return w
+		}
+
+		Convey("When the geography hierarchy is rebuilt", func() {
+			w := rebuild("geography")
+
+			Convey("Then the task is reset to created and the hierarchy build is triggered", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, `{"code_list_id":"456","dimension_name":"geography","state":"created"}`)
+
+				So(len(mockedDataStore.UpdateBuildHierarchyTaskStateCalls()), ShouldEqual, 1)
+				call := mockedDataStore.UpdateBuildHierarchyTaskStateCalls()[0]
+				So(call.ID, ShouldEqual, "123")
+				So(call.Dimension, ShouldEqual, "geography")
+				So(call.State, ShouldEqual, models.CreatedState)
+				So(call.Reason, ShouldBeEmpty)
+
+				So(len(trigger.BuildHierarchyCalls()), ShouldEqual, 1)
+				So(trigger.BuildHierarchyCalls()[0].InstanceID, ShouldEqual, "123")
+				So(trigger.BuildHierarchyCalls()[0].DimensionName, ShouldEqual, "geography")
+				So(trigger.BuildHierarchyCalls()[0].CodeListID, ShouldEqual, "456")
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{instance.RebuildHierarchyAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123", "dimension": "geography"}},
+					auditortest.Expected{instance.RebuildHierarchyAction, audit.Successful, common.Params{"instance_id": "123", "dimension": "geography"}},
+				)
+			})
+		})
+
+		Convey("When the hierarchy of a dimension without a build hierarchy task is rebuilt", func() {
+			w := rebuild("time")
+
+			Convey("Then a not found status is returned and no build is triggered", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrHierarchyTaskNotFound.Error())
+				So(len(mockedDataStore.UpdateBuildHierarchyTaskStateCalls()), ShouldEqual, 0)
+				So(len(trigger.BuildHierarchyCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the hierarchy build cannot be triggered", func() {
+			triggerErr = errors.New("kafka unavailable")
+			w := rebuild("geography")
+
+			Convey("Then an internal server error is returned and the task is left as it was", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+				So(len(trigger.BuildHierarchyCalls()), ShouldEqual, 1)
+				So(len(mockedDataStore.UpdateBuildHierarchyTaskStateCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the instance is published and its hierarchy is rebuilt", func() {
+			state = models.PublishedState
+			w := rebuild("geography")
+
+			Convey("Then a forbidden status is returned and no build is triggered", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(len(mockedDataStore.UpdateBuildHierarchyTaskStateCalls()), ShouldEqual, 0)
+				So(len(trigger.BuildHierarchyCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"strconv"
 
@@ -19,8 +18,21 @@ import (
 )
 
 // UpdateObservations increments the count of inserted_observations against
-// an instance
+// an instance, responding with the import observations task holding the updated total
 func (s *Store) UpdateObservations(w http.ResponseWriter, r *http.Request) {
+	s.addInsertedObservations(w, r, UpdateInsertedObservationsAction, s.UpdateObservationInserted)
+}
+
+// IncrementObservations atomically adds the number of observations an importer has inserted since it last reported
+// to the count of inserted_observations against an instance, responding with the import observations task holding
+// the updated total
+func (s *Store) IncrementObservations(w http.ResponseWriter, r *http.Request) {
+	s.addInsertedObservations(w, r, IncrementInsertedObservationsAction, s.UpdateObservationInserted)
+}
+
+// addInsertedObservations adds the inserted_observations request parameter to the stored count using the given
+// store method, responding with the total stored by the addition
+func (s *Store) addInsertedObservations(w http.ResponseWriter, r *http.Request, action string, add func(string, int64) (*models.ImportObservationsTask, error)) {
 	ctx := r.Context()
 	vars := mux.Vars(r)
 	instanceID := vars["instance_id"]
@@ -28,33 +40,45 @@ func (s *Store) UpdateObservations(w http.ResponseWriter, r *http.Request) {
 	auditParams := common.Params{"instance_id": instanceID, "inserted_observations": insert}
 	logData := audit.ToLogData(auditParams)
 
-	if err := func() error {
+	b, err := func() ([]byte, error) {
 		observations, err := strconv.ParseInt(insert, 10, 64)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "update imported observations: failed to parse inserted_observations string to int"), logData)
-			return errs.ErrInsertedObservationsInvalidSyntax
+			return nil, errs.ErrInsertedObservationsInvalidSyntax
 		}
 
-		if err = s.UpdateObservationInserted(instanceID, observations); err != nil {
-			log.ErrorCtx(ctx, errors.WithMessage(err, "update imported observations: store.UpdateObservationInserted returned an error"), logData)
-			return err
+		// the total stored includes the increments of other importers
+		task, err := add(instanceID, observations)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "update imported observations: failed to add to the stored count"), logData)
+			return nil, err
 		}
+		logData["total_inserted_observations"] = task.InsertedObservations
 
-		return nil
-	}(); err != nil {
-		if auditErr := s.Auditor.Record(ctx, UpdateInsertedObservationsAction, audit.Unsuccessful, auditParams); auditErr != nil {
+		b, err := json.Marshal(task)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "update imported observations: failed to marshal import observations task to json"), logData)
+			return nil, err
+		}
+		return b, nil
+	}()
+
+	if err != nil {
+		if auditErr := s.auditor().Record(ctx, action, audit.Unsuccessful, auditParams); auditErr != nil {
 			err = auditErr
 		}
 		handleInstanceErr(ctx, err, w, logData)
 		return
 	}
 
-	s.Auditor.Record(ctx, UpdateInsertedObservationsAction, audit.Successful, auditParams)
+	s.auditor().Record(ctx, action, audit.Successful, auditParams)
 
+	writeBody(ctx, w, b)
 	log.InfoCtx(ctx, "update imported observations: request successful", logData)
 }
 
-// UpdateImportTask updates any task in the request body against an instance
+// UpdateImportTask updates any task in the request body against an instance. Tasks can be completed, or stopped
+// by moving them to failed or cancelled along with the reason
 func (s *Store) UpdateImportTask(w http.ResponseWriter, r *http.Request) {
 
 	defer request.DrainBody(r)
@@ -67,7 +91,7 @@ func (s *Store) UpdateImportTask(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	updateErr := func() *taskError {
-		tasks, err := unmarshalImportTasks(r.Body)
+		tasks, err := unmarshalImportTasks(r.Body, s.JSONLimits)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "failed to unmarshal request body to UpdateImportTasks model"), logData)
 			return &taskError{err, http.StatusBadRequest}
@@ -79,10 +103,11 @@ func (s *Store) UpdateImportTask(w http.ResponseWriter, r *http.Request) {
 		if tasks.ImportObservations != nil {
 			hasImportTasks = true
 			if tasks.ImportObservations.State != "" {
-				if tasks.ImportObservations.State != models.CompletedState {
+				if !models.IsValidImportTaskState(tasks.ImportObservations.State) {
 					validationErrs = append(validationErrs, fmt.Errorf("bad request - invalid task state value for import observations: %v", tasks.ImportObservations.State))
 				} else {
-					if err := s.UpdateImportObservationsTaskState(instanceID, tasks.ImportObservations.State); err != nil {
+					auditTaskState(auditParams, "import_observations", tasks.ImportObservations.State, tasks.ImportObservations.Reason)
+					if err := s.UpdateImportObservationsTaskState(instanceID, tasks.ImportObservations.State, tasks.ImportObservations.Reason); err != nil {
 						log.ErrorCtx(ctx, errors.WithMessage(err, "Failed to update import observations task state"), logData)
 						return &taskError{err, http.StatusInternalServerError}
 					}
@@ -100,7 +125,8 @@ func (s *Store) UpdateImportTask(w http.ResponseWriter, r *http.Request) {
 				if err := models.ValidateImportTask(task.GenericTaskDetails); err != nil {
 					validationErrs = append(validationErrs, err)
 				} else {
-					if err := s.UpdateBuildHierarchyTaskState(instanceID, task.DimensionName, task.State); err != nil {
+					auditTaskState(auditParams, "build_hierarchies."+task.DimensionName, task.State, task.Reason)
+					if err := s.UpdateBuildHierarchyTaskState(instanceID, task.DimensionName, task.State, task.Reason); err != nil {
 						if err.Error() == errs.ErrNotFound.Error() {
 							notFoundErr := task.DimensionName + " hierarchy import task does not exist"
 							log.ErrorCtx(ctx, errors.WithMessage(err, notFoundErr), logData)
@@ -124,7 +150,8 @@ func (s *Store) UpdateImportTask(w http.ResponseWriter, r *http.Request) {
 				if err := models.ValidateImportTask(task.GenericTaskDetails); err != nil {
 					validationErrs = append(validationErrs, err)
 				} else {
-					if err := s.UpdateBuildSearchTaskState(instanceID, task.DimensionName, task.State); err != nil {
+					auditTaskState(auditParams, "build_search_indexes."+task.DimensionName, task.State, task.Reason)
+					if err := s.UpdateBuildSearchTaskState(instanceID, task.DimensionName, task.State, task.Reason); err != nil {
 						if err.Error() == "not found" {
 							notFoundErr := task.DimensionName + " search index import task does not exist"
 							log.ErrorCtx(ctx, errors.WithMessage(err, notFoundErr), logData)
@@ -155,30 +182,37 @@ func (s *Store) UpdateImportTask(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	if updateErr != nil {
-		if auditErr := s.Auditor.Record(ctx, UpdateImportTasksAction, audit.Unsuccessful, auditParams); auditErr != nil {
+		if auditErr := s.auditor().Record(ctx, UpdateImportTasksAction, audit.Unsuccessful, auditParams); auditErr != nil {
 			updateErr = &taskError{errs.ErrInternalServer, http.StatusInternalServerError}
 		}
 		log.ErrorCtx(ctx, errors.WithMessage(updateErr, "updateImportTask endpoint: request unsuccessful"), logData)
-		http.Error(w, updateErr.Error(), updateErr.status)
+		errs.WriteError(w, updateErr, updateErr.status)
 		return
 	}
 
-	if auditErr := s.Auditor.Record(ctx, UpdateImportTasksAction, audit.Successful, auditParams); auditErr != nil {
+	if auditErr := s.auditor().Record(ctx, UpdateImportTasksAction, audit.Successful, auditParams); auditErr != nil {
 		return
 	}
 
 	log.InfoCtx(ctx, "updateImportTask endpoint: request successful", logData)
 }
 
-func unmarshalImportTasks(reader io.Reader) (*models.InstanceImportTasks, error) {
+// auditTaskState adds the state of a task which has been failed or cancelled, and the reason given, to the audit
+// params. Completed tasks are not audited individually.
+func auditTaskState(auditParams common.Params, task, state, reason string) {
+	if state == models.CompletedState {
+		return
+	}
 
-	b, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return nil, errs.ErrUnableToReadMessage
+	auditParams[task] = state
+	if reason != "" {
+		auditParams[task+".reason"] = reason
 	}
+}
 
+func unmarshalImportTasks(reader io.Reader, limits models.JSONLimits) (*models.InstanceImportTasks, error) {
 	var tasks models.InstanceImportTasks
-	if err := json.Unmarshal(b, &tasks); err != nil {
+	if err := models.DecodeJSON(reader, &tasks, limits); err != nil {
 		return nil, err
 	}
 
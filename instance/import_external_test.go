@@ -28,12 +28,19 @@ func Test_InsertedObservationsReturnsOk(t *testing.T) {
 				So(err, ShouldBeNil)
 				w := httptest.NewRecorder()
 
+				var inserted int64 = 1000
 				mockedDataStore := &storetest.StorerMock{
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
-						return &models.Instance{State: models.EditionConfirmedState}, nil
+						return &models.Instance{
+							State: models.EditionConfirmedState,
+							ImportTasks: &models.InstanceImportTasks{
+								ImportObservations: &models.ImportObservationsTask{InsertedObservations: inserted},
+							},
+						}, nil
 					},
-					UpdateObservationInsertedFunc: func(id string, ob int64) error {
-						return nil
+					UpdateObservationInsertedFunc: func(id string, ob int64) (*models.ImportObservationsTask, error) {
+						inserted += ob
+						return &models.ImportObservationsTask{InsertedObservations: inserted}, nil
 					},
 				}
 
@@ -45,8 +52,11 @@ func Test_InsertedObservationsReturnsOk(t *testing.T) {
 				datasetAPI.Router.ServeHTTP(w, r)
 
 				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get("Content-Type"), ShouldEqual, "application/json")
+				So(w.Body.String(), ShouldEqual, `{"total_inserted_observations":1200}`)
 				So(datasetPermissions.Required.Calls, ShouldEqual, 0)
 				So(permissions.Required.Calls, ShouldEqual, 1)
+				// Gets called once to check the instance is not published, the total is returned by the update
 				So(len(mockedDataStore.GetInstanceCalls()), ShouldEqual, 1)
 				So(len(mockedDataStore.UpdateObservationInsertedCalls()), ShouldEqual, 1)
 
@@ -59,6 +69,68 @@ func Test_InsertedObservationsReturnsOk(t *testing.T) {
 	})
 }
 
+func Test_IncrementInsertedObservationsAccumulates(t *testing.T) {
+	t.Parallel()
+	Convey("Given an instance with inserted observations", t, func() {
+		var inserted int64 = 1000
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{
+					State: models.SubmittedState,
+					ImportTasks: &models.InstanceImportTasks{
+						ImportObservations: &models.ImportObservationsTask{InsertedObservations: inserted},
+					},
+				}, nil
+			},
+			UpdateObservationInsertedFunc: func(id string, observations int64) (*models.ImportObservationsTask, error) {
+				inserted += observations
+				return &models.ImportObservationsTask{InsertedObservations: inserted}, nil
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+
+		Convey("When two importers each post an increment", func() {
+			r, err := createRequestWithToken("POST", "http://localhost:21800/instances/123/inserted_observations/increment/200", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			datasetAPI.Router.ServeHTTP(w, r)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			r, err = createRequestWithToken("POST", "http://localhost:21800/instances/123/inserted_observations/increment/50", nil)
+			So(err, ShouldBeNil)
+			w = httptest.NewRecorder()
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then both increments are added to the stored total", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, `{"total_inserted_observations":1250}`)
+				So(len(mockedDataStore.UpdateObservationInsertedCalls()), ShouldEqual, 2)
+				So(mockedDataStore.UpdateObservationInsertedCalls()[1].ObservationInserted, ShouldEqual, 50)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{instance.IncrementInsertedObservationsAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk", "inserted_observations": "200", "instance_id": "123"}},
+					auditortest.Expected{instance.IncrementInsertedObservationsAction, audit.Successful, common.Params{"instance_id": "123", "inserted_observations": "200"}},
+					auditortest.Expected{instance.IncrementInsertedObservationsAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk", "inserted_observations": "50", "instance_id": "123"}},
+					auditortest.Expected{instance.IncrementInsertedObservationsAction, audit.Successful, common.Params{"instance_id": "123", "inserted_observations": "50"}},
+				)
+			})
+		})
+
+		Convey("When the increment is not an integer then a bad request status is returned", func() {
+			r, err := createRequestWithToken("POST", "http://localhost:21800/instances/123/inserted_observations/increment/ten", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrInsertedObservationsInvalidSyntax.Error())
+			So(len(mockedDataStore.UpdateObservationInsertedCalls()), ShouldEqual, 0)
+		})
+	})
+}
+
 func Test_InsertedObservationsReturnsError(t *testing.T) {
 	t.Parallel()
 	Convey("Given a PUT request to update an instance resource with inserted observations", t, func() {
@@ -107,8 +179,8 @@ func Test_InsertedObservationsReturnsError(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateObservationInsertedFunc: func(id string, ob int64) error {
-						return errs.ErrInstanceNotFound
+					UpdateObservationInsertedFunc: func(id string, ob int64) (*models.ImportObservationsTask, error) {
+						return nil, errs.ErrInstanceNotFound
 					},
 				}
 				datasetPermissions := mocks.NewAuthHandlerMock()
@@ -247,8 +319,8 @@ func Test_InsertedObservations_AuditFailure(t *testing.T) {
 				GetInstanceFunc: func(id string) (*models.Instance, error) {
 					return &models.Instance{State: models.CreatedState}, nil
 				},
-				UpdateObservationInsertedFunc: func(id string, observations int64) error {
-					return nil
+				UpdateObservationInsertedFunc: func(id string, observations int64) (*models.ImportObservationsTask, error) {
+					return &models.ImportObservationsTask{InsertedObservations: observations}, nil
 				},
 			}
 
@@ -291,7 +363,7 @@ func Test_UpdateImportTask_UpdateImportObservationsReturnsOk(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.CreatedState}, nil
 					},
-					UpdateImportObservationsTaskStateFunc: func(id string, state string) error {
+					UpdateImportObservationsTaskStateFunc: func(id string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -453,7 +525,7 @@ func Test_UpdateImportTask_UpdateImportObservationsReturnsError(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateImportObservationsTaskStateFunc: func(id string, state string) error {
+					UpdateImportObservationsTaskStateFunc: func(id string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -466,7 +538,7 @@ func Test_UpdateImportTask_UpdateImportObservationsReturnsError(t *testing.T) {
 				datasetAPI.Router.ServeHTTP(w, r)
 
 				So(w.Code, ShouldEqual, http.StatusBadRequest)
-				So(w.Body.String(), ShouldContainSubstring, "unexpected end of JSON input")
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrUnableToParseJSON.Error())
 
 				So(datasetPermissions.Required.Calls, ShouldEqual, 0)
 				So(permissions.Required.Calls, ShouldEqual, 1)
@@ -493,7 +565,7 @@ func Test_UpdateImportTask_UpdateImportObservationsReturnsError(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateImportObservationsTaskStateFunc: func(id string, state string) error {
+					UpdateImportObservationsTaskStateFunc: func(id string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -532,7 +604,7 @@ func Test_UpdateImportTask_UpdateImportObservationsReturnsError(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateImportObservationsTaskStateFunc: func(id string, state string) error {
+					UpdateImportObservationsTaskStateFunc: func(id string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -570,7 +642,7 @@ func Test_UpdateImportTask_UpdateImportObservationsReturnsError(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateImportObservationsTaskStateFunc: func(id string, state string) error {
+					UpdateImportObservationsTaskStateFunc: func(id string, state string, reason string) error {
 						return errs.ErrInternalServer
 					},
 				}
@@ -616,7 +688,7 @@ func Test_UpdateImportTask_BuildHierarchyTaskReturnsError(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -629,7 +701,7 @@ func Test_UpdateImportTask_BuildHierarchyTaskReturnsError(t *testing.T) {
 				datasetAPI.Router.ServeHTTP(w, r)
 
 				So(w.Code, ShouldEqual, http.StatusBadRequest)
-				So(w.Body.String(), ShouldContainSubstring, "unexpected end of JSON input")
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrUnableToParseJSON.Error())
 
 				So(datasetPermissions.Required.Calls, ShouldEqual, 0)
 				So(permissions.Required.Calls, ShouldEqual, 1)
@@ -656,7 +728,7 @@ func Test_UpdateImportTask_BuildHierarchyTaskReturnsError(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -697,7 +769,7 @@ func Test_UpdateImportTask_BuildHierarchyTaskReturnsError(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -737,7 +809,7 @@ func Test_UpdateImportTask_BuildHierarchyTaskReturnsError(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -777,7 +849,7 @@ func Test_UpdateImportTask_BuildHierarchyTaskReturnsError(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -817,7 +889,7 @@ func Test_UpdateImportTask_BuildHierarchyTaskReturnsError(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -857,7 +929,7 @@ func Test_UpdateImportTask_BuildHierarchyTaskReturnsError(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return errors.New("not found")
 					},
 				}
@@ -897,7 +969,7 @@ func Test_UpdateImportTask_BuildHierarchyTaskReturnsError(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return errors.New("internal error")
 					},
 				}
@@ -943,7 +1015,7 @@ func Test_UpdateImportTask_BuildHierarchyTaskReturnsOk(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.EditionConfirmedState}, nil
 					},
-					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -971,6 +1043,54 @@ func Test_UpdateImportTask_BuildHierarchyTaskReturnsOk(t *testing.T) {
 	})
 }
 
+func Test_UpdateImportTask_FailedTaskReturnsOk(t *testing.T) {
+	t.Parallel()
+	Convey("Given a PUT request to mark import tasks of a stuck import as failed", t, func() {
+		body := strings.NewReader(`{"import_observations":{"state":"failed","reason":"observation importer stopped responding"},"build_hierarchies":[{"state":"cancelled","dimension_name":"geography"}]}`)
+		r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123/import_tasks", body)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{State: models.SubmittedState}, nil
+			},
+			UpdateImportObservationsTaskStateFunc: func(id string, state string, reason string) error {
+				return nil
+			},
+			UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string, reason string) error {
+				return nil
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then the tasks are stopped and the reason is recorded", func() {
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			So(len(mockedDataStore.UpdateImportObservationsTaskStateCalls()), ShouldEqual, 1)
+			So(mockedDataStore.UpdateImportObservationsTaskStateCalls()[0].State, ShouldEqual, models.FailedState)
+			So(mockedDataStore.UpdateImportObservationsTaskStateCalls()[0].Reason, ShouldEqual, "observation importer stopped responding")
+
+			So(len(mockedDataStore.UpdateBuildHierarchyTaskStateCalls()), ShouldEqual, 1)
+			So(mockedDataStore.UpdateBuildHierarchyTaskStateCalls()[0].State, ShouldEqual, models.CancelledState)
+			So(mockedDataStore.UpdateBuildHierarchyTaskStateCalls()[0].Reason, ShouldBeEmpty)
+
+			auditor.AssertRecordCalls(
+				auditortest.NewExpectation(instance.UpdateImportTasksAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123"}),
+				auditortest.NewExpectation(instance.UpdateImportTasksAction, audit.Successful, common.Params{
+					"instance_id":                 "123",
+					"import_observations":         models.FailedState,
+					"import_observations.reason":  "observation importer stopped responding",
+					"build_hierarchies.geography": models.CancelledState,
+				}),
+			)
+		})
+	})
+}
+
 func Test_UpdateImportTask_UpdateBuildSearchIndexTask_Failure(t *testing.T) {
 	auditParams := common.Params{"instance_id": "123"}
 	auditParamsWithCallerIdentity := common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123"}
@@ -988,7 +1108,7 @@ func Test_UpdateImportTask_UpdateBuildSearchIndexTask_Failure(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.CreatedState}, nil
 					},
-					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -1001,7 +1121,7 @@ func Test_UpdateImportTask_UpdateBuildSearchIndexTask_Failure(t *testing.T) {
 				datasetAPI.Router.ServeHTTP(w, r)
 
 				So(w.Code, ShouldEqual, http.StatusBadRequest)
-				So(w.Body.String(), ShouldContainSubstring, "unexpected end of JSON input")
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrUnableToParseJSON.Error())
 
 				So(datasetPermissions.Required.Calls, ShouldEqual, 0)
 				So(permissions.Required.Calls, ShouldEqual, 1)
@@ -1028,7 +1148,7 @@ func Test_UpdateImportTask_UpdateBuildSearchIndexTask_Failure(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.CreatedState}, nil
 					},
-					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -1068,7 +1188,7 @@ func Test_UpdateImportTask_UpdateBuildSearchIndexTask_Failure(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.CreatedState}, nil
 					},
-					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -1107,7 +1227,7 @@ func Test_UpdateImportTask_UpdateBuildSearchIndexTask_Failure(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.CreatedState}, nil
 					},
-					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -1147,7 +1267,7 @@ func Test_UpdateImportTask_UpdateBuildSearchIndexTask_Failure(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.CreatedState}, nil
 					},
-					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -1187,7 +1307,7 @@ func Test_UpdateImportTask_UpdateBuildSearchIndexTask_Failure(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.CreatedState}, nil
 					},
-					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -1227,7 +1347,7 @@ func Test_UpdateImportTask_UpdateBuildSearchIndexTask_Failure(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.CreatedState}, nil
 					},
-					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return errors.New("not found")
 					},
 				}
@@ -1266,7 +1386,7 @@ func Test_UpdateImportTask_UpdateBuildSearchIndexTask_Failure(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.CreatedState}, nil
 					},
-					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return errors.New("internal error")
 					},
 				}
@@ -1312,7 +1432,7 @@ func Test_UpdateImportTask_UpdateBuildSearchIndexReturnsOk(t *testing.T) {
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return &models.Instance{State: models.CreatedState}, nil
 					},
-					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string) error {
+					UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 						return nil
 					},
 				}
@@ -1434,7 +1554,7 @@ func Test_UpdateImportTask_AuditUnsuccessfulError(t *testing.T) {
 				GetInstanceFunc: func(id string) (*models.Instance, error) {
 					return &models.Instance{State: models.CreatedState}, nil
 				},
-				UpdateImportObservationsTaskStateFunc: func(id string, state string) error {
+				UpdateImportObservationsTaskStateFunc: func(id string, state string, reason string) error {
 					return errors.New("error")
 				},
 			}
@@ -1474,7 +1594,7 @@ func Test_UpdateImportTask_AuditUnsuccessfulError(t *testing.T) {
 				GetInstanceFunc: func(id string) (*models.Instance, error) {
 					return &models.Instance{State: models.CreatedState}, nil
 				},
-				UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string) error {
+				UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 					return errors.New("error")
 				},
 			}
@@ -1513,7 +1633,7 @@ func Test_UpdateImportTask_AuditUnsuccessfulError(t *testing.T) {
 				GetInstanceFunc: func(id string) (*models.Instance, error) {
 					return &models.Instance{State: models.CreatedState}, nil
 				},
-				UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string) error {
+				UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 					return errors.New("error")
 				},
 			}
@@ -1558,7 +1678,7 @@ func Test_UpdateImportTask_AuditSuccessfulError(t *testing.T) {
 				GetInstanceFunc: func(id string) (*models.Instance, error) {
 					return &models.Instance{State: models.CreatedState}, nil
 				},
-				UpdateImportObservationsTaskStateFunc: func(id string, state string) error {
+				UpdateImportObservationsTaskStateFunc: func(id string, state string, reason string) error {
 					return nil
 				},
 			}
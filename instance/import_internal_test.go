@@ -4,12 +4,14 @@ import (
 	"strings"
 	"testing"
 
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 func TestUnmarshalImportTaskWithBadReader(t *testing.T) {
 	Convey("Create an import task with an invalid reader", t, func() {
-		task, err := unmarshalImportTasks(Reader{})
+		task, err := unmarshalImportTasks(Reader{}, models.DefaultJSONLimits)
 		So(task, ShouldBeNil)
 		So(err.Error(), ShouldEqual, "failed to read message body")
 	})
@@ -17,24 +19,24 @@ func TestUnmarshalImportTaskWithBadReader(t *testing.T) {
 
 func TestUnmarshalImportTaskWithInvalidJson(t *testing.T) {
 	Convey("Create an import observation task with invalid json", t, func() {
-		task, err := unmarshalImportTasks(strings.NewReader("{ "))
+		task, err := unmarshalImportTasks(strings.NewReader("{ "), models.DefaultJSONLimits)
 		So(task, ShouldBeNil)
-		So(err.Error(), ShouldContainSubstring, "unexpected end of JSON input")
+		So(err, ShouldEqual, errs.ErrUnableToParseJSON)
 	})
 }
 
 func TestUnmarshalImportTaskWithInvalidData(t *testing.T) {
 	Convey("Create an import observation task with correctly named fields of the wrong type", t, func() {
-		task, err := unmarshalImportTasks(strings.NewReader(`{"build_hierarchies": "this should fail"}`))
+		task, err := unmarshalImportTasks(strings.NewReader(`{"build_hierarchies": "this should fail"}`), models.DefaultJSONLimits)
 		So(task, ShouldBeNil)
 		So(err, ShouldNotBeNil)
-		So(err.Error(), ShouldContainSubstring, "json: cannot unmarshal string into Go struct field InstanceImportTasks.build_hierarchies of type []*models.BuildHierarchyTask")
+		So(err, ShouldEqual, errs.ErrUnableToParseJSON)
 	})
 }
 
 func TestUnmarshalImportTask_ImportObservations(t *testing.T) {
 	Convey("Create an import observation task with valid json", t, func() {
-		task, err := unmarshalImportTasks(strings.NewReader(`{"import_observations":{"state":"completed"}}`))
+		task, err := unmarshalImportTasks(strings.NewReader(`{"import_observations":{"state":"completed"}}`), models.DefaultJSONLimits)
 		So(err, ShouldBeNil)
 		So(task, ShouldNotBeNil)
 		So(task.ImportObservations, ShouldNotBeNil)
@@ -44,7 +46,7 @@ func TestUnmarshalImportTask_ImportObservations(t *testing.T) {
 
 func TestUnmarshalImportTask_BuildHierarchies(t *testing.T) {
 	Convey("Create an import observation task with valid json", t, func() {
-		task, err := unmarshalImportTasks(strings.NewReader(`{"build_hierarchies":[{"state":"completed"}]}`))
+		task, err := unmarshalImportTasks(strings.NewReader(`{"build_hierarchies":[{"state":"completed"}]}`), models.DefaultJSONLimits)
 		So(err, ShouldBeNil)
 		So(task, ShouldNotBeNil)
 		So(task.BuildHierarchyTasks, ShouldNotBeNil)
@@ -54,7 +56,7 @@ func TestUnmarshalImportTask_BuildHierarchies(t *testing.T) {
 
 func TestUnmarshalImportTask_BuildSearch(t *testing.T) {
 	Convey("Create an import observation task with valid json", t, func() {
-		task, err := unmarshalImportTasks(strings.NewReader(`{"build_search_indexes":[{"state":"completed"}]}`))
+		task, err := unmarshalImportTasks(strings.NewReader(`{"build_search_indexes":[{"state":"completed"}]}`), models.DefaultJSONLimits)
 		So(err, ShouldBeNil)
 		So(task, ShouldNotBeNil)
 		So(task.BuildSearchIndexTasks, ShouldNotBeNil)
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
 	"github.com/ONSdigital/dp-dataset-api/models"
@@ -22,12 +24,56 @@ import (
 	"github.com/satori/go.uuid"
 )
 
-//Store provides a backend for instances
+//Store provides a backend for instances. MaxEditions and MaxVersions cap the editions of a dataset and the versions
+// of an edition confirming an instance can create, guarding against an importer creating them without end, 0 leaves
+// them uncapped.
 type Store struct {
 	store.Storer
-	Host                string
-	Auditor             audit.AuditorService
-	EnableDetachDataset bool
+	Host                    string
+	Auditor                 audit.AuditorService
+	EnableDetachDataset     bool
+	NormaliseDimensionNames bool
+	ProgressInterval        time.Duration
+	HierarchyBuildTrigger   HierarchyBuildTrigger
+	DownloadServiceToken    string
+	DownloadURLSigner       DownloadURLSigner
+	DownloadURLExpiry       time.Duration
+	JSONLimits              models.JSONLimits
+	MaxEditions             int
+	MaxVersions             int
+	PublishDenylist         []string
+	RejectPastReleaseDates  bool
+}
+
+// nopAuditor stands in for an auditor which has not been set, so actions go unaudited rather than the handler
+// panicking
+var nopAuditor audit.AuditorService = &audit.NopAuditor{}
+
+// auditor returns the auditor of the store, or one which records nothing when none has been set
+func (s *Store) auditor() audit.AuditorService {
+	if s.Auditor == nil {
+		return nopAuditor
+	}
+	return s.Auditor
+}
+
+// checkPublishAllowed returns an error if the dataset is on the publish denylist
+func (s *Store) checkPublishAllowed(datasetID string) error {
+	for _, denied := range s.PublishDenylist {
+		if denied == datasetID {
+			return errs.ErrDatasetPublishDenied
+		}
+	}
+	return nil
+}
+
+// checkPublishReleaseDate returns an error if the instance is being published with a release date before today, when
+// past release dates are rejected
+func (s *Store) checkPublishReleaseDate(releaseDate string) error {
+	if !s.RejectPastReleaseDates {
+		return nil
+	}
+	return models.ValidateReleaseDateNotPast(releaseDate, time.Now())
 }
 
 type taskError struct {
@@ -44,43 +90,80 @@ func (e taskError) Error() string {
 
 // List of audit actions for instances
 const (
-	AddInstanceAction                = "addInstance"
-	CreateEditionAction              = "createEditionForInstance"
-	GetInstanceAction                = "getInstance"
-	GetInstancesAction               = "getInstances"
-	UpdateInstanceAction             = "updateInstance"
-	UpdateDimensionAction            = "updateDimension"
-	UpdateEditionAction              = "updateEditionNextSubDocForInstance"
-	UpdateInsertedObservationsAction = "updateInsertedObservations"
-	UpdateImportTasksAction          = "updateImportTasks"
+	AddInstanceAction                   = "addInstance"
+	CreateEditionAction                 = "createEditionForInstance"
+	GetInstanceAction                   = "getInstance"
+	GetInstancesAction                  = "getInstances"
+	IncrementInsertedObservationsAction = "incrementInsertedObservations"
+	UpdateInstanceAction                = "updateInstance"
+	UpdateDimensionAction               = "updateDimension"
+	UpdateEditionAction                 = "updateEditionNextSubDocForInstance"
+	UpdateInsertedObservationsAction    = "updateInsertedObservations"
+	UpdateImportTasksAction             = "updateImportTasks"
 )
 
+// idempotencyKeyHeader is the header a client sets so that retrying instance creation does not create duplicates
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// returnChanges is the value of the return query parameter which has an instance update respond with only the
+// fields the update changed rather than the updated instance
+const returnChanges = "changes"
+
+// ndjsonContentType is the media type of a list of instances written as newline delimited json, one per line
+const ndjsonContentType = "application/x-ndjson"
+
+// downloadServiceTokenHeader is the header the download service authenticates with to be given the private links of
+// downloads
+const downloadServiceTokenHeader = "X-Download-Service-Token"
+
+// DownloadURLSigner signs the download links of unpublished instances, so a link can only be used until it expires
+type DownloadURLSigner interface {
+	Sign(href string, expires time.Time) (string, error)
+}
+
 //GetList a list of all instances
 func (s *Store) GetList(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logData := log.Data{}
 	stateFilterQuery := r.URL.Query().Get("state")
 	datasetFilterQuery := r.URL.Query().Get("dataset")
+	isBasedOnFilterQuery := r.URL.Query().Get("is_based_on")
 	var auditParams common.Params
 	var stateFilterList []string
 	var datasetFilterList []string
+	var isBasedOnFilterList []string
 
-	if stateFilterQuery != "" || datasetFilterQuery != "" {
+	if stateFilterQuery != "" || datasetFilterQuery != "" || isBasedOnFilterQuery != "" {
 		auditParams = make(common.Params)
 	}
 
 	if stateFilterQuery != "" {
 		logData["state_query"] = stateFilterQuery
 		auditParams["state_query"] = stateFilterQuery
-		stateFilterList = strings.Split(stateFilterQuery, ",")
+		stateFilterList = splitFilterQuery(stateFilterQuery)
 	}
 
 	if datasetFilterQuery != "" {
 		logData["dataset_query"] = datasetFilterQuery
 		auditParams["dataset_query"] = datasetFilterQuery
-		datasetFilterList = strings.Split(datasetFilterQuery, ",")
+		datasetFilterList = splitFilterQuery(datasetFilterQuery)
 	}
 
+	if isBasedOnFilterQuery != "" {
+		logData["is_based_on_query"] = isBasedOnFilterQuery
+		auditParams["is_based_on_query"] = isBasedOnFilterQuery
+		isBasedOnFilterList = splitFilterQuery(isBasedOnFilterQuery)
+	}
+
+	view := s.newInstanceView(r)
+	logData["trim"] = view.trim
+	logData["verbose"] = view.verbose
+
+	// streaming consumers can ask for one instance per line, rather than waiting for the full list to be read
+	streamed := 0
+	ndjson := acceptsNDJSON(r)
+	logData["ndjson"] = ndjson
+
 	log.InfoCtx(ctx, "get list of instances", logData)
 
 	b, err := func() ([]byte, error) {
@@ -91,13 +174,31 @@ func (s *Store) GetList(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		results, err := s.GetInstances(stateFilterList, datasetFilterList)
+		if ndjson {
+			var err error
+			streamed, err = s.streamInstances(w, view, stateFilterList, datasetFilterList, isBasedOnFilterList)
+			if err != nil {
+				logData["streamed"] = streamed
+				log.ErrorCtx(ctx, errors.WithMessage(err, "get instances: failed to stream instances"), logData)
+			}
+			return nil, err
+		}
+
+		results, err := s.GetInstances(stateFilterList, datasetFilterList, isBasedOnFilterList)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "get instances: store.GetInstances returned and error"), nil)
 			return nil, err
 		}
 
-		b, err := json.Marshal(results)
+		items := make([]interface{}, len(results.Items))
+		for i := range results.Items {
+			if items[i], err = view.body(&results.Items[i]); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "get instances: failed to sign download links of instance"), nil)
+				return nil, err
+			}
+		}
+
+		b, err := json.Marshal(instanceList{Items: items})
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "get instances: failed to marshal results to json"), nil)
 			return nil, err
@@ -105,16 +206,29 @@ func (s *Store) GetList(w http.ResponseWriter, r *http.Request) {
 		return b, nil
 	}()
 
+	// once an instance has been streamed the response has started, so a later error cannot change its status
 	if err != nil {
-		if auditErr := s.Auditor.Record(ctx, GetInstancesAction, audit.Unsuccessful, auditParams); auditErr != nil {
+		if auditErr := s.auditor().Record(ctx, GetInstancesAction, audit.Unsuccessful, auditParams); auditErr != nil {
 			err = auditErr
 		}
-		handleInstanceErr(ctx, err, w, logData)
+		if streamed == 0 {
+			handleInstanceErr(ctx, err, w, logData)
+		}
 		return
 	}
 
-	if auditErr := s.Auditor.Record(ctx, GetInstancesAction, audit.Successful, auditParams); auditErr != nil {
-		handleInstanceErr(ctx, auditErr, w, logData)
+	if auditErr := s.auditor().Record(ctx, GetInstancesAction, audit.Successful, auditParams); auditErr != nil {
+		if streamed == 0 {
+			handleInstanceErr(ctx, auditErr, w, logData)
+		}
+		return
+	}
+
+	if ndjson {
+		if streamed == 0 {
+			w.Header().Set("Content-Type", ndjsonContentType)
+		}
+		log.InfoCtx(ctx, "get instances: request successful", logData)
 		return
 	}
 
@@ -122,6 +236,47 @@ func (s *Store) GetList(w http.ResponseWriter, r *http.Request) {
 	log.InfoCtx(ctx, "get instances: request successful", logData)
 }
 
+// acceptsNDJSON reports whether the request asks for instances as newline delimited json
+func acceptsNDJSON(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(accept, ";", 2)[0])
+		if strings.EqualFold(mediaType, ndjsonContentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// streamInstances writes each instance matching the filters to the response as a line of json as it is read from
+// the store, flushing after each so consumers can read the instances as they arrive. The number of instances written
+// is returned.
+func (s *Store) streamInstances(w http.ResponseWriter, view instanceView, states, datasets, isBasedOn []string) (int, error) {
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	written := 0
+	err := s.StreamInstances(states, datasets, isBasedOn, func(instance *models.Instance) error {
+		if written == 0 {
+			w.Header().Set("Content-Type", ndjsonContentType)
+		}
+		body, err := view.body(instance)
+		if err != nil {
+			return err
+		}
+		if err := encoder.Encode(body); err != nil {
+			return err
+		}
+		written++
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+
+	return written, err
+}
+
 //Get a single instance by id
 func (s *Store) Get(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -130,6 +285,10 @@ func (s *Store) Get(w http.ResponseWriter, r *http.Request) {
 	auditParams := common.Params{"instance_id": instanceID}
 	logData := audit.ToLogData(auditParams)
 
+	view := s.newInstanceView(r)
+	logData["trim"] = view.trim
+	logData["verbose"] = view.verbose
+
 	log.InfoCtx(ctx, "get instance", logData)
 
 	b, err := func() ([]byte, error) {
@@ -147,8 +306,14 @@ func (s *Store) Get(w http.ResponseWriter, r *http.Request) {
 			return nil, err
 		}
 
+		body, err := view.body(instance)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "get instance: failed to sign download links of instance"), logData)
+			return nil, err
+		}
+
 		log.InfoCtx(ctx, "instance get: marshalling instance json", logData)
-		b, err := json.Marshal(instance)
+		b, err := json.Marshal(body)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "get instance: failed to marshal instance to json"), logData)
 			return nil, err
@@ -159,14 +324,14 @@ func (s *Store) Get(w http.ResponseWriter, r *http.Request) {
 
 	log.InfoCtx(ctx, "instance get: auditing outcome", logData)
 	if err != nil {
-		if auditErr := s.Auditor.Record(ctx, GetInstanceAction, audit.Unsuccessful, auditParams); auditErr != nil {
+		if auditErr := s.auditor().Record(ctx, GetInstanceAction, audit.Unsuccessful, auditParams); auditErr != nil {
 			err = auditErr
 		}
 		handleInstanceErr(ctx, err, w, logData)
 		return
 	}
 
-	if auditErr := s.Auditor.Record(ctx, GetInstanceAction, audit.Successful, auditParams); auditErr != nil {
+	if auditErr := s.auditor().Record(ctx, GetInstanceAction, audit.Successful, auditParams); auditErr != nil {
 		handleInstanceErr(ctx, auditErr, w, logData)
 		return
 	}
@@ -186,8 +351,21 @@ func (s *Store) Add(w http.ResponseWriter, r *http.Request) {
 
 	log.InfoCtx(ctx, "add instance", logData)
 
+	// a repeated request with the same idempotency key returns the instance the
+	// first request created, rather than creating a duplicate
+	idempotencyKey := r.Header.Get(idempotencyKeyHeader)
+	status := http.StatusCreated
+
 	b, err := func() ([]byte, error) {
-		instance, err := unmarshalInstance(ctx, r.Body, true)
+		if idempotencyKey != "" {
+			logData["idempotency_key"] = idempotencyKey
+			if existing, err := s.getInstanceByIdempotencyKey(ctx, idempotencyKey, logData); err != nil || existing != nil {
+				status = http.StatusOK
+				return existing, err
+			}
+		}
+
+		instance, err := unmarshalInstance(ctx, r.Body, true, s.JSONLimits)
 		if err != nil {
 			return nil, err
 		}
@@ -195,12 +373,33 @@ func (s *Store) Add(w http.ResponseWriter, r *http.Request) {
 		logData["instance_id"] = instance.InstanceID
 		auditParams["instance_id"] = instance.InstanceID
 
+		if err = models.NormaliseDimensions(instance.Dimensions, s.NormaliseDimensionNames); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "add instance: dimension name invalid"), logData)
+			return nil, err
+		}
+
+		if instance.Headers != nil {
+			if err = models.ValidateHeaders(*instance.Headers); err != nil {
+				logData["headers"] = *instance.Headers
+				log.ErrorCtx(ctx, errors.WithMessage(err, "add instance: headers invalid"), logData)
+				return nil, err
+			}
+		}
+
 		instance.Links.Self = &models.LinkObject{
 			HRef: fmt.Sprintf("%s/instances/%s", s.Host, instance.InstanceID),
 		}
+		instance.IdempotencyKey = idempotencyKey
 
 		instance, err = s.AddInstance(instance)
 		if err != nil {
+			// a concurrent request with the same key may have created the instance first
+			if idempotencyKey != "" {
+				if existing, lookupErr := s.getInstanceByIdempotencyKey(ctx, idempotencyKey, logData); lookupErr == nil && existing != nil {
+					status = http.StatusOK
+					return existing, nil
+				}
+			}
 			log.ErrorCtx(ctx, errors.WithMessage(err, "add instance: store.AddInstance returned an error"), logData)
 			return nil, err
 		}
@@ -214,23 +413,47 @@ func (s *Store) Add(w http.ResponseWriter, r *http.Request) {
 		return b, nil
 	}()
 	if err != nil {
-		if auditErr := s.Auditor.Record(ctx, AddInstanceAction, audit.Unsuccessful, auditParams); auditErr != nil {
+		if auditErr := s.auditor().Record(ctx, AddInstanceAction, audit.Unsuccessful, auditParams); auditErr != nil {
 			err = auditErr
 		}
 		handleInstanceErr(ctx, err, w, logData)
 		return
 	}
 
-	s.Auditor.Record(ctx, AddInstanceAction, audit.Successful, auditParams)
+	s.auditor().Record(ctx, AddInstanceAction, audit.Successful, auditParams)
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(status)
 	writeBody(ctx, w, b)
 
 	log.InfoCtx(ctx, "add instance: request successful", logData)
 }
 
-//Update a specific instance
+// getInstanceByIdempotencyKey returns the marshalled instance created with the idempotency key,
+// or nil if no instance has been created with it yet
+func (s *Store) getInstanceByIdempotencyKey(ctx context.Context, key string, logData log.Data) ([]byte, error) {
+	instance, err := s.GetInstanceByIdempotencyKey(key)
+	if err == errs.ErrInstanceNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "add instance: store.GetInstanceByIdempotencyKey returned an error"), logData)
+		return nil, err
+	}
+
+	logData["instance_id"] = instance.InstanceID
+	log.InfoCtx(ctx, "add instance: returning the instance already created with the idempotency key", logData)
+
+	b, err := json.Marshal(instance)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "add instance: failed to marshal instance to json"), logData)
+		return nil, err
+	}
+	return b, nil
+}
+
+// Update a specific instance, responding with the updated instance or, when return=changes is given, only the fields
+// the update changed
 func (s *Store) Update(w http.ResponseWriter, r *http.Request) {
 
 	defer request.DrainBody(r)
@@ -244,7 +467,14 @@ func (s *Store) Update(w http.ResponseWriter, r *http.Request) {
 	var err error
 
 	if b, err = func() ([]byte, error) {
-		instance, err := unmarshalInstance(ctx, r.Body, false)
+		returnQuery := r.URL.Query().Get("return")
+		if returnQuery != "" && returnQuery != returnChanges {
+			logData["return"] = returnQuery
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrInvalidReturn, "instance update: invalid return parameter"), logData)
+			return nil, errs.ErrInvalidReturn
+		}
+
+		instance, err := unmarshalInstance(ctx, r.Body, false, s.JSONLimits)
 		if err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "instance update: failed unmarshalling json to model"), logData)
 			return nil, taskError{error: err, status: 400}
@@ -254,6 +484,19 @@ func (s *Store) Update(w http.ResponseWriter, r *http.Request) {
 			return nil, taskError{error: err, status: 400}
 		}
 
+		if err = models.NormaliseDimensions(instance.Dimensions, s.NormaliseDimensionNames); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "instance update: dimension name invalid"), logData)
+			return nil, taskError{error: err, status: http.StatusBadRequest}
+		}
+
+		if instance.Headers != nil {
+			if err = models.ValidateHeaders(*instance.Headers); err != nil {
+				logData["headers"] = *instance.Headers
+				log.ErrorCtx(ctx, errors.WithMessage(err, "instance update: headers invalid"), logData)
+				return nil, taskError{error: err, status: http.StatusBadRequest}
+			}
+		}
+
 		// Get the current document
 		currentInstance, err := s.GetInstance(instanceID)
 		if err != nil {
@@ -261,17 +504,83 @@ func (s *Store) Update(w http.ResponseWriter, r *http.Request) {
 			return nil, err
 		}
 
+		if err = checkUnmodifiedSince(r, currentInstance); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "instance update: instance modified since the client last read it"), logData)
+			return nil, taskError{error: err, status: http.StatusPreconditionFailed}
+		}
+
+		// the instance is marshalled before it is updated, as confirming the edition changes its links in place
+		var previous []byte
+		if returnQuery == returnChanges {
+			if previous, err = json.Marshal(currentInstance); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "instance update: failed to marshal current instance to json"), logData)
+				return nil, err
+			}
+		}
+
 		logData["current_state"] = currentInstance.State
 		logData["requested_state"] = instance.State
-		if instance.State != "" && instance.State != currentInstance.State {
-			if err = validateInstanceStateUpdate(instance, currentInstance); err != nil {
-				log.ErrorCtx(ctx, errors.Errorf("instance update: instance state invalid"), logData)
+		if err = models.ValidateStateTransition(currentInstance.State, instance.State); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "instance update: instance state invalid"), logData)
+			return nil, err
+		}
+
+		// a failed instance can only be reset, so nothing other than its state may be sent
+		if currentInstance.State == models.FailedState && hasUpdates(instance) {
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrInstanceFailed, "instance update: failed instance cannot be edited"), logData)
+			return nil, errs.ErrInstanceFailed
+		}
+
+		if instance.FailureReason != "" && instance.State != models.FailedState {
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrInstanceFailureReasonInvalid, "instance update: failure reason given without moving to failed"), logData)
+			return nil, errs.ErrInstanceFailureReasonInvalid
+		}
+
+		if instance.State == models.FailedState && currentInstance.State != models.FailedState && strings.TrimSpace(instance.FailureReason) == "" {
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrInstanceFailureReasonMissing, "instance update: no reason given for the failure"), logData)
+			return nil, errs.ErrInstanceFailureReasonMissing
+		}
+
+		// the instance becomes a version when its edition is confirmed, so it must have the release date every
+		// version needs before the edition is confirmed rather than failing validation when the version is updated
+		if instance.State == models.EditionConfirmedState && currentInstance.State != models.EditionConfirmedState {
+			releaseDate := instance.ReleaseDate
+			if releaseDate == "" {
+				releaseDate = currentInstance.ReleaseDate
+			}
+
+			if err = models.ValidateInstanceReleaseDate(releaseDate); err != nil {
+				logData["release_date"] = releaseDate
+				log.ErrorCtx(ctx, errors.WithMessage(err, "instance update: release date invalid"), logData)
 				return nil, err
 			}
 		}
 
 		datasetID := currentInstance.Links.Dataset.ID
 
+		// set once the edition is confirmed, so the confirmation can be undone if the instance is not then updated
+		var confirmedEdition string
+		var confirmedVersion int
+
+		// publishing through the instance must be refused for the same datasets as publishing through the version
+		if instance.State == models.PublishedState && currentInstance.State != models.PublishedState {
+			if err = s.checkPublishAllowed(datasetID); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "instance update: dataset may not be published"), logData)
+				return nil, err
+			}
+
+			releaseDate := instance.ReleaseDate
+			if releaseDate == "" {
+				releaseDate = currentInstance.ReleaseDate
+			}
+
+			if err = s.checkPublishReleaseDate(releaseDate); err != nil {
+				logData["release_date"] = releaseDate
+				log.ErrorCtx(ctx, errors.WithMessage(err, "instance update: release date invalid for publishing"), logData)
+				return nil, err
+			}
+		}
+
 		//edition confirmation is a one time process - cannot be editted for an instance once done
 		if instance.State == models.EditionConfirmedState && instance.Version == 0 {
 			if instance.Edition == "" {
@@ -281,7 +590,7 @@ func (s *Store) Update(w http.ResponseWriter, r *http.Request) {
 			edition := instance.Edition
 			editionLogData := log.Data{"instance_id": instanceID, "dataset_id": datasetID, "edition": edition}
 
-			editionDoc, editionConfirmErr := s.confirmEdition(ctx, datasetID, edition, instanceID)
+			editionDoc, versionLink, editionConfirmErr := s.confirmEdition(ctx, datasetID, edition, instanceID)
 			if editionConfirmErr != nil {
 				log.ErrorCtx(ctx, errors.WithMessage(editionConfirmErr, "instance update: store.getEdition returned an error"), editionLogData)
 				return nil, editionConfirmErr
@@ -294,15 +603,33 @@ func (s *Store) Update(w http.ResponseWriter, r *http.Request) {
 				HRef: editionDoc.Next.Links.Self.HRef,
 			}
 
-			instance.Links.Version = editionDoc.Next.Links.LatestVersion
-			instance.Version, editionConfirmErr = strconv.Atoi(editionDoc.Next.Links.LatestVersion.ID)
+			instance.Links.Version = versionLink
+			instance.Version, editionConfirmErr = strconv.Atoi(versionLink.ID)
 			if editionConfirmErr != nil {
 				log.ErrorCtx(ctx, errors.WithMessage(editionConfirmErr, "instance update: failed to convert edition latestVersion id to instance.version int"), editionLogData)
 				return nil, editionConfirmErr
 			}
+			confirmedEdition, confirmedVersion = edition, instance.Version
+
+			headers := currentInstance.Headers
+			if instance.Headers != nil {
+				headers = instance.Headers
+			}
+
+			// record which observation metadata columns the version has, so clients need not parse its headers
+			if headers != nil {
+				hasDataMarkings, hasConfidenceIntervals, headersErr := models.HeaderMetadataColumns(*headers)
+				if headersErr != nil {
+					log.ErrorCtx(ctx, errors.WithMessage(headersErr, "instance update: unable to read metadata columns from headers"), editionLogData)
+				} else {
+					instance.HasDataMarkings = &hasDataMarkings
+					instance.HasConfidenceIntervals = &hasConfidenceIntervals
+				}
+			}
 
 			if versionErr := s.AddVersionDetailsToInstance(ctx, currentInstance.InstanceID, datasetID, edition, instance.Version); versionErr != nil {
 				log.ErrorCtx(ctx, errors.WithMessage(versionErr, "instance update: datastore.AddVersionDetailsToInstance returned an error"), editionLogData)
+				s.abandonEdition(ctx, datasetID, confirmedEdition, confirmedVersion, editionLogData)
 				return nil, versionErr
 			}
 
@@ -313,6 +640,9 @@ func (s *Store) Update(w http.ResponseWriter, r *http.Request) {
 		instance.UniqueTimestamp = currentInstance.UniqueTimestamp
 		if err = s.UpdateInstance(ctx, instanceID, instance); err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "instance update: store.UpdateInstance returned an error"), logData)
+			if confirmedVersion > 0 {
+				s.abandonEdition(ctx, datasetID, confirmedEdition, confirmedVersion, logData)
+			}
 			return nil, err
 		}
 
@@ -327,9 +657,16 @@ func (s *Store) Update(w http.ResponseWriter, r *http.Request) {
 			return nil, err
 		}
 
+		if returnQuery == returnChanges {
+			if b, err = models.DiffJSONFields(previous, b); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "instance update: failed to find the changed fields of the instance"), logData)
+				return nil, err
+			}
+		}
+
 		return b, nil
 	}(); err != nil {
-		if auditErr := s.Auditor.Record(ctx, UpdateInstanceAction, audit.Unsuccessful, auditParams); auditErr != nil {
+		if auditErr := s.auditor().Record(ctx, UpdateInstanceAction, audit.Unsuccessful, auditParams); auditErr != nil {
 			err = auditErr
 		}
 
@@ -341,11 +678,114 @@ func (s *Store) Update(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	writeBody(ctx, w, b)
 
-	s.Auditor.Record(ctx, UpdateInstanceAction, audit.Successful, auditParams)
+	s.auditor().Record(ctx, UpdateInstanceAction, audit.Successful, auditParams)
 
 	log.InfoCtx(ctx, "instance update: request successful", logData)
 }
 
+// checkUnmodifiedSince honours the If-Unmodified-Since header, returning an error
+// if the instance has been updated after the time given. A missing or invalid
+// header is ignored, as described in RFC 7232
+func checkUnmodifiedSince(r *http.Request, currentInstance *models.Instance) error {
+	header := r.Header.Get("If-Unmodified-Since")
+	if header == "" {
+		return nil
+	}
+
+	unmodifiedSince, err := http.ParseTime(header)
+	if err != nil {
+		log.InfoCtx(r.Context(), "instance update: ignoring invalid If-Unmodified-Since header", log.Data{"header": header})
+		return nil
+	}
+
+	// HTTP dates have a resolution of one second
+	if currentInstance.LastUpdated.Truncate(time.Second).After(unmodifiedSince) {
+		return errs.ErrInstanceModified
+	}
+
+	return nil
+}
+
+// splitFilterQuery splits a comma separated filter query into its values, trimming each value and dropping any
+// which are empty or repeated. The values are sorted so the same filter always results in the same query.
+func splitFilterQuery(query string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, value := range strings.Split(query, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		values = append(values, value)
+	}
+
+	sort.Strings(values)
+	return values
+}
+
+// instanceView is how instances are written in a response. By default they are written in full, a caller can ask for
+// the trimmed view without the import diagnostics, and import services can ask for the verbose view which adds the
+// internal fields of the instance. Only the download service is given the private links of downloads, and the
+// download links of unpublished instances are signed as they are for versions.
+type instanceView struct {
+	trim             bool
+	verbose          bool
+	privateDownloads bool
+	sign             func(href string) (string, error)
+}
+
+// newInstanceView returns the view of instances asked for by the request, users asking for the verbose view get
+// the default view
+func (s *Store) newInstanceView(r *http.Request) instanceView {
+	query := r.URL.Query()
+	view := instanceView{
+		trim:             query.Get("trim") == "true",
+		verbose:          query.Get("verbose") == "true" && isServiceCaller(r.Context()),
+		privateDownloads: s.DownloadServiceToken != "" && r.Header.Get(downloadServiceTokenHeader) == s.DownloadServiceToken,
+	}
+
+	if s.DownloadURLSigner != nil {
+		expires := time.Now().Add(s.DownloadURLExpiry)
+		view.sign = func(href string) (string, error) {
+			return s.DownloadURLSigner.Sign(href, expires)
+		}
+	}
+	return view
+}
+
+// body returns the instance as it is written in the response
+func (v instanceView) body(instance *models.Instance) (interface{}, error) {
+	if !v.privateDownloads {
+		instance.Downloads.RemovePrivateLinks()
+	}
+	if v.sign != nil && instance.State != models.PublishedState {
+		if err := instance.Downloads.Sign(v.sign); err != nil {
+			return nil, err
+		}
+	}
+
+	if v.trim {
+		instance.Trim()
+	}
+	if v.verbose {
+		return models.NewVerboseInstance(instance), nil
+	}
+	return instance, nil
+}
+
+// instanceList is a list of instances each written in the view asked for, in the same shape as
+// models.InstanceResults
+type instanceList struct {
+	Items []interface{} `json:"items"`
+}
+
+// isServiceCaller reports whether the request was made by a service rather than a user, services authenticating
+// with a service token so having a caller identity but no user identity
+func isServiceCaller(ctx context.Context) bool {
+	return common.IsCallerPresent(ctx) && !common.IsUserPresent(ctx)
+}
+
 func validateInstanceUpdate(instance *models.Instance) error {
 	var fieldsUnableToUpdate []string
 	if instance.Links != nil {
@@ -392,53 +832,17 @@ func validateInstanceUpdate(instance *models.Instance) error {
 	return nil
 }
 
-func validateInstanceStateUpdate(instance, currentInstance *models.Instance) (err error) {
-	if instance.State != "" && instance.State != currentInstance.State {
-		switch instance.State {
-		case models.SubmittedState:
-			if currentInstance.State != models.CreatedState {
-				return errs.ErrExpectedResourceStateOfCreated
-			}
-			break
-		case models.CompletedState:
-			if currentInstance.State != models.SubmittedState {
-				return errs.ErrExpectedResourceStateOfSubmitted
-			}
-			break
-		case models.EditionConfirmedState:
-			if currentInstance.State != models.CompletedState {
-				return errs.ErrExpectedResourceStateOfCompleted
-			}
-			break
-		case models.AssociatedState:
-			if currentInstance.State != models.EditionConfirmedState {
-				return errs.ErrExpectedResourceStateOfEditionConfirmed
-			}
-			break
-		case models.PublishedState:
-			if currentInstance.State != models.AssociatedState {
-				return errs.ErrExpectedResourceStateOfAssociated
-			}
-			break
-		default:
-			err = errors.New("instance resource has an invalid state")
-			return err
-		}
-	}
-
-	return nil
+// hasUpdates reports whether an update sets any field of the instance other than its state
+func hasUpdates(instance *models.Instance) bool {
+	fields := *instance
+	fields.State = ""
+	return !reflect.DeepEqual(fields, models.Instance{})
 }
 
-func unmarshalInstance(ctx context.Context, reader io.Reader, post bool) (*models.Instance, error) {
-	b, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return nil, errs.ErrUnableToReadMessage
-	}
-
+func unmarshalInstance(ctx context.Context, reader io.Reader, post bool, limits models.JSONLimits) (*models.Instance, error) {
 	var instance models.Instance
-	err = json.Unmarshal(b, &instance)
-	if err != nil {
-		return nil, errs.ErrUnableToParseJSON
+	if err := models.DecodeJSON(reader, &instance, limits); err != nil {
+		return nil, err
 	}
 
 	if instance.State != "" {
@@ -474,6 +878,11 @@ func unmarshalInstance(ctx context.Context, reader io.Reader, post bool) (*model
 		// for an id only of the dataset and build the href here or vice versa
 		// expect an href and strip the job id from the href?
 
+		// instances derived from a parent dataset, such as filter outputs, link to it by id
+		if instance.Links.IsBasedOn != nil && instance.Links.IsBasedOn.ID == "" {
+			return nil, errs.ErrIsBasedOnLinkInvalid
+		}
+
 		if instance.State == "" {
 			instance.State = models.CreatedState
 		}
@@ -484,14 +893,14 @@ func unmarshalInstance(ctx context.Context, reader io.Reader, post bool) (*model
 
 func internalError(ctx context.Context, w http.ResponseWriter, err error) {
 	log.ErrorCtx(ctx, err, nil)
-	http.Error(w, err.Error(), http.StatusInternalServerError)
+	errs.WriteError(w, err, http.StatusInternalServerError)
 }
 
 func writeBody(ctx context.Context, w http.ResponseWriter, b []byte) {
 	w.Header().Set("Content-Type", "application/json")
 	if _, err := w.Write(b); err != nil {
 		log.ErrorCtx(ctx, err, nil)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		errs.WriteError(w, err, http.StatusInternalServerError)
 	}
 }
 
@@ -501,6 +910,14 @@ type PublishCheck struct {
 	Auditor   audit.AuditorService
 }
 
+// auditor returns the auditor of the check, or one which records nothing when none has been set
+func (d *PublishCheck) auditor() audit.AuditorService {
+	if d.Auditor == nil {
+		return nopAuditor
+	}
+	return d.Auditor
+}
+
 // Check wraps a HTTP handle. Checks that the state is not published
 func (d *PublishCheck) Check(handle func(http.ResponseWriter, *http.Request), action string) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -516,7 +933,7 @@ func (d *PublishCheck) Check(handle func(http.ResponseWriter, *http.Request), ac
 
 		if err := d.checkState(instanceID, logData, auditParams); err != nil {
 			log.ErrorCtx(ctx, errors.WithMessage(err, "errored whilst checking instance state"), logData)
-			if auditErr := d.Auditor.Record(ctx, action, audit.Unsuccessful, auditParams); auditErr != nil {
+			if auditErr := d.auditor().Record(ctx, action, audit.Unsuccessful, auditParams); auditErr != nil {
 				handleInstanceErr(ctx, errs.ErrAuditActionAttemptedFailure, w, logData)
 				return
 			}
@@ -575,5 +992,5 @@ func handleInstanceErr(ctx context.Context, err error, w http.ResponseWriter, lo
 
 	logData["responseStatus"] = status
 	log.ErrorCtx(ctx, errors.WithMessage(err, "request unsuccessful"), logData)
-	http.Error(w, response.Error(), status)
+	errs.WriteError(w, response, status)
 }
@@ -26,7 +26,7 @@ func Test_UpdateInstanceToEditionConfirmedReturnsOk(t *testing.T) {
 	Convey("Given a PUT request to update an instance resource", t, func() {
 		Convey("When the requested state change is to 'edition-confirmed'", func() {
 			Convey("Then return status ok (200)", func() {
-				body := strings.NewReader(`{"state":"edition-confirmed", "edition": "2017"}`)
+				body := strings.NewReader(`{"state":"edition-confirmed", "edition": "2017", "release_date": "2017-12-12"}`)
 				r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", body)
 				So(err, ShouldBeNil)
 				w := httptest.NewRecorder()
@@ -59,7 +59,13 @@ func Test_UpdateInstanceToEditionConfirmedReturnsOk(t *testing.T) {
 					UpsertEditionFunc: func(datasetID, edition string, editionDoc *models.EditionUpdate) error {
 						return nil
 					},
-					GetNextVersionFunc: func(string, string) (int, error) {
+					CountEditionsFunc: func(string) (int, error) {
+						return 0, nil
+					},
+					CountVersionsFunc: func(string, string, string) (int, error) {
+						return 0, nil
+					},
+					ClaimNextVersionFunc: func(string, string) (int, error) {
 						return 1, nil
 					},
 					UpdateInstanceFunc: func(ctx context.Context, id string, i *models.Instance) error {
@@ -82,7 +88,7 @@ func Test_UpdateInstanceToEditionConfirmedReturnsOk(t *testing.T) {
 				So(len(mockedDataStore.GetInstanceCalls()), ShouldEqual, 3)
 				So(len(mockedDataStore.GetEditionCalls()), ShouldEqual, 1)
 				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 1)
-				//	So(len(mockedDataStore.GetNextVersionCalls()), ShouldEqual, 1)
+				So(len(mockedDataStore.ClaimNextVersionCalls()), ShouldEqual, 1)
 				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 1)
 				So(len(mockedDataStore.AddVersionDetailsToInstanceCalls()), ShouldEqual, 1)
 
@@ -97,6 +103,110 @@ func Test_UpdateInstanceToEditionConfirmedReturnsOk(t *testing.T) {
 	})
 }
 
+func Test_UpdateInstanceToEditionConfirmedRecordsMetadataColumns(t *testing.T) {
+	Convey("Given an instance whose headers include data markings but not confidence intervals", t, func() {
+		headers := []string{"V4_1", "data_marking", "time_codelist", "time"}
+		currentInstance := &models.Instance{
+			Edition: "2017",
+			Headers: &headers,
+			Links: &models.InstanceLinks{
+				Job:     &models.LinkObject{ID: "7654", HRef: "job-link"},
+				Dataset: &models.LinkObject{ID: "4567", HRef: "dataset-link"},
+				Self:    &models.LinkObject{HRef: "self-link"},
+			},
+			State: models.CompletedState,
+		}
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return currentInstance, nil
+			},
+			GetEditionFunc: func(datasetID string, edition string, state string) (*models.EditionUpdate, error) {
+				return nil, errs.ErrEditionNotFound
+			},
+			CountEditionsFunc: func(string) (int, error) {
+				return 0, nil
+			},
+			CountVersionsFunc: func(string, string, string) (int, error) {
+				return 0, nil
+			},
+			ClaimNextVersionFunc: func(string, string) (int, error) {
+				return 1, nil
+			},
+			UpsertEditionFunc: func(datasetID, edition string, editionDoc *models.EditionUpdate) error {
+				return nil
+			},
+			UpdateInstanceFunc: func(ctx context.Context, id string, i *models.Instance) error {
+				return nil
+			},
+			AddVersionDetailsToInstanceFunc: func(ctx context.Context, instanceID string, datasetID string, edition string, version int) error {
+				return nil
+			},
+		}
+
+		Convey("When the instance is updated to 'edition-confirmed'", func() {
+			body := strings.NewReader(`{"state":"edition-confirmed", "edition": "2017", "release_date": "2017-12-12"}`)
+			r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", body)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then the version is stored with the metadata columns derived from its headers", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 1)
+
+				updated := mockedDataStore.UpdateInstanceCalls()[0].Instance
+				So(*updated.HasDataMarkings, ShouldBeTrue)
+				So(*updated.HasConfidenceIntervals, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func Test_UpdateInstanceToEditionConfirmedRequiresReleaseDate(t *testing.T) {
+	t.Parallel()
+	update := func(body string) (*httptest.ResponseRecorder, *storetest.StorerMock) {
+		r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", strings.NewReader(body))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{
+					Edition: "2017",
+					Links:   &models.InstanceLinks{Dataset: &models.LinkObject{ID: "4567", HRef: "dataset-link"}},
+					State:   models.CompletedState,
+				}, nil
+			},
+		}
+
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		return w, mockedDataStore
+	}
+
+	Convey("When an instance is moved to edition-confirmed without a release date then a bad request is returned", t, func() {
+		w, mockedDataStore := update(`{"state":"edition-confirmed", "edition": "2017"}`)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrInstanceReleaseDateMissing.Error())
+		So(len(mockedDataStore.GetEditionCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+	})
+
+	Convey("When an instance is moved to edition-confirmed with a release date which is not a date then a bad request is returned", t, func() {
+		w, mockedDataStore := update(`{"state":"edition-confirmed", "edition": "2017", "release_date": "next tuesday"}`)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Body.String(), ShouldContainSubstring, errs.ErrInstanceReleaseDateInvalid.Error())
+		So(len(mockedDataStore.GetEditionCalls()), ShouldEqual, 0)
+		So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+	})
+}
+
 func Test_UpdateInstanceToEditionConfirmedReturnsError(t *testing.T) {
 	auditParams := common.Params{"instance_id": "123"}
 	auditParamsWithCallerIdentity := common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123"}
@@ -108,7 +218,7 @@ func Test_UpdateInstanceToEditionConfirmedReturnsError(t *testing.T) {
 		Convey(`When request updates state to 'edition-confirmed'
         but fails to update instance with version details`, func() {
 			Convey("Then return status internal server error (500)", func() {
-				body := strings.NewReader(`{"state":"edition-confirmed", "edition": "2017"}`)
+				body := strings.NewReader(`{"state":"edition-confirmed", "edition": "2017", "release_date": "2017-12-12"}`)
 				r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", body)
 				So(err, ShouldBeNil)
 				w := httptest.NewRecorder()
@@ -131,17 +241,28 @@ func Test_UpdateInstanceToEditionConfirmedReturnsError(t *testing.T) {
 					State: models.CompletedState,
 				}
 
+				var upserted *models.EditionUpdate
 				mockedDataStore := &storetest.StorerMock{
 					GetInstanceFunc: func(id string) (*models.Instance, error) {
 						return currentInstanceTest_Data, nil
 					},
 					GetEditionFunc: func(datasetID string, edition string, state string) (*models.EditionUpdate, error) {
-						return nil, errs.ErrEditionNotFound
+						if upserted == nil {
+							return nil, errs.ErrEditionNotFound
+						}
+						return upserted, nil
 					},
 					UpsertEditionFunc: func(datasetID, edition string, editionDoc *models.EditionUpdate) error {
+						upserted = editionDoc
 						return nil
 					},
-					GetNextVersionFunc: func(string, string) (int, error) {
+					CountEditionsFunc: func(string) (int, error) {
+						return 0, nil
+					},
+					CountVersionsFunc: func(string, string, string) (int, error) {
+						return 0, nil
+					},
+					ClaimNextVersionFunc: func(string, string) (int, error) {
 						return 1, nil
 					},
 					UpdateInstanceFunc: func(ctx context.Context, id string, i *models.Instance) error {
@@ -150,6 +271,15 @@ func Test_UpdateInstanceToEditionConfirmedReturnsError(t *testing.T) {
 					AddVersionDetailsToInstanceFunc: func(ctx context.Context, instanceID string, datasetID string, edition string, version int) error {
 						return errors.New("boom")
 					},
+					GetLatestVersionFunc: func(string, string, string) (*models.Version, error) {
+						return nil, errs.ErrVersionNotFound
+					},
+					DeleteEditionFunc: func(string) error {
+						return nil
+					},
+					ReleaseVersionFunc: func(string, string, int) error {
+						return nil
+					},
 				}
 
 				datasetPermissions := mocks.NewAuthHandlerMock()
@@ -163,11 +293,16 @@ func Test_UpdateInstanceToEditionConfirmedReturnsError(t *testing.T) {
 				So(datasetPermissions.Required.Calls, ShouldEqual, 0)
 				So(permissions.Required.Calls, ShouldEqual, 1)
 				So(len(mockedDataStore.GetInstanceCalls()), ShouldEqual, 2)
-				So(len(mockedDataStore.GetEditionCalls()), ShouldEqual, 1)
+				So(len(mockedDataStore.GetEditionCalls()), ShouldEqual, 2)
 				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 1)
 				So(len(mockedDataStore.AddVersionDetailsToInstanceCalls()), ShouldEqual, 1)
 				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
 
+				// the edition created for the version is removed and its number released
+				So(len(mockedDataStore.DeleteEditionCalls()), ShouldEqual, 1)
+				So(len(mockedDataStore.ReleaseVersionCalls()), ShouldEqual, 1)
+				So(mockedDataStore.ReleaseVersionCalls()[0].Version, ShouldEqual, 1)
+
 				auditor.AssertRecordCalls(
 					auditortest.Expected{instance.UpdateInstanceAction, audit.Attempted, auditParamsWithCallerIdentity},
 					auditortest.Expected{instance.CreateEditionAction, audit.Attempted, editionAuditParams},
@@ -227,7 +362,7 @@ func Test_UpdateInstanceToEditionConfirmedReturnsError(t *testing.T) {
 
 		Convey(`When the requested state change is to 'edition-confirmed' and attempt to audit request to create edition fails`, func() {
 			Convey("Then return status internal server error (500)", func() {
-				body := strings.NewReader(`{"state":"edition-confirmed", "edition": "2017"}`)
+				body := strings.NewReader(`{"state":"edition-confirmed", "edition": "2017", "release_date": "2017-12-12"}`)
 				r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", body)
 				So(err, ShouldBeNil)
 				w := httptest.NewRecorder()
@@ -289,7 +424,7 @@ func Test_UpdateInstanceToEditionConfirmedReturnsError(t *testing.T) {
 		Convey(`When the requested state changes to 'associated' and the edition is updated
 			but unable to update instance and then the auditor attempts an unsuccessful message and fails`, func() {
 			Convey("Then return status internal server error (500)", func() {
-				body := strings.NewReader(`{"state":"edition-confirmed", "edition": "2017"}`)
+				body := strings.NewReader(`{"state":"edition-confirmed", "edition": "2017", "release_date": "2017-12-12"}`)
 				r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", body)
 				So(err, ShouldBeNil)
 				w := httptest.NewRecorder()
@@ -355,7 +490,16 @@ func Test_UpdateInstanceToEditionConfirmedReturnsError(t *testing.T) {
 					UpsertEditionFunc: func(datasetID, edition string, editionDoc *models.EditionUpdate) error {
 						return nil
 					},
-					GetNextVersionFunc: func(string, string) (int, error) {
+					CountEditionsFunc: func(string) (int, error) {
+						return 0, nil
+					},
+					CountVersionsFunc: func(string, string, string) (int, error) {
+						return 0, nil
+					},
+					GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+						return &models.DatasetUpdate{}, nil
+					},
+					ClaimNextVersionFunc: func(string, string) (int, error) {
 						return 2, nil
 					},
 					UpdateInstanceFunc: func(ctx context.Context, id string, i *models.Instance) error {
@@ -364,6 +508,9 @@ func Test_UpdateInstanceToEditionConfirmedReturnsError(t *testing.T) {
 					AddVersionDetailsToInstanceFunc: func(ctx context.Context, instanceID string, datasetID string, edition string, version int) error {
 						return nil
 					},
+					ReleaseVersionFunc: func(string, string, int) error {
+						return nil
+					},
 				}
 
 				datasetPermissions := mocks.NewAuthHandlerMock()
@@ -379,11 +526,15 @@ func Test_UpdateInstanceToEditionConfirmedReturnsError(t *testing.T) {
 				So(datasetPermissions.Required.Calls, ShouldEqual, 0)
 				So(permissions.Required.Calls, ShouldEqual, 1)
 				So(len(mockedDataStore.GetInstanceCalls()), ShouldEqual, 2)
-				So(len(mockedDataStore.GetEditionCalls()), ShouldEqual, 1)
+				So(len(mockedDataStore.GetEditionCalls()), ShouldEqual, 2)
 				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 1)
 				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 1)
 				So(len(mockedDataStore.AddVersionDetailsToInstanceCalls()), ShouldEqual, 1)
 
+				// the version number claimed for the instance is released as the instance was not updated
+				So(len(mockedDataStore.ReleaseVersionCalls()), ShouldEqual, 1)
+				So(mockedDataStore.ReleaseVersionCalls()[0].Version, ShouldEqual, 2)
+
 				auditor.AssertRecordCalls(
 					auditortest.Expected{instance.UpdateInstanceAction, audit.Attempted, auditParamsWithCallerIdentity},
 					auditortest.Expected{instance.UpdateEditionAction, audit.Attempted, editionAuditParams},
@@ -396,7 +547,7 @@ func Test_UpdateInstanceToEditionConfirmedReturnsError(t *testing.T) {
 		Convey(`When the requested state changes to 'associated' and the edition
 			 is updated, yet the auditor fails is unsuccessful in writing success message`, func() {
 			Convey("Then return status internal server error (500)", func() {
-				body := strings.NewReader(`{"state":"edition-confirmed", "edition": "2017"}`)
+				body := strings.NewReader(`{"state":"edition-confirmed", "edition": "2017", "release_date": "2017-12-12"}`)
 				r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", body)
 				So(err, ShouldBeNil)
 				w := httptest.NewRecorder()
@@ -457,8 +608,17 @@ func Test_UpdateInstanceToEditionConfirmedReturnsError(t *testing.T) {
 					UpsertEditionFunc: func(datasetID, edition string, editionDoc *models.EditionUpdate) error {
 						return nil
 					},
-					GetNextVersionFunc: func(string, string) (int, error) {
-						return 1, nil
+					CountEditionsFunc: func(string) (int, error) {
+						return 0, nil
+					},
+					CountVersionsFunc: func(string, string, string) (int, error) {
+						return 0, nil
+					},
+					GetDatasetFunc: func(string) (*models.DatasetUpdate, error) {
+						return &models.DatasetUpdate{}, nil
+					},
+					ClaimNextVersionFunc: func(string, string) (int, error) {
+						return 2, nil
 					},
 					UpdateInstanceFunc: func(ctx context.Context, id string, i *models.Instance) error {
 						return nil
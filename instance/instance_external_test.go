@@ -1,7 +1,9 @@
 package instance_test
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -9,6 +11,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-dataset-api/api"
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
@@ -51,7 +54,7 @@ func Test_GetInstancesReturnsOK(t *testing.T) {
 				w := httptest.NewRecorder()
 
 				mockedDataStore := &storetest.StorerMock{
-					GetInstancesFunc: func([]string, []string) (*models.InstanceResults, error) {
+					GetInstancesFunc: func([]string, []string, []string) (*models.InstanceResults, error) {
 						return &models.InstanceResults{}, nil
 					},
 				}
@@ -82,7 +85,7 @@ func Test_GetInstancesReturnsOK(t *testing.T) {
 				var result []string
 
 				mockedDataStore := &storetest.StorerMock{
-					GetInstancesFunc: func(state []string, dataset []string) (*models.InstanceResults, error) {
+					GetInstancesFunc: func(state []string, dataset []string, isBasedOn []string) (*models.InstanceResults, error) {
 						result = state
 						return &models.InstanceResults{}, nil
 					},
@@ -115,7 +118,7 @@ func Test_GetInstancesReturnsOK(t *testing.T) {
 				var result []string
 
 				mockedDataStore := &storetest.StorerMock{
-					GetInstancesFunc: func(state []string, dataset []string) (*models.InstanceResults, error) {
+					GetInstancesFunc: func(state []string, dataset []string, isBasedOn []string) (*models.InstanceResults, error) {
 						result = dataset
 						return &models.InstanceResults{}, nil
 					},
@@ -148,7 +151,7 @@ func Test_GetInstancesReturnsOK(t *testing.T) {
 				var result []string
 
 				mockedDataStore := &storetest.StorerMock{
-					GetInstancesFunc: func(state []string, dataset []string) (*models.InstanceResults, error) {
+					GetInstancesFunc: func(state []string, dataset []string, isBasedOn []string) (*models.InstanceResults, error) {
 						result = state
 						return &models.InstanceResults{}, nil
 					},
@@ -173,6 +176,35 @@ func Test_GetInstancesReturnsOK(t *testing.T) {
 			})
 		})
 
+		Convey("When the request includes a filter by state with repeated and empty values 'edition-confirmed,,completed, edition-confirmed'", func() {
+			Convey("Then each state is filtered on once, in a consistent order", func() {
+				r, err := createRequestWithToken("GET", "http://localhost:21800/instances?state=edition-confirmed,,completed,%20edition-confirmed", nil)
+				So(err, ShouldBeNil)
+				w := httptest.NewRecorder()
+				var result []string
+
+				mockedDataStore := &storetest.StorerMock{
+					GetInstancesFunc: func(state []string, dataset []string, isBasedOn []string) (*models.InstanceResults, error) {
+						result = state
+						return &models.InstanceResults{}, nil
+					},
+				}
+
+				auditor := auditortest.New()
+				datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+				datasetAPI.Router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(result, ShouldResemble, []string{models.CompletedState, models.EditionConfirmedState})
+				So(len(mockedDataStore.GetInstancesCalls()), ShouldEqual, 1)
+
+				auditor.AssertRecordCalls(
+					auditortest.NewExpectation(instance.GetInstancesAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk"}),
+					auditortest.NewExpectation(instance.GetInstancesAction, audit.Successful, common.Params{"state_query": "edition-confirmed,,completed, edition-confirmed"}),
+				)
+			})
+		})
+
 		Convey("When the request includes a filter by state of 'completed' and dataset 'test'", func() {
 			Convey("Then return status ok (200)", func() {
 				r, err := createRequestWithToken("GET", "http://localhost:21800/instances?state=completed&dataset=test", nil)
@@ -181,7 +213,7 @@ func Test_GetInstancesReturnsOK(t *testing.T) {
 				var result []string
 
 				mockedDataStore := &storetest.StorerMock{
-					GetInstancesFunc: func(state []string, dataset []string) (*models.InstanceResults, error) {
+					GetInstancesFunc: func(state []string, dataset []string, isBasedOn []string) (*models.InstanceResults, error) {
 						result = append(result, state...)
 						result = append(result, dataset...)
 						return &models.InstanceResults{}, nil
@@ -209,6 +241,81 @@ func Test_GetInstancesReturnsOK(t *testing.T) {
 	})
 }
 
+func Test_GetInstancesAsNDJSON(t *testing.T) {
+	t.Parallel()
+	Convey("Given a GET request for completed instances as newline delimited json", t, func() {
+		r, err := createRequestWithToken("GET", "http://localhost:21800/instances?state=completed", nil)
+		So(err, ShouldBeNil)
+		r.Header.Set("Accept", "application/x-ndjson")
+		w := httptest.NewRecorder()
+
+		instances := []models.Instance{
+			{InstanceID: "123", State: models.CompletedState, Links: &models.InstanceLinks{Dataset: &models.LinkObject{ID: "cpih01"}}},
+			{InstanceID: "456", State: models.CompletedState, Edition: "2018"},
+		}
+
+		mockedDataStore := &storetest.StorerMock{
+			StreamInstancesFunc: func(states []string, datasets []string, isBasedOn []string, fn func(*models.Instance) error) error {
+				for i := range instances {
+					if err := fn(&instances[i]); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+
+		Convey("When the instances are listed", func() {
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then each instance is streamed as a line of json with the state filter applied", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get("Content-Type"), ShouldEqual, "application/x-ndjson")
+				So(len(mockedDataStore.GetInstancesCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.StreamInstancesCalls()), ShouldEqual, 1)
+				So(mockedDataStore.StreamInstancesCalls()[0].States, ShouldResemble, []string{models.CompletedState})
+
+				var streamed []models.Instance
+				scanner := bufio.NewScanner(w.Body)
+				for scanner.Scan() {
+					var line models.Instance
+					So(json.Unmarshal(scanner.Bytes(), &line), ShouldBeNil)
+					streamed = append(streamed, line)
+				}
+				So(scanner.Err(), ShouldBeNil)
+				So(streamed, ShouldResemble, instances)
+
+				auditor.AssertRecordCalls(
+					auditortest.NewExpectation(instance.GetInstancesAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk"}),
+					auditortest.NewExpectation(instance.GetInstancesAction, audit.Successful, common.Params{"state_query": "completed"}),
+				)
+			})
+		})
+	})
+
+	Convey("When the instances cannot be read before any are streamed then an internal server error is returned", t, func() {
+		r, err := createRequestWithToken("GET", "http://localhost:21800/instances", nil)
+		So(err, ShouldBeNil)
+		r.Header.Set("Accept", "application/json, application/x-ndjson;q=0.9")
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			StreamInstancesFunc: func(states []string, datasets []string, isBasedOn []string, fn func(*models.Instance) error) error {
+				return errs.ErrInternalServer
+			},
+		}
+
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+		So(len(mockedDataStore.StreamInstancesCalls()), ShouldEqual, 1)
+	})
+}
+
 func Test_GetInstancesReturnsError(t *testing.T) {
 	t.Parallel()
 	Convey("Given a GET request to retrieve a list of instance resources is made", t, func() {
@@ -219,7 +326,7 @@ func Test_GetInstancesReturnsError(t *testing.T) {
 				w := httptest.NewRecorder()
 
 				mockedDataStore := &storetest.StorerMock{
-					GetInstancesFunc: func([]string, []string) (*models.InstanceResults, error) {
+					GetInstancesFunc: func([]string, []string, []string) (*models.InstanceResults, error) {
 						return nil, errs.ErrInternalServer
 					},
 				}
@@ -268,6 +375,22 @@ func Test_GetInstancesReturnsError(t *testing.T) {
 				)
 			})
 		})
+
+		Convey("When the request contains an invalid state alongside repeated and empty values", func() {
+			Convey("Then return status bad request (400) naming only the invalid state", func() {
+				r, err := createRequestWithToken("GET", "http://localhost:21800/instances?state=completed,,foo,completed", nil)
+				So(err, ShouldBeNil)
+				w := httptest.NewRecorder()
+
+				mockedDataStore := &storetest.StorerMock{}
+				datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+				datasetAPI.Router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, "bad request - invalid filter state values: [foo]")
+				So(len(mockedDataStore.GetInstancesCalls()), ShouldEqual, 0)
+			})
+		})
 	})
 }
 
@@ -312,7 +435,7 @@ func Test_GetInstancesAuditErrors(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			mockedDataStore := &storetest.StorerMock{
-				GetInstancesFunc: func([]string, []string) (*models.InstanceResults, error) {
+				GetInstancesFunc: func([]string, []string, []string) (*models.InstanceResults, error) {
 					return nil, errs.ErrInternalServer
 				},
 			}
@@ -347,7 +470,7 @@ func Test_GetInstancesAuditErrors(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			mockedDataStore := &storetest.StorerMock{
-				GetInstancesFunc: func([]string, []string) (*models.InstanceResults, error) {
+				GetInstancesFunc: func([]string, []string, []string) (*models.InstanceResults, error) {
 					return &models.InstanceResults{}, nil
 				},
 			}
@@ -411,6 +534,179 @@ func Test_GetInstanceReturnsOK(t *testing.T) {
 	})
 }
 
+func Test_GetInstanceViews(t *testing.T) {
+	t.Parallel()
+	Convey("Given an instance with import tasks, events and internal fields", t, func() {
+		newInstance := func() *models.Instance {
+			return &models.Instance{
+				InstanceID:     "123",
+				State:          models.SubmittedState,
+				IdempotencyKey: "abc",
+				Events:         &[]models.Event{{Type: "error", Message: "import failed"}},
+				ImportTasks: &models.InstanceImportTasks{
+					ImportObservations: &models.ImportObservationsTask{State: models.CreatedState},
+				},
+				UniqueTimestamp: 6571730337357332481,
+			}
+		}
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return newInstance(), nil
+			},
+			GetInstancesFunc: func([]string, []string, []string) (*models.InstanceResults, error) {
+				return &models.InstanceResults{Items: []models.Instance{*newInstance()}}, nil
+			},
+		}
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+
+		// getInstance returns the instance from both the instance and the list of instances requested with the query
+		getInstance := func(query string, user string) []map[string]interface{} {
+			var bodies []map[string]interface{}
+
+			r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123"+query, nil)
+			So(err, ShouldBeNil)
+			if user != "" {
+				r = r.WithContext(common.SetUser(r.Context(), user))
+			}
+			w := httptest.NewRecorder()
+			datasetAPI.Router.ServeHTTP(w, r)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			var body map[string]interface{}
+			So(json.Unmarshal(w.Body.Bytes(), &body), ShouldBeNil)
+			bodies = append(bodies, body)
+
+			r, err = createRequestWithToken("GET", "http://localhost:21800/instances"+query, nil)
+			So(err, ShouldBeNil)
+			if user != "" {
+				r = r.WithContext(common.SetUser(r.Context(), user))
+			}
+			w = httptest.NewRecorder()
+			datasetAPI.Router.ServeHTTP(w, r)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			var list struct {
+				Items []map[string]interface{} `json:"items"`
+			}
+			So(json.Unmarshal(w.Body.Bytes(), &list), ShouldBeNil)
+			So(list.Items, ShouldHaveLength, 1)
+			return append(bodies, list.Items[0])
+		}
+
+		Convey("When the instance is requested without a view", func() {
+			bodies := getInstance("", "")
+
+			Convey("Then the import diagnostics are returned without the internal fields", func() {
+				for _, body := range bodies {
+					So(body["id"], ShouldEqual, "123")
+					So(body, ShouldContainKey, "import_tasks")
+					So(body, ShouldContainKey, "events")
+					So(body, ShouldNotContainKey, "idempotency_key")
+					So(body, ShouldNotContainKey, "unique_timestamp")
+				}
+			})
+		})
+
+		Convey("When the trimmed view is requested", func() {
+			bodies := getInstance("?trim=true", "")
+
+			Convey("Then the import diagnostics are left out", func() {
+				for _, body := range bodies {
+					So(body["id"], ShouldEqual, "123")
+					So(body["import_tasks"], ShouldBeNil)
+					So(body, ShouldNotContainKey, "events")
+				}
+			})
+		})
+
+		Convey("When a service requests the verbose view", func() {
+			bodies := getInstance("?verbose=true", "")
+
+			Convey("Then the import diagnostics and internal fields are returned", func() {
+				for _, body := range bodies {
+					So(body["id"], ShouldEqual, "123")
+					So(body, ShouldContainKey, "import_tasks")
+					So(body, ShouldContainKey, "events")
+					So(body["idempotency_key"], ShouldEqual, "abc")
+					So(body["unique_timestamp"], ShouldEqual, 6571730337357332481)
+				}
+			})
+		})
+
+		Convey("When a user requests the verbose view", func() {
+			bodies := getInstance("?verbose=true", "someone@ons.gov.uk")
+
+			Convey("Then the internal fields are left out", func() {
+				for _, body := range bodies {
+					So(body, ShouldContainKey, "import_tasks")
+					So(body, ShouldNotContainKey, "idempotency_key")
+					So(body, ShouldNotContainKey, "unique_timestamp")
+				}
+			})
+		})
+	})
+}
+
+func Test_GetInstanceDownloads(t *testing.T) {
+	t.Parallel()
+	Convey("Given an unpublished instance with downloads and a download link signer", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(ID string) (*models.Instance, error) {
+				return &models.Instance{
+					InstanceID: "123",
+					State:      models.AssociatedState,
+					Downloads: &models.DownloadList{
+						CSV: &models.DownloadObject{HRef: "http://localhost:23600/downloads/123.csv", Private: "s3://private/123.csv"},
+					},
+				}, nil
+			},
+		}
+		signer := &mocks.DownloadURLSignerMock{
+			SignFunc: func(href string, expires time.Time) (string, error) {
+				return href + "?signature=abc", nil
+			},
+		}
+
+		mu.Lock()
+		cfg, err := config.Get()
+		mu.Unlock()
+		So(err, ShouldBeNil)
+		cfg.ServiceAuthToken = "dataset"
+		cfg.DatasetAPIURL = "http://localhost:22000"
+		cfg.EnablePrivateEnpoints = true
+		datasetAPI := api.NewDatasetAPI(*cfg, mux.NewRouter(), store.DataStore{Backend: mockedDataStore}, urlBuilder, &mocks.DownloadsGeneratorMock{}, &mocks.HierarchyBuildTriggerMock{}, &mocks.PublishNotifierMock{}, signer, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+
+		getInstance := func(r *http.Request) *models.Instance {
+			w := httptest.NewRecorder()
+			datasetAPI.Router.ServeHTTP(w, r)
+			So(w.Code, ShouldEqual, http.StatusOK)
+
+			var instance models.Instance
+			So(json.Unmarshal(w.Body.Bytes(), &instance), ShouldBeNil)
+			return &instance
+		}
+
+		Convey("When the instance is requested then the download link is signed and the private link removed", func() {
+			r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123", nil)
+			So(err, ShouldBeNil)
+			instance := getInstance(r)
+
+			So(instance.Downloads.CSV.HRef, ShouldEqual, "http://localhost:23600/downloads/123.csv?signature=abc")
+			So(instance.Downloads.CSV.Private, ShouldBeEmpty)
+			So(signer.SignCalls(), ShouldHaveLength, 1)
+		})
+
+		Convey("When the download service requests the instance then the private link is returned", func() {
+			r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123", nil)
+			So(err, ShouldBeNil)
+			r.Header.Set("X-Download-Service-Token", cfg.DownloadServiceSecretKey)
+			instance := getInstance(r)
+
+			So(instance.Downloads.CSV.Private, ShouldEqual, "s3://private/123.csv")
+		})
+	})
+}
+
 func Test_GetInstanceReturnsError(t *testing.T) {
 	auditParams := common.Params{"instance_id": "123"}
 	auditParamsWithCallerIdentity := common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123"}
@@ -687,6 +983,142 @@ func Test_AddInstanceReturnsCreated(t *testing.T) {
 	})
 }
 
+func Test_AddInstanceWithIdempotencyKey(t *testing.T) {
+	t.Parallel()
+	Convey("Given two POST requests to create an instance with the same idempotency key", t, func() {
+		created := map[string]*models.Instance{}
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceByIdempotencyKeyFunc: func(key string) (*models.Instance, error) {
+				if instance, ok := created[key]; ok {
+					return instance, nil
+				}
+				return nil, errs.ErrInstanceNotFound
+			},
+			AddInstanceFunc: func(instance *models.Instance) (*models.Instance, error) {
+				created[instance.IdempotencyKey] = instance
+				return instance, nil
+			},
+		}
+
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+
+		responses := []*httptest.ResponseRecorder{}
+		for i := 0; i < 2; i++ {
+			body := strings.NewReader(`{"links": { "job": { "id":"123-456", "href":"http://localhost:2200/jobs/123-456" } } }`)
+			r, err := createRequestWithToken("POST", "http://localhost:21800/instances", body)
+			So(err, ShouldBeNil)
+			r.Header.Set("Idempotency-Key", "import-123-456")
+
+			w := httptest.NewRecorder()
+			datasetAPI.Router.ServeHTTP(w, r)
+			responses = append(responses, w)
+		}
+
+		Convey("Then only one instance is created and the repeat returns it with status ok", func() {
+			So(responses[0].Code, ShouldEqual, http.StatusCreated)
+			So(responses[1].Code, ShouldEqual, http.StatusOK)
+			So(len(mockedDataStore.AddInstanceCalls()), ShouldEqual, 1)
+			So(mockedDataStore.AddInstanceCalls()[0].Instance.IdempotencyKey, ShouldEqual, "import-123-456")
+
+			var first, repeat models.Instance
+			So(json.Unmarshal(responses[0].Body.Bytes(), &first), ShouldBeNil)
+			So(json.Unmarshal(responses[1].Body.Bytes(), &repeat), ShouldBeNil)
+			So(first.InstanceID, ShouldNotBeEmpty)
+			So(repeat.InstanceID, ShouldEqual, first.InstanceID)
+		})
+	})
+
+	Convey("Given a POST request to create an instance without an idempotency key", t, func() {
+		body := strings.NewReader(`{"links": { "job": { "id":"123-456", "href":"http://localhost:2200/jobs/123-456" } } }`)
+		r, err := createRequestWithToken("POST", "http://localhost:21800/instances", body)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			AddInstanceFunc: func(instance *models.Instance) (*models.Instance, error) {
+				return instance, nil
+			},
+		}
+
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then the instance is created without looking up a previous request", func() {
+			So(w.Code, ShouldEqual, http.StatusCreated)
+			So(len(mockedDataStore.GetInstanceByIdempotencyKeyCalls()), ShouldEqual, 0)
+			So(len(mockedDataStore.AddInstanceCalls()), ShouldEqual, 1)
+		})
+	})
+}
+
+func Test_InstanceIsBasedOnLink(t *testing.T) {
+	t.Parallel()
+	Convey("Given an instance derived from a parent dataset", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			AddInstanceFunc: func(instance *models.Instance) (*models.Instance, error) {
+				return instance, nil
+			},
+			GetInstancesFunc: func(states []string, datasets []string, isBasedOn []string) (*models.InstanceResults, error) {
+				return &models.InstanceResults{Items: []models.Instance{{
+					InstanceID: "123",
+					Links:      &models.InstanceLinks{IsBasedOn: &models.LinkObject{ID: "cpih01"}},
+				}}}, nil
+			},
+		}
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+
+		Convey("When the instance is created with a link to its parent dataset", func() {
+			body := strings.NewReader(`{"links": {"job": {"id":"123-456", "href":"http://localhost:2200/jobs/123-456"}, "is_based_on": {"id":"cpih01", "href":"http://localhost:22000/datasets/cpih01"}}}`)
+			r, err := createRequestWithToken("POST", "http://localhost:21800/instances", body)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then the link is stored and returned", func() {
+				So(w.Code, ShouldEqual, http.StatusCreated)
+				So(len(mockedDataStore.AddInstanceCalls()), ShouldEqual, 1)
+				So(mockedDataStore.AddInstanceCalls()[0].Instance.Links.IsBasedOn, ShouldResemble, &models.LinkObject{ID: "cpih01", HRef: "http://localhost:22000/datasets/cpih01"})
+				So(w.Body.String(), ShouldContainSubstring, `"is_based_on":{"href":"http://localhost:22000/datasets/cpih01","id":"cpih01"}`)
+			})
+		})
+
+		Convey("When the instance is created with a link to its parent dataset without an id", func() {
+			body := strings.NewReader(`{"links": {"job": {"id":"123-456", "href":"http://localhost:2200/jobs/123-456"}, "is_based_on": {"href":"http://localhost:22000/datasets/cpih01"}}}`)
+			r, err := createRequestWithToken("POST", "http://localhost:21800/instances", body)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then a bad request status is returned and the instance is not created", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrIsBasedOnLinkInvalid.Error())
+				So(len(mockedDataStore.AddInstanceCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the instances based on the parent dataset are requested", func() {
+			r, err := createRequestWithToken("GET", "http://localhost:21800/instances?is_based_on=cpih01", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then the instances are filtered by their parent dataset", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.GetInstancesCalls()), ShouldEqual, 1)
+				So(mockedDataStore.GetInstancesCalls()[0].IsBasedOn, ShouldResemble, []string{"cpih01"})
+				So(w.Body.String(), ShouldContainSubstring, `"is_based_on":{"id":"cpih01"}`)
+
+				auditor.AssertRecordCalls(
+					auditortest.NewExpectation(instance.GetInstancesAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk"}),
+					auditortest.NewExpectation(instance.GetInstancesAction, audit.Successful, common.Params{"is_based_on_query": "cpih01"}),
+				)
+			})
+		})
+	})
+}
+
 func Test_AddInstanceReturnsError(t *testing.T) {
 	t.Parallel()
 	Convey("Given a POST request to create an instance resources", t, func() {
@@ -968,6 +1400,523 @@ func Test_UpdateInstanceReturnsOk(t *testing.T) {
 	})
 }
 
+func Test_UpdateInstanceReturnChanges(t *testing.T) {
+	t.Parallel()
+	Convey("Given a created instance", t, func() {
+		var mockedDataStore *storetest.StorerMock
+		mockedDataStore = &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				instance := &models.Instance{
+					InstanceID: "123",
+					Links: &models.InstanceLinks{
+						Dataset: &models.LinkObject{ID: "234", HRef: "example.com/234"},
+						Self:    &models.LinkObject{ID: "123", HRef: "example.com/123"},
+					},
+					State: models.CreatedState,
+				}
+				if len(mockedDataStore.UpdateInstanceCalls()) > 0 {
+					instance.State = models.SubmittedState
+				}
+				return instance, nil
+			},
+			UpdateInstanceFunc: func(ctx context.Context, id string, i *models.Instance) error {
+				return nil
+			},
+		}
+
+		Convey("When the instance is updated with return=changes", func() {
+			body := strings.NewReader(`{"state":"submitted"}`)
+			r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123?return=changes", body)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then only the fields changed by the update are returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 1)
+				So(w.Body.String(), ShouldEqual, `{"state":"submitted"}`)
+			})
+		})
+
+		Convey("When the instance is updated without a return value", func() {
+			body := strings.NewReader(`{"state":"submitted"}`)
+			r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", body)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then the updated instance is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var updated models.Instance
+				So(json.Unmarshal(w.Body.Bytes(), &updated), ShouldBeNil)
+				So(updated.InstanceID, ShouldEqual, "123")
+				So(updated.State, ShouldEqual, models.SubmittedState)
+				So(updated.Links.Dataset.ID, ShouldEqual, "234")
+			})
+		})
+
+		Convey("When the instance is updated with an unknown return value", func() {
+			body := strings.NewReader(`{"state":"submitted"}`)
+			r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123?return=representation", body)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then a bad request response is returned and the instance is not updated", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrInvalidReturn.Error())
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func Test_UpdateInstanceNormalisesDimensionNames(t *testing.T) {
+	t.Parallel()
+	Convey("Given a PUT request to update the dimensions of an instance with a mixed case dimension name", t, func() {
+		body := strings.NewReader(`{"dimensions":[{"name":"Geography"}]}`)
+		r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", body)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{
+					Links: &models.InstanceLinks{
+						Dataset: &models.LinkObject{ID: "234", HRef: "example.com/234"},
+						Self:    &models.LinkObject{ID: "123", HRef: "example.com/123"},
+					},
+					State: models.CreatedState,
+				}, nil
+			},
+			UpdateInstanceFunc: func(ctx context.Context, id string, i *models.Instance) error {
+				return nil
+			},
+		}
+
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then the instance is updated with the lowercase dimension name", func() {
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 1)
+			So(mockedDataStore.UpdateInstanceCalls()[0].Instance.Dimensions[0].Name, ShouldEqual, "geography")
+		})
+	})
+}
+
+func Test_UpdateInstanceRejectsMalformedHeaders(t *testing.T) {
+	t.Parallel()
+	Convey("Given a PUT request to update an instance with headers which do not start with a v4 header", t, func() {
+		body := strings.NewReader(`{"headers":["time_codelist","time"]}`)
+		r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", body)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{State: models.CreatedState}, nil
+			},
+		}
+
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then a bad request is returned naming the problem and the instance is not updated", func() {
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrHeadersFirstCellInvalid.Error())
+			So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+		})
+	})
+}
+
+func Test_UpdateInstanceSubmittedTransition(t *testing.T) {
+	t.Parallel()
+	getAPI := func(currentState string) (*storetest.StorerMock, *api.DatasetAPI) {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{
+					Links: &models.InstanceLinks{
+						Dataset: &models.LinkObject{ID: "234", HRef: "example.com/234"},
+						Self:    &models.LinkObject{ID: "123", HRef: "example.com/123"},
+					},
+					State: currentState,
+				}, nil
+			},
+			UpdateInstanceFunc: func(ctx context.Context, id string, i *models.Instance) error {
+				return nil
+			},
+		}
+		return mockedDataStore, getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+	}
+
+	put := func(datasetAPI *api.DatasetAPI, body string) *httptest.ResponseRecorder {
+		r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", strings.NewReader(body))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+		datasetAPI.Router.ServeHTTP(w, r)
+		return w
+	}
+
+	Convey("Given an instance in a created state", t, func() {
+		mockedDataStore, datasetAPI := getAPI(models.CreatedState)
+
+		Convey("When the instance is updated to submitted", func() {
+			w := put(datasetAPI, `{"state":"submitted"}`)
+
+			Convey("Then the instance is updated", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 1)
+				So(mockedDataStore.UpdateInstanceCalls()[0].Instance.State, ShouldEqual, models.SubmittedState)
+			})
+		})
+
+		Convey("When the instance is updated to completed without being submitted", func() {
+			w := put(datasetAPI, `{"state":"completed"}`)
+
+			Convey("Then the update is forbidden", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrExpectedResourceStateOfSubmitted.Error())
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given an instance in a submitted state", t, func() {
+		mockedDataStore, datasetAPI := getAPI(models.SubmittedState)
+
+		Convey("When the instance is updated to completed", func() {
+			w := put(datasetAPI, `{"state":"completed"}`)
+
+			Convey("Then the instance is updated", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 1)
+				So(mockedDataStore.UpdateInstanceCalls()[0].Instance.State, ShouldEqual, models.CompletedState)
+			})
+		})
+
+		Convey("When the instance is updated to edition-confirmed without being completed", func() {
+			w := put(datasetAPI, `{"state":"edition-confirmed"}`)
+
+			Convey("Then the update is forbidden", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrExpectedResourceStateOfCompleted.Error())
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given an instance in a completed state", t, func() {
+		mockedDataStore, datasetAPI := getAPI(models.CompletedState)
+
+		Convey("When the instance is moved back to submitted", func() {
+			w := put(datasetAPI, `{"state":"submitted"}`)
+
+			Convey("Then the update is forbidden", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrExpectedResourceStateOfCreated.Error())
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func Test_UpdateInstanceFailedTransition(t *testing.T) {
+	t.Parallel()
+	getAPI := func(currentState string) (*storetest.StorerMock, *api.DatasetAPI) {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{
+					Links: &models.InstanceLinks{
+						Dataset: &models.LinkObject{ID: "234", HRef: "example.com/234"},
+						Self:    &models.LinkObject{ID: "123", HRef: "example.com/123"},
+					},
+					State: currentState,
+				}, nil
+			},
+			UpdateInstanceFunc: func(ctx context.Context, id string, i *models.Instance) error {
+				return nil
+			},
+		}
+		return mockedDataStore, getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+	}
+
+	put := func(datasetAPI *api.DatasetAPI, body string) *httptest.ResponseRecorder {
+		r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", strings.NewReader(body))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+		datasetAPI.Router.ServeHTTP(w, r)
+		return w
+	}
+
+	Convey("Given an instance in a submitted state", t, func() {
+		mockedDataStore, datasetAPI := getAPI(models.SubmittedState)
+
+		Convey("When the instance is moved to failed with a reason", func() {
+			w := put(datasetAPI, `{"state":"failed","failure_reason":"observation import timed out"}`)
+
+			Convey("Then the instance is updated with the state and reason", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 1)
+				So(mockedDataStore.UpdateInstanceCalls()[0].Instance.State, ShouldEqual, models.FailedState)
+				So(mockedDataStore.UpdateInstanceCalls()[0].Instance.FailureReason, ShouldEqual, "observation import timed out")
+			})
+		})
+
+		Convey("When the instance is moved to failed without a reason", func() {
+			w := put(datasetAPI, `{"state":"failed"}`)
+
+			Convey("Then a bad request is returned and the instance is not updated", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrInstanceFailureReasonMissing.Error())
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a failure reason is given without moving the instance to failed", func() {
+			w := put(datasetAPI, `{"state":"completed","failure_reason":"observation import timed out"}`)
+
+			Convey("Then a bad request is returned and the instance is not updated", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrInstanceFailureReasonInvalid.Error())
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given an instance in a failed state", t, func() {
+		mockedDataStore, datasetAPI := getAPI(models.FailedState)
+
+		Convey("When the instance is updated to another state", func() {
+			w := put(datasetAPI, `{"state":"completed"}`)
+
+			Convey("Then the update is forbidden", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrInstanceFailed.Error())
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When other fields of the instance are updated", func() {
+			w := put(datasetAPI, `{"state":"failed","failure_reason":"another reason","release_date":"2018-11-01"}`)
+
+			Convey("Then the update is forbidden", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrInstanceFailed.Error())
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When only the state of the instance is given", func() {
+			w := put(datasetAPI, `{"state":"failed"}`)
+
+			Convey("Then the instance is left as it is", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+	})
+}
+
+func Test_UpdateInstancePublishDenylist(t *testing.T) {
+	t.Parallel()
+	publishInstance := func(publishDenylist []string) (*httptest.ResponseRecorder, *storetest.StorerMock) {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{
+					Links: &models.InstanceLinks{
+						Dataset: &models.LinkObject{ID: "234", HRef: "example.com/234"},
+						Self:    &models.LinkObject{ID: "123", HRef: "example.com/123"},
+					},
+					State: models.AssociatedState,
+				}, nil
+			},
+			UpdateInstanceFunc: func(ctx context.Context, id string, i *models.Instance) error {
+				return nil
+			},
+		}
+		s := &instance.Store{Storer: mockedDataStore, Auditor: auditortest.New(), PublishDenylist: publishDenylist}
+
+		router := mux.NewRouter()
+		router.HandleFunc("/instances/{instance_id}", s.Update).Methods("PUT")
+
+		r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", strings.NewReader(`{"state":"published"}`))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		return w, mockedDataStore
+	}
+
+	Convey("Given the dataset of an associated instance is on the publish denylist", t, func() {
+		Convey("When the instance is published", func() {
+			w, mockedDataStore := publishInstance([]string{"234"})
+
+			Convey("Then the update is forbidden and the instance is left unpublished", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrDatasetPublishDenied.Error())
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given the dataset of an associated instance is not on the publish denylist", t, func() {
+		Convey("When the instance is published", func() {
+			w, mockedDataStore := publishInstance([]string{"456"})
+
+			Convey("Then the instance is published", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 1)
+				So(mockedDataStore.UpdateInstanceCalls()[0].Instance.State, ShouldEqual, models.PublishedState)
+			})
+		})
+	})
+}
+
+func Test_UpdateInstancePublishReleaseDate(t *testing.T) {
+	t.Parallel()
+	publishInstance := func(body, currentReleaseDate string) (*httptest.ResponseRecorder, *storetest.StorerMock) {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{
+					Links: &models.InstanceLinks{
+						Dataset: &models.LinkObject{ID: "234", HRef: "example.com/234"},
+						Self:    &models.LinkObject{ID: "123", HRef: "example.com/123"},
+					},
+					ReleaseDate: currentReleaseDate,
+					State:       models.AssociatedState,
+				}, nil
+			},
+			UpdateInstanceFunc: func(ctx context.Context, id string, i *models.Instance) error {
+				return nil
+			},
+		}
+		s := &instance.Store{Storer: mockedDataStore, Auditor: auditortest.New(), RejectPastReleaseDates: true}
+
+		router := mux.NewRouter()
+		router.HandleFunc("/instances/{instance_id}", s.Update).Methods("PUT")
+
+		r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", strings.NewReader(body))
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		return w, mockedDataStore
+	}
+
+	Convey("Given past release dates are rejected", t, func() {
+		Convey("When an associated instance with a past release date is published", func() {
+			w, mockedDataStore := publishInstance(`{"state":"published"}`, "2017-12-12")
+
+			Convey("Then the update is rejected and the instance is left unpublished", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrVersionReleaseDateInPast.Error())
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When an associated instance is published with a past release date in the request", func() {
+			w, mockedDataStore := publishInstance(`{"state":"published","release_date":"2017-12-12"}`, "2099-12-12")
+
+			Convey("Then the update is rejected and the instance is left unpublished", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrVersionReleaseDateInPast.Error())
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When an associated instance with a future release date is published", func() {
+			w, mockedDataStore := publishInstance(`{"state":"published"}`, "2099-12-12")
+
+			Convey("Then the instance is published", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 1)
+				So(mockedDataStore.UpdateInstanceCalls()[0].Instance.State, ShouldEqual, models.PublishedState)
+			})
+		})
+	})
+}
+
+func Test_UpdateInstanceIfUnmodifiedSince(t *testing.T) {
+	auditParams := common.Params{"instance_id": "123"}
+	auditParamsWithCallerIdentity := common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123"}
+	lastUpdated := time.Date(2018, time.October, 1, 10, 30, 0, 0, time.UTC)
+
+	t.Parallel()
+	Convey("Given a PUT request to update an instance with an If-Unmodified-Since header", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{
+					LastUpdated: lastUpdated,
+					Links: &models.InstanceLinks{
+						Dataset: &models.LinkObject{
+							ID:   "234",
+							HRef: "example.com/234",
+						},
+					},
+					State: models.CreatedState,
+				}, nil
+			},
+			UpdateInstanceFunc: func(ctx context.Context, id string, i *models.Instance) error {
+				return nil
+			},
+		}
+
+		Convey("When the instance has not been modified since the time given", func() {
+			body := strings.NewReader(`{"state":"submitted"}`)
+			r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", body)
+			So(err, ShouldBeNil)
+			r.Header.Set("If-Unmodified-Since", lastUpdated.Format(http.TimeFormat))
+			w := httptest.NewRecorder()
+
+			auditor := auditortest.New()
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then the instance is updated and status ok (200) is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.GetInstanceCalls()), ShouldEqual, 3)
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 1)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{instance.UpdateInstanceAction, audit.Attempted, auditParamsWithCallerIdentity},
+					auditortest.Expected{instance.UpdateInstanceAction, audit.Successful, auditParams},
+				)
+			})
+		})
+
+		Convey("When the instance has been modified since the time given", func() {
+			body := strings.NewReader(`{"state":"submitted"}`)
+			r, err := createRequestWithToken("PUT", "http://localhost:21800/instances/123", body)
+			So(err, ShouldBeNil)
+			r.Header.Set("If-Unmodified-Since", lastUpdated.Add(-time.Minute).Format(http.TimeFormat))
+			w := httptest.NewRecorder()
+
+			auditor := auditortest.New()
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then the instance is not updated and status precondition failed (412) is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusPreconditionFailed)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrInstanceModified.Error())
+				So(len(mockedDataStore.GetInstanceCalls()), ShouldEqual, 2)
+				So(len(mockedDataStore.UpdateInstanceCalls()), ShouldEqual, 0)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{instance.UpdateInstanceAction, audit.Attempted, auditParamsWithCallerIdentity},
+					auditortest.Expected{instance.UpdateInstanceAction, audit.Unsuccessful, auditParams},
+				)
+			})
+		})
+	})
+}
+
 func Test_UpdateInstanceReturnsError(t *testing.T) {
 	auditParams := common.Params{"instance_id": "123"}
 	auditParamsWithCallerIdentity := common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123"}
@@ -1299,9 +2248,13 @@ func Test_UpdateInstance_AuditFailure(t *testing.T) {
 	})
 }
 
-var urlBuilder = url.NewBuilder("localhost:20000")
+var urlBuilder = url.NewBuilder("localhost:20000", "http://localhost:22000")
 
 func getAPIWithMocks(mockedDataStore store.Storer, mockedGeneratedDownloads api.DownloadsGenerator, mockAuditor api.Auditor, datasetPermissions api.AuthHandler, permissions api.AuthHandler) *api.DatasetAPI {
+	return getAPIWithHierarchyTrigger(mockedDataStore, mockedGeneratedDownloads, &mocks.HierarchyBuildTriggerMock{}, mockAuditor, datasetPermissions, permissions)
+}
+
+func getAPIWithHierarchyTrigger(mockedDataStore store.Storer, mockedGeneratedDownloads api.DownloadsGenerator, hierarchyBuildTrigger instance.HierarchyBuildTrigger, mockAuditor api.Auditor, datasetPermissions api.AuthHandler, permissions api.AuthHandler) *api.DatasetAPI {
 	mu.Lock()
 	defer mu.Unlock()
 	cfg, err := config.Get()
@@ -1310,5 +2263,5 @@ func getAPIWithMocks(mockedDataStore store.Storer, mockedGeneratedDownloads api.
 	cfg.DatasetAPIURL = "http://localhost:22000"
 	cfg.EnablePrivateEnpoints = true
 
-	return api.NewDatasetAPI(*cfg, mux.NewRouter(), store.DataStore{Backend: mockedDataStore}, urlBuilder, mockedGeneratedDownloads, mockAuditor, datasetPermissions, permissions)
+	return api.NewDatasetAPI(*cfg, mux.NewRouter(), store.DataStore{Backend: mockedDataStore}, urlBuilder, mockedGeneratedDownloads, hierarchyBuildTrigger, &mocks.PublishNotifierMock{}, nil, mockAuditor, datasetPermissions, permissions)
 }
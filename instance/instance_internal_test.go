@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -23,7 +24,7 @@ var ctx = context.Background()
 
 func TestUnmarshalInstanceWithBadReader(t *testing.T) {
 	Convey("Create an instance with an invalid reader", t, func() {
-		instance, err := unmarshalInstance(ctx, Reader{}, true)
+		instance, err := unmarshalInstance(ctx, Reader{}, true, models.DefaultJSONLimits)
 		So(instance, ShouldBeNil)
 		So(err.Error(), ShouldEqual, "failed to read message body")
 	})
@@ -31,7 +32,7 @@ func TestUnmarshalInstanceWithBadReader(t *testing.T) {
 
 func TestUnmarshalInstanceWithInvalidJson(t *testing.T) {
 	Convey("Create an instance with invalid json", t, func() {
-		instance, err := unmarshalInstance(ctx, strings.NewReader("{ "), true)
+		instance, err := unmarshalInstance(ctx, strings.NewReader("{ "), true, models.DefaultJSONLimits)
 		So(instance, ShouldBeNil)
 		So(err.Error(), ShouldContainSubstring, errs.ErrUnableToParseJSON.Error())
 	})
@@ -39,31 +40,31 @@ func TestUnmarshalInstanceWithInvalidJson(t *testing.T) {
 
 func TestUnmarshalInstanceWithEmptyJson(t *testing.T) {
 	Convey("Create an instance with empty json", t, func() {
-		instance, err := unmarshalInstance(ctx, strings.NewReader("{ }"), true)
+		instance, err := unmarshalInstance(ctx, strings.NewReader("{ }"), true, models.DefaultJSONLimits)
 		So(instance, ShouldBeNil)
 		So(err.Error(), ShouldEqual, errs.ErrMissingJobProperties.Error())
 	})
 
 	Convey("Create an instance with empty job link", t, func() {
-		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links":{"job": null}}`), true)
+		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links":{"job": null}}`), true, models.DefaultJSONLimits)
 		So(instance, ShouldBeNil)
 		So(err.Error(), ShouldEqual, errs.ErrMissingJobProperties.Error())
 	})
 
 	Convey("Create an instance with empty href in job link", t, func() {
-		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links":{"job":{"id": "456"}}}`), true)
+		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links":{"job":{"id": "456"}}}`), true, models.DefaultJSONLimits)
 		So(instance, ShouldBeNil)
 		So(err.Error(), ShouldEqual, errs.ErrMissingJobProperties.Error())
 	})
 
 	Convey("Create an instance with empty href in job link", t, func() {
-		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links":{"job":{"href": "http://localhost:21800/jobs/456"}}}`), true)
+		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links":{"job":{"href": "http://localhost:21800/jobs/456"}}}`), true, models.DefaultJSONLimits)
 		So(instance, ShouldBeNil)
 		So(err.Error(), ShouldEqual, errs.ErrMissingJobProperties.Error())
 	})
 
 	Convey("Update an instance with empty json", t, func() {
-		instance, err := unmarshalInstance(ctx, strings.NewReader("{ }"), false)
+		instance, err := unmarshalInstance(ctx, strings.NewReader("{ }"), false, models.DefaultJSONLimits)
 		So(instance, ShouldNotBeEmpty)
 		So(err, ShouldBeNil)
 	})
@@ -71,25 +72,25 @@ func TestUnmarshalInstanceWithEmptyJson(t *testing.T) {
 
 func TestUnmarshalInstanceWithMissingFields(t *testing.T) {
 	Convey("Create an instance with no id", t, func() {
-		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links": { "job": { "link":"http://localhost:2200/jobs/123-456" } }}`), true)
+		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links": { "job": { "link":"http://localhost:2200/jobs/123-456" } }}`), true, models.DefaultJSONLimits)
 		So(instance, ShouldBeNil)
 		So(err.Error(), ShouldEqual, errs.ErrMissingJobProperties.Error())
 	})
 
 	Convey("Create an instance with no link", t, func() {
-		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links": { "job": {"id":"123-456"} }}`), true)
+		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links": { "job": {"id":"123-456"} }}`), true, models.DefaultJSONLimits)
 		So(instance, ShouldBeNil)
 		So(err.Error(), ShouldEqual, errs.ErrMissingJobProperties.Error())
 	})
 
 	Convey("Update an instance with no id", t, func() {
-		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links": { "job": { "link":"http://localhost:2200/jobs/123-456" } }}`), false)
+		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links": { "job": { "link":"http://localhost:2200/jobs/123-456" } }}`), false, models.DefaultJSONLimits)
 		So(instance, ShouldNotBeNil)
 		So(err, ShouldBeNil)
 	})
 
 	Convey("Update an instance with no link", t, func() {
-		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links": { "job": {"id":"123-456"} }}`), false)
+		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links": { "job": {"id":"123-456"} }}`), false, models.DefaultJSONLimits)
 		So(instance, ShouldNotBeNil)
 		So(err, ShouldBeNil)
 	})
@@ -97,8 +98,22 @@ func TestUnmarshalInstanceWithMissingFields(t *testing.T) {
 
 func TestUnmarshalInstance(t *testing.T) {
 	Convey("Create an instance with the required fields", t, func() {
-		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links": { "job": { "id":"123-456", "href":"http://localhost:2200/jobs/123-456" } }}`), true)
+		instance, err := unmarshalInstance(ctx, strings.NewReader(`{"links": { "job": { "id":"123-456", "href":"http://localhost:2200/jobs/123-456" } }}`), true, models.DefaultJSONLimits)
 		So(err, ShouldBeNil)
 		So(instance.Links.Job.ID, ShouldEqual, "123-456")
 	})
 }
+
+func TestSplitFilterQuery(t *testing.T) {
+	Convey("Given a filter query with repeated, empty and padded values", t, func() {
+		values := splitFilterQuery("edition-confirmed,, completed ,edition-confirmed,")
+
+		Convey("Then each value is returned once, trimmed and sorted", func() {
+			So(values, ShouldResemble, []string{"completed", "edition-confirmed"})
+		})
+	})
+
+	Convey("Given a filter query with only empty values then no values are returned", t, func() {
+		So(splitFilterQuery(" , ,"), ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,138 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// GetProgressAction represents the audit action to stream the import progress of an instance
+const GetProgressAction = "getInstanceProgress"
+
+// defaultProgressInterval is how often the instance is re-read when no ProgressInterval is configured
+const defaultProgressInterval = time.Second
+
+// importFinishedStates are the states of an instance whose import will make no further progress
+var importFinishedStates = map[string]bool{
+	models.CompletedState:        true,
+	models.EditionConfirmedState: true,
+	models.AssociatedState:       true,
+	models.PublishedState:        true,
+	models.FailedState:           true,
+}
+
+// Progress streams the import progress of an instance as server-sent events. An event is sent when the stream
+// opens and whenever the inserted or total observations or the state change, the stream is closed once the
+// import has finished or failed. Each event pushes back the write deadline of the connection, so a stream of any
+// length is kept open while the import progresses, but an import which makes no progress for longer than the
+// server write timeout has its stream closed. Clients using EventSource reconnect automatically.
+func (s *Store) Progress(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	instanceID := vars["instance_id"]
+	auditParams := common.Params{"instance_id": instanceID}
+	logData := audit.ToLogData(auditParams)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		err := errors.New("response writer does not support streaming")
+		if auditErr := s.auditor().Record(ctx, GetProgressAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleInstanceErr(ctx, err, w, logData)
+		return
+	}
+
+	instance, err := s.GetInstance(instanceID)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "instance progress: store.GetInstance returned an error"), logData)
+		if auditErr := s.auditor().Record(ctx, GetProgressAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleInstanceErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := s.auditor().Record(ctx, GetProgressAction, audit.Successful, auditParams); auditErr != nil {
+		handleInstanceErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	progress := models.NewImportProgress(instance)
+	if err = writeProgressEvent(w, flusher, progress); err != nil || isImportFinished(progress.State) {
+		closeProgressStream(ctx, err, logData)
+		return
+	}
+
+	interval := s.ProgressInterval
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.InfoCtx(ctx, "instance progress: client closed the stream", logData)
+			return
+		case <-ticker.C:
+			instance, err := s.GetInstance(instanceID)
+			if err != nil {
+				closeProgressStream(ctx, errors.WithMessage(err, "store.GetInstance returned an error"), logData)
+				return
+			}
+
+			latest := models.NewImportProgress(instance)
+			if latest == progress {
+				continue
+			}
+			progress = latest
+
+			if err = writeProgressEvent(w, flusher, progress); err != nil || isImportFinished(progress.State) {
+				closeProgressStream(ctx, err, logData)
+				return
+			}
+		}
+	}
+}
+
+func closeProgressStream(ctx context.Context, err error, logData log.Data) {
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "instance progress: stream closed with an error"), logData)
+		return
+	}
+	log.InfoCtx(ctx, "instance progress: import finished, stream closed", logData)
+}
+
+func isImportFinished(state string) bool {
+	return importFinishedStates[state]
+}
+
+func writeProgressEvent(w http.ResponseWriter, flusher http.Flusher, progress models.ImportProgress) error {
+	b, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	if _, err = fmt.Fprintf(w, "event: progress\ndata: %s\n\n", b); err != nil {
+		return err
+	}
+
+	flusher.Flush()
+	return nil
+}
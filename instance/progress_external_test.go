@@ -0,0 +1,177 @@
+package instance_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/instance"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	storetest "github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func importingInstance(state string, inserted int64, total int) *models.Instance {
+	return &models.Instance{
+		State:             state,
+		TotalObservations: &total,
+		ImportTasks: &models.InstanceImportTasks{
+			ImportObservations: &models.ImportObservationsTask{InsertedObservations: inserted},
+		},
+	}
+}
+
+func getProgressRouter(s *instance.Store) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/instances/{instance_id}/progress", s.Progress)
+	return router
+}
+
+func Test_InstanceProgressStreamsEvents(t *testing.T) {
+	t.Parallel()
+	Convey("Given an instance which is importing observations", t, func() {
+		reads := []*models.Instance{
+			importingInstance(models.SubmittedState, 10, 100),
+			importingInstance(models.SubmittedState, 10, 100),
+			importingInstance(models.SubmittedState, 60, 100),
+			importingInstance(models.CompletedState, 100, 100),
+		}
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				i := reads[0]
+				if len(reads) > 1 {
+					reads = reads[1:]
+				}
+				return i, nil
+			},
+		}
+
+		auditor := auditortest.New()
+		s := &instance.Store{Storer: mockedDataStore, Auditor: auditor, ProgressInterval: time.Millisecond}
+
+		Convey("When the progress of the instance is requested", func() {
+			r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123/progress", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			getProgressRouter(s).ServeHTTP(w, r)
+
+			Convey("Then an event is sent for each change in progress and the stream closes on completion", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get("Content-Type"), ShouldEqual, "text/event-stream")
+				So(w.Flushed, ShouldBeTrue)
+
+				So(len(mockedDataStore.GetInstanceCalls()), ShouldEqual, 4)
+				So(w.Body.String(), ShouldEqual,
+					"event: progress\ndata: {\"state\":\"submitted\",\"inserted_observations\":10,\"total_observations\":100}\n\n"+
+						"event: progress\ndata: {\"state\":\"submitted\",\"inserted_observations\":60,\"total_observations\":100}\n\n"+
+						"event: progress\ndata: {\"state\":\"completed\",\"inserted_observations\":100,\"total_observations\":100}\n\n")
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{instance.GetProgressAction, audit.Successful, common.Params{"instance_id": "123"}},
+				)
+			})
+		})
+	})
+
+	finishedStates := []string{
+		models.CompletedState,
+		models.EditionConfirmedState,
+		models.AssociatedState,
+		models.PublishedState,
+		models.FailedState,
+	}
+
+	for _, state := range finishedStates {
+		state := state
+		Convey("Given an instance whose import has finished in the "+state+" state", t, func() {
+			mockedDataStore := &storetest.StorerMock{
+				GetInstanceFunc: func(id string) (*models.Instance, error) {
+					return importingInstance(state, 100, 100), nil
+				},
+			}
+
+			s := &instance.Store{Storer: mockedDataStore, Auditor: auditortest.New(), ProgressInterval: time.Millisecond}
+
+			Convey("When the progress of the instance is requested", func() {
+				r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123/progress", nil)
+				So(err, ShouldBeNil)
+				w := httptest.NewRecorder()
+				getProgressRouter(s).ServeHTTP(w, r)
+
+				Convey("Then a single event is sent and the stream is closed", func() {
+					So(w.Code, ShouldEqual, http.StatusOK)
+					So(len(mockedDataStore.GetInstanceCalls()), ShouldEqual, 1)
+					So(strings.Count(w.Body.String(), "event: progress"), ShouldEqual, 1)
+				})
+			})
+		})
+	}
+
+	Convey("Given an instance whose import does not progress", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return importingInstance(models.SubmittedState, 10, 100), nil
+			},
+		}
+
+		s := &instance.Store{Storer: mockedDataStore, Auditor: auditortest.New(), ProgressInterval: time.Millisecond}
+
+		Convey("When the client closes the stream", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123/progress", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			getProgressRouter(s).ServeHTTP(w, r.WithContext(ctx))
+
+			Convey("Then the handler returns having sent only the initial event", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.GetInstanceCalls()), ShouldBeGreaterThan, 1)
+				So(strings.Count(w.Body.String(), "event: progress"), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func Test_InstanceProgressReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("Given a GET request for the progress of an instance which does not exist", t, func() {
+		r, err := createRequestWithToken("GET", "http://localhost:21800/instances/123/progress", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return nil, errs.ErrInstanceNotFound
+			},
+		}
+
+		datasetPermissions := mocks.NewAuthHandlerMock()
+		permissions := mocks.NewAuthHandlerMock()
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then a not found response is returned and the failure audited", func() {
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrInstanceNotFound.Error())
+			So(permissions.Required.Calls, ShouldEqual, 1)
+
+			auditor.AssertRecordCalls(
+				auditortest.Expected{instance.GetProgressAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123"}},
+				auditortest.Expected{instance.GetProgressAction, audit.Unsuccessful, common.Params{"instance_id": "123"}},
+			)
+		})
+	})
+}
@@ -0,0 +1,92 @@
+package instance
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/pkg/errors"
+)
+
+// PurgeInstancesAction represents the audit action to purge abandoned instances
+const PurgeInstancesAction = "purgeInstances"
+
+// Purge removes instances which have not been updated within the retention period
+// given by the older_than query parameter, along with their dimension options. Only
+// instances abandoned during an import are removed, in the created, submitted or failed
+// states unless a comma separated list of states is given. Edition-confirmed, associated
+// and published instances are in use, so are never removed.
+func (s *Store) Purge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	olderThanQuery := r.URL.Query().Get("older_than")
+	stateFilterQuery := r.URL.Query().Get("state")
+	auditParams := common.Params{"older_than": olderThanQuery}
+	var stateFilterList []string
+
+	if stateFilterQuery != "" {
+		auditParams["state_query"] = stateFilterQuery
+		stateFilterList = splitFilterQuery(stateFilterQuery)
+	}
+	logData := audit.ToLogData(auditParams)
+
+	b, err := func() ([]byte, error) {
+		retention, err := time.ParseDuration(olderThanQuery)
+		if err != nil || retention <= 0 {
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrInvalidRetentionPeriod, "purge instances: invalid retention period"), logData)
+			return nil, errs.ErrInvalidRetentionPeriod
+		}
+
+		if len(stateFilterList) > 0 {
+			if err := models.ValidateStateFilter(stateFilterList); err != nil {
+				log.ErrorCtx(ctx, errors.WithMessage(err, "purge instances: filter state invalid"), logData)
+				return nil, taskError{error: err, status: http.StatusBadRequest}
+			}
+		}
+
+		if err := models.ValidatePurgeStates(stateFilterList); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "purge instances: filter state invalid"), logData)
+			return nil, err
+		}
+
+		olderThan := time.Now().UTC().Add(-retention)
+		logData["cutoff"] = olderThan
+
+		count, err := s.PurgeInstances(olderThan, stateFilterList)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "purge instances: store.PurgeInstances returned an error"), logData)
+			return nil, err
+		}
+
+		logData["count"] = count
+		auditParams["count"] = strconv.Itoa(count)
+
+		b, err := json.Marshal(models.PurgedInstances{Count: count})
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "purge instances: failed to marshal result to json"), logData)
+			return nil, err
+		}
+		return b, nil
+	}()
+
+	if err != nil {
+		if auditErr := s.auditor().Record(ctx, PurgeInstancesAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+		handleInstanceErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := s.auditor().Record(ctx, PurgeInstancesAction, audit.Successful, auditParams); auditErr != nil {
+		handleInstanceErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	writeBody(ctx, w, b)
+	log.InfoCtx(ctx, "purge instances: request successful", logData)
+}
@@ -0,0 +1,146 @@
+package instance_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/instance"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	storetest "github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_PurgeInstancesReturnsOk(t *testing.T) {
+	t.Parallel()
+	Convey("Given a DELETE request to purge instances older than 30 days in the created and submitted states", t, func() {
+		r, err := createRequestWithToken("DELETE", "http://localhost:21800/instances?older_than=720h&state=created,submitted", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			PurgeInstancesFunc: func(olderThan time.Time, states []string) (int, error) {
+				return 3, nil
+			},
+		}
+
+		datasetPermissions := mocks.NewAuthHandlerMock()
+		permissions := mocks.NewAuthHandlerMock()
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then the instances are purged and the count is returned and audited", func() {
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldContainSubstring, `"count":3`)
+			So(datasetPermissions.Required.Calls, ShouldEqual, 0)
+			So(permissions.Required.Calls, ShouldEqual, 1)
+			So(len(mockedDataStore.PurgeInstancesCalls()), ShouldEqual, 1)
+			So(mockedDataStore.PurgeInstancesCalls()[0].States, ShouldResemble, []string{"created", "submitted"})
+
+			cutoff := time.Now().UTC().Add(-720 * time.Hour)
+			So(mockedDataStore.PurgeInstancesCalls()[0].OlderThan, ShouldHappenWithin, time.Minute, cutoff)
+
+			auditor.AssertRecordCalls(
+				auditortest.Expected{instance.PurgeInstancesAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk"}},
+				auditortest.Expected{instance.PurgeInstancesAction, audit.Successful, common.Params{"older_than": "720h", "state_query": "created,submitted", "count": "3"}},
+			)
+		})
+	})
+}
+
+func Test_PurgeInstancesReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("Given a DELETE request to purge instances without a valid retention period", t, func() {
+		r, err := createRequestWithToken("DELETE", "http://localhost:21800/instances?older_than=yesterday", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{}
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then a bad request is returned and nothing is purged", func() {
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrInvalidRetentionPeriod.Error())
+			So(len(mockedDataStore.PurgeInstancesCalls()), ShouldEqual, 0)
+
+			auditor.AssertRecordCalls(
+				auditortest.Expected{instance.PurgeInstancesAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk"}},
+				auditortest.Expected{instance.PurgeInstancesAction, audit.Unsuccessful, common.Params{"older_than": "yesterday"}},
+			)
+		})
+	})
+
+	Convey("Given a DELETE request to purge published instances", t, func() {
+		r, err := createRequestWithToken("DELETE", "http://localhost:21800/instances?older_than=720h&state=completed,published", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{}
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then a bad request is returned and nothing is purged", func() {
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrPurgeInstancesInUse.Error())
+			So(len(mockedDataStore.PurgeInstancesCalls()), ShouldEqual, 0)
+
+			auditor.AssertRecordCalls(
+				auditortest.Expected{instance.PurgeInstancesAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk"}},
+				auditortest.Expected{instance.PurgeInstancesAction, audit.Unsuccessful, common.Params{"older_than": "720h", "state_query": "completed,published"}},
+			)
+		})
+	})
+
+	Convey("Given a DELETE request to purge instances which are edition-confirmed or associated", t, func() {
+		r, err := createRequestWithToken("DELETE", "http://localhost:21800/instances?older_than=720h&state=edition-confirmed,associated", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{}
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then a bad request is returned and nothing is purged", func() {
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrPurgeInstancesInUse.Error())
+			So(len(mockedDataStore.PurgeInstancesCalls()), ShouldEqual, 0)
+		})
+	})
+
+	Convey("Given the store fails to purge instances", t, func() {
+		r, err := createRequestWithToken("DELETE", "http://localhost:21800/instances?older_than=720h", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			PurgeInstancesFunc: func(olderThan time.Time, states []string) (int, error) {
+				return 0, errors.New("mongo is down")
+			},
+		}
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then an internal server error is returned", func() {
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrInternalServer.Error())
+			So(len(mockedDataStore.PurgeInstancesCalls()), ShouldEqual, 1)
+			So(mockedDataStore.PurgeInstancesCalls()[0].States, ShouldBeNil)
+
+			auditor.AssertRecordCalls(
+				auditortest.Expected{instance.PurgeInstancesAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk"}},
+				auditortest.Expected{instance.PurgeInstancesAction, audit.Unsuccessful, common.Params{"older_than": "720h"}},
+			)
+		})
+	})
+}
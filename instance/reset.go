@@ -0,0 +1,100 @@
+package instance
+
+import (
+	"encoding/json"
+	"net/http"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/ONSdigital/go-ns/request"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// ResetInstanceAction represents the audit action to reset an instance
+const ResetInstanceAction = "resetInstance"
+
+// Reset moves an edition-confirmed instance back to completed, or a failed
+// instance back to created, so the import can be redone. The edition and
+// version links added when the edition was confirmed are removed, published
+// instances can never be reset. The edition is left as it was before the
+// instance was confirmed to it, and the version number is released to be
+// claimed again unless a later number has been claimed since.
+func (s *Store) Reset(w http.ResponseWriter, r *http.Request) {
+
+	defer request.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	instanceID := vars["instance_id"]
+	auditParams := common.Params{"instance_id": instanceID}
+	logData := audit.ToLogData(auditParams)
+
+	b, err := func() ([]byte, error) {
+		currentInstance, err := s.GetInstance(instanceID)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "reset instance: store.GetInstance returned an error"), logData)
+			return nil, err
+		}
+
+		logData["current_state"] = currentInstance.State
+		auditParams["instance_state"] = currentInstance.State
+
+		if currentInstance.State == models.PublishedState {
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrResourcePublished, "reset instance: unable to reset a published instance"), logData)
+			return nil, errs.ErrResourcePublished
+		}
+
+		if currentInstance.State != models.EditionConfirmedState && currentInstance.State != models.FailedState {
+			log.ErrorCtx(ctx, errors.WithMessage(errs.ErrExpectedResourceStateOfEditionConfirmed, "reset instance: instance state invalid"), logData)
+			return nil, errs.ErrExpectedResourceStateOfEditionConfirmed
+		}
+
+		if err = s.ResetInstance(ctx, instanceID, currentInstance.State, currentInstance.UniqueTimestamp); err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "reset instance: store.ResetInstance returned an error"), logData)
+			return nil, err
+		}
+
+		if currentInstance.Version > 0 && currentInstance.Edition != "" {
+			logData["edition"] = currentInstance.Edition
+			logData["version"] = currentInstance.Version
+			if err = s.unconfirmEdition(ctx, currentInstance.Links.Dataset.ID, currentInstance.Edition, currentInstance.Version, logData); err != nil {
+				return nil, err
+			}
+		}
+
+		instance, err := s.GetInstance(instanceID)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "reset instance: store.GetInstance for response returned an error"), logData)
+			return nil, err
+		}
+
+		b, err := json.Marshal(instance)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "reset instance: failed to marshal instance to json"), logData)
+			return nil, err
+		}
+
+		return b, nil
+	}()
+
+	if err != nil {
+		if auditErr := s.auditor().Record(ctx, ResetInstanceAction, audit.Unsuccessful, auditParams); auditErr != nil {
+			err = auditErr
+		}
+
+		handleInstanceErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := s.auditor().Record(ctx, ResetInstanceAction, audit.Successful, auditParams); auditErr != nil {
+		handleInstanceErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	writeBody(ctx, w, b)
+	log.InfoCtx(ctx, "reset instance: request successful", logData)
+}
@@ -0,0 +1,295 @@
+package instance_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/instance"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	storetest "github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/globalsign/mgo/bson"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_ResetInstanceReturnsOk(t *testing.T) {
+	t.Parallel()
+	Convey("Given a POST request to reset an edition-confirmed instance is made", t, func() {
+		r, err := createRequestWithToken("POST", "http://localhost:21800/instances/123/reset", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		reset := false
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				if reset {
+					return &models.Instance{
+						InstanceID: "123",
+						Links: &models.InstanceLinks{
+							Dataset: &models.LinkObject{ID: "234", HRef: "example.com/234"},
+						},
+						State: models.CompletedState,
+					}, nil
+				}
+
+				return &models.Instance{
+					InstanceID: "123",
+					Edition:    "2017",
+					Links: &models.InstanceLinks{
+						Dataset: &models.LinkObject{ID: "234", HRef: "example.com/234"},
+						Edition: &models.LinkObject{ID: "2017", HRef: "example.com/234/editions/2017"},
+						Version: &models.LinkObject{ID: "2", HRef: "example.com/234/editions/2017/versions/2"},
+					},
+					State:           models.EditionConfirmedState,
+					UniqueTimestamp: 1,
+					Version:         2,
+				}, nil
+			},
+			ResetInstanceFunc: func(ctx context.Context, id, currentState string, uniqueTimestamp bson.MongoTimestamp) error {
+				reset = true
+				return nil
+			},
+			GetEditionFunc: func(datasetID, edition, state string) (*models.EditionUpdate, error) {
+				return confirmedEdition("2"), nil
+			},
+			GetLatestVersionFunc: func(datasetID, edition, state string) (*models.Version, error) {
+				return &models.Version{Edition: "2017", State: models.PublishedState, Version: 1}, nil
+			},
+			UpsertEditionFunc: func(datasetID, edition string, editionDoc *models.EditionUpdate) error {
+				return nil
+			},
+			ReleaseVersionFunc: func(datasetID, edition string, version int) error {
+				return nil
+			},
+		}
+
+		datasetPermissions := mocks.NewAuthHandlerMock()
+		permissions := mocks.NewAuthHandlerMock()
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then the instance is reset and the version links are cleared", func() {
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(datasetPermissions.Required.Calls, ShouldEqual, 0)
+			So(permissions.Required.Calls, ShouldEqual, 1)
+			So(len(mockedDataStore.GetInstanceCalls()), ShouldEqual, 3)
+			So(len(mockedDataStore.ResetInstanceCalls()), ShouldEqual, 1)
+			So(mockedDataStore.ResetInstanceCalls()[0].ID, ShouldEqual, "123")
+			So(mockedDataStore.ResetInstanceCalls()[0].CurrentState, ShouldEqual, models.EditionConfirmedState)
+			So(mockedDataStore.ResetInstanceCalls()[0].UniqueTimestamp, ShouldEqual, bson.MongoTimestamp(1))
+
+			So(w.Body.String(), ShouldContainSubstring, `"state":"completed"`)
+			So(w.Body.String(), ShouldNotContainSubstring, `"version":`)
+			So(w.Body.String(), ShouldNotContainSubstring, `"edition":`)
+
+			So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 1)
+			So(mockedDataStore.UpsertEditionCalls()[0].EditionDoc.Next.Links.LatestVersion.ID, ShouldEqual, "1")
+			So(len(mockedDataStore.ReleaseVersionCalls()), ShouldEqual, 1)
+			So(mockedDataStore.ReleaseVersionCalls()[0].DatasetID, ShouldEqual, "234")
+			So(mockedDataStore.ReleaseVersionCalls()[0].EditionID, ShouldEqual, "2017")
+			So(mockedDataStore.ReleaseVersionCalls()[0].Version, ShouldEqual, 2)
+
+			auditor.AssertRecordCalls(
+				auditortest.Expected{instance.ResetInstanceAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123"}},
+				auditortest.Expected{instance.ResetInstanceAction, audit.Successful, common.Params{"instance_id": "123", "instance_state": models.EditionConfirmedState}},
+			)
+		})
+	})
+}
+
+func Test_ResetInstanceUnconfirmsEdition(t *testing.T) {
+	t.Parallel()
+	reset := func(version int, editionDoc *models.EditionUpdate, latestErr error) (*httptest.ResponseRecorder, *storetest.StorerMock) {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{
+					InstanceID: "123",
+					Edition:    "2017",
+					Links: &models.InstanceLinks{
+						Dataset: &models.LinkObject{ID: "234", HRef: "example.com/234"},
+					},
+					State:   models.EditionConfirmedState,
+					Version: version,
+				}, nil
+			},
+			ResetInstanceFunc: func(ctx context.Context, id, currentState string, uniqueTimestamp bson.MongoTimestamp) error {
+				return nil
+			},
+			GetEditionFunc: func(datasetID, edition, state string) (*models.EditionUpdate, error) {
+				return editionDoc, nil
+			},
+			GetLatestVersionFunc: func(datasetID, edition, state string) (*models.Version, error) {
+				if latestErr != nil {
+					return nil, latestErr
+				}
+				return &models.Version{Edition: "2017", Version: version - 1}, nil
+			},
+			UpsertEditionFunc: func(datasetID, edition string, editionDoc *models.EditionUpdate) error {
+				return nil
+			},
+			DeleteEditionFunc: func(id string) error {
+				return nil
+			},
+			ReleaseVersionFunc: func(datasetID, edition string, version int) error {
+				return nil
+			},
+		}
+
+		r, err := createRequestWithToken("POST", "http://localhost:21800/instances/123/reset", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		return w, mockedDataStore
+	}
+
+	Convey("Given an instance confirmed as the only version of a new edition", t, func() {
+		Convey("When the instance is reset", func() {
+			w, mockedDataStore := reset(1, confirmedEdition("1"), errs.ErrVersionNotFound)
+
+			Convey("Then the edition is removed and the version number released", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.DeleteEditionCalls()), ShouldEqual, 1)
+				So(mockedDataStore.DeleteEditionCalls()[0].ID, ShouldEqual, "edition-id")
+				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.ReleaseVersionCalls()), ShouldEqual, 1)
+				So(mockedDataStore.ReleaseVersionCalls()[0].Version, ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given an instance whose edition links point at an earlier version", t, func() {
+		Convey("When the instance is reset", func() {
+			w, mockedDataStore := reset(2, confirmedEdition("1"), nil)
+
+			Convey("Then the edition is left alone and the version number released", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.GetLatestVersionCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.DeleteEditionCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.ReleaseVersionCalls()), ShouldEqual, 1)
+				So(mockedDataStore.ReleaseVersionCalls()[0].Version, ShouldEqual, 2)
+			})
+		})
+	})
+
+	Convey("Given the edition of an instance cannot be updated", t, func() {
+		Convey("When the instance is reset", func() {
+			w, mockedDataStore := reset(2, confirmedEdition("2"), errs.ErrInternalServer)
+
+			Convey("Then an error is returned and the version number is not released", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+				So(len(mockedDataStore.UpsertEditionCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.ReleaseVersionCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+// confirmedEdition returns an unpublished edition whose latest version link points at the given version
+func confirmedEdition(latestVersion string) *models.EditionUpdate {
+	return &models.EditionUpdate{
+		ID: "edition-id",
+		Next: &models.Edition{
+			Edition: "2017",
+			State:   models.EditionConfirmedState,
+			Links: &models.EditionUpdateLinks{
+				Dataset:       &models.LinkObject{ID: "234", HRef: "example.com/234"},
+				LatestVersion: &models.LinkObject{ID: latestVersion, HRef: "example.com/234/editions/2017/versions/" + latestVersion},
+			},
+		},
+	}
+}
+
+func Test_ResetFailedInstanceReturnsOK(t *testing.T) {
+	t.Parallel()
+	Convey("Given a POST request to reset a failed instance is made", t, func() {
+		r, err := createRequestWithToken("POST", "http://localhost:21800/instances/123/reset", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{InstanceID: "123", State: models.FailedState, FailureReason: "observation import timed out", UniqueTimestamp: 1}, nil
+			},
+			ResetInstanceFunc: func(ctx context.Context, id, currentState string, uniqueTimestamp bson.MongoTimestamp) error {
+				return nil
+			},
+		}
+
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditortest.New(), mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then the instance is reset from its failed state", func() {
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(len(mockedDataStore.ResetInstanceCalls()), ShouldEqual, 1)
+			So(mockedDataStore.ResetInstanceCalls()[0].CurrentState, ShouldEqual, models.FailedState)
+		})
+	})
+}
+
+func Test_ResetInstanceReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("Given a POST request to reset a published instance is made", t, func() {
+		r, err := createRequestWithToken("POST", "http://localhost:21800/instances/123/reset", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{State: models.PublishedState}, nil
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then a forbidden status is returned and the instance is not reset", func() {
+			So(w.Code, ShouldEqual, http.StatusForbidden)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrResourcePublished.Error())
+			So(len(mockedDataStore.ResetInstanceCalls()), ShouldEqual, 0)
+
+			auditor.AssertRecordCalls(
+				auditortest.Expected{instance.ResetInstanceAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123"}},
+				auditortest.Expected{instance.ResetInstanceAction, audit.Unsuccessful, common.Params{"instance_id": "123", "instance_state": models.PublishedState}},
+			)
+		})
+	})
+
+	Convey("Given a POST request to reset an instance which has not been edition confirmed is made", t, func() {
+		r, err := createRequestWithToken("POST", "http://localhost:21800/instances/123/reset", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceFunc: func(id string) (*models.Instance, error) {
+				return &models.Instance{State: models.SubmittedState}, nil
+			},
+		}
+
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then a forbidden status is returned and the instance is not reset", func() {
+			So(w.Code, ShouldEqual, http.StatusForbidden)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrExpectedResourceStateOfEditionConfirmed.Error())
+			So(len(mockedDataStore.GetInstanceCalls()), ShouldEqual, 2)
+			So(len(mockedDataStore.ResetInstanceCalls()), ShouldEqual, 0)
+
+			auditor.AssertRecordCalls(
+				auditortest.Expected{instance.ResetInstanceAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk", "instance_id": "123"}},
+				auditortest.Expected{instance.ResetInstanceAction, audit.Unsuccessful, common.Params{"instance_id": "123", "instance_state": models.SubmittedState}},
+			)
+		})
+	})
+}
@@ -0,0 +1,55 @@
+package instance
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/pkg/errors"
+)
+
+// GetInstancesSummaryAction represents the audit action to summarise the number of instances in each state
+const GetInstancesSummaryAction = "getInstancesSummary"
+
+// Summary returns the number of instances in each state, counted by the datastore so that the instances need not be
+// listed to be tallied
+func (s *Store) Summary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logData := log.Data{}
+
+	b, err := func() ([]byte, error) {
+		counts, err := s.GetInstanceStateCounts()
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "instances summary: store.GetInstanceStateCounts returned an error"), logData)
+			return nil, err
+		}
+
+		summary := models.NewInstancesSummary(counts)
+		logData["total_count"] = summary.TotalCount
+
+		b, err := json.Marshal(summary)
+		if err != nil {
+			log.ErrorCtx(ctx, errors.WithMessage(err, "instances summary: failed to marshal summary to json"), logData)
+			return nil, err
+		}
+		return b, nil
+	}()
+
+	if err != nil {
+		if auditErr := s.auditor().Record(ctx, GetInstancesSummaryAction, audit.Unsuccessful, nil); auditErr != nil {
+			err = auditErr
+		}
+		handleInstanceErr(ctx, err, w, logData)
+		return
+	}
+
+	if auditErr := s.auditor().Record(ctx, GetInstancesSummaryAction, audit.Successful, nil); auditErr != nil {
+		handleInstanceErr(ctx, auditErr, w, logData)
+		return
+	}
+
+	writeBody(ctx, w, b)
+	log.InfoCtx(ctx, "instances summary: request successful", logData)
+}
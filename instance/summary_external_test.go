@@ -0,0 +1,123 @@
+package instance_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/instance"
+	"github.com/ONSdigital/dp-dataset-api/mocks"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	storetest "github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	"github.com/ONSdigital/go-ns/audit"
+	"github.com/ONSdigital/go-ns/audit/auditortest"
+	"github.com/ONSdigital/go-ns/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_InstancesSummaryReturnsOk(t *testing.T) {
+	t.Parallel()
+	Convey("Given instances in various states", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceStateCountsFunc: func() ([]models.InstanceStateCount, error) {
+				return []models.InstanceStateCount{
+					{State: models.CompletedState, Count: 1},
+					{State: models.CreatedState, Count: 2},
+					{State: models.PublishedState, Count: 3},
+					{State: models.SubmittedState, Count: 1},
+				}, nil
+			},
+		}
+
+		Convey("When a summary of the instances is requested", func() {
+			r, err := createRequestWithToken("GET", "http://localhost:21800/instances/summary", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+
+			datasetPermissions := mocks.NewAuthHandlerMock()
+			permissions := mocks.NewAuthHandlerMock()
+			auditor := auditortest.New()
+			datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, datasetPermissions, permissions)
+			datasetAPI.Router.ServeHTTP(w, r)
+
+			Convey("Then the number of instances in each state is returned without the instances being listed", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(datasetPermissions.Required.Calls, ShouldEqual, 0)
+				So(permissions.Required.Calls, ShouldEqual, 1)
+				So(len(mockedDataStore.GetInstanceStateCountsCalls()), ShouldEqual, 1)
+				So(len(mockedDataStore.GetInstancesCalls()), ShouldEqual, 0)
+				So(len(mockedDataStore.GetInstanceCalls()), ShouldEqual, 0)
+
+				var summary models.InstancesSummary
+				So(json.Unmarshal(w.Body.Bytes(), &summary), ShouldBeNil)
+				So(summary.States, ShouldResemble, map[string]int{
+					models.CompletedState: 1,
+					models.CreatedState:   2,
+					models.PublishedState: 3,
+					models.SubmittedState: 1,
+				})
+				So(summary.TotalCount, ShouldEqual, 7)
+
+				auditor.AssertRecordCalls(
+					auditortest.Expected{instance.GetInstancesSummaryAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk"}},
+					auditortest.Expected{instance.GetInstancesSummaryAction, audit.Successful, nil},
+				)
+			})
+		})
+	})
+}
+
+func Test_InstancesSummaryReturnsError(t *testing.T) {
+	t.Parallel()
+	Convey("Given the store fails to count instances", t, func() {
+		r, err := createRequestWithToken("GET", "http://localhost:21800/instances/summary", nil)
+		So(err, ShouldBeNil)
+		w := httptest.NewRecorder()
+
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceStateCountsFunc: func() ([]models.InstanceStateCount, error) {
+				return nil, errors.New("mongo is down")
+			},
+		}
+		auditor := auditortest.New()
+		datasetAPI := getAPIWithMocks(mockedDataStore, &mocks.DownloadsGeneratorMock{}, auditor, mocks.NewAuthHandlerMock(), mocks.NewAuthHandlerMock())
+		datasetAPI.Router.ServeHTTP(w, r)
+
+		Convey("Then an internal server error is returned", func() {
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			So(w.Body.String(), ShouldContainSubstring, errs.ErrInternalServer.Error())
+
+			auditor.AssertRecordCalls(
+				auditortest.Expected{instance.GetInstancesSummaryAction, audit.Attempted, common.Params{"caller_identity": "someone@ons.gov.uk"}},
+				auditortest.Expected{instance.GetInstancesSummaryAction, audit.Unsuccessful, nil},
+			)
+		})
+	})
+}
+
+func Test_InstancesSummaryWithoutAuditorReturnsOk(t *testing.T) {
+	t.Parallel()
+	Convey("Given an instance store which has no auditor", t, func() {
+		mockedDataStore := &storetest.StorerMock{
+			GetInstanceStateCountsFunc: func() ([]models.InstanceStateCount, error) {
+				return []models.InstanceStateCount{{State: models.CreatedState, Count: 2}}, nil
+			},
+		}
+		instanceStore := &instance.Store{Storer: mockedDataStore}
+
+		Convey("When a summary of the instances is requested", func() {
+			r := httptest.NewRequest("GET", "http://localhost:21800/instances/summary", nil)
+			w := httptest.NewRecorder()
+
+			So(func() { instanceStore.Summary(w, r) }, ShouldNotPanic)
+
+			Convey("Then the summary is returned without being audited", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(len(mockedDataStore.GetInstanceStateCountsCalls()), ShouldEqual, 1)
+			})
+		})
+	})
+}
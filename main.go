@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -11,23 +12,29 @@ import (
 	"github.com/ONSdigital/dp-dataset-api/api"
 	"github.com/ONSdigital/dp-dataset-api/config"
 	"github.com/ONSdigital/dp-dataset-api/download"
+	"github.com/ONSdigital/dp-dataset-api/hierarchy"
+	"github.com/ONSdigital/dp-dataset-api/metrics"
 	"github.com/ONSdigital/dp-dataset-api/mongo"
 	"github.com/ONSdigital/dp-dataset-api/schema"
 	"github.com/ONSdigital/dp-dataset-api/store"
 	"github.com/ONSdigital/dp-dataset-api/url"
+	"github.com/ONSdigital/dp-dataset-api/webhook"
 	"github.com/ONSdigital/dp-graph/graph"
+	"github.com/ONSdigital/dp-graph/observation"
 	rchttp "github.com/ONSdigital/dp-rchttp"
 	"github.com/ONSdigital/go-ns/audit"
 	"github.com/ONSdigital/go-ns/healthcheck"
 	"github.com/ONSdigital/go-ns/kafka"
 	"github.com/ONSdigital/go-ns/log"
 	mongolib "github.com/ONSdigital/go-ns/mongo"
+	"github.com/ONSdigital/go-ns/server"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 )
 
 // check that DatsetAPIStore satifies the the store.Storer interface
 var _ store.Storer = (*DatsetAPIStore)(nil)
+var _ store.Storer = (*transactionStore)(nil)
 
 //DatsetAPIStore is a wrapper which embeds Neo4j Mongo stucts which between them satisfy the store.Storer interface.
 type DatsetAPIStore struct {
@@ -35,18 +42,56 @@ type DatsetAPIStore struct {
 	*graph.DB
 }
 
+// WithTransaction runs fn against a store whose mongo writes are undone if fn returns an error.
+// Writes to the graph database are not part of the transaction.
+func (s DatsetAPIStore) WithTransaction(fn func(store.Storer) error) error {
+	return s.Mongo.RunInTransaction(func(tx *mongo.Transaction) error {
+		return fn(transactionStore{tx, s.DB})
+	})
+}
+
+// transactionStore is the store.Storer handed to a transaction, recording mongo writes through the transaction.
+// The graph database is not embedded, as its drivers would make the mongo methods ambiguous, so the graph
+// methods of the store.Storer are forwarded explicitly.
+type transactionStore struct {
+	*mongo.Transaction
+	graphDB *graph.DB
+}
+
+// AddVersionDetailsToInstance calls the graph database
+func (s transactionStore) AddVersionDetailsToInstance(ctx context.Context, instanceID string, datasetID string, edition string, version int) error {
+	return s.graphDB.AddVersionDetailsToInstance(ctx, instanceID, datasetID, edition, version)
+}
+
+// SetInstanceIsPublished calls the graph database
+func (s transactionStore) SetInstanceIsPublished(ctx context.Context, instanceID string) error {
+	return s.graphDB.SetInstanceIsPublished(ctx, instanceID)
+}
+
+// StreamCSVRows calls the graph database
+func (s transactionStore) StreamCSVRows(ctx context.Context, filter *observation.Filter, limit *int) (observation.StreamRowReader, error) {
+	return s.graphDB.StreamCSVRows(ctx, filter, limit)
+}
+
+// WithTransaction runs fn within the transaction already in progress
+func (s transactionStore) WithTransaction(fn func(store.Storer) error) error {
+	return fn(s)
+}
+
 type initialisedStruct struct {
-	generateDownloadsProducer bool
-	auditProducer             bool
-	mongo                     bool
-	healthTicker              bool
+	generateDownloadsProducer  bool
+	dataImportCompleteProducer bool
+	auditProducer              bool
+	mongo                      bool
+	healthTicker               bool
 }
 
 var initialised = initialisedStruct{
-	generateDownloadsProducer: true,
-	auditProducer:             true,
-	mongo:                     true,
-	healthTicker:              true,
+	generateDownloadsProducer:  true,
+	dataImportCompleteProducer: true,
+	auditProducer:              true,
+	mongo:                      true,
+	healthTicker:               true,
 }
 
 func main() {
@@ -76,6 +121,12 @@ func main() {
 		initialised.generateDownloadsProducer = false
 	}
 
+	dataImportCompleteProducer, err := kafka.NewProducer(cfg.KafkaAddr, cfg.DataImportCompleteTopic, 0)
+	if err != nil {
+		log.Error(errors.Wrap(err, "error creating kakfa data import complete producer"), nil)
+		initialised.dataImportCompleteProducer = false
+	}
+
 	var auditor audit.AuditorService
 	var auditProducer kafka.Producer
 
@@ -94,12 +145,17 @@ func main() {
 		auditor = &audit.NopAuditor{}
 	}
 
+	// reads are only served by a secondary for the public API, which only handles GET requests, so that the
+	// publishing API always reads what it writes
 	mongodb := &mongo.Mongo{
-		CodeListURL: cfg.CodeListAPIURL,
-		Collection:  cfg.MongoConfig.Collection,
-		Database:    cfg.MongoConfig.Database,
-		DatasetURL:  cfg.DatasetAPIURL,
-		URI:         cfg.MongoConfig.BindAddr,
+		CodeListURL:       cfg.CodeListAPIURL,
+		Collection:        cfg.MongoConfig.Collection,
+		Database:          cfg.MongoConfig.Database,
+		DatasetURL:        cfg.DatasetAPIURL,
+		URI:               cfg.MongoConfig.BindAddr,
+		SecondaryReads:    cfg.MongoConfig.SecondaryReads && !cfg.EnablePrivateEnpoints,
+		WriteMaxAttempts:  cfg.MongoConfig.WriteMaxAttempts,
+		WriteRetryBackoff: cfg.MongoConfig.WriteRetryBackoff,
 	}
 
 	session, err := mongodb.Init()
@@ -118,13 +174,38 @@ func main() {
 		log.ErrorC("failed to initialise graph driver", err, nil)
 	}
 
-	store := store.DataStore{Backend: DatsetAPIStore{mongodb, graphDB}}
+	metricsRegistry := metrics.NewRegistry()
+
+	store := store.DataStore{Backend: metrics.NewInstrumentedStorer(DatsetAPIStore{mongodb, graphDB}, metricsRegistry)}
 
 	downloadGenerator := &download.Generator{
 		Producer:   generateDownloadsProducer,
 		Marshaller: schema.GenerateDownloadsEvent,
 	}
 
+	hierarchyBuildTrigger := &hierarchy.Trigger{
+		Producer:   dataImportCompleteProducer,
+		Marshaller: schema.DataImportCompleteEvent,
+	}
+
+	// the webhooks have their own client, as changing the timeout of the default client would change it for every
+	// other client sharing it
+	publishNotifier := &webhook.Notifier{
+		URLs: cfg.PublishWebhookURLs,
+		Client: &rchttp.Client{
+			MaxRetries:         cfg.PublishWebhookMaxRetries,
+			ExponentialBackoff: true,
+			RetryTime:          rchttp.DefaultClient.RetryTime,
+			HTTPClient:         &http.Client{Timeout: cfg.PublishWebhookTimeout},
+		},
+	}
+
+	// download links are only signed when a key is configured
+	var downloadURLSigner api.DownloadURLSigner
+	if cfg.DownloadURLSigningKey != "" {
+		downloadURLSigner = &download.URLSigner{Key: []byte(cfg.DownloadURLSigningKey)}
+	}
+
 	var healthyClients []healthcheck.Client
 	healthyClients = append(healthyClients, *graphDB)
 	if initialised.mongo {
@@ -145,11 +226,25 @@ func main() {
 
 	apiErrors := make(chan error, 1)
 
-	urlBuilder := url.NewBuilder(cfg.WebsiteURL)
+	urlBuilder := url.NewBuilder(cfg.WebsiteURL, cfg.DatasetAPIURL)
 
 	datasetPermissions, permissions := getAuthorisationHandlers(cfg)
 
-	api.CreateAndInitialiseDatasetAPI(*cfg, store, urlBuilder, apiErrors, downloadGenerator, auditor, datasetPermissions, permissions)
+	api.CreateAndInitialiseDatasetAPI(*cfg, store, urlBuilder, apiErrors, downloadGenerator, hierarchyBuildTrigger, publishNotifier, downloadURLSigner, auditor, datasetPermissions, permissions, metricsRegistry)
+
+	metricsRouter := mux.NewRouter()
+	metricsRouter.Handle("/metrics", metricsRegistry.Handler()).Methods("GET")
+	metricsServer := server.New(cfg.MetricsBindAddr, metricsRouter)
+	metricsServer.HandleOSSignals = false
+
+	go func() {
+		log.Debug("Starting metrics server...", log.Data{"bind_address": cfg.MetricsBindAddr})
+		// the server closing is expected during a graceful shutdown, so it is not reported as an error
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.ErrorC("metrics http server returned error", err, nil)
+			apiErrors <- err
+		}
+	}()
 
 	// Gracefully shutdown the application closing any open resources.
 	gracefulShutdown := func() {
@@ -159,6 +254,10 @@ func main() {
 		// stop any incoming requests before closing any outbound connections
 		api.Close(ctx)
 
+		if err = metricsServer.Shutdown(ctx); err != nil {
+			log.Error(err, nil)
+		}
+
 		if initialised.healthTicker {
 			healthTicker.Close()
 		}
@@ -179,6 +278,12 @@ func main() {
 			}
 		}
 
+		if initialised.dataImportCompleteProducer {
+			if err = dataImportCompleteProducer.Close(ctx); err != nil {
+				log.Error(errors.Wrap(err, "error while attempting to shutdown kafka data import complete producer"), nil)
+			}
+		}
+
 		if cfg.EnablePrivateEnpoints {
 			log.Debug("exiting audit producer", nil)
 			if initialised.auditProducer {
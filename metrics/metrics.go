@@ -0,0 +1,230 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the request duration histogram buckets
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry holds the metrics collected by the dataset API and renders them in
+// the Prometheus text exposition format
+type Registry struct {
+	mu         sync.Mutex
+	histograms []*HistogramVec
+	counters   []*CounterVec
+}
+
+// NewRegistry creates an empty metrics registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewHistogramVec registers a histogram, partitioned by the given label names
+func (reg *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	h := &HistogramVec{
+		name:       name,
+		help:       help,
+		buckets:    buckets,
+		labelNames: labelNames,
+		series:     make(map[string]*histogram),
+	}
+
+	reg.mu.Lock()
+	reg.histograms = append(reg.histograms, h)
+	reg.mu.Unlock()
+	return h
+}
+
+// NewCounterVec registers a counter, partitioned by the given label names
+func (reg *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		series:     make(map[string]*counter),
+	}
+
+	reg.mu.Lock()
+	reg.counters = append(reg.counters, c)
+	reg.mu.Unlock()
+	return c
+}
+
+// Handler returns a http.Handler which serves the registered metrics
+func (reg *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		reg.Write(w)
+	})
+}
+
+// Write renders every registered metric to w in the Prometheus text exposition format
+func (reg *Registry) Write(w io.Writer) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for _, h := range reg.histograms {
+		h.write(w)
+	}
+
+	for _, c := range reg.counters {
+		c.write(w)
+	}
+}
+
+// HistogramVec is a histogram partitioned by a set of labels
+type HistogramVec struct {
+	mu         sync.Mutex
+	name       string
+	help       string
+	buckets    []float64
+	labelNames []string
+	series     map[string]*histogram
+}
+
+type histogram struct {
+	labels string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe records a value against the series identified by the label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	labels := formatLabels(h.labelNames, labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[labels]
+	if !ok {
+		s = &histogram{labels: labels, counts: make([]uint64, len(h.buckets))}
+		h.series[labels] = s
+	}
+
+	for i, upperBound := range h.buckets {
+		if value <= upperBound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += value
+}
+
+// Count returns the number of values observed against the series identified by the label values
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s, ok := h.series[formatLabels(h.labelNames, labelValues)]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", h.name, h.help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", h.name)
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := h.series[key]
+		for i, upperBound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket{%s} %d\n", h.name, joinLabels(s.labels, fmt.Sprintf(`le="%g"`, upperBound)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s} %d\n", h.name, joinLabels(s.labels, `le="+Inf"`), s.count)
+		fmt.Fprintf(w, "%s_sum{%s} %g\n", h.name, s.labels, s.sum)
+		fmt.Fprintf(w, "%s_count{%s} %d\n", h.name, s.labels, s.count)
+	}
+}
+
+// CounterVec is a counter partitioned by a set of labels
+type CounterVec struct {
+	mu         sync.Mutex
+	name       string
+	help       string
+	labelNames []string
+	series     map[string]*counter
+}
+
+type counter struct {
+	labels string
+	value  uint64
+}
+
+// Inc increments the series identified by the label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	labels := formatLabels(c.labelNames, labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.series[labels]
+	if !ok {
+		s = &counter{labels: labels}
+		c.series[labels] = s
+	}
+	s.value++
+}
+
+// Value returns the current value of the series identified by the label values
+func (c *CounterVec) Value(labelValues ...string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if s, ok := c.series[formatLabels(c.labelNames, labelValues)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", c.name, c.help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", c.name)
+
+	keys := make([]string, 0, len(c.series))
+	for key := range c.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s{%s} %d\n", c.name, c.series[key].labels, c.series[key].value)
+	}
+}
+
+func formatLabels(names, values []string) string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		var value string
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs[i] = fmt.Sprintf("%s=%q", name, value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func joinLabels(labels, extra string) string {
+	if labels == "" {
+		return extra
+	}
+	return labels + "," + extra
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// RequestDurationMetric is the name of the histogram recording the time taken to handle each request
+const RequestDurationMetric = "dataset_api_http_request_duration_seconds"
+
+// unmatchedRoute is the route label given to requests which do not match a registered route
+const unmatchedRoute = "unmatched"
+
+// NewRequestDurationHistogram registers the request duration histogram, labelled by route, method and status
+func NewRequestDurationHistogram(reg *Registry) *HistogramVec {
+	return reg.NewHistogramVec(RequestDurationMetric, "Time taken to handle HTTP requests, in seconds.", DefaultBuckets, "route", "method", "status")
+}
+
+// Middleware records the duration and response status of every request handled by the router. Requests are
+// labelled with the path template of the matched route, rather than the request path, to bound the number of series.
+func Middleware(requestDurations *HistogramVec, router *mux.Router) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			h.ServeHTTP(rw, r)
+
+			requestDurations.Observe(time.Since(start).Seconds(), routeTemplate(router, r), r.Method, strconv.Itoa(rw.status))
+		})
+	}
+}
+
+func routeTemplate(router *mux.Router, r *http.Request) string {
+	var match mux.RouteMatch
+	if !router.Match(r, &match) || match.Route == nil {
+		return unmatchedRoute
+	}
+
+	template, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return unmatchedRoute
+	}
+	return template
+}
+
+// statusRecorder captures the status code written by the wrapped handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rw *statusRecorder) WriteHeader(status int) {
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+// Flush sends any buffered data to the client, allowing streaming handlers to be instrumented
+func (rw *statusRecorder) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
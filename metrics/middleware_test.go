@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMiddleware(t *testing.T) {
+	Convey("Given a router wrapped in the metrics middleware", t, func() {
+		reg := NewRegistry()
+		requestDurations := NewRequestDurationHistogram(reg)
+
+		router := mux.NewRouter()
+		router.HandleFunc("/datasets/{dataset_id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}).Methods("GET")
+
+		handler := Middleware(requestDurations, router)(router)
+
+		Convey("When a request is made to a registered route", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/datasets/123", nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			Convey("Then the histogram observes the request against the route template, method and status", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+				So(requestDurations.Count("/datasets/{dataset_id}", "GET", "404"), ShouldEqual, 1)
+				So(requestDurations.Count("/datasets/123", "GET", "404"), ShouldEqual, 0)
+
+				var buf bytes.Buffer
+				reg.Write(&buf)
+				So(buf.String(), ShouldContainSubstring, `dataset_api_http_request_duration_seconds_count{route="/datasets/{dataset_id}",method="GET",status="404"} 1`)
+			})
+		})
+
+		Convey("When a streaming handler flushes the response", func() {
+			router.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+				w.(http.Flusher).Flush()
+			}).Methods("GET")
+
+			r := httptest.NewRequest("GET", "http://localhost:22000/stream", nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			Convey("Then the flush is passed to the underlying response writer", func() {
+				So(w.Flushed, ShouldBeTrue)
+				So(requestDurations.Count("/stream", "GET", "200"), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When a request is made to an unregistered route", func() {
+			r := httptest.NewRequest("GET", "http://localhost:22000/unknown", nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			Convey("Then the histogram observes the request against the unmatched route", func() {
+				So(requestDurations.Count(unmatchedRoute, "GET", "404"), ShouldEqual, 1)
+			})
+		})
+	})
+}
@@ -0,0 +1,488 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/dp-dataset-api/store"
+	"github.com/ONSdigital/dp-graph/observation"
+	"github.com/globalsign/mgo/bson"
+)
+
+// StoreCallsMetric is the name of the counter recording calls made to the data store
+const StoreCallsMetric = "dataset_api_store_calls_total"
+
+const (
+	resultSuccess = "success"
+	resultError   = "error"
+)
+
+// check that InstrumentedStorer satisfies the store.Storer interface
+var _ store.Storer = (*InstrumentedStorer)(nil)
+
+// InstrumentedStorer decorates a store.Storer, counting each call by method and result
+type InstrumentedStorer struct {
+	store.Storer
+	calls *CounterVec
+}
+
+// NewInstrumentedStorer wraps the given Storer, registering the store call counter with the registry
+func NewInstrumentedStorer(storer store.Storer, reg *Registry) *InstrumentedStorer {
+	return &InstrumentedStorer{
+		Storer: storer,
+		calls:  reg.NewCounterVec(StoreCallsMetric, "Number of calls made to the data store.", "method", "result"),
+	}
+}
+
+func (s *InstrumentedStorer) record(method string, err error) {
+	result := resultSuccess
+	if err != nil {
+		result = resultError
+	}
+	s.calls.Inc(method, result)
+}
+
+// AddAuditEvent calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) AddAuditEvent(event *models.AuditEvent) error {
+	err := s.Storer.AddAuditEvent(event)
+	s.record("AddAuditEvent", err)
+	return err
+}
+
+// AddDimensionToInstance calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) AddDimensionToInstance(dimension *models.CachedDimensionOption) error {
+	err := s.Storer.AddDimensionToInstance(dimension)
+	s.record("AddDimensionToInstance", err)
+	return err
+}
+
+// AddEventToInstance calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) AddEventToInstance(instanceID string, event *models.Event) error {
+	err := s.Storer.AddEventToInstance(instanceID, event)
+	s.record("AddEventToInstance", err)
+	return err
+}
+
+// AddInstance calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) AddInstance(instance *models.Instance) (*models.Instance, error) {
+	result, err := s.Storer.AddInstance(instance)
+	s.record("AddInstance", err)
+	return result, err
+}
+
+// CheckDatasetExists calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) CheckDatasetExists(ID, state string) error {
+	err := s.Storer.CheckDatasetExists(ID, state)
+	s.record("CheckDatasetExists", err)
+	return err
+}
+
+// CheckEditionExists calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) CheckEditionExists(ID, editionID, state string) error {
+	err := s.Storer.CheckEditionExists(ID, editionID, state)
+	s.record("CheckEditionExists", err)
+	return err
+}
+
+// GetAuditEvents calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetAuditEvents(instanceID string) ([]models.AuditEvent, error) {
+	result, err := s.Storer.GetAuditEvents(instanceID)
+	s.record("GetAuditEvents", err)
+	return result, err
+}
+
+// GetDataset calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetDataset(ID string) (*models.DatasetUpdate, error) {
+	result, err := s.Storer.GetDataset(ID)
+	s.record("GetDataset", err)
+	return result, err
+}
+
+// GetDatasets calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetDatasets(datasetType string) ([]models.DatasetUpdate, error) {
+	result, err := s.Storer.GetDatasets(datasetType)
+	s.record("GetDatasets", err)
+	return result, err
+}
+
+// GetDistinctThemes calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetDistinctThemes() ([]string, error) {
+	result, err := s.Storer.GetDistinctThemes()
+	s.record("GetDistinctThemes", err)
+	return result, err
+}
+
+// GetDatasetsModifiedSince calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetDatasetsModifiedSince(t time.Time, datasetType string, publishedOnly bool, offset, limit int) (*models.DatasetUpdatePage, error) {
+	result, err := s.Storer.GetDatasetsModifiedSince(t, datasetType, publishedOnly, offset, limit)
+	s.record("GetDatasetsModifiedSince", err)
+	return result, err
+}
+
+// GetDimensionsFromInstance calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetDimensionsFromInstance(ID string) (*models.DimensionNodeResults, error) {
+	result, err := s.Storer.GetDimensionsFromInstance(ID)
+	s.record("GetDimensionsFromInstance", err)
+	return result, err
+}
+
+// GetDimensions calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetDimensions(datasetID, versionID string) ([]bson.M, error) {
+	result, err := s.Storer.GetDimensions(datasetID, versionID)
+	s.record("GetDimensions", err)
+	return result, err
+}
+
+// GetDimensionOptionsByCodes calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetDimensionOptionsByCodes(instanceID, dimension string, codes []string) (*models.DimensionOptionResults, error) {
+	result, err := s.Storer.GetDimensionOptionsByCodes(instanceID, dimension, codes)
+	s.record("GetDimensionOptionsByCodes", err)
+	return result, err
+}
+
+// GetDimensionCodeList calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetDimensionCodeList(instanceID, dimension string) (string, error) {
+	result, err := s.Storer.GetDimensionCodeList(instanceID, dimension)
+	s.record("GetDimensionCodeList", err)
+	return result, err
+}
+
+// GetDimensionOptionCounts calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetDimensionOptionCounts(instanceID string) ([]models.DimensionOptionCount, error) {
+	result, err := s.Storer.GetDimensionOptionCounts(instanceID)
+	s.record("GetDimensionOptionCounts", err)
+	return result, err
+}
+
+// GetDimensionOptions calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetDimensionOptions(version *models.Version, dimension string) (*models.DimensionOptionResults, error) {
+	result, err := s.Storer.GetDimensionOptions(version, dimension)
+	s.record("GetDimensionOptions", err)
+	return result, err
+}
+
+// GetEdition calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetEdition(ID, editionID, state string) (*models.EditionUpdate, error) {
+	result, err := s.Storer.GetEdition(ID, editionID, state)
+	s.record("GetEdition", err)
+	return result, err
+}
+
+// GetEditions calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetEditions(ID, state string) (*models.EditionUpdateResults, error) {
+	result, err := s.Storer.GetEditions(ID, state)
+	s.record("GetEditions", err)
+	return result, err
+}
+
+// GetEditionsForDatasets calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetEditionsForDatasets(IDs []string, state string) (map[string][]*models.EditionUpdate, error) {
+	result, err := s.Storer.GetEditionsForDatasets(IDs, state)
+	s.record("GetEditionsForDatasets", err)
+	return result, err
+}
+
+// GetInstanceStateCounts calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetInstanceStateCounts() ([]models.InstanceStateCount, error) {
+	result, err := s.Storer.GetInstanceStateCounts()
+	s.record("GetInstanceStateCounts", err)
+	return result, err
+}
+
+// GetInstanceByIdempotencyKey calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetInstanceByIdempotencyKey(key string) (*models.Instance, error) {
+	result, err := s.Storer.GetInstanceByIdempotencyKey(key)
+	s.record("GetInstanceByIdempotencyKey", err)
+	return result, err
+}
+
+// GetInstances calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetInstances(states []string, datasets []string, isBasedOn []string) (*models.InstanceResults, error) {
+	result, err := s.Storer.GetInstances(states, datasets, isBasedOn)
+	s.record("GetInstances", err)
+	return result, err
+}
+
+// StreamInstances calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) StreamInstances(states []string, datasets []string, isBasedOn []string, fn func(*models.Instance) error) error {
+	err := s.Storer.StreamInstances(states, datasets, isBasedOn, fn)
+	s.record("StreamInstances", err)
+	return err
+}
+
+// StreamDimensionOptions calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) StreamDimensionOptions(instanceID, dimension string, fn func(*models.DimensionOption) error) error {
+	err := s.Storer.StreamDimensionOptions(instanceID, dimension, fn)
+	s.record("StreamDimensionOptions", err)
+	return err
+}
+
+// GetInstance calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetInstance(ID string) (*models.Instance, error) {
+	result, err := s.Storer.GetInstance(ID)
+	s.record("GetInstance", err)
+	return result, err
+}
+
+// GetLatestVersion calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetLatestVersion(datasetID, editionID, state string) (*models.Version, error) {
+	result, err := s.Storer.GetLatestVersion(datasetID, editionID, state)
+	s.record("GetLatestVersion", err)
+	return result, err
+}
+
+// ClaimNextVersion calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) ClaimNextVersion(datasetID, editionID string) (int, error) {
+	result, err := s.Storer.ClaimNextVersion(datasetID, editionID)
+	s.record("ClaimNextVersion", err)
+	return result, err
+}
+
+// ReleaseVersion calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) ReleaseVersion(datasetID, editionID string, version int) error {
+	err := s.Storer.ReleaseVersion(datasetID, editionID, version)
+	s.record("ReleaseVersion", err)
+	return err
+}
+
+// GetNextVersion calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetNextVersion(datasetID, editionID string) (int, error) {
+	result, err := s.Storer.GetNextVersion(datasetID, editionID)
+	s.record("GetNextVersion", err)
+	return result, err
+}
+
+// GetUniqueDimensionAndOptions calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetUniqueDimensionAndOptions(ID, dimension string) (*models.DimensionValues, error) {
+	result, err := s.Storer.GetUniqueDimensionAndOptions(ID, dimension)
+	s.record("GetUniqueDimensionAndOptions", err)
+	return result, err
+}
+
+// GetVersion calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetVersion(datasetID, editionID, version, state string) (*models.Version, error) {
+	result, err := s.Storer.GetVersion(datasetID, editionID, version, state)
+	s.record("GetVersion", err)
+	return result, err
+}
+
+// GetVersions calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetVersions(datasetID, editionID, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
+	result, err := s.Storer.GetVersions(datasetID, editionID, state, releaseDates)
+	s.record("GetVersions", err)
+	return result, err
+}
+
+// CountVersions calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) CountVersions(datasetID, editionID, state string) (int, error) {
+	result, err := s.Storer.CountVersions(datasetID, editionID, state)
+	s.record("CountVersions", err)
+	return result, err
+}
+
+// SummariseEditionVersions calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) SummariseEditionVersions(datasetID, state string) ([]models.EditionVersions, error) {
+	result, err := s.Storer.SummariseEditionVersions(datasetID, state)
+	s.record("SummariseEditionVersions", err)
+	return result, err
+}
+
+// CountEditions calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) CountEditions(datasetID string) (int, error) {
+	result, err := s.Storer.CountEditions(datasetID)
+	s.record("CountEditions", err)
+	return result, err
+}
+
+// GetVersionsByNumbers calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) GetVersionsByNumbers(datasetID string, refs []models.EditionVersionRef) ([]models.Version, error) {
+	result, err := s.Storer.GetVersionsByNumbers(datasetID, refs)
+	s.record("GetVersionsByNumbers", err)
+	return result, err
+}
+
+// PatchDataset calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) PatchDataset(ID string, patch *models.DatasetPatch, currentState string) error {
+	err := s.Storer.PatchDataset(ID, patch, currentState)
+	s.record("PatchDataset", err)
+	return err
+}
+
+// PurgeInstances calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) PurgeInstances(olderThan time.Time, states []string) (int, error) {
+	result, err := s.Storer.PurgeInstances(olderThan, states)
+	s.record("PurgeInstances", err)
+	return result, err
+}
+
+// RenameDimension calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) RenameDimension(instanceID, oldName, newName string) error {
+	err := s.Storer.RenameDimension(instanceID, oldName, newName)
+	s.record("RenameDimension", err)
+	return err
+}
+
+// SetDatasetLinksFrozen calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) SetDatasetLinksFrozen(ID string, frozen bool) error {
+	err := s.Storer.SetDatasetLinksFrozen(ID, frozen)
+	s.record("SetDatasetLinksFrozen", err)
+	return err
+}
+
+// UpdateDataset calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpdateDataset(ID string, dataset *models.Dataset, currentState string) error {
+	err := s.Storer.UpdateDataset(ID, dataset, currentState)
+	s.record("UpdateDataset", err)
+	return err
+}
+
+// UpdateDatasetWithAssociation calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpdateDatasetWithAssociation(ID, state string, version *models.Version) error {
+	err := s.Storer.UpdateDatasetWithAssociation(ID, state, version)
+	s.record("UpdateDatasetWithAssociation", err)
+	return err
+}
+
+// UpdateDimensionNodeID calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpdateDimensionNodeID(dimension *models.DimensionOption) error {
+	err := s.Storer.UpdateDimensionNodeID(dimension)
+	s.record("UpdateDimensionNodeID", err)
+	return err
+}
+
+// UpdateDimensionOption calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpdateDimensionOption(instanceID, dimension, option string, update *models.DimensionOptionUpdate) error {
+	err := s.Storer.UpdateDimensionOption(instanceID, dimension, option, update)
+	s.record("UpdateDimensionOption", err)
+	return err
+}
+
+// UpdateInstance calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpdateInstance(ctx context.Context, ID string, instance *models.Instance) error {
+	err := s.Storer.UpdateInstance(ctx, ID, instance)
+	s.record("UpdateInstance", err)
+	return err
+}
+
+// UpdateObservationInserted calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpdateObservationInserted(ID string, observationInserted int64) (*models.ImportObservationsTask, error) {
+	result, err := s.Storer.UpdateObservationInserted(ID, observationInserted)
+	s.record("UpdateObservationInserted", err)
+	return result, err
+}
+
+// UpdateImportObservationsTaskState calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpdateImportObservationsTaskState(id, state, reason string) error {
+	err := s.Storer.UpdateImportObservationsTaskState(id, state, reason)
+	s.record("UpdateImportObservationsTaskState", err)
+	return err
+}
+
+// UpdateBuildHierarchyTaskState calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpdateBuildHierarchyTaskState(id, dimension, state, reason string) error {
+	err := s.Storer.UpdateBuildHierarchyTaskState(id, dimension, state, reason)
+	s.record("UpdateBuildHierarchyTaskState", err)
+	return err
+}
+
+// UpdateBuildSearchTaskState calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpdateBuildSearchTaskState(id, dimension, state, reason string) error {
+	err := s.Storer.UpdateBuildSearchTaskState(id, dimension, state, reason)
+	s.record("UpdateBuildSearchTaskState", err)
+	return err
+}
+
+// UpdateVersion calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpdateVersion(ID string, version *models.Version) error {
+	err := s.Storer.UpdateVersion(ID, version)
+	s.record("UpdateVersion", err)
+	return err
+}
+
+// ResetInstance calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) ResetInstance(ctx context.Context, ID, currentState string, uniqueTimestamp bson.MongoTimestamp) error {
+	err := s.Storer.ResetInstance(ctx, ID, currentState, uniqueTimestamp)
+	s.record("ResetInstance", err)
+	return err
+}
+
+// UpsertContact calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpsertContact(ID string, update interface{}) error {
+	err := s.Storer.UpsertContact(ID, update)
+	s.record("UpsertContact", err)
+	return err
+}
+
+// UpsertDataset calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpsertDataset(ID string, datasetDoc *models.DatasetUpdate) error {
+	err := s.Storer.UpsertDataset(ID, datasetDoc)
+	s.record("UpsertDataset", err)
+	return err
+}
+
+// UpsertEdition calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpsertEdition(datasetID, edition string, editionDoc *models.EditionUpdate) error {
+	err := s.Storer.UpsertEdition(datasetID, edition, editionDoc)
+	s.record("UpsertEdition", err)
+	return err
+}
+
+// UpsertVersion calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) UpsertVersion(ID string, versionDoc *models.Version) error {
+	err := s.Storer.UpsertVersion(ID, versionDoc)
+	s.record("UpsertVersion", err)
+	return err
+}
+
+// WithTransaction calls the wrapped Storer and records the outcome, instrumenting the
+// Storer handed to fn so that calls made within the transaction are also counted
+func (s *InstrumentedStorer) WithTransaction(fn func(store.Storer) error) error {
+	err := s.Storer.WithTransaction(func(tx store.Storer) error {
+		return fn(&InstrumentedStorer{Storer: tx, calls: s.calls})
+	})
+	s.record("WithTransaction", err)
+	return err
+}
+
+// DeleteDataset calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) DeleteDataset(ID string) error {
+	err := s.Storer.DeleteDataset(ID)
+	s.record("DeleteDataset", err)
+	return err
+}
+
+// DeleteDimensionOptions calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) DeleteDimensionOptions(instanceID, dimension string) error {
+	err := s.Storer.DeleteDimensionOptions(instanceID, dimension)
+	s.record("DeleteDimensionOptions", err)
+	return err
+}
+
+// DeleteEdition calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) DeleteEdition(ID string) error {
+	err := s.Storer.DeleteEdition(ID)
+	s.record("DeleteEdition", err)
+	return err
+}
+
+// AddVersionDetailsToInstance calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) AddVersionDetailsToInstance(ctx context.Context, instanceID string, datasetID string, edition string, version int) error {
+	err := s.Storer.AddVersionDetailsToInstance(ctx, instanceID, datasetID, edition, version)
+	s.record("AddVersionDetailsToInstance", err)
+	return err
+}
+
+// SetInstanceIsPublished calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) SetInstanceIsPublished(ctx context.Context, instanceID string) error {
+	err := s.Storer.SetInstanceIsPublished(ctx, instanceID)
+	s.record("SetInstanceIsPublished", err)
+	return err
+}
+
+// StreamCSVRows calls the wrapped Storer and records the outcome
+func (s *InstrumentedStorer) StreamCSVRows(ctx context.Context, filter *observation.Filter, limit *int) (observation.StreamRowReader, error) {
+	result, err := s.Storer.StreamCSVRows(ctx, filter, limit)
+	s.record("StreamCSVRows", err)
+	return result, err
+}
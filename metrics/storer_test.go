@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-dataset-api/models"
+	storetest "github.com/ONSdigital/dp-dataset-api/store/datastoretest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInstrumentedStorer(t *testing.T) {
+	Convey("Given a Storer wrapped in the instrumented decorator", t, func() {
+		reg := NewRegistry()
+		mockedDataStore := &storetest.StorerMock{
+			GetDatasetFunc: func(ID string) (*models.DatasetUpdate, error) {
+				if ID == "123" {
+					return &models.DatasetUpdate{ID: ID}, nil
+				}
+				return nil, errors.New("dataset not found")
+			},
+		}
+		storer := NewInstrumentedStorer(mockedDataStore, reg)
+
+		Convey("When calls are made through the decorator", func() {
+			dataset, err := storer.GetDataset("123")
+			So(err, ShouldBeNil)
+			So(dataset.ID, ShouldEqual, "123")
+
+			_, err = storer.GetDataset("456")
+			So(err, ShouldNotBeNil)
+
+			Convey("Then the wrapped Storer is called and each call is counted by result", func() {
+				So(len(mockedDataStore.GetDatasetCalls()), ShouldEqual, 2)
+				So(storer.calls.Value("GetDataset", resultSuccess), ShouldEqual, 1)
+				So(storer.calls.Value("GetDataset", resultError), ShouldEqual, 1)
+			})
+		})
+	})
+}
@@ -17,7 +17,7 @@ var (
 //
 //         // make and configure a mocked DownloadsGenerator
 //         mockedDownloadsGenerator := &DownloadsGeneratorMock{
-//             GenerateFunc: func(datasetID string, instanceID string, edition string, version string) error {
+//             GenerateFunc: func(datasetID string, instanceID string, edition string, version string, formats []string) error {
 // 	               panic("TODO: mock out the Generate method")
 //             },
 //         }
@@ -28,7 +28,7 @@ var (
 //     }
 type DownloadsGeneratorMock struct {
 	// GenerateFunc mocks the Generate method.
-	GenerateFunc func(datasetID string, instanceID string, edition string, version string) error
+	GenerateFunc func(datasetID string, instanceID string, edition string, version string, formats []string) error
 
 	// calls tracks calls to the methods.
 	calls struct {
@@ -42,12 +42,14 @@ type DownloadsGeneratorMock struct {
 			Edition string
 			// Version is the version argument value.
 			Version string
+			// Formats is the formats argument value.
+			Formats []string
 		}
 	}
 }
 
 // Generate calls GenerateFunc.
-func (mock *DownloadsGeneratorMock) Generate(datasetID string, instanceID string, edition string, version string) error {
+func (mock *DownloadsGeneratorMock) Generate(datasetID string, instanceID string, edition string, version string, formats []string) error {
 	if mock.GenerateFunc == nil {
 		panic("moq: DownloadsGeneratorMock.GenerateFunc is nil but DownloadsGenerator.Generate was just called")
 	}
@@ -56,16 +58,18 @@ func (mock *DownloadsGeneratorMock) Generate(datasetID string, instanceID string
 		InstanceID string
 		Edition    string
 		Version    string
+		Formats    []string
 	}{
 		DatasetID:  datasetID,
 		InstanceID: instanceID,
 		Edition:    edition,
 		Version:    version,
+		Formats:    formats,
 	}
 	lockDownloadsGeneratorMockGenerate.Lock()
 	mock.calls.Generate = append(mock.calls.Generate, callInfo)
 	lockDownloadsGeneratorMockGenerate.Unlock()
-	return mock.GenerateFunc(datasetID, instanceID, edition, version)
+	return mock.GenerateFunc(datasetID, instanceID, edition, version, formats)
 }
 
 // GenerateCalls gets all the calls that were made to Generate.
@@ -76,12 +80,14 @@ func (mock *DownloadsGeneratorMock) GenerateCalls() []struct {
 	InstanceID string
 	Edition    string
 	Version    string
+	Formats    []string
 } {
 	var calls []struct {
 		DatasetID  string
 		InstanceID string
 		Edition    string
 		Version    string
+		Formats    []string
 	}
 	lockDownloadsGeneratorMockGenerate.RLock()
 	calls = mock.calls.Generate
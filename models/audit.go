@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// AuditEvent represents an audited action taken against an instance, stored so the audit trail of an instance can be
+// read back without replaying the audit events topic
+type AuditEvent struct {
+	InstanceID string            `bson:"instance_id"          json:"instance_id"`
+	Action     string            `bson:"action"               json:"action"`
+	Result     string            `bson:"result"               json:"result"`
+	User       string            `bson:"user,omitempty"       json:"user,omitempty"`
+	Caller     string            `bson:"caller,omitempty"     json:"caller,omitempty"`
+	RequestID  string            `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	Params     map[string]string `bson:"params,omitempty"     json:"params,omitempty"`
+	Created    time.Time         `bson:"created"              json:"created"`
+}
+
+// AuditEventResults wraps the audit events of an instance, oldest first
+type AuditEventResults struct {
+	Items []AuditEvent `json:"items"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"io"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+)
+
+// List of outcomes reported for each version in a request to attach versions to a collection
+const (
+	CollectionVersionAttached = "attached"
+	CollectionVersionSkipped  = "skipped"
+	CollectionVersionFailed   = "failed"
+)
+
+// CollectionVersion identifies a version of a dataset to attach to a collection
+type CollectionVersion struct {
+	DatasetID string `json:"dataset_id"`
+	Edition   string `json:"edition"`
+	Version   int    `json:"version"`
+}
+
+// CollectionVersionResult represents the outcome of attaching a single version to a collection
+type CollectionVersionResult struct {
+	DatasetID string `json:"dataset_id"`
+	Edition   string `json:"edition"`
+	Version   int    `json:"version"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// CollectionVersionResults represents the outcome of attaching a batch of versions to a collection
+type CollectionVersionResults struct {
+	CollectionID string                    `json:"collection_id"`
+	Count        int                       `json:"count"`
+	Items        []CollectionVersionResult `json:"items"`
+}
+
+// CreateCollectionVersions manages the creation of a list of versions to attach to a collection from a reader
+func CreateCollectionVersions(reader io.Reader, limits JSONLimits) ([]CollectionVersion, error) {
+	var versions []CollectionVersion
+	if err := DecodeJSON(reader, &versions, limits); err != nil {
+		return nil, err
+	}
+
+	if len(versions) == 0 {
+		return nil, errs.ErrNoCollectionVersions
+	}
+	return versions, nil
+}
+
+// Validate checks the collection version identifies a single version
+func (v CollectionVersion) Validate() error {
+	if v.DatasetID == "" || v.Edition == "" || v.Version < 1 {
+		return errs.ErrCollectionVersionInvalid
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCreateCollectionVersions(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a list of versions to attach to a collection", t, func() {
+		r := bytes.NewBufferString(`[{"dataset_id":"123","edition":"2017","version":1},{"dataset_id":"456","edition":"time-series","version":3}]`)
+
+		Convey("Then each version is returned without error", func() {
+			versions, err := CreateCollectionVersions(r, DefaultJSONLimits)
+			So(err, ShouldBeNil)
+			So(versions, ShouldResemble, []CollectionVersion{
+				{DatasetID: "123", Edition: "2017", Version: 1},
+				{DatasetID: "456", Edition: "time-series", Version: 3},
+			})
+		})
+	})
+
+	Convey("Given an empty list of versions", t, func() {
+		r := bytes.NewBufferString(`[]`)
+
+		Convey("Then an error is returned", func() {
+			versions, err := CreateCollectionVersions(r, DefaultJSONLimits)
+			So(err, ShouldEqual, errs.ErrNoCollectionVersions)
+			So(versions, ShouldBeNil)
+		})
+	})
+
+	Convey("Given a body which is not a list of versions", t, func() {
+		r := bytes.NewBufferString(`{"dataset_id":"123"}`)
+
+		Convey("Then an error is returned", func() {
+			versions, err := CreateCollectionVersions(r, DefaultJSONLimits)
+			So(err, ShouldEqual, errs.ErrUnableToParseJSON)
+			So(versions, ShouldBeNil)
+		})
+	})
+}
+
+func TestCollectionVersionValidate(t *testing.T) {
+	t.Parallel()
+
+	Convey("A collection version identifying a single version is valid", t, func() {
+		So(CollectionVersion{DatasetID: "123", Edition: "2017", Version: 1}.Validate(), ShouldBeNil)
+	})
+
+	Convey("A collection version missing any of its fields is invalid", t, func() {
+		So(CollectionVersion{Edition: "2017", Version: 1}.Validate(), ShouldEqual, errs.ErrCollectionVersionInvalid)
+		So(CollectionVersion{DatasetID: "123", Version: 1}.Validate(), ShouldEqual, errs.ErrCollectionVersionInvalid)
+		So(CollectionVersion{DatasetID: "123", Edition: "2017"}.Validate(), ShouldEqual, errs.ErrCollectionVersionInvalid)
+	})
+}
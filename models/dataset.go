@@ -1,14 +1,20 @@
 package models
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
+	neturl "net/url"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/url"
 	"github.com/ONSdigital/go-ns/log"
 	"github.com/pkg/errors"
 	"github.com/satori/go.uuid"
@@ -20,19 +26,85 @@ var (
 	ErrPublishedVersionCollectionIDInvalid  = errors.New("unexpected collection_id in published version")
 	ErrVersionStateInvalid                  = errors.New("incorrect state, can be one of the following: edition-confirmed, associated or published")
 	ErrEditionLinksInvalid                  = errors.New("editions links do not exist")
+	ErrReleaseDateRangeInvalid              = errors.New("invalid release date, expected a date in the format YYYY-MM-DD or RFC3339")
+	ErrReleaseDateRangeOrder                = errors.New("invalid release date range, released_after must be earlier than released_before")
 )
 
+// List of dataset types
+const (
+	FilterableDatasetType = "filterable"
+	StaticDatasetType     = "static"
+)
+
+var validDatasetTypes = map[string]bool{
+	FilterableDatasetType: true,
+	StaticDatasetType:     true,
+}
+
+// clearableDatasetFields lists the fields of a dataset which can be removed by
+// setting them to null in a merge patch document
+var clearableDatasetFields = []string{
+	"collection_id",
+	"contacts",
+	"deprecated",
+	"deprecation_notice",
+	"description",
+	"keywords",
+	"license",
+	"links.access_rights",
+	"links.taxonomy",
+	"methodologies",
+	"national_statistic",
+	"next_release",
+	"publications",
+	"publisher",
+	"qmi",
+	"related_datasets",
+	"release_frequency",
+	"subtopics",
+	"sunset_date",
+	"survey",
+	"theme",
+	"title",
+	"translations",
+	"unit_of_measure",
+	"uri",
+}
+
 // DatasetResults represents a structure for a list of datasets
 type DatasetResults struct {
 	Items []*Dataset `json:"items"`
 }
 
+// ThemeResults represents a structure for the list of themes given to published datasets
+type ThemeResults struct {
+	Items []string `json:"items"`
+}
+
 // DatasetUpdateResults represents a structure for a list of evolving dataset
 // with the current dataset and the updated dataset
 type DatasetUpdateResults struct {
 	Items []DatasetUpdate `json:"items"`
 }
 
+// DatasetUpdatePage represents a single page of evolving datasets along with the details needed to request the next page
+type DatasetUpdatePage struct {
+	Items      []DatasetUpdate `json:"items"`
+	Count      int             `json:"count"`
+	Offset     int             `json:"offset"`
+	Limit      int             `json:"limit"`
+	TotalCount int             `json:"total_count"`
+}
+
+// DatasetPage represents a single page of datasets along with the details needed to request the next page
+type DatasetPage struct {
+	Items      []*Dataset `json:"items"`
+	Count      int        `json:"count"`
+	Offset     int        `json:"offset"`
+	Limit      int        `json:"limit"`
+	TotalCount int        `json:"total_count"`
+}
+
 // EditionResults represents a structure for a list of editions for a dataset
 type EditionResults struct {
 	Items []*Edition `json:"items"`
@@ -51,19 +123,23 @@ type VersionResults struct {
 
 // DatasetUpdate represents an evolving dataset with the current dataset and the updated dataset
 type DatasetUpdate struct {
-	ID      string   `bson:"_id,omitempty"         json:"id,omitempty"`
-	Current *Dataset `bson:"current,omitempty"     json:"current,omitempty"`
-	Next    *Dataset `bson:"next,omitempty"        json:"next,omitempty"`
+	ID          string   `bson:"_id,omitempty"          json:"id,omitempty"`
+	Current     *Dataset `bson:"current,omitempty"      json:"current,omitempty"`
+	Next        *Dataset `bson:"next,omitempty"         json:"next,omitempty"`
+	LinksFrozen bool     `bson:"links_frozen,omitempty" json:"links_frozen,omitempty"`
 }
 
 // Dataset represents information related to a single dataset
 type Dataset struct {
 	CollectionID      string           `bson:"collection_id,omitempty"          json:"collection_id,omitempty"`
 	Contacts          []ContactDetails `bson:"contacts,omitempty"               json:"contacts,omitempty"`
+	Deprecated        *bool            `bson:"deprecated,omitempty"             json:"deprecated,omitempty"`
+	DeprecationNotice string           `bson:"deprecation_notice,omitempty"     json:"deprecation_notice,omitempty"`
 	Description       string           `bson:"description,omitempty"            json:"description,omitempty"`
 	Keywords          []string         `bson:"keywords,omitempty"               json:"keywords,omitempty"`
 	ID                string           `bson:"_id,omitempty"                    json:"id,omitempty"`
 	LastUpdated       time.Time        `bson:"last_updated,omitempty"           json:"-"`
+	LatestVersion     *Version         `bson:"-"                                json:"latest_version,omitempty"`
 	License           string           `bson:"license,omitempty"                json:"license,omitempty"`
 	Links             *DatasetLinks    `bson:"links,omitempty"                  json:"links,omitempty"`
 	Methodologies     []GeneralDetails `bson:"methodologies,omitempty"          json:"methodologies,omitempty"`
@@ -75,12 +151,24 @@ type Dataset struct {
 	RelatedDatasets   []GeneralDetails `bson:"related_datasets,omitempty"       json:"related_datasets,omitempty"`
 	ReleaseFrequency  string           `bson:"release_frequency,omitempty"      json:"release_frequency,omitempty"`
 	State             string           `bson:"state,omitempty"                  json:"state,omitempty"`
+	Subtopics         []string         `bson:"subtopics,omitempty"              json:"subtopics,omitempty"`
+	SunsetDate        string           `bson:"sunset_date,omitempty"            json:"sunset_date,omitempty"`
+	Survey            string           `bson:"survey,omitempty"                 json:"survey,omitempty"`
 	Theme             string           `bson:"theme,omitempty"                  json:"theme,omitempty"`
 	Title             string           `bson:"title,omitempty"                  json:"title,omitempty"`
+	Translations      Translations     `bson:"translations,omitempty"           json:"translations,omitempty"`
+	Type              string           `bson:"type,omitempty"                   json:"type,omitempty"`
 	UnitOfMeasure     string           `bson:"unit_of_measure,omitempty"        json:"unit_of_measure,omitempty"`
 	URI               string           `bson:"uri,omitempty"                    json:"uri,omitempty"`
 }
 
+// DatasetPatch represents a JSON merge patch (RFC 7386) for a dataset, the
+// fields to set are held in Dataset and the fields to remove in Clear
+type DatasetPatch struct {
+	Dataset *Dataset
+	Clear   []string
+}
+
 // DatasetLinks represents a list of specific links related to the dataset resource
 type DatasetLinks struct {
 	AccessRights  *LinkObject `bson:"access_rights,omitempty"   json:"access_rights,omitempty"`
@@ -134,6 +222,18 @@ type EditionUpdateLinks struct {
 	Versions      *LinkObject `bson:"versions,omitempty"       json:"versions,omitempty"`
 }
 
+// EditionLinksRefresh reports how the latest version links of an edition changed when they were refreshed
+type EditionLinksRefresh struct {
+	Current *LatestVersionChange `json:"current,omitempty"`
+	Next    *LatestVersionChange `json:"next"`
+}
+
+// LatestVersionChange holds the id of the version a latest version link pointed at before and after a refresh
+type LatestVersionChange struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after"`
+}
+
 // Edition represents information related to a single edition for a dataset
 type Edition struct {
 	Edition     string              `bson:"edition,omitempty"     json:"edition,omitempty"`
@@ -141,6 +241,16 @@ type Edition struct {
 	LastUpdated time.Time           `bson:"last_updated,omitempty" json:"-"`
 	Links       *EditionUpdateLinks `bson:"links,omitempty"       json:"links,omitempty"`
 	State       string              `bson:"state,omitempty"        json:"state,omitempty"`
+	// VersionCount is the number of versions of the edition, set when editions are listed rather than stored
+	VersionCount *int `bson:"-"                      json:"version_count,omitempty"`
+}
+
+// EditionVersions summarises the versions of an edition, so editions can be listed without reading each version
+type EditionVersions struct {
+	Edition           string `bson:"_id"`
+	Count             int    `bson:"count"`
+	PublishedCount    int    `bson:"published_count"`
+	LatestReleaseDate string `bson:"latest_release_date"`
 }
 
 // Publisher represents an object containing information of the publisher
@@ -150,23 +260,58 @@ type Publisher struct {
 	Type string `bson:"type,omitempty" json:"type,omitempty"`
 }
 
+// A list of the types of publisher of a dataset
+const (
+	PublisherTypeGovernment             = "government"
+	PublisherTypeGovernmentDepartment   = "government department"
+	PublisherTypeDevolvedAdministration = "devolved administration"
+	PublisherTypePublicBody             = "public body"
+)
+
+var validPublisherTypes = map[string]bool{
+	PublisherTypeGovernment:             true,
+	PublisherTypeGovernmentDepartment:   true,
+	PublisherTypeDevolvedAdministration: true,
+	PublisherTypePublicBody:             true,
+}
+
 // Version represents information related to a single version for an edition of a dataset
 type Version struct {
-	Alerts        *[]Alert             `bson:"alerts,omitempty"         json:"alerts,omitempty"`
-	CollectionID  string               `bson:"collection_id,omitempty"  json:"collection_id,omitempty"`
-	Dimensions    []Dimension          `bson:"dimensions,omitempty"     json:"dimensions,omitempty"`
-	Downloads     *DownloadList        `bson:"downloads,omitempty"      json:"downloads,omitempty"`
-	Edition       string               `bson:"edition,omitempty"        json:"edition,omitempty"`
-	Headers       []string             `bson:"headers,omitempty"        json:"-"`
-	ID            string               `bson:"id,omitempty"             json:"id,omitempty"`
-	LastUpdated   time.Time            `bson:"last_updated,omitempty"   json:"-"`
-	LatestChanges *[]LatestChange      `bson:"latest_changes,omitempty" json:"latest_changes,omitempty"`
-	Links         *VersionLinks        `bson:"links,omitempty"          json:"links,omitempty"`
-	ReleaseDate   string               `bson:"release_date,omitempty"   json:"release_date,omitempty"`
-	State         string               `bson:"state,omitempty"          json:"state,omitempty"`
-	Temporal      *[]TemporalFrequency `bson:"temporal,omitempty"       json:"temporal,omitempty"`
-	UsageNotes    *[]UsageNote         `bson:"usage_notes,omitempty"    json:"usage_notes,omitempty"`
-	Version       int                  `bson:"version,omitempty"        json:"version,omitempty"`
+	Alerts            *[]Alert             `bson:"alerts,omitempty"             json:"alerts,omitempty"`
+	CollectionID      string               `bson:"collection_id,omitempty"      json:"collection_id,omitempty"`
+	Dimensions        []Dimension          `bson:"dimensions,omitempty"         json:"dimensions,omitempty"`
+	DownloadFormats   []string             `bson:"download_formats,omitempty" json:"download_formats,omitempty"`
+	Downloads         *DownloadList        `bson:"downloads,omitempty"          json:"downloads,omitempty"`
+	Edition           string               `bson:"edition,omitempty"            json:"edition,omitempty"`
+	Headers           []string             `bson:"headers,omitempty"            json:"-"`
+	ID                string               `bson:"id,omitempty"                 json:"id,omitempty"`
+	LastUpdated       time.Time            `bson:"last_updated,omitempty"       json:"-"`
+	LatestChanges     *[]LatestChange      `bson:"latest_changes,omitempty"     json:"latest_changes,omitempty"`
+	Links             *VersionLinks        `bson:"links,omitempty"              json:"links,omitempty"`
+	ReleaseDate       string               `bson:"release_date,omitempty"       json:"release_date,omitempty"`
+	State             string               `bson:"state,omitempty"              json:"state,omitempty"`
+	Temporal          *[]TemporalFrequency `bson:"temporal,omitempty"           json:"temporal,omitempty"`
+	TotalObservations *int                 `bson:"total_observations,omitempty" json:"-"`
+	UnitOfMeasure     string               `bson:"-"                            json:"unit_of_measure,omitempty"`
+	UsageNotes        *[]UsageNote         `bson:"usage_notes,omitempty"        json:"usage_notes,omitempty"`
+	Version           int                  `bson:"version,omitempty"            json:"version,omitempty"`
+
+	HasConfidenceIntervals *bool `bson:"has_confidence_intervals,omitempty" json:"has_confidence_intervals,omitempty"`
+	HasDataMarkings        *bool `bson:"has_data_markings,omitempty"        json:"has_data_markings,omitempty"`
+	// Labels are free-form key-value metadata of the version, such as the editorial workflow it has been through,
+	// replaced in full when given in an update
+	Labels map[string]string `bson:"labels,omitempty" json:"labels,omitempty"`
+}
+
+// VersionPublishedEvent summarises a version of a dataset which has been published, for the webhooks notified of
+// publication
+type VersionPublishedEvent struct {
+	DatasetID   string `json:"dataset_id"`
+	Edition     string `json:"edition"`
+	Version     int    `json:"version"`
+	InstanceID  string `json:"instance_id"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	HRef        string `json:"href,omitempty"`
 }
 
 // Alert represents an object containing information on an alert
@@ -176,6 +321,92 @@ type Alert struct {
 	Type        string `bson:"type,omitempty"        json:"type,omitempty"`
 }
 
+// The limits on the labels of a version, keeping them to short metadata rather than content
+const (
+	MaxVersionLabels           = 50
+	MaxVersionLabelKeyLength   = 64
+	MaxVersionLabelValueLength = 256
+)
+
+// A list of the formats full dataset downloads can be generated in
+const (
+	DownloadFormatCSV  = "csv"
+	DownloadFormatCSVW = "csvw"
+	DownloadFormatXLS  = "xls"
+)
+
+var validDownloadFormats = map[string]bool{
+	DownloadFormatCSV:  true,
+	DownloadFormatCSVW: true,
+	DownloadFormatXLS:  true,
+}
+
+// InvalidDownloadFormats returns each of the formats which downloads cannot be generated in
+func InvalidDownloadFormats(formats []string) []string {
+	var invalid []string
+	for _, format := range formats {
+		if !validDownloadFormats[format] {
+			invalid = append(invalid, format)
+		}
+	}
+	return invalid
+}
+
+// UnpublishedFormats returns each of the formats which has a download without a public link
+func (d *DownloadList) UnpublishedFormats(formats []string) []string {
+	if d == nil {
+		return nil
+	}
+
+	downloads := map[string]*DownloadObject{
+		DownloadFormatCSV:  d.CSV,
+		DownloadFormatCSVW: d.CSVW,
+		DownloadFormatXLS:  d.XLS,
+	}
+
+	var unpublished []string
+	for _, format := range formats {
+		if download := downloads[format]; download != nil && download.Public == "" {
+			unpublished = append(unpublished, format)
+		}
+	}
+	return unpublished
+}
+
+// Sign replaces the link of each download with the signed link returned by sign
+func (d *DownloadList) Sign(sign func(href string) (string, error)) error {
+	if d == nil {
+		return nil
+	}
+
+	for _, download := range []*DownloadObject{d.CSV, d.CSVW, d.XLS} {
+		if download == nil || download.HRef == "" {
+			continue
+		}
+
+		signed, err := sign(download.HRef)
+		if err != nil {
+			return err
+		}
+		download.HRef = signed
+	}
+	return nil
+}
+
+// RemovePrivateLinks removes the links to the private copies of the downloads, which only the download service is
+// given
+func (d *DownloadList) RemovePrivateLinks() {
+	if d == nil {
+		return
+	}
+
+	for _, download := range []*DownloadObject{d.CSV, d.CSVW, d.XLS} {
+		if download != nil {
+			download.Private = ""
+		}
+	}
+}
+
 // DownloadList represents a list of objects of containing information on the downloadable files
 type DownloadList struct {
 	CSV  *DownloadObject `bson:"csv,omitempty" json:"csv,omitempty"`
@@ -224,29 +455,318 @@ type VersionLinks struct {
 	Version    *LinkObject `bson:"version,omitempty"     json:"-"`
 }
 
-// CreateDataset manages the creation of a dataset from a reader
-func CreateDataset(reader io.Reader) (*Dataset, error) {
-	b, err := ioutil.ReadAll(reader)
+// EditionVersionRef identifies a version of a dataset by its edition and version number
+type EditionVersionRef struct {
+	Edition string `json:"edition"`
+	Version int    `json:"version"`
+}
+
+// ReleaseDateRange represents an optional window on the release date of versions,
+// both bounds are exclusive and a nil bound leaves that side of the window open
+type ReleaseDateRange struct {
+	After  *time.Time
+	Before *time.Time
+}
+
+// ParseReleaseDateRange creates a release date range from the released_after and
+// released_before query parameters, an empty parameter is treated as unbounded. When both are
+// given released_after must be earlier than released_before, otherwise no version could match
+func ParseReleaseDateRange(releasedAfter, releasedBefore string) (*ReleaseDateRange, error) {
+	after, err := parseReleaseDate(releasedAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := parseReleaseDate(releasedBefore)
 	if err != nil {
-		return nil, errs.ErrUnableToReadMessage
+		return nil, err
+	}
+
+	if after != nil && before != nil && !after.Before(*before) {
+		return nil, ErrReleaseDateRangeOrder
+	}
+
+	return &ReleaseDateRange{After: after, Before: before}, nil
+}
+
+func parseReleaseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
 	}
+	return nil, ErrReleaseDateRangeInvalid
+}
+
+// ValidateInstanceReleaseDate checks the release date an instance becomes a version with is given and is a date in
+// the format YYYY-MM-DD or RFC3339, as every version must have a valid release date
+func ValidateInstanceReleaseDate(releaseDate string) error {
+	if strings.TrimSpace(releaseDate) == "" {
+		return errs.ErrInstanceReleaseDateMissing
+	}
+
+	if _, err := parseReleaseDate(releaseDate); err != nil {
+		return errs.ErrInstanceReleaseDateInvalid
+	}
+	return nil
+}
+
+// IsEmpty returns true if neither bound of the range has been set
+func (r *ReleaseDateRange) IsEmpty() bool {
+	return r == nil || (r.After == nil && r.Before == nil)
+}
 
+// CreateDataset manages the creation of a dataset from a reader
+func CreateDataset(reader io.Reader, limits JSONLimits) (*Dataset, error) {
 	var dataset Dataset
+	if err := DecodeJSON(reader, &dataset, limits); err != nil {
+		return nil, err
+	}
+	return &dataset, nil
+}
+
+// ValidateDatasetType checks the type of a dataset is one of the known dataset types
+func ValidateDatasetType(datasetType string) error {
+	if !validDatasetTypes[datasetType] {
+		return errs.ErrDatasetTypeInvalid
+	}
+	return nil
+}
+
+// ValidateDatasetClassification checks the survey and subtopics of a dataset are within the configured limits,
+// the survey and each subtopic can be at most maxLength characters and there can be at most maxSubtopics subtopics
+func ValidateDatasetClassification(dataset *Dataset, maxLength, maxSubtopics int) error {
+	if utf8.RuneCountInString(dataset.Survey) > maxLength {
+		return errs.ErrDatasetSurveyInvalid
+	}
+
+	if len(dataset.Subtopics) > maxSubtopics {
+		return errs.ErrDatasetSubtopicsInvalid
+	}
+
+	for _, subtopic := range dataset.Subtopics {
+		if utf8.RuneCountInString(subtopic) > maxLength {
+			return errs.ErrDatasetSubtopicsInvalid
+		}
+	}
+
+	return nil
+}
+
+// ValidateDatasetContacts checks each contact of a dataset has at least a name or an email
+func ValidateDatasetContacts(contacts []ContactDetails) error {
+	for _, contact := range contacts {
+		if strings.TrimSpace(contact.Name) == "" && strings.TrimSpace(contact.Email) == "" {
+			return errs.ErrDatasetContactInvalid
+		}
+	}
+	return nil
+}
+
+// ValidateDatasetPublisher checks the type of the publisher of a dataset, when one is given, is one of the known
+// publisher types
+func ValidateDatasetPublisher(publisher *Publisher) error {
+	if publisher == nil || publisher.Type == "" {
+		return nil
+	}
+
+	if !validPublisherTypes[publisher.Type] {
+		return errs.ErrDatasetPublisherTypeInvalid
+	}
+	return nil
+}
+
+// validURISchemes are the schemes the uri of a dataset can use
+var validURISchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+}
+
+// ValidateDatasetURI checks the uri of a dataset, when one is given, is an absolute url with a host and one of the
+// valid schemes
+func ValidateDatasetURI(uri string) error {
+	if uri == "" {
+		return nil
+	}
+
+	parsed, err := neturl.Parse(uri)
+	if err != nil || !validURISchemes[strings.ToLower(parsed.Scheme)] || parsed.Host == "" {
+		return errs.ErrDatasetURIInvalid
+	}
+	return nil
+}
+
+// IsDeprecated returns true if the dataset has been deprecated
+func (d *Dataset) IsDeprecated() bool {
+	return d.Deprecated != nil && *d.Deprecated
+}
+
+// ValidateDatasetDeprecation checks the sunset date of a dataset update can be parsed, and that a dataset left
+// deprecated by the update has a sunset date which is after now. The update is merged with the current dataset, which
+// is nil for a new dataset, and the fields it clears. A dataset whose deprecation is not changed by the update is not
+// checked, so a deprecated dataset can still be updated once its sunset date has passed.
+func ValidateDatasetDeprecation(update, current *Dataset, clear []string, now time.Time) error {
+	if update.SunsetDate != "" {
+		if _, err := ParseSunsetDate(update.SunsetDate); err != nil {
+			return err
+		}
+	}
+
+	cleared := make(map[string]bool)
+	for _, field := range clear {
+		cleared[field] = true
+	}
+
+	if update.Deprecated == nil && update.SunsetDate == "" && !cleared["deprecated"] && !cleared["sunset_date"] {
+		return nil
+	}
+
+	var deprecated bool
+	var sunsetDate string
+	if current != nil {
+		deprecated = current.IsDeprecated() && !cleared["deprecated"]
+		if !cleared["sunset_date"] {
+			sunsetDate = current.SunsetDate
+		}
+	}
+	if update.Deprecated != nil {
+		deprecated = *update.Deprecated
+	}
+	if update.SunsetDate != "" {
+		sunsetDate = update.SunsetDate
+	}
+
+	if !deprecated {
+		return nil
+	}
+
+	sunset, err := ParseSunsetDate(sunsetDate)
+	if err != nil || !sunset.After(now) {
+		return errs.ErrDatasetSunsetDateInvalid
+	}
+	return nil
+}
 
-	err = json.Unmarshal(b, &dataset)
+// ValidateReleaseDateNotPast checks the release date of a version being published is today or later, compared by
+// calendar day in the UK so a version released today can be published at any time of day, including during BST
+func ValidateReleaseDateNotPast(releaseDate string, now time.Time) error {
+	released, err := parseReleaseDate(releaseDate)
 	if err != nil {
-		return nil, errs.ErrUnableToParseJSON
+		return errs.ErrVersionReleaseDateInvalid
 	}
-	return &dataset, nil
+	if released == nil {
+		return nil
+	}
+
+	if ukDay(*released).Before(ukDay(now)) {
+		return errs.ErrVersionReleaseDateInPast
+	}
+	return nil
 }
 
-// CreateVersion manages the creation of a version from a reader
-func CreateVersion(reader io.Reader) (*Version, error) {
-	b, err := ioutil.ReadAll(reader)
+// ukLocation is the time zone release dates are compared in, UTC when the zone database is not installed
+var ukLocation = loadUKLocation()
+
+func loadUKLocation() *time.Location {
+	location, err := time.LoadLocation("Europe/London")
 	if err != nil {
-		return nil, errs.ErrUnableToReadMessage
+		log.Error(errors.WithMessage(err, "unable to load the Europe/London time zone, release dates are compared in UTC"), nil)
+		return time.UTC
+	}
+	return location
+}
+
+// ukDay returns the calendar day the time falls on in the UK. A date given without a time is midnight UTC, which is
+// on the same day in the UK whether or not BST applies.
+func ukDay(t time.Time) time.Time {
+	year, month, day := t.In(ukLocation).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseSunsetDate parses the sunset date of a dataset, given as either a date or an RFC3339 timestamp
+func ParseSunsetDate(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errs.ErrDatasetSunsetDateInvalid
+}
+
+// CreateDatasetPatch manages the creation of a dataset merge patch from a reader,
+// a key with an explicit null value is cleared and an absent key is left untouched
+func CreateDatasetPatch(reader io.Reader, limits JSONLimits) (*DatasetPatch, error) {
+	// the body is checked against the limits once, then decoded both as a document and as a dataset
+	var b json.RawMessage
+	if err := DecodeJSON(reader, &b, limits); err != nil {
+		return nil, err
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(b, &document); err != nil {
+		return nil, errs.ErrUnableToParseJSON
+	}
+
+	var dataset Dataset
+	if err := json.Unmarshal(b, &dataset); err != nil {
+		return nil, errs.ErrUnableToParseJSON
 	}
 
+	clear := nullFields("", document)
+	for _, field := range clear {
+		if !isClearableDatasetField(field) {
+			return nil, errs.ErrDatasetPatchFieldInvalid
+		}
+	}
+
+	return &DatasetPatch{Dataset: &dataset, Clear: clear}, nil
+}
+
+// ChangesOnlyState reports whether the patch sets the state of the dataset and changes nothing else
+func (p *DatasetPatch) ChangesOnlyState() bool {
+	return len(p.Clear) == 0 && reflect.DeepEqual(*p.Dataset, Dataset{State: p.Dataset.State})
+}
+
+// nullFields walks a JSON document returning the dot separated path of each
+// key which has been explicitly set to null
+func nullFields(prefix string, document map[string]json.RawMessage) []string {
+	var fields []string
+	for key, value := range document {
+		path := prefix + key
+
+		if string(bytes.TrimSpace(value)) == "null" {
+			fields = append(fields, path)
+			continue
+		}
+
+		var nested map[string]json.RawMessage
+		if err := json.Unmarshal(value, &nested); err != nil {
+			// value is not an object, so it cannot contain nulls to clear
+			continue
+		}
+
+		fields = append(fields, nullFields(path+".", nested)...)
+	}
+
+	sort.Strings(fields)
+	return fields
+}
+
+func isClearableDatasetField(field string) bool {
+	for _, clearable := range clearableDatasetFields {
+		if field == clearable || strings.HasPrefix(field, clearable+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateVersion manages the creation of a version from a reader
+func CreateVersion(reader io.Reader, limits JSONLimits) (*Version, error) {
 	// Create unique id
 	id, err := uuid.NewV4()
 	if err != nil {
@@ -256,39 +776,28 @@ func CreateVersion(reader io.Reader) (*Version, error) {
 	var version Version
 	version.ID = id.String()
 
-	err = json.Unmarshal(b, &version)
-	if err != nil {
-		return nil, errs.ErrUnableToParseJSON
+	if err = DecodeJSON(reader, &version, limits); err != nil {
+		return nil, err
 	}
 
 	return &version, nil
 }
 
 // CreateDownloadList manages the creation of a list downloadable items from a reader
-func CreateDownloadList(reader io.Reader) (*DownloadList, error) {
-	b, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to read response body")
-	}
-
+func CreateDownloadList(reader io.Reader, limits JSONLimits) (*DownloadList, error) {
 	var downloadList DownloadList
-	if err := json.Unmarshal(b, &downloadList); err != nil {
-		return nil, errors.Wrap(err, "failed to parse json to downloadList")
+	if err := DecodeJSON(reader, &downloadList, limits); err != nil {
+		return nil, err
 	}
 
 	return &downloadList, nil
 }
 
 // CreateContact manages the creation of a contact from a reader
-func CreateContact(reader io.Reader) (*Contact, error) {
-	b, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return nil, errs.ErrUnableToReadMessage
-	}
+func CreateContact(reader io.Reader, limits JSONLimits) (*Contact, error) {
 	var contact Contact
-	err = json.Unmarshal(b, &contact)
-	if err != nil {
-		return nil, errs.ErrUnableToReadMessage
+	if err := DecodeJSON(reader, &contact, limits); err != nil {
+		return nil, err
 	}
 
 	// Create unique id
@@ -370,6 +879,40 @@ func (ed *EditionUpdate) UpdateLinks(host string) error {
 	return nil
 }
 
+// ClaimLinks points the latest version link of the editions.next document at a version number claimed for the
+// edition, ensuring links can't regress below the version published to current, and returns the link to the claimed
+// version. When the links of the dataset are frozen the editions.next document keeps its latest version link, unless
+// it has none yet.
+func (ed *EditionUpdate) ClaimLinks(host string, version int, frozen bool) (*LinkObject, error) {
+	if ed.Next == nil || ed.Next.Links == nil || ed.Next.Links.Dataset == nil {
+		return nil, ErrEditionLinksInvalid
+	}
+
+	if ed.Current != nil && ed.Current.Links != nil && ed.Current.Links.LatestVersion != nil {
+		currentVersion, err := strconv.Atoi(ed.Current.Links.LatestVersion.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to convert version id from edition.current document")
+		}
+
+		if currentVersion >= version {
+			log.Debug("published edition links to a version at or above the claimed version", log.Data{"doc": ed, "version": version})
+			return nil, errors.New("published edition links to a version at or above the claimed version")
+		}
+	}
+
+	versionID := strconv.Itoa(version)
+	versionLink := &LinkObject{
+		ID:   versionID,
+		HRef: fmt.Sprintf("%s/datasets/%s/editions/%s/versions/%s", host, ed.Next.Links.Dataset.ID, ed.Next.Edition, versionID),
+	}
+
+	if !frozen || ed.Next.Links.LatestVersion == nil {
+		ed.Next.Links.LatestVersion = versionLink
+	}
+
+	return versionLink, nil
+}
+
 //PublishLinks applies the provided versionLink object to the edition being published only
 //if that version is greater than the latest published version
 func (ed *EditionUpdate) PublishLinks(host string, versionLink *LinkObject) error {
@@ -405,9 +948,47 @@ func (ed *EditionUpdate) PublishLinks(host string, versionLink *LinkObject) erro
 	return nil
 }
 
+// RefreshLinks points the latest version links of the edition at the given versions. Unlike UpdateLinks
+// and PublishLinks a link may move to a lower version, so that a link left pointing at the wrong version
+// is corrected. The published link is only refreshed when latestPublished is not nil.
+func (ed *EditionUpdate) RefreshLinks(host string, latest, latestPublished *Version) (*EditionLinksRefresh, error) {
+	if ed.Next == nil || ed.Next.Links == nil || ed.Next.Links.Dataset == nil || latest == nil {
+		return nil, ErrEditionLinksInvalid
+	}
+
+	datasetID := ed.Next.Links.Dataset.ID
+	refresh := &EditionLinksRefresh{
+		Next: refreshLatestVersionLink(ed.Next.Links, host, datasetID, ed.Next.Edition, latest.Version),
+	}
+
+	if latestPublished != nil && ed.Current != nil && ed.Current.Links != nil {
+		refresh.Current = refreshLatestVersionLink(ed.Current.Links, host, datasetID, ed.Current.Edition, latestPublished.Version)
+	}
+
+	return refresh, nil
+}
+
+func refreshLatestVersionLink(links *EditionUpdateLinks, host, datasetID, edition string, version int) *LatestVersionChange {
+	change := &LatestVersionChange{After: strconv.Itoa(version)}
+	if links.LatestVersion != nil {
+		change.Before = links.LatestVersion.ID
+	}
+
+	links.LatestVersion = &LinkObject{
+		ID:   change.After,
+		HRef: fmt.Sprintf("%s/datasets/%s/editions/%s/versions/%s", host, datasetID, edition, change.After),
+	}
+
+	return change
+}
+
 // ValidateVersion checks the content of the version structure
 func ValidateVersion(version *Version) error {
 
+	if err := ValidateVersionLabels(version.Labels); err != nil {
+		return err
+	}
+
 	switch version.State {
 	case "":
 		return errs.ErrVersionMissingState
@@ -469,6 +1050,10 @@ func ValidateVersion(version *Version) error {
 		}
 	}
 
+	for _, format := range InvalidDownloadFormats(version.DownloadFormats) {
+		invalidFields = append(invalidFields, "download_formats "+format)
+	}
+
 	if missingFields != nil {
 		return fmt.Errorf("missing mandatory fields: %v", missingFields)
 	}
@@ -479,3 +1064,104 @@ func ValidateVersion(version *Version) error {
 
 	return nil
 }
+
+// ValidateVersionLabels checks a version has no more than MaxVersionLabels labels, and that each label has a key
+// which is not blank and a key and value within the maximum lengths allowed. Labels are stored as the fields of a
+// document, so a key cannot contain a "." or start with a "$".
+func ValidateVersionLabels(labels map[string]string) error {
+	if len(labels) > MaxVersionLabels {
+		return errs.ErrVersionLabelsInvalid
+	}
+
+	for key, value := range labels {
+		if strings.TrimSpace(key) == "" || utf8.RuneCountInString(key) > MaxVersionLabelKeyLength || utf8.RuneCountInString(value) > MaxVersionLabelValueLength {
+			return errs.ErrVersionLabelsInvalid
+		}
+		if strings.Contains(key, ".") || strings.HasPrefix(key, "$") {
+			return errs.ErrVersionLabelsInvalid
+		}
+	}
+	return nil
+}
+
+// ValidateVersionLinks checks the links of a version are consistent with the dataset, edition and version it is
+// stored as, the self and version links being the canonical URL of the version built by urlBuilder. Editions are
+// matched case insensitively, as they are stored, and the canonical URL uses the edition as the links spell it.
+// Links which are not set are not checked.
+func ValidateVersionLinks(version *Version, datasetID, edition, versionID string, urlBuilder *url.Builder) error {
+	if version.Links == nil {
+		return nil
+	}
+
+	var invalidFields []string
+
+	if version.Links.Dataset != nil && version.Links.Dataset.ID != datasetID {
+		invalidFields = append(invalidFields, fmt.Sprintf("links.dataset.id %q does not match dataset %q", version.Links.Dataset.ID, datasetID))
+	}
+
+	if version.Links.Edition != nil {
+		if strings.EqualFold(version.Links.Edition.ID, edition) {
+			edition = version.Links.Edition.ID
+		} else {
+			invalidFields = append(invalidFields, fmt.Sprintf("links.edition.id %q does not match edition %q", version.Links.Edition.ID, edition))
+		}
+	}
+
+	expected := urlBuilder.BuildDatasetVersionURL(datasetID, edition, versionID)
+	if version.Links.Self != nil && version.Links.Self.HRef != expected {
+		invalidFields = append(invalidFields, fmt.Sprintf("links.self.href %q does not match %q", version.Links.Self.HRef, expected))
+	}
+	if version.Links.Version != nil && version.Links.Version.HRef != expected {
+		invalidFields = append(invalidFields, fmt.Sprintf("links.version.href %q does not match %q", version.Links.Version.HRef, expected))
+	}
+
+	if invalidFields != nil {
+		return fmt.Errorf("invalid fields: %v", invalidFields)
+	}
+
+	return nil
+}
+
+// DatasetTree represents a dataset along with each of its editions and their versions
+type DatasetTree struct {
+	ID        string        `json:"id"`
+	State     string        `json:"state,omitempty"`
+	Title     string        `json:"title,omitempty"`
+	Editions  []EditionTree `json:"editions"`
+	Truncated bool          `json:"truncated,omitempty"`
+}
+
+// EditionTree represents an edition of a dataset within a dataset tree
+type EditionTree struct {
+	Edition  string           `json:"edition"`
+	State    string           `json:"state,omitempty"`
+	Versions []VersionSummary `json:"versions"`
+}
+
+// VersionSummary holds the identifying fields of a version within a dataset tree
+type VersionSummary struct {
+	ID          string `json:"id"`
+	Version     int    `json:"version"`
+	State       string `json:"state,omitempty"`
+	ReleaseDate string `json:"release_date,omitempty"`
+}
+
+// DatasetExport represents a dataset with all of its editions and versions, along with the metadata of each
+// version. The export is streamed, so it is only ever held in memory when it is being read back.
+type DatasetExport struct {
+	Dataset   *DatasetUpdate  `json:"dataset"`
+	Editions  []EditionExport `json:"editions"`
+	Truncated bool            `json:"truncated"`
+}
+
+// EditionExport represents an edition and its versions within a dataset export
+type EditionExport struct {
+	Edition  *EditionUpdate  `json:"edition"`
+	Versions []VersionExport `json:"versions"`
+}
+
+// VersionExport represents a version and its metadata within a dataset export
+type VersionExport struct {
+	Version  *Version  `json:"version"`
+	Metadata *Metadata `json:"metadata"`
+}
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/url"
 	"github.com/ONSdigital/go-ns/log"
 	"github.com/pkg/errors"
 	. "github.com/smartystreets/goconvey/convey"
@@ -29,7 +31,7 @@ func TestCreateDataset(t *testing.T) {
 				os.Exit(1)
 			}
 			r := bytes.NewReader(b)
-			dataset, err := CreateDataset(r)
+			dataset, err := CreateDataset(r, DefaultJSONLimits)
 			So(err, ShouldBeNil)
 			So(dataset.Links.AccessRights.HRef, ShouldEqual, "http://ons.gov.uk/accessrights")
 			So(dataset.CollectionID, ShouldEqual, collectionID)
@@ -48,6 +50,8 @@ func TestCreateDataset(t *testing.T) {
 			So(dataset.RelatedDatasets[0], ShouldResemble, relatedDatasets)
 			So(dataset.ReleaseFrequency, ShouldEqual, "yearly")
 			So(dataset.State, ShouldEqual, AssociatedState)
+			So(dataset.Subtopics, ShouldResemble, []string{"ethnicity", "national identity"})
+			So(dataset.Survey, ShouldEqual, "census")
 			So(dataset.Theme, ShouldEqual, "population")
 			So(dataset.Title, ShouldEqual, "CensusEthnicity")
 			So(dataset.UnitOfMeasure, ShouldEqual, "Pounds Sterling")
@@ -68,7 +72,7 @@ func TestCreateDataset(t *testing.T) {
 				os.Exit(1)
 			}
 			r := bytes.NewReader(b)
-			dataset, err := CreateDataset(r)
+			dataset, err := CreateDataset(r, DefaultJSONLimits)
 			So(dataset.ID, ShouldNotBeNil)
 
 			// Check id exists and emove before comparison with expected dataset; id
@@ -87,13 +91,385 @@ func TestCreateDataset(t *testing.T) {
 			os.Exit(1)
 		}
 		r := bytes.NewReader(b)
-		version, err := CreateDataset(r)
+		version, err := CreateDataset(r, DefaultJSONLimits)
 		So(version, ShouldBeNil)
 		So(err, ShouldNotBeNil)
 		So(err, ShouldResemble, errs.ErrUnableToParseJSON)
 	})
 }
 
+func TestValidateDatasetType(t *testing.T) {
+	t.Parallel()
+
+	Convey("Successfully return without any errors", t, func() {
+
+		Convey("when the dataset type is filterable", func() {
+			So(ValidateDatasetType(FilterableDatasetType), ShouldBeNil)
+		})
+
+		Convey("when the dataset type is static", func() {
+			So(ValidateDatasetType(StaticDatasetType), ShouldBeNil)
+		})
+	})
+
+	Convey("Return with error when the dataset type is not recognised", t, func() {
+		So(ValidateDatasetType("interactive"), ShouldEqual, errs.ErrDatasetTypeInvalid)
+		So(ValidateDatasetType(""), ShouldEqual, errs.ErrDatasetTypeInvalid)
+	})
+}
+
+func TestValidateDatasetContacts(t *testing.T) {
+	t.Parallel()
+
+	Convey("Successfully return without any errors when every contact has a name or an email", t, func() {
+		contacts := []ContactDetails{
+			{Name: "natalie jarrod"},
+			{Email: "cpi@test.com", Telephone: "01658 234567"},
+		}
+		So(ValidateDatasetContacts(contacts), ShouldBeNil)
+		So(ValidateDatasetContacts(nil), ShouldBeNil)
+	})
+
+	Convey("Return with error when a contact has neither a name nor an email", t, func() {
+		contacts := []ContactDetails{
+			{Name: "natalie jarrod"},
+			{Name: " ", Telephone: "01658 234567"},
+		}
+		So(ValidateDatasetContacts(contacts), ShouldEqual, errs.ErrDatasetContactInvalid)
+	})
+}
+
+func TestValidateDatasetPublisher(t *testing.T) {
+	t.Parallel()
+
+	Convey("Successfully return without any errors when the publisher type is known or not given", t, func() {
+		So(ValidateDatasetPublisher(&Publisher{Name: "ONS", Type: PublisherTypeDevolvedAdministration}), ShouldBeNil)
+		So(ValidateDatasetPublisher(&Publisher{Name: "ONS"}), ShouldBeNil)
+		So(ValidateDatasetPublisher(nil), ShouldBeNil)
+	})
+
+	Convey("Return with error when the publisher type is unknown", t, func() {
+		So(ValidateDatasetPublisher(&Publisher{Name: "ONS", Type: "Government"}), ShouldEqual, errs.ErrDatasetPublisherTypeInvalid)
+	})
+}
+
+func TestValidateDatasetURI(t *testing.T) {
+	t.Parallel()
+
+	Convey("Successfully return without any errors when the uri is an http or https url or not given", t, func() {
+		So(ValidateDatasetURI("http://localhost:22000/datasets/123/breadcrumbs"), ShouldBeNil)
+		So(ValidateDatasetURI("https://www.ons.gov.uk/economy/inflationandpriceindices"), ShouldBeNil)
+		So(ValidateDatasetURI(""), ShouldBeNil)
+	})
+
+	Convey("Return with error when the uri is malformed", t, func() {
+		So(ValidateDatasetURI("localhost:22000/datasets/123"), ShouldEqual, errs.ErrDatasetURIInvalid)
+		So(ValidateDatasetURI("/datasets/123/breadcrumbs"), ShouldEqual, errs.ErrDatasetURIInvalid)
+		So(ValidateDatasetURI("ftp://localhost/datasets/123"), ShouldEqual, errs.ErrDatasetURIInvalid)
+		So(ValidateDatasetURI("http://%41:8080/"), ShouldEqual, errs.ErrDatasetURIInvalid)
+	})
+}
+
+func TestValidateDatasetClassification(t *testing.T) {
+	t.Parallel()
+
+	Convey("Successfully return without any errors", t, func() {
+
+		Convey("when the survey and subtopics are within the limits", func() {
+			dataset := &Dataset{Survey: "census", Subtopics: []string{"ethnicity", "national identity"}}
+			So(ValidateDatasetClassification(dataset, 17, 2), ShouldBeNil)
+		})
+
+		Convey("when the survey and subtopics are not set", func() {
+			So(ValidateDatasetClassification(&Dataset{}, 1, 1), ShouldBeNil)
+		})
+	})
+
+	Convey("Return with error when the survey is too long", t, func() {
+		dataset := &Dataset{Survey: "labour force survey"}
+		So(ValidateDatasetClassification(dataset, 10, 2), ShouldEqual, errs.ErrDatasetSurveyInvalid)
+	})
+
+	Convey("Return with error when there are too many subtopics", t, func() {
+		dataset := &Dataset{Subtopics: []string{"ethnicity", "religion", "language"}}
+		So(ValidateDatasetClassification(dataset, 10, 2), ShouldEqual, errs.ErrDatasetSubtopicsInvalid)
+	})
+
+	Convey("Return with error when a subtopic is too long", t, func() {
+		dataset := &Dataset{Subtopics: []string{"ethnicity", "national identity"}}
+		So(ValidateDatasetClassification(dataset, 10, 2), ShouldEqual, errs.ErrDatasetSubtopicsInvalid)
+	})
+}
+
+func TestValidateVersionLabels(t *testing.T) {
+	t.Parallel()
+
+	Convey("Successfully return without any errors", t, func() {
+
+		Convey("when the version has no labels", func() {
+			So(ValidateVersionLabels(nil), ShouldBeNil)
+			So(ValidateVersionLabels(map[string]string{}), ShouldBeNil)
+		})
+
+		Convey("when the labels are within the limits", func() {
+			So(ValidateVersionLabels(map[string]string{"reviewed_by": "someone", "qa_status": ""}), ShouldBeNil)
+			So(ValidateVersionLabels(map[string]string{
+				strings.Repeat("k", MaxVersionLabelKeyLength): strings.Repeat("v", MaxVersionLabelValueLength),
+			}), ShouldBeNil)
+		})
+	})
+
+	Convey("Return with error when there are too many labels", t, func() {
+		labels := make(map[string]string)
+		for i := 0; i <= MaxVersionLabels; i++ {
+			labels[fmt.Sprintf("label_%d", i)] = "value"
+		}
+		So(ValidateVersionLabels(labels), ShouldEqual, errs.ErrVersionLabelsInvalid)
+	})
+
+	Convey("Return with error when a label key is blank", t, func() {
+		So(ValidateVersionLabels(map[string]string{" ": "value"}), ShouldEqual, errs.ErrVersionLabelsInvalid)
+	})
+
+	Convey("Return with error when a label key cannot be stored as a field name", t, func() {
+		So(ValidateVersionLabels(map[string]string{"qa.status": "passed"}), ShouldEqual, errs.ErrVersionLabelsInvalid)
+		So(ValidateVersionLabels(map[string]string{"$set": "passed"}), ShouldEqual, errs.ErrVersionLabelsInvalid)
+		So(ValidateVersionLabels(map[string]string{"qa_$status": "passed"}), ShouldBeNil)
+	})
+
+	Convey("Return with error when a label key or value is too long", t, func() {
+		So(ValidateVersionLabels(map[string]string{strings.Repeat("k", MaxVersionLabelKeyLength+1): "value"}), ShouldEqual, errs.ErrVersionLabelsInvalid)
+		So(ValidateVersionLabels(map[string]string{"qa_status": strings.Repeat("v", MaxVersionLabelValueLength+1)}), ShouldEqual, errs.ErrVersionLabelsInvalid)
+	})
+}
+
+func TestValidateReleaseDateNotPast(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2018, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+	Convey("Successfully return without any errors", t, func() {
+
+		Convey("when the release date is today or later", func() {
+			So(ValidateReleaseDateNotPast("2018-06-01", now), ShouldBeNil)
+			So(ValidateReleaseDateNotPast("2018-06-01T00:00:00Z", now), ShouldBeNil)
+			So(ValidateReleaseDateNotPast("2018-12-31", now), ShouldBeNil)
+		})
+
+		Convey("when there is no release date", func() {
+			So(ValidateReleaseDateNotPast("", now), ShouldBeNil)
+		})
+	})
+
+	Convey("Return with error when the release date is before today", t, func() {
+		So(ValidateReleaseDateNotPast("2018-05-31", now), ShouldEqual, errs.ErrVersionReleaseDateInPast)
+		So(ValidateReleaseDateNotPast("2018-05-31T22:59:59Z", now), ShouldEqual, errs.ErrVersionReleaseDateInPast)
+	})
+
+	Convey("Return with error when the release date cannot be parsed", t, func() {
+		So(ValidateReleaseDateNotPast("31/05/2018", now), ShouldEqual, errs.ErrVersionReleaseDateInvalid)
+	})
+
+	Convey("The release date is compared by calendar day in the UK during BST", t, func() {
+		lateEvening := time.Date(2018, time.June, 1, 23, 30, 0, 0, time.UTC)
+
+		Convey("when a release date at UK midnight is published later that day", func() {
+			So(ValidateReleaseDateNotPast("2018-06-01T23:00:00Z", lateEvening), ShouldBeNil)
+			So(ValidateReleaseDateNotPast("2018-06-01T23:00:00Z", lateEvening.Add(10*time.Hour)), ShouldBeNil)
+		})
+
+		Convey("when the previous UK day is published after UK midnight", func() {
+			So(ValidateReleaseDateNotPast("2018-06-01", lateEvening), ShouldEqual, errs.ErrVersionReleaseDateInPast)
+		})
+	})
+}
+
+func TestValidateDatasetDeprecation(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2018, time.June, 1, 9, 0, 0, 0, time.UTC)
+	deprecated := true
+	notDeprecated := false
+
+	Convey("Successfully return without any errors", t, func() {
+
+		Convey("when a deprecated dataset has a sunset date in the future", func() {
+			So(ValidateDatasetDeprecation(&Dataset{Deprecated: &deprecated, SunsetDate: "2018-12-31"}, nil, nil, now), ShouldBeNil)
+			So(ValidateDatasetDeprecation(&Dataset{Deprecated: &deprecated, SunsetDate: "2018-06-01T10:00:00Z"}, nil, nil, now), ShouldBeNil)
+		})
+
+		Convey("when the dataset is not deprecated", func() {
+			So(ValidateDatasetDeprecation(&Dataset{}, nil, nil, now), ShouldBeNil)
+			So(ValidateDatasetDeprecation(&Dataset{SunsetDate: "2018-01-01"}, nil, nil, now), ShouldBeNil)
+		})
+
+		Convey("when a dataset is deprecated by an update and already has a sunset date in the future", func() {
+			current := &Dataset{SunsetDate: "2018-12-31"}
+			So(ValidateDatasetDeprecation(&Dataset{Deprecated: &deprecated}, current, nil, now), ShouldBeNil)
+		})
+
+		Convey("when an update to a deprecated dataset whose sunset date has passed does not change its deprecation", func() {
+			current := &Dataset{Deprecated: &deprecated, SunsetDate: "2018-01-01"}
+			So(ValidateDatasetDeprecation(&Dataset{Title: "CPI"}, current, nil, now), ShouldBeNil)
+		})
+
+		Convey("when a deprecated dataset is no longer deprecated", func() {
+			current := &Dataset{Deprecated: &deprecated, SunsetDate: "2018-01-01"}
+			So(ValidateDatasetDeprecation(&Dataset{Deprecated: &notDeprecated}, current, nil, now), ShouldBeNil)
+			So(ValidateDatasetDeprecation(&Dataset{}, current, []string{"deprecated", "sunset_date"}, now), ShouldBeNil)
+		})
+	})
+
+	Convey("Return with error when a deprecated dataset has a sunset date which is not in the future", t, func() {
+		So(ValidateDatasetDeprecation(&Dataset{Deprecated: &deprecated, SunsetDate: "2018-01-01"}, nil, nil, now), ShouldEqual, errs.ErrDatasetSunsetDateInvalid)
+		So(ValidateDatasetDeprecation(&Dataset{Deprecated: &deprecated, SunsetDate: "2018-06-01T09:00:00Z"}, nil, nil, now), ShouldEqual, errs.ErrDatasetSunsetDateInvalid)
+	})
+
+	Convey("Return with error when the sunset date of a deprecated dataset is moved into the past", t, func() {
+		current := &Dataset{Deprecated: &deprecated, SunsetDate: "2018-12-31"}
+		So(ValidateDatasetDeprecation(&Dataset{SunsetDate: "2018-01-01"}, current, nil, now), ShouldEqual, errs.ErrDatasetSunsetDateInvalid)
+	})
+
+	Convey("Return with error when a dataset is deprecated by an update and its current sunset date has passed", t, func() {
+		current := &Dataset{SunsetDate: "2018-01-01"}
+		So(ValidateDatasetDeprecation(&Dataset{Deprecated: &deprecated}, current, nil, now), ShouldEqual, errs.ErrDatasetSunsetDateInvalid)
+	})
+
+	Convey("Return with error when the sunset date of a deprecated dataset is cleared", t, func() {
+		current := &Dataset{Deprecated: &deprecated, SunsetDate: "2018-12-31"}
+		So(ValidateDatasetDeprecation(&Dataset{}, current, []string{"sunset_date"}, now), ShouldEqual, errs.ErrDatasetSunsetDateInvalid)
+	})
+
+	Convey("Return with error when a deprecated dataset has no sunset date", t, func() {
+		So(ValidateDatasetDeprecation(&Dataset{Deprecated: &deprecated}, nil, nil, now), ShouldEqual, errs.ErrDatasetSunsetDateInvalid)
+	})
+
+	Convey("Return with error when the sunset date cannot be parsed", t, func() {
+		So(ValidateDatasetDeprecation(&Dataset{SunsetDate: "31/12/2018"}, nil, nil, now), ShouldEqual, errs.ErrDatasetSunsetDateInvalid)
+	})
+}
+
+func TestCreateDatasetPatch(t *testing.T) {
+	t.Parallel()
+
+	Convey("Successfully return without any errors", t, func() {
+
+		Convey("when a field is explicitly set to null it is cleared", func() {
+			r := bytes.NewReader([]byte(`{"description": null, "title": "CPI"}`))
+			patch, err := CreateDatasetPatch(r, DefaultJSONLimits)
+			So(err, ShouldBeNil)
+			So(patch.Clear, ShouldResemble, []string{"description"})
+			So(patch.Dataset.Title, ShouldEqual, "CPI")
+			So(patch.Dataset.Description, ShouldEqual, "")
+		})
+
+		Convey("when a nested field is explicitly set to null it is cleared", func() {
+			r := bytes.NewReader([]byte(`{"publisher": {"name": null, "type": "gov"}}`))
+			patch, err := CreateDatasetPatch(r, DefaultJSONLimits)
+			So(err, ShouldBeNil)
+			So(patch.Clear, ShouldResemble, []string{"publisher.name"})
+			So(patch.Dataset.Publisher.Type, ShouldEqual, "gov")
+		})
+
+		Convey("when no fields are null nothing is cleared", func() {
+			r := bytes.NewReader([]byte(`{"title": "CPI"}`))
+			patch, err := CreateDatasetPatch(r, DefaultJSONLimits)
+			So(err, ShouldBeNil)
+			So(patch.Clear, ShouldBeEmpty)
+		})
+
+		Convey("when only the state is set the patch changes only the state", func() {
+			r := bytes.NewReader([]byte(`{"state": "published"}`))
+			patch, err := CreateDatasetPatch(r, DefaultJSONLimits)
+			So(err, ShouldBeNil)
+			So(patch.ChangesOnlyState(), ShouldBeTrue)
+		})
+
+		Convey("when the state and another field are set the patch does not change only the state", func() {
+			r := bytes.NewReader([]byte(`{"state": "published", "title": "CPI"}`))
+			patch, err := CreateDatasetPatch(r, DefaultJSONLimits)
+			So(err, ShouldBeNil)
+			So(patch.ChangesOnlyState(), ShouldBeFalse)
+		})
+
+		Convey("when the state is set and a field is cleared the patch does not change only the state", func() {
+			r := bytes.NewReader([]byte(`{"state": "published", "description": null}`))
+			patch, err := CreateDatasetPatch(r, DefaultJSONLimits)
+			So(err, ShouldBeNil)
+			So(patch.ChangesOnlyState(), ShouldBeFalse)
+		})
+	})
+
+	Convey("Return with error", t, func() {
+
+		Convey("when the patch attempts to clear a field which cannot be removed", func() {
+			r := bytes.NewReader([]byte(`{"state": null}`))
+			patch, err := CreateDatasetPatch(r, DefaultJSONLimits)
+			So(patch, ShouldBeNil)
+			So(err, ShouldEqual, errs.ErrDatasetPatchFieldInvalid)
+		})
+
+		Convey("when the request body is not a json object", func() {
+			r := bytes.NewReader([]byte(`["description"]`))
+			patch, err := CreateDatasetPatch(r, DefaultJSONLimits)
+			So(patch, ShouldBeNil)
+			So(err, ShouldEqual, errs.ErrUnableToParseJSON)
+		})
+	})
+}
+
+func TestParseReleaseDateRange(t *testing.T) {
+	t.Parallel()
+
+	Convey("Successfully return a release date range", t, func() {
+
+		Convey("when both bounds are dates or RFC3339 timestamps", func() {
+			releaseDates, err := ParseReleaseDateRange("2017-01-01", "2018-01-01T12:00:00+01:00")
+			So(err, ShouldBeNil)
+			So(releaseDates.After.Equal(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)), ShouldBeTrue)
+			So(releaseDates.Before.Equal(time.Date(2018, 1, 1, 11, 0, 0, 0, time.UTC)), ShouldBeTrue)
+			So(releaseDates.IsEmpty(), ShouldBeFalse)
+		})
+
+		Convey("when neither bound is provided", func() {
+			releaseDates, err := ParseReleaseDateRange("", "")
+			So(err, ShouldBeNil)
+			So(releaseDates.IsEmpty(), ShouldBeTrue)
+		})
+	})
+
+	Convey("Return with error when a bound is not a valid date", t, func() {
+		_, err := ParseReleaseDateRange("04/04/2017", "")
+		So(err, ShouldEqual, ErrReleaseDateRangeInvalid)
+
+		_, err = ParseReleaseDateRange("", "2017-13-01")
+		So(err, ShouldEqual, ErrReleaseDateRangeInvalid)
+	})
+
+	Convey("Return with error when released_after is not earlier than released_before", t, func() {
+		_, err := ParseReleaseDateRange("2018-01-01", "2017-01-01")
+		So(err, ShouldEqual, ErrReleaseDateRangeOrder)
+
+		_, err = ParseReleaseDateRange("2018-01-01", "2018-01-01T00:00:00Z")
+		So(err, ShouldEqual, ErrReleaseDateRangeOrder)
+	})
+}
+
+func TestValidateInstanceReleaseDate(t *testing.T) {
+	t.Parallel()
+	Convey("A release date which is a date or RFC3339 timestamp is valid", t, func() {
+		So(ValidateInstanceReleaseDate("2017-12-12"), ShouldBeNil)
+		So(ValidateInstanceReleaseDate("2017-12-12T09:30:00Z"), ShouldBeNil)
+	})
+
+	Convey("A missing release date is rejected", t, func() {
+		So(ValidateInstanceReleaseDate(""), ShouldEqual, errs.ErrInstanceReleaseDateMissing)
+		So(ValidateInstanceReleaseDate("  "), ShouldEqual, errs.ErrInstanceReleaseDateMissing)
+	})
+
+	Convey("A release date which is not a date is rejected", t, func() {
+		So(ValidateInstanceReleaseDate("12/12/2017"), ShouldEqual, errs.ErrInstanceReleaseDateInvalid)
+	})
+}
+
 func TestCreateVersion(t *testing.T) {
 	t.Parallel()
 	Convey("Successfully return without any errors", t, func() {
@@ -104,7 +480,7 @@ func TestCreateVersion(t *testing.T) {
 				os.Exit(1)
 			}
 			r := bytes.NewReader(b)
-			version, err := CreateVersion(r)
+			version, err := CreateVersion(r, DefaultJSONLimits)
 			So(err, ShouldBeNil)
 			So(version.CollectionID, ShouldEqual, collectionID)
 			So(version.Dimensions, ShouldResemble, []Dimension{dimension})
@@ -127,7 +503,7 @@ func TestCreateVersion(t *testing.T) {
 			os.Exit(1)
 		}
 		r := bytes.NewReader(b)
-		version, err := CreateVersion(r)
+		version, err := CreateVersion(r, DefaultJSONLimits)
 		So(version, ShouldBeNil)
 		So(err, ShouldNotBeNil)
 		So(err, ShouldResemble, errs.ErrUnableToParseJSON)
@@ -220,6 +596,88 @@ func TestValidateVersion(t *testing.T) {
 			v.Downloads = &DownloadList{CSVW: &DownloadObject{HRef: "/", Size: "bob"}}
 			assertVersionDownloadError(fmt.Errorf("invalid fields: %v", []string{"Downloads.CSVW.Size not a number"}), v)
 		})
+
+		Convey("when an unknown download format is requested", func() {
+			v := &Version{ReleaseDate: "Today", State: EditionConfirmedState, DownloadFormats: []string{"csv", "xlsx"}}
+			assertVersionDownloadError(fmt.Errorf("invalid fields: %v", []string{"download_formats xlsx"}), v)
+		})
+	})
+}
+
+func TestValidateVersionLinks(t *testing.T) {
+	t.Parallel()
+	urlBuilder := url.NewBuilder("http://localhost:20000", "http://localhost:22000")
+
+	newVersion := func(datasetID string) *Version {
+		return &Version{
+			Links: &VersionLinks{
+				Dataset: &LinkObject{ID: datasetID, HRef: "http://localhost:22000/datasets/" + datasetID},
+				Edition: &LinkObject{ID: "2017", HRef: "http://localhost:22000/datasets/" + datasetID + "/editions/2017"},
+				Self:    &LinkObject{HRef: "http://localhost:22000/datasets/" + datasetID + "/editions/2017/versions/1"},
+				Version: &LinkObject{ID: "1", HRef: "http://localhost:22000/datasets/" + datasetID + "/editions/2017/versions/1"},
+			},
+		}
+	}
+
+	Convey("Given a version whose links match the dataset, edition and version", t, func() {
+		err := ValidateVersionLinks(newVersion("123"), "123", "2017", "1", urlBuilder)
+
+		Convey("Then no error is returned", func() {
+			So(err, ShouldBeNil)
+		})
+	})
+
+	Convey("Given a version without links", t, func() {
+		err := ValidateVersionLinks(&Version{}, "123", "2017", "1", urlBuilder)
+
+		Convey("Then no error is returned", func() {
+			So(err, ShouldBeNil)
+		})
+	})
+
+	Convey("Given a version whose links are for a different dataset", t, func() {
+		err := ValidateVersionLinks(newVersion("456"), "123", "2017", "1", urlBuilder)
+
+		Convey("Then each mismatched link is described in the error", func() {
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, `invalid fields: [links.dataset.id "456" does not match dataset "123" `+
+				`links.self.href "http://localhost:22000/datasets/456/editions/2017/versions/1" does not match "http://localhost:22000/datasets/123/editions/2017/versions/1" `+
+				`links.version.href "http://localhost:22000/datasets/456/editions/2017/versions/1" does not match "http://localhost:22000/datasets/123/editions/2017/versions/1"]`)
+		})
+	})
+
+	Convey("Given a version whose edition link is for a different edition", t, func() {
+		version := newVersion("123")
+		version.Links.Edition.ID = "2018"
+		err := ValidateVersionLinks(version, "123", "2017", "1", urlBuilder)
+
+		Convey("Then the mismatched edition is described in the error", func() {
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, `invalid fields: [links.edition.id "2018" does not match edition "2017"]`)
+		})
+	})
+
+	Convey("Given a version whose links spell the edition in a different case", t, func() {
+		version := newVersion("123")
+		version.Links.Edition.ID = "Time-Series"
+		version.Links.Self.HRef = "http://localhost:22000/datasets/123/editions/Time-Series/versions/1"
+		version.Links.Version.HRef = version.Links.Self.HRef
+		err := ValidateVersionLinks(version, "123", "time-series", "1", urlBuilder)
+
+		Convey("Then no error is returned", func() {
+			So(err, ShouldBeNil)
+		})
+	})
+
+	Convey("Given a version whose self link is for a different version", t, func() {
+		version := newVersion("123")
+		version.Links.Self.HRef = "http://localhost:22000/datasets/123/editions/2017/versions/2"
+		err := ValidateVersionLinks(version, "123", "2017", "1", urlBuilder)
+
+		Convey("Then the mismatched self link is described in the error", func() {
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, `invalid fields: [links.self.href "http://localhost:22000/datasets/123/editions/2017/versions/2" does not match "http://localhost:22000/datasets/123/editions/2017/versions/1"]`)
+		})
 	})
 }
 
@@ -232,9 +690,9 @@ func assertVersionDownloadError(expected error, v *Version) {
 func TestCreateDownloadList(t *testing.T) {
 	Convey("invalid input bytes return the expected error", t, func() {
 		reader := bytes.NewReader([]byte("hello"))
-		dl, err := CreateDownloadList(reader)
+		dl, err := CreateDownloadList(reader, DefaultJSONLimits)
 		So(dl, ShouldBeNil)
-		So(reflect.TypeOf(errors.Cause(err)), ShouldEqual, reflect.TypeOf(&json.SyntaxError{}))
+		So(err, ShouldEqual, errs.ErrUnableToParseJSON)
 	})
 
 	Convey("valid input returns the expected value", t, func() {
@@ -248,13 +706,73 @@ func TestCreateDownloadList(t *testing.T) {
 		input, _ := json.Marshal(expected)
 		reader := bytes.NewReader(input)
 
-		dl, err := CreateDownloadList(reader)
+		dl, err := CreateDownloadList(reader, DefaultJSONLimits)
 		So(err, ShouldBeNil)
 		So(dl, ShouldResemble, expected)
 	})
 
 }
 
+func TestUnpublishedFormats(t *testing.T) {
+	t.Parallel()
+	Convey("Given downloads of which only the csv has been published", t, func() {
+		downloads := &DownloadList{
+			CSV:  &DownloadObject{Private: "s3://csv", Public: "https://csv"},
+			CSVW: &DownloadObject{Private: "s3://csvw"},
+			XLS:  &DownloadObject{Private: "s3://xls"},
+		}
+
+		Convey("When all formats are requested then those without a public link are returned", func() {
+			So(downloads.UnpublishedFormats([]string{"csv", "csvw", "xls"}), ShouldResemble, []string{"csvw", "xls"})
+		})
+
+		Convey("When only the csv is requested then no formats are returned", func() {
+			So(downloads.UnpublishedFormats([]string{"csv"}), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a version without downloads then no formats are returned", t, func() {
+		var downloads *DownloadList
+		So(downloads.UnpublishedFormats([]string{"csv"}), ShouldBeNil)
+	})
+}
+
+func TestSignDownloads(t *testing.T) {
+	t.Parallel()
+	Convey("Given downloads with and without links", t, func() {
+		downloads := &DownloadList{
+			CSV: &DownloadObject{HRef: "https://csv", Private: "s3://csv"},
+			XLS: &DownloadObject{Private: "s3://xls"},
+		}
+		sign := func(href string) (string, error) {
+			return href + "?signature=abc", nil
+		}
+
+		Convey("When they are signed then only the links which are set are replaced", func() {
+			So(downloads.Sign(sign), ShouldBeNil)
+			So(downloads.CSV.HRef, ShouldEqual, "https://csv?signature=abc")
+			So(downloads.XLS.HRef, ShouldBeEmpty)
+		})
+
+		Convey("When signing fails then the error is returned", func() {
+			signErr := errors.New("no key")
+			So(downloads.Sign(func(string) (string, error) { return "", signErr }), ShouldEqual, signErr)
+		})
+
+		Convey("When the private links are removed then the other fields are kept", func() {
+			downloads.RemovePrivateLinks()
+			So(downloads.CSV, ShouldResemble, &DownloadObject{HRef: "https://csv"})
+			So(downloads.XLS, ShouldResemble, &DownloadObject{})
+		})
+	})
+
+	Convey("Given a version without downloads then nothing is signed or removed", t, func() {
+		var downloads *DownloadList
+		So(downloads.Sign(nil), ShouldBeNil)
+		downloads.RemovePrivateLinks()
+	})
+}
+
 func TestUpdateLinks(t *testing.T) {
 	host := "example.com"
 
@@ -387,6 +905,62 @@ func TestUpdateLinks(t *testing.T) {
 	})
 }
 
+func TestClaimLinks(t *testing.T) {
+	host := "example.com"
+
+	Convey("Given a new edition with no links", t, func() {
+		edition := &EditionUpdate{ID: "test", Next: &Edition{ID: "test", Edition: "time-series"}}
+
+		Convey("when ClaimLinks is called then an error should be returned", func() {
+			versionLink, err := edition.ClaimLinks(host, 1, false)
+			So(err, ShouldEqual, ErrEditionLinksInvalid)
+			So(versionLink, ShouldBeNil)
+		})
+	})
+
+	Convey("Given an edition with version 1 published", t, func() {
+		links := func() *EditionUpdateLinks {
+			return &EditionUpdateLinks{
+				LatestVersion: &LinkObject{ID: "1", HRef: "example.com/datasets/1/editions/time-series/versions/1"},
+				Dataset:       &LinkObject{ID: "1", HRef: "example.com/datasets/1"},
+			}
+		}
+		edition := &EditionUpdate{
+			ID:      "test",
+			Next:    &Edition{ID: "test", Edition: "time-series", Links: links()},
+			Current: &Edition{ID: "test", Edition: "time-series", Links: links()},
+		}
+
+		Convey("when ClaimLinks is called with a claimed version after the published version", func() {
+			versionLink, err := edition.ClaimLinks(host, 3, false)
+
+			Convey("then the next latest version links to the claimed version", func() {
+				So(err, ShouldBeNil)
+				So(versionLink, ShouldResemble, &LinkObject{ID: "3", HRef: "example.com/datasets/1/editions/time-series/versions/3"})
+				So(edition.Next.Links.LatestVersion, ShouldResemble, versionLink)
+				So(edition.Current.Links.LatestVersion.ID, ShouldEqual, "1")
+			})
+		})
+
+		Convey("when ClaimLinks is called while the dataset links are frozen", func() {
+			versionLink, err := edition.ClaimLinks(host, 3, true)
+
+			Convey("then the claimed version is returned but the next latest version link is unchanged", func() {
+				So(err, ShouldBeNil)
+				So(versionLink, ShouldResemble, &LinkObject{ID: "3", HRef: "example.com/datasets/1/editions/time-series/versions/3"})
+				So(edition.Next.Links.LatestVersion.ID, ShouldEqual, "1")
+			})
+		})
+
+		Convey("when ClaimLinks is called with the published version then an error should be returned", func() {
+			_, err := edition.ClaimLinks(host, 1, false)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "published edition links to a version at or above the claimed version")
+			So(edition.Next.Links.LatestVersion.ID, ShouldEqual, "1")
+		})
+	})
+}
+
 func TestPublishLinks(t *testing.T) {
 	host := "example.com"
 
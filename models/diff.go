@@ -0,0 +1,84 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// VersionDiff reports how the dimensions of a version differ from those of another version it is compared against
+type VersionDiff struct {
+	Version           int      `json:"version"`
+	Against           int      `json:"against"`
+	DimensionsAdded   []string `json:"dimensions_added"`
+	DimensionsRemoved []string `json:"dimensions_removed"`
+	DimensionsChanged []string `json:"dimensions_changed"`
+}
+
+// DiffVersionDimensions compares the dimensions of a version against those of another version, by name. A dimension
+// is changed when it is in both versions but uses a different code list. Each list of names is sorted.
+func DiffVersionDimensions(version, against *Version) *VersionDiff {
+	diff := &VersionDiff{
+		Version:           version.Version,
+		Against:           against.Version,
+		DimensionsAdded:   []string{},
+		DimensionsRemoved: []string{},
+		DimensionsChanged: []string{},
+	}
+
+	previous := make(map[string]Dimension, len(against.Dimensions))
+	for _, dimension := range against.Dimensions {
+		previous[dimension.Name] = dimension
+	}
+
+	current := make(map[string]bool, len(version.Dimensions))
+	for _, dimension := range version.Dimensions {
+		current[dimension.Name] = true
+
+		old, ok := previous[dimension.Name]
+		switch {
+		case !ok:
+			diff.DimensionsAdded = append(diff.DimensionsAdded, dimension.Name)
+		case old.ID != dimension.ID:
+			diff.DimensionsChanged = append(diff.DimensionsChanged, dimension.Name)
+		}
+	}
+
+	for _, dimension := range against.Dimensions {
+		if !current[dimension.Name] {
+			diff.DimensionsRemoved = append(diff.DimensionsRemoved, dimension.Name)
+		}
+	}
+
+	sort.Strings(diff.DimensionsAdded)
+	sort.Strings(diff.DimensionsRemoved)
+	sort.Strings(diff.DimensionsChanged)
+	return diff
+}
+
+// DiffJSONFields compares two JSON objects field by field, returning an object holding the top level fields of after
+// whose values differ from those in before, along with a null for each field of before which is not in after
+func DiffJSONFields(before, after []byte) ([]byte, error) {
+	var previous, current map[string]json.RawMessage
+	if err := json.Unmarshal(before, &previous); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(after, &current); err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]json.RawMessage)
+	for field, value := range current {
+		if old, ok := previous[field]; !ok || !bytes.Equal(old, value) {
+			changes[field] = value
+		}
+	}
+
+	for field := range previous {
+		if _, ok := current[field]; !ok {
+			changes[field] = json.RawMessage("null")
+		}
+	}
+
+	return json.Marshal(changes)
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiffVersionDimensions(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given two versions whose dimensions differ", t, func() {
+		against := &Version{Version: 1, Dimensions: []Dimension{
+			{Name: "time", ID: "time"},
+			{Name: "geography", ID: "uk-only"},
+			{Name: "sex", ID: "sex"},
+		}}
+		version := &Version{Version: 2, Dimensions: []Dimension{
+			{Name: "time", ID: "time"},
+			{Name: "geography", ID: "local-authority"},
+			{Name: "age", ID: "age"},
+			{Name: "aggregate", ID: "cpih1dim1aggid"},
+		}}
+
+		Convey("Then the added, removed and changed dimensions are reported by name", func() {
+			So(DiffVersionDimensions(version, against), ShouldResemble, &VersionDiff{
+				Version:           2,
+				Against:           1,
+				DimensionsAdded:   []string{"age", "aggregate"},
+				DimensionsRemoved: []string{"sex"},
+				DimensionsChanged: []string{"geography"},
+			})
+		})
+	})
+
+	Convey("Given two versions with the same dimensions then no differences are reported", t, func() {
+		dimensions := []Dimension{{Name: "time", ID: "time"}}
+
+		diff := DiffVersionDimensions(&Version{Version: 2, Dimensions: dimensions}, &Version{Version: 1, Dimensions: dimensions})
+		So(diff.DimensionsAdded, ShouldBeEmpty)
+		So(diff.DimensionsRemoved, ShouldBeEmpty)
+		So(diff.DimensionsChanged, ShouldBeEmpty)
+	})
+}
+
+func TestDiffJSONFields(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given two json objects whose fields differ", t, func() {
+		before := []byte(`{"state":"created","edition":"2017","links":{"self":{"id":"123"}},"headers":["V4_0"]}`)
+		after := []byte(`{"state":"submitted","edition":"2017","links":{"self":{"id":"123"}},"version":1}`)
+
+		Convey("Then the changed and added fields are returned with a null for each removed field", func() {
+			changes, err := DiffJSONFields(before, after)
+			So(err, ShouldBeNil)
+			So(string(changes), ShouldEqual, `{"headers":null,"state":"submitted","version":1}`)
+		})
+	})
+
+	Convey("Given two identical json objects then no fields are returned", t, func() {
+		changes, err := DiffJSONFields([]byte(`{"state":"created"}`), []byte(`{"state":"created"}`))
+		So(err, ShouldBeNil)
+		So(string(changes), ShouldEqual, `{}`)
+	})
+
+	Convey("Given a document which is not a json object then an error is returned", t, func() {
+		_, err := DiffJSONFields([]byte(`["state"]`), []byte(`{"state":"created"}`))
+		So(err, ShouldNotBeNil)
+	})
+}
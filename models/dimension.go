@@ -1,6 +1,13 @@
 package models
 
-import "time"
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+)
 
 // DatasetDimensionResults represents a structure for a list of dimensions
 type DatasetDimensionResults struct {
@@ -12,6 +19,11 @@ type DimensionOptionResults struct {
 	Items []PublicDimensionOption `json:"items"`
 }
 
+// DimensionOptionCodes holds the codes of the options of a dimension to be retrieved
+type DimensionOptionCodes struct {
+	Codes []string `json:"codes"`
+}
+
 // Dimension represents an overview for a single dimension. This includes a link to the code list API
 // which provides metadata about the dimension and all possible values.
 type Dimension struct {
@@ -22,6 +34,7 @@ type Dimension struct {
 	HRef        string        `json:"href,omitempty"`
 	ID          string        `json:"id,omitempty"`
 	Name        string        `bson:"name,omitempty"          json:"name,omitempty"`
+	Ordinal     bool          `bson:"ordinal,omitempty"       json:"ordinal,omitempty"`
 }
 
 // DimensionLink contains all links needed for a dimension
@@ -40,6 +53,7 @@ type CachedDimensionOption struct {
 	Name       string `bson:"name,omitempty"           json:"dimension"`
 	NodeID     string `bson:"node_id,omitempty"        json:"node_id"`
 	Option     string `bson:"option,omitempty"         json:"option"`
+	Order      *int   `bson:"order,omitempty"          json:"order,omitempty"`
 }
 
 // DimensionOption contains unique information and metadata used when processing the data
@@ -51,6 +65,14 @@ type DimensionOption struct {
 	Name        string               `bson:"name,omitempty"           json:"dimension"`
 	NodeID      string               `bson:"node_id,omitempty"        json:"node_id"`
 	Option      string               `bson:"option,omitempty"         json:"option"`
+	Order       *int                 `bson:"order,omitempty"          json:"order,omitempty"`
+}
+
+// DimensionOptionUpdate represents a partial update to the label and links of a dimension option, only the fields
+// given are changed
+type DimensionOptionUpdate struct {
+	Label string                `json:"label,omitempty"`
+	Links *DimensionOptionLinks `json:"links,omitempty"`
 }
 
 // PublicDimensionOption hides values which are only used by interval services
@@ -59,6 +81,7 @@ type PublicDimensionOption struct {
 	Links  DimensionOptionLinks `bson:"links,omitempty"          json:"links"`
 	Name   string               `bson:"name,omitempty"           json:"dimension"`
 	Option string               `bson:"option,omitempty"         json:"option"`
+	Order  *int                 `bson:"order,omitempty"          json:"order,omitempty"`
 }
 
 // DimensionOptionLinks represents a list of link objects related to dimension options
@@ -70,7 +93,43 @@ type DimensionOptionLinks struct {
 
 // DimensionNodeResults wraps dimension node objects for pagination
 type DimensionNodeResults struct {
-	Items []DimensionOption `json:"items"`
+	Items        []DimensionOption      `json:"items"`
+	OptionCounts []DimensionOptionCount `json:"option_counts,omitempty"`
+}
+
+// SortByDimensionOrder sorts the dimension options and option counts of an instance into the canonical order of its
+// dimensions, followed by any dimensions missing from the order sorted by name. The options of a dimension keep the
+// order they were stored in.
+func (r *DimensionNodeResults) SortByDimensionOrder(order []string) {
+	position := make(map[string]int, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		position[order[i]] = i
+	}
+
+	before := func(a, b string) bool {
+		i, aOrdered := position[a]
+		j, bOrdered := position[b]
+		switch {
+		case aOrdered && bOrdered:
+			return i < j
+		case aOrdered != bOrdered:
+			return aOrdered
+		}
+		return a < b
+	}
+
+	sort.SliceStable(r.Items, func(i, j int) bool {
+		return before(r.Items[i].Name, r.Items[j].Name)
+	})
+	sort.SliceStable(r.OptionCounts, func(i, j int) bool {
+		return before(r.OptionCounts[i].Name, r.OptionCounts[j].Name)
+	})
+}
+
+// DimensionOptionCount holds the number of options stored for a dimension of an instance
+type DimensionOptionCount struct {
+	Name        string `bson:"_id"          json:"dimension"`
+	OptionCount int    `bson:"option_count" json:"option_count"`
 }
 
 // DimensionValues holds all unique values for a dimension
@@ -78,3 +137,38 @@ type DimensionValues struct {
 	Name    string   `json:"dimension"`
 	Options []string `json:"options"`
 }
+
+// NormaliseDimensionName returns the canonical form of a dimension name, which is
+// lowercase and without any surrounding whitespace
+func NormaliseDimensionName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// ValidateDimensionName checks a dimension name is in its canonical form
+func ValidateDimensionName(name string) error {
+	if name == "" {
+		return errs.ErrDimensionNameInvalid
+	}
+
+	for _, r := range name {
+		if unicode.IsSpace(r) || unicode.IsUpper(r) {
+			return errs.ErrDimensionNameInvalid
+		}
+	}
+	return nil
+}
+
+// NormaliseDimensions normalises the names of the dimensions, if normalise is true,
+// and then checks every name is in its canonical form
+func NormaliseDimensions(dimensions []Dimension, normalise bool) error {
+	for i := range dimensions {
+		if normalise {
+			dimensions[i].Name = NormaliseDimensionName(dimensions[i].Name)
+		}
+
+		if err := ValidateDimensionName(dimensions[i].Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
@@ -13,10 +15,13 @@ type Instance struct {
 	Alerts            *[]Alert             `bson:"alerts,omitempty"                      json:"alerts,omitempty"`
 	CollectionID      string               `bson:"collection_id,omitempty"               json:"collection_id,omitempty"`
 	Dimensions        []Dimension          `bson:"dimensions,omitempty"                  json:"dimensions,omitempty"`
+	DimensionOrder    []string             `bson:"dimension_order,omitempty"             json:"dimension_order,omitempty"`
 	Downloads         *DownloadList        `bson:"downloads,omitempty"                   json:"downloads,omitempty"`
 	Edition           string               `bson:"edition,omitempty"                     json:"edition,omitempty"`
 	Events            *[]Event             `bson:"events,omitempty"                      json:"events,omitempty"`
+	FailureReason     string               `bson:"failure_reason,omitempty"              json:"failure_reason,omitempty"`
 	Headers           *[]string            `bson:"headers,omitempty"                     json:"headers,omitempty"`
+	IdempotencyKey    string               `bson:"idempotency_key,omitempty"             json:"-"`
 	ImportTasks       *InstanceImportTasks `bson:"import_tasks,omitempty"                json:"import_tasks"`
 	InstanceID        string               `bson:"id,omitempty"                          json:"id,omitempty"`
 	LastUpdated       time.Time            `bson:"last_updated,omitempty"                json:"last_updated,omitempty"`
@@ -28,6 +33,192 @@ type Instance struct {
 	TotalObservations *int                 `bson:"total_observations,omitempty"          json:"total_observations,omitempty"`
 	UniqueTimestamp   bson.MongoTimestamp  `bson:"unique_timestamp"                      json:"-"`
 	Version           int                  `bson:"version,omitempty"                     json:"version,omitempty"`
+
+	HasConfidenceIntervals *bool `bson:"has_confidence_intervals,omitempty" json:"has_confidence_intervals,omitempty"`
+	HasDataMarkings        *bool `bson:"has_data_markings,omitempty"        json:"has_data_markings,omitempty"`
+}
+
+// ImportProgress represents the progress of importing the observations of an instance
+type ImportProgress struct {
+	State                string `json:"state"`
+	InsertedObservations int64  `json:"inserted_observations"`
+	TotalObservations    int    `json:"total_observations"`
+}
+
+// NewImportProgress returns the import progress of the instance
+func NewImportProgress(instance *Instance) ImportProgress {
+	progress := ImportProgress{State: instance.State}
+
+	if instance.TotalObservations != nil {
+		progress.TotalObservations = *instance.TotalObservations
+	}
+
+	if instance.ImportTasks != nil && instance.ImportTasks.ImportObservations != nil {
+		progress.InsertedObservations = instance.ImportTasks.ImportObservations.InsertedObservations
+	}
+
+	return progress
+}
+
+// VerboseInstance is the raw instance document, including the internal fields otherwise never serialised, returned
+// to the import services which ask for it to diagnose an import
+type VerboseInstance struct {
+	*Instance
+	IdempotencyKey  string              `json:"idempotency_key,omitempty"`
+	UniqueTimestamp bson.MongoTimestamp `json:"unique_timestamp"`
+}
+
+// NewVerboseInstance returns the verbose view of the instance
+func NewVerboseInstance(instance *Instance) *VerboseInstance {
+	return &VerboseInstance{
+		Instance:        instance,
+		IdempotencyKey:  instance.IdempotencyKey,
+		UniqueTimestamp: instance.UniqueTimestamp,
+	}
+}
+
+// Trim removes the import diagnostics, the states of the import tasks and the events raised by the import, from an
+// instance returned to a caller which has asked for the trimmed view
+func (i *Instance) Trim() {
+	if i == nil {
+		return
+	}
+
+	i.ImportTasks = nil
+	i.Events = nil
+}
+
+// DimensionOffset returns the number of metadata columns between the observation column and the first
+// dimension column, given by the N of the V4_N first header.
+func DimensionOffset(headers []string) (int, error) {
+	if len(headers) == 0 {
+		return 0, errs.ErrIndexOutOfRange
+	}
+
+	metaData := strings.Split(headers[0], "_")
+	if len(metaData) < 2 {
+		return 0, errs.ErrIndexOutOfRange
+	}
+
+	dimensionOffset, err := strconv.Atoi(metaData[1])
+	if err != nil {
+		return 0, err
+	}
+
+	return dimensionOffset, nil
+}
+
+// The names of the observation metadata columns which may follow the observation column
+const (
+	DataMarkingColumn        = "data_marking"
+	ConfidenceIntervalColumn = "confidence_interval"
+)
+
+// HeaderMetadataColumns reports whether the metadata columns of the headers, those between the observation column
+// and the first dimension column, include data markings and confidence intervals.
+func HeaderMetadataColumns(headers []string) (hasDataMarkings, hasConfidenceIntervals bool, err error) {
+	dimensionOffset, err := DimensionOffset(headers)
+	if err != nil {
+		return false, false, err
+	}
+
+	if dimensionOffset >= len(headers) {
+		return false, false, errs.ErrIndexOutOfRange
+	}
+
+	for _, column := range headers[1 : dimensionOffset+1] {
+		switch strings.ToLower(column) {
+		case DataMarkingColumn:
+			hasDataMarkings = true
+		case ConfidenceIntervalColumn:
+			hasConfidenceIntervals = true
+		}
+	}
+
+	return hasDataMarkings, hasConfidenceIntervals, nil
+}
+
+// ValidateHeaders checks the headers are non-empty and that the first header is in the V4_N format, where N is
+// the number of metadata columns which follow the observation column.
+func ValidateHeaders(headers []string) error {
+	if len(headers) == 0 {
+		return errs.ErrHeadersEmpty
+	}
+
+	if !strings.HasPrefix(strings.ToLower(headers[0]), "v4_") {
+		return errs.ErrHeadersFirstCellInvalid
+	}
+
+	dimensionOffset, err := DimensionOffset(headers)
+	if err != nil || dimensionOffset < 0 || dimensionOffset >= len(headers) {
+		return errs.ErrHeadersFirstCellInvalid
+	}
+
+	return nil
+}
+
+// CSVHeaders holds the header row of a V4 file, as given to preview how it will be parsed
+type CSVHeaders struct {
+	Headers []string `json:"headers"`
+}
+
+// CSVHeadersPreview describes how the header row of a V4 file is parsed, the number of metadata columns following
+// the observation column and the names of the dimensions taken from the label column of each dimension
+type CSVHeadersPreview struct {
+	DimensionOffset int      `json:"dimension_offset"`
+	Dimensions      []string `json:"dimensions"`
+}
+
+// CSVHeadersValidation holds the result of checking the dimensions of a V4 header row against the dimensions
+// declared on a version, the header row is valid when no dimension is missing from it or unexpected in it
+type CSVHeadersValidation struct {
+	Valid                bool     `json:"valid"`
+	MissingDimensions    []string `json:"missing_dimensions"`
+	UnexpectedDimensions []string `json:"unexpected_dimensions"`
+}
+
+// PreviewHeaders validates the header row of a V4 file and returns how it will be parsed. After the metadata
+// columns the headers must be in pairs, a code list column followed by a label column for each dimension.
+func PreviewHeaders(headers []string) (*CSVHeadersPreview, error) {
+	if err := ValidateHeaders(headers); err != nil {
+		return nil, err
+	}
+
+	dimensionOffset, err := DimensionOffset(headers)
+	if err != nil {
+		return nil, errs.ErrHeadersFirstCellInvalid
+	}
+
+	if (len(headers)-dimensionOffset-1)%2 != 0 {
+		return nil, errs.ErrHeadersDimensionColumnsInvalid
+	}
+
+	dimensions := []string{}
+	for i := dimensionOffset + 2; i < len(headers); i += 2 {
+		dimensions = append(dimensions, strings.ToLower(headers[i]))
+	}
+
+	return &CSVHeadersPreview{DimensionOffset: dimensionOffset, Dimensions: dimensions}, nil
+}
+
+// RenameHeadersDimension returns a copy of the header row of a V4 file with the label column of a dimension renamed.
+// Headers which cannot be parsed are returned unchanged.
+func RenameHeadersDimension(headers []string, oldName, newName string) []string {
+	renamed := make([]string, len(headers))
+	copy(renamed, headers)
+
+	dimensionOffset, err := DimensionOffset(headers)
+	if err != nil || dimensionOffset < 0 {
+		return renamed
+	}
+
+	for i := dimensionOffset + 2; i < len(renamed); i += 2 {
+		if strings.EqualFold(renamed[i], oldName) {
+			renamed[i] = newName
+		}
+	}
+
+	return renamed
 }
 
 // InstanceImportTasks represents all of the tasks required to complete an import job.
@@ -40,6 +231,7 @@ type InstanceImportTasks struct {
 // ImportObservationsTask represents the task of importing instance observation data into the database.
 type ImportObservationsTask struct {
 	InsertedObservations int64  `bson:"total_inserted_observations" json:"total_inserted_observations"`
+	Reason               string `bson:"reason,omitempty"            json:"reason,omitempty"`
 	State                string `bson:"state,omitempty"             json:"state,omitempty"`
 }
 
@@ -51,6 +243,7 @@ type BuildHierarchyTask struct {
 
 type GenericTaskDetails struct {
 	DimensionName string `bson:"dimension_name,omitempty" json:"dimension_name,omitempty"`
+	Reason        string `bson:"reason,omitempty"         json:"reason,omitempty"`
 	State         string `bson:"state,omitempty"          json:"state,omitempty"`
 }
 
@@ -61,13 +254,14 @@ type BuildSearchIndexTask struct {
 
 // InstanceLinks holds all links for an instance
 type InstanceLinks struct {
-	Dataset    *LinkObject `bson:"dataset,omitempty"    json:"dataset,omitempty"`
-	Dimensions *LinkObject `bson:"dimensions,omitempty" json:"dimensions,omitempty"`
-	Edition    *LinkObject `bson:"edition,omitempty"    json:"edition,omitempty"`
-	Job        *LinkObject `bson:"job,omitempty"        json:"job"`
-	Self       *LinkObject `bson:"self,omitempty"       json:"self,omitempty"`
-	Spatial    *LinkObject `bson:"spatial,omitempty"    json:"spatial,omitempty"`
-	Version    *LinkObject `bson:"version,omitempty"    json:"version,omitempty"`
+	Dataset    *LinkObject `bson:"dataset,omitempty"     json:"dataset,omitempty"`
+	Dimensions *LinkObject `bson:"dimensions,omitempty"  json:"dimensions,omitempty"`
+	Edition    *LinkObject `bson:"edition,omitempty"     json:"edition,omitempty"`
+	IsBasedOn  *LinkObject `bson:"is_based_on,omitempty" json:"is_based_on,omitempty"`
+	Job        *LinkObject `bson:"job,omitempty"         json:"job"`
+	Self       *LinkObject `bson:"self,omitempty"        json:"self,omitempty"`
+	Spatial    *LinkObject `bson:"spatial,omitempty"     json:"spatial,omitempty"`
+	Version    *LinkObject `bson:"version,omitempty"     json:"version,omitempty"`
 }
 
 // Event which has happened to an instance
@@ -83,6 +277,34 @@ type InstanceResults struct {
 	Items []Instance `json:"items"`
 }
 
+// PurgedInstances represents the number of instances removed by a purge
+type PurgedInstances struct {
+	Count int `json:"count"`
+}
+
+// InstanceStateCount holds the number of instances in a state
+type InstanceStateCount struct {
+	State string `bson:"_id"`
+	Count int    `bson:"count"`
+}
+
+// InstancesSummary represents the number of instances in each state, along with the total number of instances
+type InstancesSummary struct {
+	States     map[string]int `json:"states"`
+	TotalCount int            `json:"total_count"`
+}
+
+// NewInstancesSummary summarises the number of instances in each state. An instance without a state is counted
+// against an empty state.
+func NewInstancesSummary(counts []InstanceStateCount) *InstancesSummary {
+	summary := &InstancesSummary{States: make(map[string]int, len(counts))}
+	for _, count := range counts {
+		summary.States[count.State] += count.Count
+		summary.TotalCount += count.Count
+	}
+	return summary
+}
+
 // Validate the event structure
 func (e *Event) Validate() error {
 	if e.Message == "" || e.MessageOffset == "" || e.Time == nil || e.Type == "" {
@@ -107,9 +329,15 @@ func ValidateImportTask(task GenericTaskDetails) error {
 		return fmt.Errorf("bad request - missing mandatory fields: %v", missingFields)
 	}
 
-	if task.State != CompletedState {
+	if !IsValidImportTaskState(task.State) {
 		return fmt.Errorf("bad request - invalid task state value: %v", task.State)
 	}
 
 	return nil
 }
+
+// IsValidImportTaskState reports whether an import task can be moved to the state, tasks are either completed or
+// have been stopped because they failed or were cancelled
+func IsValidImportTaskState(state string) bool {
+	return validImportTaskStates[state] == 1
+}
@@ -210,6 +210,19 @@ func TestValidateImportTask(t *testing.T) {
 		})
 	})
 
+	Convey("Given an import task has been stopped with a state of 'failed' or 'cancelled'", t, func() {
+		Convey("Then successfully return without any errors", func() {
+			for _, state := range []string{FailedState, CancelledState} {
+				task := GenericTaskDetails{
+					DimensionName: "geography",
+					Reason:        "hierarchy builder timed out",
+					State:         state,
+				}
+				So(ValidateImportTask(task), ShouldBeNil)
+			}
+		})
+	})
+
 	Convey("Given an import task is missing mandatory field 'dimension_name'", t, func() {
 		Convey("Then import task fails validation and returns an error", func() {
 			task := GenericTaskDetails{
@@ -253,3 +266,241 @@ func TestValidateImportTask(t *testing.T) {
 		})
 	})
 }
+
+func TestDimensionOffset(t *testing.T) {
+	t.Parallel()
+	Convey("Given the version headers are valid", t, func() {
+		Convey("When the version has no metadata headers", func() {
+			version := &Version{
+				Headers: []string{
+					"v4_0",
+					"time_codelist",
+					"time",
+					"aggregate_codelist",
+					"Aggregate",
+					"geography_codelist",
+					"geography",
+				},
+			}
+
+			Convey("Then the number of metadata columns is returned", func() {
+				dimensionOffset, err := DimensionOffset(version.Headers)
+
+				So(err, ShouldBeNil)
+				So(dimensionOffset, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the version has metadata headers", func() {
+			version := &Version{
+				Headers: []string{
+					"V4_2",
+					"data_marking",
+					"confidence_interval",
+					"time_codelist",
+					"time",
+				},
+			}
+
+			Convey("Then the number of metadata columns is returned", func() {
+				dimensionOffset, err := DimensionOffset(version.Headers)
+
+				So(err, ShouldBeNil)
+				So(dimensionOffset, ShouldEqual, 2)
+			})
+		})
+	})
+
+	Convey("Given the first value in the header does not have an underscore `_` in value", t, func() {
+		Convey("When DimensionOffset is called", func() {
+			version := &Version{
+				Headers: []string{
+					"v4",
+					"time_codelist",
+					"time",
+					"aggregate_codelist",
+					"aggregate",
+					"geography_codelist",
+					"geography",
+				},
+			}
+			Convey("Then function returns error, `index out of range`", func() {
+				dimensionOffset, err := DimensionOffset(version.Headers)
+
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldResemble, "index out of range")
+				So(dimensionOffset, ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given the first value in the header does not follow the format `v4_1`", t, func() {
+		Convey("When DimensionOffset is called", func() {
+			version := &Version{
+				Headers: []string{
+					"v4_one",
+					"time_codelist",
+					"time",
+					"aggregate_codelist",
+					"aggregate",
+					"geography_codelist",
+					"geography",
+				},
+			}
+			Convey("Then function returns error, `index out of range`", func() {
+				dimensionOffset, err := DimensionOffset(version.Headers)
+
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldResemble, "strconv.Atoi: parsing \"one\": invalid syntax")
+				So(dimensionOffset, ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestHeaderMetadataColumns(t *testing.T) {
+	t.Parallel()
+	Convey("Given the headers of a v4_2 file with data markings and confidence intervals", t, func() {
+		headers := []string{"V4_2", "Data_Marking", "confidence_interval", "time_codelist", "time", "geography_codelist", "geography"}
+
+		Convey("When HeaderMetadataColumns is called", func() {
+			hasDataMarkings, hasConfidenceIntervals, err := HeaderMetadataColumns(headers)
+
+			Convey("Then both metadata columns are found", func() {
+				So(err, ShouldBeNil)
+				So(hasDataMarkings, ShouldBeTrue)
+				So(hasConfidenceIntervals, ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given the headers of a v4_0 file with a dimension named like a metadata column", t, func() {
+		headers := []string{"v4_0", "data_marking_codelist", "data_marking", "time_codelist", "time"}
+
+		Convey("When HeaderMetadataColumns is called", func() {
+			hasDataMarkings, hasConfidenceIntervals, err := HeaderMetadataColumns(headers)
+
+			Convey("Then neither metadata column is found", func() {
+				So(err, ShouldBeNil)
+				So(hasDataMarkings, ShouldBeFalse)
+				So(hasConfidenceIntervals, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given headers with fewer columns than their metadata offset", t, func() {
+		headers := []string{"v4_3", "data_marking"}
+
+		Convey("When HeaderMetadataColumns is called", func() {
+			_, _, err := HeaderMetadataColumns(headers)
+
+			Convey("Then an index out of range error is returned", func() {
+				So(err, ShouldEqual, errs.ErrIndexOutOfRange)
+			})
+		})
+	})
+}
+
+func TestValidateHeaders(t *testing.T) {
+	t.Parallel()
+	Convey("Successfully return without any errors", t, func() {
+		Convey("when the headers have no metadata columns", func() {
+			So(ValidateHeaders([]string{"v4_0", "time_codelist", "time"}), ShouldBeNil)
+		})
+
+		Convey("when the headers have metadata columns and an uppercase first header", func() {
+			So(ValidateHeaders([]string{"V4_2", "data_marking", "confidence_interval", "time_codelist", "time"}), ShouldBeNil)
+		})
+	})
+
+	Convey("Return with errors", t, func() {
+		Convey("when the headers are empty", func() {
+			So(ValidateHeaders([]string{}), ShouldEqual, errs.ErrHeadersEmpty)
+			So(ValidateHeaders(nil), ShouldEqual, errs.ErrHeadersEmpty)
+		})
+
+		Convey("when the first header is not a v4 header", func() {
+			So(ValidateHeaders([]string{"time_codelist", "time"}), ShouldEqual, errs.ErrHeadersFirstCellInvalid)
+			So(ValidateHeaders([]string{"v5_0", "time_codelist", "time"}), ShouldEqual, errs.ErrHeadersFirstCellInvalid)
+		})
+
+		Convey("when the first header has no metadata column count", func() {
+			So(ValidateHeaders([]string{"v4", "time_codelist", "time"}), ShouldEqual, errs.ErrHeadersFirstCellInvalid)
+			So(ValidateHeaders([]string{"v4_", "time_codelist", "time"}), ShouldEqual, errs.ErrHeadersFirstCellInvalid)
+		})
+
+		Convey("when the metadata column count is not a number", func() {
+			So(ValidateHeaders([]string{"v4_one", "time_codelist", "time"}), ShouldEqual, errs.ErrHeadersFirstCellInvalid)
+		})
+
+		Convey("when the metadata column count is negative", func() {
+			So(ValidateHeaders([]string{"v4_-1", "time_codelist", "time"}), ShouldEqual, errs.ErrHeadersFirstCellInvalid)
+		})
+
+		Convey("when the metadata column count exceeds the number of headers", func() {
+			So(ValidateHeaders([]string{"v4_3", "data_marking"}), ShouldEqual, errs.ErrHeadersFirstCellInvalid)
+		})
+	})
+}
+
+func TestPreviewHeaders(t *testing.T) {
+	t.Parallel()
+	Convey("When the headers are valid the dimension offset and dimension names are returned", t, func() {
+		preview, err := PreviewHeaders([]string{"V4_1", "data_marking", "time_codelist", "Time", "geography_codelist", "geography"})
+		So(err, ShouldBeNil)
+		So(preview.DimensionOffset, ShouldEqual, 1)
+		So(preview.Dimensions, ShouldResemble, []string{"time", "geography"})
+	})
+
+	Convey("When the headers have no dimensions an empty list of dimensions is returned", t, func() {
+		preview, err := PreviewHeaders([]string{"v4_0"})
+		So(err, ShouldBeNil)
+		So(preview.Dimensions, ShouldBeEmpty)
+	})
+
+	Convey("When the first header is invalid the validation error is returned", t, func() {
+		_, err := PreviewHeaders([]string{"time_codelist", "time"})
+		So(err, ShouldEqual, errs.ErrHeadersFirstCellInvalid)
+	})
+
+	Convey("When a dimension is missing its label column an error is returned", t, func() {
+		_, err := PreviewHeaders([]string{"v4_0", "time_codelist", "time", "geography_codelist"})
+		So(err, ShouldEqual, errs.ErrHeadersDimensionColumnsInvalid)
+	})
+}
+
+func TestRenameHeadersDimension(t *testing.T) {
+	t.Parallel()
+	Convey("When a dimension is renamed only its label column is changed", t, func() {
+		headers := []string{"V4_1", "time", "time_codelist", "Time", "geography_codelist", "geography"}
+		renamed := RenameHeadersDimension(headers, "time", "year")
+		So(renamed, ShouldResemble, []string{"V4_1", "time", "time_codelist", "year", "geography_codelist", "geography"})
+		So(headers[3], ShouldEqual, "Time")
+	})
+
+	Convey("When the headers cannot be parsed they are returned unchanged", t, func() {
+		renamed := RenameHeadersDimension([]string{"time_codelist", "time"}, "time", "year")
+		So(renamed, ShouldResemble, []string{"time_codelist", "time"})
+	})
+}
+
+func TestNewInstancesSummary(t *testing.T) {
+	Convey("When the counts of instances in each state are summarised then the total is the sum of the counts", t, func() {
+		summary := NewInstancesSummary([]InstanceStateCount{
+			{State: CompletedState, Count: 1},
+			{State: CreatedState, Count: 2},
+			{State: PublishedState, Count: 3},
+		})
+
+		So(summary.States, ShouldResemble, map[string]int{CompletedState: 1, CreatedState: 2, PublishedState: 3})
+		So(summary.TotalCount, ShouldEqual, 6)
+	})
+
+	Convey("When there are no instances then an empty summary is returned", t, func() {
+		summary := NewInstancesSummary(nil)
+
+		So(summary.States, ShouldNotBeNil)
+		So(summary.States, ShouldBeEmpty)
+		So(summary.TotalCount, ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+)
+
+// JSONLimits bounds the size and nesting of JSON request bodies accepted by DecodeJSON, a limit which is not set
+// takes the value of DefaultJSONLimits
+type JSONLimits struct {
+	MaxDepth int
+	MaxSize  int64
+}
+
+// DefaultJSONLimits are the limits of the configuration when none are given
+var DefaultJSONLimits = JSONLimits{MaxDepth: 32, MaxSize: 10 << 20}
+
+// DecodeJSON decodes a single JSON document from reader into v. A body which cannot be read returns
+// ErrUnableToReadMessage, one larger than the maximum size of the limits returns ErrRequestBodyTooLarge,
+// one nested deeper than their maximum depth returns ErrJSONTooDeep and any other invalid document
+// returns ErrUnableToParseJSON
+func DecodeJSON(reader io.Reader, v interface{}, limits JSONLimits) error {
+	if limits.MaxDepth == 0 {
+		limits.MaxDepth = DefaultJSONLimits.MaxDepth
+	}
+	if limits.MaxSize == 0 {
+		limits.MaxSize = DefaultJSONLimits.MaxSize
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(reader, limits.MaxSize+1))
+	if err != nil {
+		return errs.ErrUnableToReadMessage
+	}
+
+	if int64(len(b)) > limits.MaxSize {
+		return errs.ErrRequestBodyTooLarge
+	}
+
+	if err = checkJSONDepth(b, limits.MaxDepth); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	if err = decoder.Decode(v); err != nil {
+		return errs.ErrUnableToParseJSON
+	}
+
+	// only a single document is accepted, anything following it is invalid
+	if _, err = decoder.Token(); err != io.EOF {
+		return errs.ErrUnableToParseJSON
+	}
+
+	return nil
+}
+
+// checkJSONDepth walks the tokens of a document, before any of it is decoded, so that a
+// deeply nested document is rejected without being unmarshalled
+func checkJSONDepth(b []byte, maxDepth int) error {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errs.ErrUnableToParseJSON
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+			if depth > maxDepth {
+				return errs.ErrJSONTooDeep
+			}
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	t.Parallel()
+	limits := JSONLimits{MaxDepth: 3, MaxSize: 64}
+
+	Convey("Given a valid document within the limits", t, func() {
+		var dimension Dimension
+		err := DecodeJSON(strings.NewReader(`{"name":"geography","links":{"code_list":{"id":"123"}}}`), &dimension, limits)
+
+		Convey("Then the document is decoded", func() {
+			So(err, ShouldBeNil)
+			So(dimension.Name, ShouldEqual, "geography")
+			So(dimension.Links.CodeList.ID, ShouldEqual, "123")
+		})
+	})
+
+	Convey("Given a document nested deeper than the maximum depth", t, func() {
+		var dimension Dimension
+		err := DecodeJSON(strings.NewReader(`{"name":"geography","links":{"code_list":{"id":[1]}}}`), &dimension, limits)
+
+		Convey("Then it is rejected before being decoded", func() {
+			So(err, ShouldEqual, errs.ErrJSONTooDeep)
+			So(dimension.Name, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given a deeply nested array with the default limits", t, func() {
+		var value interface{}
+		err := DecodeJSON(strings.NewReader(strings.Repeat("[", 10000)+strings.Repeat("]", 10000)), &value, DefaultJSONLimits)
+
+		Convey("Then it is rejected", func() {
+			So(err, ShouldEqual, errs.ErrJSONTooDeep)
+		})
+	})
+
+	Convey("Given a document larger than the maximum size", t, func() {
+		var dimension Dimension
+		err := DecodeJSON(strings.NewReader(`{"name":"`+strings.Repeat("a", 64)+`"}`), &dimension, limits)
+
+		Convey("Then a request body too large error is returned", func() {
+			So(err, ShouldEqual, errs.ErrRequestBodyTooLarge)
+		})
+	})
+
+	Convey("Given limits which are not set then the default limits are applied", t, func() {
+		var value interface{}
+		err := DecodeJSON(strings.NewReader(strings.Repeat("[", 33)+strings.Repeat("]", 33)), &value, JSONLimits{})
+		So(err, ShouldEqual, errs.ErrJSONTooDeep)
+
+		err = DecodeJSON(strings.NewReader(strings.Repeat("[", 32)+strings.Repeat("]", 32)), &value, JSONLimits{})
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Given invalid documents", t, func() {
+		for _, body := range []string{``, `{"name":`, `{"name":"geography"}{}`, `{"name":1}`} {
+			var dimension Dimension
+			err := DecodeJSON(strings.NewReader(body), &dimension, limits)
+
+			Convey("Then a parse error is returned for '"+body+"'", func() {
+				So(err, ShouldEqual, errs.ErrUnableToParseJSON)
+			})
+		}
+	})
+}
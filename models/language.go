@@ -0,0 +1,119 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+)
+
+// DatasetText holds the text of a dataset in a language other than the default
+type DatasetText struct {
+	Description string `bson:"description,omitempty" json:"description,omitempty"`
+	Title       string `bson:"title,omitempty"       json:"title,omitempty"`
+}
+
+// Translations holds the text of a dataset in other languages, keyed by language tag
+type Translations map[string]DatasetText
+
+// languageTagPattern matches a language tag such as cy or cy-GB, the primary language subtag being lowercase
+var languageTagPattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{1,8})*$`)
+
+// ValidateDatasetTranslations checks each translation of a dataset is keyed by a language tag and has a title or
+// a description
+func ValidateDatasetTranslations(translations Translations) error {
+	for language, text := range translations {
+		if !languageTagPattern.MatchString(language) {
+			return errs.ErrDatasetTranslationInvalid
+		}
+
+		if strings.TrimSpace(text.Title) == "" && strings.TrimSpace(text.Description) == "" {
+			return errs.ErrDatasetTranslationInvalid
+		}
+	}
+	return nil
+}
+
+// ParseAcceptLanguage returns the languages of an Accept-Language header in order of preference. Languages with a
+// quality of 0 and the * wildcard are omitted, as the default language is used when none of the others match.
+func ParseAcceptLanguage(header string) []string {
+	type weightedLanguage struct {
+		language string
+		quality  float64
+	}
+
+	var weighted []weightedLanguage
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		language := strings.TrimSpace(fields[0])
+		if language == "" || language == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+
+			q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			if err != nil {
+				q = 0
+			}
+			quality = q
+		}
+
+		if quality <= 0 {
+			continue
+		}
+
+		weighted = append(weighted, weightedLanguage{language: language, quality: quality})
+	}
+
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return weighted[i].quality > weighted[j].quality
+	})
+
+	languages := make([]string, 0, len(weighted))
+	for _, w := range weighted {
+		languages = append(languages, w.language)
+	}
+	return languages
+}
+
+// Localise replaces the title and description of the dataset with those of the first of the languages it has a
+// translation for, a language such as cy-GB matching a translation for cy when there is not one for cy-GB. The
+// language of the translation used is returned, or an empty string when the dataset is left in the default language.
+func (d *Dataset) Localise(languages []string) string {
+	if d == nil || len(d.Translations) == 0 {
+		return ""
+	}
+
+	for _, language := range languages {
+		candidates := []string{language}
+		if i := strings.Index(language, "-"); i > 0 {
+			candidates = append(candidates, language[:i])
+		}
+
+		for _, candidate := range candidates {
+			for key, text := range d.Translations {
+				if !strings.EqualFold(key, candidate) {
+					continue
+				}
+
+				if text.Title != "" {
+					d.Title = text.Title
+				}
+				if text.Description != "" {
+					d.Description = text.Description
+				}
+				return key
+			}
+		}
+	}
+
+	return ""
+}
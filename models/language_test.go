@@ -0,0 +1,85 @@
+package models
+
+import (
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidateDatasetTranslations(t *testing.T) {
+	t.Parallel()
+	Convey("When each translation is keyed by a language tag and has text then it is valid", t, func() {
+		translations := Translations{
+			"cy":    {Title: "Mynegai Prisiau Defnyddwyr"},
+			"cy-GB": {Description: "Mesur o chwyddiant"},
+		}
+		So(ValidateDatasetTranslations(translations), ShouldBeNil)
+		So(ValidateDatasetTranslations(nil), ShouldBeNil)
+	})
+
+	Convey("When a translation is not keyed by a language tag then it is invalid", t, func() {
+		So(ValidateDatasetTranslations(Translations{"Welsh": {Title: "Mynegai"}}), ShouldEqual, errs.ErrDatasetTranslationInvalid)
+		So(ValidateDatasetTranslations(Translations{"": {Title: "Mynegai"}}), ShouldEqual, errs.ErrDatasetTranslationInvalid)
+	})
+
+	Convey("When a translation has neither a title nor a description then it is invalid", t, func() {
+		So(ValidateDatasetTranslations(Translations{"cy": {Title: " "}}), ShouldEqual, errs.ErrDatasetTranslationInvalid)
+	})
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	t.Parallel()
+	Convey("When the header is empty then no languages are returned", t, func() {
+		So(ParseAcceptLanguage(""), ShouldBeEmpty)
+	})
+
+	Convey("When languages have qualities then they are ordered by preference", t, func() {
+		So(ParseAcceptLanguage("en;q=0.5, cy-GB, cy;q=0.9"), ShouldResemble, []string{"cy-GB", "cy", "en"})
+	})
+
+	Convey("When a language has a quality of 0 or is the wildcard then it is omitted", t, func() {
+		So(ParseAcceptLanguage("fr;q=0, *;q=0.1, cy"), ShouldResemble, []string{"cy"})
+	})
+}
+
+func TestDatasetLocalise(t *testing.T) {
+	t.Parallel()
+	newDataset := func() *Dataset {
+		return &Dataset{
+			Title:       "Consumer Prices Index",
+			Description: "A measure of inflation",
+			Translations: Translations{
+				"cy": {Title: "Mynegai Prisiau Defnyddwyr", Description: "Mesur o chwyddiant"},
+			},
+		}
+	}
+
+	Convey("When Welsh is requested then the Welsh text is used", t, func() {
+		dataset := newDataset()
+		So(dataset.Localise([]string{"cy"}), ShouldEqual, "cy")
+		So(dataset.Title, ShouldEqual, "Mynegai Prisiau Defnyddwyr")
+		So(dataset.Description, ShouldEqual, "Mesur o chwyddiant")
+	})
+
+	Convey("When a regional variant of Welsh is requested then the Welsh text is used", t, func() {
+		dataset := newDataset()
+		So(dataset.Localise([]string{"fr", "cy-GB"}), ShouldEqual, "cy")
+		So(dataset.Title, ShouldEqual, "Mynegai Prisiau Defnyddwyr")
+	})
+
+	Convey("When no requested language has a translation then the default text is kept", t, func() {
+		dataset := newDataset()
+		So(dataset.Localise([]string{"fr"}), ShouldBeEmpty)
+		So(dataset.Title, ShouldEqual, "Consumer Prices Index")
+		So(dataset.Description, ShouldEqual, "A measure of inflation")
+	})
+
+	Convey("When a translation only has a title then the default description is kept", t, func() {
+		dataset := newDataset()
+		dataset.Translations["cy"] = DatasetText{Title: "Mynegai Prisiau Defnyddwyr"}
+		So(dataset.Localise([]string{"cy"}), ShouldEqual, "cy")
+		So(dataset.Title, ShouldEqual, "Mynegai Prisiau Defnyddwyr")
+		So(dataset.Description, ShouldEqual, "A measure of inflation")
+	})
+}
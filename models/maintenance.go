@@ -0,0 +1,25 @@
+package models
+
+import (
+	"io"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+)
+
+// MaintenanceMode represents whether the API is in maintenance mode, rejecting write requests while reads continue
+type MaintenanceMode struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// CreateMaintenanceMode manages the creation of a maintenance mode from a reader
+func CreateMaintenanceMode(reader io.Reader, limits JSONLimits) (*MaintenanceMode, error) {
+	var mode MaintenanceMode
+	if err := DecodeJSON(reader, &mode, limits); err != nil {
+		return nil, err
+	}
+
+	if mode.Enabled == nil {
+		return nil, errs.ErrMissingParameters
+	}
+	return &mode, nil
+}
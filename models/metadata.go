@@ -44,8 +44,9 @@ type MetadataLinks struct {
 	WebsiteVersion *LinkObject `json:"website_version,omitempty"`
 }
 
-// CreateMetaDataDoc manages the creation of metadata across dataset and version docs
-func CreateMetaDataDoc(datasetDoc *Dataset, versionDoc *Version, urlBuilder *url.Builder) *Metadata {
+// CreateMetaDataDoc manages the creation of metadata across dataset and version docs, leaving only the names of the
+// contacts of the dataset when redactContacts is set
+func CreateMetaDataDoc(datasetDoc *Dataset, versionDoc *Version, urlBuilder *url.Builder, redactContacts bool) *Metadata {
 	metaDataDoc := &Metadata{
 		Alerts:            versionDoc.Alerts,
 		Contacts:          datasetDoc.Contacts,
@@ -73,6 +74,10 @@ func CreateMetaDataDoc(datasetDoc *Dataset, versionDoc *Version, urlBuilder *url
 		UsageNotes:        versionDoc.UsageNotes,
 	}
 
+	if redactContacts {
+		metaDataDoc.Contacts = contactNames(metaDataDoc.Contacts)
+	}
+
 	// Add relevant metdata links from dataset document
 	if datasetDoc.Links != nil {
 		metaDataDoc.Links.AccessRights = datasetDoc.Links.AccessRights
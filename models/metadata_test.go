@@ -7,7 +7,7 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 )
 
-var urlBuilder = url.NewBuilder("http://localhost:20000")
+var urlBuilder = url.NewBuilder("http://localhost:20000", "http://localhost:22000")
 
 func TestCreateMetadataDoc(t *testing.T) {
 	t.Parallel()
@@ -20,7 +20,7 @@ func TestCreateMetadataDoc(t *testing.T) {
 
 		inputVersionDoc := &Version{}
 
-		metaDataDoc := CreateMetaDataDoc(inputDatasetDoc, inputVersionDoc, urlBuilder)
+		metaDataDoc := CreateMetaDataDoc(inputDatasetDoc, inputVersionDoc, urlBuilder, false)
 		So(metaDataDoc.Title, ShouldEqual, "CPI")
 	})
 
@@ -31,7 +31,17 @@ func TestCreateMetadataDoc(t *testing.T) {
 
 		exectedMetadataDoc := expectedMetadataDoc()
 
-		metaDataDoc := CreateMetaDataDoc(inputDatasetDoc, inputVersionDoc, urlBuilder)
+		metaDataDoc := CreateMetaDataDoc(inputDatasetDoc, inputVersionDoc, urlBuilder, false)
 		So(metaDataDoc, ShouldResemble, &exectedMetadataDoc)
 	})
+
+	Convey("Successfully create metadata document with only the names of the contacts when they are redacted", t, func() {
+		inputDatasetDoc := &Dataset{
+			Contacts: []ContactDetails{{Name: "Jane Doe", Email: "jane@ons.gov.uk", Telephone: "01234 567890"}},
+		}
+
+		metaDataDoc := CreateMetaDataDoc(inputDatasetDoc, &Version{}, urlBuilder, true)
+		So(metaDataDoc.Contacts, ShouldResemble, []ContactDetails{{Name: "Jane Doe"}})
+		So(inputDatasetDoc.Contacts[0].Email, ShouldEqual, "jane@ons.gov.uk")
+	})
 }
@@ -1,6 +1,41 @@
 package models
 
-const wildcard = "*"
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	wildcard = "*"
+
+	// TimeDimension is the name of the dimension which accepts a range of time points in observation queries
+	TimeDimension = "time"
+
+	rangeSeparator = ".."
+)
+
+// ErrTimeRangeInvalid is returned when a time range is malformed or its start is after its end
+var ErrTimeRangeInvalid = errors.New("invalid time range, expected from..to where from and to are in the same format, e.g. 2015..2017 or Jan-15..Dec-17, and from is not after to")
+
+// ErrOptionRangeInvalid is returned when a range of options is malformed, either end is not an option of the
+// dimension or its start comes after its end
+var ErrOptionRangeInvalid = errors.New("invalid option range, expected from..to where from and to are options of the dimension and from does not come after to")
+
+// timePeriod is a layout time points can be given in, along with the length of the period one point covers
+type timePeriod struct {
+	layout string
+	years  int
+	months int
+	days   int
+}
+
+var timePeriods = []timePeriod{
+	{layout: "2006", years: 1},
+	{layout: "2006-01", months: 1},
+	{layout: "Jan-06", months: 1},
+	{layout: "2006-01-02", days: 1},
+}
 
 // ObservationsDoc represents information (observations) relevant to a version
 type ObservationsDoc struct {
@@ -14,6 +49,33 @@ type ObservationsDoc struct {
 	UsageNotes        *[]UsageNote      `json:"usage_notes,omitempty"`
 }
 
+// ObservationsMetadata represents what is needed to interpret the observations of a version: the unit they are
+// measured in, the usage notes explaining any data markings, and which metadata columns the observations have
+type ObservationsMetadata struct {
+	ConfidenceIntervals bool              `json:"confidence_intervals"`
+	DataMarkings        bool              `json:"data_markings"`
+	Links               *ObservationLinks `json:"links"`
+	UnitOfMeasure       string            `json:"unit_of_measure,omitempty"`
+	UsageNotes          *[]UsageNote      `json:"usage_notes,omitempty"`
+}
+
+// ObservationCount represents the total number of observations in a version
+type ObservationCount struct {
+	Count int `json:"count"`
+}
+
+// ObservationDimensions represents the dimensions which must be given to query the observations of a version
+type ObservationDimensions struct {
+	Items []ObservationDimension `json:"items"`
+}
+
+// ObservationDimension represents a dimension which can be queried for observations
+// and whether a wildcard (*) can be given as its value
+type ObservationDimension struct {
+	Name     string `json:"name"`
+	Wildcard bool   `json:"wildcard"`
+}
+
 // Observation represents an object containing a single
 // observation and its equivalent metadata
 type Observation struct {
@@ -42,6 +104,34 @@ type Option struct {
 	LinkObject *LinkObject `json:"option,omitempty"`
 }
 
+// CreateObservationsMetadata composes the metadata of the observations of a version from the version and dataset
+// docs, returning an error if the version headers do not describe the observation metadata columns
+func CreateObservationsMetadata(versionDoc *Version, datasetDoc *Dataset) (*ObservationsMetadata, error) {
+	hasDataMarkings, hasConfidenceIntervals, err := HeaderMetadataColumns(versionDoc.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObservationsMetadata{
+		ConfidenceIntervals: hasConfidenceIntervals,
+		DataMarkings:        hasDataMarkings,
+		Links: &ObservationLinks{
+			DatasetMetadata: &LinkObject{
+				HRef: versionDoc.Links.Version.HRef + "/metadata",
+			},
+			Self: &LinkObject{
+				HRef: versionDoc.Links.Version.HRef + "/observations/metadata",
+			},
+			Version: &LinkObject{
+				HRef: versionDoc.Links.Version.HRef,
+				ID:   versionDoc.Links.Version.ID,
+			},
+		},
+		UnitOfMeasure: datasetDoc.UnitOfMeasure,
+		UsageNotes:    versionDoc.UsageNotes,
+	}, nil
+}
+
 // CreateObservationsDoc manages the creation of metadata across dataset and version docs
 func CreateObservationsDoc(rawQuery string, versionDoc *Version, datasetDoc *Dataset, observations []Observation, queryParameters map[string]string, offset, limit int) *ObservationsDoc {
 
@@ -72,7 +162,7 @@ func CreateObservationsDoc(rawQuery string, versionDoc *Version, datasetDoc *Dat
 	for paramKey, paramValue := range queryParameters {
 		for _, dimension := range versionDoc.Dimensions {
 			var linkObjects []*LinkObject
-			if dimension.Name == paramKey && paramValue != wildcard {
+			if dimension.Name == paramKey && paramValue != wildcard && !IsTimeRange(paramKey, paramValue) && !IsOptionRange(paramKey, paramValue) {
 
 				linkObject := &LinkObject{
 					HRef: dimension.HRef + "/codes/" + paramValue,
@@ -90,3 +180,118 @@ func CreateObservationsDoc(rawQuery string, versionDoc *Version, datasetDoc *Dat
 
 	return observationsDoc
 }
+
+// ObservationRowDimensions maps each dimension of an observation row to its code and label. After the observation
+// and dimensionOffset metadata columns, each dimension is given by a code column followed by a label column, the
+// header of the label column being the name of the dimension. Dimensions which are not on the version are skipped.
+func ObservationRowDimensions(headers, row []string, dimensionOffset int, versionDimensions []Dimension) map[string]*DimensionObject {
+	dimensions := make(map[string]*DimensionObject)
+
+	for i := dimensionOffset + 2; i < len(headers) && i < len(row); i += 2 {
+		name := strings.ToLower(headers[i])
+
+		for _, versionDimension := range versionDimensions {
+			if versionDimension.Name == name {
+				dimensions[headers[i]] = &DimensionObject{
+					ID:    row[i-1],
+					HRef:  versionDimension.HRef + "/codes/" + row[i-1],
+					Label: row[i],
+				}
+				break
+			}
+		}
+	}
+
+	return dimensions
+}
+
+// TimeRange represents an inclusive range of time points of the time dimension, the end of the range
+// is the end of the period the last time point covers, e.g. 2015..2017 includes Dec-17
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// IsTimeRange returns true if the value given for a dimension uses the time range syntax, only the time
+// dimension accepts a range, other dimensions treat the value as an option
+func IsTimeRange(dimension, value string) bool {
+	return dimension == TimeDimension && strings.Contains(value, rangeSeparator)
+}
+
+// ParseTimeRange creates a time range from a value in the format from..to
+func ParseTimeRange(value string) (*TimeRange, error) {
+	bounds := strings.Split(value, rangeSeparator)
+	if len(bounds) != 2 {
+		return nil, ErrTimeRangeInvalid
+	}
+
+	for _, period := range timePeriods {
+		from, err := time.Parse(period.layout, bounds[0])
+		if err != nil {
+			continue
+		}
+
+		to, err := time.Parse(period.layout, bounds[1])
+		if err != nil || to.Before(from) {
+			return nil, ErrTimeRangeInvalid
+		}
+
+		return &TimeRange{From: from, To: to.AddDate(period.years, period.months, period.days)}, nil
+	}
+
+	return nil, ErrTimeRangeInvalid
+}
+
+// Contains returns true if the time point an option represents starts within the range, options
+// which are not in a recognised time format are never within a range
+func (r *TimeRange) Contains(option string) bool {
+	for _, period := range timePeriods {
+		if t, err := time.Parse(period.layout, option); err == nil {
+			return !t.Before(r.From) && t.Before(r.To)
+		}
+	}
+	return false
+}
+
+// OptionRange represents an inclusive range of the options of an ordinal dimension, such as age bands, which are
+// ordered by the order given to them
+type OptionRange struct {
+	From string
+	To   string
+}
+
+// IsOptionRange returns true if the value given for a dimension other than time uses the range syntax, only
+// ordinal dimensions accept a range of options
+func IsOptionRange(dimension, value string) bool {
+	return dimension != TimeDimension && strings.Contains(value, rangeSeparator)
+}
+
+// ParseOptionRange creates a range of options from a value in the format from..to, a value which could be split into
+// more than one range, such as 1...2, is rejected
+func ParseOptionRange(value string) (*OptionRange, error) {
+	bounds := strings.Split(value, rangeSeparator)
+	if len(bounds) != 2 || bounds[0] == "" || bounds[1] == "" || strings.Contains(value, rangeSeparator+".") {
+		return nil, ErrOptionRangeInvalid
+	}
+
+	return &OptionRange{From: bounds[0], To: bounds[1]}, nil
+}
+
+// Select returns the options from the start to the end of the range, in the order they are given
+func (r *OptionRange) Select(options []string) ([]string, error) {
+	from, to := -1, -1
+	for i, option := range options {
+		if option == r.From && from == -1 {
+			from = i
+		}
+		if option == r.To {
+			to = i
+		}
+	}
+
+	if from == -1 || to == -1 || from > to {
+		return nil, ErrOptionRangeInvalid
+	}
+
+	return options[from : to+1], nil
+}
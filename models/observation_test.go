@@ -153,3 +153,165 @@ func setUpTestObservations() []Observation {
 
 	return observations
 }
+
+func TestCreateObservationsMetadata(t *testing.T) {
+	t.Parallel()
+	versionDoc := func(headers []string) *Version {
+		return &Version{
+			Headers:    headers,
+			Links:      &VersionLinks{Version: &LinkObject{ID: "1", HRef: "http://localhost:22000/datasets/123/editions/2017/versions/1"}},
+			UsageNotes: &[]UsageNote{{Title: "Confidence intervals", Note: "95% confidence intervals are given"}},
+		}
+	}
+
+	Convey("Given a version with confidence intervals", t, func() {
+		metadata, err := CreateObservationsMetadata(versionDoc([]string{"v4_1", "confidence_interval", "time_codelist", "time"}), &Dataset{UnitOfMeasure: "Percent"})
+
+		Convey("Then the metadata is composed from the version and dataset", func() {
+			So(err, ShouldBeNil)
+			So(metadata.ConfidenceIntervals, ShouldBeTrue)
+			So(metadata.DataMarkings, ShouldBeFalse)
+			So(metadata.UnitOfMeasure, ShouldEqual, "Percent")
+			So(metadata.UsageNotes, ShouldResemble, &[]UsageNote{{Title: "Confidence intervals", Note: "95% confidence intervals are given"}})
+			So(metadata.Links.DatasetMetadata.HRef, ShouldEqual, "http://localhost:22000/datasets/123/editions/2017/versions/1/metadata")
+			So(metadata.Links.Version.ID, ShouldEqual, "1")
+		})
+	})
+
+	Convey("Given a version with malformed headers then an error is returned", t, func() {
+		metadata, err := CreateObservationsMetadata(versionDoc([]string{"v4"}), &Dataset{})
+		So(err, ShouldNotBeNil)
+		So(metadata, ShouldBeNil)
+	})
+}
+
+func TestObservationRowDimensions(t *testing.T) {
+	Convey("Given a version with two dimensions and an observation row with a metadata column", t, func() {
+		headers := []string{"v4_1", "data_marking", "time_codelist", "Time", "geography_codelist", "geography"}
+		row := []string{"146.3", "p", "Month", "Aug-16", "K02000001", "United Kingdom"}
+		versionDimensions := []Dimension{
+			{Name: "time", HRef: "http://localhost:22400/code-lists/time"},
+			{Name: "geography", HRef: "http://localhost:22400/code-lists/uk-only"},
+		}
+
+		Convey("When the dimensions of the row are resolved", func() {
+			dimensions := ObservationRowDimensions(headers, row, 1, versionDimensions)
+
+			Convey("Then each dimension is mapped to its code and label", func() {
+				So(dimensions, ShouldResemble, map[string]*DimensionObject{
+					"Time": {
+						ID:    "Month",
+						HRef:  "http://localhost:22400/code-lists/time/codes/Month",
+						Label: "Aug-16",
+					},
+					"geography": {
+						ID:    "K02000001",
+						HRef:  "http://localhost:22400/code-lists/uk-only/codes/K02000001",
+						Label: "United Kingdom",
+					},
+				})
+			})
+		})
+
+		Convey("When a dimension in the row is not on the version", func() {
+			dimensions := ObservationRowDimensions(headers, row, 1, versionDimensions[1:])
+
+			Convey("Then only the dimensions of the version are resolved", func() {
+				So(dimensions, ShouldHaveLength, 1)
+				So(dimensions["geography"].Label, ShouldEqual, "United Kingdom")
+			})
+		})
+	})
+}
+
+func TestParseTimeRange(t *testing.T) {
+	t.Parallel()
+	Convey("Given a range of years", t, func() {
+		timeRange, err := ParseTimeRange("2015..2017")
+		So(err, ShouldBeNil)
+
+		Convey("Then every time point starting within the years is contained", func() {
+			So(timeRange.Contains("2015"), ShouldBeTrue)
+			So(timeRange.Contains("2017"), ShouldBeTrue)
+			So(timeRange.Contains("Jan-15"), ShouldBeTrue)
+			So(timeRange.Contains("Dec-17"), ShouldBeTrue)
+			So(timeRange.Contains("2017-12-31"), ShouldBeTrue)
+		})
+
+		Convey("Then time points outside of the years are not contained", func() {
+			So(timeRange.Contains("2014"), ShouldBeFalse)
+			So(timeRange.Contains("Dec-14"), ShouldBeFalse)
+			So(timeRange.Contains("2018"), ShouldBeFalse)
+			So(timeRange.Contains("Jan-18"), ShouldBeFalse)
+		})
+
+		Convey("Then options which are not time points are not contained", func() {
+			So(timeRange.Contains("2015/16"), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given a range of months", t, func() {
+		timeRange, err := ParseTimeRange("Mar-16..May-16")
+		So(err, ShouldBeNil)
+		So(timeRange.Contains("Feb-16"), ShouldBeFalse)
+		So(timeRange.Contains("Mar-16"), ShouldBeTrue)
+		So(timeRange.Contains("May-16"), ShouldBeTrue)
+		So(timeRange.Contains("Jun-16"), ShouldBeFalse)
+	})
+
+	Convey("Given a malformed range then an error is returned", t, func() {
+		for _, value := range []string{"2015..", "..2017", "2015..2016..2017", "2017..2015", "2015..Dec-17", "last..year"} {
+			_, err := ParseTimeRange(value)
+			So(err, ShouldEqual, ErrTimeRangeInvalid)
+		}
+	})
+}
+
+func TestIsTimeRange(t *testing.T) {
+	t.Parallel()
+	Convey("Only a range on the time dimension is a time range", t, func() {
+		So(IsTimeRange("time", "2015..2017"), ShouldBeTrue)
+		So(IsTimeRange("time", "2015"), ShouldBeFalse)
+		So(IsTimeRange("geography", "2015..2017"), ShouldBeFalse)
+	})
+}
+
+func TestOptionRange(t *testing.T) {
+	t.Parallel()
+	Convey("Given a range of age bands", t, func() {
+		optionRange, err := ParseOptionRange("20-24..35-39")
+		So(err, ShouldBeNil)
+		So(optionRange, ShouldResemble, &OptionRange{From: "20-24", To: "35-39"})
+
+		options := []string{"15-19", "20-24", "25-29", "30-34", "35-39", "40-44"}
+
+		Convey("Then the options from the start to the end of the range are selected in order", func() {
+			selected, err := optionRange.Select(options)
+			So(err, ShouldBeNil)
+			So(selected, ShouldResemble, []string{"20-24", "25-29", "30-34", "35-39"})
+		})
+
+		Convey("Then an error is returned when either end is not an option", func() {
+			_, err := optionRange.Select([]string{"20-24", "25-29"})
+			So(err, ShouldEqual, ErrOptionRangeInvalid)
+		})
+
+		Convey("Then an error is returned when the start comes after the end", func() {
+			_, err := (&OptionRange{From: "35-39", To: "20-24"}).Select(options)
+			So(err, ShouldEqual, ErrOptionRangeInvalid)
+		})
+	})
+
+	Convey("Given a malformed range then an error is returned", t, func() {
+		for _, value := range []string{"20-24..", "..35-39", "15-19..20-24..25-29", "1...2"} {
+			_, err := ParseOptionRange(value)
+			So(err, ShouldEqual, ErrOptionRangeInvalid)
+		}
+	})
+
+	Convey("Only a range on a dimension other than time is an option range", t, func() {
+		So(IsOptionRange("age", "20-24..35-39"), ShouldBeTrue)
+		So(IsOptionRange("age", "20-24"), ShouldBeFalse)
+		So(IsOptionRange("time", "2015..2017"), ShouldBeFalse)
+	})
+}
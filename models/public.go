@@ -0,0 +1,47 @@
+package models
+
+// Resources returned to public callers, those not authorised to see unpublished resources, are sanitised to remove
+// the fields only used by the publishing process. A field is stripped here rather than the resource having a
+// separate public type, so a new field is returned to every caller unless it is added below.
+
+// Sanitise removes the internal-only fields of a dataset before it is returned to a public caller
+func (d *Dataset) Sanitise() {
+	if d == nil {
+		return
+	}
+
+	d.CollectionID = ""
+}
+
+// RedactContacts removes the email and telephone of each contact of a dataset, leaving only their names, for when
+// contact details are not to be published
+func (d *Dataset) RedactContacts() {
+	if d == nil {
+		return
+	}
+
+	d.Contacts = contactNames(d.Contacts)
+}
+
+// contactNames returns the names of contacts alone. A new slice is made so the contacts of any other resource
+// sharing the slice are left as they are.
+func contactNames(contacts []ContactDetails) []ContactDetails {
+	if contacts == nil {
+		return nil
+	}
+
+	redacted := make([]ContactDetails, len(contacts))
+	for i, contact := range contacts {
+		redacted[i] = ContactDetails{Name: contact.Name}
+	}
+	return redacted
+}
+
+// Sanitise removes the internal-only fields of a version before it is returned to a public caller
+func (v *Version) Sanitise() {
+	if v == nil {
+		return
+	}
+
+	v.CollectionID = ""
+}
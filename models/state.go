@@ -17,12 +17,33 @@ const (
 	DetachedState         = "detached"
 )
 
+// A list of states an import task can be moved to other than completed, the reason is recorded against the task
+const (
+	FailedState    = "failed"
+	CancelledState = "cancelled"
+)
+
+var validImportTaskStates = map[string]int{
+	CompletedState: 1,
+	FailedState:    1,
+	CancelledState: 1,
+}
+
 var validVersionStates = map[string]int{
 	EditionConfirmedState: 1,
 	AssociatedState:       1,
 	PublishedState:        1,
 }
 
+// DefaultPurgeStates are the states of instances abandoned during an import, which are purged when no states are
+// given. Completed instances are only purged when asked for, as they may be waiting to be confirmed as an edition.
+var DefaultPurgeStates = []string{CreatedState, SubmittedState, FailedState}
+
+// InUseStates returns the states of instances which have been confirmed as a version of a dataset, so are never purged
+func InUseStates() []string {
+	return []string{EditionConfirmedState, AssociatedState, PublishedState}
+}
+
 var validStates = map[string]int{
 	CreatedState:          1,
 	SubmittedState:        1,
@@ -30,6 +51,56 @@ var validStates = map[string]int{
 	EditionConfirmedState: 1,
 	AssociatedState:       1,
 	PublishedState:        1,
+	FailedState:           1,
+}
+
+// instanceStateTransitions maps each instance state to the state an instance must be in to move to it, along
+// with the error returned when it is not
+var instanceStateTransitions = map[string]struct {
+	from string
+	err  error
+}{
+	SubmittedState:        {from: CreatedState, err: errs.ErrExpectedResourceStateOfCreated},
+	CompletedState:        {from: SubmittedState, err: errs.ErrExpectedResourceStateOfSubmitted},
+	EditionConfirmedState: {from: CompletedState, err: errs.ErrExpectedResourceStateOfCompleted},
+	AssociatedState:       {from: EditionConfirmedState, err: errs.ErrExpectedResourceStateOfEditionConfirmed},
+	PublishedState:        {from: AssociatedState, err: errs.ErrExpectedResourceStateOfAssociated},
+}
+
+// ValidateStateTransition checks an instance can move from its current state to the next state. Instances
+// progress through the import in a fixed order:
+//
+//	created -> submitted -> completed -> edition-confirmed -> associated -> published
+//
+// An instance is submitted once its import job has started inserting observations, and must have been submitted
+// before it can be marked as completed. Submitted is only ever an instance state, versions cannot be submitted.
+// Any instance which has not been published can be moved to failed, which is terminal: a failed instance can
+// only be moved out of it by resetting the instance. Leaving the state unchanged is always valid.
+func ValidateStateTransition(current, next string) error {
+	if next == "" || next == current {
+		return nil
+	}
+
+	if current == FailedState {
+		return errs.ErrInstanceFailed
+	}
+
+	if next == FailedState {
+		if current == PublishedState {
+			return errs.ErrResourcePublished
+		}
+		return nil
+	}
+
+	transition, ok := instanceStateTransitions[next]
+	if !ok {
+		return errs.ErrInstanceStateInvalid
+	}
+
+	if current != transition.from {
+		return transition.err
+	}
+	return nil
 }
 
 // ValidateStateFilter checks the list of filter states from a whitelist
@@ -50,6 +121,25 @@ func ValidateStateFilter(filterList []string) error {
 	return nil
 }
 
+// ValidatePurgeStates checks none of the states instances are to be purged in are states of instances in use
+func ValidatePurgeStates(states []string) error {
+	for _, state := range states {
+		if _, ok := validVersionStates[state]; ok {
+			return errs.ErrPurgeInstancesInUse
+		}
+	}
+	return nil
+}
+
+// ValidateVersionStateFilter checks a version filter state against the states a version can be in
+func ValidateVersionStateFilter(state string) error {
+	if _, ok := validVersionStates[state]; !ok {
+		return fmt.Errorf("bad request - invalid filter state values: %v", []string{state})
+	}
+
+	return nil
+}
+
 // ValidateInstanceState checks the list of instance states from a whitelist
 func ValidateInstanceState(state string) error {
 	var invalidInstantStateValues []string
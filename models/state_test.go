@@ -96,3 +96,99 @@ func TestCheckState(t *testing.T) {
 		})
 	})
 }
+
+func TestValidateStateTransition(t *testing.T) {
+	Convey("Successfully return without any errors", t, func() {
+		Convey("when an instance moves from created to submitted", func() {
+			So(ValidateStateTransition(CreatedState, SubmittedState), ShouldBeNil)
+		})
+
+		Convey("when an instance moves from submitted to completed", func() {
+			So(ValidateStateTransition(SubmittedState, CompletedState), ShouldBeNil)
+		})
+
+		Convey("when an instance moves through the remaining states in order", func() {
+			So(ValidateStateTransition(CompletedState, EditionConfirmedState), ShouldBeNil)
+			So(ValidateStateTransition(EditionConfirmedState, AssociatedState), ShouldBeNil)
+			So(ValidateStateTransition(AssociatedState, PublishedState), ShouldBeNil)
+		})
+
+		Convey("when the state is unchanged or not provided", func() {
+			So(ValidateStateTransition(SubmittedState, SubmittedState), ShouldBeNil)
+			So(ValidateStateTransition(SubmittedState, ""), ShouldBeNil)
+		})
+
+		Convey("when an unpublished instance moves to failed", func() {
+			So(ValidateStateTransition(CreatedState, FailedState), ShouldBeNil)
+			So(ValidateStateTransition(EditionConfirmedState, FailedState), ShouldBeNil)
+		})
+	})
+
+	Convey("Return with errors", t, func() {
+		Convey("when an instance skips the submitted state", func() {
+			So(ValidateStateTransition(CreatedState, CompletedState), ShouldEqual, errs.ErrExpectedResourceStateOfSubmitted)
+		})
+
+		Convey("when a submitted instance skips the completed state", func() {
+			So(ValidateStateTransition(SubmittedState, EditionConfirmedState), ShouldEqual, errs.ErrExpectedResourceStateOfCompleted)
+		})
+
+		Convey("when a completed instance moves back to submitted", func() {
+			So(ValidateStateTransition(CompletedState, SubmittedState), ShouldEqual, errs.ErrExpectedResourceStateOfCreated)
+		})
+
+		Convey("when the next state is not an instance state", func() {
+			So(ValidateStateTransition(AssociatedState, DetachedState), ShouldEqual, errs.ErrInstanceStateInvalid)
+		})
+
+		Convey("when a published instance moves to failed", func() {
+			So(ValidateStateTransition(PublishedState, FailedState), ShouldEqual, errs.ErrResourcePublished)
+		})
+
+		Convey("when a failed instance moves to another state", func() {
+			So(ValidateStateTransition(FailedState, CreatedState), ShouldEqual, errs.ErrInstanceFailed)
+			So(ValidateStateTransition(FailedState, CompletedState), ShouldEqual, errs.ErrInstanceFailed)
+		})
+	})
+}
+
+func TestValidatePurgeStates(t *testing.T) {
+	t.Parallel()
+	Convey("Successfully return without any errors when the instances are not in use", t, func() {
+		So(ValidatePurgeStates(nil), ShouldBeNil)
+		So(ValidatePurgeStates([]string{CreatedState, SubmittedState, CompletedState, FailedState}), ShouldBeNil)
+	})
+
+	Convey("Return with errors when any of the states is a state of instances in use", t, func() {
+		for _, state := range []string{EditionConfirmedState, AssociatedState, PublishedState} {
+			Convey("when the state is `"+state+"`", func() {
+				So(ValidatePurgeStates([]string{CreatedState, state}), ShouldEqual, errs.ErrPurgeInstancesInUse)
+			})
+		}
+	})
+}
+
+func TestValidateVersionStateFilter(t *testing.T) {
+	t.Parallel()
+	Convey("Successfully return without any errors", t, func() {
+		for _, state := range []string{EditionConfirmedState, AssociatedState, PublishedState} {
+			Convey("when the filter state is `"+state+"`", func() {
+				So(ValidateVersionStateFilter(state), ShouldBeNil)
+			})
+		}
+	})
+
+	Convey("Return with errors", t, func() {
+		Convey("when the filter state is not a state", func() {
+			err := ValidateVersionStateFilter("foo")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "bad request - invalid filter state values: [foo]")
+		})
+
+		Convey("when the filter state is an instance state that a version cannot be in", func() {
+			err := ValidateVersionStateFilter(CompletedState)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "bad request - invalid filter state values: [completed]")
+		})
+	})
+}
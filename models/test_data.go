@@ -74,6 +74,8 @@ func createTestDataset() *Dataset {
 		},
 		ReleaseFrequency: "yearly",
 		State:            AssociatedState,
+		Subtopics:        []string{"ethnicity", "national identity"},
+		Survey:           "census",
 		Theme:            "population",
 		Title:            "CensusEthnicity",
 		UnitOfMeasure:    "Pounds Sterling",
@@ -108,6 +110,8 @@ func expectedDataset() Dataset {
 		RelatedDatasets:   []GeneralDetails{relatedDatasets},
 		ReleaseFrequency:  "yearly",
 		State:             AssociatedState,
+		Subtopics:         []string{"ethnicity", "national identity"},
+		Survey:            "census",
 		Theme:             "population",
 		Title:             "CensusEthnicity",
 		UnitOfMeasure:     "Pounds Sterling",
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"io"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+)
+
+// List of outcomes reported for each version in a request to move the versions of an edition to another state
+const (
+	VersionTransitioned      = "transitioned"
+	VersionTransitionSkipped = "skipped"
+	VersionTransitionFailed  = "failed"
+	VersionTransitionAborted = "aborted"
+)
+
+// VersionsTransition is the state to move every unpublished version of an edition to, along with the collection
+// the versions are associated with when moving them to the associated state
+type VersionsTransition struct {
+	State        string `json:"state"`
+	CollectionID string `json:"collection_id,omitempty"`
+}
+
+// VersionTransitionResult represents the outcome of moving a single version of an edition to another state
+type VersionTransitionResult struct {
+	Version int    `json:"version"`
+	From    string `json:"from"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// VersionTransitionResults represents the outcome of moving the versions of an edition to another state
+type VersionTransitionResults struct {
+	State string                    `json:"state"`
+	Count int                       `json:"count"`
+	Items []VersionTransitionResult `json:"items"`
+}
+
+// CreateVersionsTransition manages the creation of a transition of the versions of an edition from a reader
+func CreateVersionsTransition(reader io.Reader, limits JSONLimits) (*VersionsTransition, error) {
+	var transition VersionsTransition
+	if err := DecodeJSON(reader, &transition, limits); err != nil {
+		return nil, err
+	}
+
+	if transition.State == "" {
+		return nil, errs.ErrVersionMissingState
+	}
+
+	if _, ok := validVersionStates[transition.State]; !ok {
+		return nil, ErrVersionStateInvalid
+	}
+	return &transition, nil
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCreateVersionsTransition(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a transition to the associated state", t, func() {
+		r := bytes.NewBufferString(`{"state":"associated","collection_id":"collection-1"}`)
+
+		Convey("Then the transition is returned without error", func() {
+			transition, err := CreateVersionsTransition(r, DefaultJSONLimits)
+			So(err, ShouldBeNil)
+			So(transition, ShouldResemble, &VersionsTransition{State: AssociatedState, CollectionID: "collection-1"})
+		})
+	})
+
+	Convey("Given a transition without a state", t, func() {
+		r := bytes.NewBufferString(`{"collection_id":"collection-1"}`)
+
+		Convey("Then an error is returned", func() {
+			transition, err := CreateVersionsTransition(r, DefaultJSONLimits)
+			So(err, ShouldEqual, errs.ErrVersionMissingState)
+			So(transition, ShouldBeNil)
+		})
+	})
+
+	Convey("Given a transition to a state versions cannot be in", t, func() {
+		r := bytes.NewBufferString(`{"state":"completed"}`)
+
+		Convey("Then an error is returned", func() {
+			transition, err := CreateVersionsTransition(r, DefaultJSONLimits)
+			So(err, ShouldEqual, ErrVersionStateInvalid)
+			So(transition, ShouldBeNil)
+		})
+	})
+}
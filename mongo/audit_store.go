@@ -0,0 +1,40 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/globalsign/mgo/bson"
+)
+
+const auditEventsCollection = "audit_events"
+
+// auditEventsRetention is how long audit events are kept before mongo removes them, the audit events topic remains
+// the long term record
+const auditEventsRetention = 90 * 24 * time.Hour
+
+// AddAuditEvent stores an audit event recorded against an instance
+func (m *Mongo) AddAuditEvent(event *models.AuditEvent) error {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	return s.DB(m.Database).C(auditEventsCollection).Insert(event)
+}
+
+// GetAuditEvents returns the audit events recorded against an instance in the order they were created
+func (m *Mongo) GetAuditEvents(instanceID string) ([]models.AuditEvent, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	events := []models.AuditEvent{}
+	err := s.DB(m.Database).C(auditEventsCollection).Find(bson.M{"instance_id": instanceID}).Select(bson.M{"_id": 0}).Sort(auditEventsOrder...).All(&events)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// auditEventsOrder sorts audit events chronologically, falling back to insertion order for events created at the
+// same time
+var auditEventsOrder = []string{"created", "_id"}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,15 +24,30 @@ type Mongo struct {
 	DatasetURL     string
 	Session        *mgo.Session
 	URI            string
-	lastPingTime   time.Time
-	lastPingResult error
+	SecondaryReads bool
+	// WriteMaxAttempts and WriteRetryBackoff control how writes failing with a transient error are retried
+	WriteMaxAttempts  int
+	WriteRetryBackoff time.Duration
+	lastPingTime      time.Time
+	lastPingResult    error
 }
 
 const (
-	editionsCollection = "editions"
+	datasetsCollection         = "datasets"
+	editionsCollection         = "editions"
+	versionSequencesCollection = "version_sequences"
+
+	// releaseDateLayout is the layout release date bounds are formatted with when filtering versions
+	releaseDateLayout = "2006-01-02T15:04:05.000Z"
 )
 
-// Init creates a new mgo.Session with a strong consistency and a write mode of "majortiy".
+// editionCollation compares strings ignoring case. It is used by every query which selects editions, or versions
+// by edition, so an edition is found whatever the case it is requested in while the name it was created with is
+// kept, as that is the name used in links. The edition and version indexes are created with the same collation.
+var editionCollation = &mgo.Collation{Locale: "en", Strength: 2}
+
+// Init creates a new mgo.Session with a strong consistency and a write mode of "majortiy",
+// and ensures the indexes required by the dataset API queries exist.
 func (m *Mongo) Init() (session *mgo.Session, err error) {
 	if session != nil {
 		return nil, errors.New("session already exists")
@@ -43,15 +59,35 @@ func (m *Mongo) Init() (session *mgo.Session, err error) {
 
 	session.EnsureSafe(&mgo.Safe{WMode: "majority"})
 	session.SetMode(mgo.Strong, true)
+
+	m.ensureIndexes(session)
+
 	return session, nil
 }
 
-// GetDatasets retrieves all dataset documents
-func (m *Mongo) GetDatasets() ([]models.DatasetUpdate, error) {
+// readMode returns the consistency mode used by read-only queries. Secondary reads are preferred when enabled,
+// otherwise reads keep the strong consistency set on the session by Init.
+func (m *Mongo) readMode() mgo.Mode {
+	if m.SecondaryReads {
+		return mgo.SecondaryPreferred
+	}
+	return mgo.Strong
+}
+
+// readSession copies the session for a read-only query, setting the read mode on the copy so that writes
+// made through the original session are unaffected.
+func (m *Mongo) readSession() *mgo.Session {
 	s := m.Session.Copy()
+	s.SetMode(m.readMode(), true)
+	return s
+}
+
+// GetDatasets retrieves all dataset documents
+func (m *Mongo) GetDatasets(datasetType string) ([]models.DatasetUpdate, error) {
+	s := m.readSession()
 	defer s.Close()
 
-	iter := s.DB(m.Database).C("datasets").Find(nil).Iter()
+	iter := s.DB(m.Database).C(datasetsCollection).Find(buildDatasetsQuery(datasetType)).Iter()
 	defer func() {
 		err := iter.Close()
 		if err != nil {
@@ -70,12 +106,95 @@ func (m *Mongo) GetDatasets() ([]models.DatasetUpdate, error) {
 	return results, nil
 }
 
+// buildDatasetsQuery selects the datasets of the given type, or every dataset when no type is given. Datasets created
+// before the type field was introduced have no type and are treated as filterable, the type given to new datasets
+// by default.
+func buildDatasetsQuery(datasetType string) bson.M {
+	if datasetType == "" {
+		return nil
+	}
+
+	if datasetType == models.FilterableDatasetType {
+		return bson.M{"next.type": bson.M{"$in": []interface{}{datasetType, nil}}}
+	}
+
+	return bson.M{"next.type": datasetType}
+}
+
+// GetDistinctThemes returns each theme given to a published dataset once, datasets without a theme are skipped
+func (m *Mongo) GetDistinctThemes() ([]string, error) {
+	s := m.readSession()
+	defer s.Close()
+
+	themes := []string{}
+	if err := s.DB(m.Database).C(datasetsCollection).Find(buildDistinctThemesQuery()).Distinct("current.theme", &themes); err != nil {
+		return nil, err
+	}
+
+	return themes, nil
+}
+
+func buildDistinctThemesQuery() bson.M {
+	return bson.M{
+		"current.state": models.PublishedState,
+		"current.theme": bson.M{"$exists": true, "$ne": ""},
+	}
+}
+
+// GetDatasetsModifiedSince retrieves a page of the datasets of the given type, or of every type when none is given,
+// whose current or next document was updated at or after the given time, ordered by id so that consecutive pages are
+// stable. When only published datasets are wanted just the current document is considered, so the total count
+// matches the datasets a public caller can be shown.
+func (m *Mongo) GetDatasetsModifiedSince(t time.Time, datasetType string, publishedOnly bool, offset, limit int) (*models.DatasetUpdatePage, error) {
+	s := m.readSession()
+	defer s.Close()
+
+	query := s.DB(m.Database).C(datasetsCollection).Find(buildDatasetsModifiedSinceQuery(t, datasetType, publishedOnly))
+
+	totalCount, err := query.Count()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.DatasetUpdate{}
+	if err = query.Sort("_id").Skip(offset).Limit(limit).All(&results); err != nil {
+		return nil, err
+	}
+
+	return &models.DatasetUpdatePage{
+		Items:      results,
+		Count:      len(results),
+		Offset:     offset,
+		Limit:      limit,
+		TotalCount: totalCount,
+	}, nil
+}
+
+func buildDatasetsModifiedSinceQuery(t time.Time, datasetType string, publishedOnly bool) bson.M {
+	var query bson.M
+	if publishedOnly {
+		query = bson.M{"current.last_updated": bson.M{"$gte": t}}
+	} else {
+		query = bson.M{
+			"$or": []bson.M{
+				{"current.last_updated": bson.M{"$gte": t}},
+				{"next.last_updated": bson.M{"$gte": t}},
+			},
+		}
+	}
+
+	for field, value := range buildDatasetsQuery(datasetType) {
+		query[field] = value
+	}
+	return query
+}
+
 // GetDataset retrieves a dataset document
 func (m *Mongo) GetDataset(id string) (*models.DatasetUpdate, error) {
-	s := m.Session.Copy()
+	s := m.readSession()
 	defer s.Close()
 	var dataset models.DatasetUpdate
-	err := s.DB(m.Database).C("datasets").Find(bson.M{"_id": id}).One(&dataset)
+	err := s.DB(m.Database).C(datasetsCollection).Find(bson.M{"_id": id}).One(&dataset)
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			return nil, errs.ErrDatasetNotFound
@@ -88,12 +207,12 @@ func (m *Mongo) GetDataset(id string) (*models.DatasetUpdate, error) {
 
 // GetEditions retrieves all edition documents for a dataset
 func (m *Mongo) GetEditions(id, state string) (*models.EditionUpdateResults, error) {
-	s := m.Session.Copy()
+	s := m.readSession()
 	defer s.Close()
 
 	selector := buildEditionsQuery(id, state)
 
-	iter := s.DB(m.Database).C(editionsCollection).Find(selector).Iter()
+	iter := s.DB(m.Database).C(editionsCollection).Find(selector).Collation(editionCollation).Iter()
 	defer func() {
 		err := iter.Close()
 		if err != nil {
@@ -115,6 +234,15 @@ func (m *Mongo) GetEditions(id, state string) (*models.EditionUpdateResults, err
 	return &models.EditionUpdateResults{Items: results}, nil
 }
 
+// CountEditions counts the editions of a dataset, whatever their state, without reading the edition documents. A
+// dataset without any editions has a count of 0.
+func (m *Mongo) CountEditions(id string) (int, error) {
+	s := m.readSession()
+	defer s.Close()
+
+	return s.DB(m.Database).C(editionsCollection).Find(buildEditionsQuery(id, "")).Count()
+}
+
 func buildEditionsQuery(id, state string) bson.M {
 	var selector bson.M
 	if state != "" {
@@ -131,15 +259,68 @@ func buildEditionsQuery(id, state string) bson.M {
 	return selector
 }
 
+// GetEditionsForDatasets retrieves the editions of many datasets in a single query, grouped by the id of the dataset
+// they belong to, so the editions of a list of datasets do not need a query per dataset. Datasets without editions
+// are omitted from the results rather than returned as an error.
+func (m *Mongo) GetEditionsForDatasets(ids []string, state string) (map[string][]*models.EditionUpdate, error) {
+	if len(ids) == 0 {
+		return map[string][]*models.EditionUpdate{}, nil
+	}
+
+	s := m.readSession()
+	defer s.Close()
+
+	var results []*models.EditionUpdate
+	err := s.DB(m.Database).C(editionsCollection).Find(buildEditionsForDatasetsQuery(ids, state)).Collation(editionCollation).All(&results)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupEditionsByDataset(results, state), nil
+}
+
+func buildEditionsForDatasetsQuery(ids []string, state string) bson.M {
+	if state != "" {
+		return bson.M{
+			"current.links.dataset.id": bson.M{"$in": ids},
+			"current.state":            state,
+		}
+	}
+
+	return bson.M{
+		"next.links.dataset.id": bson.M{"$in": ids},
+	}
+}
+
+// groupEditionsByDataset groups editions by the id of their dataset, taken from the current edition when a state was
+// queried for and from the next edition otherwise, as in buildEditionsForDatasetsQuery
+func groupEditionsByDataset(editions []*models.EditionUpdate, state string) map[string][]*models.EditionUpdate {
+	grouped := make(map[string][]*models.EditionUpdate)
+	for _, edition := range editions {
+		doc := edition.Next
+		if state != "" {
+			doc = edition.Current
+		}
+
+		if doc == nil || doc.Links == nil || doc.Links.Dataset == nil {
+			continue
+		}
+
+		grouped[doc.Links.Dataset.ID] = append(grouped[doc.Links.Dataset.ID], edition)
+	}
+
+	return grouped
+}
+
 // GetEdition retrieves an edition document for a dataset
 func (m *Mongo) GetEdition(id, editionID, state string) (*models.EditionUpdate, error) {
-	s := m.Session.Copy()
+	s := m.readSession()
 	defer s.Close()
 
 	selector := buildEditionQuery(id, editionID, state)
 
 	var edition models.EditionUpdate
-	err := s.DB(m.Database).C(editionsCollection).Find(selector).One(&edition)
+	err := s.DB(m.Database).C(editionsCollection).Find(selector).Collation(editionCollation).One(&edition)
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			return nil, errs.ErrEditionNotFound
@@ -180,7 +361,7 @@ func (m *Mongo) GetNextVersion(datasetID, edition string) (int, error) {
 	}
 
 	// Results are sorted in reverse order to get latest version
-	err := s.DB(m.Database).C("instances").Find(selector).Sort("-version").One(&version)
+	err := s.DB(m.Database).C("instances").Find(selector).Collation(editionCollation).Sort("-version").One(&version)
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			return 1, nil
@@ -193,14 +374,84 @@ func (m *Mongo) GetNextVersion(datasetID, edition string) (int, error) {
 	return nextVersion, nil
 }
 
-// GetVersions retrieves all version documents for a dataset edition
-func (m *Mongo) GetVersions(id, editionID, state string) (*models.VersionResults, error) {
+// versionSequence holds the last version number claimed for an edition of a dataset
+type versionSequence struct {
+	ID        string `bson:"_id"`
+	DatasetID string `bson:"dataset_id"`
+	Edition   string `bson:"edition"`
+	Version   int    `bson:"version"`
+}
+
+// versionSequenceID identifies the version sequence of an edition, ignoring the case of the edition as edition
+// lookups do
+func versionSequenceID(datasetID, edition string) string {
+	return datasetID + "/" + strings.ToLower(edition)
+}
+
+// ClaimNextVersion atomically claims the next version number of an edition of a dataset, so concurrent callers are
+// never given the same number. A number is claimed whether or not a version is then created with it, unless it is
+// given back with ReleaseVersion. The sequence of an edition is started from the versions it already has the first
+// time a number is claimed.
+func (m *Mongo) ClaimNextVersion(datasetID, edition string) (int, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	sequences := s.DB(m.Database).C(versionSequencesCollection)
+	id := versionSequenceID(datasetID, edition)
+	increment := mgo.Change{Update: bson.M{"$inc": bson.M{"version": 1}}, ReturnNew: true}
+
+	var sequence versionSequence
+	_, err := sequences.FindId(id).Apply(increment, &sequence)
+	if err == nil {
+		return sequence.Version, nil
+	}
+	if err != mgo.ErrNotFound {
+		return 0, err
+	}
+
+	next, err := m.GetNextVersion(datasetID, edition)
+	if err != nil {
+		return 0, err
+	}
+
+	err = sequences.Insert(&versionSequence{ID: id, DatasetID: datasetID, Edition: edition, Version: next})
+	if err == nil {
+		return next, nil
+	}
+	if !mgo.IsDup(err) {
+		return 0, err
+	}
+
+	// another caller started the sequence first, so the next number is claimed from it
+	if _, err = sequences.FindId(id).Apply(increment, &sequence); err != nil {
+		return 0, err
+	}
+	return sequence.Version, nil
+}
+
+// ReleaseVersion returns a claimed version number to the sequence of an edition when it is still the last number
+// claimed, so it is claimed again by the next caller. A number is left claimed once a later one has been, as the
+// versions claimed since keep their numbers, leaving a gap in the versions of the edition.
+func (m *Mongo) ReleaseVersion(datasetID, edition string, version int) error {
 	s := m.Session.Copy()
 	defer s.Close()
 
-	selector := buildVersionsQuery(id, editionID, state)
+	selector := bson.M{"_id": versionSequenceID(datasetID, edition), "version": version}
+	err := s.DB(m.Database).C(versionSequencesCollection).Update(selector, bson.M{"$inc": bson.M{"version": -1}})
+	if err != nil && err != mgo.ErrNotFound {
+		return err
+	}
+	return nil
+}
 
-	iter := s.DB(m.Database).C("instances").Find(selector).Iter()
+// GetVersions retrieves all version documents for a dataset edition
+func (m *Mongo) GetVersions(id, editionID, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
+	s := m.readSession()
+	defer s.Close()
+
+	selector := buildVersionsQuery(id, editionID, state, releaseDates)
+
+	iter := s.DB(m.Database).C("instances").Find(selector).Collation(editionCollation).Iter()
 	defer func() {
 		err := iter.Close()
 		if err != nil {
@@ -228,7 +479,53 @@ func (m *Mongo) GetVersions(id, editionID, state string) (*models.VersionResults
 	return &models.VersionResults{Items: results}, nil
 }
 
-func buildVersionsQuery(id, editionID, state string) bson.M {
+// CountVersions counts the versions of a dataset edition, optionally only those in the given state, without reading
+// the version documents. An edition without any matching versions has a count of 0.
+func (m *Mongo) CountVersions(id, editionID, state string) (int, error) {
+	s := m.readSession()
+	defer s.Close()
+
+	return s.DB(m.Database).C("instances").Find(buildVersionsQuery(id, editionID, state, nil)).Collation(editionCollation).Count()
+}
+
+// SummariseEditionVersions counts the versions of every edition of a dataset, optionally only those in the given
+// state, and finds the release date of the latest of them in a single query rather than one per edition. Edition
+// names are matched case insensitively, so editions are identified by their lower case name.
+func (m *Mongo) SummariseEditionVersions(id, state string) ([]models.EditionVersions, error) {
+	s := m.readSession()
+	defer s.Close()
+
+	results := []models.EditionVersions{}
+	if err := s.DB(m.Database).C("instances").Pipe(editionVersionsPipeline(id, state)).All(&results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// editionVersionsPipeline groups the versions of a dataset by edition, latest version first so its release date is
+// taken for the edition
+func editionVersionsPipeline(id, state string) []bson.M {
+	selector := bson.M{"links.dataset.id": id, "state": state}
+	if state == "" {
+		selector["state"] = bson.M{"$in": []string{models.EditionConfirmedState, models.AssociatedState, models.PublishedState}}
+	}
+
+	return []bson.M{
+		{"$match": selector},
+		{"$sort": bson.M{"version": -1}},
+		{"$group": bson.M{
+			"_id":   bson.M{"$toLower": "$edition"},
+			"count": bson.M{"$sum": 1},
+			"published_count": bson.M{"$sum": bson.M{
+				"$cond": []interface{}{bson.M{"$eq": []interface{}{"$state", models.PublishedState}}, 1, 0},
+			}},
+			"latest_release_date": bson.M{"$first": "$release_date"},
+		}},
+	}
+}
+
+func buildVersionsQuery(id, editionID, state string, releaseDates *models.ReleaseDateRange) bson.M {
 	var selector bson.M
 	if state == "" {
 		selector = bson.M{
@@ -248,12 +545,29 @@ func buildVersionsQuery(id, editionID, state string) bson.M {
 		}
 	}
 
+	if !releaseDates.IsEmpty() {
+		selector["release_date"] = buildReleaseDateQuery(releaseDates)
+	}
+
 	return selector
 }
 
+// buildReleaseDateQuery creates the condition for a release date range, release dates are
+// stored as ISO 8601 strings so the bounds are formatted in the same way to compare lexically
+func buildReleaseDateQuery(releaseDates *models.ReleaseDateRange) bson.M {
+	query := bson.M{}
+	if releaseDates.After != nil {
+		query["$gt"] = releaseDates.After.UTC().Format(releaseDateLayout)
+	}
+	if releaseDates.Before != nil {
+		query["$lt"] = releaseDates.Before.UTC().Format(releaseDateLayout)
+	}
+	return query
+}
+
 // GetVersion retrieves a version document for a dataset edition
 func (m *Mongo) GetVersion(id, editionID, versionID, state string) (*models.Version, error) {
-	s := m.Session.Copy()
+	s := m.readSession()
 	defer s.Close()
 
 	versionNumber, err := strconv.Atoi(versionID)
@@ -263,7 +577,58 @@ func (m *Mongo) GetVersion(id, editionID, versionID, state string) (*models.Vers
 	selector := buildVersionQuery(id, editionID, state, versionNumber)
 
 	var version models.Version
-	err = s.DB(m.Database).C("instances").Find(selector).One(&version)
+	err = s.DB(m.Database).C("instances").Find(selector).Collation(editionCollation).One(&version)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, errs.ErrVersionNotFound
+		}
+		return nil, err
+	}
+	return &version, nil
+}
+
+// GetVersionsByNumbers retrieves the versions of a dataset identified by each edition and version number pair in a
+// single query, so versions can be compared across editions. Pairs which do not identify a version are omitted
+// from the results rather than returned as an error.
+func (m *Mongo) GetVersionsByNumbers(datasetID string, refs []models.EditionVersionRef) ([]models.Version, error) {
+	versions := []models.Version{}
+	if len(refs) == 0 {
+		return versions, nil
+	}
+
+	s := m.readSession()
+	defer s.Close()
+
+	err := s.DB(m.Database).C("instances").Find(buildVersionsByNumbersQuery(datasetID, refs)).Collation(editionCollation).All(&versions)
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+func buildVersionsByNumbersQuery(datasetID string, refs []models.EditionVersionRef) bson.M {
+	pairs := make([]bson.M, 0, len(refs))
+	for _, ref := range refs {
+		pairs = append(pairs, bson.M{"edition": ref.Edition, "version": ref.Version})
+	}
+
+	return bson.M{
+		"links.dataset.id": datasetID,
+		"$or":              pairs,
+		// instances which have not yet become a version of an edition are excluded
+		"state": bson.M{"$in": []string{models.EditionConfirmedState, models.AssociatedState, models.PublishedState}},
+	}
+}
+
+// GetLatestVersion retrieves the version of a dataset edition with the highest version number, optionally
+// only considering versions in the given state
+func (m *Mongo) GetLatestVersion(id, editionID, state string) (*models.Version, error) {
+	s := m.readSession()
+	defer s.Close()
+
+	var version models.Version
+	err := s.DB(m.Database).C("instances").Find(buildLatestVersionQuery(id, editionID, state)).Collation(editionCollation).Sort("-version").One(&version)
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			return nil, errs.ErrVersionNotFound
@@ -273,6 +638,22 @@ func (m *Mongo) GetVersion(id, editionID, versionID, state string) (*models.Vers
 	return &version, nil
 }
 
+func buildLatestVersionQuery(id, editionID, state string) bson.M {
+	selector := bson.M{
+		"links.dataset.id": id,
+		"edition":          editionID,
+	}
+
+	if state != "" {
+		selector["state"] = state
+	} else {
+		// instances which have not yet become a version of the edition are excluded
+		selector["state"] = bson.M{"$in": []string{models.EditionConfirmedState, models.AssociatedState, models.PublishedState}}
+	}
+
+	return selector
+}
+
 func buildVersionQuery(id, editionID, state string, versionID int) bson.M {
 	var selector bson.M
 	if state != models.PublishedState {
@@ -300,7 +681,10 @@ func (m *Mongo) UpdateDataset(id string, dataset *models.Dataset, currentState s
 
 	updates := createDatasetUpdateQuery(id, dataset, currentState)
 	update := bson.M{"$set": updates, "$setOnInsert": bson.M{"next.last_updated": time.Now()}}
-	if err = s.DB(m.Database).C("datasets").UpdateId(id, update); err != nil {
+	err = m.retryWrite(s, func() error {
+		return s.DB(m.Database).C(datasetsCollection).UpdateId(id, update)
+	})
+	if err != nil {
 		if err == mgo.ErrNotFound {
 			return errs.ErrDatasetNotFound
 		}
@@ -323,6 +707,18 @@ func createDatasetUpdateQuery(id string, dataset *models.Dataset, currentState s
 		updates["next.contacts"] = dataset.Contacts
 	}
 
+	if dataset.Translations != nil {
+		updates["next.translations"] = dataset.Translations
+	}
+
+	if dataset.Deprecated != nil {
+		updates["next.deprecated"] = *dataset.Deprecated
+	}
+
+	if dataset.DeprecationNotice != "" {
+		updates["next.deprecation_notice"] = dataset.DeprecationNotice
+	}
+
 	if dataset.Description != "" {
 		updates["next.description"] = dataset.Description
 	}
@@ -401,6 +797,18 @@ func createDatasetUpdateQuery(id string, dataset *models.Dataset, currentState s
 		}
 	}
 
+	if dataset.Subtopics != nil {
+		updates["next.subtopics"] = dataset.Subtopics
+	}
+
+	if dataset.SunsetDate != "" {
+		updates["next.sunset_date"] = dataset.SunsetDate
+	}
+
+	if dataset.Survey != "" {
+		updates["next.survey"] = dataset.Survey
+	}
+
 	if dataset.Theme != "" {
 		updates["next.theme"] = dataset.Theme
 	}
@@ -422,6 +830,50 @@ func createDatasetUpdateQuery(id string, dataset *models.Dataset, currentState s
 	return updates
 }
 
+// PatchDataset applies a merge patch to an existing dataset document, setting
+// the fields provided and removing any fields which have been cleared
+func (m *Mongo) PatchDataset(id string, patch *models.DatasetPatch, currentState string) (err error) {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	update := createDatasetPatchQuery(id, patch, currentState)
+	err = m.retryWrite(s, func() error {
+		return s.DB(m.Database).C(datasetsCollection).UpdateId(id, update)
+	})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return errs.ErrDatasetNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func createDatasetPatchQuery(id string, patch *models.DatasetPatch, currentState string) bson.M {
+	updates := createDatasetUpdateQuery(id, patch.Dataset, currentState)
+	updates["next.last_updated"] = time.Now()
+
+	if len(patch.Clear) == 0 {
+		return bson.M{"$set": updates}
+	}
+
+	removals := make(bson.M)
+	for _, field := range patch.Clear {
+		path := "next." + field
+		removals[path] = ""
+
+		// mongo rejects an update which both sets and unsets the same path
+		for key := range updates {
+			if key == path || strings.HasPrefix(key, path+".") {
+				delete(updates, key)
+			}
+		}
+	}
+
+	return bson.M{"$set": updates, "$unset": removals}
+}
+
 // UpdateDatasetWithAssociation updates an existing dataset document with collection data
 func (m *Mongo) UpdateDatasetWithAssociation(id, state string, version *models.Version) (err error) {
 	s := m.Session.Copy()
@@ -437,7 +889,26 @@ func (m *Mongo) UpdateDatasetWithAssociation(id, state string, version *models.V
 		},
 	}
 
-	err = s.DB(m.Database).C("datasets").UpdateId(id, update)
+	err = m.retryWrite(s, func() error {
+		return s.DB(m.Database).C(datasetsCollection).UpdateId(id, update)
+	})
+	return
+}
+
+// SetDatasetLinksFrozen sets whether the latest version links of the editions of a dataset are left unchanged when
+// its versions are published
+func (m *Mongo) SetDatasetLinksFrozen(id string, frozen bool) (err error) {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	update := bson.M{"$set": bson.M{"links_frozen": frozen}}
+
+	err = m.retryWrite(s, func() error {
+		return s.DB(m.Database).C(datasetsCollection).UpdateId(id, update)
+	})
+	if err == mgo.ErrNotFound {
+		return errs.ErrDatasetNotFound
+	}
 	return
 }
 
@@ -448,7 +919,9 @@ func (m *Mongo) UpdateVersion(id string, version *models.Version) (err error) {
 
 	updates := createVersionUpdateQuery(version)
 
-	err = s.DB(m.Database).C("instances").Update(bson.M{"id": id}, bson.M{"$set": updates, "$setOnInsert": bson.M{"last_updated": time.Now()}})
+	err = m.retryWrite(s, func() error {
+		return s.DB(m.Database).C("instances").Update(bson.M{"id": id}, bson.M{"$set": updates, "$setOnInsert": bson.M{"last_updated": time.Now()}})
+	})
 	return
 }
 
@@ -473,10 +946,18 @@ func createVersionUpdateQuery(version *models.Version) bson.M {
 		setUpdates["alerts"] = version.Alerts
 	}
 
+	if version.DownloadFormats != nil {
+		setUpdates["download_formats"] = version.DownloadFormats
+	}
+
 	if version.Downloads != nil {
 		setUpdates["downloads"] = version.Downloads
 	}
 
+	if version.Labels != nil {
+		setUpdates["labels"] = version.Labels
+	}
+
 	if version.LatestChanges != nil {
 		setUpdates["latest_changes"] = version.LatestChanges
 	}
@@ -520,7 +1001,10 @@ func (m *Mongo) UpsertDataset(id string, datasetDoc *models.DatasetUpdate) (err
 		},
 	}
 
-	_, err = s.DB(m.Database).C("datasets").UpsertId(id, update)
+	err = m.retryWrite(s, func() error {
+		_, err := s.DB(m.Database).C(datasetsCollection).UpsertId(id, update)
+		return err
+	})
 	return
 }
 
@@ -529,10 +1013,7 @@ func (m *Mongo) UpsertEdition(datasetID, edition string, editionDoc *models.Edit
 	s := m.Session.Copy()
 	defer s.Close()
 
-	selector := bson.M{
-		"next.edition":          edition,
-		"next.links.dataset.id": datasetID,
-	}
+	selector := buildUpsertEditionQuery(datasetID, edition, editionDoc)
 
 	editionDoc.Next.LastUpdated = time.Now()
 
@@ -540,15 +1021,35 @@ func (m *Mongo) UpsertEdition(datasetID, edition string, editionDoc *models.Edit
 		"$set": editionDoc,
 	}
 
-	_, err = s.DB(m.Database).C(editionsCollection).Upsert(selector, update)
+	err = m.retryWrite(s, func() error {
+		_, err := s.DB(m.Database).C(editionsCollection).Upsert(selector, update)
+		return err
+	})
 	return
 }
 
-// UpsertVersion adds or overrides an existing version document
+// buildUpsertEditionQuery selects the edition document to write. An upsert cannot be given a collation, so the
+// edition name held by the document is matched exactly, it being the name stored when the edition was found by a
+// lookup in a different case.
+func buildUpsertEditionQuery(datasetID, edition string, editionDoc *models.EditionUpdate) bson.M {
+	if editionDoc.Next != nil && editionDoc.Next.Edition != "" {
+		edition = editionDoc.Next.Edition
+	}
+
+	return bson.M{
+		"next.edition":          edition,
+		"next.links.dataset.id": datasetID,
+	}
+}
+
+// UpsertVersion adds or overrides an existing version document, returning ErrVersionOutOfSequence if the version is
+// published or its number is held by another version of its edition, as the unique version index refuses it. Version
+// numbers are given out by ClaimNextVersion, so they are not checked against the next number of the edition here.
 func (m *Mongo) UpsertVersion(id string, version *models.Version) (err error) {
 	s := m.Session.Copy()
 	defer s.Close()
 
+	selector := bson.M{"_id": id, "state": bson.M{"$ne": models.PublishedState}}
 	update := bson.M{
 		"$set": version,
 		"$setOnInsert": bson.M{
@@ -556,7 +1057,13 @@ func (m *Mongo) UpsertVersion(id string, version *models.Version) (err error) {
 		},
 	}
 
-	_, err = s.DB(m.Database).C("instances").UpsertId(id, update)
+	err = m.retryWrite(s, func() error {
+		_, err := s.DB(m.Database).C("instances").Upsert(selector, update)
+		return err
+	})
+	if mgo.IsDup(err) {
+		return errs.ErrVersionOutOfSequence
+	}
 	return err
 }
 
@@ -565,7 +1072,10 @@ func (m *Mongo) UpsertContact(id string, update interface{}) (err error) {
 	s := m.Session.Copy()
 	defer s.Close()
 
-	_, err = s.DB(m.Database).C("contacts").UpsertId(id, update)
+	err = m.retryWrite(s, func() error {
+		_, err := s.DB(m.Database).C("contacts").UpsertId(id, update)
+		return err
+	})
 	return
 }
 
@@ -586,7 +1096,7 @@ func (m *Mongo) CheckDatasetExists(id, state string) error {
 		}
 	}
 
-	count, err := s.DB(m.Database).C("datasets").Find(query).Count()
+	count, err := s.DB(m.Database).C(datasetsCollection).Find(query).Count()
 	if err != nil {
 		return err
 	}
@@ -617,15 +1127,16 @@ func (m *Mongo) CheckEditionExists(id, editionID, state string) error {
 		}
 	}
 
-	count, err := s.DB(m.Database).C(editionsCollection).Find(query).Count()
+	// Count does not support a collation, so look for a matching document instead
+	var edition bson.M
+	err := s.DB(m.Database).C(editionsCollection).Find(query).Collation(editionCollation).Select(bson.M{"_id": 1}).One(&edition)
 	if err != nil {
+		if err == mgo.ErrNotFound {
+			return errs.ErrEditionNotFound
+		}
 		return err
 	}
 
-	if count == 0 {
-		return errs.ErrEditionNotFound
-	}
-
 	return nil
 }
 
@@ -672,7 +1183,7 @@ func (m *Mongo) DeleteDataset(id string) (err error) {
 	s := m.Session.Copy()
 	defer s.Close()
 
-	if err = s.DB(m.Database).C("datasets").RemoveId(id); err != nil {
+	if err = s.DB(m.Database).C(datasetsCollection).RemoveId(id); err != nil {
 		if err == mgo.ErrNotFound {
 			return errs.ErrDatasetNotFound
 		}
@@ -1,10 +1,16 @@
 package mongo
 
 import (
+	"os"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
 	"github.com/ONSdigital/dp-dataset-api/models"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -42,6 +48,57 @@ func TestBuildEditionsQuery(t *testing.T) {
 	})
 }
 
+func TestBuildEditionsForDatasetsQuery(t *testing.T) {
+	t.Parallel()
+	ids := []string{"123", "456"}
+
+	Convey("When no state was set then the next editions of any of the datasets are selected", t, func() {
+		selector := buildEditionsForDatasetsQuery(ids, "")
+		So(selector, ShouldResemble, bson.M{
+			"next.links.dataset.id": bson.M{"$in": ids},
+		})
+	})
+
+	Convey("When state was set to published then the current editions of any of the datasets are selected", t, func() {
+		selector := buildEditionsForDatasetsQuery(ids, state)
+		So(selector, ShouldResemble, bson.M{
+			"current.links.dataset.id": bson.M{"$in": ids},
+			"current.state":            state,
+		})
+	})
+}
+
+func TestGroupEditionsByDataset(t *testing.T) {
+	t.Parallel()
+	edition := func(datasetID, editionID string) *models.Edition {
+		return &models.Edition{
+			Edition: editionID,
+			Links:   &models.EditionUpdateLinks{Dataset: &models.LinkObject{ID: datasetID}},
+		}
+	}
+
+	Convey("Given the editions of two datasets", t, func() {
+		first2017 := &models.EditionUpdate{ID: "1", Current: edition("123", "2017"), Next: edition("123", "2017")}
+		first2018 := &models.EditionUpdate{ID: "2", Next: edition("123", "2018")}
+		second2017 := &models.EditionUpdate{ID: "3", Current: edition("456", "2017"), Next: edition("456", "2017")}
+		editions := []*models.EditionUpdate{first2017, second2017, first2018}
+
+		Convey("When they are grouped without a state then they are grouped by the dataset of the next edition", func() {
+			grouped := groupEditionsByDataset(editions, "")
+			So(grouped, ShouldHaveLength, 2)
+			So(grouped["123"], ShouldResemble, []*models.EditionUpdate{first2017, first2018})
+			So(grouped["456"], ShouldResemble, []*models.EditionUpdate{second2017})
+		})
+
+		Convey("When they are grouped with a state then editions without a current edition are left out", func() {
+			grouped := groupEditionsByDataset(editions, state)
+			So(grouped, ShouldHaveLength, 2)
+			So(grouped["123"], ShouldResemble, []*models.EditionUpdate{first2017})
+			So(grouped["456"], ShouldResemble, []*models.EditionUpdate{second2017})
+		})
+	})
+}
+
 func TestBuildEditionQuery(t *testing.T) {
 	t.Parallel()
 	Convey("When no state was set", t, func() {
@@ -70,6 +127,120 @@ func TestBuildEditionQuery(t *testing.T) {
 	})
 }
 
+func TestBuildUpsertEditionQuery(t *testing.T) {
+	t.Parallel()
+	Convey("When the edition document holds the stored edition name then it is matched", t, func() {
+		editionDoc := &models.EditionUpdate{Next: &models.Edition{Edition: "time-series"}}
+
+		selector := buildUpsertEditionQuery(id, "Time-Series", editionDoc)
+		So(selector, ShouldResemble, bson.M{
+			"next.links.dataset.id": id,
+			"next.edition":          "time-series",
+		})
+	})
+
+	Convey("When the edition document holds no edition name then the edition given is matched", t, func() {
+		selector := buildUpsertEditionQuery(id, "Time-Series", &models.EditionUpdate{Next: &models.Edition{}})
+		So(selector, ShouldResemble, bson.M{
+			"next.links.dataset.id": id,
+			"next.edition":          "Time-Series",
+		})
+	})
+}
+
+// TestEditionLookupIgnoresCase requires a running MongoDB instance, the address
+// of which is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestEditionLookupIgnoresCase(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given a published edition stored in lowercase with a version", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-edition-case-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		edition := &models.Edition{
+			Edition: "time-series",
+			State:   models.PublishedState,
+			Links:   &models.EditionUpdateLinks{Dataset: &models.LinkObject{ID: id}},
+		}
+		err = session.DB(m.Database).C(editionsCollection).Insert(&models.EditionUpdate{ID: "1", Current: edition, Next: edition})
+		So(err, ShouldBeNil)
+
+		err = session.DB(m.Database).C("instances").Insert(&models.Version{
+			ID:      "1",
+			Edition: "time-series",
+			State:   models.PublishedState,
+			Version: 1,
+			Links: &models.VersionLinks{
+				Dataset: &models.LinkObject{ID: id},
+				Version: &models.LinkObject{HRef: "http://localhost:22000/versions/1"},
+			},
+		})
+		So(err, ShouldBeNil)
+
+		Convey("When the edition is requested in a different case then the stored documents are found", func() {
+			editionDoc, err := m.GetEdition(id, "Time-Series", models.PublishedState)
+			So(err, ShouldBeNil)
+			So(editionDoc.ID, ShouldEqual, "1")
+			So(editionDoc.Current.Edition, ShouldEqual, "time-series")
+
+			So(m.CheckEditionExists(id, "TIME-SERIES", models.PublishedState), ShouldBeNil)
+			So(m.CheckEditionExists(id, "TIME-SERIES", ""), ShouldBeNil)
+
+			versions, err := m.GetVersions(id, "Time-Series", models.PublishedState, nil)
+			So(err, ShouldBeNil)
+			So(versions.Items, ShouldHaveLength, 1)
+			So(versions.Items[0].ID, ShouldEqual, "1")
+		})
+
+		Convey("When an edition found in a different case is upserted then the stored document is updated", func() {
+			editionDoc, err := m.GetEdition(id, "Time-Series", "")
+			So(err, ShouldBeNil)
+
+			So(m.UpsertEdition(id, "Time-Series", editionDoc), ShouldBeNil)
+
+			count, err := session.DB(m.Database).C(editionsCollection).Count()
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+		})
+	})
+}
+
+func TestEditionVersionsPipeline(t *testing.T) {
+	t.Parallel()
+	Convey("When the versions of editions are summarised in a state", t, func() {
+		pipeline := editionVersionsPipeline(id, state)
+
+		Convey("Then only the versions of the dataset in that state are matched", func() {
+			So(pipeline[0], ShouldResemble, bson.M{"$match": bson.M{"links.dataset.id": id, "state": state}})
+		})
+
+		Convey("Then the versions are grouped by edition, ignoring case, latest version first", func() {
+			So(pipeline[1], ShouldResemble, bson.M{"$sort": bson.M{"version": -1}})
+			group := pipeline[2]["$group"].(bson.M)
+			So(group["_id"], ShouldResemble, bson.M{"$toLower": "$edition"})
+			So(group["count"], ShouldResemble, bson.M{"$sum": 1})
+			So(group["latest_release_date"], ShouldResemble, bson.M{"$first": "$release_date"})
+		})
+	})
+
+	Convey("When no state was set then versions in any state visible to authorised callers are matched", t, func() {
+		So(editionVersionsPipeline(id, "")[0], ShouldResemble, bson.M{"$match": bson.M{
+			"links.dataset.id": id,
+			"state":            bson.M{"$in": []string{"edition-confirmed", "associated", "published"}},
+		}})
+	})
+}
+
 func TestBuildVersionsQuery(t *testing.T) {
 	t.Parallel()
 	Convey("When no state was set", t, func() {
@@ -84,7 +255,7 @@ func TestBuildVersionsQuery(t *testing.T) {
 			},
 		}
 
-		selector := buildVersionsQuery(id, editionID, "")
+		selector := buildVersionsQuery(id, editionID, "", nil)
 		So(selector, ShouldNotBeNil)
 		So(selector, ShouldResemble, expectedSelector)
 	})
@@ -97,10 +268,194 @@ func TestBuildVersionsQuery(t *testing.T) {
 			"state":            state,
 		}
 
-		selector := buildVersionsQuery(id, editionID, state)
+		selector := buildVersionsQuery(id, editionID, state, nil)
+		So(selector, ShouldNotBeNil)
+		So(selector, ShouldResemble, expectedSelector)
+	})
+
+	Convey("When a release date range was set", t, func() {
+		after := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		before := time.Date(2018, 6, 30, 12, 0, 0, 0, time.FixedZone("BST", 3600))
+
+		expectedSelector := bson.M{
+			"links.dataset.id": id,
+			"edition":          editionID,
+			"state":            state,
+			"release_date": bson.M{
+				"$gt": "2017-01-01T00:00:00.000Z",
+				"$lt": "2018-06-30T11:00:00.000Z",
+			},
+		}
+
+		selector := buildVersionsQuery(id, editionID, state, &models.ReleaseDateRange{After: &after, Before: &before})
 		So(selector, ShouldNotBeNil)
 		So(selector, ShouldResemble, expectedSelector)
 	})
+
+	Convey("When only the lower bound of a release date range was set", t, func() {
+		after := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		selector := buildVersionsQuery(id, editionID, state, &models.ReleaseDateRange{After: &after})
+		So(selector["release_date"], ShouldResemble, bson.M{"$gt": "2017-01-01T00:00:00.000Z"})
+	})
+}
+
+// TestGetVersionsReleaseDateRange requires a running MongoDB instance, the address
+// of which is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestGetVersionsReleaseDateRange(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given published versions released across several years", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-versions-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		for i, releaseDate := range []string{"2016-04-04T00:00:00.000Z", "2017-04-04T00:00:00.000Z", "2018-04-04T00:00:00.000Z"} {
+			err := session.DB(m.Database).C("instances").Insert(&models.Version{
+				ID:          strconv.Itoa(i),
+				Edition:     editionID,
+				ReleaseDate: releaseDate,
+				State:       models.PublishedState,
+				Version:     i + 1,
+				Links: &models.VersionLinks{
+					Dataset: &models.LinkObject{ID: id},
+					Version: &models.LinkObject{HRef: "http://localhost:22000/versions/" + strconv.Itoa(i+1)},
+				},
+			})
+			So(err, ShouldBeNil)
+		}
+
+		Convey("When the versions are filtered by a release date range", func() {
+			releaseDates, err := models.ParseReleaseDateRange("2017-01-01", "2018-01-01")
+			So(err, ShouldBeNil)
+
+			results, err := m.GetVersions(id, editionID, models.PublishedState, releaseDates)
+
+			Convey("Then only the versions released within the range are returned", func() {
+				So(err, ShouldBeNil)
+				So(results.Items, ShouldHaveLength, 1)
+				So(results.Items[0].ReleaseDate, ShouldEqual, "2017-04-04T00:00:00.000Z")
+			})
+		})
+	})
+}
+
+// TestCountVersions requires a running MongoDB instance, the address of which is
+// provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestCountVersions(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given an edition with published and unpublished versions, and an instance not yet a version", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-count-versions-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		states := []string{models.PublishedState, models.PublishedState, models.AssociatedState, models.EditionConfirmedState, models.CreatedState}
+		for i, state := range states {
+			err := session.DB(m.Database).C("instances").Insert(&models.Version{
+				ID:      strconv.Itoa(i),
+				Edition: editionID,
+				State:   state,
+				Version: i + 1,
+				Links: &models.VersionLinks{
+					Dataset: &models.LinkObject{ID: id},
+				},
+			})
+			So(err, ShouldBeNil)
+		}
+
+		Convey("When the versions of the edition are counted", func() {
+			all, err := m.CountVersions(id, editionID, "")
+			So(err, ShouldBeNil)
+			published, err := m.CountVersions(id, editionID, models.PublishedState)
+			So(err, ShouldBeNil)
+
+			Convey("Then every version is counted, and only published versions when the state is published", func() {
+				So(all, ShouldEqual, 4)
+				So(published, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When the versions of an edition without any are counted", func() {
+			count, err := m.CountVersions(id, "unknown", "")
+
+			Convey("Then zero is returned", func() {
+				So(err, ShouldBeNil)
+				So(count, ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+// TestGetDistinctThemes requires a running MongoDB instance, the address of which is
+// provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestGetDistinctThemes(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given published datasets sharing themes, one without a theme and an unpublished dataset", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-themes-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		datasets := []*models.DatasetUpdate{
+			{ID: "1", Current: &models.Dataset{State: models.PublishedState, Theme: "population"}},
+			{ID: "2", Current: &models.Dataset{State: models.PublishedState, Theme: "economy"}},
+			{ID: "3", Current: &models.Dataset{State: models.PublishedState, Theme: "population"}},
+			{ID: "4", Current: &models.Dataset{State: models.PublishedState}},
+			{ID: "5", Next: &models.Dataset{State: models.CreatedState, Theme: "health"}},
+		}
+		for _, dataset := range datasets {
+			So(session.DB(m.Database).C("datasets").Insert(dataset), ShouldBeNil)
+		}
+
+		Convey("When the distinct themes are read", func() {
+			themes, err := m.GetDistinctThemes()
+
+			Convey("Then each theme of a published dataset is returned once", func() {
+				So(err, ShouldBeNil)
+				So(themes, ShouldHaveLength, 2)
+				So(themes, ShouldContain, "economy")
+				So(themes, ShouldContain, "population")
+			})
+		})
+	})
+}
+
+func TestBuildDistinctThemesQuery(t *testing.T) {
+	t.Parallel()
+	Convey("When the query for distinct themes is built then only published datasets with a theme are selected", t, func() {
+		So(buildDistinctThemesQuery(), ShouldResemble, bson.M{
+			"current.state": models.PublishedState,
+			"current.theme": bson.M{"$exists": true, "$ne": ""},
+		})
+	})
 }
 
 func TestBuildVersionQuery(t *testing.T) {
@@ -133,6 +488,430 @@ func TestBuildVersionQuery(t *testing.T) {
 	})
 }
 
+func TestBuildLatestVersionQuery(t *testing.T) {
+	t.Parallel()
+	Convey("When no state was set only instances which are versions of the edition are selected", t, func() {
+
+		expectedSelector := bson.M{
+			"links.dataset.id": id,
+			"edition":          editionID,
+			"state":            bson.M{"$in": []string{models.EditionConfirmedState, models.AssociatedState, models.PublishedState}},
+		}
+
+		selector := buildLatestVersionQuery(id, editionID, "")
+		So(selector, ShouldResemble, expectedSelector)
+	})
+
+	Convey("When state was set to published", t, func() {
+
+		expectedSelector := bson.M{
+			"links.dataset.id": id,
+			"edition":          editionID,
+			"state":            state,
+		}
+
+		selector := buildLatestVersionQuery(id, editionID, state)
+		So(selector, ShouldResemble, expectedSelector)
+	})
+}
+
+func TestBuildVersionsByNumbersQuery(t *testing.T) {
+	t.Parallel()
+	Convey("When edition and version pairs are given then a version matching any pair is selected", t, func() {
+		refs := []models.EditionVersionRef{{Edition: "2017", Version: 2}, {Edition: "time-series", Version: 5}}
+
+		expectedSelector := bson.M{
+			"links.dataset.id": id,
+			"$or": []bson.M{
+				{"edition": "2017", "version": 2},
+				{"edition": "time-series", "version": 5},
+			},
+			"state": bson.M{"$in": []string{models.EditionConfirmedState, models.AssociatedState, models.PublishedState}},
+		}
+
+		selector := buildVersionsByNumbersQuery(id, refs)
+		So(selector, ShouldResemble, expectedSelector)
+	})
+}
+
+// TestGetVersionsByNumbers requires a running MongoDB instance, the address
+// of which is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestGetVersionsByNumbers(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given versions of a dataset across two editions", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-versions-by-numbers-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		for i, ref := range []models.EditionVersionRef{{Edition: "2017", Version: 1}, {Edition: "2017", Version: 2}, {Edition: "2018", Version: 1}} {
+			err := session.DB(m.Database).C("instances").Insert(&models.Version{
+				ID:      strconv.Itoa(i),
+				Edition: ref.Edition,
+				State:   models.PublishedState,
+				Version: ref.Version,
+				Links:   &models.VersionLinks{Dataset: &models.LinkObject{ID: id}},
+			})
+			So(err, ShouldBeNil)
+		}
+
+		Convey("When versions are requested by edition and version number", func() {
+			versions, err := m.GetVersionsByNumbers(id, []models.EditionVersionRef{
+				{Edition: "2017", Version: 2},
+				{Edition: "2018", Version: 1},
+				{Edition: "2019", Version: 1},
+			})
+
+			Convey("Then exactly the requested versions which exist are returned", func() {
+				So(err, ShouldBeNil)
+				So(versions, ShouldHaveLength, 2)
+
+				ids := []string{versions[0].ID, versions[1].ID}
+				So(ids, ShouldContain, "1")
+				So(ids, ShouldContain, "2")
+			})
+		})
+
+		Convey("When no versions are requested then none are returned", func() {
+			versions, err := m.GetVersionsByNumbers(id, nil)
+			So(err, ShouldBeNil)
+			So(versions, ShouldBeEmpty)
+		})
+	})
+}
+
+// TestClaimNextVersion requires a running MongoDB instance, the address
+// of which is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestClaimNextVersion(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given an edition with versions 1 and 2", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-claim-version-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		for _, number := range []int{1, 2} {
+			err := session.DB(m.Database).C("instances").Insert(&models.Version{
+				ID:      strconv.Itoa(number),
+				Edition: editionID,
+				State:   models.PublishedState,
+				Version: number,
+				Links:   &models.VersionLinks{Dataset: &models.LinkObject{ID: id}},
+			})
+			So(err, ShouldBeNil)
+		}
+
+		Convey("When version numbers are claimed concurrently", func() {
+			claims := 20
+			numbers := make(chan int, claims)
+			failures := make(chan error, claims)
+
+			var wg sync.WaitGroup
+			for i := 0; i < claims; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					number, err := m.ClaimNextVersion(id, editionID)
+					numbers <- number
+					failures <- err
+				}()
+			}
+			wg.Wait()
+			close(numbers)
+			close(failures)
+
+			Convey("Then each claim is given a distinct number following the existing versions", func() {
+				for err := range failures {
+					So(err, ShouldBeNil)
+				}
+
+				claimed := map[int]bool{}
+				for number := range numbers {
+					So(claimed[number], ShouldBeFalse)
+					claimed[number] = true
+				}
+				for number := 3; number < 3+claims; number++ {
+					So(claimed[number], ShouldBeTrue)
+				}
+			})
+		})
+
+		Convey("When version numbers are claimed for another edition then its own sequence is used", func() {
+			first, err := m.ClaimNextVersion(id, editionID)
+			So(err, ShouldBeNil)
+			So(first, ShouldEqual, 3)
+
+			other, err := m.ClaimNextVersion(id, "2018")
+			So(err, ShouldBeNil)
+			So(other, ShouldEqual, 1)
+
+			second, err := m.ClaimNextVersion(id, editionID)
+			So(err, ShouldBeNil)
+			So(second, ShouldEqual, 4)
+		})
+
+		Convey("When the last number claimed is released then it is claimed again", func() {
+			first, err := m.ClaimNextVersion(id, editionID)
+			So(err, ShouldBeNil)
+			So(first, ShouldEqual, 3)
+
+			So(m.ReleaseVersion(id, editionID, first), ShouldBeNil)
+
+			again, err := m.ClaimNextVersion(id, editionID)
+			So(err, ShouldBeNil)
+			So(again, ShouldEqual, 3)
+		})
+
+		Convey("When a number is released after a later one is claimed then it is left claimed", func() {
+			first, err := m.ClaimNextVersion(id, editionID)
+			So(err, ShouldBeNil)
+			second, err := m.ClaimNextVersion(id, editionID)
+			So(err, ShouldBeNil)
+			So(second, ShouldEqual, first+1)
+
+			So(m.ReleaseVersion(id, editionID, first), ShouldBeNil)
+
+			next, err := m.ClaimNextVersion(id, editionID)
+			So(err, ShouldBeNil)
+			So(next, ShouldEqual, second+1)
+		})
+	})
+}
+
+// TestUpsertVersionSequence requires a running MongoDB instance, the address
+// of which is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestUpsertVersionSequence(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given an edition with only version 1", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-upsert-version-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		newVersion := func(number int) *models.Version {
+			return &models.Version{
+				ID:      strconv.Itoa(number),
+				Edition: editionID,
+				State:   models.EditionConfirmedState,
+				Version: number,
+				Links:   &models.VersionLinks{Dataset: &models.LinkObject{ID: id}},
+			}
+		}
+		So(m.UpsertVersion("1", newVersion(1)), ShouldBeNil)
+
+		Convey("When version 2 is upserted then it is written", func() {
+			So(m.UpsertVersion("2", newVersion(2)), ShouldBeNil)
+		})
+
+		Convey("When version 3 is upserted after version 2 was claimed but not created then it is written", func() {
+			So(m.UpsertVersion("3", newVersion(3)), ShouldBeNil)
+		})
+
+		Convey("When the unpublished version 1 is upserted again then it is overwritten", func() {
+			So(m.UpsertVersion("1", newVersion(1)), ShouldBeNil)
+		})
+
+		Convey("When version 1 is upserted as another document then it is rejected", func() {
+			So(m.UpsertVersion("2", newVersion(1)), ShouldEqual, errs.ErrVersionOutOfSequence)
+		})
+
+		Convey("When version 1 has been published then it is not overwritten", func() {
+			So(session.DB(m.Database).C("instances").UpdateId("1", bson.M{"$set": bson.M{"state": models.PublishedState}}), ShouldBeNil)
+			So(m.UpsertVersion("1", newVersion(1)), ShouldEqual, errs.ErrVersionOutOfSequence)
+		})
+	})
+}
+
+func TestDatasetsQuery(t *testing.T) {
+	t.Parallel()
+	Convey("When no type is given every dataset is selected", t, func() {
+		So(buildDatasetsQuery(""), ShouldBeNil)
+	})
+
+	Convey("When static datasets are requested only datasets with the static type are selected", t, func() {
+		So(buildDatasetsQuery(models.StaticDatasetType), ShouldResemble, bson.M{"next.type": models.StaticDatasetType})
+	})
+
+	Convey("When filterable datasets are requested datasets without a type are also selected", t, func() {
+		So(buildDatasetsQuery(models.FilterableDatasetType), ShouldResemble, bson.M{"next.type": bson.M{"$in": []interface{}{models.FilterableDatasetType, nil}}})
+	})
+}
+
+// TestGetDatasetsByType requires a running MongoDB instance, the address
+// of which is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestGetDatasetsByType(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given filterable, static and untyped datasets", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-type-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		datasets := []*models.DatasetUpdate{
+			{ID: "filterable", Next: &models.Dataset{Type: models.FilterableDatasetType}},
+			{ID: "static", Next: &models.Dataset{Type: models.StaticDatasetType}},
+			{ID: "untyped", Next: &models.Dataset{}},
+		}
+		for _, dataset := range datasets {
+			So(session.DB(m.Database).C("datasets").Insert(dataset), ShouldBeNil)
+		}
+
+		Convey("When the static datasets are requested then only the static dataset is returned", func() {
+			results, err := m.GetDatasets(models.StaticDatasetType)
+			So(err, ShouldBeNil)
+			So(results, ShouldHaveLength, 1)
+			So(results[0].ID, ShouldEqual, "static")
+		})
+
+		Convey("When the filterable datasets are requested then the untyped dataset is included", func() {
+			results, err := m.GetDatasets(models.FilterableDatasetType)
+			So(err, ShouldBeNil)
+			So(results, ShouldHaveLength, 2)
+		})
+	})
+}
+
+func TestDatasetsModifiedSinceQuery(t *testing.T) {
+	t.Parallel()
+	Convey("When datasets modified since a time are requested then either the current or next document must have been updated since", t, func() {
+		since := time.Date(2018, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+		expectedSelector := bson.M{
+			"$or": []bson.M{
+				{"current.last_updated": bson.M{"$gte": since}},
+				{"next.last_updated": bson.M{"$gte": since}},
+			},
+		}
+
+		selector := buildDatasetsModifiedSinceQuery(since, "", false)
+		So(selector, ShouldResemble, expectedSelector)
+	})
+
+	Convey("When only published datasets modified since a time are requested then the current document must have been updated since", t, func() {
+		since := time.Date(2018, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+		expectedSelector := bson.M{"current.last_updated": bson.M{"$gte": since}}
+
+		selector := buildDatasetsModifiedSinceQuery(since, "", true)
+		So(selector, ShouldResemble, expectedSelector)
+	})
+
+	Convey("When datasets of a type modified since a time are requested then both filters are applied", t, func() {
+		since := time.Date(2018, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+		expectedSelector := bson.M{
+			"current.last_updated": bson.M{"$gte": since},
+			"next.type":            models.StaticDatasetType,
+		}
+
+		selector := buildDatasetsModifiedSinceQuery(since, models.StaticDatasetType, true)
+		So(selector, ShouldResemble, expectedSelector)
+	})
+}
+
+// TestGetDatasetsModifiedSince requires a running MongoDB instance, the address
+// of which is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestGetDatasetsModifiedSince(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given datasets updated at different times", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-modified-since-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		since := time.Date(2018, time.June, 1, 9, 0, 0, 0, time.UTC)
+		datasets := []*models.DatasetUpdate{
+			{ID: "old", Current: &models.Dataset{LastUpdated: since.Add(-time.Hour)}, Next: &models.Dataset{LastUpdated: since.Add(-time.Hour)}},
+			{ID: "current-updated", Current: &models.Dataset{LastUpdated: since.Add(time.Hour)}},
+			{ID: "next-updated", Current: &models.Dataset{LastUpdated: since.Add(-time.Hour)}, Next: &models.Dataset{LastUpdated: since.Add(time.Hour)}},
+			{ID: "unpublished", Next: &models.Dataset{LastUpdated: since.Add(time.Hour)}},
+		}
+		for _, dataset := range datasets {
+			So(session.DB(m.Database).C("datasets").Insert(dataset), ShouldBeNil)
+		}
+
+		Convey("When the datasets modified since a time are requested", func() {
+			page, err := m.GetDatasetsModifiedSince(since, "", false, 0, 20)
+
+			Convey("Then only the datasets updated since that time are returned", func() {
+				So(err, ShouldBeNil)
+				So(page.TotalCount, ShouldEqual, 3)
+				So(page.Count, ShouldEqual, 3)
+				So(page.Items[0].ID, ShouldEqual, "current-updated")
+				So(page.Items[1].ID, ShouldEqual, "next-updated")
+				So(page.Items[2].ID, ShouldEqual, "unpublished")
+			})
+		})
+
+		Convey("When the published datasets modified since a time are requested", func() {
+			page, err := m.GetDatasetsModifiedSince(since, "", true, 0, 20)
+
+			Convey("Then only the datasets whose current document was updated since that time are counted and returned", func() {
+				So(err, ShouldBeNil)
+				So(page.TotalCount, ShouldEqual, 1)
+				So(page.Count, ShouldEqual, 1)
+				So(page.Items[0].ID, ShouldEqual, "current-updated")
+			})
+		})
+
+		Convey("When the second page of a single item is requested", func() {
+			page, err := m.GetDatasetsModifiedSince(since, "", false, 1, 1)
+
+			Convey("Then the second of the updated datasets is returned along with the total count", func() {
+				So(err, ShouldBeNil)
+				So(page.TotalCount, ShouldEqual, 3)
+				So(page.Items, ShouldHaveLength, 1)
+				So(page.Items[0].ID, ShouldEqual, "next-updated")
+			})
+		})
+	})
+}
+
 func TestDatasetUpdateQuery(t *testing.T) {
 	t.Parallel()
 	Convey("When all possible fields exist", t, func() {
@@ -173,10 +952,13 @@ func TestDatasetUpdateQuery(t *testing.T) {
 		publications = append(publications, publication)
 		relatedDatasets = append(relatedDatasets, relatedDataset)
 		nationalStatistic := true
+		deprecated := true
 
 		expectedUpdate := bson.M{
 			"next.collection_id":            "12345678",
 			"next.contacts":                 contacts,
+			"next.deprecated":               true,
+			"next.deprecation_notice":       "replaced by cpih02",
 			"next.description":              "test description",
 			"next.keywords":                 []string{"statistics", "national"},
 			"next.license":                  "ONS License",
@@ -193,17 +975,22 @@ func TestDatasetUpdateQuery(t *testing.T) {
 			"next.qmi.title":                "some qmi title",
 			"next.related_datasets":         relatedDatasets,
 			"next.release_frequency":        "yearly",
+			"next.subtopics":                []string{"consumer prices", "inflation"},
+			"next.sunset_date":              "2030-01-31",
+			"next.survey":                   "living costs and food survey",
 			"next.theme":                    "construction",
 			"next.title":                    "CPI",
 			"next.uri":                      "http://ons.gov.uk/dataset/123/landing-page",
 		}
 
 		dataset := &models.Dataset{
-			Contacts:     contacts,
-			CollectionID: "12345678",
-			Description:  "test description",
-			Keywords:     []string{"statistics", "national"},
-			License:      "ONS License",
+			Contacts:          contacts,
+			CollectionID:      "12345678",
+			Deprecated:        &deprecated,
+			DeprecationNotice: "replaced by cpih02",
+			Description:       "test description",
+			Keywords:          []string{"statistics", "national"},
+			License:           "ONS License",
 			Links: &models.DatasetLinks{
 				AccessRights: &models.LinkObject{
 					HRef: "http://ons.gov.uk/accessrights",
@@ -221,6 +1008,9 @@ func TestDatasetUpdateQuery(t *testing.T) {
 			QMI:              &qmi,
 			RelatedDatasets:  relatedDatasets,
 			ReleaseFrequency: "yearly",
+			Subtopics:        []string{"consumer prices", "inflation"},
+			SunsetDate:       "2030-01-31",
+			Survey:           "living costs and food survey",
 			Theme:            "construction",
 			Title:            "CPI",
 			URI:              "http://ons.gov.uk/dataset/123/landing-page",
@@ -251,6 +1041,64 @@ func TestDatasetUpdateQuery(t *testing.T) {
 		So(selector, ShouldNotBeNil)
 		So(selector, ShouldResemble, bson.M{})
 	})
+
+	Convey("When two contacts are set then the contact list is replaced wholesale", t, func() {
+		contacts := []models.ContactDetails{
+			{Name: "natalie jarrod", Email: "njarrod@test.com"},
+			{Email: "cpi@test.com", Telephone: "01658 234567"},
+		}
+		dataset := &models.Dataset{Contacts: contacts}
+
+		selector := createDatasetUpdateQuery("123", dataset, models.CreatedState)
+		So(selector, ShouldResemble, bson.M{"next.contacts": contacts})
+	})
+
+	Convey("When translations are set then the translations are replaced wholesale", t, func() {
+		translations := models.Translations{"cy": {Title: "Mynegai Prisiau Defnyddwyr"}}
+		dataset := &models.Dataset{Translations: translations}
+
+		selector := createDatasetUpdateQuery("123", dataset, models.CreatedState)
+		So(selector, ShouldResemble, bson.M{"next.translations": translations})
+	})
+}
+
+func TestDatasetPatchQuery(t *testing.T) {
+	Convey("When the description is cleared and the title is set", t, func() {
+		patch := &models.DatasetPatch{
+			Dataset: &models.Dataset{Title: "CPI"},
+			Clear:   []string{"description"},
+		}
+
+		update := createDatasetPatchQuery("123", patch, models.CreatedState)
+		So(update, ShouldNotBeNil)
+
+		updates := update["$set"].(bson.M)
+		So(updates["next.title"], ShouldEqual, "CPI")
+		So(updates["next.last_updated"], ShouldNotBeNil)
+		So(updates, ShouldNotContainKey, "next.description")
+		So(update["$unset"], ShouldResemble, bson.M{"next.description": ""})
+	})
+
+	Convey("When a cleared field is also set then only the removal is kept", t, func() {
+		patch := &models.DatasetPatch{
+			Dataset: &models.Dataset{Publisher: &models.Publisher{Name: "ONS"}},
+			Clear:   []string{"publisher"},
+		}
+
+		update := createDatasetPatchQuery("123", patch, models.CreatedState)
+		So(update["$set"], ShouldNotContainKey, "next.publisher.name")
+		So(update["$unset"], ShouldResemble, bson.M{"next.publisher": ""})
+	})
+
+	Convey("When no fields are cleared", t, func() {
+		patch := &models.DatasetPatch{
+			Dataset: &models.Dataset{Title: "CPI"},
+		}
+
+		update := createDatasetPatchQuery("123", patch, models.CreatedState)
+		So(update, ShouldNotContainKey, "$unset")
+		So(update["$set"].(bson.M)["next.title"], ShouldEqual, "CPI")
+	})
 }
 
 func TestVersionUpdateQuery(t *testing.T) {
@@ -269,6 +1117,8 @@ func TestVersionUpdateQuery(t *testing.T) {
 			"links.spatial.href": "http://ons.gov.uk/geographylist",
 			"state":              models.PublishedState,
 			"temporal":           &[]models.TemporalFrequency{temporal},
+			"labels":             map[string]string{"qa_status": "passed"},
+			"download_formats":   []string{"csv"},
 		}
 
 		version := &models.Version{
@@ -279,8 +1129,10 @@ func TestVersionUpdateQuery(t *testing.T) {
 					HRef: "http://ons.gov.uk/geographylist",
 				},
 			},
-			State:    models.PublishedState,
-			Temporal: &[]models.TemporalFrequency{temporal},
+			DownloadFormats: []string{"csv"},
+			Labels:          map[string]string{"qa_status": "passed"},
+			State:           models.PublishedState,
+			Temporal:        &[]models.TemporalFrequency{temporal},
 		}
 
 		selector := createVersionUpdateQuery(version)
@@ -288,3 +1140,42 @@ func TestVersionUpdateQuery(t *testing.T) {
 		So(selector, ShouldResemble, expectedUpdate)
 	})
 }
+
+func TestReadMode(t *testing.T) {
+	t.Parallel()
+	Convey("When secondary reads are disabled then read-only queries use strong consistency", t, func() {
+		m := &Mongo{}
+		So(m.readMode(), ShouldEqual, mgo.Strong)
+	})
+
+	Convey("When secondary reads are enabled then read-only queries prefer a secondary", t, func() {
+		m := &Mongo{SecondaryReads: true}
+		So(m.readMode(), ShouldEqual, mgo.SecondaryPreferred)
+	})
+}
+
+// TestReadSession is an integration test which requires a running MongoDB instance, the address
+// of which is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestReadSession(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given a mongo session with secondary reads enabled", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-read-session-test", URI: uri, SecondaryReads: true}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer session.Close()
+
+		Convey("Then read sessions prefer a secondary and the original session remains strong", func() {
+			s := m.readSession()
+			defer s.Close()
+
+			So(s.Mode(), ShouldEqual, mgo.SecondaryPreferred)
+			So(m.Session.Mode(), ShouldEqual, mgo.Strong)
+		})
+	})
+}
@@ -6,6 +6,8 @@ import (
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
 	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 )
 
@@ -27,6 +29,29 @@ func (m *Mongo) GetDimensionsFromInstance(id string) (*models.DimensionNodeResul
 	return &models.DimensionNodeResults{Items: dimensions}, nil
 }
 
+// GetDimensionOptionCounts returns the number of options stored for each dimension of an instance
+func (m *Mongo) GetDimensionOptionCounts(instanceID string) ([]models.DimensionOptionCount, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	counts := []models.DimensionOptionCount{}
+	err := s.DB(m.Database).C(dimensionOptions).Pipe(dimensionOptionCountsPipeline(instanceID)).All(&counts)
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// dimensionOptionCountsPipeline groups the dimension options of an instance by name, counting the options in each
+func dimensionOptionCountsPipeline(instanceID string) []bson.M {
+	return []bson.M{
+		{"$match": bson.M{"instance_id": instanceID}},
+		{"$group": bson.M{"_id": "$name", "option_count": bson.M{"$sum": 1}}},
+		{"$sort": bson.M{"_id": 1}},
+	}
+}
+
 // GetUniqueDimensionAndOptions returns a list of dimension options for an instance resource
 func (m *Mongo) GetUniqueDimensionAndOptions(id, dimension string) (*models.DimensionValues, error) {
 	s := m.Session.Copy()
@@ -45,12 +70,83 @@ func (m *Mongo) GetUniqueDimensionAndOptions(id, dimension string) (*models.Dime
 	return &models.DimensionValues{Name: dimension, Options: values}, nil
 }
 
+// GetDimensionOptionsByCodes returns the options of a dimension of an instance with the given codes, codes which are
+// not options of the dimension are left out of the results
+func (m *Mongo) GetDimensionOptionsByCodes(instanceID, dimension string, codes []string) (*models.DimensionOptionResults, error) {
+	s := m.readSession()
+	defer s.Close()
+
+	values := []models.PublicDimensionOption{}
+	iter := s.DB(m.Database).C(dimensionOptions).Find(buildDimensionOptionsByCodesQuery(instanceID, dimension, codes)).Sort("option").Iter()
+	if err := iter.All(&values); err != nil {
+		return nil, err
+	}
+
+	return &models.DimensionOptionResults{Items: values}, nil
+}
+
+// StreamDimensionOptions calls fn with each option of a dimension of an instance in turn, ordered by option code and
+// read from the collection as they are needed rather than loaded all at once. Iteration stops at the first error
+// from fn.
+func (m *Mongo) StreamDimensionOptions(instanceID, dimension string, fn func(*models.DimensionOption) error) error {
+	s := m.readSession()
+	defer s.Close()
+
+	iter := s.DB(m.Database).C(dimensionOptions).Find(bson.M{"instance_id": instanceID, "name": dimension}).Sort("option").Iter()
+	for {
+		// decode into a new option each time, as fields missing from a document are not reset by Next
+		var option models.DimensionOption
+		if !iter.Next(&option) {
+			break
+		}
+
+		if err := fn(&option); err != nil {
+			if closeErr := iter.Close(); closeErr != nil {
+				log.ErrorC("error closing iterator", closeErr, log.Data{"instance_id": instanceID, "dimension": dimension})
+			}
+			return err
+		}
+	}
+
+	return iter.Close()
+}
+
+func buildDimensionOptionsByCodesQuery(instanceID, dimension string, codes []string) bson.M {
+	return bson.M{"instance_id": instanceID, "name": dimension, "option": bson.M{"$in": codes}}
+}
+
+// GetDimensionCodeList returns the id of the code list the options of a dimension on an instance are taken from
+func (m *Mongo) GetDimensionCodeList(instanceID, dimension string) (string, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	var option models.DimensionOption
+	err := s.DB(m.Database).C(dimensionOptions).Find(bson.M{"instance_id": instanceID, "name": dimension}).Select(bson.M{"links.code_list": 1}).One(&option)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return "", errs.ErrDimensionNotFound
+		}
+		return "", err
+	}
+
+	return option.Links.CodeList.ID, nil
+}
+
+// DeleteDimensionOptions removes every option of a dimension on an instance
+func (m *Mongo) DeleteDimensionOptions(instanceID, dimension string) error {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	_, err := s.DB(m.Database).C(dimensionOptions).RemoveAll(bson.M{"instance_id": instanceID, "name": dimension})
+	return err
+}
+
 // AddDimensionToInstance to the dimension collection
 func (m *Mongo) AddDimensionToInstance(opt *models.CachedDimensionOption) error {
 	s := m.Session.Copy()
 	defer s.Close()
 
-	option := models.DimensionOption{InstanceID: opt.InstanceID, Option: opt.Option, Name: opt.Name, Label: opt.Label}
+	option := models.DimensionOption{InstanceID: opt.InstanceID, Option: opt.Option, Name: opt.Name, Label: opt.Label, Order: opt.Order}
 	option.Links.CodeList = models.LinkObject{ID: opt.CodeList, HRef: fmt.Sprintf("%s/code-lists/%s", m.CodeListURL, opt.CodeList)}
 	option.Links.Code = models.LinkObject{ID: opt.Code, HRef: fmt.Sprintf("%s/code-lists/%s/codes/%s", m.CodeListURL, opt.CodeList, opt.Code)}
 
@@ -61,9 +157,115 @@ func (m *Mongo) AddDimensionToInstance(opt *models.CachedDimensionOption) error
 	return err
 }
 
+// RenameDimension changes the name of a dimension on an unpublished instance, on each of its dimension options and in
+// the header row of its V4 file. The instance is renamed first and its options only once the instance has matched, so
+// a rename refused for the instance leaves the options as they were. Should renaming the options fail, the instance
+// and any options already renamed are given back the old name.
+func (m *Mongo) RenameDimension(instanceID, oldName, newName string) error {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	var instance models.Instance
+	if err := s.DB(m.Database).C(instanceCollection).Find(bson.M{"id": instanceID}).Select(bson.M{"headers": 1, "dimensions": 1}).One(&instance); err != nil {
+		if err == mgo.ErrNotFound {
+			return errs.ErrInstanceNotFound
+		}
+		return err
+	}
+
+	exists, err := m.dimensionNameExists(s, instanceID, instance.Dimensions, newName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errs.ErrDimensionNameExists
+	}
+
+	instanceSelector, instanceUpdate := createRenameInstanceDimensionQuery(instanceID, oldName, newName, instance.Headers)
+	if err = s.DB(m.Database).C(instanceCollection).Update(instanceSelector, instanceUpdate); err != nil {
+		if err == mgo.ErrNotFound {
+			return errs.ErrDimensionNotFound
+		}
+		return err
+	}
+
+	optionSelector, optionUpdate := createRenameDimensionOptionsQuery(instanceID, oldName, newName)
+	if _, err = s.DB(m.Database).C(dimensionOptions).UpdateAll(optionSelector, optionUpdate); err != nil {
+		if revertErr := m.revertRenameDimension(s, instanceID, oldName, newName, instance.Headers); revertErr != nil {
+			log.ErrorC("failed to revert the rename of a dimension", revertErr, log.Data{"instance_id": instanceID, "dimension": oldName, "new_name": newName})
+		}
+
+		if mgo.IsDup(err) {
+			return errs.ErrDimensionNameExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// dimensionNameExists returns true if the instance already has a dimension, or dimension options, with the name
+func (m *Mongo) dimensionNameExists(s *mgo.Session, instanceID string, dimensions []models.Dimension, name string) (bool, error) {
+	for _, dimension := range dimensions {
+		if dimension.Name == name {
+			return true, nil
+		}
+	}
+
+	count, err := s.DB(m.Database).C(dimensionOptions).Find(bson.M{"instance_id": instanceID, "name": name}).Count()
+	return count > 0, err
+}
+
+// revertRenameDimension gives the dimension of the instance, and any of its options already renamed, back the old
+// name. No options had the new name before the rename, so every option with it is moved back.
+func (m *Mongo) revertRenameDimension(s *mgo.Session, instanceID, oldName, newName string, headers *[]string) error {
+	if headers != nil {
+		renamed := models.RenameHeadersDimension(*headers, oldName, newName)
+		headers = &renamed
+	}
+
+	instanceSelector, instanceUpdate := createRenameInstanceDimensionQuery(instanceID, newName, oldName, headers)
+	if err := s.DB(m.Database).C(instanceCollection).Update(instanceSelector, instanceUpdate); err != nil {
+		return err
+	}
+
+	optionSelector, optionUpdate := createRenameDimensionOptionsQuery(instanceID, newName, oldName)
+	_, err := s.DB(m.Database).C(dimensionOptions).UpdateAll(optionSelector, optionUpdate)
+	return err
+}
+
+func createRenameDimensionOptionsQuery(instanceID, oldName, newName string) (bson.M, bson.M) {
+	selector := bson.M{"instance_id": instanceID, "name": oldName}
+	update := bson.M{"$set": bson.M{"name": newName, "last_updated": time.Now().UTC()}}
+
+	return selector, update
+}
+
+// createRenameInstanceDimensionQuery renames the dimension of an unpublished instance and its label column in the
+// headers read from the instance, the headers only being rewritten while they are unchanged since they were read
+func createRenameInstanceDimensionQuery(instanceID, oldName, newName string, headers *[]string) (bson.M, bson.M) {
+	selector := bson.M{
+		"id":              instanceID,
+		"state":           bson.M{"$ne": models.PublishedState},
+		"dimensions.name": oldName,
+	}
+
+	set := bson.M{
+		"dimensions.$.name": newName,
+		"last_updated":      time.Now().UTC(),
+	}
+
+	if headers != nil {
+		selector["headers"] = *headers
+		set["headers"] = models.RenameHeadersDimension(*headers, oldName, newName)
+	}
+
+	return selector, bson.M{"$set": set}
+}
+
 // GetDimensions returns a list of all dimensions from a dataset
 func (m *Mongo) GetDimensions(datasetID, versionID string) ([]bson.M, error) {
-	s := m.Session.Copy()
+	s := m.readSession()
 	defer s.Close()
 
 	// To get all unique values an aggregation is needed, as using distinct() will only return the distinct values and
@@ -85,13 +287,14 @@ func (m *Mongo) GetDimensions(datasetID, versionID string) ([]bson.M, error) {
 	return results, nil
 }
 
-// GetDimensionOptions returns all dimension options for a dimensions within a dataset.
+// GetDimensionOptions returns all dimension options for a dimensions within a dataset, sorted by the order given to
+// the options of an ordinal dimension and otherwise by option code.
 func (m *Mongo) GetDimensionOptions(version *models.Version, dimension string) (*models.DimensionOptionResults, error) {
-	s := m.Session.Copy()
+	s := m.readSession()
 	defer s.Close()
 
 	var values []models.PublicDimensionOption
-	iter := s.DB(m.Database).C(dimensionOptions).Find(bson.M{"instance_id": version.ID, "name": dimension}).Iter()
+	iter := s.DB(m.Database).C(dimensionOptions).Find(bson.M{"instance_id": version.ID, "name": dimension}).Sort("order", "option").Iter()
 	if err := iter.All(&values); err != nil {
 		return nil, err
 	}
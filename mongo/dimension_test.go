@@ -0,0 +1,289 @@
+package mongo
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRenameDimensionQueries(t *testing.T) {
+	t.Parallel()
+	Convey("When a dimension is renamed", t, func() {
+		optionSelector, optionUpdate := createRenameDimensionOptionsQuery("123", "geography", "geography-2018")
+		instanceSelector, instanceUpdate := createRenameInstanceDimensionQuery("123", "geography", "geography-2018", nil)
+
+		Convey("Then every option of the dimension is renamed", func() {
+			So(optionSelector, ShouldResemble, bson.M{"instance_id": "123", "name": "geography"})
+
+			set := optionUpdate["$set"].(bson.M)
+			So(set["name"], ShouldEqual, "geography-2018")
+			So(set["last_updated"], ShouldHaveSameTypeAs, time.Time{})
+		})
+
+		Convey("Then only the matching dimension of an unpublished instance is renamed", func() {
+			So(instanceSelector, ShouldResemble, bson.M{
+				"id":              "123",
+				"state":           bson.M{"$ne": models.PublishedState},
+				"dimensions.name": "geography",
+			})
+
+			set := instanceUpdate["$set"].(bson.M)
+			So(set["dimensions.$.name"], ShouldEqual, "geography-2018")
+			So(set["last_updated"], ShouldHaveSameTypeAs, time.Time{})
+			So(set, ShouldNotContainKey, "headers")
+		})
+	})
+
+	Convey("When a dimension of an instance with headers is renamed", t, func() {
+		headers := []string{"V4_1", "data_marking", "uk-only", "Geography", "time", "time"}
+		instanceSelector, instanceUpdate := createRenameInstanceDimensionQuery("123", "geography", "geography-2018", &headers)
+
+		Convey("Then the label column of the dimension is renamed while the headers are unchanged", func() {
+			So(instanceSelector["headers"], ShouldResemble, headers)
+
+			set := instanceUpdate["$set"].(bson.M)
+			So(set["headers"], ShouldResemble, []string{"V4_1", "data_marking", "uk-only", "geography-2018", "time", "time"})
+		})
+	})
+}
+
+// TestRenameDimension requires a running MongoDB instance, the address of which
+// is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestRenameDimension(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given an instance with a dimension and its options", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-rename-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		instance := &models.Instance{
+			InstanceID: "123",
+			State:      models.CompletedState,
+			Dimensions: []models.Dimension{{Name: "age"}, {Name: "geography"}},
+			Headers:    &[]string{"V4_0", "age-list", "age", "uk-only", "geography"},
+		}
+		So(session.DB(m.Database).C(instanceCollection).Insert(instance), ShouldBeNil)
+
+		for _, option := range []string{"K02000001", "W92000004"} {
+			err := session.DB(m.Database).C(dimensionOptions).Insert(&models.DimensionOption{InstanceID: "123", Name: "geography", Option: option})
+			So(err, ShouldBeNil)
+		}
+
+		Convey("When the dimension is renamed", func() {
+			err := m.RenameDimension("123", "geography", "geography-2018")
+			So(err, ShouldBeNil)
+
+			Convey("Then the instance dimension is renamed", func() {
+				updated, err := m.GetInstance("123")
+				So(err, ShouldBeNil)
+				So(updated.Dimensions[0].Name, ShouldEqual, "age")
+				So(updated.Dimensions[1].Name, ShouldEqual, "geography-2018")
+				So(*updated.Headers, ShouldResemble, []string{"V4_0", "age-list", "age", "uk-only", "geography-2018"})
+			})
+
+			Convey("Then every option is moved to the new name", func() {
+				values, err := m.GetUniqueDimensionAndOptions("123", "geography-2018")
+				So(err, ShouldBeNil)
+				So(values.Options, ShouldHaveLength, 2)
+
+				_, err = m.GetUniqueDimensionAndOptions("123", "geography")
+				So(err, ShouldEqual, errs.ErrDimensionNodeNotFound)
+			})
+		})
+
+		Convey("When a dimension is renamed to the name of a dimension with the same options", func() {
+			err := session.DB(m.Database).C(dimensionOptions).Insert(&models.DimensionOption{InstanceID: "123", Name: "age", Option: "K02000001"})
+			So(err, ShouldBeNil)
+
+			err = m.RenameDimension("123", "geography", "age")
+
+			Convey("Then a dimension name exists error is returned", func() {
+				So(err, ShouldEqual, errs.ErrDimensionNameExists)
+			})
+		})
+
+		Convey("When a dimension which is not on the instance is renamed", func() {
+			err := m.RenameDimension("123", "sex", "gender")
+
+			Convey("Then a dimension not found error is returned", func() {
+				So(err, ShouldEqual, errs.ErrDimensionNotFound)
+			})
+		})
+
+		Convey("When the dimension of an instance published since it was read is renamed", func() {
+			err := session.DB(m.Database).C(instanceCollection).Update(bson.M{"id": "123"}, bson.M{"$set": bson.M{"state": models.PublishedState}})
+			So(err, ShouldBeNil)
+
+			err = m.RenameDimension("123", "geography", "geography-2018")
+
+			Convey("Then a dimension not found error is returned and the options keep the old name", func() {
+				So(err, ShouldEqual, errs.ErrDimensionNotFound)
+
+				values, err := m.GetUniqueDimensionAndOptions("123", "geography")
+				So(err, ShouldBeNil)
+				So(values.Options, ShouldHaveLength, 2)
+
+				_, err = m.GetUniqueDimensionAndOptions("123", "geography-2018")
+				So(err, ShouldEqual, errs.ErrDimensionNodeNotFound)
+			})
+		})
+	})
+}
+
+func TestDimensionOptionUpdateQuery(t *testing.T) {
+	t.Parallel()
+	Convey("When only the label of an option is updated then only the label is set", t, func() {
+		selector, update := createDimensionOptionUpdateQuery("123", "age", "55", &models.DimensionOptionUpdate{Label: "55 years"})
+		So(selector, ShouldResemble, bson.M{"instance_id": "123", "name": "age", "option": "55"})
+
+		set := update["$set"].(bson.M)
+		So(set, ShouldHaveLength, 2)
+		So(set["label"], ShouldEqual, "55 years")
+		So(set["last_updated"], ShouldHaveSameTypeAs, time.Time{})
+		So(set, ShouldNotContainKey, "node_id")
+	})
+
+	Convey("When the links of an option are updated then only the given link fields are set", t, func() {
+		links := &models.DimensionOptionLinks{
+			Code:     models.LinkObject{ID: "55", HRef: "http://localhost:22400/code-lists/age/codes/55"},
+			CodeList: models.LinkObject{ID: "age"},
+		}
+		_, update := createDimensionOptionUpdateQuery("123", "age", "55", &models.DimensionOptionUpdate{Links: links})
+
+		set := update["$set"].(bson.M)
+		So(set, ShouldHaveLength, 4)
+		So(set["links.code.id"], ShouldEqual, "55")
+		So(set["links.code.href"], ShouldEqual, "http://localhost:22400/code-lists/age/codes/55")
+		So(set["links.code_list.id"], ShouldEqual, "age")
+		So(set, ShouldNotContainKey, "label")
+	})
+}
+
+// TestUpdateDimensionOption requires a running MongoDB instance, the address of which
+// is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestUpdateDimensionOption(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given an option of a dimension which has been assigned a node_id", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-option-update-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		option := &models.DimensionOption{InstanceID: "123", Name: "age", Option: "55", Label: "55", NodeID: "node-55"}
+		So(session.DB(m.Database).C(dimensionOptions).Insert(option), ShouldBeNil)
+
+		Convey("When the label of the option is updated", func() {
+			err := m.UpdateDimensionOption("123", "age", "55", &models.DimensionOptionUpdate{Label: "55 years"})
+			So(err, ShouldBeNil)
+
+			Convey("Then the label is changed and the node_id is left intact", func() {
+				var updated models.DimensionOption
+				err := session.DB(m.Database).C(dimensionOptions).Find(bson.M{"instance_id": "123", "name": "age", "option": "55"}).One(&updated)
+				So(err, ShouldBeNil)
+				So(updated.Label, ShouldEqual, "55 years")
+				So(updated.NodeID, ShouldEqual, "node-55")
+			})
+		})
+
+		Convey("When an option which does not exist is updated", func() {
+			err := m.UpdateDimensionOption("123", "age", "56", &models.DimensionOptionUpdate{Label: "56 years"})
+
+			Convey("Then a dimension option not found error is returned", func() {
+				So(err, ShouldEqual, errs.ErrDimensionOptionNotFound)
+			})
+		})
+	})
+}
+
+func TestDimensionOptionCountsPipeline(t *testing.T) {
+	t.Parallel()
+	Convey("When the options of an instance's dimensions are counted", t, func() {
+		pipeline := dimensionOptionCountsPipeline("123")
+
+		Convey("Then only the instance's options are grouped and counted by dimension name", func() {
+			So(pipeline, ShouldResemble, []bson.M{
+				{"$match": bson.M{"instance_id": "123"}},
+				{"$group": bson.M{"_id": "$name", "option_count": bson.M{"$sum": 1}}},
+				{"$sort": bson.M{"_id": 1}},
+			})
+		})
+	})
+}
+
+func TestBuildDimensionOptionsByCodesQuery(t *testing.T) {
+	t.Parallel()
+	Convey("When the options of a dimension are selected by code", t, func() {
+		query := buildDimensionOptionsByCodesQuery("123", "age", []string{"30", "40"})
+
+		Convey("Then only the options of the instance's dimension with one of the codes are matched", func() {
+			So(query, ShouldResemble, bson.M{"instance_id": "123", "name": "age", "option": bson.M{"$in": []string{"30", "40"}}})
+		})
+	})
+}
+
+// TestGetDimensionOptionCounts requires a running MongoDB instance, the address of which
+// is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestGetDimensionOptionCounts(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given an instance with two dimensions and their options", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-option-counts-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		options := []*models.DimensionOption{
+			{InstanceID: "123", Name: "geography", Option: "K02000001"},
+			{InstanceID: "123", Name: "geography", Option: "W92000004"},
+			{InstanceID: "123", Name: "age", Option: "0-15"},
+			{InstanceID: "123", Name: "age", Option: "16-64"},
+			{InstanceID: "123", Name: "age", Option: "65+"},
+			{InstanceID: "456", Name: "geography", Option: "K02000001"},
+		}
+		for _, option := range options {
+			So(session.DB(m.Database).C(dimensionOptions).Insert(option), ShouldBeNil)
+		}
+
+		Convey("When the options are counted then each dimension has the number of options loaded for it", func() {
+			counts, err := m.GetDimensionOptionCounts("123")
+			So(err, ShouldBeNil)
+			So(counts, ShouldResemble, []models.DimensionOptionCount{
+				{Name: "age", OptionCount: 3},
+				{Name: "geography", OptionCount: 2},
+			})
+		})
+	})
+}
@@ -0,0 +1,87 @@
+package mongo
+
+import (
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/ONSdigital/go-ns/log"
+)
+
+// collectionIndex represents an index required on a collection
+type collectionIndex struct {
+	collection string
+	index      mgo.Index
+}
+
+// requiredIndexes lists the indexes needed by the queries made against each
+// collection, see buildEditionQuery, buildVersionQuery, GetInstances, GetInstanceByIdempotencyKey,
+// GetAuditEvents and StreamDimensionOptions.
+// The edition and version indexes are named as they share their keys with the indexes created before
+// edition lookups were case-insensitive, a query can only use an index created with its collation.
+// The version sequence index keeps each version number of an edition unique. It only covers instances
+// given a version number, so it cannot serve the version queries and is keyed in a different order to
+// the version index they use.
+var requiredIndexes = []collectionIndex{
+	{
+		collection: editionsCollection,
+		index:      mgo.Index{Key: []string{"next.links.dataset.id", "next.edition"}, Name: "next_edition_ci", Collation: editionCollation, Background: true},
+	},
+	{
+		collection: editionsCollection,
+		index:      mgo.Index{Key: []string{"current.links.dataset.id", "current.edition", "current.state"}, Name: "current_edition_ci", Collation: editionCollation, Background: true},
+	},
+	{
+		collection: instanceCollection,
+		index:      mgo.Index{Key: []string{"links.dataset.id", "edition", "version"}, Name: "version_edition_ci", Collation: editionCollation, Background: true},
+	},
+	{
+		collection: instanceCollection,
+		index: mgo.Index{
+			Key:           []string{"edition", "links.dataset.id", "version"},
+			Name:          "version_sequence_ci",
+			Collation:     editionCollation,
+			Unique:        true,
+			PartialFilter: bson.M{"version": bson.M{"$exists": true}},
+			Background:    true,
+		},
+	},
+	{
+		collection: instanceCollection,
+		index:      mgo.Index{Key: []string{"state"}, Background: true},
+	},
+	{
+		collection: instanceCollection,
+		index:      mgo.Index{Key: []string{"links.is_based_on.id"}, Sparse: true, Background: true},
+	},
+	{
+		collection: instanceCollection,
+		index:      mgo.Index{Key: []string{"idempotency_key"}, Unique: true, Sparse: true, Background: true},
+	},
+	{
+		collection: auditEventsCollection,
+		index:      mgo.Index{Key: []string{"instance_id", "created"}, Background: true},
+	},
+	{
+		collection: auditEventsCollection,
+		index:      mgo.Index{Key: []string{"created"}, ExpireAfter: auditEventsRetention, Background: true},
+	},
+	{
+		collection: dimensionOptions,
+		index:      mgo.Index{Key: []string{"instance_id", "name", "option"}, Background: true},
+	},
+}
+
+// ensureIndexes creates any of the required indexes which do not already exist,
+// a failure is logged rather than returned as the service can run without them
+func (m *Mongo) ensureIndexes(session *mgo.Session) {
+	for _, required := range requiredIndexes {
+		logData := log.Data{"collection": required.collection, "index": required.index.Key}
+
+		if err := session.DB(m.Database).C(required.collection).EnsureIndex(required.index); err != nil {
+			log.ErrorC("failed to ensure index", err, logData)
+			continue
+		}
+
+		log.Debug("ensured index", logData)
+	}
+}
@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"os"
+	"testing"
+
+	"github.com/globalsign/mgo"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// TestEnsureIndexes requires a running MongoDB instance, the address of which
+// is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestEnsureIndexes(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given a connection to an empty database", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-index-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		Convey("When the indexes are ensured a second time", func() {
+			m.ensureIndexes(session)
+
+			Convey("Then each required index exists once", func() {
+				for _, required := range requiredIndexes {
+					indexes, err := session.DB(m.Database).C(required.collection).Indexes()
+					So(err, ShouldBeNil)
+					So(countIndexes(indexes, required.index.Key), ShouldEqual, 1)
+				}
+			})
+		})
+	})
+}
+
+func countIndexes(indexes []mgo.Index, key []string) int {
+	count := 0
+	for _, index := range indexes {
+		if len(index.Key) != len(key) {
+			continue
+		}
+
+		matches := true
+		for i := range key {
+			if index.Key[i] != key[i] {
+				matches = false
+			}
+		}
+
+		if matches {
+			count++
+		}
+	}
+	return count
+}
@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"math/rand"
 	"time"
 
 	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
@@ -15,24 +16,17 @@ import (
 const instanceCollection = "instances"
 
 // GetInstances from a mongo collection
-func (m *Mongo) GetInstances(states []string, datasets []string) (*models.InstanceResults, error) {
+func (m *Mongo) GetInstances(states []string, datasets []string, isBasedOn []string) (*models.InstanceResults, error) {
 	s := m.Session.Copy()
 	defer s.Close()
 
-	filter := bson.M{}
-	if len(states) > 0 {
-		filter["state"] = bson.M{"$in": states}
-	}
-
-	if len(datasets) > 0 {
-		filter["links.dataset.id"] = bson.M{"$in": datasets}
-	}
+	filter := buildInstancesQuery(states, datasets, isBasedOn)
 
 	iter := s.DB(m.Database).C(instanceCollection).Find(filter).Sort("-$natural").Iter()
 	defer func() {
 		err := iter.Close()
 		if err != nil {
-			log.ErrorC("error closing iterator", err, log.Data{"state_query": states, "dataset_query": datasets})
+			log.ErrorC("error closing iterator", err, log.Data{"state_query": states, "dataset_query": datasets, "is_based_on_query": isBasedOn})
 		}
 	}()
 
@@ -47,6 +41,52 @@ func (m *Mongo) GetInstances(states []string, datasets []string) (*models.Instan
 	return &models.InstanceResults{Items: results}, nil
 }
 
+// StreamInstances calls fn with each instance matching the filters in turn, reading the instances from the
+// collection as they are needed rather than loading them all at once. Iteration stops at the first error from fn.
+func (m *Mongo) StreamInstances(states []string, datasets []string, isBasedOn []string, fn func(*models.Instance) error) error {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	filter := buildInstancesQuery(states, datasets, isBasedOn)
+
+	iter := s.DB(m.Database).C(instanceCollection).Find(filter).Sort("-$natural").Iter()
+	for {
+		// decode into a new instance each time, as fields missing from a document are not reset by Next
+		var instance models.Instance
+		if !iter.Next(&instance) {
+			break
+		}
+
+		if err := fn(&instance); err != nil {
+			if closeErr := iter.Close(); closeErr != nil {
+				log.ErrorC("error closing iterator", closeErr, log.Data{"state_query": states, "dataset_query": datasets, "is_based_on_query": isBasedOn})
+			}
+			return err
+		}
+	}
+
+	return iter.Close()
+}
+
+// buildInstancesQuery matches the instances in any of the states, of any of the datasets and based on any of the
+// parent datasets, a filter which is empty is not applied
+func buildInstancesQuery(states, datasets, isBasedOn []string) bson.M {
+	filter := bson.M{}
+	if len(states) > 0 {
+		filter["state"] = bson.M{"$in": states}
+	}
+
+	if len(datasets) > 0 {
+		filter["links.dataset.id"] = bson.M{"$in": datasets}
+	}
+
+	if len(isBasedOn) > 0 {
+		filter["links.is_based_on.id"] = bson.M{"$in": isBasedOn}
+	}
+
+	return filter
+}
+
 // GetInstance returns a single instance from an ID
 func (m *Mongo) GetInstance(ID string) (*models.Instance, error) {
 	s := m.Session.Copy()
@@ -62,6 +102,42 @@ func (m *Mongo) GetInstance(ID string) (*models.Instance, error) {
 	return &instance, err
 }
 
+// GetInstanceByIdempotencyKey returns the instance created by a request with the given idempotency key
+func (m *Mongo) GetInstanceByIdempotencyKey(key string) (*models.Instance, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	var instance models.Instance
+	err := s.DB(m.Database).C(instanceCollection).Find(bson.M{"idempotency_key": key}).One(&instance)
+
+	if err == mgo.ErrNotFound {
+		return nil, errs.ErrInstanceNotFound
+	}
+
+	return &instance, err
+}
+
+// GetInstanceStateCounts returns the number of instances in each state, without reading the instances themselves
+func (m *Mongo) GetInstanceStateCounts() ([]models.InstanceStateCount, error) {
+	s := m.readSession()
+	defer s.Close()
+
+	counts := []models.InstanceStateCount{}
+	if err := s.DB(m.Database).C(instanceCollection).Pipe(instanceStateCountsPipeline()).All(&counts); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// instanceStateCountsPipeline groups every instance by state, counting the instances in each
+func instanceStateCountsPipeline() []bson.M {
+	return []bson.M{
+		{"$group": bson.M{"_id": "$state", "count": bson.M{"$sum": 1}}},
+		{"$sort": bson.M{"_id": 1}},
+	}
+}
+
 // AddInstance to the instance collection
 func (m *Mongo) AddInstance(instance *models.Instance) (*models.Instance, error) {
 	s := m.Session.Copy()
@@ -86,14 +162,28 @@ func (m *Mongo) UpdateInstance(ctx context.Context, instanceID string, instance
 
 	instance.LastUpdated = time.Now().UTC()
 
+	uniqueTimestamp, err := newUniqueTimestamp(instance.LastUpdated)
+	if err != nil {
+		return err
+	}
+
 	updates := createInstanceUpdateQuery(ctx, instanceID, instance)
-	update := bson.M{"$set": updates}
-	updateWithTimestamps, err := mongo.WithUpdates(update)
+	updates[mongo.UniqueTimestampKey] = uniqueTimestamp
+	updateWithTimestamps, err := mongo.WithLastUpdatedUpdate(bson.M{"$set": updates})
 	if err != nil {
 		return err
 	}
 
-	if err = s.DB(m.Database).C(instanceCollection).Update(bson.M{"id": instanceID, mongo.UniqueTimestampKey: instance.UniqueTimestamp}, updateWithTimestamps); err != nil {
+	selector := updateInstanceSelector(instanceID, instance.UniqueTimestamp, uniqueTimestamp)
+	err = m.retryWrite(s, func() error {
+		return s.DB(m.Database).C(instanceCollection).Update(selector, updateWithTimestamps)
+	})
+	if err != nil {
+		if mgo.IsDup(err) {
+			// the unique version index refuses a version number already held by another instance of the edition
+			return errs.ErrVersionOutOfSequence
+		}
+
 		if err != mgo.ErrNotFound {
 			return err
 		}
@@ -104,6 +194,23 @@ func (m *Mongo) UpdateInstance(ctx context.Context, instanceID string, instance
 	return nil
 }
 
+// newUniqueTimestamp creates the unique timestamp set by an update to an instance. It is made here rather than by the
+// database so that the update is known to have been applied when the instance carries it, the increment is random so
+// concurrent updates made in the same second are still given different timestamps.
+func newUniqueTimestamp(t time.Time) (bson.MongoTimestamp, error) {
+	return bson.NewMongoTimestamp(t, rand.Uint32())
+}
+
+// updateInstanceSelector selects the instance while it has the unique timestamp the update was made against, so an
+// update made by someone else since is not overwritten, or the unique timestamp set by the update, so a retried update
+// still matches once an earlier attempt has been applied
+func updateInstanceSelector(instanceID string, current, updated bson.MongoTimestamp) bson.M {
+	return bson.M{
+		"id":                     instanceID,
+		mongo.UniqueTimestampKey: bson.M{"$in": []bson.MongoTimestamp{current, updated}},
+	}
+}
+
 func createInstanceUpdateQuery(ctx context.Context, instanceID string, instance *models.Instance) bson.M {
 	updates := make(bson.M)
 
@@ -127,6 +234,10 @@ func createInstanceUpdateQuery(ctx context.Context, instanceID string, instance
 		updates["dimensions"] = instance.Dimensions
 	}
 
+	if instance.DimensionOrder != nil {
+		updates["dimension_order"] = instance.DimensionOrder
+	}
+
 	if instance.Downloads != nil {
 		if instance.Downloads.CSV != nil {
 			if instance.Downloads.CSV.HRef != "" {
@@ -163,6 +274,18 @@ func createInstanceUpdateQuery(ctx context.Context, instanceID string, instance
 		updates["edition"] = instance.Edition
 	}
 
+	if instance.FailureReason != "" {
+		updates["failure_reason"] = instance.FailureReason
+	}
+
+	if instance.HasConfidenceIntervals != nil {
+		updates["has_confidence_intervals"] = instance.HasConfidenceIntervals
+	}
+
+	if instance.HasDataMarkings != nil {
+		updates["has_data_markings"] = instance.HasDataMarkings
+	}
+
 	if instance.Headers != nil && instance.Headers != &[]string{""} {
 		updates["headers"] = instance.Headers
 	}
@@ -233,6 +356,123 @@ func createInstanceUpdateQuery(ctx context.Context, instanceID string, instance
 	return updates
 }
 
+// ResetInstance moves an instance back to an earlier state so the import can be redone. An edition-confirmed
+// instance is moved back to completed and a failed instance back to created, in both cases removing the edition
+// and version details added when the edition was confirmed
+func (m *Mongo) ResetInstance(ctx context.Context, instanceID, currentState string, uniqueTimestamp bson.MongoTimestamp) error {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	selector := bson.M{
+		"id":                     instanceID,
+		"state":                  currentState,
+		mongo.UniqueTimestampKey: uniqueTimestamp,
+	}
+
+	updateWithTimestamps, err := mongo.WithUpdates(createInstanceResetQuery(currentState))
+	if err != nil {
+		return err
+	}
+
+	log.InfoCtx(ctx, "resetting instance resource", log.Data{"instance_id": instanceID, "updates": updateWithTimestamps})
+
+	if err = s.DB(m.Database).C(instanceCollection).Update(selector, updateWithTimestamps); err != nil {
+		if err != mgo.ErrNotFound {
+			return err
+		}
+
+		return errs.ErrConflictUpdatingInstance
+	}
+
+	return nil
+}
+
+func createInstanceResetQuery(currentState string) bson.M {
+	if currentState == models.FailedState {
+		return bson.M{
+			"$set": bson.M{
+				"state": models.CreatedState,
+			},
+			"$unset": bson.M{
+				"collection_id":  "",
+				"edition":        "",
+				"failure_reason": "",
+				"version":        "",
+				"links.edition":  "",
+				"links.version":  "",
+			},
+		}
+	}
+
+	return bson.M{
+		"$set": bson.M{
+			"state": models.CompletedState,
+		},
+		"$unset": bson.M{
+			"collection_id": "",
+			"edition":       "",
+			"version":       "",
+			"links.edition": "",
+			"links.version": "",
+		},
+	}
+}
+
+// PurgeInstances removes instances in the given states, or the default purge states when none
+// are given, which have not been updated since the cutoff, returning the number removed. The
+// dimension options of each instance are removed with it. Instances in use by a dataset are
+// never removed.
+func (m *Mongo) PurgeInstances(olderThan time.Time, states []string) (int, error) {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	selector := createInstancePurgeQuery(olderThan, states)
+
+	var ids []string
+	if err := s.DB(m.Database).C(instanceCollection).Find(selector).Distinct("id", &ids); err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, id := range ids {
+		// the instance is only removed if it still matches, in case it was updated after it was found
+		err := s.DB(m.Database).C(instanceCollection).Remove(createInstancePurgeByIDQuery(id, selector))
+		if err == mgo.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return removed, err
+		}
+		removed++
+
+		if _, err = s.DB(m.Database).C(dimensionOptions).RemoveAll(bson.M{"instance_id": id}); err != nil {
+			return removed, err
+		}
+	}
+
+	log.Info("purged instances", log.Data{"selector": selector, "removed": removed})
+	return removed, nil
+}
+
+func createInstancePurgeQuery(olderThan time.Time, states []string) bson.M {
+	if len(states) == 0 {
+		states = models.DefaultPurgeStates
+	}
+
+	return bson.M{
+		"state":        bson.M{"$in": states, "$nin": models.InUseStates()},
+		"last_updated": bson.M{"$lt": olderThan},
+	}
+}
+
+func createInstancePurgeByIDQuery(id string, selector bson.M) bson.M {
+	query := bson.M{"id": id}
+	for k, v := range selector {
+		query[k] = v
+	}
+	return query
+}
+
 // AddEventToInstance to the instance collection
 func (m *Mongo) AddEventToInstance(instanceID string, event *models.Event) error {
 	s := m.Session.Copy()
@@ -256,8 +496,10 @@ func (m *Mongo) UpdateDimensionNodeID(dimension *models.DimensionOption) error {
 	s := m.Session.Copy()
 	defer s.Close()
 
-	err := s.DB(m.Database).C(dimensionOptions).Update(bson.M{"instance_id": dimension.InstanceID, "name": dimension.Name,
-		"option": dimension.Option}, bson.M{"$set": bson.M{"node_id": &dimension.NodeID, "last_updated": time.Now().UTC()}})
+	err := m.retryWrite(s, func() error {
+		return s.DB(m.Database).C(dimensionOptions).Update(bson.M{"instance_id": dimension.InstanceID, "name": dimension.Name,
+			"option": dimension.Option}, bson.M{"$set": bson.M{"node_id": &dimension.NodeID, "last_updated": time.Now().UTC()}})
+	})
 	if err == mgo.ErrNotFound {
 		return errs.ErrDimensionOptionNotFound
 	}
@@ -269,40 +511,89 @@ func (m *Mongo) UpdateDimensionNodeID(dimension *models.DimensionOption) error {
 	return nil
 }
 
-// UpdateObservationInserted by incrementing the stored value
-func (m *Mongo) UpdateObservationInserted(id string, observationInserted int64) error {
+// UpdateDimensionOption sets the label and links of a dimension option given in the update, leaving the other fields
+// of the option, such as its node_id, unchanged
+func (m *Mongo) UpdateDimensionOption(instanceID, dimension, option string, update *models.DimensionOptionUpdate) error {
+	s := m.Session.Copy()
+	defer s.Close()
+
+	selector, set := createDimensionOptionUpdateQuery(instanceID, dimension, option, update)
+	err := m.retryWrite(s, func() error {
+		return s.DB(m.Database).C(dimensionOptions).Update(selector, set)
+	})
+	if err == mgo.ErrNotFound {
+		return errs.ErrDimensionOptionNotFound
+	}
+
+	return err
+}
+
+func createDimensionOptionUpdateQuery(instanceID, dimension, option string, update *models.DimensionOptionUpdate) (bson.M, bson.M) {
+	selector := bson.M{"instance_id": instanceID, "name": dimension, "option": option}
+
+	setUpdates := bson.M{"last_updated": time.Now().UTC()}
+	if update.Label != "" {
+		setUpdates["label"] = update.Label
+	}
+
+	if update.Links != nil {
+		links := map[string]models.LinkObject{
+			"code":      update.Links.Code,
+			"code_list": update.Links.CodeList,
+			"version":   update.Links.Version,
+		}
+		for name, link := range links {
+			if link.ID != "" {
+				setUpdates["links."+name+".id"] = link.ID
+			}
+			if link.HRef != "" {
+				setUpdates["links."+name+".href"] = link.HRef
+			}
+		}
+	}
+
+	return selector, bson.M{"$set": setUpdates}
+}
+
+// UpdateObservationInserted by incrementing the stored value, the increment is atomic so concurrent importers
+// reporting their progress never overwrite each other's counts. The import observations task is returned as it was
+// left by this increment.
+func (m *Mongo) UpdateObservationInserted(id string, observationInserted int64) (*models.ImportObservationsTask, error) {
 	s := m.Session.Copy()
 	defer s.Close()
 
-	err := s.DB(m.Database).C(instanceCollection).Update(bson.M{"id": id},
-		bson.M{
+	change := mgo.Change{
+		Update: bson.M{
 			"$inc": bson.M{"import_tasks.import_observations.total_inserted_observations": observationInserted},
 			"$set": bson.M{"last_updated": time.Now().UTC()},
 		},
-	)
+		ReturnNew: true,
+	}
+
+	var instance models.Instance
+	_, err := s.DB(m.Database).C(instanceCollection).Find(bson.M{"id": id}).Select(bson.M{"import_tasks.import_observations": 1}).Apply(change, &instance)
 
 	if err == mgo.ErrNotFound {
-		return errs.ErrInstanceNotFound
+		return nil, errs.ErrInstanceNotFound
 	}
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return instance.ImportTasks.ImportObservations, nil
 }
 
-// UpdateImportObservationsTaskState to the given state.
-func (m *Mongo) UpdateImportObservationsTaskState(id string, state string) error {
+// UpdateImportObservationsTaskState to the given state, recording the reason when one is given.
+func (m *Mongo) UpdateImportObservationsTaskState(id, state, reason string) error {
 	s := m.Session.Copy()
 	defer s.Close()
 
-	err := s.DB(m.Database).C(instanceCollection).Update(bson.M{"id": id},
-		bson.M{
-			"$set":         bson.M{"import_tasks.import_observations.state": state},
-			"$currentDate": bson.M{"last_updated": true},
-		},
-	)
+	err := m.retryWrite(s, func() error {
+		return s.DB(m.Database).C(instanceCollection).Update(bson.M{"id": id},
+			createTaskStateUpdate("import_tasks.import_observations.", state, reason),
+		)
+	})
 
 	if err == mgo.ErrNotFound {
 		return errs.ErrInstanceNotFound
@@ -315,8 +606,8 @@ func (m *Mongo) UpdateImportObservationsTaskState(id string, state string) error
 	return nil
 }
 
-// UpdateBuildHierarchyTaskState updates the state of a build hierarchy task.
-func (m *Mongo) UpdateBuildHierarchyTaskState(id, dimension, state string) (err error) {
+// UpdateBuildHierarchyTaskState updates the state of a build hierarchy task, recording the reason when one is given.
+func (m *Mongo) UpdateBuildHierarchyTaskState(id, dimension, state, reason string) (err error) {
 	s := m.Session.Copy()
 	defer s.Close()
 
@@ -325,17 +616,16 @@ func (m *Mongo) UpdateBuildHierarchyTaskState(id, dimension, state string) (err
 		"import_tasks.build_hierarchies.dimension_name": dimension,
 	}
 
-	update := bson.M{
-		"$set":         bson.M{"import_tasks.build_hierarchies.$.state": state},
-		"$currentDate": bson.M{"last_updated": true},
-	}
+	update := createTaskStateUpdate("import_tasks.build_hierarchies.$.", state, reason)
 
-	err = s.DB(m.Database).C(instanceCollection).Update(selector, update)
+	err = m.retryWrite(s, func() error {
+		return s.DB(m.Database).C(instanceCollection).Update(selector, update)
+	})
 	return
 }
 
-// UpdateBuildSearchTaskState updates the state of a build search task.
-func (m *Mongo) UpdateBuildSearchTaskState(id, dimension, state string) (err error) {
+// UpdateBuildSearchTaskState updates the state of a build search task, recording the reason when one is given.
+func (m *Mongo) UpdateBuildSearchTaskState(id, dimension, state, reason string) (err error) {
 	s := m.Session.Copy()
 	defer s.Close()
 
@@ -344,11 +634,28 @@ func (m *Mongo) UpdateBuildSearchTaskState(id, dimension, state string) (err err
 		"import_tasks.build_search_indexes.dimension_name": dimension,
 	}
 
+	update := createTaskStateUpdate("import_tasks.build_search_indexes.$.", state, reason)
+
+	err = m.retryWrite(s, func() error {
+		return s.DB(m.Database).C(instanceCollection).Update(selector, update)
+	})
+	return
+}
+
+// createTaskStateUpdate sets the state of the task at the given path, the reason from any earlier failure
+// is removed when the task is updated without one
+func createTaskStateUpdate(path, state, reason string) bson.M {
+	set := bson.M{path + "state": state}
 	update := bson.M{
-		"$set":         bson.M{"import_tasks.build_search_indexes.$.state": state},
+		"$set":         set,
 		"$currentDate": bson.M{"last_updated": true},
 	}
 
-	err = s.DB(m.Database).C(instanceCollection).Update(selector, update)
-	return
+	if reason != "" {
+		set[path+"reason"] = reason
+	} else {
+		update["$unset"] = bson.M{path + "reason": ""}
+	}
+
+	return update
 }
@@ -0,0 +1,382 @@
+package mongo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	errs "github.com/ONSdigital/dp-dataset-api/apierrors"
+	"github.com/ONSdigital/dp-dataset-api/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInstancesQuery(t *testing.T) {
+	Convey("When no filters are given then every instance is matched", t, func() {
+		So(buildInstancesQuery(nil, nil, nil), ShouldResemble, bson.M{})
+	})
+
+	Convey("When instances are filtered by state, dataset and parent dataset then each filter is applied", t, func() {
+		expectedSelector := bson.M{
+			"state":                bson.M{"$in": []string{models.CompletedState}},
+			"links.dataset.id":     bson.M{"$in": []string{"cpih01"}},
+			"links.is_based_on.id": bson.M{"$in": []string{"cpih01", "mid-year-pop-est"}},
+		}
+
+		So(buildInstancesQuery([]string{models.CompletedState}, []string{"cpih01"}, []string{"cpih01", "mid-year-pop-est"}), ShouldResemble, expectedSelector)
+	})
+}
+
+func TestUpdateInstanceSelector(t *testing.T) {
+	t.Parallel()
+	Convey("When an instance is updated then it is selected by either the current or the updated unique timestamp", t, func() {
+		current := bson.MongoTimestamp(6591600000000000001)
+		updated, err := newUniqueTimestamp(time.Now())
+		So(err, ShouldBeNil)
+
+		So(updateInstanceSelector("123", current, updated), ShouldResemble, bson.M{
+			"id":               "123",
+			"unique_timestamp": bson.M{"$in": []bson.MongoTimestamp{current, updated}},
+		})
+	})
+}
+
+func TestInstanceResetQuery(t *testing.T) {
+	Convey("When an instance is reset the state is set to completed and the version and collection details are removed", t, func() {
+		expectedUpdate := bson.M{
+			"$set": bson.M{
+				"state": models.CompletedState,
+			},
+			"$unset": bson.M{
+				"collection_id": "",
+				"edition":       "",
+				"version":       "",
+				"links.edition": "",
+				"links.version": "",
+			},
+		}
+
+		So(createInstanceResetQuery(models.EditionConfirmedState), ShouldResemble, expectedUpdate)
+	})
+
+	Convey("When a failed instance is reset the state is set to created and the failure reason is removed", t, func() {
+		expectedUpdate := bson.M{
+			"$set": bson.M{
+				"state": models.CreatedState,
+			},
+			"$unset": bson.M{
+				"collection_id":  "",
+				"edition":        "",
+				"failure_reason": "",
+				"version":        "",
+				"links.edition":  "",
+				"links.version":  "",
+			},
+		}
+
+		So(createInstanceResetQuery(models.FailedState), ShouldResemble, expectedUpdate)
+	})
+}
+
+func TestInstancePurgeQuery(t *testing.T) {
+	olderThan := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	inUse := []string{models.EditionConfirmedState, models.AssociatedState, models.PublishedState}
+
+	Convey("When instances are purged without a state filter only abandoned instances are selected", t, func() {
+		expectedSelector := bson.M{
+			"state":        bson.M{"$in": []string{models.CreatedState, models.SubmittedState, models.FailedState}, "$nin": inUse},
+			"last_updated": bson.M{"$lt": olderThan},
+		}
+
+		So(createInstancePurgeQuery(olderThan, nil), ShouldResemble, expectedSelector)
+	})
+
+	Convey("When instances are purged with a state filter instances in use are still excluded", t, func() {
+		states := []string{models.CompletedState, models.AssociatedState}
+		expectedSelector := bson.M{
+			"state":        bson.M{"$in": states, "$nin": inUse},
+			"last_updated": bson.M{"$lt": olderThan},
+		}
+
+		So(createInstancePurgeQuery(olderThan, states), ShouldResemble, expectedSelector)
+	})
+
+	Convey("When a single instance is purged it is selected by its id as well as the purge query", t, func() {
+		selector := createInstancePurgeQuery(olderThan, nil)
+
+		So(createInstancePurgeByIDQuery("123", selector), ShouldResemble, bson.M{
+			"id":           "123",
+			"state":        selector["state"],
+			"last_updated": selector["last_updated"],
+		})
+	})
+}
+
+func TestTaskStateUpdate(t *testing.T) {
+	Convey("When a task is failed with a reason the reason is set alongside the state", t, func() {
+		update := createTaskStateUpdate("import_tasks.build_hierarchies.$.", models.FailedState, "hierarchy builder timed out")
+
+		So(update["$set"], ShouldResemble, bson.M{
+			"import_tasks.build_hierarchies.$.state":  models.FailedState,
+			"import_tasks.build_hierarchies.$.reason": "hierarchy builder timed out",
+		})
+		So(update["$unset"], ShouldBeNil)
+		So(update["$currentDate"], ShouldResemble, bson.M{"last_updated": true})
+	})
+
+	Convey("When a task is updated without a reason any earlier reason is removed", t, func() {
+		update := createTaskStateUpdate("import_tasks.import_observations.", models.CompletedState, "")
+
+		So(update["$set"], ShouldResemble, bson.M{"import_tasks.import_observations.state": models.CompletedState})
+		So(update["$unset"], ShouldResemble, bson.M{"import_tasks.import_observations.reason": ""})
+	})
+}
+
+// TestPurgeInstances requires a running MongoDB instance, the address of which
+// is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestPurgeInstances(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given old instances in the created, edition-confirmed and published states", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-purge-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		lastUpdated := time.Now().UTC().Add(-48 * time.Hour)
+		states := map[string]string{"abandoned": models.CreatedState, "confirmed": models.EditionConfirmedState, "live": models.PublishedState}
+		for id, state := range states {
+			err := session.DB(m.Database).C(instanceCollection).Insert(&models.Instance{InstanceID: id, State: state, LastUpdated: lastUpdated})
+			So(err, ShouldBeNil)
+
+			err = session.DB(m.Database).C(dimensionOptions).Insert(&models.DimensionOption{InstanceID: id, Name: "geography", Option: "K02000001"})
+			So(err, ShouldBeNil)
+		}
+
+		Convey("When instances in the default states older than a day are purged", func() {
+			count, err := m.PurgeInstances(time.Now().UTC().Add(-24*time.Hour), nil)
+
+			Convey("Then the abandoned instance and its dimension options are removed and the instances in use survive", func() {
+				So(err, ShouldBeNil)
+				So(count, ShouldEqual, 1)
+
+				_, err = m.GetInstance("abandoned")
+				So(err, ShouldEqual, errs.ErrInstanceNotFound)
+
+				options, err := session.DB(m.Database).C(dimensionOptions).Find(bson.M{"instance_id": "abandoned"}).Count()
+				So(err, ShouldBeNil)
+				So(options, ShouldEqual, 0)
+
+				for _, id := range []string{"confirmed", "live"} {
+					instance, err := m.GetInstance(id)
+					So(err, ShouldBeNil)
+					So(instance.State, ShouldEqual, states[id])
+
+					options, err := session.DB(m.Database).C(dimensionOptions).Find(bson.M{"instance_id": id}).Count()
+					So(err, ShouldBeNil)
+					So(options, ShouldEqual, 1)
+				}
+			})
+		})
+	})
+}
+
+func TestInstanceStateCountsPipeline(t *testing.T) {
+	Convey("When instances are counted by state then every instance is grouped and counted by its state", t, func() {
+		So(instanceStateCountsPipeline(), ShouldResemble, []bson.M{
+			{"$group": bson.M{"_id": "$state", "count": bson.M{"$sum": 1}}},
+			{"$sort": bson.M{"_id": 1}},
+		})
+	})
+}
+
+// TestGetInstanceStateCounts requires a running MongoDB instance, the address of which
+// is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestGetInstanceStateCounts(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given instances in various states", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-instance-state-counts-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		states := map[string]string{
+			"1": models.CreatedState,
+			"2": models.CreatedState,
+			"3": models.SubmittedState,
+			"4": models.CompletedState,
+			"5": models.PublishedState,
+			"6": models.PublishedState,
+			"7": models.PublishedState,
+		}
+		for id, state := range states {
+			err := session.DB(m.Database).C(instanceCollection).Insert(&models.Instance{InstanceID: id, State: state})
+			So(err, ShouldBeNil)
+		}
+
+		Convey("When the instances are counted by state then each state has the number of instances in it", func() {
+			counts, err := m.GetInstanceStateCounts()
+			So(err, ShouldBeNil)
+			So(counts, ShouldResemble, []models.InstanceStateCount{
+				{State: models.CompletedState, Count: 1},
+				{State: models.CreatedState, Count: 2},
+				{State: models.PublishedState, Count: 3},
+				{State: models.SubmittedState, Count: 1},
+			})
+		})
+	})
+}
+
+// TestUpdateObservationInserted requires a running MongoDB instance, the address
+// of which is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestUpdateObservationInserted(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given an instance with inserted observations", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-increment-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		instance := &models.Instance{
+			InstanceID: "123",
+			State:      models.SubmittedState,
+			ImportTasks: &models.InstanceImportTasks{
+				ImportObservations: &models.ImportObservationsTask{InsertedObservations: 100},
+			},
+		}
+		So(session.DB(m.Database).C(instanceCollection).Insert(instance), ShouldBeNil)
+
+		Convey("When two importers each report the observations they have inserted", func() {
+			first, err := m.UpdateObservationInserted("123", 25)
+			So(err, ShouldBeNil)
+			So(first.InsertedObservations, ShouldEqual, 125)
+
+			second, err := m.UpdateObservationInserted("123", 40)
+			So(err, ShouldBeNil)
+			So(second.InsertedObservations, ShouldEqual, 165)
+
+			Convey("Then both increments are added to the stored count", func() {
+				stored, err := m.GetInstance("123")
+				So(err, ShouldBeNil)
+				So(stored.ImportTasks.ImportObservations.InsertedObservations, ShouldEqual, 165)
+			})
+		})
+
+		Convey("When the instance does not exist then a not found error is returned", func() {
+			_, err := m.UpdateObservationInserted("456", 25)
+			So(err, ShouldEqual, errs.ErrInstanceNotFound)
+		})
+	})
+}
+
+// TestGetInstanceByIdempotencyKey requires a running MongoDB instance, the address of which
+// is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestGetInstanceByIdempotencyKey(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given an instance created with an idempotency key", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-idempotency-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		_, err = m.AddInstance(&models.Instance{InstanceID: "123", State: models.CreatedState, IdempotencyKey: "import-123"})
+		So(err, ShouldBeNil)
+
+		Convey("When the instance is looked up by its key then it is returned", func() {
+			instance, err := m.GetInstanceByIdempotencyKey("import-123")
+			So(err, ShouldBeNil)
+			So(instance.InstanceID, ShouldEqual, "123")
+		})
+
+		Convey("When a second instance is added with the same key then it is rejected", func() {
+			_, err := m.AddInstance(&models.Instance{InstanceID: "456", State: models.CreatedState, IdempotencyKey: "import-123"})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("When no instance has the key then a not found error is returned", func() {
+			_, err := m.GetInstanceByIdempotencyKey("import-456")
+			So(err, ShouldEqual, errs.ErrInstanceNotFound)
+		})
+	})
+}
+
+// TestUpdateInstanceVersionNumber requires a running MongoDB instance, the address of which
+// is provided by the MONGODB_TEST_BIND_ADDR environment variable
+func TestUpdateInstanceVersionNumber(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given an instance which is version 1 of an edition", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-version-number-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		dataset := &models.LinkObject{ID: "123"}
+		_, err = m.AddInstance(&models.Instance{InstanceID: "1", Edition: "2017", State: models.EditionConfirmedState, Version: 1, Links: &models.InstanceLinks{Dataset: dataset}})
+		So(err, ShouldBeNil)
+		_, err = m.AddInstance(&models.Instance{InstanceID: "2", State: models.CompletedState, Links: &models.InstanceLinks{Dataset: dataset}})
+		So(err, ShouldBeNil)
+
+		Convey("When another instance of the edition is given version 1 then it is refused", func() {
+			instance, err := m.GetInstance("2")
+			So(err, ShouldBeNil)
+
+			err = m.UpdateInstance(context.Background(), "2", &models.Instance{Edition: "2017", State: models.EditionConfirmedState, Version: 1, UniqueTimestamp: instance.UniqueTimestamp})
+			So(err, ShouldEqual, errs.ErrVersionOutOfSequence)
+		})
+
+		Convey("When another instance of the edition is given version 2 then it is updated", func() {
+			instance, err := m.GetInstance("2")
+			So(err, ShouldBeNil)
+
+			err = m.UpdateInstance(context.Background(), "2", &models.Instance{Edition: "2017", State: models.EditionConfirmedState, Version: 2, UniqueTimestamp: instance.UniqueTimestamp})
+			So(err, ShouldBeNil)
+		})
+	})
+}
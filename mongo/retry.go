@@ -0,0 +1,80 @@
+package mongo
+
+import (
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/globalsign/mgo"
+)
+
+// transientErrorCodes are the MongoDB error codes returned while a server cannot be reached, a replica set is
+// electing a new primary or a server is shutting down, a write which fails with one of these can be expected to
+// succeed if retried over a new connection. A server rejects a write with a stepdown or shutdown code without
+// applying it.
+var transientErrorCodes = map[int]bool{
+	6:     true, // HostUnreachable
+	7:     true, // HostNotFound
+	89:    true, // NetworkTimeout
+	91:    true, // ShutdownInProgress
+	189:   true, // PrimarySteppedDown
+	9001:  true, // SocketException
+	10107: true, // NotMaster
+	11600: true, // InterruptedAtShutdown
+	11602: true, // InterruptedDueToReplStateChange
+	13435: true, // NotMasterNoSlaveOk
+	13436: true, // NotMasterOrSecondary
+}
+
+// refresher is the part of a session used to retry a write, a session is refreshed before each retry so that
+// the write is sent over a new connection rather than the one which failed
+type refresher interface {
+	Refresh()
+}
+
+// retryWrite runs a write through the session it was made with, retrying it with the configured backoff
+// while it fails with a transient error. A write failing with a network error may still have been applied, so
+// only writes which can safely be applied more than once, such as upserts and updates setting fields, should
+// be retried, and their selector must still match the document once the write has been applied.
+func (m *Mongo) retryWrite(s refresher, write func() error) error {
+	return retryWrite(s, m.WriteMaxAttempts, m.WriteRetryBackoff, write)
+}
+
+// retryWrite makes up to maxAttempts attempts at a write, doubling the backoff between each attempt. Errors
+// which are not transient, such as duplicate keys or failed validation, are returned without a retry.
+func retryWrite(s refresher, maxAttempts int, backoff time.Duration, write func() error) error {
+	err := write()
+
+	for attempt := 1; attempt < maxAttempts && isTransientError(err); attempt++ {
+		log.Info("retrying mongo write after a transient error", log.Data{"attempt": attempt, "backoff": backoff.String(), "error": err.Error()})
+
+		time.Sleep(backoff)
+		backoff *= 2
+
+		s.Refresh()
+		err = write()
+	}
+
+	return err
+}
+
+// isTransientError returns true if an error is caused by the database being temporarily unavailable
+func isTransientError(err error) bool {
+	if err == nil || mgo.IsDup(err) {
+		return false
+	}
+
+	switch e := err.(type) {
+	case *mgo.LastError:
+		return transientErrorCodes[e.Code]
+	case *mgo.QueryError:
+		return transientErrorCodes[e.Code]
+	case net.Error:
+		return true
+	}
+
+	// the driver reports a dropped connection as io.EOF and an unreachable primary by message alone
+	return err == io.EOF || strings.HasPrefix(err.Error(), "no reachable servers")
+}
@@ -0,0 +1,125 @@
+package mongo
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type mockSession struct {
+	refreshed int
+}
+
+func (s *mockSession) Refresh() {
+	s.refreshed++
+}
+
+func TestRetryWrite(t *testing.T) {
+	t.Parallel()
+	Convey("Given a write which fails once with a network error", t, func() {
+		session := &mockSession{}
+		attempts := 0
+		write := func() error {
+			attempts++
+			if attempts == 1 {
+				return &mgo.LastError{Code: 89, Err: "network timeout"}
+			}
+			return nil
+		}
+
+		Convey("When the write is retried", func() {
+			err := retryWrite(session, 3, time.Millisecond, write)
+
+			Convey("Then the write succeeds on the second attempt over a refreshed session", func() {
+				So(err, ShouldBeNil)
+				So(attempts, ShouldEqual, 2)
+				So(session.refreshed, ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given a write rejected during a primary stepdown", t, func() {
+		for _, stepdown := range []error{
+			&mgo.LastError{Code: 189, Err: "primary stepped down"},
+			&mgo.QueryError{Code: 10107, Message: "not master"},
+		} {
+			session := &mockSession{}
+			attempts := 0
+			write := func() error {
+				attempts++
+				if attempts == 1 {
+					return stepdown
+				}
+				return nil
+			}
+
+			err := retryWrite(session, 3, time.Millisecond, write)
+
+			So(err, ShouldBeNil)
+			So(attempts, ShouldEqual, 2)
+			So(session.refreshed, ShouldEqual, 1)
+		}
+	})
+
+	Convey("Given a write which always fails with a network error", t, func() {
+		session := &mockSession{}
+		attempts := 0
+		write := func() error {
+			attempts++
+			return io.EOF
+		}
+
+		Convey("When the write is retried", func() {
+			err := retryWrite(session, 3, time.Millisecond, write)
+
+			Convey("Then the error is returned once the maximum attempts have been made", func() {
+				So(err, ShouldEqual, io.EOF)
+				So(attempts, ShouldEqual, 3)
+				So(session.refreshed, ShouldEqual, 2)
+			})
+		})
+	})
+
+	Convey("Given a write which fails with a duplicate key error", t, func() {
+		session := &mockSession{}
+		attempts := 0
+		duplicate := &mgo.LastError{Code: 11000, Err: "E11000 duplicate key error"}
+		write := func() error {
+			attempts++
+			return duplicate
+		}
+
+		Convey("When the write is retried", func() {
+			err := retryWrite(session, 3, time.Millisecond, write)
+
+			Convey("Then the error is returned without a retry", func() {
+				So(err, ShouldEqual, duplicate)
+				So(attempts, ShouldEqual, 1)
+				So(session.refreshed, ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestIsTransientError(t *testing.T) {
+	t.Parallel()
+	Convey("Errors raised while the database is temporarily unavailable are transient", t, func() {
+		So(isTransientError(&mgo.LastError{Code: 89}), ShouldBeTrue)
+		So(isTransientError(&mgo.QueryError{Code: 9001}), ShouldBeTrue)
+		So(isTransientError(&mgo.LastError{Code: 189}), ShouldBeTrue)
+		So(isTransientError(&mgo.QueryError{Code: 11602}), ShouldBeTrue)
+		So(isTransientError(io.EOF), ShouldBeTrue)
+		So(isTransientError(errors.New("no reachable servers")), ShouldBeTrue)
+	})
+
+	Convey("Errors caused by the write itself are not transient", t, func() {
+		So(isTransientError(nil), ShouldBeFalse)
+		So(isTransientError(mgo.ErrNotFound), ShouldBeFalse)
+		So(isTransientError(&mgo.LastError{Code: 11000}), ShouldBeFalse)
+		So(isTransientError(&mgo.QueryError{Code: 121, Message: "Document failed validation"}), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,189 @@
+package mongo
+
+import (
+	"strings"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/ONSdigital/go-ns/mongo"
+)
+
+// Transaction wraps a Mongo store, recording the prior state of every document
+// written through it so the writes can be undone if the unit of work fails.
+//
+// The MongoDB driver in use has no support for multi-document transactions, so
+// this gives all-or-nothing behaviour for the documents written during a publish
+// but not isolation: other readers may observe intermediate state, and a process
+// crash part way through leaves the writes made so far in place.
+type Transaction struct {
+	*Mongo
+	undo []func() error
+}
+
+// RunInTransaction runs fn against a Transaction, restoring every document written
+// through it if fn returns an error. The error of fn is returned; a rollback which
+// fails is logged along with it, as the documents may then be left partly updated.
+func (m *Mongo) RunInTransaction(fn func(tx *Transaction) error) error {
+	tx := &Transaction{Mongo: m}
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.rollback(); rollbackErr != nil {
+			log.Error(errors.WithMessage(rollbackErr, "transaction rollback failed"), log.Data{"transaction_error": err.Error()})
+		}
+		return err
+	}
+
+	return nil
+}
+
+// UpdateDatasetWithAssociation updates an existing dataset document with collection data
+func (tx *Transaction) UpdateDatasetWithAssociation(id, state string, version *models.Version) error {
+	return tx.write(datasetsCollection, bson.M{"_id": id}, func() error {
+		return tx.Mongo.UpdateDatasetWithAssociation(id, state, version)
+	})
+}
+
+// SetDatasetLinksFrozen sets whether the edition links of a dataset are frozen
+func (tx *Transaction) SetDatasetLinksFrozen(id string, frozen bool) error {
+	return tx.write(datasetsCollection, bson.M{"_id": id}, func() error {
+		return tx.Mongo.SetDatasetLinksFrozen(id, frozen)
+	})
+}
+
+// UpdateVersion updates an existing version document
+func (tx *Transaction) UpdateVersion(id string, version *models.Version) error {
+	return tx.write(instanceCollection, bson.M{"id": id}, func() error {
+		return tx.Mongo.UpdateVersion(id, version)
+	})
+}
+
+// UpsertDataset adds or overides an existing dataset document
+func (tx *Transaction) UpsertDataset(id string, datasetDoc *models.DatasetUpdate) error {
+	return tx.write(datasetsCollection, bson.M{"_id": id}, func() error {
+		return tx.Mongo.UpsertDataset(id, datasetDoc)
+	})
+}
+
+// UpsertEdition adds or overides an existing edition document
+func (tx *Transaction) UpsertEdition(datasetID, edition string, editionDoc *models.EditionUpdate) error {
+	return tx.write(editionsCollection, buildUpsertEditionQuery(datasetID, edition, editionDoc), func() error {
+		return tx.Mongo.UpsertEdition(datasetID, edition, editionDoc)
+	})
+}
+
+// write makes a write to the document matching selector, recording the document as it was before and after the
+// write so it can be put back on rollback. A document which did not exist before the write is removed again.
+func (tx *Transaction) write(collection string, selector bson.M, write func() error) error {
+	prior, err := tx.find(collection, selector)
+	if err != nil {
+		return err
+	}
+
+	writeErr := write()
+
+	// the document is read back even when the write failed, as a write reported as failed may still have been made
+	written, err := tx.find(collection, selector)
+	if err != nil {
+		if writeErr != nil {
+			return writeErr
+		}
+		return err
+	}
+
+	if written != nil {
+		tx.undo = append(tx.undo, func() error {
+			return tx.restore(collection, prior, written)
+		})
+	}
+	return writeErr
+}
+
+func (tx *Transaction) find(collection string, selector bson.M) (bson.M, error) {
+	s := tx.Session.Copy()
+	defer s.Close()
+
+	var doc bson.M
+	err := s.DB(tx.Database).C(collection).Find(selector).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// restore puts back the document written by the transaction as it was before the write, or removes it if it was
+// created by the write. A document changed by another writer since the transaction wrote it is left alone rather
+// than having that change overwritten.
+func (tx *Transaction) restore(collection string, prior, written bson.M) error {
+	s := tx.Session.Copy()
+	defer s.Close()
+
+	selector := restoreSelector(prior, written)
+	c := s.DB(tx.Database).C(collection)
+
+	err := tx.retryWrite(s, func() error {
+		if prior == nil {
+			return c.Remove(selector)
+		}
+		return c.Update(selector, prior)
+	})
+	if err == mgo.ErrNotFound && prior == nil {
+		// a retried removal finds nothing once an earlier attempt has removed the document
+		var n int
+		if n, err = c.FindId(written["_id"]).Count(); err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		err = mgo.ErrNotFound
+	}
+	if err == mgo.ErrNotFound {
+		return errors.Errorf("%s document %v was changed after it was written in the transaction and has not been restored", collection, written["_id"])
+	}
+	return err
+}
+
+// restoreSelector selects a document written by a transaction by its id and, when it carries one, the unique timestamp
+// it had after the write. A write made since which updates the unique timestamp, such as an instance update, then
+// stops the document being selected and so being overwritten. The unique timestamp of the prior document is also
+// selected, so a retried restore still matches once an earlier attempt has been applied.
+func restoreSelector(prior, written bson.M) bson.M {
+	selector := bson.M{"_id": written["_id"]}
+	timestamp, ok := written[mongo.UniqueTimestampKey]
+	if !ok {
+		return selector
+	}
+
+	if priorTimestamp, ok := prior[mongo.UniqueTimestampKey]; ok {
+		selector[mongo.UniqueTimestampKey] = bson.M{"$in": []interface{}{timestamp, priorTimestamp}}
+	} else {
+		selector[mongo.UniqueTimestampKey] = timestamp
+	}
+	return selector
+}
+
+// rollback undoes the recorded writes, most recent first. Every write is undone even when undoing one of them fails,
+// and the failures are returned together.
+func (tx *Transaction) rollback() error {
+	var failures []string
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i](); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	total := len(tx.undo)
+	tx.undo = nil
+
+	if len(failures) > 0 {
+		return errors.Errorf("failed to undo %d of %d writes: %s", len(failures), total, strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package mongo
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/globalsign/mgo/bson"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRunInTransaction(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_BIND_ADDR")
+	if uri == "" || testing.Short() {
+		t.Skip("skipping mongo integration test, MONGODB_TEST_BIND_ADDR not set")
+	}
+
+	Convey("Given an associated version of a dataset", t, func() {
+		m := &Mongo{Database: "dp-dataset-api-transaction-test", URI: uri}
+
+		session, err := m.Init()
+		So(err, ShouldBeNil)
+		m.Session = session
+		defer func() {
+			session.DB(m.Database).DropDatabase()
+			session.Close()
+		}()
+
+		version := &models.Instance{InstanceID: "123", State: models.AssociatedState}
+		So(session.DB(m.Database).C(instanceCollection).Insert(version), ShouldBeNil)
+
+		published := &models.Version{State: models.PublishedState}
+		dataset := &models.DatasetUpdate{ID: "456", Next: &models.Dataset{State: models.PublishedState}}
+
+		Convey("When a step of the transaction fails after the version is published", func() {
+			failure := errors.New("publish failed")
+			err := m.RunInTransaction(func(tx *Transaction) error {
+				if err := tx.UpdateVersion("123", published); err != nil {
+					return err
+				}
+				if err := tx.UpsertDataset("456", dataset); err != nil {
+					return err
+				}
+				return failure
+			})
+
+			Convey("Then the error is returned and the writes are rolled back", func() {
+				So(err, ShouldEqual, failure)
+
+				stored, err := m.GetInstance("123")
+				So(err, ShouldBeNil)
+				So(stored.State, ShouldEqual, models.AssociatedState)
+
+				count, err := session.DB(m.Database).C("datasets").FindId("456").Count()
+				So(err, ShouldBeNil)
+				So(count, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the version is changed by another writer before the transaction fails", func() {
+			failure := errors.New("publish failed")
+			err := m.RunInTransaction(func(tx *Transaction) error {
+				if err := tx.UpdateVersion("123", published); err != nil {
+					return err
+				}
+				concurrent := bson.M{"$set": bson.M{"state": models.EditionConfirmedState, "unique_timestamp": bson.MongoTimestamp(1)}}
+				if err := session.DB(m.Database).C(instanceCollection).Update(bson.M{"id": "123"}, concurrent); err != nil {
+					return err
+				}
+				return failure
+			})
+
+			Convey("Then the error is returned and the change of the other writer is not overwritten", func() {
+				So(err, ShouldEqual, failure)
+
+				stored, err := m.GetInstance("123")
+				So(err, ShouldBeNil)
+				So(stored.State, ShouldEqual, models.EditionConfirmedState)
+			})
+		})
+
+		Convey("When every step of the transaction succeeds then the writes are kept", func() {
+			err := m.RunInTransaction(func(tx *Transaction) error {
+				return tx.UpdateVersion("123", published)
+			})
+			So(err, ShouldBeNil)
+
+			stored, err := m.GetInstance("123")
+			So(err, ShouldBeNil)
+			So(stored.State, ShouldEqual, models.PublishedState)
+		})
+	})
+}
+
+func TestTransactionRollback(t *testing.T) {
+	t.Parallel()
+	Convey("Given a transaction which has recorded three writes, the second of which cannot be undone", t, func() {
+		var undone []int
+		undoWrite := func(i int, err error) func() error {
+			return func() error {
+				undone = append(undone, i)
+				return err
+			}
+		}
+
+		tx := &Transaction{undo: []func() error{
+			undoWrite(1, nil),
+			undoWrite(2, errors.New("document changed")),
+			undoWrite(3, nil),
+		}}
+
+		Convey("When the transaction is rolled back", func() {
+			err := tx.rollback()
+
+			Convey("Then every write is undone, most recent first, and the failure is returned", func() {
+				So(undone, ShouldResemble, []int{3, 2, 1})
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "failed to undo 1 of 3 writes: document changed")
+				So(tx.undo, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a transaction whose writes are undone", t, func() {
+		tx := &Transaction{undo: []func() error{func() error { return nil }}}
+
+		Convey("When the transaction is rolled back then no error is returned", func() {
+			So(tx.rollback(), ShouldBeNil)
+		})
+	})
+}
+
+func TestRunInTransactionRollbackFailure(t *testing.T) {
+	t.Parallel()
+	Convey("Given a unit of work which fails after a write which cannot be undone", t, func() {
+		failure := errors.New("publish failed")
+		undoCalls := 0
+
+		m := &Mongo{}
+		err := m.RunInTransaction(func(tx *Transaction) error {
+			tx.undo = append(tx.undo, func() error {
+				undoCalls++
+				return errors.New("document changed")
+			})
+			return failure
+		})
+
+		Convey("Then the rollback is attempted and the error of the unit of work is returned", func() {
+			So(undoCalls, ShouldEqual, 1)
+			So(err, ShouldEqual, failure)
+		})
+	})
+}
+
+func TestRestoreSelector(t *testing.T) {
+	t.Parallel()
+	Convey("Given a written document with a unique timestamp", t, func() {
+		timestamp := bson.MongoTimestamp(6591600000000000001)
+		written := bson.M{"_id": "123", "id": "123", "state": "published", "unique_timestamp": timestamp}
+
+		Convey("Then a removal is only made while the unique timestamp is unchanged", func() {
+			So(restoreSelector(nil, written), ShouldResemble, bson.M{"_id": "123", "unique_timestamp": timestamp})
+		})
+
+		Convey("Then a restore also matches the unique timestamp of the prior document, so it can be retried", func() {
+			priorTimestamp := bson.MongoTimestamp(6591599000000000001)
+			prior := bson.M{"_id": "123", "id": "123", "state": "associated", "unique_timestamp": priorTimestamp}
+			So(restoreSelector(prior, written), ShouldResemble, bson.M{
+				"_id":              "123",
+				"unique_timestamp": bson.M{"$in": []interface{}{timestamp, priorTimestamp}},
+			})
+		})
+	})
+
+	Convey("Given a written document without a unique timestamp", t, func() {
+		written := bson.M{"_id": "123", "next": bson.M{"state": "published"}}
+
+		Convey("Then it is restored by id alone", func() {
+			So(restoreSelector(bson.M{"_id": "123"}, written), ShouldResemble, bson.M{"_id": "123"})
+		})
+	})
+}
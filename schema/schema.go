@@ -10,7 +10,8 @@ var generateDownloads = `{
     {"name": "instance_id", "type": "string", "default": ""},
     {"name": "dataset_id", "type": "string", "default": ""},
     {"name": "edition", "type": "string", "default": ""},
-    {"name": "version", "type": "string", "default": ""}
+    {"name": "version", "type": "string", "default": ""},
+    {"name": "formats", "type": ["null", {"type": "array", "items": "string"}], "default": null}
   ]
 }`
 
@@ -18,3 +19,18 @@ var generateDownloads = `{
 var GenerateDownloadsEvent = &avro.Schema{
 	Definition: generateDownloads,
 }
+
+var dataImportComplete = `{
+  "type": "record",
+  "name": "data-import-complete",
+  "fields": [
+    {"name": "instance_id", "type": "string", "default": ""},
+    {"name": "code_list_id", "type": "string", "default": ""},
+    {"name": "dimension_name", "type": "string", "default": ""}
+  ]
+}`
+
+// DataImportCompleteEvent the Avro schema for DataImportComplete messages, which trigger a hierarchy build.
+var DataImportCompleteEvent = &avro.Schema{
+	Definition: dataImportComplete,
+}
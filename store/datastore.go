@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/ONSdigital/dp-dataset-api/models"
 	"github.com/ONSdigital/dp-graph/observation"
@@ -17,38 +18,65 @@ type DataStore struct {
 
 // Storer represents basic data access via Get, Remove and Upsert methods.
 type Storer interface {
+	AddAuditEvent(event *models.AuditEvent) error
 	AddDimensionToInstance(dimension *models.CachedDimensionOption) error
 	AddEventToInstance(instanceID string, event *models.Event) error
 	AddInstance(instance *models.Instance) (*models.Instance, error)
 	CheckDatasetExists(ID, state string) error
 	CheckEditionExists(ID, editionID, state string) error
+	ClaimNextVersion(datasetID, editionID string) (int, error)
+	GetAuditEvents(instanceID string) ([]models.AuditEvent, error)
 	GetDataset(ID string) (*models.DatasetUpdate, error)
-	GetDatasets() ([]models.DatasetUpdate, error)
+	GetDatasets(datasetType string) ([]models.DatasetUpdate, error)
+	GetDatasetsModifiedSince(t time.Time, datasetType string, publishedOnly bool, offset, limit int) (*models.DatasetUpdatePage, error)
+	GetDistinctThemes() ([]string, error)
 	GetDimensionsFromInstance(ID string) (*models.DimensionNodeResults, error)
 	GetDimensions(datasetID, versionID string) ([]bson.M, error)
 	GetDimensionOptions(version *models.Version, dimension string) (*models.DimensionOptionResults, error)
+	GetDimensionOptionsByCodes(instanceID, dimension string, codes []string) (*models.DimensionOptionResults, error)
+	StreamDimensionOptions(instanceID, dimension string, fn func(*models.DimensionOption) error) error
+	GetDimensionCodeList(instanceID, dimension string) (string, error)
+	GetDimensionOptionCounts(instanceID string) ([]models.DimensionOptionCount, error)
 	GetEdition(ID, editionID, state string) (*models.EditionUpdate, error)
 	GetEditions(ID, state string) (*models.EditionUpdateResults, error)
-	GetInstances(states []string, datasets []string) (*models.InstanceResults, error)
+	GetEditionsForDatasets(IDs []string, state string) (map[string][]*models.EditionUpdate, error)
+	GetInstances(states []string, datasets []string, isBasedOn []string) (*models.InstanceResults, error)
+	StreamInstances(states []string, datasets []string, isBasedOn []string, fn func(*models.Instance) error) error
 	GetInstance(ID string) (*models.Instance, error)
+	GetInstanceByIdempotencyKey(key string) (*models.Instance, error)
+	GetInstanceStateCounts() ([]models.InstanceStateCount, error)
+	GetLatestVersion(datasetID, editionID, state string) (*models.Version, error)
 	GetNextVersion(datasetID, editionID string) (int, error)
 	GetUniqueDimensionAndOptions(ID, dimension string) (*models.DimensionValues, error)
 	GetVersion(datasetID, editionID, version, state string) (*models.Version, error)
-	GetVersions(datasetID, editionID, state string) (*models.VersionResults, error)
+	GetVersions(datasetID, editionID, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error)
+	CountVersions(datasetID, editionID, state string) (int, error)
+	SummariseEditionVersions(datasetID, state string) ([]models.EditionVersions, error)
+	CountEditions(datasetID string) (int, error)
+	GetVersionsByNumbers(datasetID string, refs []models.EditionVersionRef) ([]models.Version, error)
+	PatchDataset(ID string, patch *models.DatasetPatch, currentState string) error
+	PurgeInstances(olderThan time.Time, states []string) (int, error)
+	ReleaseVersion(datasetID, editionID string, version int) error
+	RenameDimension(instanceID, oldName, newName string) error
+	SetDatasetLinksFrozen(ID string, frozen bool) error
 	UpdateDataset(ID string, dataset *models.Dataset, currentState string) error
 	UpdateDatasetWithAssociation(ID, state string, version *models.Version) error
 	UpdateDimensionNodeID(dimension *models.DimensionOption) error
+	UpdateDimensionOption(instanceID, dimension, option string, update *models.DimensionOptionUpdate) error
 	UpdateInstance(ctx context.Context, ID string, instance *models.Instance) error
-	UpdateObservationInserted(ID string, observationInserted int64) error
-	UpdateImportObservationsTaskState(id, state string) error
-	UpdateBuildHierarchyTaskState(id, dimension, state string) error
-	UpdateBuildSearchTaskState(id, dimension, state string) error
+	UpdateObservationInserted(ID string, observationInserted int64) (*models.ImportObservationsTask, error)
+	UpdateImportObservationsTaskState(id, state, reason string) error
+	UpdateBuildHierarchyTaskState(id, dimension, state, reason string) error
+	UpdateBuildSearchTaskState(id, dimension, state, reason string) error
 	UpdateVersion(ID string, version *models.Version) error
+	ResetInstance(ctx context.Context, ID, currentState string, uniqueTimestamp bson.MongoTimestamp) error
 	UpsertContact(ID string, update interface{}) error
 	UpsertDataset(ID string, datasetDoc *models.DatasetUpdate) error
 	UpsertEdition(datasetID, edition string, editionDoc *models.EditionUpdate) error
 	UpsertVersion(ID string, versionDoc *models.Version) error
+	WithTransaction(fn func(Storer) error) error
 	DeleteDataset(ID string) error
+	DeleteDimensionOptions(instanceID, dimension string) error
 	DeleteEdition(ID string) error
 
 	AddVersionDetailsToInstance(ctx context.Context, instanceID string, datasetID string, edition string, version int) error
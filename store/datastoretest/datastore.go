@@ -6,40 +6,68 @@ package storetest
 import (
 	"context"
 	"github.com/ONSdigital/dp-dataset-api/models"
+	"github.com/ONSdigital/dp-dataset-api/store"
 	"github.com/ONSdigital/dp-graph/observation"
 	"github.com/globalsign/mgo/bson"
 	"sync"
+	"time"
 )
 
 var (
+	lockStorerMockAddAuditEvent                     sync.RWMutex
 	lockStorerMockAddDimensionToInstance            sync.RWMutex
 	lockStorerMockAddEventToInstance                sync.RWMutex
 	lockStorerMockAddInstance                       sync.RWMutex
 	lockStorerMockAddVersionDetailsToInstance       sync.RWMutex
 	lockStorerMockCheckDatasetExists                sync.RWMutex
 	lockStorerMockCheckEditionExists                sync.RWMutex
+	lockStorerMockClaimNextVersion                  sync.RWMutex
+	lockStorerMockCountEditions                     sync.RWMutex
+	lockStorerMockCountVersions                     sync.RWMutex
 	lockStorerMockDeleteDataset                     sync.RWMutex
+	lockStorerMockDeleteDimensionOptions            sync.RWMutex
 	lockStorerMockDeleteEdition                     sync.RWMutex
+	lockStorerMockGetAuditEvents                    sync.RWMutex
 	lockStorerMockGetDataset                        sync.RWMutex
 	lockStorerMockGetDatasets                       sync.RWMutex
+	lockStorerMockGetDatasetsModifiedSince          sync.RWMutex
+	lockStorerMockGetDimensionCodeList              sync.RWMutex
+	lockStorerMockGetDimensionOptionCounts          sync.RWMutex
 	lockStorerMockGetDimensionOptions               sync.RWMutex
+	lockStorerMockGetDimensionOptionsByCodes        sync.RWMutex
 	lockStorerMockGetDimensions                     sync.RWMutex
 	lockStorerMockGetDimensionsFromInstance         sync.RWMutex
+	lockStorerMockGetDistinctThemes                 sync.RWMutex
 	lockStorerMockGetEdition                        sync.RWMutex
 	lockStorerMockGetEditions                       sync.RWMutex
+	lockStorerMockGetEditionsForDatasets            sync.RWMutex
 	lockStorerMockGetInstance                       sync.RWMutex
+	lockStorerMockGetInstanceByIdempotencyKey       sync.RWMutex
+	lockStorerMockGetInstanceStateCounts            sync.RWMutex
 	lockStorerMockGetInstances                      sync.RWMutex
+	lockStorerMockGetLatestVersion                  sync.RWMutex
 	lockStorerMockGetNextVersion                    sync.RWMutex
 	lockStorerMockGetUniqueDimensionAndOptions      sync.RWMutex
 	lockStorerMockGetVersion                        sync.RWMutex
 	lockStorerMockGetVersions                       sync.RWMutex
+	lockStorerMockGetVersionsByNumbers              sync.RWMutex
+	lockStorerMockPatchDataset                      sync.RWMutex
+	lockStorerMockPurgeInstances                    sync.RWMutex
+	lockStorerMockReleaseVersion                    sync.RWMutex
+	lockStorerMockRenameDimension                   sync.RWMutex
+	lockStorerMockResetInstance                     sync.RWMutex
+	lockStorerMockSetDatasetLinksFrozen             sync.RWMutex
 	lockStorerMockSetInstanceIsPublished            sync.RWMutex
 	lockStorerMockStreamCSVRows                     sync.RWMutex
+	lockStorerMockStreamDimensionOptions            sync.RWMutex
+	lockStorerMockStreamInstances                   sync.RWMutex
+	lockStorerMockSummariseEditionVersions          sync.RWMutex
 	lockStorerMockUpdateBuildHierarchyTaskState     sync.RWMutex
 	lockStorerMockUpdateBuildSearchTaskState        sync.RWMutex
 	lockStorerMockUpdateDataset                     sync.RWMutex
 	lockStorerMockUpdateDatasetWithAssociation      sync.RWMutex
 	lockStorerMockUpdateDimensionNodeID             sync.RWMutex
+	lockStorerMockUpdateDimensionOption             sync.RWMutex
 	lockStorerMockUpdateImportObservationsTaskState sync.RWMutex
 	lockStorerMockUpdateInstance                    sync.RWMutex
 	lockStorerMockUpdateObservationInserted         sync.RWMutex
@@ -48,6 +76,7 @@ var (
 	lockStorerMockUpsertDataset                     sync.RWMutex
 	lockStorerMockUpsertEdition                     sync.RWMutex
 	lockStorerMockUpsertVersion                     sync.RWMutex
+	lockStorerMockWithTransaction                   sync.RWMutex
 )
 
 // StorerMock is a mock implementation of Storer.
@@ -56,6 +85,9 @@ var (
 //
 //         // make and configure a mocked Storer
 //         mockedStorer := &StorerMock{
+//             AddAuditEventFunc: func(event *models.AuditEvent) error {
+// 	               panic("TODO: mock out the AddAuditEvent method")
+//             },
 //             AddDimensionToInstanceFunc: func(dimension *models.CachedDimensionOption) error {
 // 	               panic("TODO: mock out the AddDimensionToInstance method")
 //             },
@@ -74,39 +106,81 @@ var (
 //             CheckEditionExistsFunc: func(ID string, editionID string, state string) error {
 // 	               panic("TODO: mock out the CheckEditionExists method")
 //             },
+//             ClaimNextVersionFunc: func(datasetID string, editionID string) (int, error) {
+// 	               panic("TODO: mock out the ClaimNextVersion method")
+//             },
+//             CountEditionsFunc: func(datasetID string) (int, error) {
+// 	               panic("TODO: mock out the CountEditions method")
+//             },
+//             CountVersionsFunc: func(datasetID string, editionID string, state string) (int, error) {
+// 	               panic("TODO: mock out the CountVersions method")
+//             },
 //             DeleteDatasetFunc: func(ID string) error {
 // 	               panic("TODO: mock out the DeleteDataset method")
 //             },
+//             DeleteDimensionOptionsFunc: func(instanceID string, dimension string) error {
+// 	               panic("TODO: mock out the DeleteDimensionOptions method")
+//             },
 //             DeleteEditionFunc: func(ID string) error {
 // 	               panic("TODO: mock out the DeleteEdition method")
 //             },
+//             GetAuditEventsFunc: func(instanceID string) ([]models.AuditEvent, error) {
+// 	               panic("TODO: mock out the GetAuditEvents method")
+//             },
 //             GetDatasetFunc: func(ID string) (*models.DatasetUpdate, error) {
 // 	               panic("TODO: mock out the GetDataset method")
 //             },
-//             GetDatasetsFunc: func() ([]models.DatasetUpdate, error) {
+//             GetDatasetsFunc: func(datasetType string) ([]models.DatasetUpdate, error) {
 // 	               panic("TODO: mock out the GetDatasets method")
 //             },
+//             GetDatasetsModifiedSinceFunc: func(t time.Time, datasetType string, publishedOnly bool, offset int, limit int) (*models.DatasetUpdatePage, error) {
+// 	               panic("TODO: mock out the GetDatasetsModifiedSince method")
+//             },
+//             GetDimensionCodeListFunc: func(instanceID string, dimension string) (string, error) {
+// 	               panic("TODO: mock out the GetDimensionCodeList method")
+//             },
+//             GetDimensionOptionCountsFunc: func(instanceID string) ([]models.DimensionOptionCount, error) {
+// 	               panic("TODO: mock out the GetDimensionOptionCounts method")
+//             },
 //             GetDimensionOptionsFunc: func(version *models.Version, dimension string) (*models.DimensionOptionResults, error) {
 // 	               panic("TODO: mock out the GetDimensionOptions method")
 //             },
+//             GetDimensionOptionsByCodesFunc: func(instanceID string, dimension string, codes []string) (*models.DimensionOptionResults, error) {
+// 	               panic("TODO: mock out the GetDimensionOptionsByCodes method")
+//             },
 //             GetDimensionsFunc: func(datasetID string, versionID string) ([]bson.M, error) {
 // 	               panic("TODO: mock out the GetDimensions method")
 //             },
 //             GetDimensionsFromInstanceFunc: func(ID string) (*models.DimensionNodeResults, error) {
 // 	               panic("TODO: mock out the GetDimensionsFromInstance method")
 //             },
+//             GetDistinctThemesFunc: func() ([]string, error) {
+// 	               panic("TODO: mock out the GetDistinctThemes method")
+//             },
 //             GetEditionFunc: func(ID string, editionID string, state string) (*models.EditionUpdate, error) {
 // 	               panic("TODO: mock out the GetEdition method")
 //             },
 //             GetEditionsFunc: func(ID string, state string) (*models.EditionUpdateResults, error) {
 // 	               panic("TODO: mock out the GetEditions method")
 //             },
+//             GetEditionsForDatasetsFunc: func(IDs []string, state string) (map[string][]*models.EditionUpdate, error) {
+// 	               panic("TODO: mock out the GetEditionsForDatasets method")
+//             },
 //             GetInstanceFunc: func(ID string) (*models.Instance, error) {
 // 	               panic("TODO: mock out the GetInstance method")
 //             },
-//             GetInstancesFunc: func(states []string, datasets []string) (*models.InstanceResults, error) {
+//             GetInstanceByIdempotencyKeyFunc: func(key string) (*models.Instance, error) {
+// 	               panic("TODO: mock out the GetInstanceByIdempotencyKey method")
+//             },
+//             GetInstanceStateCountsFunc: func() ([]models.InstanceStateCount, error) {
+// 	               panic("TODO: mock out the GetInstanceStateCounts method")
+//             },
+//             GetInstancesFunc: func(states []string, datasets []string, isBasedOn []string) (*models.InstanceResults, error) {
 // 	               panic("TODO: mock out the GetInstances method")
 //             },
+//             GetLatestVersionFunc: func(datasetID string, editionID string, state string) (*models.Version, error) {
+// 	               panic("TODO: mock out the GetLatestVersion method")
+//             },
 //             GetNextVersionFunc: func(datasetID string, editionID string) (int, error) {
 // 	               panic("TODO: mock out the GetNextVersion method")
 //             },
@@ -116,19 +190,49 @@ var (
 //             GetVersionFunc: func(datasetID string, editionID string, version string, state string) (*models.Version, error) {
 // 	               panic("TODO: mock out the GetVersion method")
 //             },
-//             GetVersionsFunc: func(datasetID string, editionID string, state string) (*models.VersionResults, error) {
+//             GetVersionsFunc: func(datasetID string, editionID string, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
 // 	               panic("TODO: mock out the GetVersions method")
 //             },
+//             GetVersionsByNumbersFunc: func(datasetID string, refs []models.EditionVersionRef) ([]models.Version, error) {
+// 	               panic("TODO: mock out the GetVersionsByNumbers method")
+//             },
+//             PatchDatasetFunc: func(ID string, patch *models.DatasetPatch, currentState string) error {
+// 	               panic("TODO: mock out the PatchDataset method")
+//             },
+//             PurgeInstancesFunc: func(olderThan time.Time, states []string) (int, error) {
+// 	               panic("TODO: mock out the PurgeInstances method")
+//             },
+//             ReleaseVersionFunc: func(datasetID string, editionID string, version int) error {
+// 	               panic("TODO: mock out the ReleaseVersion method")
+//             },
+//             RenameDimensionFunc: func(instanceID string, oldName string, newName string) error {
+// 	               panic("TODO: mock out the RenameDimension method")
+//             },
+//             ResetInstanceFunc: func(ctx context.Context, ID string, currentState string, uniqueTimestamp bson.MongoTimestamp) error {
+// 	               panic("TODO: mock out the ResetInstance method")
+//             },
+//             SetDatasetLinksFrozenFunc: func(ID string, frozen bool) error {
+// 	               panic("TODO: mock out the SetDatasetLinksFrozen method")
+//             },
 //             SetInstanceIsPublishedFunc: func(ctx context.Context, instanceID string) error {
 // 	               panic("TODO: mock out the SetInstanceIsPublished method")
 //             },
 //             StreamCSVRowsFunc: func(ctx context.Context, filter *observation.Filter, limit *int) (observation.StreamRowReader, error) {
 // 	               panic("TODO: mock out the StreamCSVRows method")
 //             },
-//             UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string) error {
+//             StreamDimensionOptionsFunc: func(instanceID string, dimension string, fn func(*models.DimensionOption) error) error {
+// 	               panic("TODO: mock out the StreamDimensionOptions method")
+//             },
+//             StreamInstancesFunc: func(states []string, datasets []string, isBasedOn []string, fn func(*models.Instance) error) error {
+// 	               panic("TODO: mock out the StreamInstances method")
+//             },
+//             SummariseEditionVersionsFunc: func(datasetID string, state string) ([]models.EditionVersions, error) {
+// 	               panic("TODO: mock out the SummariseEditionVersions method")
+//             },
+//             UpdateBuildHierarchyTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 // 	               panic("TODO: mock out the UpdateBuildHierarchyTaskState method")
 //             },
-//             UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string) error {
+//             UpdateBuildSearchTaskStateFunc: func(id string, dimension string, state string, reason string) error {
 // 	               panic("TODO: mock out the UpdateBuildSearchTaskState method")
 //             },
 //             UpdateDatasetFunc: func(ID string, dataset *models.Dataset, currentState string) error {
@@ -140,13 +244,16 @@ var (
 //             UpdateDimensionNodeIDFunc: func(dimension *models.DimensionOption) error {
 // 	               panic("TODO: mock out the UpdateDimensionNodeID method")
 //             },
-//             UpdateImportObservationsTaskStateFunc: func(id string, state string) error {
+//             UpdateDimensionOptionFunc: func(instanceID string, dimension string, option string, update *models.DimensionOptionUpdate) error {
+// 	               panic("TODO: mock out the UpdateDimensionOption method")
+//             },
+//             UpdateImportObservationsTaskStateFunc: func(id string, state string, reason string) error {
 // 	               panic("TODO: mock out the UpdateImportObservationsTaskState method")
 //             },
 //             UpdateInstanceFunc: func(ctx context.Context, ID string, instance *models.Instance) error {
 // 	               panic("TODO: mock out the UpdateInstance method")
 //             },
-//             UpdateObservationInsertedFunc: func(ID string, observationInserted int64) error {
+//             UpdateObservationInsertedFunc: func(ID string, observationInserted int64) (*models.ImportObservationsTask, error) {
 // 	               panic("TODO: mock out the UpdateObservationInserted method")
 //             },
 //             UpdateVersionFunc: func(ID string, version *models.Version) error {
@@ -164,6 +271,9 @@ var (
 //             UpsertVersionFunc: func(ID string, versionDoc *models.Version) error {
 // 	               panic("TODO: mock out the UpsertVersion method")
 //             },
+//             WithTransactionFunc: func(fn func(store.Storer) error) error {
+// 	               panic("TODO: mock out the WithTransaction method")
+//             },
 //         }
 //
 //         // TODO: use mockedStorer in code that requires Storer
@@ -171,6 +281,9 @@ var (
 //
 //     }
 type StorerMock struct {
+	// AddAuditEventFunc mocks the AddAuditEvent method.
+	AddAuditEventFunc func(event *models.AuditEvent) error
+
 	// AddDimensionToInstanceFunc mocks the AddDimensionToInstance method.
 	AddDimensionToInstanceFunc func(dimension *models.CachedDimensionOption) error
 
@@ -189,38 +302,80 @@ type StorerMock struct {
 	// CheckEditionExistsFunc mocks the CheckEditionExists method.
 	CheckEditionExistsFunc func(ID string, editionID string, state string) error
 
+	// ClaimNextVersionFunc mocks the ClaimNextVersion method.
+	ClaimNextVersionFunc func(datasetID string, editionID string) (int, error)
+
+	// CountEditionsFunc mocks the CountEditions method.
+	CountEditionsFunc func(datasetID string) (int, error)
+
+	// CountVersionsFunc mocks the CountVersions method.
+	CountVersionsFunc func(datasetID string, editionID string, state string) (int, error)
+
 	// DeleteDatasetFunc mocks the DeleteDataset method.
 	DeleteDatasetFunc func(ID string) error
 
+	// DeleteDimensionOptionsFunc mocks the DeleteDimensionOptions method.
+	DeleteDimensionOptionsFunc func(instanceID string, dimension string) error
+
 	// DeleteEditionFunc mocks the DeleteEdition method.
 	DeleteEditionFunc func(ID string) error
 
+	// GetAuditEventsFunc mocks the GetAuditEvents method.
+	GetAuditEventsFunc func(instanceID string) ([]models.AuditEvent, error)
+
 	// GetDatasetFunc mocks the GetDataset method.
 	GetDatasetFunc func(ID string) (*models.DatasetUpdate, error)
 
 	// GetDatasetsFunc mocks the GetDatasets method.
-	GetDatasetsFunc func() ([]models.DatasetUpdate, error)
+	GetDatasetsFunc func(datasetType string) ([]models.DatasetUpdate, error)
+
+	// GetDatasetsModifiedSinceFunc mocks the GetDatasetsModifiedSince method.
+	GetDatasetsModifiedSinceFunc func(t time.Time, datasetType string, publishedOnly bool, offset int, limit int) (*models.DatasetUpdatePage, error)
+
+	// GetDimensionCodeListFunc mocks the GetDimensionCodeList method.
+	GetDimensionCodeListFunc func(instanceID string, dimension string) (string, error)
+
+	// GetDimensionOptionCountsFunc mocks the GetDimensionOptionCounts method.
+	GetDimensionOptionCountsFunc func(instanceID string) ([]models.DimensionOptionCount, error)
 
 	// GetDimensionOptionsFunc mocks the GetDimensionOptions method.
 	GetDimensionOptionsFunc func(version *models.Version, dimension string) (*models.DimensionOptionResults, error)
 
+	// GetDimensionOptionsByCodesFunc mocks the GetDimensionOptionsByCodes method.
+	GetDimensionOptionsByCodesFunc func(instanceID string, dimension string, codes []string) (*models.DimensionOptionResults, error)
+
 	// GetDimensionsFunc mocks the GetDimensions method.
 	GetDimensionsFunc func(datasetID string, versionID string) ([]bson.M, error)
 
 	// GetDimensionsFromInstanceFunc mocks the GetDimensionsFromInstance method.
 	GetDimensionsFromInstanceFunc func(ID string) (*models.DimensionNodeResults, error)
 
+	// GetDistinctThemesFunc mocks the GetDistinctThemes method.
+	GetDistinctThemesFunc func() ([]string, error)
+
 	// GetEditionFunc mocks the GetEdition method.
 	GetEditionFunc func(ID string, editionID string, state string) (*models.EditionUpdate, error)
 
 	// GetEditionsFunc mocks the GetEditions method.
 	GetEditionsFunc func(ID string, state string) (*models.EditionUpdateResults, error)
 
+	// GetEditionsForDatasetsFunc mocks the GetEditionsForDatasets method.
+	GetEditionsForDatasetsFunc func(IDs []string, state string) (map[string][]*models.EditionUpdate, error)
+
 	// GetInstanceFunc mocks the GetInstance method.
 	GetInstanceFunc func(ID string) (*models.Instance, error)
 
+	// GetInstanceByIdempotencyKeyFunc mocks the GetInstanceByIdempotencyKey method.
+	GetInstanceByIdempotencyKeyFunc func(key string) (*models.Instance, error)
+
+	// GetInstanceStateCountsFunc mocks the GetInstanceStateCounts method.
+	GetInstanceStateCountsFunc func() ([]models.InstanceStateCount, error)
+
 	// GetInstancesFunc mocks the GetInstances method.
-	GetInstancesFunc func(states []string, datasets []string) (*models.InstanceResults, error)
+	GetInstancesFunc func(states []string, datasets []string, isBasedOn []string) (*models.InstanceResults, error)
+
+	// GetLatestVersionFunc mocks the GetLatestVersion method.
+	GetLatestVersionFunc func(datasetID string, editionID string, state string) (*models.Version, error)
 
 	// GetNextVersionFunc mocks the GetNextVersion method.
 	GetNextVersionFunc func(datasetID string, editionID string) (int, error)
@@ -232,7 +387,28 @@ type StorerMock struct {
 	GetVersionFunc func(datasetID string, editionID string, version string, state string) (*models.Version, error)
 
 	// GetVersionsFunc mocks the GetVersions method.
-	GetVersionsFunc func(datasetID string, editionID string, state string) (*models.VersionResults, error)
+	GetVersionsFunc func(datasetID string, editionID string, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error)
+
+	// GetVersionsByNumbersFunc mocks the GetVersionsByNumbers method.
+	GetVersionsByNumbersFunc func(datasetID string, refs []models.EditionVersionRef) ([]models.Version, error)
+
+	// PatchDatasetFunc mocks the PatchDataset method.
+	PatchDatasetFunc func(ID string, patch *models.DatasetPatch, currentState string) error
+
+	// PurgeInstancesFunc mocks the PurgeInstances method.
+	PurgeInstancesFunc func(olderThan time.Time, states []string) (int, error)
+
+	// ReleaseVersionFunc mocks the ReleaseVersion method.
+	ReleaseVersionFunc func(datasetID string, editionID string, version int) error
+
+	// RenameDimensionFunc mocks the RenameDimension method.
+	RenameDimensionFunc func(instanceID string, oldName string, newName string) error
+
+	// ResetInstanceFunc mocks the ResetInstance method.
+	ResetInstanceFunc func(ctx context.Context, ID string, currentState string, uniqueTimestamp bson.MongoTimestamp) error
+
+	// SetDatasetLinksFrozenFunc mocks the SetDatasetLinksFrozen method.
+	SetDatasetLinksFrozenFunc func(ID string, frozen bool) error
 
 	// SetInstanceIsPublishedFunc mocks the SetInstanceIsPublished method.
 	SetInstanceIsPublishedFunc func(ctx context.Context, instanceID string) error
@@ -240,11 +416,20 @@ type StorerMock struct {
 	// StreamCSVRowsFunc mocks the StreamCSVRows method.
 	StreamCSVRowsFunc func(ctx context.Context, filter *observation.Filter, limit *int) (observation.StreamRowReader, error)
 
+	// StreamDimensionOptionsFunc mocks the StreamDimensionOptions method.
+	StreamDimensionOptionsFunc func(instanceID string, dimension string, fn func(*models.DimensionOption) error) error
+
+	// StreamInstancesFunc mocks the StreamInstances method.
+	StreamInstancesFunc func(states []string, datasets []string, isBasedOn []string, fn func(*models.Instance) error) error
+
+	// SummariseEditionVersionsFunc mocks the SummariseEditionVersions method.
+	SummariseEditionVersionsFunc func(datasetID string, state string) ([]models.EditionVersions, error)
+
 	// UpdateBuildHierarchyTaskStateFunc mocks the UpdateBuildHierarchyTaskState method.
-	UpdateBuildHierarchyTaskStateFunc func(id string, dimension string, state string) error
+	UpdateBuildHierarchyTaskStateFunc func(id string, dimension string, state string, reason string) error
 
 	// UpdateBuildSearchTaskStateFunc mocks the UpdateBuildSearchTaskState method.
-	UpdateBuildSearchTaskStateFunc func(id string, dimension string, state string) error
+	UpdateBuildSearchTaskStateFunc func(id string, dimension string, state string, reason string) error
 
 	// UpdateDatasetFunc mocks the UpdateDataset method.
 	UpdateDatasetFunc func(ID string, dataset *models.Dataset, currentState string) error
@@ -255,14 +440,17 @@ type StorerMock struct {
 	// UpdateDimensionNodeIDFunc mocks the UpdateDimensionNodeID method.
 	UpdateDimensionNodeIDFunc func(dimension *models.DimensionOption) error
 
+	// UpdateDimensionOptionFunc mocks the UpdateDimensionOption method.
+	UpdateDimensionOptionFunc func(instanceID string, dimension string, option string, update *models.DimensionOptionUpdate) error
+
 	// UpdateImportObservationsTaskStateFunc mocks the UpdateImportObservationsTaskState method.
-	UpdateImportObservationsTaskStateFunc func(id string, state string) error
+	UpdateImportObservationsTaskStateFunc func(id string, state string, reason string) error
 
 	// UpdateInstanceFunc mocks the UpdateInstance method.
 	UpdateInstanceFunc func(ctx context.Context, ID string, instance *models.Instance) error
 
 	// UpdateObservationInsertedFunc mocks the UpdateObservationInserted method.
-	UpdateObservationInsertedFunc func(ID string, observationInserted int64) error
+	UpdateObservationInsertedFunc func(ID string, observationInserted int64) (*models.ImportObservationsTask, error)
 
 	// UpdateVersionFunc mocks the UpdateVersion method.
 	UpdateVersionFunc func(ID string, version *models.Version) error
@@ -279,8 +467,16 @@ type StorerMock struct {
 	// UpsertVersionFunc mocks the UpsertVersion method.
 	UpsertVersionFunc func(ID string, versionDoc *models.Version) error
 
+	// WithTransactionFunc mocks the WithTransaction method.
+	WithTransactionFunc func(fn func(store.Storer) error) error
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// AddAuditEvent holds details about calls to the AddAuditEvent method.
+		AddAuditEvent []struct {
+			// Event is the event argument value.
+			Event *models.AuditEvent
+		}
 		// AddDimensionToInstance holds details about calls to the AddDimensionToInstance method.
 		AddDimensionToInstance []struct {
 			// Dimension is the dimension argument value.
@@ -327,16 +523,49 @@ type StorerMock struct {
 			// State is the state argument value.
 			State string
 		}
+		// ClaimNextVersion holds details about calls to the ClaimNextVersion method.
+		ClaimNextVersion []struct {
+			// DatasetID is the datasetID argument value.
+			DatasetID string
+			// EditionID is the editionID argument value.
+			EditionID string
+		}
+		// CountEditions holds details about calls to the CountEditions method.
+		CountEditions []struct {
+			// DatasetID is the datasetID argument value.
+			DatasetID string
+		}
+		// CountVersions holds details about calls to the CountVersions method.
+		CountVersions []struct {
+			// DatasetID is the datasetID argument value.
+			DatasetID string
+			// EditionID is the editionID argument value.
+			EditionID string
+			// State is the state argument value.
+			State string
+		}
 		// DeleteDataset holds details about calls to the DeleteDataset method.
 		DeleteDataset []struct {
 			// ID is the ID argument value.
 			ID string
 		}
+		// DeleteDimensionOptions holds details about calls to the DeleteDimensionOptions method.
+		DeleteDimensionOptions []struct {
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+		}
 		// DeleteEdition holds details about calls to the DeleteEdition method.
 		DeleteEdition []struct {
 			// ID is the ID argument value.
 			ID string
 		}
+		// GetAuditEvents holds details about calls to the GetAuditEvents method.
+		GetAuditEvents []struct {
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+		}
 		// GetDataset holds details about calls to the GetDataset method.
 		GetDataset []struct {
 			// ID is the ID argument value.
@@ -344,6 +573,33 @@ type StorerMock struct {
 		}
 		// GetDatasets holds details about calls to the GetDatasets method.
 		GetDatasets []struct {
+			// DatasetType is the datasetType argument value.
+			DatasetType string
+		}
+		// GetDatasetsModifiedSince holds details about calls to the GetDatasetsModifiedSince method.
+		GetDatasetsModifiedSince []struct {
+			// T is the t argument value.
+			T time.Time
+			// DatasetType is the datasetType argument value.
+			DatasetType string
+			// PublishedOnly is the publishedOnly argument value.
+			PublishedOnly bool
+			// Offset is the offset argument value.
+			Offset int
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// GetDimensionCodeList holds details about calls to the GetDimensionCodeList method.
+		GetDimensionCodeList []struct {
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+		}
+		// GetDimensionOptionCounts holds details about calls to the GetDimensionOptionCounts method.
+		GetDimensionOptionCounts []struct {
+			// InstanceID is the instanceID argument value.
+			InstanceID string
 		}
 		// GetDimensionOptions holds details about calls to the GetDimensionOptions method.
 		GetDimensionOptions []struct {
@@ -352,6 +608,15 @@ type StorerMock struct {
 			// Dimension is the dimension argument value.
 			Dimension string
 		}
+		// GetDimensionOptionsByCodes holds details about calls to the GetDimensionOptionsByCodes method.
+		GetDimensionOptionsByCodes []struct {
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+			// Codes is the codes argument value.
+			Codes []string
+		}
 		// GetDimensions holds details about calls to the GetDimensions method.
 		GetDimensions []struct {
 			// DatasetID is the datasetID argument value.
@@ -364,6 +629,9 @@ type StorerMock struct {
 			// ID is the ID argument value.
 			ID string
 		}
+		// GetDistinctThemes holds details about calls to the GetDistinctThemes method.
+		GetDistinctThemes []struct {
+		}
 		// GetEdition holds details about calls to the GetEdition method.
 		GetEdition []struct {
 			// ID is the ID argument value.
@@ -380,17 +648,43 @@ type StorerMock struct {
 			// State is the state argument value.
 			State string
 		}
+		// GetEditionsForDatasets holds details about calls to the GetEditionsForDatasets method.
+		GetEditionsForDatasets []struct {
+			// IDs is the IDs argument value.
+			IDs []string
+			// State is the state argument value.
+			State string
+		}
 		// GetInstance holds details about calls to the GetInstance method.
 		GetInstance []struct {
 			// ID is the ID argument value.
 			ID string
 		}
+		// GetInstanceByIdempotencyKey holds details about calls to the GetInstanceByIdempotencyKey method.
+		GetInstanceByIdempotencyKey []struct {
+			// Key is the key argument value.
+			Key string
+		}
+		// GetInstanceStateCounts holds details about calls to the GetInstanceStateCounts method.
+		GetInstanceStateCounts []struct {
+		}
 		// GetInstances holds details about calls to the GetInstances method.
 		GetInstances []struct {
 			// States is the states argument value.
 			States []string
 			// Datasets is the datasets argument value.
 			Datasets []string
+			// IsBasedOn is the isBasedOn argument value.
+			IsBasedOn []string
+		}
+		// GetLatestVersion holds details about calls to the GetLatestVersion method.
+		GetLatestVersion []struct {
+			// DatasetID is the datasetID argument value.
+			DatasetID string
+			// EditionID is the editionID argument value.
+			EditionID string
+			// State is the state argument value.
+			State string
 		}
 		// GetNextVersion holds details about calls to the GetNextVersion method.
 		GetNextVersion []struct {
@@ -425,6 +719,67 @@ type StorerMock struct {
 			EditionID string
 			// State is the state argument value.
 			State string
+			// ReleaseDates is the releaseDates argument value.
+			ReleaseDates *models.ReleaseDateRange
+		}
+		// GetVersionsByNumbers holds details about calls to the GetVersionsByNumbers method.
+		GetVersionsByNumbers []struct {
+			// DatasetID is the datasetID argument value.
+			DatasetID string
+			// Refs is the refs argument value.
+			Refs []models.EditionVersionRef
+		}
+		// PatchDataset holds details about calls to the PatchDataset method.
+		PatchDataset []struct {
+			// ID is the ID argument value.
+			ID string
+			// Patch is the patch argument value.
+			Patch *models.DatasetPatch
+			// CurrentState is the currentState argument value.
+			CurrentState string
+		}
+		// PurgeInstances holds details about calls to the PurgeInstances method.
+		PurgeInstances []struct {
+			// OlderThan is the olderThan argument value.
+			OlderThan time.Time
+			// States is the states argument value.
+			States []string
+		}
+		// ReleaseVersion holds details about calls to the ReleaseVersion method.
+		ReleaseVersion []struct {
+			// DatasetID is the datasetID argument value.
+			DatasetID string
+			// EditionID is the editionID argument value.
+			EditionID string
+			// Version is the version argument value.
+			Version int
+		}
+		// RenameDimension holds details about calls to the RenameDimension method.
+		RenameDimension []struct {
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// OldName is the oldName argument value.
+			OldName string
+			// NewName is the newName argument value.
+			NewName string
+		}
+		// ResetInstance holds details about calls to the ResetInstance method.
+		ResetInstance []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the ID argument value.
+			ID string
+			// CurrentState is the currentState argument value.
+			CurrentState string
+			// UniqueTimestamp is the uniqueTimestamp argument value.
+			UniqueTimestamp bson.MongoTimestamp
+		}
+		// SetDatasetLinksFrozen holds details about calls to the SetDatasetLinksFrozen method.
+		SetDatasetLinksFrozen []struct {
+			// ID is the ID argument value.
+			ID string
+			// Frozen is the frozen argument value.
+			Frozen bool
 		}
 		// SetInstanceIsPublished holds details about calls to the SetInstanceIsPublished method.
 		SetInstanceIsPublished []struct {
@@ -442,6 +797,33 @@ type StorerMock struct {
 			// Limit is the limit argument value.
 			Limit *int
 		}
+		// StreamDimensionOptions holds details about calls to the StreamDimensionOptions method.
+		StreamDimensionOptions []struct {
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+			// Fn is the fn argument value.
+			Fn func(*models.DimensionOption) error
+		}
+		// StreamInstances holds details about calls to the StreamInstances method.
+		StreamInstances []struct {
+			// States is the states argument value.
+			States []string
+			// Datasets is the datasets argument value.
+			Datasets []string
+			// IsBasedOn is the isBasedOn argument value.
+			IsBasedOn []string
+			// Fn is the fn argument value.
+			Fn func(*models.Instance) error
+		}
+		// SummariseEditionVersions holds details about calls to the SummariseEditionVersions method.
+		SummariseEditionVersions []struct {
+			// DatasetID is the datasetID argument value.
+			DatasetID string
+			// State is the state argument value.
+			State string
+		}
 		// UpdateBuildHierarchyTaskState holds details about calls to the UpdateBuildHierarchyTaskState method.
 		UpdateBuildHierarchyTaskState []struct {
 			// ID is the id argument value.
@@ -450,6 +832,8 @@ type StorerMock struct {
 			Dimension string
 			// State is the state argument value.
 			State string
+			// Reason is the reason argument value.
+			Reason string
 		}
 		// UpdateBuildSearchTaskState holds details about calls to the UpdateBuildSearchTaskState method.
 		UpdateBuildSearchTaskState []struct {
@@ -459,6 +843,8 @@ type StorerMock struct {
 			Dimension string
 			// State is the state argument value.
 			State string
+			// Reason is the reason argument value.
+			Reason string
 		}
 		// UpdateDataset holds details about calls to the UpdateDataset method.
 		UpdateDataset []struct {
@@ -483,12 +869,25 @@ type StorerMock struct {
 			// Dimension is the dimension argument value.
 			Dimension *models.DimensionOption
 		}
+		// UpdateDimensionOption holds details about calls to the UpdateDimensionOption method.
+		UpdateDimensionOption []struct {
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+			// Option is the option argument value.
+			Option string
+			// Update is the update argument value.
+			Update *models.DimensionOptionUpdate
+		}
 		// UpdateImportObservationsTaskState holds details about calls to the UpdateImportObservationsTaskState method.
 		UpdateImportObservationsTaskState []struct {
 			// ID is the id argument value.
 			ID string
 			// State is the state argument value.
 			State string
+			// Reason is the reason argument value.
+			Reason string
 		}
 		// UpdateInstance holds details about calls to the UpdateInstance method.
 		UpdateInstance []struct {
@@ -543,9 +942,45 @@ type StorerMock struct {
 			// VersionDoc is the versionDoc argument value.
 			VersionDoc *models.Version
 		}
+		// WithTransaction holds details about calls to the WithTransaction method.
+		WithTransaction []struct {
+			// Fn is the fn argument value.
+			Fn func(store.Storer) error
+		}
 	}
 }
 
+// AddAuditEvent calls AddAuditEventFunc.
+func (mock *StorerMock) AddAuditEvent(event *models.AuditEvent) error {
+	if mock.AddAuditEventFunc == nil {
+		panic("StorerMock.AddAuditEventFunc: method is nil but Storer.AddAuditEvent was just called")
+	}
+	callInfo := struct {
+		Event *models.AuditEvent
+	}{
+		Event: event,
+	}
+	lockStorerMockAddAuditEvent.Lock()
+	mock.calls.AddAuditEvent = append(mock.calls.AddAuditEvent, callInfo)
+	lockStorerMockAddAuditEvent.Unlock()
+	return mock.AddAuditEventFunc(event)
+}
+
+// AddAuditEventCalls gets all the calls that were made to AddAuditEvent.
+// Check the length with:
+//     len(mockedStorer.AddAuditEventCalls())
+func (mock *StorerMock) AddAuditEventCalls() []struct {
+	Event *models.AuditEvent
+} {
+	var calls []struct {
+		Event *models.AuditEvent
+	}
+	lockStorerMockAddAuditEvent.RLock()
+	calls = mock.calls.AddAuditEvent
+	lockStorerMockAddAuditEvent.RUnlock()
+	return calls
+}
+
 // AddDimensionToInstance calls AddDimensionToInstanceFunc.
 func (mock *StorerMock) AddDimensionToInstance(dimension *models.CachedDimensionOption) error {
 	if mock.AddDimensionToInstanceFunc == nil {
@@ -764,6 +1199,111 @@ func (mock *StorerMock) CheckEditionExistsCalls() []struct {
 	return calls
 }
 
+// ClaimNextVersion calls ClaimNextVersionFunc.
+func (mock *StorerMock) ClaimNextVersion(datasetID string, editionID string) (int, error) {
+	if mock.ClaimNextVersionFunc == nil {
+		panic("StorerMock.ClaimNextVersionFunc: method is nil but Storer.ClaimNextVersion was just called")
+	}
+	callInfo := struct {
+		DatasetID string
+		EditionID string
+	}{
+		DatasetID: datasetID,
+		EditionID: editionID,
+	}
+	lockStorerMockClaimNextVersion.Lock()
+	mock.calls.ClaimNextVersion = append(mock.calls.ClaimNextVersion, callInfo)
+	lockStorerMockClaimNextVersion.Unlock()
+	return mock.ClaimNextVersionFunc(datasetID, editionID)
+}
+
+// ClaimNextVersionCalls gets all the calls that were made to ClaimNextVersion.
+// Check the length with:
+//     len(mockedStorer.ClaimNextVersionCalls())
+func (mock *StorerMock) ClaimNextVersionCalls() []struct {
+	DatasetID string
+	EditionID string
+} {
+	var calls []struct {
+		DatasetID string
+		EditionID string
+	}
+	lockStorerMockClaimNextVersion.RLock()
+	calls = mock.calls.ClaimNextVersion
+	lockStorerMockClaimNextVersion.RUnlock()
+	return calls
+}
+
+// CountEditions calls CountEditionsFunc.
+func (mock *StorerMock) CountEditions(datasetID string) (int, error) {
+	if mock.CountEditionsFunc == nil {
+		panic("StorerMock.CountEditionsFunc: method is nil but Storer.CountEditions was just called")
+	}
+	callInfo := struct {
+		DatasetID string
+	}{
+		DatasetID: datasetID,
+	}
+	lockStorerMockCountEditions.Lock()
+	mock.calls.CountEditions = append(mock.calls.CountEditions, callInfo)
+	lockStorerMockCountEditions.Unlock()
+	return mock.CountEditionsFunc(datasetID)
+}
+
+// CountEditionsCalls gets all the calls that were made to CountEditions.
+// Check the length with:
+//     len(mockedStorer.CountEditionsCalls())
+func (mock *StorerMock) CountEditionsCalls() []struct {
+	DatasetID string
+} {
+	var calls []struct {
+		DatasetID string
+	}
+	lockStorerMockCountEditions.RLock()
+	calls = mock.calls.CountEditions
+	lockStorerMockCountEditions.RUnlock()
+	return calls
+}
+
+// CountVersions calls CountVersionsFunc.
+func (mock *StorerMock) CountVersions(datasetID string, editionID string, state string) (int, error) {
+	if mock.CountVersionsFunc == nil {
+		panic("StorerMock.CountVersionsFunc: method is nil but Storer.CountVersions was just called")
+	}
+	callInfo := struct {
+		DatasetID string
+		EditionID string
+		State     string
+	}{
+		DatasetID: datasetID,
+		EditionID: editionID,
+		State:     state,
+	}
+	lockStorerMockCountVersions.Lock()
+	mock.calls.CountVersions = append(mock.calls.CountVersions, callInfo)
+	lockStorerMockCountVersions.Unlock()
+	return mock.CountVersionsFunc(datasetID, editionID, state)
+}
+
+// CountVersionsCalls gets all the calls that were made to CountVersions.
+// Check the length with:
+//     len(mockedStorer.CountVersionsCalls())
+func (mock *StorerMock) CountVersionsCalls() []struct {
+	DatasetID string
+	EditionID string
+	State     string
+} {
+	var calls []struct {
+		DatasetID string
+		EditionID string
+		State     string
+	}
+	lockStorerMockCountVersions.RLock()
+	calls = mock.calls.CountVersions
+	lockStorerMockCountVersions.RUnlock()
+	return calls
+}
+
 // DeleteDataset calls DeleteDatasetFunc.
 func (mock *StorerMock) DeleteDataset(ID string) error {
 	if mock.DeleteDatasetFunc == nil {
@@ -795,6 +1335,41 @@ func (mock *StorerMock) DeleteDatasetCalls() []struct {
 	return calls
 }
 
+// DeleteDimensionOptions calls DeleteDimensionOptionsFunc.
+func (mock *StorerMock) DeleteDimensionOptions(instanceID string, dimension string) error {
+	if mock.DeleteDimensionOptionsFunc == nil {
+		panic("StorerMock.DeleteDimensionOptionsFunc: method is nil but Storer.DeleteDimensionOptions was just called")
+	}
+	callInfo := struct {
+		InstanceID string
+		Dimension  string
+	}{
+		InstanceID: instanceID,
+		Dimension:  dimension,
+	}
+	lockStorerMockDeleteDimensionOptions.Lock()
+	mock.calls.DeleteDimensionOptions = append(mock.calls.DeleteDimensionOptions, callInfo)
+	lockStorerMockDeleteDimensionOptions.Unlock()
+	return mock.DeleteDimensionOptionsFunc(instanceID, dimension)
+}
+
+// DeleteDimensionOptionsCalls gets all the calls that were made to DeleteDimensionOptions.
+// Check the length with:
+//     len(mockedStorer.DeleteDimensionOptionsCalls())
+func (mock *StorerMock) DeleteDimensionOptionsCalls() []struct {
+	InstanceID string
+	Dimension  string
+} {
+	var calls []struct {
+		InstanceID string
+		Dimension  string
+	}
+	lockStorerMockDeleteDimensionOptions.RLock()
+	calls = mock.calls.DeleteDimensionOptions
+	lockStorerMockDeleteDimensionOptions.RUnlock()
+	return calls
+}
+
 // DeleteEdition calls DeleteEditionFunc.
 func (mock *StorerMock) DeleteEdition(ID string) error {
 	if mock.DeleteEditionFunc == nil {
@@ -826,9 +1401,40 @@ func (mock *StorerMock) DeleteEditionCalls() []struct {
 	return calls
 }
 
-// GetDataset calls GetDatasetFunc.
-func (mock *StorerMock) GetDataset(ID string) (*models.DatasetUpdate, error) {
-	if mock.GetDatasetFunc == nil {
+// GetAuditEvents calls GetAuditEventsFunc.
+func (mock *StorerMock) GetAuditEvents(instanceID string) ([]models.AuditEvent, error) {
+	if mock.GetAuditEventsFunc == nil {
+		panic("StorerMock.GetAuditEventsFunc: method is nil but Storer.GetAuditEvents was just called")
+	}
+	callInfo := struct {
+		InstanceID string
+	}{
+		InstanceID: instanceID,
+	}
+	lockStorerMockGetAuditEvents.Lock()
+	mock.calls.GetAuditEvents = append(mock.calls.GetAuditEvents, callInfo)
+	lockStorerMockGetAuditEvents.Unlock()
+	return mock.GetAuditEventsFunc(instanceID)
+}
+
+// GetAuditEventsCalls gets all the calls that were made to GetAuditEvents.
+// Check the length with:
+//     len(mockedStorer.GetAuditEventsCalls())
+func (mock *StorerMock) GetAuditEventsCalls() []struct {
+	InstanceID string
+} {
+	var calls []struct {
+		InstanceID string
+	}
+	lockStorerMockGetAuditEvents.RLock()
+	calls = mock.calls.GetAuditEvents
+	lockStorerMockGetAuditEvents.RUnlock()
+	return calls
+}
+
+// GetDataset calls GetDatasetFunc.
+func (mock *StorerMock) GetDataset(ID string) (*models.DatasetUpdate, error) {
+	if mock.GetDatasetFunc == nil {
 		panic("StorerMock.GetDatasetFunc: method is nil but Storer.GetDataset was just called")
 	}
 	callInfo := struct {
@@ -858,24 +1464,29 @@ func (mock *StorerMock) GetDatasetCalls() []struct {
 }
 
 // GetDatasets calls GetDatasetsFunc.
-func (mock *StorerMock) GetDatasets() ([]models.DatasetUpdate, error) {
+func (mock *StorerMock) GetDatasets(datasetType string) ([]models.DatasetUpdate, error) {
 	if mock.GetDatasetsFunc == nil {
 		panic("StorerMock.GetDatasetsFunc: method is nil but Storer.GetDatasets was just called")
 	}
 	callInfo := struct {
-	}{}
+		DatasetType string
+	}{
+		DatasetType: datasetType,
+	}
 	lockStorerMockGetDatasets.Lock()
 	mock.calls.GetDatasets = append(mock.calls.GetDatasets, callInfo)
 	lockStorerMockGetDatasets.Unlock()
-	return mock.GetDatasetsFunc()
+	return mock.GetDatasetsFunc(datasetType)
 }
 
 // GetDatasetsCalls gets all the calls that were made to GetDatasets.
 // Check the length with:
 //     len(mockedStorer.GetDatasetsCalls())
 func (mock *StorerMock) GetDatasetsCalls() []struct {
+	DatasetType string
 } {
 	var calls []struct {
+		DatasetType string
 	}
 	lockStorerMockGetDatasets.RLock()
 	calls = mock.calls.GetDatasets
@@ -883,6 +1494,119 @@ func (mock *StorerMock) GetDatasetsCalls() []struct {
 	return calls
 }
 
+// GetDatasetsModifiedSince calls GetDatasetsModifiedSinceFunc.
+func (mock *StorerMock) GetDatasetsModifiedSince(t time.Time, datasetType string, publishedOnly bool, offset int, limit int) (*models.DatasetUpdatePage, error) {
+	if mock.GetDatasetsModifiedSinceFunc == nil {
+		panic("StorerMock.GetDatasetsModifiedSinceFunc: method is nil but Storer.GetDatasetsModifiedSince was just called")
+	}
+	callInfo := struct {
+		T             time.Time
+		DatasetType   string
+		PublishedOnly bool
+		Offset        int
+		Limit         int
+	}{
+		T:             t,
+		DatasetType:   datasetType,
+		PublishedOnly: publishedOnly,
+		Offset:        offset,
+		Limit:         limit,
+	}
+	lockStorerMockGetDatasetsModifiedSince.Lock()
+	mock.calls.GetDatasetsModifiedSince = append(mock.calls.GetDatasetsModifiedSince, callInfo)
+	lockStorerMockGetDatasetsModifiedSince.Unlock()
+	return mock.GetDatasetsModifiedSinceFunc(t, datasetType, publishedOnly, offset, limit)
+}
+
+// GetDatasetsModifiedSinceCalls gets all the calls that were made to GetDatasetsModifiedSince.
+// Check the length with:
+//     len(mockedStorer.GetDatasetsModifiedSinceCalls())
+func (mock *StorerMock) GetDatasetsModifiedSinceCalls() []struct {
+	T             time.Time
+	DatasetType   string
+	PublishedOnly bool
+	Offset        int
+	Limit         int
+} {
+	var calls []struct {
+		T             time.Time
+		DatasetType   string
+		PublishedOnly bool
+		Offset        int
+		Limit         int
+	}
+	lockStorerMockGetDatasetsModifiedSince.RLock()
+	calls = mock.calls.GetDatasetsModifiedSince
+	lockStorerMockGetDatasetsModifiedSince.RUnlock()
+	return calls
+}
+
+// GetDimensionCodeList calls GetDimensionCodeListFunc.
+func (mock *StorerMock) GetDimensionCodeList(instanceID string, dimension string) (string, error) {
+	if mock.GetDimensionCodeListFunc == nil {
+		panic("StorerMock.GetDimensionCodeListFunc: method is nil but Storer.GetDimensionCodeList was just called")
+	}
+	callInfo := struct {
+		InstanceID string
+		Dimension  string
+	}{
+		InstanceID: instanceID,
+		Dimension:  dimension,
+	}
+	lockStorerMockGetDimensionCodeList.Lock()
+	mock.calls.GetDimensionCodeList = append(mock.calls.GetDimensionCodeList, callInfo)
+	lockStorerMockGetDimensionCodeList.Unlock()
+	return mock.GetDimensionCodeListFunc(instanceID, dimension)
+}
+
+// GetDimensionCodeListCalls gets all the calls that were made to GetDimensionCodeList.
+// Check the length with:
+//     len(mockedStorer.GetDimensionCodeListCalls())
+func (mock *StorerMock) GetDimensionCodeListCalls() []struct {
+	InstanceID string
+	Dimension  string
+} {
+	var calls []struct {
+		InstanceID string
+		Dimension  string
+	}
+	lockStorerMockGetDimensionCodeList.RLock()
+	calls = mock.calls.GetDimensionCodeList
+	lockStorerMockGetDimensionCodeList.RUnlock()
+	return calls
+}
+
+// GetDimensionOptionCounts calls GetDimensionOptionCountsFunc.
+func (mock *StorerMock) GetDimensionOptionCounts(instanceID string) ([]models.DimensionOptionCount, error) {
+	if mock.GetDimensionOptionCountsFunc == nil {
+		panic("StorerMock.GetDimensionOptionCountsFunc: method is nil but Storer.GetDimensionOptionCounts was just called")
+	}
+	callInfo := struct {
+		InstanceID string
+	}{
+		InstanceID: instanceID,
+	}
+	lockStorerMockGetDimensionOptionCounts.Lock()
+	mock.calls.GetDimensionOptionCounts = append(mock.calls.GetDimensionOptionCounts, callInfo)
+	lockStorerMockGetDimensionOptionCounts.Unlock()
+	return mock.GetDimensionOptionCountsFunc(instanceID)
+}
+
+// GetDimensionOptionCountsCalls gets all the calls that were made to GetDimensionOptionCounts.
+// Check the length with:
+//     len(mockedStorer.GetDimensionOptionCountsCalls())
+func (mock *StorerMock) GetDimensionOptionCountsCalls() []struct {
+	InstanceID string
+} {
+	var calls []struct {
+		InstanceID string
+	}
+	lockStorerMockGetDimensionOptionCounts.RLock()
+	calls = mock.calls.GetDimensionOptionCounts
+	lockStorerMockGetDimensionOptionCounts.RUnlock()
+	return calls
+}
+
 // GetDimensionOptions calls GetDimensionOptionsFunc.
 func (mock *StorerMock) GetDimensionOptions(version *models.Version, dimension string) (*models.DimensionOptionResults, error) {
 	if mock.GetDimensionOptionsFunc == nil {
@@ -918,6 +1642,45 @@ func (mock *StorerMock) GetDimensionOptionsCalls() []struct {
 	return calls
 }
 
+// GetDimensionOptionsByCodes calls GetDimensionOptionsByCodesFunc.
+func (mock *StorerMock) GetDimensionOptionsByCodes(instanceID string, dimension string, codes []string) (*models.DimensionOptionResults, error) {
+	if mock.GetDimensionOptionsByCodesFunc == nil {
+		panic("StorerMock.GetDimensionOptionsByCodesFunc: method is nil but Storer.GetDimensionOptionsByCodes was just called")
+	}
+	callInfo := struct {
+		InstanceID string
+		Dimension  string
+		Codes      []string
+	}{
+		InstanceID: instanceID,
+		Dimension:  dimension,
+		Codes:      codes,
+	}
+	lockStorerMockGetDimensionOptionsByCodes.Lock()
+	mock.calls.GetDimensionOptionsByCodes = append(mock.calls.GetDimensionOptionsByCodes, callInfo)
+	lockStorerMockGetDimensionOptionsByCodes.Unlock()
+	return mock.GetDimensionOptionsByCodesFunc(instanceID, dimension, codes)
+}
+
+// GetDimensionOptionsByCodesCalls gets all the calls that were made to GetDimensionOptionsByCodes.
+// Check the length with:
+//     len(mockedStorer.GetDimensionOptionsByCodesCalls())
+func (mock *StorerMock) GetDimensionOptionsByCodesCalls() []struct {
+	InstanceID string
+	Dimension  string
+	Codes      []string
+} {
+	var calls []struct {
+		InstanceID string
+		Dimension  string
+		Codes      []string
+	}
+	lockStorerMockGetDimensionOptionsByCodes.RLock()
+	calls = mock.calls.GetDimensionOptionsByCodes
+	lockStorerMockGetDimensionOptionsByCodes.RUnlock()
+	return calls
+}
+
 // GetDimensions calls GetDimensionsFunc.
 func (mock *StorerMock) GetDimensions(datasetID string, versionID string) ([]bson.M, error) {
 	if mock.GetDimensionsFunc == nil {
@@ -984,6 +1747,32 @@ func (mock *StorerMock) GetDimensionsFromInstanceCalls() []struct {
 	return calls
 }
 
+// GetDistinctThemes calls GetDistinctThemesFunc.
+func (mock *StorerMock) GetDistinctThemes() ([]string, error) {
+	if mock.GetDistinctThemesFunc == nil {
+		panic("StorerMock.GetDistinctThemesFunc: method is nil but Storer.GetDistinctThemes was just called")
+	}
+	callInfo := struct {
+	}{}
+	lockStorerMockGetDistinctThemes.Lock()
+	mock.calls.GetDistinctThemes = append(mock.calls.GetDistinctThemes, callInfo)
+	lockStorerMockGetDistinctThemes.Unlock()
+	return mock.GetDistinctThemesFunc()
+}
+
+// GetDistinctThemesCalls gets all the calls that were made to GetDistinctThemes.
+// Check the length with:
+//     len(mockedStorer.GetDistinctThemesCalls())
+func (mock *StorerMock) GetDistinctThemesCalls() []struct {
+} {
+	var calls []struct {
+	}
+	lockStorerMockGetDistinctThemes.RLock()
+	calls = mock.calls.GetDistinctThemes
+	lockStorerMockGetDistinctThemes.RUnlock()
+	return calls
+}
+
 // GetEdition calls GetEditionFunc.
 func (mock *StorerMock) GetEdition(ID string, editionID string, state string) (*models.EditionUpdate, error) {
 	if mock.GetEditionFunc == nil {
@@ -1058,6 +1847,41 @@ func (mock *StorerMock) GetEditionsCalls() []struct {
 	return calls
 }
 
+// GetEditionsForDatasets calls GetEditionsForDatasetsFunc.
+func (mock *StorerMock) GetEditionsForDatasets(IDs []string, state string) (map[string][]*models.EditionUpdate, error) {
+	if mock.GetEditionsForDatasetsFunc == nil {
+		panic("StorerMock.GetEditionsForDatasetsFunc: method is nil but Storer.GetEditionsForDatasets was just called")
+	}
+	callInfo := struct {
+		IDs   []string
+		State string
+	}{
+		IDs:   IDs,
+		State: state,
+	}
+	lockStorerMockGetEditionsForDatasets.Lock()
+	mock.calls.GetEditionsForDatasets = append(mock.calls.GetEditionsForDatasets, callInfo)
+	lockStorerMockGetEditionsForDatasets.Unlock()
+	return mock.GetEditionsForDatasetsFunc(IDs, state)
+}
+
+// GetEditionsForDatasetsCalls gets all the calls that were made to GetEditionsForDatasets.
+// Check the length with:
+//     len(mockedStorer.GetEditionsForDatasetsCalls())
+func (mock *StorerMock) GetEditionsForDatasetsCalls() []struct {
+	IDs   []string
+	State string
+} {
+	var calls []struct {
+		IDs   []string
+		State string
+	}
+	lockStorerMockGetEditionsForDatasets.RLock()
+	calls = mock.calls.GetEditionsForDatasets
+	lockStorerMockGetEditionsForDatasets.RUnlock()
+	return calls
+}
+
 // GetInstance calls GetInstanceFunc.
 func (mock *StorerMock) GetInstance(ID string) (*models.Instance, error) {
 	if mock.GetInstanceFunc == nil {
@@ -1089,34 +1913,95 @@ func (mock *StorerMock) GetInstanceCalls() []struct {
 	return calls
 }
 
+// GetInstanceByIdempotencyKey calls GetInstanceByIdempotencyKeyFunc.
+func (mock *StorerMock) GetInstanceByIdempotencyKey(key string) (*models.Instance, error) {
+	if mock.GetInstanceByIdempotencyKeyFunc == nil {
+		panic("StorerMock.GetInstanceByIdempotencyKeyFunc: method is nil but Storer.GetInstanceByIdempotencyKey was just called")
+	}
+	callInfo := struct {
+		Key string
+	}{
+		Key: key,
+	}
+	lockStorerMockGetInstanceByIdempotencyKey.Lock()
+	mock.calls.GetInstanceByIdempotencyKey = append(mock.calls.GetInstanceByIdempotencyKey, callInfo)
+	lockStorerMockGetInstanceByIdempotencyKey.Unlock()
+	return mock.GetInstanceByIdempotencyKeyFunc(key)
+}
+
+// GetInstanceByIdempotencyKeyCalls gets all the calls that were made to GetInstanceByIdempotencyKey.
+// Check the length with:
+//     len(mockedStorer.GetInstanceByIdempotencyKeyCalls())
+func (mock *StorerMock) GetInstanceByIdempotencyKeyCalls() []struct {
+	Key string
+} {
+	var calls []struct {
+		Key string
+	}
+	lockStorerMockGetInstanceByIdempotencyKey.RLock()
+	calls = mock.calls.GetInstanceByIdempotencyKey
+	lockStorerMockGetInstanceByIdempotencyKey.RUnlock()
+	return calls
+}
+
+// GetInstanceStateCounts calls GetInstanceStateCountsFunc.
+func (mock *StorerMock) GetInstanceStateCounts() ([]models.InstanceStateCount, error) {
+	if mock.GetInstanceStateCountsFunc == nil {
+		panic("StorerMock.GetInstanceStateCountsFunc: method is nil but Storer.GetInstanceStateCounts was just called")
+	}
+	callInfo := struct {
+	}{}
+	lockStorerMockGetInstanceStateCounts.Lock()
+	mock.calls.GetInstanceStateCounts = append(mock.calls.GetInstanceStateCounts, callInfo)
+	lockStorerMockGetInstanceStateCounts.Unlock()
+	return mock.GetInstanceStateCountsFunc()
+}
+
+// GetInstanceStateCountsCalls gets all the calls that were made to GetInstanceStateCounts.
+// Check the length with:
+//     len(mockedStorer.GetInstanceStateCountsCalls())
+func (mock *StorerMock) GetInstanceStateCountsCalls() []struct {
+} {
+	var calls []struct {
+	}
+	lockStorerMockGetInstanceStateCounts.RLock()
+	calls = mock.calls.GetInstanceStateCounts
+	lockStorerMockGetInstanceStateCounts.RUnlock()
+	return calls
+}
+
 // GetInstances calls GetInstancesFunc.
-func (mock *StorerMock) GetInstances(states []string, datasets []string) (*models.InstanceResults, error) {
+func (mock *StorerMock) GetInstances(states []string, datasets []string, isBasedOn []string) (*models.InstanceResults, error) {
 	if mock.GetInstancesFunc == nil {
 		panic("StorerMock.GetInstancesFunc: method is nil but Storer.GetInstances was just called")
 	}
 	callInfo := struct {
-		States   []string
-		Datasets []string
+		States    []string
+		Datasets  []string
+		IsBasedOn []string
 	}{
-		States:   states,
-		Datasets: datasets,
+		States:    states,
+		Datasets:  datasets,
+		IsBasedOn: isBasedOn,
 	}
 	lockStorerMockGetInstances.Lock()
 	mock.calls.GetInstances = append(mock.calls.GetInstances, callInfo)
 	lockStorerMockGetInstances.Unlock()
-	return mock.GetInstancesFunc(states, datasets)
+	return mock.GetInstancesFunc(states, datasets, isBasedOn)
 }
 
 // GetInstancesCalls gets all the calls that were made to GetInstances.
 // Check the length with:
 //     len(mockedStorer.GetInstancesCalls())
 func (mock *StorerMock) GetInstancesCalls() []struct {
-	States   []string
-	Datasets []string
+	States    []string
+	Datasets  []string
+	IsBasedOn []string
 } {
 	var calls []struct {
-		States   []string
-		Datasets []string
+		States    []string
+		Datasets  []string
+		IsBasedOn []string
 	}
 	lockStorerMockGetInstances.RLock()
 	calls = mock.calls.GetInstances
@@ -1124,6 +2009,45 @@ func (mock *StorerMock) GetInstancesCalls() []struct {
 	return calls
 }
 
+// GetLatestVersion calls GetLatestVersionFunc.
+func (mock *StorerMock) GetLatestVersion(datasetID string, editionID string, state string) (*models.Version, error) {
+	if mock.GetLatestVersionFunc == nil {
+		panic("StorerMock.GetLatestVersionFunc: method is nil but Storer.GetLatestVersion was just called")
+	}
+	callInfo := struct {
+		DatasetID string
+		EditionID string
+		State     string
+	}{
+		DatasetID: datasetID,
+		EditionID: editionID,
+		State:     state,
+	}
+	lockStorerMockGetLatestVersion.Lock()
+	mock.calls.GetLatestVersion = append(mock.calls.GetLatestVersion, callInfo)
+	lockStorerMockGetLatestVersion.Unlock()
+	return mock.GetLatestVersionFunc(datasetID, editionID, state)
+}
+
+// GetLatestVersionCalls gets all the calls that were made to GetLatestVersion.
+// Check the length with:
+//     len(mockedStorer.GetLatestVersionCalls())
+func (mock *StorerMock) GetLatestVersionCalls() []struct {
+	DatasetID string
+	EditionID string
+	State     string
+} {
+	var calls []struct {
+		DatasetID string
+		EditionID string
+		State     string
+	}
+	lockStorerMockGetLatestVersion.RLock()
+	calls = mock.calls.GetLatestVersion
+	lockStorerMockGetLatestVersion.RUnlock()
+	return calls
+}
+
 // GetNextVersion calls GetNextVersionFunc.
 func (mock *StorerMock) GetNextVersion(datasetID string, editionID string) (int, error) {
 	if mock.GetNextVersionFunc == nil {
@@ -1238,41 +2162,310 @@ func (mock *StorerMock) GetVersionCalls() []struct {
 }
 
 // GetVersions calls GetVersionsFunc.
-func (mock *StorerMock) GetVersions(datasetID string, editionID string, state string) (*models.VersionResults, error) {
+func (mock *StorerMock) GetVersions(datasetID string, editionID string, state string, releaseDates *models.ReleaseDateRange) (*models.VersionResults, error) {
 	if mock.GetVersionsFunc == nil {
 		panic("StorerMock.GetVersionsFunc: method is nil but Storer.GetVersions was just called")
 	}
 	callInfo := struct {
-		DatasetID string
-		EditionID string
-		State     string
+		DatasetID    string
+		EditionID    string
+		State        string
+		ReleaseDates *models.ReleaseDateRange
 	}{
-		DatasetID: datasetID,
-		EditionID: editionID,
-		State:     state,
+		DatasetID:    datasetID,
+		EditionID:    editionID,
+		State:        state,
+		ReleaseDates: releaseDates,
 	}
 	lockStorerMockGetVersions.Lock()
 	mock.calls.GetVersions = append(mock.calls.GetVersions, callInfo)
 	lockStorerMockGetVersions.Unlock()
-	return mock.GetVersionsFunc(datasetID, editionID, state)
+	return mock.GetVersionsFunc(datasetID, editionID, state, releaseDates)
 }
 
 // GetVersionsCalls gets all the calls that were made to GetVersions.
 // Check the length with:
 //     len(mockedStorer.GetVersionsCalls())
 func (mock *StorerMock) GetVersionsCalls() []struct {
+	DatasetID    string
+	EditionID    string
+	State        string
+	ReleaseDates *models.ReleaseDateRange
+} {
+	var calls []struct {
+		DatasetID    string
+		EditionID    string
+		State        string
+		ReleaseDates *models.ReleaseDateRange
+	}
+	lockStorerMockGetVersions.RLock()
+	calls = mock.calls.GetVersions
+	lockStorerMockGetVersions.RUnlock()
+	return calls
+}
+
+// GetVersionsByNumbers calls GetVersionsByNumbersFunc.
+func (mock *StorerMock) GetVersionsByNumbers(datasetID string, refs []models.EditionVersionRef) ([]models.Version, error) {
+	if mock.GetVersionsByNumbersFunc == nil {
+		panic("StorerMock.GetVersionsByNumbersFunc: method is nil but Storer.GetVersionsByNumbers was just called")
+	}
+	callInfo := struct {
+		DatasetID string
+		Refs      []models.EditionVersionRef
+	}{
+		DatasetID: datasetID,
+		Refs:      refs,
+	}
+	lockStorerMockGetVersionsByNumbers.Lock()
+	mock.calls.GetVersionsByNumbers = append(mock.calls.GetVersionsByNumbers, callInfo)
+	lockStorerMockGetVersionsByNumbers.Unlock()
+	return mock.GetVersionsByNumbersFunc(datasetID, refs)
+}
+
+// GetVersionsByNumbersCalls gets all the calls that were made to GetVersionsByNumbers.
+// Check the length with:
+//     len(mockedStorer.GetVersionsByNumbersCalls())
+func (mock *StorerMock) GetVersionsByNumbersCalls() []struct {
+	DatasetID string
+	Refs      []models.EditionVersionRef
+} {
+	var calls []struct {
+		DatasetID string
+		Refs      []models.EditionVersionRef
+	}
+	lockStorerMockGetVersionsByNumbers.RLock()
+	calls = mock.calls.GetVersionsByNumbers
+	lockStorerMockGetVersionsByNumbers.RUnlock()
+	return calls
+}
+
+// PatchDataset calls PatchDatasetFunc.
+func (mock *StorerMock) PatchDataset(ID string, patch *models.DatasetPatch, currentState string) error {
+	if mock.PatchDatasetFunc == nil {
+		panic("StorerMock.PatchDatasetFunc: method is nil but Storer.PatchDataset was just called")
+	}
+	callInfo := struct {
+		ID           string
+		Patch        *models.DatasetPatch
+		CurrentState string
+	}{
+		ID:           ID,
+		Patch:        patch,
+		CurrentState: currentState,
+	}
+	lockStorerMockPatchDataset.Lock()
+	mock.calls.PatchDataset = append(mock.calls.PatchDataset, callInfo)
+	lockStorerMockPatchDataset.Unlock()
+	return mock.PatchDatasetFunc(ID, patch, currentState)
+}
+
+// PatchDatasetCalls gets all the calls that were made to PatchDataset.
+// Check the length with:
+//     len(mockedStorer.PatchDatasetCalls())
+func (mock *StorerMock) PatchDatasetCalls() []struct {
+	ID           string
+	Patch        *models.DatasetPatch
+	CurrentState string
+} {
+	var calls []struct {
+		ID           string
+		Patch        *models.DatasetPatch
+		CurrentState string
+	}
+	lockStorerMockPatchDataset.RLock()
+	calls = mock.calls.PatchDataset
+	lockStorerMockPatchDataset.RUnlock()
+	return calls
+}
+
+// PurgeInstances calls PurgeInstancesFunc.
+func (mock *StorerMock) PurgeInstances(olderThan time.Time, states []string) (int, error) {
+	if mock.PurgeInstancesFunc == nil {
+		panic("StorerMock.PurgeInstancesFunc: method is nil but Storer.PurgeInstances was just called")
+	}
+	callInfo := struct {
+		OlderThan time.Time
+		States    []string
+	}{
+		OlderThan: olderThan,
+		States:    states,
+	}
+	lockStorerMockPurgeInstances.Lock()
+	mock.calls.PurgeInstances = append(mock.calls.PurgeInstances, callInfo)
+	lockStorerMockPurgeInstances.Unlock()
+	return mock.PurgeInstancesFunc(olderThan, states)
+}
+
+// PurgeInstancesCalls gets all the calls that were made to PurgeInstances.
+// Check the length with:
+//     len(mockedStorer.PurgeInstancesCalls())
+func (mock *StorerMock) PurgeInstancesCalls() []struct {
+	OlderThan time.Time
+	States    []string
+} {
+	var calls []struct {
+		OlderThan time.Time
+		States    []string
+	}
+	lockStorerMockPurgeInstances.RLock()
+	calls = mock.calls.PurgeInstances
+	lockStorerMockPurgeInstances.RUnlock()
+	return calls
+}
+
+// ReleaseVersion calls ReleaseVersionFunc.
+func (mock *StorerMock) ReleaseVersion(datasetID string, editionID string, version int) error {
+	if mock.ReleaseVersionFunc == nil {
+		panic("StorerMock.ReleaseVersionFunc: method is nil but Storer.ReleaseVersion was just called")
+	}
+	callInfo := struct {
+		DatasetID string
+		EditionID string
+		Version   int
+	}{
+		DatasetID: datasetID,
+		EditionID: editionID,
+		Version:   version,
+	}
+	lockStorerMockReleaseVersion.Lock()
+	mock.calls.ReleaseVersion = append(mock.calls.ReleaseVersion, callInfo)
+	lockStorerMockReleaseVersion.Unlock()
+	return mock.ReleaseVersionFunc(datasetID, editionID, version)
+}
+
+// ReleaseVersionCalls gets all the calls that were made to ReleaseVersion.
+// Check the length with:
+//     len(mockedStorer.ReleaseVersionCalls())
+func (mock *StorerMock) ReleaseVersionCalls() []struct {
 	DatasetID string
 	EditionID string
-	State     string
+	Version   int
 } {
 	var calls []struct {
 		DatasetID string
 		EditionID string
-		State     string
+		Version   int
 	}
-	lockStorerMockGetVersions.RLock()
-	calls = mock.calls.GetVersions
-	lockStorerMockGetVersions.RUnlock()
+	lockStorerMockReleaseVersion.RLock()
+	calls = mock.calls.ReleaseVersion
+	lockStorerMockReleaseVersion.RUnlock()
+	return calls
+}
+
+// RenameDimension calls RenameDimensionFunc.
+func (mock *StorerMock) RenameDimension(instanceID string, oldName string, newName string) error {
+	if mock.RenameDimensionFunc == nil {
+		panic("StorerMock.RenameDimensionFunc: method is nil but Storer.RenameDimension was just called")
+	}
+	callInfo := struct {
+		InstanceID string
+		OldName    string
+		NewName    string
+	}{
+		InstanceID: instanceID,
+		OldName:    oldName,
+		NewName:    newName,
+	}
+	lockStorerMockRenameDimension.Lock()
+	mock.calls.RenameDimension = append(mock.calls.RenameDimension, callInfo)
+	lockStorerMockRenameDimension.Unlock()
+	return mock.RenameDimensionFunc(instanceID, oldName, newName)
+}
+
+// RenameDimensionCalls gets all the calls that were made to RenameDimension.
+// Check the length with:
+//     len(mockedStorer.RenameDimensionCalls())
+func (mock *StorerMock) RenameDimensionCalls() []struct {
+	InstanceID string
+	OldName    string
+	NewName    string
+} {
+	var calls []struct {
+		InstanceID string
+		OldName    string
+		NewName    string
+	}
+	lockStorerMockRenameDimension.RLock()
+	calls = mock.calls.RenameDimension
+	lockStorerMockRenameDimension.RUnlock()
+	return calls
+}
+
+// ResetInstance calls ResetInstanceFunc.
+func (mock *StorerMock) ResetInstance(ctx context.Context, ID string, currentState string, uniqueTimestamp bson.MongoTimestamp) error {
+	if mock.ResetInstanceFunc == nil {
+		panic("StorerMock.ResetInstanceFunc: method is nil but Storer.ResetInstance was just called")
+	}
+	callInfo := struct {
+		Ctx             context.Context
+		ID              string
+		CurrentState    string
+		UniqueTimestamp bson.MongoTimestamp
+	}{
+		Ctx:             ctx,
+		ID:              ID,
+		CurrentState:    currentState,
+		UniqueTimestamp: uniqueTimestamp,
+	}
+	lockStorerMockResetInstance.Lock()
+	mock.calls.ResetInstance = append(mock.calls.ResetInstance, callInfo)
+	lockStorerMockResetInstance.Unlock()
+	return mock.ResetInstanceFunc(ctx, ID, currentState, uniqueTimestamp)
+}
+
+// ResetInstanceCalls gets all the calls that were made to ResetInstance.
+// Check the length with:
+//     len(mockedStorer.ResetInstanceCalls())
+func (mock *StorerMock) ResetInstanceCalls() []struct {
+	Ctx             context.Context
+	ID              string
+	CurrentState    string
+	UniqueTimestamp bson.MongoTimestamp
+} {
+	var calls []struct {
+		Ctx             context.Context
+		ID              string
+		CurrentState    string
+		UniqueTimestamp bson.MongoTimestamp
+	}
+	lockStorerMockResetInstance.RLock()
+	calls = mock.calls.ResetInstance
+	lockStorerMockResetInstance.RUnlock()
+	return calls
+}
+
+// SetDatasetLinksFrozen calls SetDatasetLinksFrozenFunc.
+func (mock *StorerMock) SetDatasetLinksFrozen(ID string, frozen bool) error {
+	if mock.SetDatasetLinksFrozenFunc == nil {
+		panic("StorerMock.SetDatasetLinksFrozenFunc: method is nil but Storer.SetDatasetLinksFrozen was just called")
+	}
+	callInfo := struct {
+		ID     string
+		Frozen bool
+	}{
+		ID:     ID,
+		Frozen: frozen,
+	}
+	lockStorerMockSetDatasetLinksFrozen.Lock()
+	mock.calls.SetDatasetLinksFrozen = append(mock.calls.SetDatasetLinksFrozen, callInfo)
+	lockStorerMockSetDatasetLinksFrozen.Unlock()
+	return mock.SetDatasetLinksFrozenFunc(ID, frozen)
+}
+
+// SetDatasetLinksFrozenCalls gets all the calls that were made to SetDatasetLinksFrozen.
+// Check the length with:
+//     len(mockedStorer.SetDatasetLinksFrozenCalls())
+func (mock *StorerMock) SetDatasetLinksFrozenCalls() []struct {
+	ID     string
+	Frozen bool
+} {
+	var calls []struct {
+		ID     string
+		Frozen bool
+	}
+	lockStorerMockSetDatasetLinksFrozen.RLock()
+	calls = mock.calls.SetDatasetLinksFrozen
+	lockStorerMockSetDatasetLinksFrozen.RUnlock()
 	return calls
 }
 
@@ -1350,8 +2543,125 @@ func (mock *StorerMock) StreamCSVRowsCalls() []struct {
 	return calls
 }
 
+// StreamDimensionOptions calls StreamDimensionOptionsFunc.
+func (mock *StorerMock) StreamDimensionOptions(instanceID string, dimension string, fn func(*models.DimensionOption) error) error {
+	if mock.StreamDimensionOptionsFunc == nil {
+		panic("StorerMock.StreamDimensionOptionsFunc: method is nil but Storer.StreamDimensionOptions was just called")
+	}
+	callInfo := struct {
+		InstanceID string
+		Dimension  string
+		Fn         func(*models.DimensionOption) error
+	}{
+		InstanceID: instanceID,
+		Dimension:  dimension,
+		Fn:         fn,
+	}
+	lockStorerMockStreamDimensionOptions.Lock()
+	mock.calls.StreamDimensionOptions = append(mock.calls.StreamDimensionOptions, callInfo)
+	lockStorerMockStreamDimensionOptions.Unlock()
+	return mock.StreamDimensionOptionsFunc(instanceID, dimension, fn)
+}
+
+// StreamDimensionOptionsCalls gets all the calls that were made to StreamDimensionOptions.
+// Check the length with:
+//     len(mockedStorer.StreamDimensionOptionsCalls())
+func (mock *StorerMock) StreamDimensionOptionsCalls() []struct {
+	InstanceID string
+	Dimension  string
+	Fn         func(*models.DimensionOption) error
+} {
+	var calls []struct {
+		InstanceID string
+		Dimension  string
+		Fn         func(*models.DimensionOption) error
+	}
+	lockStorerMockStreamDimensionOptions.RLock()
+	calls = mock.calls.StreamDimensionOptions
+	lockStorerMockStreamDimensionOptions.RUnlock()
+	return calls
+}
+
+// StreamInstances calls StreamInstancesFunc.
+func (mock *StorerMock) StreamInstances(states []string, datasets []string, isBasedOn []string, fn func(*models.Instance) error) error {
+	if mock.StreamInstancesFunc == nil {
+		panic("StorerMock.StreamInstancesFunc: method is nil but Storer.StreamInstances was just called")
+	}
+	callInfo := struct {
+		States    []string
+		Datasets  []string
+		IsBasedOn []string
+		Fn        func(*models.Instance) error
+	}{
+		States:    states,
+		Datasets:  datasets,
+		IsBasedOn: isBasedOn,
+		Fn:        fn,
+	}
+	lockStorerMockStreamInstances.Lock()
+	mock.calls.StreamInstances = append(mock.calls.StreamInstances, callInfo)
+	lockStorerMockStreamInstances.Unlock()
+	return mock.StreamInstancesFunc(states, datasets, isBasedOn, fn)
+}
+
+// StreamInstancesCalls gets all the calls that were made to StreamInstances.
+// Check the length with:
+//     len(mockedStorer.StreamInstancesCalls())
+func (mock *StorerMock) StreamInstancesCalls() []struct {
+	States    []string
+	Datasets  []string
+	IsBasedOn []string
+	Fn        func(*models.Instance) error
+} {
+	var calls []struct {
+		States    []string
+		Datasets  []string
+		IsBasedOn []string
+		Fn        func(*models.Instance) error
+	}
+	lockStorerMockStreamInstances.RLock()
+	calls = mock.calls.StreamInstances
+	lockStorerMockStreamInstances.RUnlock()
+	return calls
+}
+
+// SummariseEditionVersions calls SummariseEditionVersionsFunc.
+func (mock *StorerMock) SummariseEditionVersions(datasetID string, state string) ([]models.EditionVersions, error) {
+	if mock.SummariseEditionVersionsFunc == nil {
+		panic("StorerMock.SummariseEditionVersionsFunc: method is nil but Storer.SummariseEditionVersions was just called")
+	}
+	callInfo := struct {
+		DatasetID string
+		State     string
+	}{
+		DatasetID: datasetID,
+		State:     state,
+	}
+	lockStorerMockSummariseEditionVersions.Lock()
+	mock.calls.SummariseEditionVersions = append(mock.calls.SummariseEditionVersions, callInfo)
+	lockStorerMockSummariseEditionVersions.Unlock()
+	return mock.SummariseEditionVersionsFunc(datasetID, state)
+}
+
+// SummariseEditionVersionsCalls gets all the calls that were made to SummariseEditionVersions.
+// Check the length with:
+//     len(mockedStorer.SummariseEditionVersionsCalls())
+func (mock *StorerMock) SummariseEditionVersionsCalls() []struct {
+	DatasetID string
+	State     string
+} {
+	var calls []struct {
+		DatasetID string
+		State     string
+	}
+	lockStorerMockSummariseEditionVersions.RLock()
+	calls = mock.calls.SummariseEditionVersions
+	lockStorerMockSummariseEditionVersions.RUnlock()
+	return calls
+}
+
 // UpdateBuildHierarchyTaskState calls UpdateBuildHierarchyTaskStateFunc.
-func (mock *StorerMock) UpdateBuildHierarchyTaskState(id string, dimension string, state string) error {
+func (mock *StorerMock) UpdateBuildHierarchyTaskState(id string, dimension string, state string, reason string) error {
 	if mock.UpdateBuildHierarchyTaskStateFunc == nil {
 		panic("StorerMock.UpdateBuildHierarchyTaskStateFunc: method is nil but Storer.UpdateBuildHierarchyTaskState was just called")
 	}
@@ -1359,15 +2669,17 @@ func (mock *StorerMock) UpdateBuildHierarchyTaskState(id string, dimension strin
 		ID        string
 		Dimension string
 		State     string
+		Reason    string
 	}{
 		ID:        id,
 		Dimension: dimension,
 		State:     state,
+		Reason:    reason,
 	}
 	lockStorerMockUpdateBuildHierarchyTaskState.Lock()
 	mock.calls.UpdateBuildHierarchyTaskState = append(mock.calls.UpdateBuildHierarchyTaskState, callInfo)
 	lockStorerMockUpdateBuildHierarchyTaskState.Unlock()
-	return mock.UpdateBuildHierarchyTaskStateFunc(id, dimension, state)
+	return mock.UpdateBuildHierarchyTaskStateFunc(id, dimension, state, reason)
 }
 
 // UpdateBuildHierarchyTaskStateCalls gets all the calls that were made to UpdateBuildHierarchyTaskState.
@@ -1377,11 +2689,13 @@ func (mock *StorerMock) UpdateBuildHierarchyTaskStateCalls() []struct {
 	ID        string
 	Dimension string
 	State     string
+	Reason    string
 } {
 	var calls []struct {
 		ID        string
 		Dimension string
 		State     string
+		Reason    string
 	}
 	lockStorerMockUpdateBuildHierarchyTaskState.RLock()
 	calls = mock.calls.UpdateBuildHierarchyTaskState
@@ -1390,7 +2704,7 @@ func (mock *StorerMock) UpdateBuildHierarchyTaskStateCalls() []struct {
 }
 
 // UpdateBuildSearchTaskState calls UpdateBuildSearchTaskStateFunc.
-func (mock *StorerMock) UpdateBuildSearchTaskState(id string, dimension string, state string) error {
+func (mock *StorerMock) UpdateBuildSearchTaskState(id string, dimension string, state string, reason string) error {
 	if mock.UpdateBuildSearchTaskStateFunc == nil {
 		panic("StorerMock.UpdateBuildSearchTaskStateFunc: method is nil but Storer.UpdateBuildSearchTaskState was just called")
 	}
@@ -1398,15 +2712,17 @@ func (mock *StorerMock) UpdateBuildSearchTaskState(id string, dimension string,
 		ID        string
 		Dimension string
 		State     string
+		Reason    string
 	}{
 		ID:        id,
 		Dimension: dimension,
 		State:     state,
+		Reason:    reason,
 	}
 	lockStorerMockUpdateBuildSearchTaskState.Lock()
 	mock.calls.UpdateBuildSearchTaskState = append(mock.calls.UpdateBuildSearchTaskState, callInfo)
 	lockStorerMockUpdateBuildSearchTaskState.Unlock()
-	return mock.UpdateBuildSearchTaskStateFunc(id, dimension, state)
+	return mock.UpdateBuildSearchTaskStateFunc(id, dimension, state, reason)
 }
 
 // UpdateBuildSearchTaskStateCalls gets all the calls that were made to UpdateBuildSearchTaskState.
@@ -1416,11 +2732,13 @@ func (mock *StorerMock) UpdateBuildSearchTaskStateCalls() []struct {
 	ID        string
 	Dimension string
 	State     string
+	Reason    string
 } {
 	var calls []struct {
 		ID        string
 		Dimension string
 		State     string
+		Reason    string
 	}
 	lockStorerMockUpdateBuildSearchTaskState.RLock()
 	calls = mock.calls.UpdateBuildSearchTaskState
@@ -1537,34 +2855,81 @@ func (mock *StorerMock) UpdateDimensionNodeIDCalls() []struct {
 	return calls
 }
 
+// UpdateDimensionOption calls UpdateDimensionOptionFunc.
+func (mock *StorerMock) UpdateDimensionOption(instanceID string, dimension string, option string, update *models.DimensionOptionUpdate) error {
+	if mock.UpdateDimensionOptionFunc == nil {
+		panic("StorerMock.UpdateDimensionOptionFunc: method is nil but Storer.UpdateDimensionOption was just called")
+	}
+	callInfo := struct {
+		InstanceID string
+		Dimension  string
+		Option     string
+		Update     *models.DimensionOptionUpdate
+	}{
+		InstanceID: instanceID,
+		Dimension:  dimension,
+		Option:     option,
+		Update:     update,
+	}
+	lockStorerMockUpdateDimensionOption.Lock()
+	mock.calls.UpdateDimensionOption = append(mock.calls.UpdateDimensionOption, callInfo)
+	lockStorerMockUpdateDimensionOption.Unlock()
+	return mock.UpdateDimensionOptionFunc(instanceID, dimension, option, update)
+}
+
+// UpdateDimensionOptionCalls gets all the calls that were made to UpdateDimensionOption.
+// Check the length with:
+//     len(mockedStorer.UpdateDimensionOptionCalls())
+func (mock *StorerMock) UpdateDimensionOptionCalls() []struct {
+	InstanceID string
+	Dimension  string
+	Option     string
+	Update     *models.DimensionOptionUpdate
+} {
+	var calls []struct {
+		InstanceID string
+		Dimension  string
+		Option     string
+		Update     *models.DimensionOptionUpdate
+	}
+	lockStorerMockUpdateDimensionOption.RLock()
+	calls = mock.calls.UpdateDimensionOption
+	lockStorerMockUpdateDimensionOption.RUnlock()
+	return calls
+}
+
 // UpdateImportObservationsTaskState calls UpdateImportObservationsTaskStateFunc.
-func (mock *StorerMock) UpdateImportObservationsTaskState(id string, state string) error {
+func (mock *StorerMock) UpdateImportObservationsTaskState(id string, state string, reason string) error {
 	if mock.UpdateImportObservationsTaskStateFunc == nil {
 		panic("StorerMock.UpdateImportObservationsTaskStateFunc: method is nil but Storer.UpdateImportObservationsTaskState was just called")
 	}
 	callInfo := struct {
-		ID    string
-		State string
+		ID     string
+		State  string
+		Reason string
 	}{
-		ID:    id,
-		State: state,
+		ID:     id,
+		State:  state,
+		Reason: reason,
 	}
 	lockStorerMockUpdateImportObservationsTaskState.Lock()
 	mock.calls.UpdateImportObservationsTaskState = append(mock.calls.UpdateImportObservationsTaskState, callInfo)
 	lockStorerMockUpdateImportObservationsTaskState.Unlock()
-	return mock.UpdateImportObservationsTaskStateFunc(id, state)
+	return mock.UpdateImportObservationsTaskStateFunc(id, state, reason)
 }
 
 // UpdateImportObservationsTaskStateCalls gets all the calls that were made to UpdateImportObservationsTaskState.
 // Check the length with:
 //     len(mockedStorer.UpdateImportObservationsTaskStateCalls())
 func (mock *StorerMock) UpdateImportObservationsTaskStateCalls() []struct {
-	ID    string
-	State string
+	ID     string
+	State  string
+	Reason string
 } {
 	var calls []struct {
-		ID    string
-		State string
+		ID     string
+		State  string
+		Reason string
 	}
 	lockStorerMockUpdateImportObservationsTaskState.RLock()
 	calls = mock.calls.UpdateImportObservationsTaskState
@@ -1612,7 +2977,7 @@ func (mock *StorerMock) UpdateInstanceCalls() []struct {
 }
 
 // UpdateObservationInserted calls UpdateObservationInsertedFunc.
-func (mock *StorerMock) UpdateObservationInserted(ID string, observationInserted int64) error {
+func (mock *StorerMock) UpdateObservationInserted(ID string, observationInserted int64) (*models.ImportObservationsTask, error) {
 	if mock.UpdateObservationInsertedFunc == nil {
 		panic("StorerMock.UpdateObservationInsertedFunc: method is nil but Storer.UpdateObservationInserted was just called")
 	}
@@ -1824,3 +3189,34 @@ func (mock *StorerMock) UpsertVersionCalls() []struct {
 	lockStorerMockUpsertVersion.RUnlock()
 	return calls
 }
+
+// WithTransaction calls WithTransactionFunc.
+func (mock *StorerMock) WithTransaction(fn func(store.Storer) error) error {
+	if mock.WithTransactionFunc == nil {
+		panic("StorerMock.WithTransactionFunc: method is nil but Storer.WithTransaction was just called")
+	}
+	callInfo := struct {
+		Fn func(store.Storer) error
+	}{
+		Fn: fn,
+	}
+	lockStorerMockWithTransaction.Lock()
+	mock.calls.WithTransaction = append(mock.calls.WithTransaction, callInfo)
+	lockStorerMockWithTransaction.Unlock()
+	return mock.WithTransactionFunc(fn)
+}
+
+// WithTransactionCalls gets all the calls that were made to WithTransaction.
+// Check the length with:
+//     len(mockedStorer.WithTransactionCalls())
+func (mock *StorerMock) WithTransactionCalls() []struct {
+	Fn func(store.Storer) error
+} {
+	var calls []struct {
+		Fn func(store.Storer) error
+	}
+	lockStorerMockWithTransaction.RLock()
+	calls = mock.calls.WithTransaction
+	lockStorerMockWithTransaction.RUnlock()
+	return calls
+}
@@ -4,13 +4,15 @@ import "fmt"
 
 // Builder encapsulates the building of urls in a central place, with knowledge of the url structures and base host names.
 type Builder struct {
-	websiteURL string
+	websiteURL    string
+	datasetAPIURL string
 }
 
 // NewBuilder returns a new instance of url.Builder
-func NewBuilder(websiteURL string) *Builder {
+func NewBuilder(websiteURL, datasetAPIURL string) *Builder {
 	return &Builder{
-		websiteURL: websiteURL,
+		websiteURL:    websiteURL,
+		datasetAPIURL: datasetAPIURL,
 	}
 }
 
@@ -19,3 +21,19 @@ func (builder Builder) BuildWebsiteDatasetVersionURL(datasetID, edition, version
 	return fmt.Sprintf("%s/datasets/%s/editions/%s/versions/%s",
 		builder.websiteURL, datasetID, edition, version)
 }
+
+// BuildDatasetVersionURL returns the dataset API URL for a specific dataset version
+func (builder Builder) BuildDatasetVersionURL(datasetID, edition, version string) string {
+	return fmt.Sprintf("%s/datasets/%s/editions/%s/versions/%s",
+		builder.datasetAPIURL, datasetID, edition, version)
+}
+
+// BuildDatasetURL returns the dataset API URL for a specific dataset
+func (builder Builder) BuildDatasetURL(datasetID string) string {
+	return fmt.Sprintf("%s/datasets/%s", builder.datasetAPIURL, datasetID)
+}
+
+// BuildDatasetEditionsURL returns the dataset API URL for the editions of a specific dataset
+func (builder Builder) BuildDatasetEditionsURL(datasetID string) string {
+	return fmt.Sprintf("%s/datasets/%s/editions", builder.datasetAPIURL, datasetID)
+}
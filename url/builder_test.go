@@ -8,17 +8,18 @@ import (
 )
 
 const (
-	websiteURL = "localhost:20000"
-	datasetID  = "123"
-	edition    = "2017"
-	version    = "1"
+	websiteURL    = "localhost:20000"
+	datasetAPIURL = "localhost:22000"
+	datasetID     = "123"
+	edition       = "2017"
+	version       = "1"
 )
 
 func TestBuilder_BuildWebsiteDatasetVersionURL(t *testing.T) {
 
 	Convey("Given a URL builder", t, func() {
 
-		urlBuilder := url.NewBuilder(websiteURL)
+		urlBuilder := url.NewBuilder(websiteURL, datasetAPIURL)
 
 		Convey("When BuildWebsiteDatasetVersionURL is called", func() {
 
@@ -33,3 +34,61 @@ func TestBuilder_BuildWebsiteDatasetVersionURL(t *testing.T) {
 		})
 	})
 }
+
+func TestBuilder_BuildDatasetVersionURL(t *testing.T) {
+
+	Convey("Given a URL builder", t, func() {
+
+		urlBuilder := url.NewBuilder(websiteURL, datasetAPIURL)
+
+		Convey("When BuildDatasetVersionURL is called", func() {
+
+			url := urlBuilder.BuildDatasetVersionURL(datasetID, edition, version)
+
+			expectedURL := fmt.Sprintf("%s/datasets/%s/editions/%s/versions/%s",
+				datasetAPIURL, datasetID, edition, version)
+
+			Convey("Then the expected URL is returned", func() {
+				So(url, ShouldEqual, expectedURL)
+			})
+		})
+	})
+}
+
+func TestBuilder_BuildDatasetURL(t *testing.T) {
+
+	Convey("Given a URL builder", t, func() {
+
+		urlBuilder := url.NewBuilder(websiteURL, datasetAPIURL)
+
+		Convey("When BuildDatasetURL is called", func() {
+
+			url := urlBuilder.BuildDatasetURL(datasetID)
+
+			expectedURL := fmt.Sprintf("%s/datasets/%s", datasetAPIURL, datasetID)
+
+			Convey("Then the expected URL is returned", func() {
+				So(url, ShouldEqual, expectedURL)
+			})
+		})
+	})
+}
+
+func TestBuilder_BuildDatasetEditionsURL(t *testing.T) {
+
+	Convey("Given a URL builder", t, func() {
+
+		urlBuilder := url.NewBuilder(websiteURL, datasetAPIURL)
+
+		Convey("When BuildDatasetEditionsURL is called", func() {
+
+			url := urlBuilder.BuildDatasetEditionsURL(datasetID)
+
+			expectedURL := fmt.Sprintf("%s/datasets/%s/editions", datasetAPIURL, datasetID)
+
+			Convey("Then the expected URL is returned", func() {
+				So(url, ShouldEqual, expectedURL)
+			})
+		})
+	})
+}
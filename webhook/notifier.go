@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ONSdigital/dp-dataset-api/models"
+	rchttp "github.com/ONSdigital/dp-rchttp"
+	"github.com/ONSdigital/go-ns/common"
+	"github.com/ONSdigital/go-ns/log"
+	"github.com/pkg/errors"
+)
+
+// Notifier posts an event to each configured webhook when a version of a dataset is published, as an alternative
+// to consuming kafka for the external systems which need to know
+type Notifier struct {
+	URLs   []string
+	Client rchttp.Clienter
+}
+
+// NotifyPublished posts the published version to every webhook in the background, so publishing is not held up by
+// a slow webhook. The client retries a webhook which cannot be reached or responds with a server error, a webhook
+// which still fails is logged and the failure is never returned to the publisher.
+func (n *Notifier) NotifyPublished(ctx context.Context, event models.VersionPublishedEvent) {
+	if len(n.URLs) == 0 {
+		return
+	}
+
+	logData := log.Data{"dataset_id": event.DatasetID, "edition": event.Edition, "version": event.Version}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "failed to marshal version published event"), logData)
+		return
+	}
+
+	// the request the version was published in will have ended before the webhooks respond, so only its id is kept
+	sendCtx := common.WithRequestId(context.Background(), common.GetRequestId(ctx))
+	for _, url := range n.URLs {
+		go n.send(sendCtx, url, body, logData)
+	}
+}
+
+// send posts the event to a single webhook
+func (n *Notifier) send(ctx context.Context, url string, body []byte, logData log.Data) {
+	data := log.Data{"url": url}
+	for k, v := range logData {
+		data[k] = v
+	}
+
+	resp, err := n.Client.Post(ctx, url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.ErrorCtx(ctx, errors.WithMessage(err, "failed to notify webhook of published version"), data)
+		return
+	}
+	// the client gives no response when a server error is not retried
+	if resp == nil {
+		log.ErrorCtx(ctx, errors.New("webhook gave no response to the published version"), data)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data["response_status"] = resp.StatusCode
+		log.ErrorCtx(ctx, fmt.Errorf("webhook responded with unexpected status %d", resp.StatusCode), data)
+		return
+	}
+	log.InfoCtx(ctx, "notified webhook of published version", data)
+}
@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-dataset-api/models"
+	rchttp "github.com/ONSdigital/dp-rchttp"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newTestClient() rchttp.Clienter {
+	return &rchttp.Client{
+		MaxRetries:         2,
+		ExponentialBackoff: true,
+		RetryTime:          5 * time.Millisecond,
+		HTTPClient:         &http.Client{Timeout: time.Second},
+	}
+}
+
+func TestNotifier_NotifyPublished(t *testing.T) {
+	event := models.VersionPublishedEvent{DatasetID: "123", Edition: "2017", Version: 1, InstanceID: "789"}
+
+	Convey("Given a notifier with a webhook", t, func() {
+		received := make(chan []byte, 10)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			received <- body
+		}))
+		defer server.Close()
+
+		notifier := &Notifier{URLs: []string{server.URL}, Client: newTestClient()}
+
+		Convey("When a version is published then the webhook is sent the event once", func() {
+			notifier.NotifyPublished(context.Background(), event)
+
+			var body []byte
+			select {
+			case body = <-received:
+			case <-time.After(time.Second):
+			}
+
+			var sent models.VersionPublishedEvent
+			So(json.Unmarshal(body, &sent), ShouldBeNil)
+			So(sent, ShouldResemble, event)
+
+			select {
+			case <-received:
+				t.Error("webhook was notified more than once")
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	})
+
+	Convey("Given a notifier with a webhook which responds with a server error", t, func() {
+		attempts := make(chan struct{}, 10)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts <- struct{}{}
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		notifier := &Notifier{URLs: []string{server.URL}, Client: newTestClient()}
+
+		Convey("When a version is published then the webhook is retried without the failure being returned", func() {
+			notifier.NotifyPublished(context.Background(), event)
+
+			count := 0
+			timeout := time.After(time.Second)
+			for count < 3 {
+				select {
+				case <-attempts:
+					count++
+				case <-timeout:
+					t.Fatalf("expected 3 attempts, got %d", count)
+				}
+			}
+			So(count, ShouldEqual, 3)
+		})
+	})
+}
+
+func TestNotifier_SendWithoutRetries(t *testing.T) {
+	Convey("Given a notifier whose client does not retry and a webhook which responds with a server error", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		client := newTestClient()
+		client.SetMaxRetries(0)
+		notifier := &Notifier{URLs: []string{server.URL}, Client: client}
+
+		Convey("When the event is sent then the missing response is logged without panicking", func() {
+			So(func() { notifier.send(context.Background(), server.URL, []byte("{}"), nil) }, ShouldNotPanic)
+		})
+	})
+}